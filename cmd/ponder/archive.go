@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runArchive(args []string) error {
+	fs := flag.NewFlagSet("archive", flag.ContinueOnError)
+	before := fs.String("before", "30d", "Archive tasks completed longer ago than this (e.g. 30d, 12h)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder archive [--before age]")
+	}
+
+	age, err := parseAge(*before)
+	if err != nil {
+		return fmt.Errorf("invalid --before: %w", err)
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := database.ArchiveCompleted(ctx, time.Now().Add(-age))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Archived %d tasks and %d features\n", result.Tasks, result.Features)
+	return nil
+}
+
+// parseAge parses a duration that may also be given in whole days ("30d").
+func parseAge(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("age must be >= 0")
+	}
+	return d, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"0d":  0,
+		"12h": 12 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := parseAge(in)
+		if err != nil || got != want {
+			t.Errorf("parseAge(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"d", "1.5d", "-1d", "soon"} {
+		if _, err := parseAge(in); err == nil {
+			t.Errorf("parseAge(%q): expected error", in)
+		}
+	}
+}
+
+func TestArchiveCommand(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runComplete([]string{"--feature", "feature1", "--summary", "done", "task1"})
+	if err == nil {
+		err = runArchive([]string{"--before", "0s"})
+	}
+	if err == nil {
+		err = runListTasks([]string{"--include-archived"})
+	}
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("archive flow failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+	if !strings.Contains(output, "Archived 1 tasks and 1 features") {
+		t.Errorf("expected archive summary, got %q", output)
+	}
+	if !strings.Contains(output, "archived") || !strings.Contains(output, "task1") {
+		t.Errorf("expected task1 listed as archived, got %q", output)
+	}
+
+	data, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if strings.Contains(string(data), "task1") {
+		t.Error("expected archived task to be left out of the default snapshot")
+	}
+}
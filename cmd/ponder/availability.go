@@ -0,0 +1,31 @@
+package main
+
+import "github.com/nick-dorsch/ponder/internal/db"
+
+// availabilityPolicy is the availability policy configured in config.json,
+// applied to the commands that list, count or claim available tasks.
+var availabilityPolicy db.AvailabilityPolicy
+
+type availabilityConfig struct {
+	// Dependencies is "strict" or "soft".
+	Dependencies string `json:"dependencies"`
+	// Penalty is taken off the priority for each blocked or cancelled
+	// dependency under the soft policy.
+	Penalty *int `json:"penalty,omitempty"`
+}
+
+// parse converts the configured availability policy. Under the soft policy
+// the penalty defaults to 1.
+func (ac *availabilityConfig) parse() (db.AvailabilityPolicy, error) {
+	policy := db.AvailabilityPolicy{Dependencies: ac.Dependencies}
+	if policy.Soft() {
+		policy.Penalty = 1
+	}
+	if ac.Penalty != nil {
+		policy.Penalty = *ac.Penalty
+	}
+	if err := policy.Validate(); err != nil {
+		return db.AvailabilityPolicy{}, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// cliSession is the staging session used to create a task and its
+// dependencies in one transaction.
+const cliSession = "cli"
+
+// openBacklogDB opens the database for a mutating CLI command, exporting the
+// snapshot after every change like the MCP server does.
+func openBacklogDB() (*db.DB, context.Context, error) {
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := actor.With(context.Background(), "cli")
+	if err := database.Init(ctx); err != nil {
+		database.Close()
+		return nil, nil, err
+	}
+	database.SetAutoBackup(autoBackup)
+
+	exportSnapshotOnChange(database)
+	return database, ctx, nil
+}
+
+func runAddFeature(args []string) error {
+	fs := flag.NewFlagSet("add-feature", flag.ContinueOnError)
+	description := fs.String("description", "", "Short feature description")
+	specification := fs.String("spec", "", "Detailed feature specification")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder add-feature [--description text] [--spec text] <name>")
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	f := &models.Feature{
+		Name:          fs.Arg(0),
+		Description:   *description,
+		Specification: *specification,
+	}
+	if err := database.CreateFeature(ctx, f); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Created feature %s\n", f.Name)
+	return nil
+}
+
+func runAddTask(args []string) error {
+	fs := flag.NewFlagSet("add-task", flag.ContinueOnError)
+	featureName := fs.String("feature", "misc", "Feature the task belongs to")
+	description := fs.String("description", "", "Short task description")
+	specification := fs.String("spec", "", "Detailed task specification")
+	priority := fs.Int("priority", 5, "Priority from 0 (lowest) to 10 (highest)")
+	testsRequired := fs.Bool("tests", true, "Whether the task requires tests")
+	dependsOn := fs.String("depends-on", "", "Comma-separated prerequisite tasks (task or feature/task)")
+	notBefore := fs.String("not-before", "", "Don't start the task before this time (RFC 3339 or YYYY-MM-DD)")
+	due := fs.String("due", "", "When the task is due (RFC 3339 or YYYY-MM-DD)")
+	parent := fs.String("parent", "", "Make the task a subtask of this task in the same feature")
+	subtaskOrder := fs.String("subtask-order", "", "For the task's own subtasks: children_first (default) or parent_first")
+	estimate := fs.String("estimate", "", "How long the task should take, in minutes or as a duration like 1h30m")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder add-task [--feature name] [--priority n] [--depends-on a,b] [--parent task] [--subtask-order order] [--not-before time] [--due time] [--estimate 90m] [--description text] [--spec text] <name>")
+	}
+	order, err := models.ParseSubtaskOrder(*subtaskOrder)
+	if err != nil {
+		return err
+	}
+	if *priority < 0 || *priority > 10 {
+		return fmt.Errorf("priority must be between 0 and 10, got %d", *priority)
+	}
+	notBeforeAt, err := parseTaskTimeFlag("not-before", *notBefore)
+	if err != nil {
+		return err
+	}
+	dueAt, err := parseTaskTimeFlag("due", *due)
+	if err != nil {
+		return err
+	}
+	estimateMinutes, err := parseEstimate(*estimate)
+	if err != nil {
+		return fmt.Errorf("--estimate: %w", err)
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	task := &models.Task{
+		FeatureName:     *featureName,
+		Name:            fs.Arg(0),
+		Description:     *description,
+		Specification:   *specification,
+		Priority:        *priority,
+		TestsRequired:   *testsRequired,
+		Status:          models.TaskStatusPending,
+		NotBefore:       notBeforeAt,
+		DueAt:           dueAt,
+		ParentTaskName:  *parent,
+		SubtaskOrder:    order,
+		EstimateMinutes: estimateMinutes,
+	}
+
+	// Stage the task together with its dependencies so that a bad dependency
+	// leaves nothing behind.
+	database.Staging.Discard(cliSession)
+	database.Staging.AddTask(cliSession, task)
+	for _, ref := range splitList(*dependsOn) {
+		depFeature, depTask := *featureName, ref
+		if i := strings.Index(ref, "/"); i >= 0 {
+			depFeature, depTask = ref[:i], ref[i+1:]
+		}
+		database.Staging.AddDependency(cliSession, &models.Dependency{
+			FeatureName:          task.FeatureName,
+			TaskName:             task.Name,
+			DependsOnFeatureName: depFeature,
+			DependsOnTaskName:    depTask,
+		})
+	}
+
+	if err := database.CommitBatch(ctx, cliSession); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Created task %s/%s\n", task.FeatureName, task.Name)
+	return nil
+}
+
+// parseTaskTimeFlag parses an optional --not-before or --due value.
+func parseTaskTimeFlag(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := models.ParseTaskTime(value)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", name, err)
+	}
+	return &t, nil
+}
+
+// parseEstimate parses an estimate given in minutes or as a duration such as
+// 1h30m, rounding up to whole minutes. An empty value gives nil.
+func parseEstimate(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var minutes int
+	if n, err := strconv.Atoi(value); err == nil {
+		minutes = n
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid estimate %q: use minutes or a duration like 1h30m", value)
+		}
+		minutes = int((d + time.Minute - 1) / time.Minute)
+	}
+	if minutes <= 0 {
+		return nil, db.ErrInvalidEstimate
+	}
+	return &minutes, nil
+}
+
+// runEstimate sets or, with 0, clears the estimate of an existing task.
+func runEstimate(args []string) error {
+	fs := flag.NewFlagSet("estimate", flag.ContinueOnError)
+	featureFilter := fs.String("feature", "", "Feature the task belongs to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: ponder estimate [--feature name] <task> <minutes|duration|0>")
+	}
+	var minutes *int
+	if fs.Arg(1) != "0" {
+		var err error
+		if minutes, err = parseEstimate(fs.Arg(1)); err != nil {
+			return err
+		}
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	task, err := findTaskByName(ctx, database, *featureFilter, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	task.EstimateMinutes = minutes
+	if err := database.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+
+	if minutes == nil {
+		fmt.Printf("✓ Cleared the estimate of %s/%s\n", task.FeatureName, task.Name)
+	} else {
+		fmt.Printf("✓ Estimated %s/%s at %d minutes\n", task.FeatureName, task.Name, *minutes)
+	}
+	return nil
+}
+
+func runComplete(args []string) error {
+	fs := flag.NewFlagSet("complete", flag.ContinueOnError)
+	featureFilter := fs.String("feature", "", "Feature the task belongs to")
+	summary := fs.String("summary", "Completed manually via CLI", "Completion summary (in_review tasks keep their review summary unless set)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder complete [--feature name] [--summary text] <task>")
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	task, err := findTaskByName(ctx, database, *featureFilter, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Tasks can only be completed from in_progress or in_review, so walk
+	// pending and blocked tasks through in_progress.
+	switch task.Status {
+	case models.TaskStatusCompleted, models.TaskStatusCancelled:
+		return fmt.Errorf("task %s/%s is already %s", task.FeatureName, task.Name, task.Status)
+	case models.TaskStatusInReview:
+		// Completing a reviewed task approves it.
+		if !flagProvided(fs, "summary") && task.CompletionSummary != nil {
+			summary = task.CompletionSummary
+		}
+	case models.TaskStatusPending, models.TaskStatusBlocked:
+		if err := database.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := database.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted, summary); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Completed %s/%s\n", task.FeatureName, task.Name)
+	return nil
+}
+
+func runBlock(args []string) error {
+	fs := flag.NewFlagSet("block", flag.ContinueOnError)
+	featureFilter := fs.String("feature", "", "Feature the task belongs to")
+	reason := fs.String("reason", "", "Why the task is blocked")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *reason == "" {
+		return fmt.Errorf("usage: ponder block [--feature name] --reason text <task>")
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	task, err := findTaskByName(ctx, database, *featureFilter, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := database.BlockTask(ctx, task.ID, *reason); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Blocked %s/%s\n", task.FeatureName, task.Name)
+	return nil
+}
+
+func runRemove(args []string) error {
+	fs := flag.NewFlagSet("rm", flag.ContinueOnError)
+	featureFilter := fs.String("feature", "", "Feature the task belongs to, or the feature to remove when no task is given")
+	force := fs.Bool("force", false, "Remove a feature even if it still has tasks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 0 && *featureFilter == "") {
+		return fmt.Errorf("usage: ponder rm [--feature name] <task>  |  ponder rm --feature name [--force]")
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if fs.NArg() == 1 {
+		task, err := findTaskByName(ctx, database, *featureFilter, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := database.DeleteTask(ctx, task.ID); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed task %s/%s\n", task.FeatureName, task.Name)
+		return nil
+	}
+
+	f, err := database.GetFeatureByName(ctx, *featureFilter)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("feature not found: %s", *featureFilter)
+	}
+
+	tasks, err := database.ListTasks(ctx, nil, &f.Name)
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 && !*force {
+		return fmt.Errorf("feature %s still has %d task(s), use --force to remove them too", f.Name, len(tasks))
+	}
+
+	if err := database.DeleteFeature(ctx, f.ID); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Removed feature %s\n", f.Name)
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func openTestDB(t *testing.T, path string) *db.DB {
+	t.Helper()
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	return database
+}
+
+func TestBacklogCommands(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runAddFeature([]string{"--description", "Auth work", "auth"}); err != nil {
+		t.Fatalf("add-feature failed: %v", err)
+	}
+	if err := runAddTask([]string{"--feature", "auth", "--priority", "8", "login"}); err != nil {
+		t.Fatalf("add-task failed: %v", err)
+	}
+	if err := runAddTask([]string{"--feature", "auth", "--depends-on", "login, feature1/task1", "logout"}); err != nil {
+		t.Fatalf("add-task with dependencies failed: %v", err)
+	}
+
+	// A bad dependency must not leave a half-created task behind.
+	if err := runAddTask([]string{"--feature", "auth", "--depends-on", "nope", "orphan"}); err == nil {
+		t.Error("expected error for unknown dependency")
+	}
+	if err := runAddTask([]string{"--priority", "11", "too-high"}); err == nil {
+		t.Error("expected error for out-of-range priority")
+	}
+
+	ctx := context.Background()
+	database := openTestDB(t, dbFilePath)
+	auth, _ := database.GetFeatureByName(ctx, "auth")
+	if auth == nil || auth.Description != "Auth work" {
+		t.Fatalf("expected auth feature, got %+v", auth)
+	}
+	login, _ := database.GetTaskByName(ctx, "login", auth.ID)
+	logout, _ := database.GetTaskByName(ctx, "logout", auth.ID)
+	orphan, _ := database.GetTaskByName(ctx, "orphan", auth.ID)
+	if login == nil || login.Priority != 8 {
+		t.Fatalf("expected login task with priority 8, got %+v", login)
+	}
+	if orphan != nil {
+		t.Error("expected orphan task to be rolled back")
+	}
+	deps, err := database.GetDependencies(ctx, logout.ID)
+	if err != nil || len(deps) != 2 {
+		t.Fatalf("expected logout to have 2 dependencies, got %d (%v)", len(deps), err)
+	}
+	database.Close()
+
+	if err := runComplete([]string{"--feature", "auth", "--summary", "shipped", "login"}); err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if err := runComplete([]string{"--feature", "auth", "login"}); err == nil {
+		t.Error("expected error completing an already completed task")
+	}
+	if err := runBlock([]string{"--feature", "auth", "logout"}); err == nil {
+		t.Error("expected error when block has no reason")
+	}
+	if err := runBlock([]string{"--feature", "auth", "--reason", "waiting on design", "logout"}); err != nil {
+		t.Fatalf("block failed: %v", err)
+	}
+
+	database = openTestDB(t, dbFilePath)
+	login, _ = database.GetTaskByName(ctx, "login", auth.ID)
+	if login.Status != models.TaskStatusCompleted || login.CompletionSummary == nil || *login.CompletionSummary != "shipped" {
+		t.Errorf("expected login completed with summary, got %s %v", login.Status, login.CompletionSummary)
+	}
+	logout, _ = database.GetTaskByName(ctx, "logout", auth.ID)
+	if logout.Status != models.TaskStatusBlocked || logout.BlockedReason == nil || *logout.BlockedReason != "waiting on design" {
+		t.Errorf("expected logout blocked with reason, got %s %v", logout.Status, logout.BlockedReason)
+	}
+	if strings.Contains(logout.Specification, "waiting on design") {
+		t.Errorf("expected the reason to stay out of the specification, got %q", logout.Specification)
+	}
+	database.Close()
+
+	if err := runRemove([]string{"--feature", "auth"}); err == nil {
+		t.Error("expected error removing a feature with tasks without --force")
+	}
+	if err := runRemove([]string{"--feature", "auth", "logout"}); err != nil {
+		t.Fatalf("rm task failed: %v", err)
+	}
+	if err := runRemove([]string{"--feature", "auth", "--force"}); err != nil {
+		t.Fatalf("rm feature failed: %v", err)
+	}
+
+	database = openTestDB(t, dbFilePath)
+	defer database.Close()
+	auth, _ = database.GetFeatureByName(ctx, "auth")
+	if auth != nil {
+		t.Error("expected auth feature to be removed")
+	}
+
+	if _, err := os.Stat(snapshotPath); err != nil {
+		t.Errorf("expected snapshot to be exported after changes: %v", err)
+	}
+}
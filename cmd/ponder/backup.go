@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+// defaultBackupKeep is how many automatic backups are kept when auto_backup
+// does not say.
+const defaultBackupKeep = 5
+
+// autoBackup is the rolling backup configured in config.json, applied to the
+// commands that import snapshots or archive tasks.
+var autoBackup db.AutoBackup
+
+type backupConfig struct {
+	Dir  string `json:"dir,omitempty"`
+	Keep *int   `json:"keep,omitempty"`
+}
+
+// parse fills in the defaults: backups go to .ponder/backups and the newest
+// five are kept.
+func (bc *backupConfig) parse() (db.AutoBackup, error) {
+	a := db.AutoBackup{Dir: bc.Dir, Keep: defaultBackupKeep}
+	if a.Dir == "" {
+		a.Dir = filepath.Join(configDir(), "backups")
+	}
+	if bc.Keep != nil {
+		if *bc.Keep < 0 {
+			return db.AutoBackup{}, fmt.Errorf("keep must be >= 0")
+		}
+		a.Keep = *bc.Keep
+	}
+	return a, nil
+}
+
+func runDBBackup(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ponder db backup <path>")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := database.Backup(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Backed up database to %s\n", args[0])
+	return nil
+}
+
+func runDBRestore(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ponder db restore <path>")
+	}
+
+	if err := db.Restore(args[0], dbPath); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Restored database from %s\n", args[0])
+
+	// Bring the schema and the committed snapshot in line with the restored
+	// database.
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := actor.With(context.Background(), "cli")
+	if err := database.Init(ctx); err != nil {
+		return fmt.Errorf("failed to initialize restored database: %w", err)
+	}
+	if err := database.ExportSnapshot(ctx, snapshotPath); err != nil {
+		return fmt.Errorf("failed to export snapshot: %w", err)
+	}
+	fmt.Printf("✓ Exported snapshot to %s\n", snapshotPath)
+	return nil
+}
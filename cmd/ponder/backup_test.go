@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func TestDBBackupAndRestoreCommands(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+	backupPath := filepath.Join(tmpDir, "backup.db")
+
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runDB([]string{"backup", backupPath}); err != nil {
+		t.Fatalf("runDB backup failed: %v", err)
+	}
+	if err := runRemove([]string{"--feature", "feature1", "task1"}); err != nil {
+		t.Fatalf("runRemove failed: %v", err)
+	}
+	if err := runDB([]string{"restore", backupPath}); err != nil {
+		t.Fatalf("runDB restore failed: %v", err)
+	}
+	w.Close()
+
+	database, err := db.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer database.Close()
+	tasks, err := database.ListTasks(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "task1" {
+		t.Errorf("expected the removed task to be restored, got %v", tasks)
+	}
+
+	snapshot, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if !strings.Contains(string(snapshot), `"name":"task1"`) {
+		t.Errorf("expected snapshot to be re-exported after restore, got:\n%s", snapshot)
+	}
+}
+
+func TestLoadWorkDefaultsParsesAutoBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+
+	configPath := filepath.Join(ponderDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"auto_backup": {}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	want := db.AutoBackup{Dir: filepath.Join(ponderDir, "backups"), Keep: defaultBackupKeep}
+	if defaults.AutoBackup != want {
+		t.Errorf("expected %+v, got %+v", want, defaults.AutoBackup)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"auto_backup": {"keep": -1}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Errorf("expected error for negative keep")
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/nick-dorsch/ponder/internal/actor"
 	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
@@ -79,6 +82,9 @@ func TestListFeatures(t *testing.T) {
 	if !strings.Contains(output, "feature1") {
 		t.Errorf("output missing feature1: %s", output)
 	}
+	if !strings.Contains(output, "0/1 (0%)") {
+		t.Errorf("output missing feature1 progress: %s", output)
+	}
 }
 
 func TestListTasks(t *testing.T) {
@@ -106,6 +112,72 @@ func TestListTasks(t *testing.T) {
 	}
 }
 
+func TestListTasksShowsBlockedReason(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runBlock([]string{"--feature", "feature1", "--reason", "Waiting on API keys\nfrom ops", "task1"})
+	if err == nil {
+		err = runListTasks([]string{})
+	}
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("list-tasks failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	if output := buf.String(); !strings.Contains(output, "reason: Waiting on API keys …") {
+		t.Errorf("output missing blocked reason: %s", output)
+	}
+}
+
+func TestListTasksTree(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runAddTask([]string{"--feature", "feature1", "--priority", "1", "--parent", "task1", "subtask"})
+	if err == nil {
+		err = runAddTask([]string{"--feature", "feature1", "--priority", "0", "--parent", "subtask", "leaf"})
+	}
+	if err == nil {
+		err = runListTasks([]string{})
+	}
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("list-tasks failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	task1 := strings.Index(output, "task1 ")
+	subtask := strings.Index(output, "└─ subtask")
+	leaf := strings.Index(output, "  └─ leaf")
+	if task1 < 0 || subtask < task1 || leaf < subtask {
+		t.Errorf("expected leaf nested under subtask under task1: %s", output)
+	}
+
+	if err := runAddTask([]string{"--feature", "feature1", "--parent", "missing", "orphan"}); err == nil {
+		t.Error("expected error for unknown parent")
+	}
+}
+
 func TestStatus(t *testing.T) {
 	tmpDir, _ := setupTestDB(t)
 	defer os.RemoveAll(tmpDir)
@@ -155,3 +227,469 @@ func TestDBStatus(t *testing.T) {
 		t.Errorf("output missing total tasks count: %s", output)
 	}
 }
+
+func TestStatusOverdue(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runAddTask([]string{"--feature", "feature1", "--due", "2020-01-02", "late"})
+	if err == nil {
+		err = runAddTask([]string{"--feature", "feature1", "--due", "2999-01-01T00:00:00Z", "--not-before", "2998-01-01", "later"})
+	}
+	if err == nil {
+		err = runStatus(nil)
+	}
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Overdue Tasks: 1") || !strings.Contains(output, "! feature1/late (pending, due 2020-01-02 00:00)") {
+		t.Errorf("output missing overdue task: %s", output)
+	}
+	if strings.Contains(output, "! feature1/later") {
+		t.Errorf("task due in the future reported as overdue: %s", output)
+	}
+
+	if err := runAddTask([]string{"--due", "tomorrow", "bad"}); err == nil {
+		t.Error("expected error for invalid --due")
+	}
+}
+
+func TestStatusClaims(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	database, err := db.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	claimer := models.Claimer{Hostname: "build-box", PID: 4242, WorkerID: 2}
+	claimed, err := database.ClaimNextTask(context.Background(), claimer, time.Minute)
+	database.Close()
+	if err != nil || claimed == nil {
+		t.Fatalf("failed to claim task: %v", err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err = runStatus(nil)
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Claims:") || !strings.Contains(output, "feature1/task1 by build-box:4242/worker-2 (expires ") {
+		t.Errorf("output missing claim: %s", output)
+	}
+}
+
+func TestExportToFile(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	outPath := filepath.Join(tmpDir, "plan.csv")
+	if err := runExport([]string{"--format", "csv", "--feature", "feature1", "--output", outPath}); err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if !strings.Contains(string(content), "feature1,task1,pending,10") {
+		t.Errorf("export missing task1 row: %s", content)
+	}
+
+	if err := runExport([]string{"--format", "yaml"}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestGraphToFile(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	outPath := filepath.Join(tmpDir, "graph.dot")
+	if err := runGraph([]string{"--format", "dot", "--output", outPath}); err != nil {
+		t.Fatalf("runGraph failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("failed to read graph: %v", err)
+	}
+	if !strings.Contains(string(content), "digraph ponder") || !strings.Contains(string(content), `"task1"`) {
+		t.Errorf("graph missing expected content: %s", content)
+	}
+
+	if err := runGraph([]string{"--format", "svg"}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestGraphAnalyze(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	ctx := context.Background()
+	task1, err := findTaskByName(ctx, database, "", "task1")
+	if err != nil {
+		t.Fatalf("failed to find task1: %v", err)
+	}
+	task2 := &models.Task{FeatureID: task1.FeatureID, Name: "task2", Status: models.TaskStatusPending}
+	if err := database.CreateTask(ctx, task2); err != nil {
+		t.Fatalf("failed to create task2: %v", err)
+	}
+	if err := database.CreateDependency(ctx, task2.ID, task1.ID); err != nil {
+		t.Fatalf("failed to create dependency: %v", err)
+	}
+	database.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runGraph([]string{"analyze"})
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runGraph analyze failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, want := range []string{"Critical path: 2 tasks", "1. feature1/task1 (pending)", "2. feature1/task2 (pending)", "Most blocking:", "feature1/task1"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Orphaned") {
+		t.Errorf("expected no orphaned tasks:\n%s", output)
+	}
+}
+
+func TestReportDurations(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	ctx := context.Background()
+	task1, err := findTaskByName(ctx, database, "", "task1")
+	if err != nil {
+		t.Fatalf("failed to find task1: %v", err)
+	}
+	summary := "done"
+	if err := database.UpdateTaskStatus(ctx, task1.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("failed to start task1: %v", err)
+	}
+	if err := database.UpdateTaskStatus(ctx, task1.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("failed to complete task1: %v", err)
+	}
+	if _, err := database.ExecContext(ctx, "UPDATE tasks SET started_at = datetime('now', '-90 minutes') WHERE id = ?", task1.ID); err != nil {
+		t.Fatalf("failed to backdate task1: %v", err)
+	}
+	database.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runReport([]string{"durations", "--days", "7"})
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runReport durations failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, want := range []string{"Tasks completed in the last 7 days: 1", "feature1/task1", "1h30m0s"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	if err := runReport([]string{"estimates"}); err == nil {
+		t.Error("expected an error for an unknown report")
+	}
+}
+
+func TestPrintChangelog(t *testing.T) {
+	done := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	printChangelog(&buf, &models.Changelog{
+		Since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Features: []*models.ChangelogFeature{{
+			FeatureName: "auth",
+			Entries: []*models.ChangelogEntry{
+				{TaskName: "login", Summary: "Added login.\n\nTokens last a day.", CompletedAt: done},
+				{TaskName: "logout", CompletedAt: done},
+			},
+		}},
+	})
+
+	want := `# Changelog
+
+Work completed since 2026-03-01.
+
+## auth
+
+- **login** (2026-03-02): Added login.
+
+  Tokens last a day.
+- **logout** (2026-03-02)
+`
+	if buf.String() != want {
+		t.Errorf("unexpected changelog:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printChangelog(&buf, &models.Changelog{})
+	if !strings.Contains(buf.String(), "_Nothing completed._") {
+		t.Errorf("expected an empty changelog to say so, got:\n%s", buf.String())
+	}
+	if err := runReport([]string{"changelog", "--since", "yesterday"}); err == nil {
+		t.Error("expected an invalid --since to be rejected")
+	}
+}
+
+func TestSessionSummaryAndReport(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Minute)
+	stats := orchestrator.SessionStats{
+		StartedAt: start,
+		Models:    map[string]int{"m1": 1, "m2": 3},
+		Attempted: 5, Completed: 3, Failed: 1,
+		Usage: orchestrator.Usage{TokensIn: 1200, TokensOut: 300, CostUSD: 0.42},
+	}
+
+	var buf bytes.Buffer
+	printSessionSummary(&buf, stats, end)
+	want := `Session ended after 1h30m0s: 5 tasks attempted, 3 completed, 1 failed
+  Models: m2 ×3, m1 ×1
+  Usage:  $0.42 (1200 in / 300 out tokens)
+`
+	if buf.String() != want {
+		t.Errorf("unexpected summary:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printSessionSummary(&buf, orchestrator.SessionStats{StartedAt: start}, start.Add(time.Minute))
+	if buf.String() != "Session ended after 1m0s: no tasks attempted\n" {
+		t.Errorf("unexpected summary of an idle session: %q", buf.String())
+	}
+
+	finished := &models.RunSession{ID: 1, StartedAt: start}
+	fillSession(finished, stats, end)
+	if finished.DurationMS != 90*60*1000 || finished.Attempted != 5 || finished.CostUSD != 0.42 || finished.EndedAt == nil {
+		t.Errorf("unexpected session %+v", finished)
+	}
+
+	buf.Reset()
+	printSessions(&buf, []*models.RunSession{{ID: 2, StartedAt: end}, finished})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "unfinished") || !strings.Contains(lines[2], "1h30m0s") || !strings.Contains(lines[2], "m2 ×3, m1 ×1") {
+		t.Errorf("unexpected sessions report:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	printSessions(&buf, nil)
+	if !strings.Contains(buf.String(), "No run sessions") {
+		t.Errorf("expected an empty report to say so, got %q", buf.String())
+	}
+}
+
+func TestPrepareRunRecordsSession(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	// An agent that finishes straight away.
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "opencode"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake agent: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	database, err := db.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer database.Close()
+
+	// Both `ponder` and `ponder serve` set up their runs this way.
+	ctx := context.Background()
+	opts := builtinWorkDefaults().workOptions()
+	orch := newOrchestrator(database, opts)
+	finish, err := prepareRun(ctx, database, orch, opts)
+	if err != nil {
+		t.Fatalf("prepareRun failed: %v", err)
+	}
+	sessions, err := database.ListRunSessions(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListRunSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].EndedAt != nil {
+		t.Fatalf("expected an unfinished session while the run is under way, got %+v", sessions)
+	}
+
+	// The totals are saved as tasks finish, so a crash before finish keeps them.
+	orch.SetTargetWorkers(1)
+	runCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		orch.Start(runCtx)
+	}()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		sessions, err = database.ListRunSessions(ctx, 10)
+		if err == nil && len(sessions) == 1 && sessions[0].Attempted == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the unfinished session to count the finished task, got %+v (%v)", sessions, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if s := sessions[0]; s.EndedAt != nil || s.Completed != 1 {
+		t.Errorf("expected an unfinished session with the task completed, got %+v", s)
+	}
+	cancel()
+	<-stopped
+
+	var summary bytes.Buffer
+	finish(&summary)
+	if !strings.Contains(summary.String(), "1 tasks attempted, 1 completed, 0 failed") {
+		t.Errorf("expected the session summary, got %q", summary.String())
+	}
+
+	sessions, err = database.ListRunSessions(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListRunSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].EndedAt == nil || sessions[0].Completed != 1 {
+		t.Errorf("expected the session to be finished, got %+v", sessions)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	database, err := db.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	ctx := context.Background()
+	tasks, err := database.ListTasks(ctx, nil, nil)
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("failed to load seeded task: %v", err)
+	}
+	if err := database.UpdateTaskStatus(actor.With(ctx, "cli"), tasks[0].ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("failed to update task status: %v", err)
+	}
+	database.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runHistory([]string{"--feature", "feature1", "task1"})
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runHistory failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if !strings.Contains(output, "feature1/task1") {
+		t.Errorf("output missing task label: %s", output)
+	}
+	if !strings.Contains(output, "created") || !strings.Contains(output, "status_changed") {
+		t.Errorf("output missing events: %s", output)
+	}
+	if !strings.Contains(output, "cli") {
+		t.Errorf("output missing actor: %s", output)
+	}
+	if strings.Index(output, "created") > strings.Index(output, "status_changed") {
+		t.Errorf("expected events in chronological order: %s", output)
+	}
+
+	if err := runHistory([]string{"missing"}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestNote(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	if err := runNote([]string{"--feature", "feature1", "task1", "remember", "the", "cache"}); err != nil {
+		t.Fatalf("runNote add failed: %v", err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runNote([]string{"task1"})
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runNote list failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if !strings.Contains(output, "remember the cache") {
+		t.Errorf("output missing note body: %s", output)
+	}
+	if !strings.Contains(output, "cli") {
+		t.Errorf("output missing default author: %s", output)
+	}
+
+	if err := runNote([]string{"missing", "body"}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
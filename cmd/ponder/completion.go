@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// completionCommand describes a command for shell completion.
+type completionCommand struct {
+	// flags take a value; switches don't. Both are without dashes.
+	flags    []string
+	switches []string
+	// subcommands are offered as the first argument.
+	subcommands map[string]completionCommand
+	// arg is what the first positional argument names: "task", "template"
+	// or "shell". Empty leaves it to the shell's file completion.
+	arg string
+}
+
+// completionCommands lists the commands, with their flags and arguments,
+// that completion offers. Keep it in step with execute and the commands'
+// flag sets.
+var completionCommands = map[string]completionCommand{
+	"init":          {flags: []string{"template"}},
+	"mcp":           {flags: []string{"http", "staging-ttl", "notify-interval"}, switches: []string{"read-only"}},
+	"list-features": {flags: []string{"format"}, switches: []string{"include-archived", "json"}},
+	"list-tasks":    {flags: []string{"status", "feature", "format"}, switches: []string{"include-archived", "json"}},
+	"status":        {flags: []string{"format"}, switches: []string{"json"}},
+	"watch":         {flags: []string{"interval", "feature", "n"}},
+	"add-feature":   {flags: []string{"description", "spec"}},
+	"add-task": {
+		flags: []string{"feature", "priority", "depends-on", "parent", "subtask-order", "not-before", "due",
+			"estimate", "description", "spec"},
+		switches: []string{"tests"},
+	},
+	"complete": {flags: []string{"feature", "summary"}, arg: "task"},
+	"block":    {flags: []string{"feature", "reason"}, arg: "task"},
+	"estimate": {flags: []string{"feature"}, arg: "task"},
+	"rm":       {flags: []string{"feature"}, switches: []string{"force"}, arg: "task"},
+	"archive":  {flags: []string{"before"}},
+	"template": {subcommands: map[string]completionCommand{
+		"add": {
+			flags:    []string{"feature", "task-name", "description", "spec", "priority", "every", "start"},
+			switches: []string{"tests"},
+		},
+		"list": {},
+		"use":  {flags: []string{"var"}, arg: "template"},
+		"rm":   {arg: "template"},
+	}},
+	"web": {flags: []string{"host", "port"}, switches: []string{"read-only"}},
+	"serve": {
+		flags:    []string{"host", "port", "mcp", "interval", "log-format", "log-file"},
+		switches: []string{"web", "orchestrate", "read-only"},
+	},
+	"config": {subcommands: map[string]completionCommand{
+		"get":  {},
+		"set":  {},
+		"list": {},
+	}},
+	"db": {subcommands: map[string]completionCommand{
+		"status":          {flags: []string{"format"}, switches: []string{"json"}},
+		"backup":          {},
+		"restore":         {},
+		"version":         {},
+		"migrate":         {switches: []string{"dry-run"}},
+		"vacuum":          {},
+		"integrity-check": {},
+		"checkpoint":      {flags: []string{"mode"}},
+	}},
+	"export": {flags: []string{"format", "feature", "output"}},
+	"import": {subcommands: map[string]completionCommand{
+		"github":   {flags: []string{"repo", "group-by", "default-feature"}, switches: []string{"closed", "sync"}},
+		"markdown": {switches: []string{"yes", "dry-run"}},
+	}},
+	"graph": {
+		flags: []string{"format", "feature", "output"},
+		subcommands: map[string]completionCommand{
+			"analyze": {flags: []string{"top"}, switches: []string{"json"}},
+		},
+	},
+	"snapshot": {subcommands: map[string]completionCommand{
+		"export":  {flags: []string{"output"}, switches: []string{"include-archived"}},
+		"import":  {flags: []string{"input"}, switches: []string{"mirror", "dry-run"}},
+		"merge":   {flags: []string{"base", "ours", "theirs", "output"}},
+		"history": {},
+		"restore": {},
+	}},
+	"report": {subcommands: map[string]completionCommand{
+		"durations": {flags: []string{"days", "feature"}, switches: []string{"json"}},
+		"burndown":  {flags: []string{"days", "feature"}, switches: []string{"json"}},
+		"changelog": {flags: []string{"since", "feature"}, switches: []string{"json"}},
+		"sessions":  {flags: []string{"limit"}, switches: []string{"json"}},
+	}},
+	"history":    {flags: []string{"feature", "limit"}, arg: "task"},
+	"note":       {flags: []string{"feature", "author"}, arg: "task"},
+	"label":      {flags: []string{"feature"}, switches: []string{"remove"}, arg: "task"},
+	"logs":       {flags: []string{"feature"}, switches: []string{"list"}, arg: "task"},
+	"env":        {flags: []string{"feature", "dir", "set", "unset"}, switches: []string{"clear"}, arg: "task"},
+	"completion": {arg: "shell"},
+}
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ponder completion bash|zsh|fish")
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return fmt.Errorf("unsupported shell: %s (use bash, zsh or fish)", args[0])
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runShellComplete prints the completions of the last of words, the
+// arguments typed after `ponder` so far. The completion scripts call it as
+// `ponder __complete <words>...`.
+func runShellComplete(rootFlags *flag.FlagSet, words []string) error {
+	for _, candidate := range completeWords(rootFlags, words, completionLookup) {
+		fmt.Println(candidate)
+	}
+	return nil
+}
+
+// completeWords returns the candidates for the last of words, given the
+// ones before it. lookup lists the feature, task or template names that
+// exist; for tasks, feature narrows them to one feature.
+func completeWords(rootFlags *flag.FlagSet, words []string, lookup func(kind, feature string) []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	prev := words[:len(words)-1]
+
+	// Root flags come before the command. Parsing them also picks up
+	// --db-path for the lookups.
+	rootFlags.SetOutput(io.Discard)
+	if err := rootFlags.Parse(prev); err != nil {
+		return nil
+	}
+	prev = rootFlags.Args()
+	if len(prev) == 0 {
+		if strings.HasPrefix(cur, "-") {
+			var names []string
+			rootFlags.VisitAll(func(f *flag.Flag) { names = append(names, "--"+f.Name) })
+			return withPrefix(names, cur)
+		}
+		return withPrefix(mapKeys(completionCommands), cur)
+	}
+
+	cmd, ok := completionCommands[prev[0]]
+	if !ok {
+		return nil
+	}
+	rest := prev[1:]
+	if len(cmd.subcommands) > 0 {
+		if len(rest) == 0 && !strings.HasPrefix(cur, "-") {
+			return withPrefix(mapKeys(cmd.subcommands), cur)
+		}
+		if len(rest) > 0 {
+			if sub, ok := cmd.subcommands[rest[0]]; ok {
+				cmd, rest = sub, rest[1:]
+			}
+		}
+	}
+
+	// Find the feature the command is narrowed to and count the arguments
+	// that aren't flags.
+	feature := ""
+	positional := 0
+	for j := 0; j < len(rest); j++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(rest[j], "-"), "=")
+		switch {
+		case !strings.HasPrefix(rest[j], "-"):
+			positional++
+		case hasValue || contains(cmd.switches, name):
+			if name == "feature" {
+				feature = value
+			}
+		case j+1 < len(rest):
+			if name == "feature" {
+				feature = rest[j+1]
+			}
+			j++
+		}
+	}
+
+	if len(rest) > 0 {
+		last := rest[len(rest)-1]
+		name := strings.TrimLeft(last, "-")
+		if strings.HasPrefix(last, "-") && !strings.Contains(name, "=") && contains(cmd.flags, name) {
+			switch name {
+			case "feature":
+				return withPrefix(lookup("feature", ""), cur)
+			case "parent":
+				return withPrefix(lookup("task", feature), cur)
+			case "status":
+				return withPrefix(taskStatusNames(), cur)
+			case "template":
+				return withPrefix(starterNames(), cur)
+			}
+			return nil
+		}
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		var names []string
+		for _, name := range append(append([]string{}, cmd.flags...), cmd.switches...) {
+			names = append(names, "--"+name)
+		}
+		sort.Strings(names)
+		return withPrefix(names, cur)
+	}
+	if positional > 0 {
+		return nil
+	}
+	switch cmd.arg {
+	case "task", "template":
+		return withPrefix(lookup(cmd.arg, feature), cur)
+	case "shell":
+		return withPrefix(completionShells, cur)
+	}
+	return nil
+}
+
+// completionLookup lists names from the database for completion. It never
+// creates a database and stays quiet on errors, since there is nowhere to
+// report them.
+func completionLookup(kind, feature string) []string {
+	if !db.IsPostgresDSN(dbPath) {
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil
+		}
+	}
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return nil
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	var names []string
+	switch kind {
+	case "feature":
+		features, err := database.ListFeatures(ctx)
+		if err != nil {
+			return nil
+		}
+		for _, f := range features {
+			names = append(names, f.Name)
+		}
+	case "task":
+		var featureName *string
+		if feature != "" {
+			featureName = &feature
+		}
+		tasks, err := database.ListTasks(ctx, nil, featureName)
+		if err != nil {
+			return nil
+		}
+		seen := make(map[string]bool)
+		for _, t := range tasks {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				names = append(names, t.Name)
+			}
+		}
+	case "template":
+		templates, err := database.ListTemplates(ctx)
+		if err != nil {
+			return nil
+		}
+		for _, tmpl := range templates {
+			names = append(names, tmpl.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func taskStatusNames() []string {
+	return []string{
+		string(models.TaskStatusPending),
+		string(models.TaskStatusInProgress),
+		string(models.TaskStatusInReview),
+		string(models.TaskStatusBlocked),
+		string(models.TaskStatusCompleted),
+		string(models.TaskStatusCancelled),
+	}
+}
+
+func mapKeys(m map[string]completionCommand) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func withPrefix(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// The completion scripts leave the work to `ponder __complete`, so every
+// shell completes the same way.
+
+const bashCompletion = `# bash completion for ponder. Load it with:
+#   source <(ponder completion bash)
+_ponder() {
+    local IFS=$'\n'
+    COMPREPLY=($(ponder __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _ponder ponder
+`
+
+const zshCompletion = `#compdef ponder
+# zsh completion for ponder. Load it with:
+#   source <(ponder completion zsh)
+# or save it as _ponder in a directory on $fpath.
+_ponder() {
+    local -a completions
+    completions=(${(f)"$(ponder __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    if (( ${#completions} )); then
+        compadd -a completions
+    else
+        _files
+    fi
+}
+if [[ "${funcstack[1]}" == "_ponder" ]]; then
+    _ponder "$@"
+else
+    compdef _ponder ponder
+fi
+`
+
+const fishCompletion = `# fish completion for ponder. Load it with:
+#   ponder completion fish | source
+# or save it as ~/.config/fish/completions/ponder.fish.
+function __ponder_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    ponder __complete $tokens (commandline -ct) 2>/dev/null
+end
+complete -c ponder -f -a '(__ponder_complete)'
+`
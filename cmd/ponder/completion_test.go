@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompleteWords(t *testing.T) {
+	lookup := func(kind, feature string) []string {
+		switch {
+		case kind == "feature":
+			return []string{"auth", "billing"}
+		case kind == "task" && feature == "auth":
+			return []string{"login", "logout"}
+		case kind == "task":
+			return []string{"invoice", "login", "logout"}
+		case kind == "template":
+			return []string{"weekly-review"}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"list-"}, []string{"list-features", "list-tasks"}},
+		{[]string{"--db-path", "other.db", "list-"}, []string{"list-features", "list-tasks"}},
+		{[]string{"--ver"}, []string{"--verbose", "--verify"}},
+		{[]string{"template", ""}, []string{"add", "list", "rm", "use"}},
+		{[]string{"template", "use", ""}, []string{"weekly-review"}},
+		{[]string{"complete", "--feature", ""}, []string{"auth", "billing"}},
+		{[]string{"complete", "--feature", "auth", "lo"}, []string{"login", "logout"}},
+		{[]string{"complete", "--feature=auth", ""}, []string{"login", "logout"}},
+		{[]string{"complete", "in"}, []string{"invoice"}},
+		{[]string{"complete", "login", ""}, nil},
+		{[]string{"complete", "--su"}, []string{"--summary"}},
+		{[]string{"rm", "--force", "inv"}, []string{"invoice"}},
+		{[]string{"list-tasks", "--status", "in_"}, []string{"in_progress", "in_review"}},
+		{[]string{"graph", "-"}, []string{"--feature", "--format", "--output"}},
+		{[]string{"graph", "analyze", "--"}, []string{"--json", "--top"}},
+		{[]string{"snapshot", "export", "--output", ""}, nil},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"unknown", ""}, nil},
+	}
+
+	for _, tt := range tests {
+		rootFlags := flag.NewFlagSet("ponder", flag.ContinueOnError)
+		path := rootFlags.String("db-path", "", "")
+		rootFlags.Bool("verbose", false, "")
+		rootFlags.String("verify", "", "")
+
+		got := completeWords(rootFlags, tt.words, lookup)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeWords(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+		if tt.words[0] == "--db-path" && *path != "other.db" {
+			t.Errorf("expected --db-path to be parsed, got %q", *path)
+		}
+	}
+}
+
+func TestCompletionCoversEveryCommand(t *testing.T) {
+	var usage bytes.Buffer
+	printRootUsage(&usage, flag.NewFlagSet("ponder", flag.ContinueOnError))
+
+	_, commands, _ := strings.Cut(usage.String(), "Commands:\n")
+	commands, _, _ = strings.Cut(commands, "\n\n")
+	for _, line := range strings.Split(commands, "\n") {
+		name := strings.Fields(line)[0]
+		if _, ok := completionCommands[name]; !ok {
+			t.Errorf("command %s is missing from completionCommands", name)
+		}
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	for _, shell := range completionShells {
+		if err := runCompletion([]string{shell}); err != nil {
+			t.Errorf("runCompletion(%s) failed: %v", shell, err)
+		}
+	}
+	if err := runCompletion([]string{"tcsh"}); err == nil {
+		t.Error("expected an unsupported shell to fail")
+	}
+}
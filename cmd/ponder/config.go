@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+func runConfig(args []string) error {
+	usage := fmt.Errorf("usage: ponder config get <key> | set <key> <value> | list")
+	if len(args) == 0 {
+		return usage
+	}
+
+	configPath := filepath.Join(configDir(), "config.json")
+	cfg, err := readConfigObject(configPath)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return usage
+		}
+		cfg.walk("", func(key string, value json.RawMessage) {
+			warnUnknownConfigKey(key)
+			fmt.Printf("%s = %s\n", key, value)
+		})
+		return nil
+
+	case "get":
+		if len(args) != 2 {
+			return usage
+		}
+		value, ok := cfg.get(strings.Split(args[1], "."))
+		if !ok {
+			return fmt.Errorf("%s is not set in %s", args[1], configPath)
+		}
+		fmt.Println(formatConfigValue(value))
+		return nil
+
+	case "set":
+		if len(args) != 3 {
+			return usage
+		}
+		key, raw := args[1], args[2]
+		path := strings.Split(key, ".")
+		value, err := parseConfigValue(path, raw)
+		if err != nil {
+			return err
+		}
+		warnUnknownConfigKey(key)
+		if err := cfg.set(path, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+
+		data, err := cfg.encode()
+		if err != nil {
+			return err
+		}
+		// Refuse to write anything ponder would fail to start with.
+		if _, err := parseWorkConfig(builtinWorkDefaults(), data, configPath); err != nil {
+			return err
+		}
+		if err := os.WriteFile(configPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write config file %s: %w", configPath, err)
+		}
+		fmt.Printf("%s = %s\n", key, value)
+		return nil
+	}
+	return usage
+}
+
+// configObject is a JSON object that keeps its keys in the order they were
+// read, so editing config.json does not reshuffle it.
+type configObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func readConfigObject(configPath string) (*configObject, error) {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return &configObject{values: make(map[string]json.RawMessage)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+	}
+	cfg, err := parseConfigObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+	return cfg, nil
+}
+
+func parseConfigObject(data []byte) (*configObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	o := &configObject{values: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, ok := o.values[key]; !ok {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *configObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(o.values[key])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// encode renders the object the way writeDefaultConfig does.
+func (o *configObject) encode() ([]byte, error) {
+	data, err := o.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var compact, out bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// get looks up a dotted key, split into path.
+func (o *configObject) get(path []string) (json.RawMessage, bool) {
+	value, ok := o.values[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	child, err := parseConfigObject(value)
+	if err != nil {
+		return nil, false
+	}
+	return child.get(path[1:])
+}
+
+// set stores value under path, creating the objects along the way.
+func (o *configObject) set(path []string, value json.RawMessage) error {
+	existing, ok := o.values[path[0]]
+	if !ok {
+		o.keys = append(o.keys, path[0])
+	}
+	if len(path) == 1 {
+		o.values[path[0]] = value
+		return nil
+	}
+
+	child := &configObject{values: make(map[string]json.RawMessage)}
+	if ok {
+		var err error
+		if child, err = parseConfigObject(existing); err != nil {
+			return fmt.Errorf("%s is not an object", path[0])
+		}
+	}
+	if err := child.set(path[1:], value); err != nil {
+		return err
+	}
+	data, err := child.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	o.values[path[0]] = data
+	return nil
+}
+
+// walk calls fn with the dotted key and compact value of every setting,
+// descending into nested objects.
+func (o *configObject) walk(prefix string, fn func(key string, value json.RawMessage)) {
+	for _, key := range o.keys {
+		value := o.values[key]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if child, err := parseConfigObject(value); err == nil && len(child.keys) > 0 {
+			child.walk(key, fn)
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err == nil {
+			value = compact.Bytes()
+		}
+		fn(key, value)
+	}
+}
+
+// formatConfigValue prints strings without quotes and everything else as
+// indented JSON.
+func formatConfigValue(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, value, "", "  "); err != nil {
+		return string(value)
+	}
+	return out.String()
+}
+
+// parseConfigValue converts a value given on the command line to JSON.
+// Settings that take a string are stored as given; anything else must be
+// JSON of the setting's type. Values of unknown keys are stored as JSON when
+// they parse as JSON, and as strings otherwise.
+func parseConfigValue(path []string, raw string) (json.RawMessage, error) {
+	t, known := configKeyType(path)
+	if known && t.Kind() == reflect.String {
+		return json.Marshal(raw)
+	}
+	if !json.Valid([]byte(raw)) {
+		if known {
+			return nil, fmt.Errorf("invalid value %q for %s: expected %s", raw, strings.Join(path, "."), configTypeName(t))
+		}
+		return json.Marshal(raw)
+	}
+	if known {
+		if err := json.Unmarshal([]byte(raw), reflect.New(t).Interface()); err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: expected %s", raw, strings.Join(path, "."), configTypeName(t))
+		}
+	}
+	return json.RawMessage(raw), nil
+}
+
+// configKeyType returns the Go type of the setting at path in workConfig.
+// Maps take any key.
+func configKeyType(path []string) (reflect.Type, bool) {
+	t := reflect.TypeFor[workConfig]()
+	for _, name := range path {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := configField(t, name)
+			if !ok {
+				return nil, false
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, true
+}
+
+func configField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, f := range reflect.VisibleFields(t) {
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func configTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64:
+		return "an integer"
+	case reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a JSON array"
+	case reflect.Struct, reflect.Map:
+		return "a JSON object"
+	}
+	return t.String()
+}
+
+// warnUnknownConfigKey warns about keys ponder ignores, which are usually
+// typos, suggesting the closest known key.
+func warnUnknownConfigKey(key string) {
+	path := strings.Split(key, ".")
+	if _, ok := configKeyType(path); ok {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: unknown config key %s is ignored", key)
+	if suggestion := closestConfigKey(path); suggestion != "" {
+		fmt.Fprintf(os.Stderr, " (did you mean %s?)", suggestion)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// closestConfigKey suggests a known key for the first unknown part of path
+// that is at most two edits away.
+func closestConfigKey(path []string) string {
+	for i := range path {
+		if _, ok := configKeyType(path[:i+1]); ok {
+			continue
+		}
+		parent := reflect.TypeFor[workConfig]()
+		if i > 0 {
+			parent, _ = configKeyType(path[:i])
+		}
+		if parent.Kind() != reflect.Struct {
+			return ""
+		}
+		best, bestDist := "", 3
+		for _, f := range reflect.VisibleFields(parent) {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if d := editDistance(path[i], name); name != "" && d < bestDist {
+				best, bestDist = name, d
+			}
+		}
+		if best == "" {
+			return ""
+		}
+		return strings.Join(append(append([]string{}, path[:i]...), best), ".")
+	}
+	return ""
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
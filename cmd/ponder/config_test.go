@@ -1,9 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
 )
 
 func TestLoadWorkDefaultsUsesConfigFile(t *testing.T) {
@@ -23,7 +32,8 @@ func TestLoadWorkDefaultsUsesConfigFile(t *testing.T) {
 	config := `{
   "model": "test/model",
   "max_concurrency": 9,
-  "available_models": ["test/model", "backup/model"]
+  "available_models": ["test/model", "backup/model"],
+  "worktrees": true
 }
 `
 	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
@@ -35,6 +45,9 @@ func TestLoadWorkDefaultsUsesConfigFile(t *testing.T) {
 		t.Fatalf("loadWorkDefaults failed: %v", err)
 	}
 
+	if !defaults.Worktrees {
+		t.Error("expected worktrees to be enabled")
+	}
 	if defaults.Model != "test/model" {
 		t.Errorf("expected model test/model, got %s", defaults.Model)
 	}
@@ -109,3 +122,682 @@ func TestLoadWorkDefaultsAddsConfiguredModelToAvailableList(t *testing.T) {
 		t.Errorf("expected configured model to be appended, got %v", defaults.AvailableModels)
 	}
 }
+
+func TestLoadWorkDefaultsParsesRetryPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	config := `{
+  "retry": {
+    "max_attempts": 5,
+    "initial_backoff": "10s",
+    "max_backoff": "2m",
+    "jitter": 0
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+
+	policy := defaults.RetryPolicy
+	if policy.MaxAttempts != 5 {
+		t.Errorf("expected max attempts 5, got %d", policy.MaxAttempts)
+	}
+	if policy.InitialBackoff != 10*time.Second {
+		t.Errorf("expected initial backoff 10s, got %v", policy.InitialBackoff)
+	}
+	if policy.MaxBackoff != 2*time.Minute {
+		t.Errorf("expected max backoff 2m, got %v", policy.MaxBackoff)
+	}
+	if policy.Jitter != 0 {
+		t.Errorf("expected jitter 0, got %v", policy.Jitter)
+	}
+	if policy.Multiplier != orchestrator.DefaultRetryPolicy().Multiplier {
+		t.Errorf("expected default multiplier to be kept, got %v", policy.Multiplier)
+	}
+}
+
+func TestLoadWorkDefaultsRejectsInvalidRetryPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	config := `{"retry": {"initial_backoff": "soon"}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for invalid retry config")
+	}
+}
+
+func TestLoadWorkDefaultsParsesVerification(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	config := `{"verification": {"command": "go test ./...", "timeout": "5m"}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.Verification == nil {
+		t.Fatal("expected verification to be configured")
+	}
+	if defaults.Verification.Command != "go test ./..." {
+		t.Errorf("expected command 'go test ./...', got %q", defaults.Verification.Command)
+	}
+	if defaults.Verification.Timeout != 5*time.Minute {
+		t.Errorf("expected timeout 5m, got %v", defaults.Verification.Timeout)
+	}
+
+	config = `{"verification": {"command": "make test", "timeout": "later"}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for invalid verification timeout")
+	}
+}
+
+func TestLoadWorkDefaultsParsesPriorityAging(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	config := `{"priority_aging": {"interval": "30m", "max_boost": 4}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	aging := defaults.PriorityAging
+	if aging.Interval != 30*time.Minute || aging.Step != 1 || aging.MaxBoost != 4 {
+		t.Errorf("unexpected priority aging: %+v", aging)
+	}
+
+	config = `{"priority_aging": {"interval": "30m", "step": -1}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for negative step")
+	}
+}
+
+func TestLoadWorkDefaultsParsesAvailability(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+
+	tests := []struct {
+		config  string
+		want    db.AvailabilityPolicy
+		wantErr bool
+	}{
+		{config: `{}`, want: db.AvailabilityPolicy{}},
+		{config: `{"availability": {"dependencies": "soft"}}`, want: db.AvailabilityPolicy{Dependencies: "soft", Penalty: 1}},
+		{config: `{"availability": {"dependencies": "soft", "penalty": 0}}`, want: db.AvailabilityPolicy{Dependencies: "soft"}},
+		{config: `{"availability": {"dependencies": "lenient"}}`, wantErr: true},
+		{config: `{"availability": {"dependencies": "strict", "penalty": 2}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(tt.config), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		defaults, err := loadWorkDefaults()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.config)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: loadWorkDefaults failed: %v", tt.config, err)
+		}
+		if defaults.Availability != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.config, tt.want, defaults.Availability)
+		}
+	}
+}
+
+func TestLoadWorkDefaultsParsesMaxTaskDuration(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	config := `{"max_task_duration": "45m", "max_task_duration_overrides": {"auth/migrate": "2h"}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	timeouts := defaults.TaskTimeouts
+	if timeouts.Default != 45*time.Minute || timeouts.Overrides["auth/migrate"] != 2*time.Hour {
+		t.Errorf("unexpected task timeouts: %+v", timeouts)
+	}
+
+	config = `{"max_task_duration_overrides": {"migrate": "2h"}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for override without a feature")
+	}
+}
+
+func TestLoadWorkDefaultsParsesStallDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	config := `{"stall_detection": {"window": "10m", "kill": true}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if stall := defaults.StallDetection; stall.Window != 10*time.Minute || !stall.Kill {
+		t.Errorf("unexpected stall detection: %+v", stall)
+	}
+
+	config = `{"stall_detection": {"window": "0s", "kill": true}}`
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for kill without a window")
+	}
+}
+
+func TestLoadWorkDefaultsParsesClaimLease(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.ClaimLease != orchestrator.DefaultClaimLease {
+		t.Errorf("expected default claim lease %s, got %s", orchestrator.DefaultClaimLease, defaults.ClaimLease)
+	}
+
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(`{"claim_lease": "90s"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	defaults, err = loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.ClaimLease != 90*time.Second {
+		t.Errorf("expected claim lease 90s, got %s", defaults.ClaimLease)
+	}
+
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(`{"claim_lease": "10ms"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for a claim lease under a second")
+	}
+}
+
+func TestLoadWorkDefaultsParsesModelRouting(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	configPath := filepath.Join(ponderDir, "config.json")
+	config := `{"model_routing": {"priority>=8": "opencode/gpt-5", "priority >= 5": "mid/model", "default": "opencode/gemini-3-flash"}}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	want := orchestrator.ModelRouting{{MinPriority: 8, Model: "opencode/gpt-5"}, {MinPriority: 5, Model: "mid/model"}}
+	if len(defaults.ModelRouting) != len(want) || defaults.ModelRouting[0] != want[0] || defaults.ModelRouting[1] != want[1] {
+		t.Errorf("expected routing %+v, got %+v", want, defaults.ModelRouting)
+	}
+	if defaults.Model != "opencode/gemini-3-flash" {
+		t.Errorf("expected the default route to set the model, got %s", defaults.Model)
+	}
+
+	for _, bad := range []string{
+		`{"model_routing": {"priority<8": "x"}}`,
+		`{"model_routing": {"priority>=high": "x"}}`,
+		`{"model": "a", "model_routing": {"default": "b"}}`,
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := loadWorkDefaults(); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestLoadWorkDefaultsParsesSnapshotDebounce(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	configPath := filepath.Join(ponderDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"snapshot_debounce": "1s"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.SnapshotDebounce != time.Second {
+		t.Errorf("expected snapshot debounce 1s, got %s", defaults.SnapshotDebounce)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"snapshot_debounce": "-1s"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for a negative snapshot debounce")
+	}
+}
+
+func TestLoadWorkDefaultsParsesModelFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	configPath := filepath.Join(ponderDir, "config.json")
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.ModelFallback.Enabled() {
+		t.Error("expected model fallback to be off by default")
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"model_fallback": {}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	defaults, err = loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.ModelFallback.AfterFailures != orchestrator.DefaultFallbackFailures {
+		t.Errorf("expected %d failures per model, got %d", orchestrator.DefaultFallbackFailures, defaults.ModelFallback.AfterFailures)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"model_fallback": {"after_failures": -1}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Fatal("expected error for negative after_failures")
+	}
+}
+
+func TestLoadWorkDefaultsParsesWebAuthToken(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	configPath := filepath.Join(ponderDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"web_auth_token": "from-config"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.WebAuthToken != "from-config" {
+		t.Errorf("expected token from config, got %q", defaults.WebAuthToken)
+	}
+
+	t.Setenv("PONDER_WEB_AUTH_TOKEN", "from-env")
+	defaults, err = loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.WebAuthToken != "from-env" {
+		t.Errorf("expected PONDER_WEB_AUTH_TOKEN to override the config, got %q", defaults.WebAuthToken)
+	}
+}
+
+func TestWebURL(t *testing.T) {
+	defer func(token string) { webAuthToken = token }(webAuthToken)
+
+	webAuthToken = ""
+	if got := webURL("0.0.0.0", "8000"); got != "http://localhost:8000/" {
+		t.Errorf("expected localhost for an unspecified host, got %s", got)
+	}
+	webAuthToken = "a b"
+	if got := webURL("127.0.0.1", "9000"); got != "http://127.0.0.1:9000/?token=a+b" {
+		t.Errorf("expected the token in the URL, got %s", got)
+	}
+	if !isLoopback("127.0.0.1") || !isLoopback("::1") || !isLoopback("localhost") || isLoopback("0.0.0.0") {
+		t.Error("unexpected isLoopback result")
+	}
+}
+
+func TestMCPHTTPServer(t *testing.T) {
+	defer func(token string) { webAuthToken = token }(webAuthToken)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	webAuthToken = ""
+	hs, err := mcpHTTPServer(":3920", ok, false)
+	if err != nil {
+		t.Fatalf("mcpHTTPServer failed: %v", err)
+	}
+	if hs.Addr != "127.0.0.1:3920" || hs.ReadHeaderTimeout == 0 {
+		t.Errorf("expected loopback with a header timeout, got %s, %v", hs.Addr, hs.ReadHeaderTimeout)
+	}
+	if _, err := mcpHTTPServer("0.0.0.0:3920", ok, false); err == nil {
+		t.Error("expected serving beyond loopback without a token to be refused")
+	}
+	if _, err := mcpHTTPServer("0.0.0.0:3920", ok, true); err != nil {
+		t.Errorf("expected a read-only server to be allowed beyond loopback, got %v", err)
+	}
+
+	webAuthToken = "s3cret"
+	hs, err = mcpHTTPServer("0.0.0.0:3920", ok, false)
+	if err != nil {
+		t.Fatalf("mcpHTTPServer failed: %v", err)
+	}
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "s3cret": http.StatusOK} {
+		req := httptest.NewRequest("POST", "/mcp", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		hs.Handler.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("token %q: expected %d, got %d", token, want, w.Code)
+		}
+	}
+}
+
+func TestConfigCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+	configPath := filepath.Join(ponderDir, "config.json")
+	if err := writeDefaultConfig(configPath); err != nil {
+		t.Fatalf("writeDefaultConfig failed: %v", err)
+	}
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+
+	for _, args := range [][]string{
+		{"set", "max_concurrency", "8"},
+		{"set", "model", "test/model"},
+		{"set", "retry.max_attempts", "5"},
+		{"set", "verification.command", "go test ./..."},
+	} {
+		if err := runConfig(args); err != nil {
+			t.Fatalf("config %v failed: %v", args, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"set", "max_concurrency", "0"},
+		{"set", "max_concurrency", "many"},
+		{"set", "claim_lease", "soon"},
+		{"set", "model.name", "x"},
+		{"get", "snapshot_debounce"},
+		{"unset", "model"},
+	} {
+		if err := runConfig(args); err == nil {
+			t.Errorf("expected config %v to fail", args)
+		}
+	}
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	if defaults.MaxConcurrency != 8 || defaults.Model != "test/model" {
+		t.Errorf("expected max_concurrency 8 and model test/model, got %d and %s", defaults.MaxConcurrency, defaults.Model)
+	}
+	if defaults.RetryPolicy.MaxAttempts != 5 {
+		t.Errorf("expected 5 retry attempts, got %d", defaults.RetryPolicy.MaxAttempts)
+	}
+	if defaults.Verification == nil || defaults.Verification.Command != "go test ./..." {
+		t.Errorf("expected verification command, got %+v", defaults.Verification)
+	}
+
+	// Existing keys keep their place; new ones are appended.
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	content := string(data)
+	if strings.Index(content, `"model"`) > strings.Index(content, `"available_models"`) ||
+		strings.Index(content, `"available_models"`) > strings.Index(content, `"retry"`) {
+		t.Errorf("expected key order to be kept, got:\n%s", content)
+	}
+
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err = runConfig([]string{"list"})
+	if err == nil {
+		err = runConfig([]string{"get", "model"})
+	}
+	w.Close()
+	os.Stdout = devNull
+	if err != nil {
+		t.Fatalf("config list/get failed: %v", err)
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+	for _, want := range []string{"max_concurrency = 8\n", "retry.max_attempts = 5\n", `verification.command = "go test ./..."` + "\n", "\ntest/model\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestClosestConfigKey(t *testing.T) {
+	tests := map[string]string{
+		"max_concurency":    "max_concurrency",
+		"retry.max_atempts": "retry.max_attempts",
+		"something_else":    "",
+		"pricing.gpt.inptu": "pricing.gpt.input",
+	}
+	for key, want := range tests {
+		if got := closestConfigKey(strings.Split(key, ".")); got != want {
+			t.Errorf("closestConfigKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestParseWorkConfigResourceLimits(t *testing.T) {
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"resource_limits": {"nice": 10, "memory_max": "2G", "max_processes": 256}}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	want := orchestrator.ResourceLimits{Nice: 10, MemoryMax: 2 << 30, MaxProcesses: 256}
+	if defaults.ResourceLimits != want {
+		t.Errorf("expected %+v, got %+v", want, defaults.ResourceLimits)
+	}
+
+	for _, bad := range []string{
+		`{"resource_limits": {"nice": 20}}`,
+		`{"resource_limits": {"memory_max": "lots"}}`,
+		`{"resource_limits": {"max_processes": -1}}`,
+	} {
+		if _, err := parseWorkConfig(builtinWorkDefaults(), []byte(bad), "config.json"); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{
+		"1024": 1024,
+		"512M": 512 << 20,
+		"4g":   4 << 30,
+		"64KB": 64 << 10,
+	}
+	for in, want := range cases {
+		got, err := parseByteSize(in)
+		if err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseByteSize("-1G"); err == nil {
+		t.Error("expected a negative size to be rejected")
+	}
+}
+
+func TestParseWorkConfigSandbox(t *testing.T) {
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"sandbox": {"image": "agent:latest", "binds": ["/a:/b"], "env": {"X": "1"}}}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	if defaults.Sandbox == nil || defaults.Sandbox.Image != "agent:latest" || len(defaults.Sandbox.Binds) != 1 || defaults.Sandbox.Env["X"] != "1" {
+		t.Errorf("unexpected sandbox %+v", defaults.Sandbox)
+	}
+
+	if _, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"sandbox": {"runtime": "podman"}}`), "config.json"); err == nil {
+		t.Error("expected a sandbox without an image to be rejected")
+	}
+}
+
+func TestParseWorkConfigClaimFilter(t *testing.T) {
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"claim_filter": {"features": ["ui"], "labels": ["frontend"], "min_priority": 5}}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	f := defaults.ClaimFilter
+	if len(f.Features) != 1 || f.Features[0] != "ui" || len(f.Labels) != 1 || f.Labels[0] != "frontend" || f.MinPriority != 5 {
+		t.Errorf("unexpected claim filter %+v", f)
+	}
+
+	if _, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"claim_filter": {"min_priority": 11}}`), "config.json"); err == nil {
+		t.Error("expected min_priority 11 to be rejected")
+	}
+}
+
+func TestParseWorkConfigRateLimits(t *testing.T) {
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"rate_limits": {"opencode": 30, "opencode/gpt-5": 6}}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	if defaults.RateLimits["opencode"] != 30 || defaults.RateLimits["opencode/gpt-5"] != 6 {
+		t.Errorf("unexpected rate limits %v", defaults.RateLimits)
+	}
+
+	if _, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"rate_limits": {"opencode": 0}}`), "config.json"); err == nil {
+		t.Error("expected a zero rate limit to be rejected")
+	}
+}
+
+func TestParseWorkConfigNotifications(t *testing.T) {
+	t.Setenv("PONDER_TEST_HOOK", "https://hooks.slack.com/services/T0/B0/secret")
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"notifications": [{"type": "slack", "url": "$PONDER_TEST_HOOK", "events": ["run_finished"]}]}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	if len(defaults.Notifiers) != 1 || defaults.Notifiers[0].URL != "https://hooks.slack.com/services/T0/B0/secret" || defaults.Notifiers[0].Events[0] != "run_finished" {
+		t.Errorf("unexpected notifiers %+v", defaults.Notifiers)
+	}
+
+	if _, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{"notifications": [{"type": "teams", "url": "https://example.com"}]}`), "config.json"); err == nil {
+		t.Error("expected an unknown notifier type to be rejected")
+	}
+}
+
+func TestParseWorkConfigTheme(t *testing.T) {
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), []byte(`{}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	if defaults.Theme != theme.Dark {
+		t.Errorf("expected the dark theme by default, got %+v", defaults.Theme)
+	}
+
+	defaults, err = parseWorkConfig(builtinWorkDefaults(), []byte(`{"theme": {"name": "light", "colors": {"success": "#15803d"}}}`), "config.json")
+	if err != nil {
+		t.Fatalf("parseWorkConfig failed: %v", err)
+	}
+	want := theme.Light
+	want.Success = "#15803d"
+	if defaults.Theme != want {
+		t.Errorf("expected the light theme with its success color overridden, got %+v", defaults.Theme)
+	}
+
+	for _, bad := range []string{
+		`{"theme": {"name": "solarized"}}`,
+		`{"theme": {"colors": {"sucess": "2"}}}`,
+		`{"theme": {"colors": {"success": "green"}}}`,
+	} {
+		if _, err := parseWorkConfig(builtinWorkDefaults(), []byte(bad), "config.json"); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func runEnv(args []string) error {
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	featureFilter := fs.String("feature", "", "Feature the task belongs to, or the feature to configure when no task is given")
+	dir := fs.String("dir", "", "Directory to run the agent in, relative to the repository root (\".\" for the root)")
+	clearAll := fs.Bool("clear", false, "Remove the working directory and all variables before applying other changes")
+	set := make(map[string]string)
+	var unset []string
+	fs.Func("set", "Set an environment variable, as KEY=VALUE (repeatable)", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected KEY=VALUE, got %q", s)
+		}
+		set[name] = value
+		return nil
+	})
+	fs.Func("unset", "Remove an environment variable (repeatable)", func(s string) error {
+		unset = append(unset, s)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 0 && *featureFilter == "") {
+		return fmt.Errorf("usage: ponder env [--feature name] [--dir path] [--set KEY=VALUE]... [--unset KEY]... [--clear] [<task>]")
+	}
+	dirSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "dir" {
+			dirSet = true
+		}
+	})
+	changed := dirSet || *clearAll || len(set) > 0 || len(unset) > 0
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	var (
+		label     string
+		current   *models.RunEnvironment
+		task      *models.Task
+		featureID string
+	)
+	if fs.NArg() == 1 {
+		if task, err = findTaskByName(ctx, database, *featureFilter, fs.Arg(0)); err != nil {
+			return err
+		}
+		label = task.FeatureName + "/" + task.Name
+		current, err = database.GetTaskRunEnvironment(ctx, task.ID)
+	} else {
+		f, ferr := database.GetFeatureByName(ctx, *featureFilter)
+		if ferr != nil {
+			return ferr
+		}
+		if f == nil {
+			return fmt.Errorf("feature not found: %s", *featureFilter)
+		}
+		label, featureID = f.Name, f.ID
+		current, err = database.GetFeatureRunEnvironment(ctx, f.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		printRunEnvironment(label, current)
+		if task != nil {
+			resolved, err := database.ResolveRunEnvironment(ctx, task)
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			printRunEnvironment("Agent runs with", resolved)
+		}
+		return nil
+	}
+
+	next := &models.RunEnvironment{Env: make(map[string]string)}
+	if current != nil && !*clearAll {
+		next.WorkingDir = current.WorkingDir
+		for name, value := range current.Env {
+			next.Env[name] = value
+		}
+	}
+	if dirSet {
+		next.WorkingDir = *dir
+	}
+	for name, value := range set {
+		next.Env[name] = value
+	}
+	for _, name := range unset {
+		delete(next.Env, name)
+	}
+
+	if task != nil {
+		err = database.SetTaskRunEnvironment(ctx, task.ID, next)
+	} else {
+		err = database.SetFeatureRunEnvironment(ctx, featureID, next)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Updated environment of %s\n", label)
+	return nil
+}
+
+func printRunEnvironment(label string, e *models.RunEnvironment) {
+	fmt.Printf("%s:\n", label)
+	if e == nil || e.IsZero() {
+		fmt.Println("  (nothing set)")
+		return
+	}
+	dir := e.WorkingDir
+	if dir == "" {
+		dir = "."
+	}
+	fmt.Printf("  dir: %s\n", dir)
+	names := make([]string, 0, len(e.Env))
+	for name := range e.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s=%s\n", name, e.Env[name])
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvCommand(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runEnv([]string{"--feature", "feature1", "--dir", "web", "--set", "PORT=3000", "--set", "NODE_ENV=test"}); err != nil {
+		t.Fatalf("env on feature failed: %v", err)
+	}
+	if err := runEnv([]string{"--feature", "feature1", "--set", "PORT=4000", "--unset", "NODE_ENV", "task1"}); err != nil {
+		t.Fatalf("env on task failed: %v", err)
+	}
+	if err := runEnv([]string{"--feature", "feature1", "--set", "NOVALUE", "task1"}); err == nil {
+		t.Error("expected error for --set without =")
+	}
+	if err := runEnv([]string{"--feature", "feature1", "--dir", "../elsewhere"}); err == nil {
+		t.Error("expected error for a directory outside the repository")
+	}
+	if err := runEnv([]string{"--feature", "feature1", "task1"}); err != nil {
+		t.Errorf("showing the environment failed: %v", err)
+	}
+
+	ctx := context.Background()
+	database := openTestDB(t, dbFilePath)
+	defer database.Close()
+	f, _ := database.GetFeatureByName(ctx, "feature1")
+	task, _ := database.GetTaskByName(ctx, "task1", f.ID)
+
+	featureEnv, err := database.GetFeatureRunEnvironment(ctx, f.ID)
+	if err != nil || featureEnv == nil || featureEnv.WorkingDir != "web" || featureEnv.Env["NODE_ENV"] != "test" {
+		t.Errorf("unexpected feature environment %+v (%v)", featureEnv, err)
+	}
+	resolved, err := database.ResolveRunEnvironment(ctx, task)
+	if err != nil || resolved.WorkingDir != "web" || resolved.Env["PORT"] != "4000" || resolved.Env["NODE_ENV"] != "test" {
+		t.Errorf("unexpected resolved environment %+v (%v)", resolved, err)
+	}
+
+	if err := runEnv([]string{"--feature", "feature1", "--clear"}); err != nil {
+		t.Fatalf("env --clear failed: %v", err)
+	}
+	featureEnv, err = database.GetFeatureRunEnvironment(ctx, f.ID)
+	if err != nil || featureEnv != nil {
+		t.Errorf("expected feature environment to be cleared, got %+v (%v)", featureEnv, err)
+	}
+}
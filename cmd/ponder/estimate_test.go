@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEstimate(t *testing.T) {
+	cases := map[string]int{
+		"45":    45,
+		"1h30m": 90,
+		"90s":   2,
+	}
+	for in, want := range cases {
+		got, err := parseEstimate(in)
+		if err != nil || got == nil || *got != want {
+			t.Errorf("parseEstimate(%q) = %v, %v; want %d", in, got, err, want)
+		}
+	}
+	if got, err := parseEstimate(""); got != nil || err != nil {
+		t.Errorf("parseEstimate(\"\") = %v, %v; want no estimate", got, err)
+	}
+	for _, in := range []string{"0", "-5", "-1h", "soon"} {
+		if _, err := parseEstimate(in); err == nil {
+			t.Errorf("parseEstimate(%q): expected error", in)
+		}
+	}
+}
+
+func TestEstimateAndBurndown(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+
+	if err := runAddTask([]string{"--feature", "feature1", "--estimate", "2h", "task2"}); err != nil {
+		t.Fatalf("add-task with estimate failed: %v", err)
+	}
+	if err := runEstimate([]string{"task1", "30"}); err != nil {
+		t.Fatalf("estimate failed: %v", err)
+	}
+	if err := runEstimate([]string{"task1", "later"}); err == nil {
+		t.Error("expected error for an invalid estimate")
+	}
+	os.Stdout = oldStdout
+
+	ctx := context.Background()
+	database := openTestDB(t, dbFilePath)
+	feature, _ := database.GetFeatureByName(ctx, "feature1")
+	task1, _ := database.GetTaskByName(ctx, "task1", feature.ID)
+	task2, _ := database.GetTaskByName(ctx, "task2", feature.ID)
+	if task1.EstimateMinutes == nil || *task1.EstimateMinutes != 30 {
+		t.Errorf("expected task1 estimated at 30 minutes, got %v", task1.EstimateMinutes)
+	}
+	if task2.EstimateMinutes == nil || *task2.EstimateMinutes != 120 {
+		t.Errorf("expected task2 estimated at 120 minutes, got %v", task2.EstimateMinutes)
+	}
+	database.Close()
+
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := runReport([]string{"burndown", "--days", "3"})
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("runReport burndown failed: %v", err)
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+	for _, want := range []string{"Tasks: 2 (0 completed), estimated 2h30m0s, 2h30m0s remaining", "feature1", "TOTAL"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	os.Stdout = devNull
+	err = runEstimate([]string{"task1", "0"})
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("clearing the estimate failed: %v", err)
+	}
+	database = openTestDB(t, dbFilePath)
+	defer database.Close()
+	task1, _ = database.GetTaskByName(ctx, "task1", feature.ID)
+	if task1.EstimateMinutes != nil {
+		t.Errorf("expected task1's estimate to be cleared, got %d", *task1.EstimateMinutes)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func runGraphAnalyze(args []string) error {
+	fs := flag.NewFlagSet("graph analyze", flag.ContinueOnError)
+	top := fs.Int("top", 10, "Number of most-blocking tasks to list (0 for all)")
+	asJSON := fs.Bool("json", false, "Print the full analysis as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder graph analyze [--top 10] [--json]")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	analysis, err := database.AnalyzeGraph(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(analysis)
+	}
+	printGraphAnalysis(os.Stdout, analysis, *top)
+	return nil
+}
+
+func printGraphAnalysis(w io.Writer, a *models.GraphAnalysis, top int) {
+	fmt.Fprintf(w, "Open tasks: %d\n", a.OpenTasks)
+	fmt.Fprintf(w, "Critical path: %d tasks\n", a.CriticalPathLength)
+	for i, ref := range a.CriticalPath {
+		fmt.Fprintf(w, "  %d. %s/%s (%s)\n", i+1, ref.FeatureName, ref.Name, ref.Status)
+	}
+
+	var blocking []*models.TaskAnalysis
+	for _, t := range a.Tasks {
+		if t.BlockedTasks == 0 {
+			break
+		}
+		blocking = append(blocking, t)
+	}
+	if top > 0 && len(blocking) > top {
+		blocking = blocking[:top]
+	}
+	if len(blocking) > 0 {
+		fmt.Fprintln(w, "\nMost blocking:")
+		fmt.Fprintf(w, "  %-40s %-12s %-8s %-8s %s\n", "TASK", "STATUS", "DIRECT", "TOTAL", "DEPTH")
+		for _, t := range blocking {
+			fmt.Fprintf(w, "  %-40s %-12s %-8d %-8d %d\n", t.FeatureName+"/"+t.Name, t.Status, t.DirectDependents, t.BlockedTasks, t.Depth)
+		}
+	}
+
+	printRefs(w, "Unreachable (waiting on cancelled tasks)", a.Unreachable)
+	printRefs(w, "Orphaned (no dependencies, dependents, parent or subtasks)", a.Orphaned)
+}
+
+func printRefs(w io.Writer, title string, refs []*models.GraphTaskRef) {
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, ref := range refs {
+		fmt.Fprintf(w, "  - %s/%s (%s)\n", ref.FeatureName, ref.Name, ref.Status)
+	}
+}
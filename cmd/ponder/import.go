@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/github"
+	"github.com/nick-dorsch/ponder/internal/plan"
+)
+
+// confirmInput is where import prompts read their answer from.
+var confirmInput io.Reader = os.Stdin
+
+func runImport(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: ponder import <source> [arguments]")
+		fmt.Println("\nSources:")
+		fmt.Println("  github    Import GitHub issues as tasks")
+		fmt.Println("  markdown  Import features and tasks from a Markdown plan (or pass a .md file)")
+		return nil
+	}
+
+	source := args[0]
+	subArgs := args[1:]
+
+	switch source {
+	case "github":
+		return runImportGitHub(subArgs)
+	case "markdown", "md":
+		return runImportMarkdown(subArgs)
+	default:
+		if strings.HasSuffix(strings.ToLower(source), ".md") || strings.HasSuffix(strings.ToLower(source), ".markdown") {
+			return runImportMarkdown(args)
+		}
+		return fmt.Errorf("unknown import source: %s", source)
+	}
+}
+
+func runImportGitHub(args []string) error {
+	fs := flag.NewFlagSet("import github", flag.ContinueOnError)
+	repo := fs.String("repo", "", "Repository in owner/name form")
+	groupBy := fs.String("group-by", "milestone", "Map issues to features by milestone or label")
+	defaultFeature := fs.String("default-feature", "github", "Feature for issues without a milestone or label")
+	includeClosed := fs.Bool("closed", false, "Also import closed issues as completed tasks")
+	sync := fs.Bool("sync", false, "Close open issues whose tasks are completed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *repo == "" || fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder import github --repo owner/name [--group-by milestone|label] [--default-feature name] [--closed] [--sync]")
+	}
+
+	group, err := github.ParseGroupBy(*groupBy)
+	if err != nil {
+		return err
+	}
+
+	token := envOrDefault("GITHUB_TOKEN", os.Getenv("GH_TOKEN"))
+	if *sync && token == "" {
+		return fmt.Errorf("--sync needs a token in GITHUB_TOKEN or GH_TOKEN to close issues")
+	}
+	client := github.NewClient(token)
+	client.BaseURL = envOrDefault("GITHUB_API_URL", github.DefaultBaseURL)
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	importer := &github.Importer{Client: client, DB: database}
+	result, err := importer.Import(ctx, github.ImportOptions{
+		Repo:           *repo,
+		GroupBy:        group,
+		DefaultFeature: *defaultFeature,
+		IncludeClosed:  *includeClosed,
+		Sync:           *sync,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Imported %s: %d created, %d updated, %d completed", *repo, result.Created, result.Updated, result.Completed)
+	if *sync {
+		fmt.Printf(", %d issue(s) closed", result.Closed)
+	}
+	fmt.Println()
+	return nil
+}
+
+func runImportMarkdown(args []string) error {
+	fs := flag.NewFlagSet("import markdown", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "Import without asking for confirmation")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be imported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Allow flags after the file name too: ponder import plan.md --yes.
+	var path string
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	if path == "" || fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder import [markdown] [--dry-run] [--yes] <plan.md>")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open plan: %w", err)
+	}
+	p, err := plan.Parse(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	database.Staging.Discard(cliSession)
+	defer database.Staging.Discard(cliSession)
+	summary, err := plan.Stage(ctx, database, cliSession, p)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	printPlanPreview(path, p, summary)
+
+	problems, err := database.ValidateBatch(ctx, cliSession)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		fmt.Println("\nProblems:")
+		for _, problem := range problems {
+			fmt.Printf("  - %v\n", problem)
+		}
+		return fmt.Errorf("%s has %d problem(s), nothing was imported", path, len(problems))
+	}
+
+	if *dryRun {
+		fmt.Println("\nDry run, nothing was imported.")
+		return nil
+	}
+	if !*yes {
+		fmt.Printf("\nImport %d feature(s) and %d task(s)? [y/N] ", len(summary.NewFeatures), p.Tasks())
+		answer, _ := bufio.NewReader(confirmInput).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Nothing was imported.")
+			return nil
+		}
+	}
+
+	if err := database.CommitBatch(ctx, cliSession); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Imported %d feature(s) and %d task(s) from %s\n", len(summary.NewFeatures), p.Tasks(), path)
+	return nil
+}
+
+func printPlanPreview(path string, p *plan.Plan, summary *plan.Summary) {
+	fmt.Printf("Plan %s:\n", path)
+	for _, f := range p.Features {
+		state := "existing"
+		if summary.NewFeatures[f.Name] {
+			state = "new"
+		}
+		fmt.Printf("\n  %s (%s feature)\n", f.Name, state)
+		for _, t := range f.Tasks {
+			fmt.Printf("    + %s", t.Name)
+			if t.Priority > 0 {
+				fmt.Printf(" (priority %d)", t.Priority)
+			}
+			if len(t.DependsOn) > 0 {
+				refs := make([]string, len(t.DependsOn))
+				for i, ref := range t.DependsOn {
+					refs[i] = ref.Name
+					if ref.FeatureName != "" && ref.FeatureName != f.Name {
+						refs[i] = ref.FeatureName + "/" + ref.Name
+					}
+				}
+				fmt.Printf(" after %s", strings.Join(refs, ", "))
+			}
+			fmt.Println()
+		}
+	}
+}
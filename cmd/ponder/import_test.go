@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportGitHub(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/issues" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"number": 7, "title": "Fix login", "body": "It breaks", "state": "open",
+			"html_url": "https://github.com/o/r/issues/7", "milestone": {"title": "v1"}}]`))
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runImport([]string{"github"}); err == nil {
+		t.Error("expected error without --repo")
+	}
+	if err := runImport([]string{"github", "--repo", "o/r", "--sync"}); err == nil {
+		t.Error("expected error for --sync without a token")
+	}
+	if err := runImport([]string{"jira"}); err == nil {
+		t.Error("expected error for unknown source")
+	}
+	if err := runImport([]string{"github", "--repo", "o/r"}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	ctx := context.Background()
+	database := openTestDB(t, dbFilePath)
+	defer database.Close()
+	v1, _ := database.GetFeatureByName(ctx, "v1")
+	if v1 == nil {
+		t.Fatal("expected v1 feature")
+	}
+	task, _ := database.GetTaskByName(ctx, "#7 Fix login", v1.ID)
+	if task == nil {
+		t.Fatal("expected imported task")
+	}
+	link, _ := database.GetTaskLink(ctx, "github", "o/r#7")
+	if link == nil || link.TaskID != task.ID {
+		t.Errorf("expected task to be linked to the issue, got %+v", link)
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	planPath := filepath.Join(tmpDir, "plan.md")
+	plan := "## billing\nInvoices.\n\n### schema\nAdd tables.\nPriority: 7\n\n" +
+		"### export\nExport PDFs.\nDepends on: schema, feature1/task1\n"
+	if err := os.WriteFile(planPath, []byte(plan), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+	defer func() { confirmInput = os.Stdin }()
+
+	ctx := context.Background()
+	countTasks := func() int {
+		database := openTestDB(t, dbFilePath)
+		defer database.Close()
+		tasks, err := database.ListTasks(ctx, nil, nil)
+		if err != nil {
+			t.Fatalf("ListTasks failed: %v", err)
+		}
+		return len(tasks)
+	}
+	before := countTasks()
+
+	if err := runImport([]string{planPath, "--dry-run"}); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	confirmInput = strings.NewReader("n\n")
+	if err := runImport([]string{"markdown", planPath}); err != nil {
+		t.Fatalf("declined import failed: %v", err)
+	}
+	if got := countTasks(); got != before {
+		t.Fatalf("expected nothing imported, got %d tasks instead of %d", got, before)
+	}
+
+	confirmInput = strings.NewReader("y\n")
+	if err := runImport([]string{"markdown", planPath}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	database := openTestDB(t, dbFilePath)
+	billing, _ := database.GetFeatureByName(ctx, "billing")
+	if billing == nil || billing.Description != "Invoices." {
+		t.Fatalf("expected billing feature, got %+v", billing)
+	}
+	schema, _ := database.GetTaskByName(ctx, "schema", billing.ID)
+	export, _ := database.GetTaskByName(ctx, "export", billing.ID)
+	if schema == nil || schema.Priority != 7 || export == nil {
+		t.Fatalf("expected imported tasks, got %+v and %+v", schema, export)
+	}
+	deps, err := database.GetDependencies(ctx, export.ID)
+	if err != nil || len(deps) != 2 {
+		t.Errorf("expected export to have 2 dependencies, got %d (%v)", len(deps), err)
+	}
+	database.Close()
+
+	// Importing again conflicts with what exists and changes nothing.
+	if err := runImport([]string{planPath, "--yes"}); err == nil {
+		t.Error("expected error importing the same plan twice")
+	}
+	if got := countTasks(); got != before+2 {
+		t.Errorf("expected %d tasks, got %d", before+2, got)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -32,8 +33,8 @@ func TestInit(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to read .gitignore: %v", err)
 	}
-	if string(content) != "ponder.db*\n" {
-		t.Errorf(".gitignore content mismatch: expected 'ponder.db*\\n', got %q", string(content))
+	if string(content) != "ponder.db*\nworktrees/\nbackups/\nlogs/\nsnapshots/\n" {
+		t.Errorf(".gitignore content mismatch: expected 'ponder.db*\\nworktrees/\\nbackups/\\nlogs/\\nsnapshots/\\n', got %q", string(content))
 	}
 
 	dbFilePath := filepath.Join(ponderDir, "ponder.db")
@@ -134,7 +135,81 @@ func TestInitOverwritesGitignore(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to read .gitignore: %v", err)
 	}
-	if string(content) != "ponder.db*\n" {
-		t.Errorf(".gitignore was not overwritten: expected 'ponder.db*\\n', got %q", string(content))
+	if string(content) != "ponder.db*\nworktrees/\nbackups/\nlogs/\nsnapshots/\n" {
+		t.Errorf(".gitignore was not overwritten: expected 'ponder.db*\\nworktrees/\\nbackups/\\nlogs/\\nsnapshots/\\n', got %q", string(content))
+	}
+}
+
+func TestInitWithTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dbPath = ".ponder/ponder.db"
+	snapshotPath = ".ponder/snapshot.jsonl"
+
+	if err := runInit([]string{"--template", "webapp", tmpDir}); err != nil {
+		t.Fatalf("runInit failed: %v", err)
+	}
+
+	database := openTestDB(t, filepath.Join(tmpDir, ".ponder", "ponder.db"))
+	ctx := context.Background()
+	for _, name := range []string{"misc", "setup", "backend", "frontend"} {
+		f, err := database.GetFeatureByName(ctx, name)
+		if err != nil || f == nil {
+			t.Fatalf("expected feature %s, got %v, %v", name, f, err)
+		}
+	}
+	tasks, err := database.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 7 {
+		t.Errorf("expected 7 seeded tasks, got %d", len(tasks))
+	}
+	// Only the first task has nothing to wait for.
+	ready, err := database.GetAvailableTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAvailableTasks failed: %v", err)
+	}
+	if len(ready) != 1 || ready[0].Name != "scaffold-project" {
+		t.Errorf("expected only scaffold-project to be available, got %v", ready)
+	}
+
+	if err := runInit([]string{"--template", "nope", t.TempDir()}); err == nil {
+		t.Error("expected an unknown template to fail")
+	}
+}
+
+func TestInitWithUserTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	dbPath = ".ponder/ponder.db"
+	snapshotPath = ".ponder/snapshot.jsonl"
+
+	templateDir := filepath.Join(configHome, "ponder", "templates")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"record_type":"feature","name":"ops","description":"Operations","specification":"Keep it running"}
+{"record_type":"task","feature_name":"ops","name":"monitoring","description":"Add monitoring","specification":"Alert on errors","priority":5}
+`
+	if err := os.WriteFile(filepath.Join(templateDir, "ops.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runInit([]string{"--template", "ops", tmpDir}); err != nil {
+		t.Fatalf("runInit failed: %v", err)
+	}
+
+	database := openTestDB(t, filepath.Join(tmpDir, ".ponder", "ponder.db"))
+	f, err := database.GetFeatureByName(context.Background(), "ops")
+	if err != nil || f == nil {
+		t.Fatalf("expected feature ops, got %v, %v", f, err)
+	}
+	task, err := database.GetTaskByName(context.Background(), "monitoring", f.ID)
+	if err != nil || task == nil || task.Priority != 5 {
+		t.Fatalf("expected task monitoring with priority 5, got %v, %v", task, err)
 	}
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func runLabel(args []string) error {
+	labelFlags := flag.NewFlagSet("label", flag.ContinueOnError)
+	featureFilter := labelFlags.String("feature", "", "Feature the task belongs to")
+	remove := labelFlags.Bool("remove", false, "Remove the labels instead of adding them")
+	if err := labelFlags.Parse(args); err != nil {
+		return err
+	}
+	if labelFlags.NArg() < 1 {
+		return fmt.Errorf("usage: ponder label [--feature name] [--remove] <task> [label...]")
+	}
+	taskName := labelFlags.Arg(0)
+	labels := labelFlags.Args()[1:]
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := actor.With(context.Background(), "cli")
+	task, err := findTaskByName(ctx, database, *featureFilter, taskName)
+	if err != nil {
+		return err
+	}
+
+	if len(labels) > 0 {
+		exportSnapshotOnChange(database)
+		if *remove {
+			err = database.RemoveTaskLabels(ctx, task.ID, labels...)
+		} else {
+			err = database.AddTaskLabels(ctx, task.ID, labels...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	current, err := database.ListTaskLabels(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if len(current) == 0 {
+		fmt.Printf("No labels on %s/%s\n", task.FeatureName, task.Name)
+		return nil
+	}
+	fmt.Printf("%s/%s: %s\n", task.FeatureName, task.Name, strings.Join(current, ", "))
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+)
+
+// defaultLogKeep is how many run logs are kept per task when logs does not
+// say.
+const defaultLogKeep = 5
+
+// runLogs is where agent output is logged, as configured in config.json.
+var runLogs orchestrator.RunLogs
+
+type logsConfig struct {
+	Dir    string `json:"dir,omitempty"`
+	Keep   *int   `json:"keep,omitempty"`
+	MaxAge string `json:"max_age,omitempty"`
+}
+
+// defaultRunLogs writes logs to .ponder/logs and keeps five per task.
+func defaultRunLogs() orchestrator.RunLogs {
+	return orchestrator.RunLogs{Dir: filepath.Join(configDir(), "logs"), Keep: defaultLogKeep}
+}
+
+// parse fills in the defaults of defaultRunLogs. A keep of 0 turns logging
+// off.
+func (lc *logsConfig) parse() (orchestrator.RunLogs, error) {
+	l := defaultRunLogs()
+	if lc.Dir != "" {
+		l.Dir = lc.Dir
+	}
+	if lc.Keep != nil {
+		if *lc.Keep < 0 {
+			return orchestrator.RunLogs{}, fmt.Errorf("keep must be >= 0")
+		}
+		l.Keep = *lc.Keep
+	}
+	if lc.MaxAge != "" {
+		d, err := time.ParseDuration(lc.MaxAge)
+		if err != nil {
+			return orchestrator.RunLogs{}, fmt.Errorf("max_age: %w", err)
+		}
+		if d < 0 {
+			return orchestrator.RunLogs{}, fmt.Errorf("max_age must be >= 0")
+		}
+		l.MaxAge = d
+	}
+	return l, nil
+}
+
+func runLogsCommand(args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	featureFilter := fs.String("feature", "", "Feature the task belongs to")
+	list := fs.Bool("list", false, "List the task's logs instead of printing the latest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder logs [--feature name] [--list] <task>")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	task, err := findTaskByName(context.Background(), database, *featureFilter, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	logs, err := orchestrator.ListRunLogs(runLogs.Dir, task.ID)
+	if err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return fmt.Errorf("no logs for %s/%s in %s", task.FeatureName, task.Name, runLogs.Dir)
+	}
+
+	if *list {
+		// Newest first, matching the order runs are usually looked at.
+		for i := len(logs) - 1; i >= 0; i-- {
+			info, err := os.Stat(logs[i])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s  %8d bytes  %s\n", info.ModTime().Local().Format("2006-01-02 15:04:05"), info.Size(), logs[i])
+		}
+		return nil
+	}
+
+	f, err := os.Open(logs[len(logs)-1])
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+	_, err = io.Copy(os.Stdout, f)
+	return err
+}
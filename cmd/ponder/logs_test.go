@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+)
+
+func TestLoadWorkDefaultsParsesLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	ponderDir := filepath.Join(tmpDir, ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+	dbPath = filepath.Join(ponderDir, "ponder.db")
+
+	defaults, err := loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	want := orchestrator.RunLogs{Dir: filepath.Join(ponderDir, "logs"), Keep: defaultLogKeep}
+	if defaults.RunLogs != want {
+		t.Errorf("expected logs on by default with %+v, got %+v", want, defaults.RunLogs)
+	}
+
+	configPath := filepath.Join(ponderDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"logs": {"keep": 2, "max_age": "72h"}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	defaults, err = loadWorkDefaults()
+	if err != nil {
+		t.Fatalf("loadWorkDefaults failed: %v", err)
+	}
+	want = orchestrator.RunLogs{Dir: filepath.Join(ponderDir, "logs"), Keep: 2, MaxAge: 72 * time.Hour}
+	if defaults.RunLogs != want {
+		t.Errorf("expected %+v, got %+v", want, defaults.RunLogs)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"logs": {"max_age": "soon"}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := loadWorkDefaults(); err == nil {
+		t.Errorf("expected error for invalid max_age")
+	}
+}
+
+func TestLogsCommand(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	task, err := findTaskByName(context.Background(), database, "feature1", "task1")
+	database.Close()
+	if err != nil {
+		t.Fatalf("failed to find task: %v", err)
+	}
+
+	runLogs = orchestrator.RunLogs{Dir: filepath.Join(tmpDir, ".ponder", "logs"), Keep: 5}
+	defer func() { runLogs = orchestrator.RunLogs{} }()
+	if err := runLogsCommand([]string{"task1"}); err == nil {
+		t.Errorf("expected error when the task has no logs")
+	}
+
+	now := time.Now()
+	for i, content := range []string{"first run", "second run"} {
+		f, err := runLogs.Create(task, now.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("failed to create log: %v", err)
+		}
+		f.WriteString(content)
+		f.Close()
+	}
+
+	run := func(args ...string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := runLogsCommand(args)
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("logs %v failed: %v", args, err)
+		}
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		return buf.String()
+	}
+
+	if out := run("--feature", "feature1", "task1"); out != "second run" {
+		t.Errorf("expected the latest log, got %q", out)
+	}
+	out := run("--list", "task1")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || !strings.Contains(lines[0], now.Add(time.Second).UTC().Format("20060102T150405")) {
+		t.Errorf("expected 2 logs, newest first, got %q", out)
+	}
+}
@@ -7,17 +7,24 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/nick-dorsch/ponder/internal/actor"
 	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/export"
 	"github.com/nick-dorsch/ponder/internal/mcp"
 	"github.com/nick-dorsch/ponder/internal/orchestrator"
 	"github.com/nick-dorsch/ponder/internal/server"
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
@@ -33,19 +40,240 @@ const (
 )
 
 type workConfig struct {
-	Model           *string  `json:"model"`
-	MaxConcurrency  *int     `json:"max_concurrency"`
-	AvailableModels []string `json:"available_models"`
+	Model           *string       `json:"model"`
+	MaxConcurrency  *int          `json:"max_concurrency"`
+	AvailableModels []string      `json:"available_models"`
+	Retry           *retryConfig  `json:"retry,omitempty"`
+	Worktrees       *bool         `json:"worktrees,omitempty"`
+	Verification    *verifyConfig `json:"verification,omitempty"`
+	// Pricing maps a model to its USD price per million tokens, used to
+	// estimate costs when the agent reports tokens but no cost.
+	Pricing map[string]priceConfig `json:"pricing,omitempty"`
+	// PriorityAging bumps the claim order of tasks that wait a long time.
+	PriorityAging *agingConfig `json:"priority_aging,omitempty"`
+	// Availability decides whether blocked and cancelled dependencies hold
+	// tasks back for good or only lower their priority.
+	Availability *availabilityConfig `json:"availability,omitempty"`
+	// MaxTaskDuration kills an agent that runs longer than this on one task.
+	MaxTaskDuration string `json:"max_task_duration,omitempty"`
+	// MaxTaskDurationOverrides maps "feature/task" to a task-specific limit.
+	MaxTaskDurationOverrides map[string]string `json:"max_task_duration_overrides,omitempty"`
+	// StallDetection flags (and optionally kills) agents that go quiet.
+	StallDetection *stallConfig `json:"stall_detection,omitempty"`
+	// AutoBackup backs up the database before snapshot imports and archiving.
+	AutoBackup *backupConfig `json:"auto_backup,omitempty"`
+	// Logs keeps each agent run's full output in a file.
+	Logs *logsConfig `json:"logs,omitempty"`
+	// ClaimLease is how long a claimed task stays reserved for its worker
+	// without being renewed.
+	ClaimLease string `json:"claim_lease,omitempty"`
+	// ModelRouting picks the model from the task's priority, e.g.
+	// {"priority>=8": "opencode/gpt-5", "default": "opencode/gemini-3-flash"}.
+	ModelRouting map[string]string `json:"model_routing,omitempty"`
+	// SnapshotDebounce coalesces writes within this window into one
+	// snapshot export.
+	SnapshotDebounce string `json:"snapshot_debounce,omitempty"`
+	// WebAuthToken is required as a bearer token (or cookie) by the web UI
+	// and REST API. PONDER_WEB_AUTH_TOKEN overrides it.
+	WebAuthToken string `json:"web_auth_token,omitempty"`
+	// EventHistory keeps recent orchestrator events for the TUI to replay
+	// into worker views, optionally writing them to a file too.
+	EventHistory *historyConfig `json:"event_history,omitempty"`
+	// SnapshotHistory keeps the last few exported snapshots, for
+	// `ponder snapshot restore`.
+	SnapshotHistory *snapshotHistoryConfig `json:"snapshot_history,omitempty"`
+	// ModelFallback retries a task that keeps failing with the next of the
+	// available models before blocking it.
+	ModelFallback *fallbackConfig `json:"model_fallback,omitempty"`
+	// Budget is the most a session may spend, in USD, before the
+	// orchestrator stops claiming tasks.
+	Budget *float64 `json:"budget,omitempty"`
+	// ResourceLimits lowers the priority of agent commands and caps their
+	// memory and processes.
+	ResourceLimits *limitsConfig `json:"resource_limits,omitempty"`
+	// Sandbox runs agents in a container with the repository mounted.
+	Sandbox *sandboxConfig `json:"sandbox,omitempty"`
+	// ClaimFilter limits the tasks this instance claims, e.g. to one
+	// feature or label.
+	ClaimFilter *claimFilterConfig `json:"claim_filter,omitempty"`
+	// RateLimits caps agent launches per minute by provider or model, e.g.
+	// {"opencode": 30, "opencode/gpt-5": 6}.
+	RateLimits map[string]float64 `json:"rate_limits,omitempty"`
+	// Notifications posts completed and blocked tasks and finished runs to
+	// Slack, Discord or any webhook.
+	Notifications []notifierConfig `json:"notifications,omitempty"`
+	// Theme colors the terminal UIs, e.g. {"name": "light"} on a terminal
+	// with a light background.
+	Theme *themeConfig `json:"theme,omitempty"`
+}
+
+type notifierConfig struct {
+	Type string `json:"type"`
+	// URL may name environment variables, e.g. "$SLACK_WEBHOOK_URL", so the
+	// webhook's secret needn't be committed.
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+}
+
+type claimFilterConfig struct {
+	Features    []string `json:"features,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	MinPriority int      `json:"min_priority,omitempty"`
+}
+
+type sandboxConfig struct {
+	Runtime string            `json:"runtime,omitempty"`
+	Image   string            `json:"image"`
+	User    string            `json:"user,omitempty"`
+	Binds   []string          `json:"binds,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+}
+
+type limitsConfig struct {
+	Nice int `json:"nice,omitempty"`
+	// MemoryMax is a byte count with an optional K, M or G suffix.
+	MemoryMax    string `json:"memory_max,omitempty"`
+	MaxProcesses int    `json:"max_processes,omitempty"`
+}
+
+type stallConfig struct {
+	Window string `json:"window"`
+	Kill   bool   `json:"kill,omitempty"`
+}
+
+type fallbackConfig struct {
+	AfterFailures *int `json:"after_failures,omitempty"`
+}
+
+type historyConfig struct {
+	Size *int   `json:"size,omitempty"`
+	File string `json:"file,omitempty"`
+}
+
+// eventHistory is how many orchestrator events are kept in memory, and the
+// file they are all written to, if any.
+type eventHistory struct {
+	Size int
+	File string
+}
+
+type agingConfig struct {
+	Interval string `json:"interval"`
+	Step     *int   `json:"step,omitempty"`
+	MaxBoost int    `json:"max_boost,omitempty"`
+}
+
+type priceConfig struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+type verifyConfig struct {
+	Command string `json:"command"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+type retryConfig struct {
+	MaxAttempts    *int     `json:"max_attempts"`
+	InitialBackoff *string  `json:"initial_backoff"`
+	MaxBackoff     *string  `json:"max_backoff"`
+	Multiplier     *float64 `json:"multiplier"`
+	Jitter         *float64 `json:"jitter"`
 }
 
 type workDefaults struct {
-	Model           string
+	Model            string
+	MaxConcurrency   int
+	AvailableModels  []string
+	RetryPolicy      orchestrator.RetryPolicy
+	Worktrees        bool
+	Verification     *orchestrator.Verification
+	Pricing          map[string]orchestrator.ModelPrice
+	PriorityAging    db.PriorityAging
+	Availability     db.AvailabilityPolicy
+	TaskTimeouts     orchestrator.TaskTimeouts
+	StallDetection   orchestrator.StallDetection
+	AutoBackup       db.AutoBackup
+	RunLogs          orchestrator.RunLogs
+	ClaimLease       time.Duration
+	ModelRouting     orchestrator.ModelRouting
+	SnapshotDebounce time.Duration
+	WebAuthToken     string
+	EventHistory     eventHistory
+	SnapshotHistory  db.SnapshotHistory
+	ModelFallback    orchestrator.ModelFallback
+	Budget           float64
+	ResourceLimits   orchestrator.ResourceLimits
+	Sandbox          *orchestrator.Sandbox
+	ClaimFilter      db.ClaimFilter
+	RateLimits       orchestrator.RateLimits
+	Notifiers        []orchestrator.Notifier
+	Theme            theme.Theme
+}
+
+type workOptions struct {
 	MaxConcurrency  int
+	Model           string
 	AvailableModels []string
+	Interval        time.Duration
+	EnableWeb       bool
+	WebHost         string
+	WebPort         string
+	RetryPolicy     orchestrator.RetryPolicy
+	Worktrees       bool
+	Verification    *orchestrator.Verification
+	Pricing         map[string]orchestrator.ModelPrice
+	PriorityAging   db.PriorityAging
+	TaskTimeouts    orchestrator.TaskTimeouts
+	StallDetection  orchestrator.StallDetection
+	RunLogs         orchestrator.RunLogs
+	ClaimLease      time.Duration
+	ModelRouting    orchestrator.ModelRouting
+	ModelFallback   orchestrator.ModelFallback
+	Budget          float64
+	ResourceLimits  orchestrator.ResourceLimits
+	Sandbox         *orchestrator.Sandbox
+	ClaimFilter     db.ClaimFilter
+	RateLimits      orchestrator.RateLimits
+	Notifiers       []orchestrator.Notifier
+	EventHistory    eventHistory
+	NoTUI           bool
+	LogFormat       orchestrator.LogFormat
+	LogFile         string
+	DryRun          bool
 }
 
 var runOrchestrator = runOrchestratorCommon
 
+// workOptions returns the options for running the orchestrator with these
+// settings, before flags override them.
+func (d workDefaults) workOptions() workOptions {
+	return workOptions{
+		MaxConcurrency:  d.MaxConcurrency,
+		Model:           d.Model,
+		AvailableModels: d.AvailableModels,
+		RetryPolicy:     d.RetryPolicy,
+		Worktrees:       d.Worktrees,
+		Verification:    d.Verification,
+		Pricing:         d.Pricing,
+		PriorityAging:   d.PriorityAging,
+		TaskTimeouts:    d.TaskTimeouts,
+		StallDetection:  d.StallDetection,
+		RunLogs:         d.RunLogs,
+		ClaimLease:      d.ClaimLease,
+		ModelRouting:    d.ModelRouting,
+		ModelFallback:   d.ModelFallback,
+		Budget:          d.Budget,
+		ResourceLimits:  d.ResourceLimits,
+		Sandbox:         d.Sandbox,
+		ClaimFilter:     d.ClaimFilter,
+		RateLimits:      d.RateLimits,
+		Notifiers:       d.Notifiers,
+		EventHistory:    d.EventHistory,
+	}
+}
+
 func main() {
 	err := execute(os.Args[1:], os.Stderr)
 	if err != nil {
@@ -60,14 +288,22 @@ func main() {
 func execute(args []string, stderr io.Writer) error {
 	rootFlags := flag.NewFlagSet("ponder", flag.ContinueOnError)
 	rootFlags.SetOutput(stderr)
-	rootFlags.StringVar(&dbPath, "db-path", ".ponder/ponder.db", "Path to database file")
-	rootFlags.StringVar(&snapshotPath, "snapshot-path", ".ponder/snapshot.jsonl", "Path to snapshot file")
+	rootFlags.StringVar(&dbPath, "db-path", envOrDefault("PONDER_DB_PATH", ".ponder/ponder.db"), "Path to database file, or a postgres:// DSN to share one database (env PONDER_DB_PATH)")
+	rootFlags.StringVar(&snapshotPath, "snapshot-path", envOrDefault("PONDER_SNAPSHOT_PATH", ".ponder/snapshot.jsonl"), "Path to snapshot file (env PONDER_SNAPSHOT_PATH)")
 	rootFlags.BoolVar(&verbose, "verbose", false, "Enable verbose logging")
 	maxConcurrency := rootFlags.Int("max_concurrency", defaultWorkMaxConcurrency, "Maximum number of concurrent workers")
 	model := rootFlags.String("model", defaultWorkModel, "Model to use for workers")
 	interval := rootFlags.Duration("interval", 5*time.Second, "Polling interval when idle (0 to exit)")
 	enableWeb := rootFlags.Bool("web", true, "Enable web UI")
+	webHost := rootFlags.String("host", defaultWebHost, "Address for web UI to listen on (0.0.0.0 for all interfaces)")
 	webPort := rootFlags.String("port", "8000", "Port for web UI")
+	worktrees := rootFlags.Bool("worktrees", false, "Run each task in its own git worktree and branch")
+	verify := rootFlags.String("verify", "", "Command that must pass after each task (e.g. \"go test ./...\")")
+	noTUI := rootFlags.Bool("no-tui", false, "Run unattended, logging events instead of showing the TUI")
+	logFormat := rootFlags.String("log-format", "text", "Event log format with -no-tui or -dry-run (text or json)")
+	logFile := rootFlags.String("log-file", "", "Write the -no-tui event log or -dry-run plan to a file instead of stdout")
+	dryRun := rootFlags.Bool("dry-run", false, "Print the order tasks would be claimed in, with their models and prompts, without running any agents")
+	budget := rootFlags.Float64("budget", 0, "Stop claiming tasks once this session has spent more than this many USD (0 = no limit)")
 	rootFlags.Usage = func() {
 		printRootUsage(stderr, rootFlags)
 	}
@@ -76,10 +312,36 @@ func execute(args []string, stderr io.Writer) error {
 		return err
 	}
 
+	// config runs before the config file is loaded, so it can repair an
+	// invalid one.
+	if rootFlags.Arg(0) == "config" {
+		return runConfig(rootFlags.Args()[1:])
+	}
+	// So does completion, which must not fail or print warnings in the shell.
+	switch rootFlags.Arg(0) {
+	case "completion":
+		return runCompletion(rootFlags.Args()[1:])
+	case "__complete":
+		return runShellComplete(rootFlags, rootFlags.Args()[1:])
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background())
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: tracing disabled: %v\n", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	defaults, err := loadWorkDefaults()
 	if err != nil {
 		return err
 	}
+	autoBackup = defaults.AutoBackup
+	availabilityPolicy = defaults.Availability
+	runLogs = defaults.RunLogs
+	snapshotDebounce = defaults.SnapshotDebounce
+	webAuthToken = defaults.WebAuthToken
+	snapshotHistory = defaults.SnapshotHistory
+	theme.Use(defaults.Theme)
 
 	if !flagProvided(rootFlags, "max_concurrency") {
 		*maxConcurrency = defaults.MaxConcurrency
@@ -87,9 +349,42 @@ func execute(args []string, stderr io.Writer) error {
 	if !flagProvided(rootFlags, "model") {
 		*model = defaults.Model
 	}
+	if !flagProvided(rootFlags, "worktrees") {
+		*worktrees = defaults.Worktrees
+	}
+	if !flagProvided(rootFlags, "budget") {
+		*budget = defaults.Budget
+	} else if *budget < 0 {
+		return fmt.Errorf("invalid -budget: must be >= 0")
+	}
+	verification := defaults.Verification
+	if flagProvided(rootFlags, "verify") {
+		verification = &orchestrator.Verification{Command: *verify}
+		if defaults.Verification != nil {
+			verification.Timeout = defaults.Verification.Timeout
+		}
+	}
 
 	if rootFlags.NArg() == 0 {
-		return runOrchestrator(*maxConcurrency, *model, defaults.AvailableModels, *interval, *enableWeb, *webPort)
+		format, err := orchestrator.ParseLogFormat(*logFormat)
+		if err != nil {
+			return err
+		}
+		opts := defaults.workOptions()
+		opts.MaxConcurrency = *maxConcurrency
+		opts.Model = *model
+		opts.Interval = *interval
+		opts.EnableWeb = *enableWeb
+		opts.WebHost = *webHost
+		opts.WebPort = *webPort
+		opts.Worktrees = *worktrees
+		opts.Verification = verification
+		opts.Budget = *budget
+		opts.NoTUI = *noTUI
+		opts.LogFormat = format
+		opts.LogFile = *logFile
+		opts.DryRun = *dryRun
+		return runOrchestrator(opts)
 	}
 
 	command := rootFlags.Arg(0)
@@ -108,13 +403,60 @@ func execute(args []string, stderr io.Writer) error {
 		return runStatus(commandArgs)
 	case "web":
 		return runWeb(commandArgs)
+	case "serve":
+		return runServe(commandArgs)
 	case "db":
 		return runDB(commandArgs)
+	case "export":
+		return runExport(commandArgs)
+	case "import":
+		return runImport(commandArgs)
+	case "graph":
+		return runGraph(commandArgs)
+	case "snapshot":
+		return runSnapshot(commandArgs)
+	case "history":
+		return runHistory(commandArgs)
+	case "label":
+		return runLabel(commandArgs)
+	case "note":
+		return runNote(commandArgs)
+	case "logs":
+		return runLogsCommand(commandArgs)
+	case "env":
+		return runEnv(commandArgs)
+	case "watch":
+		return runWatch(commandArgs)
+	case "add-feature":
+		return runAddFeature(commandArgs)
+	case "add-task":
+		return runAddTask(commandArgs)
+	case "complete":
+		return runComplete(commandArgs)
+	case "block":
+		return runBlock(commandArgs)
+	case "estimate":
+		return runEstimate(commandArgs)
+	case "rm":
+		return runRemove(commandArgs)
+	case "archive":
+		return runArchive(commandArgs)
+	case "template":
+		return runTemplate(commandArgs)
+	case "report":
+		return runReport(commandArgs)
 	default:
 		return fmt.Errorf("unknown command: %s", command)
 	}
 }
 
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 func flagProvided(fs *flag.FlagSet, name string) bool {
 	provided := false
 	fs.Visit(func(f *flag.Flag) {
@@ -134,21 +476,59 @@ func printRootUsage(w io.Writer, rootFlags *flag.FlagSet) {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Commands:")
 	fmt.Fprintln(w, "  init          Initialize Ponder in a directory")
-	fmt.Fprintln(w, "  mcp           Start MCP server")
+	fmt.Fprintln(w, "  mcp           Start MCP server (stdio, or --http addr)")
 	fmt.Fprintln(w, "  list-features List all features")
 	fmt.Fprintln(w, "  list-tasks    List all tasks")
 	fmt.Fprintln(w, "  status        Show project status")
+	fmt.Fprintln(w, "  watch         Follow task status changes as they happen")
+	fmt.Fprintln(w, "  add-feature   Create a feature")
+	fmt.Fprintln(w, "  add-task      Create a task, optionally with dependencies")
+	fmt.Fprintln(w, "  complete      Mark a task completed")
+	fmt.Fprintln(w, "  block         Mark a task blocked with a reason")
+	fmt.Fprintln(w, "  estimate      Set how long a task should take")
+	fmt.Fprintln(w, "  rm            Remove a task or feature")
+	fmt.Fprintln(w, "  archive       Move old completed tasks out of the live tables")
+	fmt.Fprintln(w, "  template      Create tasks from saved, optionally recurring, templates")
 	fmt.Fprintln(w, "  web           Start web server")
-	fmt.Fprintln(w, "  db            Database commands")
+	fmt.Fprintln(w, "  serve         Run the web UI, MCP over HTTP and optionally agents as one daemon")
+	fmt.Fprintln(w, "  config        Get, set or list settings in config.json")
+	fmt.Fprintln(w, "  db            Database status, backup and restore")
+	fmt.Fprintln(w, "  export        Export the plan as Markdown, CSV, or JSON")
+	fmt.Fprintln(w, "  import        Import tasks from GitHub issues")
+	fmt.Fprintln(w, "  graph         Render the dependency graph as Mermaid or DOT, or analyze it")
+	fmt.Fprintln(w, "  snapshot      Export or merge snapshot files (git merge driver)")
+	fmt.Fprintln(w, "  history       Show the change history of a task")
+	fmt.Fprintln(w, "  note          Add or list notes on a task")
+	fmt.Fprintln(w, "  label         Add, remove or list a task's labels")
+	fmt.Fprintln(w, "  logs          Show the agent output of a task's runs")
+	fmt.Fprintln(w, "  report        Report task durations, burndown against estimates, a changelog, or run sessions")
+	fmt.Fprintln(w, "  env           Show or set the directory and environment agents run with")
+	fmt.Fprintln(w, "  completion    Print a bash, zsh or fish completion script")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Flags:")
 	rootFlags.PrintDefaults()
 }
 
 func runInit(args []string) error {
+	initFlags := flag.NewFlagSet("init", flag.ContinueOnError)
+	template := initFlags.String("template", "", "Seed starter features and tasks from a template ("+strings.Join(starterNames(), ", ")+", or a .jsonl file)")
+	if err := initFlags.Parse(args); err != nil {
+		return err
+	}
+	if initFlags.NArg() > 1 {
+		return fmt.Errorf("usage: ponder init [--template name] [directory]")
+	}
 	targetDir := "."
-	if len(args) > 0 {
-		targetDir = args[0]
+	if initFlags.NArg() > 0 {
+		targetDir = initFlags.Arg(0)
+	}
+
+	var starter *starterTemplate
+	if *template != "" {
+		var err error
+		if starter, err = loadStarter(*template); err != nil {
+			return err
+		}
 	}
 
 	ponderDir := filepath.Join(targetDir, ".ponder")
@@ -158,7 +538,7 @@ func runInit(args []string) error {
 	fmt.Println("✓ Created .ponder/ directory")
 
 	gitignorePath := filepath.Join(ponderDir, ".gitignore")
-	if err := os.WriteFile(gitignorePath, []byte("ponder.db*\n"), 0644); err != nil {
+	if err := os.WriteFile(gitignorePath, []byte("ponder.db*\nworktrees/\nbackups/\nlogs/\nsnapshots/\n"), 0644); err != nil {
 		return fmt.Errorf("failed to create .gitignore: %w", err)
 	}
 	fmt.Println("✓ Created .ponder/.gitignore")
@@ -186,17 +566,25 @@ func runInit(args []string) error {
 		return err
 	}
 	defer database.Close()
+	database.SetAutoBackup(autoBackup)
 
-	ctx := context.Background()
+	ctx := actor.With(context.Background(), "cli")
 	if err := database.Init(ctx); err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	fmt.Printf("✓ Initialized database at %s\n", finalDbPath)
 
 	if _, err := os.Stat(finalSnapshotPath); err == nil {
-		if err := database.ImportSnapshot(ctx, finalSnapshotPath); err != nil {
+		if starter != nil {
+			return fmt.Errorf("--template can't be used with an existing snapshot (%s)", finalSnapshotPath)
+		}
+		result, err := database.ImportSnapshotWithOptions(ctx, finalSnapshotPath, db.ImportOptions{})
+		if err != nil {
 			return fmt.Errorf("failed to import snapshot: %w", err)
 		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
 		fmt.Printf("✓ Imported snapshot from %s\n", finalSnapshotPath)
 	} else {
 		feature := &models.Feature{
@@ -213,6 +601,13 @@ func runInit(args []string) error {
 			}
 			fmt.Println("✓ Seeded default 'misc' feature")
 		}
+		if starter != nil {
+			features, tasks, err := seedStarter(ctx, database, starter)
+			if err != nil {
+				return fmt.Errorf("failed to seed template %s: %w", *template, err)
+			}
+			fmt.Printf("✓ Seeded %d features and %d tasks from template %s\n", features, tasks, *template)
+		}
 	}
 
 	fmt.Println("✓ Ponder initialized successfully")
@@ -220,6 +615,15 @@ func runInit(args []string) error {
 }
 
 func runMCP(args []string) error {
+	mcpFlags := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	httpAddr := mcpFlags.String("http", "", "Serve over HTTP on this address instead of stdio, 127.0.0.1 unless it names a host (e.g. :3920)")
+	readOnly := mcpFlags.Bool("read-only", false, "Refuse every tool that changes tasks or features")
+	stagingTTL := mcpFlags.Duration("staging-ttl", db.DefaultStagingTTL, "Drop staged changes left uncommitted this long (0 keeps them)")
+	notifyInterval := mcpFlags.Duration("notify-interval", mcp.DefaultNotifyInterval, "How often to check for changes by other processes to notify clients of (0 only notifies of this server's own)")
+	if err := mcpFlags.Parse(args); err != nil {
+		return err
+	}
+
 	database, err := db.Open(dbPath)
 	if err != nil {
 		return err
@@ -230,20 +634,46 @@ func runMCP(args []string) error {
 	if err := database.Init(ctx); err != nil {
 		return err
 	}
+	database.SetAvailabilityPolicy(availabilityPolicy)
 
-	database.SetOnChange(func(ctx context.Context) {
-		if err := database.ExportSnapshot(ctx, snapshotPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error exporting snapshot: %v\n", err)
-		}
+	exportSnapshotOnChange(database)
+
+	// Staged changes survive restarts, so a crashed session keeps its plan.
+	err = database.PersistStaging(ctx, func(err error) {
+		fmt.Fprintf(os.Stderr, "Error saving staged changes: %v\n", err)
 	})
+	if err != nil {
+		return err
+	}
+	go database.RunStagingCleanup(ctx, *stagingTTL)
+
+	newServer := mcp.NewServer
+	if *readOnly {
+		newServer = mcp.NewReadOnlyServer
+	}
+	s := newServer(database)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go mcp.NotifyChanges(ctx, s, database, *notifyInterval)
+
+	if *httpAddr == "" {
+		return mcp.Serve(s)
+	}
 
-	s := mcp.NewServer(database)
-	return mcp.Serve(s)
+	hs, err := mcpHTTPServer(*httpAddr, mcp.NewHTTPHandler(s), *readOnly)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Serving MCP on %s (streamable HTTP at /mcp, SSE at /sse)\n", hs.Addr)
+	return runHTTPServer(ctx, hs)
 }
 
 func runWeb(args []string) error {
 	webFlags := flag.NewFlagSet("web", flag.ContinueOnError)
+	host := webFlags.String("host", defaultWebHost, "Address to listen on (0.0.0.0 for all interfaces)")
 	port := webFlags.String("port", "8000", "Port to listen on")
+	readOnly := webFlags.Bool("read-only", false, "Refuse every API request that changes something")
 	if err := webFlags.Parse(args); err != nil {
 		return err
 	}
@@ -258,16 +688,30 @@ func runWeb(args []string) error {
 	if err := database.Init(ctx); err != nil {
 		return err
 	}
+	database.SetAvailabilityPolicy(availabilityPolicy)
 
 	srv := server.NewServer(database)
-	return srv.Start(fmt.Sprintf(":%s", *port))
+	srv.SetAuthToken(webAuthToken)
+	srv.SetReadOnly(*readOnly)
+	if webAuthToken == "" && !*readOnly && !isLoopback(*host) {
+		fmt.Fprintf(os.Stderr, "Warning: serving on %s without web_auth_token; anyone who can reach it can change tasks\n", *host)
+	}
+	fmt.Printf("Serving web UI at %s\n", webURL(*host, *port))
+	return srv.Start(net.JoinHostPort(*host, *port))
 }
 
 func runDB(args []string) error {
 	if len(args) == 0 {
 		fmt.Println("Usage: ponder db <command> [arguments]")
 		fmt.Println("\nCommands:")
-		fmt.Println("  status    Show database status")
+		fmt.Println("  status           Show database status")
+		fmt.Println("  backup <path>    Write a consistent copy of the database (SQLite only)")
+		fmt.Println("  restore <path>   Replace the database with a backup; stop other ponder processes first")
+		fmt.Println("  version          Show the schema version and which migrations have run")
+		fmt.Println("  migrate          Apply pending migrations (other commands do so when they open the database)")
+		fmt.Println("  vacuum           Rebuild the database file to reclaim space from deleted rows (SQLite only)")
+		fmt.Println("  integrity-check  Check the database file and its foreign keys for corruption (SQLite only)")
+		fmt.Println("  checkpoint       Copy the write-ahead log into the database file (SQLite only)")
 		return nil
 	}
 
@@ -277,12 +721,261 @@ func runDB(args []string) error {
 	switch command {
 	case "status":
 		return runStatus(subArgs)
+	case "backup":
+		return runDBBackup(subArgs)
+	case "restore":
+		return runDBRestore(subArgs)
+	case "version":
+		return runDBVersion(subArgs)
+	case "migrate":
+		return runDBMigrate(subArgs)
+	case "vacuum":
+		return runDBVacuum(subArgs)
+	case "integrity-check":
+		return runDBIntegrityCheck(subArgs)
+	case "checkpoint":
+		return runDBCheckpoint(subArgs)
 	default:
 		return fmt.Errorf("unknown db command: %s", command)
 	}
 }
 
+func runExport(args []string) error {
+	exportFlags := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatFlag := exportFlags.String("format", "md", "Output format (md, csv, json)")
+	featureFilter := exportFlags.String("feature", "", "Only export the named feature")
+	output := exportFlags.String("output", "", "Write to file instead of stdout")
+	if err := exportFlags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	plan, err := export.Build(ctx, database, *featureFilter)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return export.Write(os.Stdout, format, plan)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	if err := export.Write(file, format, plan); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return file.Close()
+}
+
+func runHistory(args []string) error {
+	historyFlags := flag.NewFlagSet("history", flag.ContinueOnError)
+	featureFilter := historyFlags.String("feature", "", "Feature the task belongs to")
+	limit := historyFlags.Int("limit", 0, "Only show the most recent N events (0 for all)")
+	if err := historyFlags.Parse(args); err != nil {
+		return err
+	}
+	if historyFlags.NArg() != 1 {
+		return fmt.Errorf("usage: ponder history [--feature name] <task>")
+	}
+	taskName := historyFlags.Arg(0)
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	task, err := findTaskByName(ctx, database, *featureFilter, taskName)
+	if err != nil {
+		return err
+	}
+
+	events, err := database.ListEvents(ctx, db.EntityTask, task.ID, *limit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("History for %s/%s\n\n", task.FeatureName, task.Name)
+	fmt.Printf("%-20s %-24s %-18s %s\n", "TIME", "ACTOR", "ACTION", "CHANGE")
+	fmt.Println("--------------------------------------------------------------------------------")
+	for _, e := range events {
+		change := ""
+		switch {
+		case e.Before != nil && e.After != nil:
+			change = string(e.Before) + " -> " + string(e.After)
+		case e.After != nil:
+			change = string(e.After)
+		case e.Before != nil:
+			change = string(e.Before)
+		}
+		fmt.Printf("%-20s %-24s %-18s %s\n", e.CreatedAt.Local().Format("2006-01-02 15:04:05"), e.Actor, e.Action, change)
+	}
+	return nil
+}
+
+// findTaskByName looks a task up by name, optionally scoped to a feature. It
+// fails if the name matches tasks in more than one feature.
+func findTaskByName(ctx context.Context, database *db.DB, featureFilter, taskName string) (*models.Task, error) {
+	var featureName *string
+	if featureFilter != "" {
+		featureName = &featureFilter
+	}
+
+	tasks, err := database.ListTasks(ctx, nil, featureName)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*models.Task
+	for _, t := range tasks {
+		if t.Name == taskName {
+			matches = append(matches, t)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("task not found: %s", taskName)
+	case len(matches) > 1:
+		return nil, fmt.Errorf("task name %s is ambiguous, use --feature to select one", taskName)
+	}
+	return matches[0], nil
+}
+
+func runNote(args []string) error {
+	noteFlags := flag.NewFlagSet("note", flag.ContinueOnError)
+	featureFilter := noteFlags.String("feature", "", "Feature the task belongs to")
+	author := noteFlags.String("author", "", "Note author (defaults to cli)")
+	if err := noteFlags.Parse(args); err != nil {
+		return err
+	}
+	if noteFlags.NArg() < 1 {
+		return fmt.Errorf("usage: ponder note [--feature name] [--author name] <task> [body | -]")
+	}
+	taskName := noteFlags.Arg(0)
+	body := strings.Join(noteFlags.Args()[1:], " ")
+
+	if body == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read note from stdin: %w", err)
+		}
+		body = string(data)
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := actor.With(context.Background(), "cli")
+	task, err := findTaskByName(ctx, database, *featureFilter, taskName)
+	if err != nil {
+		return err
+	}
+
+	if body == "" {
+		notes, err := database.ListTaskNotes(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if len(notes) == 0 {
+			fmt.Printf("No notes on %s/%s\n", task.FeatureName, task.Name)
+			return nil
+		}
+		for i, n := range notes {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("## %s — %s\n\n%s\n", n.CreatedAt.Local().Format("2006-01-02 15:04:05"), n.Author, strings.TrimRight(n.Body, "\n"))
+		}
+		return nil
+	}
+
+	exportSnapshotOnChange(database)
+
+	note := &models.TaskNote{TaskID: task.ID, Author: *author, Body: body}
+	if err := database.AddTaskNote(ctx, note); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Added note to %s/%s\n", task.FeatureName, task.Name)
+	return nil
+}
+
+func runGraph(args []string) error {
+	if len(args) > 0 && args[0] == "analyze" {
+		return runGraphAnalyze(args[1:])
+	}
+
+	graphFlags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	formatFlag := graphFlags.String("format", "mermaid", "Diagram format (mermaid, dot)")
+	featureFilter := graphFlags.String("feature", "", "Only include the named feature")
+	output := graphFlags.String("output", "", "Write to file instead of stdout")
+	if err := graphFlags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseGraphFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	plan, err := export.Build(ctx, database, *featureFilter)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return export.WriteGraph(os.Stdout, format, plan)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	if err := export.WriteGraph(file, format, plan); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	return file.Close()
+}
+
 func runListFeatures(args []string) error {
+	featureFlags := flag.NewFlagSet("list-features", flag.ContinueOnError)
+	includeArchived := featureFlags.Bool("include-archived", false, "Also list archived features")
+	outputFormat := addFormatFlags(featureFlags)
+	if err := featureFlags.Parse(args); err != nil {
+		return err
+	}
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+
 	database, err := db.Open(dbPath)
 	if err != nil {
 		return err
@@ -294,11 +987,64 @@ func runListFeatures(args []string) error {
 	if err != nil {
 		return err
 	}
+	var archived []*models.Feature
+	if *includeArchived {
+		if archived, err = database.ListArchivedFeatures(ctx); err != nil {
+			return err
+		}
+	}
+	return printFeatures(os.Stdout, format, features, archived)
+}
+
+// listedFeature is a feature as list-features --format json prints it.
+type listedFeature struct {
+	*models.Feature
+	Archived bool `json:"archived,omitempty"`
+}
+
+// printFeatures writes features, then archived ones, in format.
+func printFeatures(w io.Writer, format outputFormat, features, archived []*models.Feature) error {
+	switch format {
+	case formatJSON:
+		listed := make([]listedFeature, 0, len(features)+len(archived))
+		for _, f := range features {
+			listed = append(listed, listedFeature{Feature: f})
+		}
+		for _, f := range archived {
+			listed = append(listed, listedFeature{Feature: f, Archived: true})
+		}
+		return writeJSON(w, listed)
+	case formatTSV:
+		var rows [][]string
+		row := func(f *models.Feature, archived bool) []string {
+			total, completed, blocked, percent := "", "", "", ""
+			if p := f.Progress; p != nil {
+				total, completed, blocked = strconv.Itoa(p.Total), strconv.Itoa(p.Completed), strconv.Itoa(p.Blocked)
+				percent = strconv.FormatFloat(p.PercentDone, 'f', -1, 64)
+			}
+			return []string{f.ID, f.Name, total, completed, percent, blocked, strconv.FormatBool(archived), f.Description}
+		}
+		for _, f := range features {
+			rows = append(rows, row(f, false))
+		}
+		for _, f := range archived {
+			rows = append(rows, row(f, true))
+		}
+		return writeTSV(w, []string{"id", "name", "total", "completed", "percent_done", "blocked", "archived", "description"}, rows)
+	}
 
-	fmt.Printf("%-20s %-30s\n", "NAME", "DESCRIPTION")
-	fmt.Println("------------------------------------------------------------")
+	fmt.Fprintf(w, "%-20s %-14s %-8s %-30s\n", "NAME", "PROGRESS", "BLOCKED", "DESCRIPTION")
+	fmt.Fprintln(w, "--------------------------------------------------------------------------------")
 	for _, f := range features {
-		fmt.Printf("%-20s %-30s\n", f.Name, f.Description)
+		progress, blocked := "", ""
+		if p := f.Progress; p != nil {
+			progress = fmt.Sprintf("%d/%d (%.0f%%)", p.Completed, p.Total, p.PercentDone)
+			blocked = strconv.Itoa(p.Blocked)
+		}
+		fmt.Fprintf(w, "%-20s %-14s %-8s %-30s\n", f.Name, progress, blocked, f.Description)
+	}
+	for _, f := range archived {
+		fmt.Fprintf(w, "%-20s %-14s %-8s %-30s\n", f.Name, "", "", "(archived) "+f.Description)
 	}
 	return nil
 }
@@ -313,9 +1059,15 @@ func runListTasks(args []string) error {
 	taskFlags := flag.NewFlagSet("list-tasks", flag.ContinueOnError)
 	statusFilter := taskFlags.String("status", "", "Filter by status (pending, in_progress, completed, blocked)")
 	featureFilter := taskFlags.String("feature", "", "Filter by feature name")
+	includeArchived := taskFlags.Bool("include-archived", false, "Also list archived tasks")
+	outputFormat := addFormatFlags(taskFlags)
 	if err := taskFlags.Parse(args); err != nil {
 		return err
 	}
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
 
 	var status *models.TaskStatus
 	if *statusFilter != "" {
@@ -334,75 +1086,344 @@ func runListTasks(args []string) error {
 		return err
 	}
 
-	fmt.Printf("%-30s %-15s %-10s %-15s\n", "NAME", "FEATURE", "PRIORITY", "STATUS")
-	fmt.Println("----------------------------------------------------------------------")
-	for _, t := range tasks {
-		fmt.Printf("%-30s %-15s %-10d %-15s\n", t.Name, t.FeatureName, t.Priority, t.Status)
+	// Only completed tasks are archived, so a status filter for anything
+	// else has nothing to add.
+	var archived []*models.Task
+	if *includeArchived && (status == nil || *status == models.TaskStatusCompleted) {
+		if archived, err = database.ListArchivedTasks(ctx, featureName); err != nil {
+			return err
+		}
+	}
+	return printTasks(os.Stdout, format, tasks, archived)
+}
+
+// listedTask is a task as list-tasks --format json prints it.
+type listedTask struct {
+	*models.Task
+	Archived bool `json:"archived,omitempty"`
+}
+
+// printTasks writes tasks, subtasks under their parent, then archived ones,
+// in format.
+func printTasks(w io.Writer, format outputFormat, tasks, archived []*models.Task) error {
+	switch format {
+	case formatJSON:
+		listed := make([]listedTask, 0, len(tasks)+len(archived))
+		for _, row := range taskTree(tasks) {
+			listed = append(listed, listedTask{Task: row.task})
+		}
+		for _, t := range archived {
+			listed = append(listed, listedTask{Task: t, Archived: true})
+		}
+		return writeJSON(w, listed)
+	case formatTSV:
+		var rows [][]string
+		row := func(t *models.Task, archived bool) []string {
+			return []string{t.ID, t.Name, t.FeatureName, strconv.Itoa(t.Priority), string(t.Status),
+				optional(t.ParentTaskID), strconv.FormatBool(archived), optional(t.BlockedReason)}
+		}
+		for _, r := range taskTree(tasks) {
+			rows = append(rows, row(r.task, false))
+		}
+		for _, t := range archived {
+			rows = append(rows, row(t, true))
+		}
+		return writeTSV(w, []string{"id", "name", "feature", "priority", "status", "parent_task_id", "archived", "blocked_reason"}, rows)
+	}
+
+	fmt.Fprintf(w, "%-30s %-15s %-10s %-15s\n", "NAME", "FEATURE", "PRIORITY", "STATUS")
+	fmt.Fprintln(w, "----------------------------------------------------------------------")
+	for _, row := range taskTree(tasks) {
+		name := row.task.Name
+		if row.depth > 0 {
+			name = strings.Repeat("  ", row.depth-1) + "└─ " + name
+		}
+		fmt.Fprintf(w, "%-30s %-15s %-10d %-15s\n", name, row.task.FeatureName, row.task.Priority, row.task.Status)
+		if row.task.Status == models.TaskStatusBlocked && row.task.BlockedReason != nil {
+			fmt.Fprintf(w, "%-30s reason: %s\n", "", firstLine(*row.task.BlockedReason))
+		}
+	}
+	for _, t := range archived {
+		fmt.Fprintf(w, "%-30s %-15s %-10d %-15s\n", t.Name, t.FeatureName, t.Priority, "archived")
 	}
 	return nil
 }
 
+// firstLine returns the first line of s, marking that more was cut off.
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i]) + " …"
+	}
+	return s
+}
+
+type taskTreeRow struct {
+	task  *models.Task
+	depth int
+}
+
+// taskTree orders tasks so that subtasks follow their parent, keeping the
+// given order among siblings. Subtasks whose parent isn't in tasks are listed
+// at the top level.
+func taskTree(tasks []*models.Task) []taskTreeRow {
+	listed := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		listed[t.ID] = true
+	}
+	children := make(map[string][]*models.Task)
+	var roots []*models.Task
+	for _, t := range tasks {
+		if t.ParentTaskID != nil && listed[*t.ParentTaskID] {
+			children[*t.ParentTaskID] = append(children[*t.ParentTaskID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	rows := make([]taskTreeRow, 0, len(tasks))
+	var walk func(t *models.Task, depth int)
+	walk = func(t *models.Task, depth int) {
+		rows = append(rows, taskTreeRow{task: t, depth: depth})
+		for _, child := range children[t.ID] {
+			walk(child, depth+1)
+		}
+	}
+	for _, t := range roots {
+		walk(t, 0)
+	}
+	return rows
+}
+
+// projectStatus is what status reports.
+type projectStatus struct {
+	Features       int                       `json:"features"`
+	TotalTasks     int                       `json:"total_tasks"`
+	AvailableTasks int                       `json:"available_tasks"`
+	ByStatus       map[models.TaskStatus]int `json:"by_status"`
+	Usage          *models.UsageTotals       `json:"usage"`
+	CostByFeature  []*models.FeatureUsage    `json:"cost_by_feature"`
+	Overdue        []*models.Task            `json:"overdue"`
+	Claims         []*models.Claim           `json:"claims"`
+	NextAvailable  []*models.Task            `json:"next_available"`
+}
+
+// statusOrder is the order status lists the task breakdown in.
+var statusOrder = []models.TaskStatus{
+	models.TaskStatusPending,
+	models.TaskStatusInProgress,
+	models.TaskStatusInReview,
+	models.TaskStatusCompleted,
+	models.TaskStatusBlocked,
+	models.TaskStatusCancelled,
+}
+
 func runStatus(args []string) error {
+	statusFlags := flag.NewFlagSet("status", flag.ContinueOnError)
+	outputFormat := addFormatFlags(statusFlags)
+	if err := statusFlags.Parse(args); err != nil {
+		return err
+	}
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+
 	database, err := db.Open(dbPath)
 	if err != nil {
 		return err
 	}
 	defer database.Close()
+	database.SetAvailabilityPolicy(availabilityPolicy)
 
-	ctx := context.Background()
-	features, err := database.ListFeatures(ctx)
+	status, err := loadStatus(context.Background(), database)
 	if err != nil {
 		return err
 	}
+	return printStatus(os.Stdout, format, status)
+}
+
+// loadStatus gathers the project status from database.
+func loadStatus(ctx context.Context, database *db.DB) (*projectStatus, error) {
+	features, err := database.ListFeatures(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	tasks, err := database.ListTasks(ctx, nil, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	available, err := database.GetAvailableTasks(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Println("Ponder Project Status")
-	fmt.Println("=====================")
-	fmt.Printf("Features:        %d\n", len(features))
-	fmt.Printf("Total Tasks:     %d\n", len(tasks))
-	fmt.Printf("Available Tasks: %d\n", len(available))
-
-	statusCounts := make(map[models.TaskStatus]int)
+	status := &projectStatus{
+		Features:       len(features),
+		TotalTasks:     len(tasks),
+		AvailableTasks: len(available),
+		ByStatus:       make(map[models.TaskStatus]int),
+		CostByFeature:  []*models.FeatureUsage{},
+		Overdue:        []*models.Task{},
+		NextAvailable:  available[:min(len(available), 5)],
+	}
+	for _, s := range statusOrder {
+		status.ByStatus[s] = 0
+	}
+	now := time.Now()
 	for _, t := range tasks {
-		statusCounts[t.Status]++
+		status.ByStatus[t.Status]++
+		if t.Overdue(now) {
+			status.Overdue = append(status.Overdue, t)
+		}
 	}
 
-	fmt.Println("\nTask Breakdown:")
-	fmt.Printf("  Pending:     %d\n", statusCounts[models.TaskStatusPending])
-	fmt.Printf("  In Progress: %d\n", statusCounts[models.TaskStatusInProgress])
-	fmt.Printf("  Completed:   %d\n", statusCounts[models.TaskStatusCompleted])
-	fmt.Printf("  Blocked:     %d\n", statusCounts[models.TaskStatusBlocked])
+	if status.Usage, err = database.GetUsageTotals(ctx); err != nil {
+		return nil, err
+	}
+	if status.Usage.Runs > 0 {
+		if status.CostByFeature, err = database.ListFeatureUsage(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	if len(available) > 0 {
-		fmt.Println("\nNext Available Tasks:")
-		for i, t := range available {
-			if i >= 5 {
-				break
+	if status.Claims, err = database.ListClaims(ctx); err != nil {
+		return nil, err
+	}
+	if status.Claims == nil {
+		status.Claims = []*models.Claim{}
+	}
+	return status, nil
+}
+
+// printStatus writes status in format. As TSV only the counts and usage
+// totals are written, one name and value per line.
+func printStatus(w io.Writer, format outputFormat, status *projectStatus) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, status)
+	case formatTSV:
+		rows := [][]string{
+			{"features", strconv.Itoa(status.Features)},
+			{"total_tasks", strconv.Itoa(status.TotalTasks)},
+			{"available_tasks", strconv.Itoa(status.AvailableTasks)},
+		}
+		for _, s := range statusOrder {
+			rows = append(rows, []string{string(s), strconv.Itoa(status.ByStatus[s])})
+		}
+		rows = append(rows,
+			[]string{"overdue", strconv.Itoa(len(status.Overdue))},
+			[]string{"claims", strconv.Itoa(len(status.Claims))},
+			[]string{"runs", strconv.Itoa(status.Usage.Runs)},
+			[]string{"tokens_in", strconv.FormatInt(status.Usage.TokensIn, 10)},
+			[]string{"tokens_out", strconv.FormatInt(status.Usage.TokensOut, 10)},
+			[]string{"cost_usd", strconv.FormatFloat(status.Usage.CostUSD, 'f', -1, 64)},
+		)
+		return writeTSV(w, []string{"name", "value"}, rows)
+	}
+
+	fmt.Fprintln(w, "Ponder Project Status")
+	fmt.Fprintln(w, "=====================")
+	fmt.Fprintf(w, "Features:        %d\n", status.Features)
+	fmt.Fprintf(w, "Total Tasks:     %d\n", status.TotalTasks)
+	fmt.Fprintf(w, "Available Tasks: %d\n", status.AvailableTasks)
+
+	fmt.Fprintln(w, "\nTask Breakdown:")
+	fmt.Fprintf(w, "  Pending:     %d\n", status.ByStatus[models.TaskStatusPending])
+	fmt.Fprintf(w, "  In Progress: %d\n", status.ByStatus[models.TaskStatusInProgress])
+	fmt.Fprintf(w, "  In Review:   %d\n", status.ByStatus[models.TaskStatusInReview])
+	fmt.Fprintf(w, "  Completed:   %d\n", status.ByStatus[models.TaskStatusCompleted])
+	fmt.Fprintf(w, "  Blocked:     %d\n", status.ByStatus[models.TaskStatusBlocked])
+	fmt.Fprintf(w, "  Cancelled:   %d\n", status.ByStatus[models.TaskStatusCancelled])
+
+	if usage := status.Usage; usage.Runs > 0 {
+		fmt.Fprintln(w, "\nUsage:")
+		fmt.Fprintf(w, "  Runs:        %d\n", usage.Runs)
+		fmt.Fprintf(w, "  Tokens In:   %d\n", usage.TokensIn)
+		fmt.Fprintf(w, "  Tokens Out:  %d\n", usage.TokensOut)
+		fmt.Fprintf(w, "  Cost:        $%.2f\n", usage.CostUSD)
+		fmt.Fprintln(w, "\nCost by Feature:")
+		for _, f := range status.CostByFeature {
+			fmt.Fprintf(w, "  - %s: $%.2f (%d in / %d out tokens, %d runs)\n", f.FeatureName, f.CostUSD, f.TokensIn, f.TokensOut, f.Runs)
+		}
+	}
+
+	if len(status.Overdue) > 0 {
+		fmt.Fprintf(w, "\nOverdue Tasks: %d\n", len(status.Overdue))
+		for _, t := range status.Overdue {
+			fmt.Fprintf(w, "  ! %s/%s (%s, due %s)\n", t.FeatureName, t.Name, t.Status, t.DueAt.Local().Format("2006-01-02 15:04"))
+		}
+	}
+
+	if len(status.Claims) > 0 {
+		now := time.Now()
+		fmt.Fprintln(w, "\nClaims:")
+		for _, c := range status.Claims {
+			state := "expires " + c.LeaseExpiresAt.Local().Format("15:04:05")
+			if !c.LeaseExpiresAt.After(now) {
+				state = "expired"
 			}
-			fmt.Printf("  - %s (priority: %d)\n", t.Name, t.Priority)
+			fmt.Fprintf(w, "  - %s/%s by %s (%s)\n", c.FeatureName, c.TaskName, c.Claimer, state)
+		}
+	}
+
+	if len(status.NextAvailable) > 0 {
+		fmt.Fprintln(w, "\nNext Available Tasks:")
+		for _, t := range status.NextAvailable {
+			fmt.Fprintf(w, "  - %s (priority: %d)\n", t.Name, t.Priority)
 		}
 	}
 
 	return nil
 }
 
-func loadWorkDefaults() (workDefaults, error) {
-	defaults := workDefaults{
-		Model:           defaultWorkModel,
-		MaxConcurrency:  defaultWorkMaxConcurrency,
-		AvailableModels: []string{defaultWorkModel},
+// configDir returns the directory holding config.json: next to the SQLite
+// database, or .ponder when the database is a Postgres DSN.
+func configDir() string {
+	if db.IsPostgresDSN(dbPath) {
+		return ".ponder"
 	}
+	return filepath.Dir(dbPath)
+}
+
+// snapshotDebounce is how long writes are coalesced before the snapshot is
+// exported, as configured in config.json.
+var snapshotDebounce = db.DefaultSnapshotDebounce
+
+// exportSnapshotOnChange keeps the snapshot at snapshotPath up to date with
+// database, exporting in the background after bursts of writes. Closing the
+// database writes out the last export. Exports are kept in the snapshot
+// history too when it is configured.
+func exportSnapshotOnChange(database *db.DB) {
+	database.SetSnapshotHistory(snapshotHistory)
+	database.EnableDebouncedSnapshot(snapshotPath, snapshotDebounce, func(err error) {
+		fmt.Fprintf(os.Stderr, "Error exporting snapshot: %v\n", err)
+	})
+}
 
-	configPath := filepath.Join(filepath.Dir(dbPath), "config.json")
+// builtinWorkDefaults returns the settings used when config.json leaves them
+// out.
+func builtinWorkDefaults() workDefaults {
+	return workDefaults{
+		Model:            defaultWorkModel,
+		MaxConcurrency:   defaultWorkMaxConcurrency,
+		AvailableModels:  []string{defaultWorkModel},
+		RetryPolicy:      orchestrator.DefaultRetryPolicy(),
+		RunLogs:          defaultRunLogs(),
+		ClaimLease:       orchestrator.DefaultClaimLease,
+		SnapshotDebounce: db.DefaultSnapshotDebounce,
+		WebAuthToken:     os.Getenv("PONDER_WEB_AUTH_TOKEN"),
+		EventHistory:     eventHistory{Size: orchestrator.DefaultHistorySize},
+		Theme:            theme.Dark,
+	}
+}
+
+func loadWorkDefaults() (workDefaults, error) {
+	defaults := builtinWorkDefaults()
+
+	configPath := filepath.Join(configDir(), "config.json")
 	data, err := os.ReadFile(configPath)
 	if os.IsNotExist(err) {
 		return defaults, nil
@@ -410,7 +1431,12 @@ func loadWorkDefaults() (workDefaults, error) {
 	if err != nil {
 		return defaults, fmt.Errorf("failed to read config file %s: %w", configPath, err)
 	}
+	return parseWorkConfig(defaults, data, configPath)
+}
 
+// parseWorkConfig overlays the config file contents data onto defaults,
+// validating every value. configPath is only used in errors.
+func parseWorkConfig(defaults workDefaults, data []byte, configPath string) (workDefaults, error) {
 	var cfg workConfig
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return defaults, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
@@ -428,6 +1454,219 @@ func loadWorkDefaults() (workDefaults, error) {
 	if len(cfg.AvailableModels) > 0 {
 		defaults.AvailableModels = cfg.AvailableModels
 	}
+	if cfg.Retry != nil {
+		policy, err := cfg.Retry.apply(defaults.RetryPolicy)
+		if err != nil {
+			return defaults, fmt.Errorf("invalid retry config in %s: %w", configPath, err)
+		}
+		defaults.RetryPolicy = policy
+	}
+	if cfg.Worktrees != nil {
+		defaults.Worktrees = *cfg.Worktrees
+	}
+	if cfg.Verification != nil && cfg.Verification.Command != "" {
+		v := &orchestrator.Verification{Command: cfg.Verification.Command}
+		if cfg.Verification.Timeout != "" {
+			d, err := time.ParseDuration(cfg.Verification.Timeout)
+			if err != nil {
+				return defaults, fmt.Errorf("invalid verification timeout in %s: %w", configPath, err)
+			}
+			v.Timeout = d
+		}
+		defaults.Verification = v
+	}
+	if len(cfg.Pricing) > 0 {
+		defaults.Pricing = make(map[string]orchestrator.ModelPrice, len(cfg.Pricing))
+		for model, price := range cfg.Pricing {
+			if price.Input < 0 || price.Output < 0 {
+				return defaults, fmt.Errorf("invalid pricing for %s in %s: prices must be >= 0", model, configPath)
+			}
+			defaults.Pricing[model] = orchestrator.ModelPrice{Input: price.Input, Output: price.Output}
+		}
+	}
+
+	if cfg.PriorityAging != nil {
+		aging, err := cfg.PriorityAging.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid priority_aging in %s: %w", configPath, err)
+		}
+		defaults.PriorityAging = aging
+	}
+
+	if cfg.Availability != nil {
+		policy, err := cfg.Availability.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid availability in %s: %w", configPath, err)
+		}
+		defaults.Availability = policy
+	}
+
+	if cfg.MaxTaskDuration != "" || len(cfg.MaxTaskDurationOverrides) > 0 {
+		timeouts, err := parseTaskTimeouts(cfg.MaxTaskDuration, cfg.MaxTaskDurationOverrides)
+		if err != nil {
+			return defaults, fmt.Errorf("invalid max_task_duration in %s: %w", configPath, err)
+		}
+		defaults.TaskTimeouts = timeouts
+	}
+
+	if cfg.StallDetection != nil {
+		stall, err := cfg.StallDetection.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid stall_detection in %s: %w", configPath, err)
+		}
+		defaults.StallDetection = stall
+	}
+
+	if cfg.AutoBackup != nil {
+		backup, err := cfg.AutoBackup.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid auto_backup in %s: %w", configPath, err)
+		}
+		defaults.AutoBackup = backup
+	}
+
+	if cfg.SnapshotHistory != nil {
+		history, err := cfg.SnapshotHistory.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid snapshot_history in %s: %w", configPath, err)
+		}
+		defaults.SnapshotHistory = history
+	}
+
+	if cfg.Logs != nil {
+		logs, err := cfg.Logs.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid logs in %s: %w", configPath, err)
+		}
+		defaults.RunLogs = logs
+	}
+
+	if cfg.ClaimLease != "" {
+		d, err := time.ParseDuration(cfg.ClaimLease)
+		if err != nil {
+			return defaults, fmt.Errorf("invalid claim_lease in %s: %w", configPath, err)
+		}
+		if d < time.Second {
+			return defaults, fmt.Errorf("invalid claim_lease in %s: must be at least 1s", configPath)
+		}
+		defaults.ClaimLease = d
+	}
+
+	if cfg.SnapshotDebounce != "" {
+		d, err := time.ParseDuration(cfg.SnapshotDebounce)
+		if err != nil {
+			return defaults, fmt.Errorf("invalid snapshot_debounce in %s: %w", configPath, err)
+		}
+		if d < 0 {
+			return defaults, fmt.Errorf("invalid snapshot_debounce in %s: must be >= 0", configPath)
+		}
+		defaults.SnapshotDebounce = d
+	}
+
+	if defaults.WebAuthToken == "" {
+		defaults.WebAuthToken = cfg.WebAuthToken
+	}
+
+	if cfg.EventHistory != nil {
+		if cfg.EventHistory.Size != nil {
+			if *cfg.EventHistory.Size < 0 {
+				return defaults, fmt.Errorf("invalid event_history in %s: size must be >= 0", configPath)
+			}
+			defaults.EventHistory.Size = *cfg.EventHistory.Size
+		}
+		defaults.EventHistory.File = cfg.EventHistory.File
+	}
+
+	if len(cfg.ModelRouting) > 0 {
+		routing, defaultModel, err := parseModelRouting(cfg.ModelRouting)
+		if err != nil {
+			return defaults, fmt.Errorf("invalid model_routing in %s: %w", configPath, err)
+		}
+		if defaultModel != "" {
+			if cfg.Model != nil && *cfg.Model != "" && *cfg.Model != defaultModel {
+				return defaults, fmt.Errorf("invalid model_routing in %s: default %q conflicts with model %q", configPath, defaultModel, *cfg.Model)
+			}
+			defaults.Model = defaultModel
+		}
+		defaults.ModelRouting = routing
+	}
+
+	if cfg.Budget != nil {
+		if *cfg.Budget < 0 {
+			return defaults, fmt.Errorf("invalid budget in %s: must be >= 0", configPath)
+		}
+		defaults.Budget = *cfg.Budget
+	}
+
+	if cfg.ModelFallback != nil {
+		fallback := orchestrator.ModelFallback{AfterFailures: orchestrator.DefaultFallbackFailures}
+		if cfg.ModelFallback.AfterFailures != nil {
+			fallback.AfterFailures = *cfg.ModelFallback.AfterFailures
+		}
+		if err := fallback.Validate(); err != nil {
+			return defaults, fmt.Errorf("invalid model_fallback in %s: %w", configPath, err)
+		}
+		defaults.ModelFallback = fallback
+	}
+
+	if cfg.ResourceLimits != nil {
+		limits, err := cfg.ResourceLimits.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid resource_limits in %s: %w", configPath, err)
+		}
+		defaults.ResourceLimits = limits
+	}
+
+	if cfg.Sandbox != nil {
+		sandbox := &orchestrator.Sandbox{
+			Runtime: cfg.Sandbox.Runtime,
+			Image:   cfg.Sandbox.Image,
+			User:    cfg.Sandbox.User,
+			Binds:   cfg.Sandbox.Binds,
+			Env:     cfg.Sandbox.Env,
+			Args:    cfg.Sandbox.Args,
+		}
+		if err := sandbox.Validate(); err != nil {
+			return defaults, fmt.Errorf("invalid sandbox in %s: %w", configPath, err)
+		}
+		defaults.Sandbox = sandbox
+	}
+
+	if cfg.ClaimFilter != nil {
+		filter := db.ClaimFilter{
+			Features:    cfg.ClaimFilter.Features,
+			Labels:      cfg.ClaimFilter.Labels,
+			MinPriority: cfg.ClaimFilter.MinPriority,
+		}
+		if err := filter.Validate(); err != nil {
+			return defaults, fmt.Errorf("invalid claim_filter in %s: %w", configPath, err)
+		}
+		defaults.ClaimFilter = filter
+	}
+
+	if cfg.RateLimits != nil {
+		limits := orchestrator.RateLimits(cfg.RateLimits)
+		if err := limits.Validate(); err != nil {
+			return defaults, fmt.Errorf("invalid rate_limits in %s: %w", configPath, err)
+		}
+		defaults.RateLimits = limits
+	}
+
+	for i, nc := range cfg.Notifications {
+		n := orchestrator.Notifier{Type: nc.Type, URL: os.ExpandEnv(nc.URL), Events: nc.Events}
+		if err := n.Validate(); err != nil {
+			return defaults, fmt.Errorf("invalid notifications[%d] in %s: %w", i, configPath, err)
+		}
+		defaults.Notifiers = append(defaults.Notifiers, n)
+	}
+
+	if cfg.Theme != nil {
+		t, err := cfg.Theme.parse()
+		if err != nil {
+			return defaults, fmt.Errorf("invalid theme in %s: %w", configPath, err)
+		}
+		defaults.Theme = t
+	}
 
 	foundModel := false
 	for _, model := range defaults.AvailableModels {
@@ -443,6 +1682,170 @@ func loadWorkDefaults() (workDefaults, error) {
 	return defaults, nil
 }
 
+// apply overlays the configured retry values onto base and validates the result.
+func (rc *retryConfig) apply(base orchestrator.RetryPolicy) (orchestrator.RetryPolicy, error) {
+	policy := base
+	if rc.MaxAttempts != nil {
+		policy.MaxAttempts = *rc.MaxAttempts
+	}
+	if rc.InitialBackoff != nil {
+		d, err := time.ParseDuration(*rc.InitialBackoff)
+		if err != nil {
+			return base, fmt.Errorf("initial_backoff: %w", err)
+		}
+		policy.InitialBackoff = d
+	}
+	if rc.MaxBackoff != nil {
+		d, err := time.ParseDuration(*rc.MaxBackoff)
+		if err != nil {
+			return base, fmt.Errorf("max_backoff: %w", err)
+		}
+		policy.MaxBackoff = d
+	}
+	if rc.Multiplier != nil {
+		policy.Multiplier = *rc.Multiplier
+	}
+	if rc.Jitter != nil {
+		policy.Jitter = *rc.Jitter
+	}
+
+	if err := policy.Validate(); err != nil {
+		return base, err
+	}
+	return policy, nil
+}
+
+// parse converts the configured aging settings. Step defaults to 1.
+func (ac *agingConfig) parse() (db.PriorityAging, error) {
+	aging := db.PriorityAging{Step: 1, MaxBoost: ac.MaxBoost}
+	if ac.Step != nil {
+		aging.Step = *ac.Step
+	}
+	if ac.Interval != "" {
+		d, err := time.ParseDuration(ac.Interval)
+		if err != nil {
+			return db.PriorityAging{}, fmt.Errorf("interval: %w", err)
+		}
+		aging.Interval = d
+	}
+	if err := aging.Validate(); err != nil {
+		return db.PriorityAging{}, err
+	}
+	return aging, nil
+}
+
+func (lc *limitsConfig) parse() (orchestrator.ResourceLimits, error) {
+	limits := orchestrator.ResourceLimits{Nice: lc.Nice, MaxProcesses: lc.MaxProcesses}
+	if lc.MemoryMax != "" {
+		n, err := parseByteSize(lc.MemoryMax)
+		if err != nil {
+			return orchestrator.ResourceLimits{}, fmt.Errorf("memory_max: %w", err)
+		}
+		limits.MemoryMax = n
+	}
+	if err := limits.Validate(); err != nil {
+		return orchestrator.ResourceLimits{}, err
+	}
+	return limits, nil
+}
+
+// parseByteSize parses a byte count such as "512M" or "4G". The suffixes
+// are powers of 1024, as for cgroup limits.
+func parseByteSize(s string) (int64, error) {
+	multiplier := int64(1)
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	if n := len(number); n > 0 {
+		switch number[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			number = number[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a byte size like 512M or 4G", s)
+	}
+	return n * multiplier, nil
+}
+
+// parseTaskTimeouts converts the configured global and per-task agent time
+// limits.
+func parseTaskTimeouts(global string, overrides map[string]string) (orchestrator.TaskTimeouts, error) {
+	var timeouts orchestrator.TaskTimeouts
+	if global != "" {
+		d, err := time.ParseDuration(global)
+		if err != nil {
+			return orchestrator.TaskTimeouts{}, err
+		}
+		timeouts.Default = d
+	}
+	if len(overrides) > 0 {
+		timeouts.Overrides = make(map[string]time.Duration, len(overrides))
+		for key, value := range overrides {
+			if !strings.Contains(key, "/") {
+				return orchestrator.TaskTimeouts{}, fmt.Errorf("override %q must be keyed by feature/task", key)
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return orchestrator.TaskTimeouts{}, fmt.Errorf("%s: %w", key, err)
+			}
+			timeouts.Overrides[key] = d
+		}
+	}
+	if err := timeouts.Validate(); err != nil {
+		return orchestrator.TaskTimeouts{}, err
+	}
+	return timeouts, nil
+}
+
+// parse converts the configured stall window. Kill needs a window.
+func (sc *stallConfig) parse() (orchestrator.StallDetection, error) {
+	window, err := time.ParseDuration(sc.Window)
+	if err != nil {
+		return orchestrator.StallDetection{}, fmt.Errorf("window: %w", err)
+	}
+	stall := orchestrator.StallDetection{Window: window, Kill: sc.Kill}
+	if err := stall.Validate(); err != nil {
+		return orchestrator.StallDetection{}, err
+	}
+	return stall, nil
+}
+
+// parseModelRouting reads "priority>=N" keys into routes. The "default" key
+// is returned separately, as it is the orchestrator's model.
+func parseModelRouting(config map[string]string) (orchestrator.ModelRouting, string, error) {
+	var routes []orchestrator.ModelRoute
+	defaultModel := ""
+	for key, model := range config {
+		if key == "default" {
+			defaultModel = model
+			continue
+		}
+		threshold, ok := strings.CutPrefix(strings.ReplaceAll(key, " ", ""), "priority>=")
+		if !ok {
+			return nil, "", fmt.Errorf("key %q must be \"default\" or \"priority>=N\"", key)
+		}
+		n, err := strconv.Atoi(threshold)
+		if err != nil {
+			return nil, "", fmt.Errorf("key %q: invalid priority %q", key, threshold)
+		}
+		routes = append(routes, orchestrator.ModelRoute{MinPriority: n, Model: model})
+	}
+	routing, err := orchestrator.NewModelRouting(routes)
+	if err != nil {
+		return nil, "", err
+	}
+	return routing, defaultModel, nil
+}
+
 func writeDefaultConfig(configPath string) error {
 	model := defaultWorkModel
 	maxConcurrency := defaultWorkMaxConcurrency
@@ -466,7 +1869,35 @@ func writeDefaultConfig(configPath string) error {
 	return nil
 }
 
-func runOrchestratorCommon(maxConcurrency int, model string, availableModels []string, interval time.Duration, enableWeb bool, webPort string) error {
+// newWorktreeManager sets up worktree isolation for the current repository.
+// Agents run inside the worktree, so their MCP server is pointed back at this
+// checkout's database and snapshot.
+func newWorktreeManager(ctx context.Context) (*orchestrator.WorktreeManager, error) {
+	absDB := dbPath
+	if !db.IsPostgresDSN(dbPath) {
+		var err error
+		absDB, err = filepath.Abs(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve database path: %w", err)
+		}
+	}
+	absSnapshot, err := filepath.Abs(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve snapshot path: %w", err)
+	}
+
+	wm, err := orchestrator.NewWorktreeManager(ctx, ".")
+	if err != nil {
+		return nil, err
+	}
+	wm.Env = []string{
+		"PONDER_DB_PATH=" + absDB,
+		"PONDER_SNAPSHOT_PATH=" + absSnapshot,
+	}
+	return wm, nil
+}
+
+func runOrchestratorCommon(opts workOptions) error {
 	database, err := db.Open(dbPath)
 	if err != nil {
 		return err
@@ -480,34 +1911,148 @@ func runOrchestratorCommon(maxConcurrency int, model string, availableModels []s
 		return err
 	}
 
-	database.SetOnChange(func(ctx context.Context) {
-		if err := database.ExportSnapshot(ctx, snapshotPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error exporting snapshot: %v\n", err)
-		}
-	})
+	exportSnapshotOnChange(database)
+	orch := newOrchestrator(database, opts)
 
-	orch := orchestrator.NewOrchestrator(database, maxConcurrency, model)
-	orch.SetAvailableModels(availableModels)
-	orch.SetTargetWorkers(0)
-	orch.PollingInterval = interval
+	if opts.DryRun {
+		return printPlan(ctx, orch, opts)
+	}
 
-	if enableWeb {
-		srv := server.NewServer(database)
-		orch.WebURL = fmt.Sprintf("http://localhost:%s", webPort)
+	finishRun, err := prepareRun(ctx, database, orch, opts)
+	if err != nil {
+		return err
+	}
+	// Headless JSON logs may be on stdout, so keep the summary apart.
+	defer finishRun(os.Stderr)
+
+	if err := watchConfig(ctx, orch, hangups(ctx)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config.json changes will need a restart: %v\n", err)
+	}
 
-		go func() {
-			if err := srv.Start(fmt.Sprintf(":%s", webPort)); err != nil && err != http.ErrServerClosed {
+	var sup supervisor
+	if opts.EnableWeb {
+		srv := server.NewServer(database)
+		srv.SetOrchestrator(orch)
+		srv.SetAuthToken(webAuthToken)
+		orch.WebURL = webURL(opts.WebHost, opts.WebPort)
+		hs := &http.Server{Addr: net.JoinHostPort(opts.WebHost, opts.WebPort), Handler: srv.Handler()}
+
+		// The web UI is optional here: if it can't start, work goes on.
+		sup.add("web", func(ctx context.Context) error {
+			if err := runHTTPServer(ctx, hs); err != nil {
 				fmt.Fprintf(os.Stderr, "Web server error: %v\n", err)
+				<-ctx.Done()
+			}
+			return nil
+		})
+	}
+
+	sup.add("orchestrator", func(ctx context.Context) error {
+		if !opts.NoTUI {
+			return orchestrator.Run(ctx, orch)
+		}
+		var out io.Writer = os.Stdout
+		if opts.LogFile != "" {
+			f, err := os.Create(opts.LogFile)
+			if err != nil {
+				return fmt.Errorf("failed to create log file: %w", err)
 			}
-		}()
+			defer f.Close()
+			out = f
+		}
+		return orchestrator.RunHeadless(ctx, orch, out, opts.LogFormat)
+	})
+	return sup.run(ctx)
+}
+
+// newOrchestrator returns an orchestrator for database with the settings in
+// opts, and applies the database-level ones.
+func newOrchestrator(database *db.DB, opts workOptions) *orchestrator.Orchestrator {
+	database.SetPriorityAging(opts.PriorityAging)
+	database.SetClaimFilter(opts.ClaimFilter)
+	database.SetAvailabilityPolicy(availabilityPolicy)
 
-		go func() {
-			<-ctx.Done()
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			srv.Shutdown(shutdownCtx)
-		}()
+	orch := orchestrator.NewOrchestrator(database, opts.MaxConcurrency, opts.Model)
+	orch.SetAvailableModels(opts.AvailableModels)
+	orch.SetRetryPolicy(opts.RetryPolicy)
+	orch.SetTargetWorkers(0)
+	orch.PollingInterval = opts.Interval
+
+	orch.SetVerification(opts.Verification)
+	orch.SetTaskTimeouts(opts.TaskTimeouts)
+	orch.SetStallDetection(opts.StallDetection)
+	orch.SetRunLogs(opts.RunLogs)
+	orch.SetModelRouting(opts.ModelRouting)
+	orch.SetModelFallback(opts.ModelFallback)
+	if opts.ClaimLease > 0 {
+		orch.SetClaimLease(opts.ClaimLease)
 	}
+	orch.SetPricing(opts.Pricing)
+	orch.SetBudget(opts.Budget)
+	orch.SetResourceLimits(opts.ResourceLimits)
+	orch.SetSandbox(opts.Sandbox)
+	orch.SetRateLimits(opts.RateLimits)
+	orch.SetNotifiers(opts.Notifiers)
+	return orch
+}
 
-	return orchestrator.Run(ctx, orch)
+// prepareRun sets up what orch needs to run tasks rather than plan them:
+// the event history, if enabled worktrees, and the run session recording
+// the run. Call finish once it has stopped, with where to write the
+// session's summary.
+func prepareRun(ctx context.Context, database *db.DB, orch *orchestrator.Orchestrator, opts workOptions) (finish func(w io.Writer), err error) {
+	closeHistory := func() {}
+	var historyFile io.Writer
+	if opts.EventHistory.File != "" {
+		f, err := os.Create(opts.EventHistory.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create event history file: %w", err)
+		}
+		closeHistory = func() { f.Close() }
+		historyFile = f
+	}
+	orch.SetHistory(orchestrator.NewHistory(opts.EventHistory.Size, historyFile))
+
+	if opts.Worktrees {
+		wm, err := newWorktreeManager(ctx)
+		if err != nil {
+			closeHistory()
+			return nil, err
+		}
+		orch.SetWorktreeManager(wm)
+	}
+
+	finishSession := startSession(database, orch)
+	return func(w io.Writer) {
+		finishSession(w)
+		closeHistory()
+	}, nil
+}
+
+// hangups delivers SIGHUP, the usual request to reload configuration, until
+// ctx is done.
+func hangups(ctx context.Context) <-chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	context.AfterFunc(ctx, func() { signal.Stop(ch) })
+	return ch
+}
+
+// printPlan writes what the orchestrator would claim, with the models and
+// prompts, to stdout or the log file.
+func printPlan(ctx context.Context, orch *orchestrator.Orchestrator, opts workOptions) error {
+	plan, err := orch.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to plan claims: %w", err)
+	}
+	var out io.Writer = os.Stdout
+	if opts.LogFile != "" {
+		f, err := os.Create(opts.LogFile)
+		if err != nil {
+			return fmt.Errorf("failed to create log file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	return orchestrator.WritePlan(out, plan, opts.LogFormat)
 }
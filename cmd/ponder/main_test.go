@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
 )
 
 func TestExecuteRoutesRootToWorkTUI(t *testing.T) {
@@ -46,28 +48,34 @@ func TestExecuteRoutesRootToWorkTUI(t *testing.T) {
 	})
 
 	called := false
-	runOrchestrator = func(maxConcurrency int, model string, availableModels []string, interval time.Duration, enableWeb bool, webPort string) error {
+	runOrchestrator = func(opts workOptions) error {
 		called = true
-		if maxConcurrency != 7 {
-			t.Errorf("expected max concurrency 7, got %d", maxConcurrency)
+		if opts.MaxConcurrency != 7 {
+			t.Errorf("expected max concurrency 7, got %d", opts.MaxConcurrency)
 		}
-		if model != "cfg/model" {
-			t.Errorf("expected model cfg/model, got %s", model)
+		if opts.Model != "cfg/model" {
+			t.Errorf("expected model cfg/model, got %s", opts.Model)
 		}
-		if len(availableModels) != 2 {
-			t.Fatalf("expected 2 available models, got %d", len(availableModels))
+		if len(opts.AvailableModels) != 2 {
+			t.Fatalf("expected 2 available models, got %d", len(opts.AvailableModels))
 		}
-		if availableModels[0] != "cfg/model" || availableModels[1] != "backup/model" {
-			t.Errorf("unexpected available models: %v", availableModels)
+		if opts.AvailableModels[0] != "cfg/model" || opts.AvailableModels[1] != "backup/model" {
+			t.Errorf("unexpected available models: %v", opts.AvailableModels)
 		}
-		if interval != 3*time.Second {
-			t.Errorf("expected interval 3s, got %v", interval)
+		if opts.Interval != 3*time.Second {
+			t.Errorf("expected interval 3s, got %v", opts.Interval)
 		}
-		if enableWeb {
+		if opts.EnableWeb {
 			t.Error("expected web to be disabled")
 		}
-		if webPort != "9001" {
-			t.Errorf("expected web port 9001, got %s", webPort)
+		if opts.WebPort != "9001" {
+			t.Errorf("expected web port 9001, got %s", opts.WebPort)
+		}
+		if opts.Worktrees {
+			t.Error("expected worktrees to be disabled by default")
+		}
+		if opts.NoTUI || opts.LogFormat != orchestrator.LogFormatText {
+			t.Errorf("expected TUI with text log format by default, got no-tui=%v format=%s", opts.NoTUI, opts.LogFormat)
 		}
 		return nil
 	}
@@ -83,6 +91,86 @@ func TestExecuteRoutesRootToWorkTUI(t *testing.T) {
 	}
 }
 
+func TestExecuteHeadlessFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalDBPath := dbPath
+	originalRunOrchestrator := runOrchestrator
+	t.Cleanup(func() {
+		dbPath = originalDBPath
+		runOrchestrator = originalRunOrchestrator
+	})
+
+	var got workOptions
+	runOrchestrator = func(opts workOptions) error {
+		got = opts
+		return nil
+	}
+
+	dbFilePath := filepath.Join(tmpDir, "ponder.db")
+	logPath := filepath.Join(tmpDir, "events.ndjson")
+	var stderr bytes.Buffer
+	if err := execute([]string{"--db-path", dbFilePath, "--no-tui", "--log-format", "json", "--log-file", logPath}, &stderr); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if !got.NoTUI || got.LogFormat != orchestrator.LogFormatJSON || got.LogFile != logPath {
+		t.Errorf("unexpected headless options: %+v", got)
+	}
+
+	if err := execute([]string{"--db-path", dbFilePath, "--no-tui", "--log-format", "xml"}, &stderr); err == nil {
+		t.Error("expected error for unknown log format")
+	}
+
+	if err := execute([]string{"--db-path", dbFilePath, "--dry-run"}, &stderr); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if !got.DryRun {
+		t.Errorf("expected -dry-run to be passed on: %+v", got)
+	}
+}
+
+func TestExecuteBudget(t *testing.T) {
+	ponderDir := filepath.Join(t.TempDir(), ".ponder")
+	if err := os.MkdirAll(ponderDir, 0755); err != nil {
+		t.Fatalf("failed to create .ponder dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ponderDir, "config.json"), []byte(`{"budget": 5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	originalDBPath := dbPath
+	originalRunOrchestrator := runOrchestrator
+	t.Cleanup(func() {
+		dbPath = originalDBPath
+		runOrchestrator = originalRunOrchestrator
+	})
+
+	var got workOptions
+	runOrchestrator = func(opts workOptions) error {
+		got = opts
+		return nil
+	}
+
+	dbFilePath := filepath.Join(ponderDir, "ponder.db")
+	var stderr bytes.Buffer
+	if err := execute([]string{"--db-path", dbFilePath}, &stderr); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if got.Budget != 5 {
+		t.Errorf("expected budget 5 from config, got %v", got.Budget)
+	}
+
+	if err := execute([]string{"--db-path", dbFilePath, "--budget", "2.5"}, &stderr); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if got.Budget != 2.5 {
+		t.Errorf("expected -budget to override config, got %v", got.Budget)
+	}
+
+	if err := execute([]string{"--db-path", dbFilePath, "--budget", "-1"}, &stderr); err == nil {
+		t.Error("expected error for negative budget")
+	}
+}
+
 func TestExecuteRejectsWorkSubcommand(t *testing.T) {
 	var stderr bytes.Buffer
 	err := execute([]string{"work"}, &stderr)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func runDBVacuum(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: ponder db vacuum")
+	}
+
+	database, err := openUnmigratedDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := database.Vacuum(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Vacuumed database: %s → %s\n", formatBytes(result.SizeBefore), formatBytes(result.SizeAfter))
+	return nil
+}
+
+func runDBIntegrityCheck(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: ponder db integrity-check")
+	}
+
+	database, err := openUnmigratedDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	problems, err := database.IntegrityCheck(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Println("✓ Database passed the integrity and foreign key checks")
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Printf("  ✗ %s\n", p)
+	}
+	return fmt.Errorf("database failed the integrity check with %d problems; restore a backup with ponder db restore, or rebuild it from the snapshot with ponder init", len(problems))
+}
+
+func runDBCheckpoint(args []string) error {
+	fs := flag.NewFlagSet("db checkpoint", flag.ContinueOnError)
+	mode := fs.String("mode", "truncate", "Checkpoint mode ("+strings.Join(db.CheckpointModes, ", ")+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder db checkpoint [--mode %s]", strings.Join(db.CheckpointModes, "|"))
+	}
+
+	database, err := openUnmigratedDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := database.Checkpoint(context.Background(), *mode)
+	if err != nil {
+		return err
+	}
+	switch {
+	case result.LogFrames < 0:
+		fmt.Println("Database is not in WAL mode; nothing to checkpoint")
+	case result.Busy:
+		fmt.Printf("Checkpointed %d of %d WAL frames; other connections kept the rest, try again once they finish\n", result.Checkpointed, result.LogFrames)
+	default:
+		fmt.Printf("✓ Checkpointed %d WAL frames\n", result.Checkpointed)
+	}
+	return nil
+}
+
+// formatBytes returns n in the largest binary unit that keeps it at least 1.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDBMaintenanceCommands(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	out := captureStdout(t, func() error { return runDB([]string{"checkpoint"}) })
+	if !strings.HasPrefix(out, "✓ Checkpointed") {
+		t.Errorf("expected the WAL to be checkpointed, got:\n%s", out)
+	}
+	out = captureStdout(t, func() error { return runDB([]string{"vacuum"}) })
+	if !strings.HasPrefix(out, "✓ Vacuumed database: ") {
+		t.Errorf("expected the database to be vacuumed, got:\n%s", out)
+	}
+	out = captureStdout(t, func() error { return runDB([]string{"integrity-check"}) })
+	if !strings.HasPrefix(out, "✓ Database passed") {
+		t.Errorf("expected the database to pass the checks, got:\n%s", out)
+	}
+
+	if err := runDB([]string{"checkpoint", "--mode", "later"}); err == nil {
+		t.Error("expected an invalid checkpoint mode to fail")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512 B",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
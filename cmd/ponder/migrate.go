@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+// openUnmigratedDB opens the database without applying pending migrations,
+// so the db commands can show and run them.
+func openUnmigratedDB() (*db.DB, error) {
+	return db.OpenWithOptions(dbPath, db.OpenOptions{SkipMigrations: true})
+}
+
+func runDBVersion(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: ponder db version")
+	}
+
+	database, err := openUnmigratedDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	return printSchemaVersion(context.Background(), os.Stdout, database)
+}
+
+// printSchemaVersion writes the database's schema version and the state of
+// each migration this build knows.
+func printSchemaVersion(ctx context.Context, w io.Writer, database *db.DB) error {
+	version, err := database.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	latest, err := database.LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	migrations, err := database.ListMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Schema version %d (latest %d)\n", version, latest)
+	if version > latest {
+		fmt.Fprintln(w, "The database was migrated by a newer ponder; upgrade ponder before changing it.")
+	}
+	for _, m := range migrations {
+		state := "pending"
+		if m.AppliedAt != nil {
+			state = "applied " + m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "  %04d %-32s %s\n", m.Version, m.Name, state)
+	}
+	return nil
+}
+
+func runDBMigrate(args []string) error {
+	fs := flag.NewFlagSet("db migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "List pending migrations without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	database, err := openUnmigratedDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if *dryRun {
+		return printSchemaVersion(ctx, os.Stdout, database)
+	}
+
+	applied, err := database.ApplyMigrations(ctx)
+	for _, m := range applied {
+		fmt.Printf("✓ Applied migration %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := database.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("Database is up to date at schema version %d\n", version)
+		return nil
+	}
+	fmt.Printf("✓ Database is at schema version %d\n", version)
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func TestDBMigrateAndVersionCommands(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	// Forget the migrations, as a database from before them would.
+	database, err := db.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if _, err := database.ExecContext(context.Background(), "DROP TABLE schema_migrations"); err != nil {
+		t.Fatalf("failed to drop schema_migrations: %v", err)
+	}
+	database.Close()
+
+	out := captureStdout(t, func() error { return runDB([]string{"version"}) })
+	if !strings.HasPrefix(out, "Schema version 0 (latest ") || !strings.Contains(out, "feature_dependencies") || !strings.Contains(out, "pending") {
+		t.Errorf("expected pending migrations, got:\n%s", out)
+	}
+
+	out = captureStdout(t, func() error { return runDB([]string{"migrate"}) })
+	if !strings.Contains(out, "✓ Applied migration 0001_feature_dependencies") {
+		t.Errorf("expected the migration to be applied, got:\n%s", out)
+	}
+
+	out = captureStdout(t, func() error { return runDB([]string{"migrate"}) })
+	if !strings.HasPrefix(out, "Database is up to date") {
+		t.Errorf("expected nothing left to migrate, got:\n%s", out)
+	}
+
+	out = captureStdout(t, func() error { return runDB([]string{"version"}) })
+	if strings.Contains(out, "pending") {
+		t.Errorf("expected every migration to be applied, got:\n%s", out)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// outputFormat is how a command prints what it lists: as a table for
+// people, or as JSON or tab-separated values for scripts.
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatTSV   outputFormat = "tsv"
+)
+
+// addFormatFlags adds --format and its --json shorthand to fs. The returned
+// function gives the chosen format once fs is parsed.
+func addFormatFlags(fs *flag.FlagSet) func() (outputFormat, error) {
+	format := fs.String("format", string(formatTable), "Output format: table, json or tsv")
+	asJSON := fs.Bool("json", false, "Print JSON (same as --format json)")
+	return func() (outputFormat, error) {
+		f := outputFormat(*format)
+		switch f {
+		case formatTable, formatJSON, formatTSV:
+		default:
+			return "", fmt.Errorf("invalid --format %q: must be table, json or tsv", *format)
+		}
+		if *asJSON {
+			if f != formatTable && f != formatJSON {
+				return "", fmt.Errorf("--json conflicts with --format %s", f)
+			}
+			f = formatJSON
+		}
+		return f, nil
+	}
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// tsvEscaper keeps each value on one line and in one column, escaping
+// backslashes, tabs and line breaks the way PostgreSQL's COPY does.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// writeTSV writes a header line and then one line per row, with the values
+// separated by tabs.
+func writeTSV(w io.Writer, header []string, rows [][]string) error {
+	for _, row := range append([][]string{header}, rows...) {
+		fields := make([]string, len(row))
+		for i, v := range row {
+			fields[i] = tsvEscaper.Replace(v)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// optional returns *s, or "" for nil.
+func optional(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout runs fn and returns what it wrote to stdout.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := fn()
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
+
+func TestFormatFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    outputFormat
+		wantErr bool
+	}{
+		{args: nil, want: formatTable},
+		{args: []string{"--json"}, want: formatJSON},
+		{args: []string{"--format", "tsv"}, want: formatTSV},
+		{args: []string{"--json", "--format", "json"}, want: formatJSON},
+		{args: []string{"--json", "--format", "tsv"}, wantErr: true},
+		{args: []string{"--format", "yaml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		format := addFormatFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("%v: parse failed: %v", tt.args, err)
+		}
+		got, err := format()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: expected an error, got %q", tt.args, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%v: got %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestListTasksJSON(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	output := captureStdout(t, func() error { return runListTasks([]string{"--json"}) })
+
+	var tasks []struct {
+		Name        string `json:"name"`
+		FeatureName string `json:"feature_name"`
+		Priority    int    `json:"priority"`
+		Status      string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &tasks); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "task1" || tasks[0].FeatureName != "feature1" ||
+		tasks[0].Priority != 10 || tasks[0].Status != "pending" {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestListFeaturesTSV(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	output := captureStdout(t, func() error { return runListFeatures([]string{"--format", "tsv"}) })
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	// feature1 and the default misc feature
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and two rows, got:\n%s", output)
+	}
+	if lines[0] != "id\tname\ttotal\tcompleted\tpercent_done\tblocked\tarchived\tdescription" {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+	fields := strings.Split(lines[1], "\t")
+	if len(fields) != 8 || fields[1] != "feature1" || fields[2] != "1" || fields[3] != "0" || fields[6] != "false" {
+		t.Errorf("unexpected row: %q", lines[1])
+	}
+}
+
+func TestWriteTSVEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTSV(&buf, []string{"a", "b"}, [][]string{{"one\ttwo", "line\nbreak\\"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "a\tb\none\\ttwo\tline\\nbreak\\\\\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	output := captureStdout(t, func() error { return runStatus([]string{"--json"}) })
+
+	var status struct {
+		Features       int            `json:"features"`
+		TotalTasks     int            `json:"total_tasks"`
+		AvailableTasks int            `json:"available_tasks"`
+		ByStatus       map[string]int `json:"by_status"`
+		NextAvailable  []struct {
+			Name string `json:"name"`
+		} `json:"next_available"`
+	}
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output)
+	}
+	if status.Features != 2 || status.TotalTasks != 1 || status.AvailableTasks != 1 {
+		t.Errorf("unexpected counts: %+v", status)
+	}
+	if status.ByStatus["pending"] != 1 || status.ByStatus["completed"] != 0 {
+		t.Errorf("unexpected breakdown: %v", status.ByStatus)
+	}
+	if len(status.NextAvailable) != 1 || status.NextAvailable[0].Name != "task1" {
+		t.Errorf("unexpected next available: %+v", status.NextAvailable)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+)
+
+// configReloadDelay lets an editor finish saving config.json before it is
+// read, and turns the burst of events a save makes into one reload.
+const configReloadDelay = 200 * time.Millisecond
+
+// configReloader is what watchConfig applies config.json changes to.
+type configReloader interface {
+	ReloadConfig(c orchestrator.ConfigReload)
+	ReportConfigError(err error)
+}
+
+// watchConfig applies changes to config.json to orch until ctx is done, and
+// rereads it whenever hup delivers, for file systems that don't report
+// changes. Only the settings that changed are applied, so values given as
+// flags stay until the file changes them.
+func watchConfig(ctx context.Context, orch configReloader, hup <-chan os.Signal) error {
+	configPath := filepath.Join(configDir(), "config.json")
+	prev, err := readConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	// Editors often replace the file rather than write to it, which a watch
+	// on the file itself would miss.
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		var reload <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == filepath.Clean(configPath) && !event.Has(fsnotify.Chmod) {
+					reload = time.After(configReloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				orch.ReportConfigError(err)
+			case <-hup:
+				reload = time.After(0)
+			case <-reload:
+				reload = nil
+				next, err := readConfigFile(configPath)
+				if err != nil {
+					orch.ReportConfigError(err)
+					continue
+				}
+				changes, err := configChanges(prev, next, configPath)
+				if err != nil {
+					orch.ReportConfigError(err)
+					continue
+				}
+				prev = next
+				orch.ReloadConfig(changes)
+			}
+		}
+	}()
+	return nil
+}
+
+// readConfigFile returns the contents of the config file, or an empty
+// object if there is none.
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []byte("{}"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// configChanges compares two versions of config.json, returning the new
+// values of the settings a running orchestrator can pick up and the names of
+// the other settings that changed. The new version must be valid.
+func configChanges(prev, next []byte, configPath string) (orchestrator.ConfigReload, error) {
+	var reload orchestrator.ConfigReload
+	defaults, err := parseWorkConfig(builtinWorkDefaults(), next, configPath)
+	if err != nil {
+		return reload, err
+	}
+
+	var before, after map[string]json.RawMessage
+	if err := json.Unmarshal(prev, &before); err != nil {
+		// The previous version was never applied; compare against nothing.
+		before = nil
+	}
+	if err := json.Unmarshal(next, &after); err != nil {
+		return reload, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+
+	var changed []string
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	for key, value := range after {
+		if !sameJSON(before[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+
+	for _, key := range changed {
+		switch key {
+		case "max_concurrency":
+			reload.MaxWorkers = defaults.MaxConcurrency
+		case "available_models":
+			reload.AvailableModels = defaults.AvailableModels
+		default:
+			reload.Ignored = append(reload.Ignored, key)
+		}
+	}
+	return reload, nil
+}
+
+// sameJSON reports whether a and b are the same JSON, ignoring whitespace.
+func sameJSON(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+)
+
+func TestConfigChanges(t *testing.T) {
+	prev := []byte(`{"model": "a/model", "max_concurrency": 2, "worktrees": false}`)
+	next := []byte(`{
+  "model": "a/model",
+  "max_concurrency": 4,
+  "available_models": ["a/model", "b/model"],
+  "worktrees": true
+}`)
+
+	changes, err := configChanges(prev, next, "config.json")
+	if err != nil {
+		t.Fatalf("configChanges failed: %v", err)
+	}
+	want := orchestrator.ConfigReload{
+		MaxWorkers:      4,
+		AvailableModels: []string{"a/model", "b/model"},
+		Ignored:         []string{"worktrees"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("expected %+v, got %+v", want, changes)
+	}
+
+	if changes, err := configChanges(next, next, "config.json"); err != nil || !reflect.DeepEqual(changes, orchestrator.ConfigReload{}) {
+		t.Errorf("expected no changes, got %+v (err %v)", changes, err)
+	}
+	if _, err := configChanges(prev, []byte(`{"max_concurrency": 0}`), "config.json"); err == nil {
+		t.Error("expected an invalid config to be rejected")
+	}
+}
+
+type recordingReloader struct {
+	reloads chan orchestrator.ConfigReload
+	errors  chan error
+}
+
+func (r *recordingReloader) ReloadConfig(c orchestrator.ConfigReload) { r.reloads <- c }
+func (r *recordingReloader) ReportConfigError(err error)              { r.errors <- err }
+
+func TestWatchConfig(t *testing.T) {
+	originalDBPath := dbPath
+	defer func() { dbPath = originalDBPath }()
+	dir := t.TempDir()
+	dbPath = filepath.Join(dir, "ponder.db")
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"max_concurrency": 2}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &recordingReloader{reloads: make(chan orchestrator.ConfigReload, 10), errors: make(chan error, 10)}
+	hup := make(chan os.Signal, 1)
+	if err := watchConfig(ctx, r, hup); err != nil {
+		t.Fatalf("watchConfig failed: %v", err)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"max_concurrency": 5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	select {
+	case c := <-r.reloads:
+		if c.MaxWorkers != 5 {
+			t.Errorf("expected max_concurrency 5 to be reloaded, got %+v", c)
+		}
+	case err := <-r.errors:
+		t.Fatalf("unexpected reload error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the config to reload")
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"max_concurrency": 5,`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	select {
+	case <-r.errors:
+	case c := <-r.reloads:
+		t.Fatalf("expected a broken config to be reported, got reload %+v", c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the config error")
+	}
+	// SIGHUP rereads the file without it changing.
+	hup <- syscall.SIGHUP
+	select {
+	case <-r.errors:
+	case c := <-r.reloads:
+		t.Fatalf("expected the broken config to be reported again, got reload %+v", c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for SIGHUP to reload the config")
+	}
+}
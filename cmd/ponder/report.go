@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func runReport(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: ponder report <durations|burndown|changelog|sessions> [--days N] [--since date] [--feature name] [--limit N] [--json]")
+	}
+	switch args[0] {
+	case "durations":
+		return runReportDurations(args[1:])
+	case "burndown":
+		return runReportBurndown(args[1:])
+	case "changelog":
+		return runReportChangelog(args[1:])
+	case "sessions":
+		return runReportSessions(args[1:])
+	default:
+		return fmt.Errorf("unknown report: %s", args[0])
+	}
+}
+
+func runReportDurations(args []string) error {
+	fs := flag.NewFlagSet("report durations", flag.ContinueOnError)
+	days := fs.Int("days", db.DefaultDurationDays, "Length of the period in days")
+	feature := fs.String("feature", "", "Only report tasks of this feature")
+	asJSON := fs.Bool("json", false, "Print the full report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder report durations [--days 30] [--feature name] [--json]")
+	}
+	if *days < 1 {
+		return fmt.Errorf("invalid --days: must be at least 1")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	report, err := database.GetDurationReport(context.Background(), *days, *feature)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printDurationReport(os.Stdout, report)
+	return nil
+}
+
+func printDurationReport(w io.Writer, r *models.DurationReport) {
+	fmt.Fprintf(w, "Tasks completed in the last %d days: %d\n", r.Days, len(r.Tasks))
+	if len(r.Tasks) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nBy feature:")
+	fmt.Fprintf(w, "  %-30s %-6s %-10s %-10s %-10s %s\n", "FEATURE", "DONE", "TOTAL", "AVERAGE", "ELAPSED", "AGENT")
+	for _, f := range r.Features {
+		fmt.Fprintf(w, "  %-30s %-6d %-10s %-10s %-10s %s\n", f.FeatureName, f.Completed,
+			formatSeconds(f.WallSeconds), formatSeconds(f.AvgWallSeconds), formatSeconds(f.ElapsedSeconds), formatSeconds(f.RunSeconds))
+	}
+
+	fmt.Fprintln(w, "\nBy task (longest first):")
+	fmt.Fprintf(w, "  %-40s %-8s %-10s %-6s %s\n", "TASK", "PRIORITY", "WALL", "RUNS", "AGENT")
+	for _, t := range r.Tasks {
+		fmt.Fprintf(w, "  %-40s %-8d %-10s %-6d %s\n", t.FeatureName+"/"+t.TaskName, t.Priority,
+			formatSeconds(t.WallSeconds), t.Runs, formatSeconds(t.RunSeconds))
+	}
+
+	fmt.Fprintln(w, "\nAverage agent run by day:")
+	for _, d := range r.RunTrend {
+		if d.AvgSeconds == nil {
+			continue
+		}
+		fmt.Fprintf(w, "  %s  %-10s (%d runs)\n", d.Date, formatSeconds(*d.AvgSeconds), d.Runs)
+	}
+}
+
+func runReportBurndown(args []string) error {
+	fs := flag.NewFlagSet("report burndown", flag.ContinueOnError)
+	days := fs.Int("days", db.DefaultBurndownDays, "Length of the period in days")
+	feature := fs.String("feature", "", "Only report tasks of this feature")
+	asJSON := fs.Bool("json", false, "Print the full report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder report burndown [--days 14] [--feature name] [--json]")
+	}
+	if *days < 1 {
+		return fmt.Errorf("invalid --days: must be at least 1")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	report, err := database.GetBurndownReport(context.Background(), *days, *feature)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printBurndownReport(os.Stdout, report)
+	return nil
+}
+
+func printBurndownReport(w io.Writer, r *models.BurndownReport) {
+	t := r.Total
+	fmt.Fprintf(w, "Tasks: %d (%d completed), estimated %s, %s remaining, %d unestimated\n",
+		t.Tasks, t.Completed, formatMinutes(float64(t.EstimateMinutes)), formatMinutes(float64(t.RemainingMinutes)), t.Unestimated)
+	if len(r.Features) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nBy feature (completed tasks: estimate vs actual):")
+	fmt.Fprintf(w, "  %-30s %-6s %-6s %-10s %-10s %-6s %-10s %s\n", "FEATURE", "TASKS", "DONE", "ESTIMATE", "ACTUAL", "RATIO", "REMAINING", "UNESTIMATED")
+	for _, f := range append(r.Features, r.Total) {
+		name := f.FeatureName
+		if f == r.Total {
+			name = "TOTAL"
+		}
+		ratio := "-"
+		if f.Ratio != nil {
+			ratio = fmt.Sprintf("%.2f", *f.Ratio)
+		}
+		fmt.Fprintf(w, "  %-30s %-6d %-6d %-10s %-10s %-6s %-10s %d\n", name, f.Tasks, f.Completed,
+			formatMinutes(float64(f.CompletedEstimateMinutes)), formatMinutes(f.ActualMinutes), ratio,
+			formatMinutes(float64(f.RemainingMinutes)), f.Unestimated)
+	}
+
+	fmt.Fprintln(w, "\nEstimated work remaining by day:")
+	for _, d := range r.Remaining {
+		fmt.Fprintf(w, "  %s  %s\n", d.Date, formatMinutes(float64(d.RemainingMinutes)))
+	}
+}
+
+func runReportChangelog(args []string) error {
+	fs := flag.NewFlagSet("report changelog", flag.ContinueOnError)
+	since := fs.String("since", "", "Only include work completed at or after this time (RFC 3339 or YYYY-MM-DD); all of it if unset")
+	feature := fs.String("feature", "", "Only include tasks of this feature")
+	asJSON := fs.Bool("json", false, "Print the changelog as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder report changelog [--since 2026-01-01] [--feature name] [--json]")
+	}
+	sinceAt, err := parseTaskTimeFlag("since", *since)
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	var from time.Time
+	if sinceAt != nil {
+		from = *sinceAt
+	}
+	changelog, err := database.GetChangelog(context.Background(), from, *feature)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changelog)
+	}
+	printChangelog(os.Stdout, changelog)
+	return nil
+}
+
+// printChangelog writes the changelog as Markdown: a section per feature
+// with a bullet per task, its summary indented under it.
+func printChangelog(w io.Writer, c *models.Changelog) {
+	fmt.Fprintln(w, "# Changelog")
+	if !c.Since.IsZero() {
+		fmt.Fprintf(w, "\nWork completed since %s.\n", c.Since.Format("2006-01-02"))
+	}
+	if len(c.Features) == 0 {
+		fmt.Fprintln(w, "\n_Nothing completed._")
+		return
+	}
+
+	for _, f := range c.Features {
+		fmt.Fprintf(w, "\n## %s\n\n", f.FeatureName)
+		for _, e := range f.Entries {
+			summary := strings.TrimSpace(e.Summary)
+			if summary == "" {
+				fmt.Fprintf(w, "- **%s** (%s)\n", e.TaskName, e.CompletedAt.Local().Format("2006-01-02"))
+				continue
+			}
+			lines := strings.Split(summary, "\n")
+			fmt.Fprintf(w, "- **%s** (%s): %s\n", e.TaskName, e.CompletedAt.Local().Format("2006-01-02"), lines[0])
+			for _, line := range lines[1:] {
+				if line = strings.TrimRight(line, " \t"); line == "" {
+					fmt.Fprintln(w)
+					continue
+				}
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+}
+
+func runReportSessions(args []string) error {
+	fs := flag.NewFlagSet("report sessions", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "Number of sessions to list, newest first (0 for all)")
+	asJSON := fs.Bool("json", false, "Print the sessions as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder report sessions [--limit 20] [--json]")
+	}
+	if *limit < 0 {
+		return fmt.Errorf("invalid --limit: must not be negative")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	sessions, err := database.ListRunSessions(context.Background(), *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sessions)
+	}
+	printSessions(os.Stdout, sessions)
+	return nil
+}
+
+// printSessions writes a row per run session. Sessions without an end are
+// still running, or crashed; their counts are as of their last finished task.
+func printSessions(w io.Writer, sessions []*models.RunSession) {
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No run sessions recorded yet")
+		return
+	}
+	fmt.Fprintf(w, "%-17s %-10s %-10s %-6s %-7s %-8s %s\n", "STARTED", "DURATION", "ATTEMPTED", "DONE", "FAILED", "COST", "MODELS")
+	for _, s := range sessions {
+		duration := "unfinished"
+		if s.EndedAt != nil {
+			duration = formatSeconds(float64(s.DurationMS) / 1000)
+		}
+		fmt.Fprintf(w, "%-17s %-10s %-10d %-6d %-7d %-8s %s\n", s.StartedAt.Local().Format("2006-01-02 15:04"), duration,
+			s.Attempted, s.Completed, s.Failed, fmt.Sprintf("$%.2f", s.CostUSD), formatModelMix(s.Models))
+	}
+}
+
+// formatMinutes renders a number of minutes as a duration such as 1h30m0s.
+func formatMinutes(m float64) string {
+	return formatSeconds(m * 60)
+}
+
+// formatSeconds renders a number of seconds as a duration such as 1h2m3s.
+func formatSeconds(s float64) string {
+	return (time.Duration(s * float64(time.Second))).Round(time.Second).String()
+}
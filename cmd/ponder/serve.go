@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/mcp"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+	"github.com/nick-dorsch/ponder/internal/server"
+)
+
+// defaultMCPAddr is where `ponder serve` serves MCP unless told otherwise.
+const defaultMCPAddr = "127.0.0.1:3920"
+
+// mcpHTTPServer returns the server for MCP over HTTP on addr, listening on
+// 127.0.0.1 when addr has no host, e.g. ":3920". MCP exposes every tool that
+// changes tasks, so with web_auth_token set every request needs it as a
+// bearer token, and listening beyond loopback without it is refused unless
+// readOnly.
+func mcpHTTPServer(addr string, handler http.Handler, readOnly bool) (*http.Server, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MCP address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = defaultWebHost
+	}
+	if webAuthToken != "" {
+		handler = server.RequireToken(webAuthToken, handler)
+	} else if !readOnly && !isLoopback(host) {
+		return nil, fmt.Errorf("serving MCP on %s needs web_auth_token, or anyone who can reach it can change tasks", host)
+	}
+	return &http.Server{
+		Addr:              net.JoinHostPort(host, port),
+		Handler:           handler,
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+	}, nil
+}
+
+// runServe runs the web UI, MCP over HTTP and optionally the orchestrator in
+// one process, logging to one place. SIGHUP rereads config.json; SIGINT or
+// SIGTERM stops the orchestrator first, letting its agents finish, then the
+// servers, then writes out the snapshot.
+func runServe(args []string) error {
+	serveFlags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	enableWeb := serveFlags.Bool("web", true, "Serve the web UI and REST API")
+	host := serveFlags.String("host", defaultWebHost, "Address for the web UI to listen on (0.0.0.0 for all interfaces)")
+	port := serveFlags.String("port", "8000", "Port for the web UI")
+	mcpAddr := serveFlags.String("mcp", defaultMCPAddr, "Address to serve MCP over HTTP on, 127.0.0.1 unless it names a host (empty to disable)")
+	orchestrate := serveFlags.Bool("orchestrate", false, "Also run agents on available tasks, as `ponder --no-tui` does")
+	interval := serveFlags.Duration("interval", 5*time.Second, "Polling interval when idle with --orchestrate (0 to stop once the backlog is done)")
+	readOnly := serveFlags.Bool("read-only", false, "Refuse every web and MCP request that changes something")
+	logFormat := serveFlags.String("log-format", "text", "Log format (text or json)")
+	logFile := serveFlags.String("log-file", "", "Write the log to a file instead of stdout")
+	if err := serveFlags.Parse(args); err != nil {
+		return err
+	}
+	if serveFlags.NArg() > 0 {
+		return fmt.Errorf("usage: ponder serve [--web=false] [--mcp addr] [--orchestrate] [flags]")
+	}
+	if !*enableWeb && *mcpAddr == "" && !*orchestrate {
+		return fmt.Errorf("nothing to serve: enable the web UI, MCP or --orchestrate")
+	}
+	format, err := orchestrator.ParseLogFormat(*logFormat)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		if err != nil {
+			return fmt.Errorf("failed to create log file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	log := &serviceLog{w: out, format: format}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := database.Init(ctx); err != nil {
+		return err
+	}
+	database.SetAvailabilityPolicy(availabilityPolicy)
+	exportSnapshotOnChange(database)
+
+	var orch *orchestrator.Orchestrator
+	var reloader configReloader = log
+	if *orchestrate {
+		defaults, err := loadWorkDefaults()
+		if err != nil {
+			return err
+		}
+		opts := defaults.workOptions()
+		opts.Interval = *interval
+		opts.NoTUI = true
+		opts.LogFormat = format
+		orch = newOrchestrator(database, opts)
+		finishRun, err := prepareRun(ctx, database, orch, opts)
+		if err != nil {
+			return err
+		}
+		defer finishRun(os.Stderr)
+		reloader = orch
+	}
+	if err := watchConfig(ctx, reloader, hangups(ctx)); err != nil {
+		log.printf("config", "changes will need a restart: %v", err)
+	}
+
+	sup := supervisor{logf: log.printf}
+	if *enableWeb {
+		srv := server.NewServer(database)
+		srv.SetAuthToken(webAuthToken)
+		srv.SetReadOnly(*readOnly)
+		url := webURL(*host, *port)
+		if orch != nil {
+			srv.SetOrchestrator(orch)
+			orch.WebURL = url
+		}
+		if webAuthToken == "" && !*readOnly && !isLoopback(*host) {
+			log.printf("web", "warning: serving on %s without web_auth_token; anyone who can reach it can change tasks", *host)
+		}
+		hs := &http.Server{Addr: net.JoinHostPort(*host, *port), Handler: srv.Handler()}
+		sup.add("web", func(ctx context.Context) error {
+			log.printf("web", "serving at %s", url)
+			return runHTTPServer(ctx, hs)
+		})
+	}
+
+	if *mcpAddr != "" {
+		err = database.PersistStaging(ctx, func(err error) {
+			log.printf("mcp", "failed to save staged changes: %v", err)
+		})
+		if err != nil {
+			return err
+		}
+		go database.RunStagingCleanup(ctx, db.DefaultStagingTTL)
+
+		newServer := mcp.NewServer
+		if *readOnly {
+			newServer = mcp.NewReadOnlyServer
+		}
+		s := newServer(database)
+		hs, err := mcpHTTPServer(*mcpAddr, mcp.NewHTTPHandler(s), *readOnly)
+		if err != nil {
+			return err
+		}
+		sup.add("mcp", func(ctx context.Context) error {
+			go mcp.NotifyChanges(ctx, s, database, mcp.DefaultNotifyInterval)
+			log.printf("mcp", "serving on %s (streamable HTTP at /mcp, SSE at /sse)", hs.Addr)
+			return runHTTPServer(ctx, hs)
+		})
+	}
+
+	if orch != nil {
+		sup.add("orchestrator", func(ctx context.Context) error {
+			log.printf("orchestrator", "running up to %d agents", orch.GetMaxWorkers())
+			return orchestrator.RunHeadless(ctx, orch, log, format)
+		})
+	}
+
+	return sup.run(ctx)
+}
+
+// serviceLog is the one log `ponder serve` writes: lines from each service,
+// and the orchestrator's events, which it writes through Write.
+type serviceLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format orchestrator.LogFormat
+}
+
+// serviceEvent is a service's line in the JSON log, alongside the
+// orchestrator's events.
+type serviceEvent struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Service string    `json:"service"`
+	Message string    `json:"message"`
+}
+
+func (l *serviceLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// printf logs a message from the named service.
+func (l *serviceLog) printf(service, format string, args ...any) {
+	now := time.Now().UTC()
+	msg := fmt.Sprintf(format, args...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.format == orchestrator.LogFormatJSON {
+		line, _ := json.Marshal(serviceEvent{Time: now, Event: "service", Service: service, Message: msg})
+		l.w.Write(append(line, '\n'))
+		return
+	}
+	fmt.Fprintf(l.w, "%s [%s] %s\n", now.Format(time.RFC3339), service, msg)
+}
+
+// ReloadConfig reports config.json changes when there is no orchestrator to
+// apply them to; the servers only pick settings up on restart.
+func (l *serviceLog) ReloadConfig(c orchestrator.ConfigReload) {
+	if len(c.Ignored) > 0 {
+		l.printf("config", "reloaded: restart to apply %s", strings.Join(c.Ignored, ", "))
+	}
+}
+
+func (l *serviceLog) ReportConfigError(err error) {
+	l.printf("config", "not reloaded: %v", err)
+}
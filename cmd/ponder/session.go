@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// startSession records the start of a run session for orch, and keeps its
+// totals up to date as tasks finish. Call the returned function once orch has
+// stopped: it stores the session's end and writes its summary to w. Failing
+// to record the session doesn't stop the run.
+func startSession(database *db.DB, orch *orchestrator.Orchestrator) (finish func(w io.Writer)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session := &models.RunSession{StartedAt: orch.GetSessionStats().StartedAt}
+	if err := database.StartRunSession(ctx, session); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: this session won't be recorded: %v\n", err)
+		session = nil
+	}
+
+	// Workers finish concurrently, so save one at a time with the latest
+	// stats rather than letting an older update land last.
+	var mu sync.Mutex
+	save := func(ended bool) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		fillSession(session, orch.GetSessionStats(), time.Now())
+		if !ended {
+			session.EndedAt = nil
+		}
+		return database.UpdateRunSession(ctx, session)
+	}
+	if session != nil {
+		// A failed update is made good by the next one, and reported by finish.
+		orch.SetSessionHook(func() { save(false) })
+	}
+
+	return func(w io.Writer) {
+		printSessionSummary(w, orch.GetSessionStats(), time.Now())
+		if session == nil {
+			return
+		}
+		orch.SetSessionHook(nil)
+		if err := save(true); err != nil {
+			fmt.Fprintf(w, "Warning: failed to record session: %v\n", err)
+		}
+	}
+}
+
+// fillSession copies the stats of a session that ended at end into s.
+func fillSession(s *models.RunSession, stats orchestrator.SessionStats, end time.Time) {
+	s.EndedAt = &end
+	s.Models = stats.Models
+	s.Attempted = stats.Attempted
+	s.Completed = stats.Completed
+	s.Failed = stats.Failed
+	s.TokensIn = stats.Usage.TokensIn
+	s.TokensOut = stats.Usage.TokensOut
+	s.CostUSD = stats.Usage.CostUSD
+	s.DurationMS = end.Sub(stats.StartedAt).Milliseconds()
+}
+
+// printSessionSummary writes what a session that ended at end got done.
+func printSessionSummary(w io.Writer, stats orchestrator.SessionStats, end time.Time) {
+	duration := end.Sub(stats.StartedAt).Round(time.Second)
+	if stats.Attempted == 0 {
+		fmt.Fprintf(w, "Session ended after %s: no tasks attempted\n", duration)
+		return
+	}
+	fmt.Fprintf(w, "Session ended after %s: %d tasks attempted, %d completed, %d failed\n",
+		duration, stats.Attempted, stats.Completed, stats.Failed)
+	fmt.Fprintf(w, "  Models: %s\n", formatModelMix(stats.Models))
+	if !stats.Usage.IsZero() {
+		fmt.Fprintf(w, "  Usage:  $%.2f (%d in / %d out tokens)\n", stats.Usage.CostUSD, stats.Usage.TokensIn, stats.Usage.TokensOut)
+	}
+}
+
+// formatModelMix lists the models of a session by how many runs they did,
+// most first.
+func formatModelMix(mix map[string]int) string {
+	if len(mix) == 0 {
+		return "-"
+	}
+	names := slices.SortedFunc(maps.Keys(mix), func(a, b string) int {
+		if mix[a] != mix[b] {
+			return mix[b] - mix[a]
+		}
+		return strings.Compare(a, b)
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s ×%d", name, mix[name])
+	}
+	return strings.Join(parts, ", ")
+}
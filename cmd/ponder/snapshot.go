@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/snapshot"
+)
+
+// defaultSnapshotHistoryKeep is how many snapshots are kept when
+// snapshot_history does not say.
+const defaultSnapshotHistoryKeep = 20
+
+// snapshotHistory is the snapshot history configured in config.json, applied
+// wherever the snapshot is exported after changes.
+var snapshotHistory db.SnapshotHistory
+
+type snapshotHistoryConfig struct {
+	Dir  string `json:"dir,omitempty"`
+	Keep *int   `json:"keep,omitempty"`
+}
+
+// parse fills in the defaults: snapshots go to .ponder/snapshots and the
+// newest twenty are kept.
+func (sc *snapshotHistoryConfig) parse() (db.SnapshotHistory, error) {
+	h := db.SnapshotHistory{Dir: sc.Dir, Keep: defaultSnapshotHistoryKeep}
+	if h.Dir == "" {
+		h.Dir = snapshotHistoryDir()
+	}
+	if sc.Keep != nil {
+		if *sc.Keep < 0 {
+			return db.SnapshotHistory{}, fmt.Errorf("keep must be >= 0")
+		}
+		h.Keep = *sc.Keep
+	}
+	return h, nil
+}
+
+// snapshotHistoryDir is where kept snapshots are looked for. Without
+// snapshot_history it is the default directory, so snapshots kept before the
+// history was turned off can still be restored.
+func snapshotHistoryDir() string {
+	if snapshotHistory.Dir != "" {
+		return snapshotHistory.Dir
+	}
+	return filepath.Join(configDir(), "snapshots")
+}
+
+func runSnapshot(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: ponder snapshot <command> [arguments]")
+		fmt.Println("\nCommands:")
+		fmt.Println("  export    Write a snapshot, optionally including archived records")
+		fmt.Println("  import    Merge a snapshot into the database, or mirror it with --mirror")
+		fmt.Println("  merge     Three-way merge snapshot files (usable as a git merge driver)")
+		fmt.Println("  history   List the snapshots kept by snapshot_history")
+		fmt.Println("  restore   Roll the database back to a kept snapshot")
+		return nil
+	}
+
+	command := args[0]
+	subArgs := args[1:]
+
+	switch command {
+	case "export":
+		return runSnapshotExport(subArgs)
+	case "import":
+		return runSnapshotImport(subArgs)
+	case "merge":
+		return runSnapshotMerge(subArgs)
+	case "history":
+		return runSnapshotHistory(subArgs)
+	case "restore":
+		return runSnapshotRestore(subArgs)
+	default:
+		return fmt.Errorf("unknown snapshot command: %s", command)
+	}
+}
+
+func runSnapshotExport(args []string) error {
+	fs := flag.NewFlagSet("snapshot export", flag.ContinueOnError)
+	includeArchived := fs.Bool("include-archived", false, "Also write archived features and tasks")
+	output := fs.String("output", "", "Write here instead of the snapshot path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder snapshot export [--include-archived] [--output file]")
+	}
+	if *output == "" {
+		*output = snapshotPath
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		return err
+	}
+	if err := database.ExportSnapshotWithOptions(ctx, *output, db.SnapshotOptions{IncludeArchived: *includeArchived}); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Wrote snapshot to %s\n", *output)
+	return nil
+}
+
+// runSnapshotImport merges a snapshot into the database. With --mirror the
+// features, tasks and dependencies it doesn't have are deleted, and
+// --dry-run lists them without changing anything.
+func runSnapshotImport(args []string) error {
+	fs := flag.NewFlagSet("snapshot import", flag.ContinueOnError)
+	input := fs.String("input", "", "Read this file instead of the snapshot path")
+	mirror := fs.Bool("mirror", false, "Delete features, tasks and dependencies missing from the snapshot")
+	dryRun := fs.Bool("dry-run", false, "Only list what --mirror would delete")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder snapshot import [--input file] [--mirror [--dry-run]]")
+	}
+	if *dryRun && !*mirror {
+		return fmt.Errorf("--dry-run lists what --mirror would delete; pass both")
+	}
+	if *input == "" {
+		*input = snapshotPath
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := database.ImportSnapshotWithOptions(ctx, *input, db.ImportOptions{Mirror: *mirror, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	verb := "Deleted"
+	if *dryRun {
+		verb = "Would delete"
+	}
+	for _, group := range []struct {
+		kind  string
+		names []string
+	}{
+		{"feature", result.DeletedFeatures},
+		{"task", result.DeletedTasks},
+		{"dependency", result.DeletedDependencies},
+	} {
+		for _, name := range group.names {
+			fmt.Printf("%s %s %s\n", verb, group.kind, name)
+		}
+	}
+	if *dryRun {
+		if len(result.DeletedFeatures)+len(result.DeletedTasks)+len(result.DeletedDependencies) == 0 {
+			fmt.Println("Nothing would be deleted")
+		}
+		return nil
+	}
+	fmt.Printf("✓ Imported snapshot from %s\n", *input)
+	return nil
+}
+
+// runSnapshotMerge follows git's merge driver contract: the result replaces
+// the --ours file unless --output is given, and conflicts make it fail.
+func runSnapshotMerge(args []string) error {
+	fs := flag.NewFlagSet("snapshot merge", flag.ContinueOnError)
+	basePath := fs.String("base", "", "Common ancestor snapshot (%O)")
+	oursPath := fs.String("ours", "", "Our snapshot (%A); receives the result by default")
+	theirsPath := fs.String("theirs", "", "Their snapshot (%B)")
+	output := fs.String("output", "", "Write the result here instead of over --ours (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *basePath == "" || *oursPath == "" || *theirsPath == "" || fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder snapshot merge --base file --ours file --theirs file [--output file]")
+	}
+
+	base, err := os.ReadFile(*basePath)
+	if err != nil {
+		return fmt.Errorf("failed to read base snapshot: %w", err)
+	}
+	ours, err := os.ReadFile(*oursPath)
+	if err != nil {
+		return fmt.Errorf("failed to read our snapshot: %w", err)
+	}
+	theirs, err := os.ReadFile(*theirsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read their snapshot: %w", err)
+	}
+
+	var merged bytes.Buffer
+	result, err := snapshot.Merge(bytes.NewReader(base), bytes.NewReader(ours), bytes.NewReader(theirs), &merged)
+	if err != nil {
+		return err
+	}
+
+	switch *output {
+	case "-":
+		if _, err := os.Stdout.Write(merged.Bytes()); err != nil {
+			return err
+		}
+	case "":
+		*output = *oursPath
+		fallthrough
+	default:
+		if err := os.WriteFile(*output, merged.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write merged snapshot: %w", err)
+		}
+	}
+
+	if n := len(result.Conflicts); n > 0 {
+		for _, key := range result.Conflicts {
+			fmt.Fprintf(os.Stderr, "CONFLICT: %s\n", key)
+		}
+		return fmt.Errorf("snapshot merge left %d conflict(s)", n)
+	}
+	return nil
+}
+
+func runSnapshotHistory(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: ponder snapshot history")
+	}
+	versions, err := db.ListSnapshotVersions(snapshotHistoryDir())
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Printf("No snapshots kept in %s; set snapshot_history in config.json to keep them\n", snapshotHistoryDir())
+		return nil
+	}
+	for _, v := range versions {
+		fmt.Printf("%s  %s\n", v.Timestamp, v.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// runSnapshotRestore makes the database match a kept snapshot. The current
+// state is exported, and kept, afterwards, so a restore can be undone the
+// same way.
+func runSnapshotRestore(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ponder snapshot restore <timestamp>")
+	}
+	version, err := db.FindSnapshotVersion(snapshotHistoryDir(), args[0])
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := database.RestoreSnapshot(ctx, version.Path); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Restored snapshot %s\n", version.Timestamp)
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func TestSnapshotMerge(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	feature := `{"record_type":"feature","id":"f1","name":"core","description":"d","specification":"s","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}` + "\n"
+	base := write("base.jsonl", feature)
+	ours := write("ours.jsonl", feature+`{"record_type":"task","id":"t1","name":"a","feature_name":"core","status":"pending"}`+"\n")
+	theirs := write("theirs.jsonl", feature+`{"record_type":"task","id":"t2","name":"b","feature_name":"core","status":"pending"}`+"\n")
+
+	if err := runSnapshot([]string{"merge", "--base", base, "--ours", ours}); err == nil {
+		t.Error("expected usage error without --theirs")
+	}
+
+	// Like a git merge driver, the result replaces the ours file.
+	if err := runSnapshot([]string{"merge", "--base", base, "--ours", ours, "--theirs", theirs}); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	data, err := os.ReadFile(ours)
+	if err != nil {
+		t.Fatalf("failed to read result: %v", err)
+	}
+	if !strings.Contains(string(data), `"name":"a"`) || !strings.Contains(string(data), `"name":"b"`) {
+		t.Errorf("expected both tasks in merged snapshot, got:\n%s", data)
+	}
+
+	conflicting := write("conflict.jsonl", feature+`{"record_type":"task","id":"t1","name":"a","feature_name":"core","status":"blocked"}`+"\n")
+	baseWithTask := write("base2.jsonl", feature+`{"record_type":"task","id":"t1","name":"a","feature_name":"core","status":"pending"}`+"\n")
+	oursDone := write("ours2.jsonl", feature+`{"record_type":"task","id":"t1","name":"a","feature_name":"core","status":"in_progress"}`+"\n")
+	output := filepath.Join(dir, "out.jsonl")
+	err = runSnapshot([]string{"merge", "--base", baseWithTask, "--ours", oursDone, "--theirs", conflicting, "--output", output})
+	if err == nil || !strings.Contains(err.Error(), "1 conflict") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	if data, _ := os.ReadFile(output); !strings.Contains(string(data), "<<<<<<< ours") {
+		t.Errorf("expected conflict markers in output, got:\n%s", data)
+	}
+}
+
+func TestSnapshotHistoryAndRestore(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+	snapshotHistory = db.SnapshotHistory{Dir: filepath.Join(tmpDir, ".ponder", "snapshots"), Keep: 5}
+	defer func() { snapshotHistory = db.SnapshotHistory{} }()
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runAddTask([]string{"--feature", "feature1", "good"}); err != nil {
+		t.Fatalf("runAddTask failed: %v", err)
+	}
+	// Kept snapshots are named to the millisecond.
+	time.Sleep(5 * time.Millisecond)
+	if err := runAddFeature([]string{"bulk"}); err != nil {
+		t.Fatalf("runAddFeature failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := runAddTask([]string{"--feature", "bulk", "--depends-on", "feature1/good", "bad"}); err != nil {
+		t.Fatalf("runAddTask failed: %v", err)
+	}
+
+	versions, err := db.ListSnapshotVersions(snapshotHistory.Dir)
+	if err != nil {
+		t.Fatalf("ListSnapshotVersions failed: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected a snapshot kept per command, got %d", len(versions))
+	}
+	if err := runSnapshot([]string{"history"}); err != nil {
+		t.Errorf("snapshot history failed: %v", err)
+	}
+
+	if err := runSnapshot([]string{"restore", "19990101"}); err == nil {
+		t.Error("expected an error for a timestamp that matches nothing")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := runSnapshot([]string{"restore", versions[2].Timestamp}); err != nil {
+		t.Fatalf("snapshot restore failed: %v", err)
+	}
+
+	database, err := db.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+	tasks, err := database.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.FeatureName+"/"+task.Name)
+	}
+	if strings.Join(names, ",") != "feature1/task1,feature1/good" {
+		t.Errorf("expected the tasks before the bulk edit, got %v", names)
+	}
+	if f, _ := database.GetFeatureByName(ctx, "bulk"); f != nil {
+		t.Error("expected the feature added after the snapshot to be removed")
+	}
+
+	// The restored state is kept too, so the restore can be undone.
+	if versions, _ := db.ListSnapshotVersions(snapshotHistory.Dir); len(versions) != 4 {
+		t.Errorf("expected the restored state to be kept, got %d versions", len(versions))
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/embed/starters"
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// starterRecord is one line of a starter template: a feature, or a task in
+// the shape of a bulk task entry.
+type starterRecord struct {
+	RecordType string `json:"record_type"`
+	db.BulkTask
+}
+
+// starterTemplate is the parsed contents of a starter template.
+type starterTemplate struct {
+	Features []*models.Feature
+	Tasks    []db.BulkTask
+}
+
+// userStarterDir is where `ponder init --template` looks for templates of
+// the user's own, before the built-in ones.
+func userStarterDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ponder", "templates")
+}
+
+// starterNames lists the built-in starter templates.
+func starterNames() []string {
+	entries, _ := fs.Glob(starters.FS, "*.jsonl")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry, ".jsonl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadStarter reads the starter template called name: a path to a .jsonl
+// file, a file in userStarterDir, or one of the built-in templates.
+func loadStarter(name string) (*starterTemplate, error) {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasSuffix(name, ".jsonl") || strings.ContainsRune(name, os.PathSeparator):
+		data, err = os.ReadFile(name)
+	default:
+		err = fs.ErrNotExist
+		if dir := userStarterDir(); dir != "" {
+			data, err = os.ReadFile(filepath.Join(dir, name+".jsonl"))
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			data, err = starters.FS.ReadFile(name + ".jsonl")
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("unknown template %q (built-in: %s)", name, strings.Join(starterNames(), ", "))
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	return parseStarter(data)
+}
+
+// parseStarter parses a starter template, one JSON record per line.
+func parseStarter(data []byte) (*starterTemplate, error) {
+	tmpl := &starterTemplate{}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var rec starterRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch rec.RecordType {
+		case "feature":
+			if rec.Name == "" {
+				return nil, fmt.Errorf("line %d: feature has no name", i+1)
+			}
+			tmpl.Features = append(tmpl.Features, &models.Feature{
+				Name:          rec.Name,
+				Description:   rec.Description,
+				Specification: rec.Specification,
+			})
+		case "task":
+			tmpl.Tasks = append(tmpl.Tasks, rec.BulkTask)
+		default:
+			return nil, fmt.Errorf("line %d: unknown record_type %q", i+1, rec.RecordType)
+		}
+	}
+	return tmpl, nil
+}
+
+// seedStarter creates the template's features that don't exist yet, then
+// its tasks and their dependencies in one batch.
+func seedStarter(ctx context.Context, database *db.DB, tmpl *starterTemplate) (features, tasks int, err error) {
+	for _, f := range tmpl.Features {
+		existing, err := database.GetFeatureByName(ctx, f.Name)
+		if err != nil {
+			return features, 0, err
+		}
+		if existing != nil {
+			continue
+		}
+		if err := database.CreateFeature(ctx, f); err != nil {
+			return features, 0, fmt.Errorf("failed to create feature %s: %w", f.Name, err)
+		}
+		features++
+	}
+	if len(tmpl.Tasks) == 0 {
+		return features, 0, nil
+	}
+	created, err := database.CreateTasks(ctx, "misc", tmpl.Tasks)
+	if err != nil {
+		return features, 0, fmt.Errorf("failed to create tasks: %w", err)
+	}
+	return features, len(created), nil
+}
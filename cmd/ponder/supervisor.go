@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// httpShutdownTimeout is how long an HTTP service waits for requests in
+// flight when it is stopped.
+const httpShutdownTimeout = 5 * time.Second
+
+// httpReadHeaderTimeout bounds how long a client may take to send a
+// request's headers, so slow ones can't hold connections open.
+const httpReadHeaderTimeout = 10 * time.Second
+
+// service is one long-running part of a process, such as the web UI or the
+// orchestrator. run blocks until ctx is cancelled, then shuts down cleanly
+// and returns; returning earlier, with or without an error, stops the whole
+// process.
+type service struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// supervisor runs services together. When ctx is done or any service
+// returns, the others are stopped one at a time in reverse start order, so
+// services started first, like the web UI, stay up while later ones, like
+// the orchestrator waiting for its agents, finish.
+type supervisor struct {
+	services []service
+	// logf, if set, reports services stopping and failing.
+	logf func(service, format string, args ...any)
+}
+
+func (s *supervisor) add(name string, run func(ctx context.Context) error) {
+	s.services = append(s.services, service{name: name, run: run})
+}
+
+func (s *supervisor) log(name, format string, args ...any) {
+	if s.logf != nil {
+		s.logf(name, format, args...)
+	}
+}
+
+// run starts the services and waits for them to stop. It returns the first
+// error a service returned, prefixed with its name.
+func (s *supervisor) run(ctx context.Context) error {
+	type running struct {
+		name   string
+		cancel context.CancelFunc
+		done   chan error
+	}
+	started := make([]running, 0, len(s.services))
+	exited := make(chan string, len(s.services))
+	for _, svc := range s.services {
+		// Each service gets its own context so they can be stopped in order.
+		svcCtx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			err := svc.run(svcCtx)
+			done <- err
+			exited <- svc.name
+		}()
+		started = append(started, running{name: svc.name, cancel: cancel, done: done})
+	}
+
+	select {
+	case <-ctx.Done():
+		s.log("supervisor", "shutting down")
+	case name := <-exited:
+		s.log("supervisor", "%s stopped; shutting down", name)
+	}
+
+	var firstErr error
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		r.cancel()
+		if err := <-r.done; err != nil && !errors.Is(err, context.Canceled) {
+			s.log(r.name, "failed: %v", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", r.name, err)
+			}
+		}
+		s.log(r.name, "stopped")
+	}
+	return firstErr
+}
+
+// runHTTPServer serves hs until ctx is done, then shuts it down, waiting for
+// requests in flight.
+func runHTTPServer(ctx context.Context, hs *http.Server) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- hs.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := hs.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down: %w", err)
+		}
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
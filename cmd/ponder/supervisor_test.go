@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/orchestrator"
+)
+
+func TestSupervisorStopsInReverseOrder(t *testing.T) {
+	var mu sync.Mutex
+	var stopped []string
+	var sup supervisor
+	for _, name := range []string{"web", "mcp", "orchestrator"} {
+		sup.add(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sup.run(ctx) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the services to stop")
+	}
+	if want := []string{"orchestrator", "mcp", "web"}; !reflect.DeepEqual(stopped, want) {
+		t.Errorf("expected services to stop in order %v, got %v", want, stopped)
+	}
+}
+
+func TestSupervisorFailureStopsOthers(t *testing.T) {
+	var logged bytes.Buffer
+	sup := supervisor{logf: func(service, format string, args ...any) {
+		fmt.Fprintf(&logged, "[%s] %s\n", service, fmt.Sprintf(format, args...))
+	}}
+	sup.add("web", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	sup.add("mcp", func(ctx context.Context) error {
+		return errors.New("address already in use")
+	})
+
+	err := sup.run(context.Background())
+	if err == nil || err.Error() != "mcp: address already in use" {
+		t.Fatalf("expected the mcp failure, got %v", err)
+	}
+	for _, want := range []string{"[supervisor] mcp stopped; shutting down", "[mcp] failed: address already in use", "[web] stopped"} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("expected log to contain %q, got:\n%s", want, logged.String())
+		}
+	}
+}
+
+func TestServiceLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := &serviceLog{w: &buf, format: orchestrator.LogFormatJSON}
+	log.printf("web", "serving at %s", "http://localhost:8000/")
+	log.ReloadConfig(orchestrator.ConfigReload{Ignored: []string{"web_auth_token"}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"event":"service","service":"web","message":"serving at http://localhost:8000/"`) {
+		t.Errorf("unexpected line %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"service":"config","message":"reloaded: restart to apply web_auth_token"`) {
+		t.Errorf("unexpected line %s", lines[1])
+	}
+
+	buf.Reset()
+	log.format = orchestrator.LogFormatText
+	log.printf("mcp", "stopped")
+	if !strings.HasSuffix(buf.String(), " [mcp] stopped\n") {
+		t.Errorf("unexpected text line %q", buf.String())
+	}
+}
+
+func TestRunServeNothingToServe(t *testing.T) {
+	err := runServe([]string{"--web=false", "--mcp="})
+	if err == nil || !strings.Contains(err.Error(), "nothing to serve") {
+		t.Errorf("expected nothing to serve, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func runTemplate(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: ponder template <command> [arguments]")
+		fmt.Println("\nCommands:")
+		fmt.Println("  add    Save a task template, optionally recurring")
+		fmt.Println("  list   List the templates")
+		fmt.Println("  use    Create a task from a template")
+		fmt.Println("  rm     Remove a template")
+		return nil
+	}
+
+	command := args[0]
+	subArgs := args[1:]
+
+	switch command {
+	case "add":
+		return runTemplateAdd(subArgs)
+	case "list":
+		return runTemplateList(subArgs)
+	case "use":
+		return runTemplateUse(subArgs)
+	case "rm":
+		return runTemplateRemove(subArgs)
+	default:
+		return fmt.Errorf("unknown template command: %s", command)
+	}
+}
+
+func runTemplateAdd(args []string) error {
+	fs := flag.NewFlagSet("template add", flag.ContinueOnError)
+	featureName := fs.String("feature", "misc", "Feature the tasks belong to")
+	taskName := fs.String("task-name", "", "Name of the tasks, with {{variable}} placeholders (defaults to the template name)")
+	description := fs.String("description", "", "Short task description")
+	specification := fs.String("spec", "", "Detailed task specification, with {{variable}} placeholders")
+	priority := fs.Int("priority", 5, "Priority from 0 (lowest) to 10 (highest)")
+	testsRequired := fs.Bool("tests", true, "Whether the tasks require tests")
+	every := fs.String("every", "", "Create a task daily, weekly, monthly or after a duration such as 36h")
+	start := fs.String("start", "", "When the first recurring task is due (RFC 3339 or YYYY-MM-DD; defaults to now)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder template add [--feature name] [--task-name name] [--every recurrence] [--start time] [--priority n] [--description text] [--spec text] <name>")
+	}
+	if *start != "" && *every == "" {
+		return fmt.Errorf("--start needs --every")
+	}
+	startAt, err := parseTaskTimeFlag("start", *start)
+	if err != nil {
+		return err
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	tmpl := &models.TaskTemplate{
+		Name:          fs.Arg(0),
+		FeatureName:   *featureName,
+		TaskName:      *taskName,
+		Description:   *description,
+		Specification: *specification,
+		Priority:      *priority,
+		TestsRequired: *testsRequired,
+		Recurrence:    *every,
+		NextRunAt:     startAt,
+	}
+	if tmpl.TaskName == "" {
+		tmpl.TaskName = tmpl.Name
+		if tmpl.Recurrence != "" {
+			tmpl.TaskName += "-{{date}}"
+		}
+	}
+	if err := database.CreateTemplate(ctx, tmpl); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Created template %s\n", tmpl.Name)
+	if tmpl.NextRunAt != nil {
+		fmt.Printf("  Next task due %s\n", tmpl.NextRunAt.Local().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func runTemplateList(args []string) error {
+	fs := flag.NewFlagSet("template list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	templates, err := database.ListTemplates(ctx)
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		fmt.Println("No templates.")
+		return nil
+	}
+
+	fmt.Printf("%-20s %-15s %-30s %-10s %-20s %s\n", "NAME", "FEATURE", "TASK NAME", "EVERY", "NEXT", "VARIABLES")
+	for _, tmpl := range templates {
+		next := ""
+		if tmpl.NextRunAt != nil {
+			next = tmpl.NextRunAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-20s %-15s %-30s %-10s %-20s %s\n", tmpl.Name, tmpl.FeatureName, tmpl.TaskName,
+			tmpl.Recurrence, next, strings.Join(db.TemplateVariables(tmpl), ", "))
+	}
+	return nil
+}
+
+func runTemplateUse(args []string) error {
+	fs := flag.NewFlagSet("template use", flag.ContinueOnError)
+	vars := make(map[string]string)
+	fs.Func("var", "Set a template variable, as NAME=VALUE (repeatable)", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected NAME=VALUE, got %q", s)
+		}
+		vars[name] = value
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder template use [--var NAME=VALUE]... <name>")
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	tmpl, err := database.GetTemplateByName(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if tmpl == nil {
+		return fmt.Errorf("template not found: %s", fs.Arg(0))
+	}
+	task, err := database.CreateTaskFromTemplate(ctx, tmpl, vars)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Created task %s/%s\n", task.FeatureName, task.Name)
+	return nil
+}
+
+func runTemplateRemove(args []string) error {
+	fs := flag.NewFlagSet("template rm", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ponder template rm <name>")
+	}
+
+	database, ctx, err := openBacklogDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	tmpl, err := database.GetTemplateByName(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if tmpl == nil {
+		return fmt.Errorf("template not found: %s", fs.Arg(0))
+	}
+	if err := database.DeleteTemplate(ctx, tmpl.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed template %s\n", tmpl.Name)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateCommand(t *testing.T) {
+	tmpDir, dbFilePath := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+	snapshotPath = filepath.Join(tmpDir, ".ponder", "snapshot.jsonl")
+
+	devNull, _ := os.Open(os.DevNull)
+	oldStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runTemplate([]string{"add", "--feature", "feature1", "--task-name", "fix-{{issue}}", "--spec", "Fix issue {{issue}}", "bugfix"}); err != nil {
+		t.Fatalf("template add failed: %v", err)
+	}
+	if err := runTemplate([]string{"add", "--feature", "feature1", "--every", "weekly", "--start", "2099-01-01", "update-deps"}); err != nil {
+		t.Fatalf("template add --every failed: %v", err)
+	}
+	if err := runTemplate([]string{"add", "--feature", "feature1", "--every", "fortnightly", "bad"}); err == nil {
+		t.Error("expected error for an unknown recurrence")
+	}
+	if err := runTemplate([]string{"list"}); err != nil {
+		t.Errorf("template list failed: %v", err)
+	}
+	if err := runTemplate([]string{"use", "bugfix"}); err == nil {
+		t.Error("expected error for a missing variable")
+	}
+	if err := runTemplate([]string{"use", "--var", "issue=42", "bugfix"}); err != nil {
+		t.Fatalf("template use failed: %v", err)
+	}
+	if err := runTemplate([]string{"rm", "bugfix"}); err != nil {
+		t.Fatalf("template rm failed: %v", err)
+	}
+
+	ctx := context.Background()
+	database := openTestDB(t, dbFilePath)
+	defer database.Close()
+	f, _ := database.GetFeatureByName(ctx, "feature1")
+	task, err := database.GetTaskByName(ctx, "fix-42", f.ID)
+	if err != nil || task == nil || task.Specification != "Fix issue 42" {
+		t.Errorf("expected task fix-42 from the template, got %+v (%v)", task, err)
+	}
+
+	templates, err := database.ListTemplates(ctx)
+	if err != nil || len(templates) != 1 || templates[0].Name != "update-deps" {
+		t.Fatalf("expected only update-deps to be left, got %+v (%v)", templates, err)
+	}
+	if templates[0].TaskName != "update-deps-{{date}}" || templates[0].NextRunAt == nil {
+		t.Errorf("unexpected recurring template %+v", templates[0])
+	}
+}
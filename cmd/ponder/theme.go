@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
+)
+
+type themeConfig struct {
+	// Name is a built-in theme: "dark", "light" or "high-contrast".
+	Name string `json:"name,omitempty"`
+	// Colors override colors of the theme by role, e.g.
+	// {"success": "#15803d", "muted": "244"}.
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+// parse returns the configured theme: the named built-in one, dark by
+// default, with the colors overridden.
+func (tc *themeConfig) parse() (theme.Theme, error) {
+	t := theme.Dark
+	if tc.Name != "" {
+		var err error
+		if t, err = theme.Named(tc.Name); err != nil {
+			return theme.Theme{}, err
+		}
+	}
+	t, err := t.Override(tc.Colors)
+	if err != nil {
+		return theme.Theme{}, fmt.Errorf("colors: %w", err)
+	}
+	return t, nil
+}
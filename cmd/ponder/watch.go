@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+const (
+	// defaultWatchInterval is how often watch polls the audit log.
+	defaultWatchInterval = time.Second
+	// watchBatch caps how many events a single poll reads.
+	watchBatch = 500
+)
+
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", defaultWatchInterval, "How often to poll for changes")
+	featureFilter := fs.String("feature", "", "Only show tasks of this feature")
+	backfill := fs.Int("n", 10, "Show the last N transitions before following")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: ponder watch [--interval 1s] [--feature name] [-n 10]")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("--interval must be > 0")
+	}
+	if *backfill < 0 {
+		return fmt.Errorf("-n must be >= 0")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := newTaskWatcher(database, os.Stdout, *featureFilter)
+	if err := w.start(ctx, *backfill); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.poll(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+// taskWatcher prints task status transitions from the audit log as they are
+// recorded. The log is shared by every process using the database, so
+// polling it sees changes made by the TUI, MCP server and CLI alike.
+type taskWatcher struct {
+	database *db.DB
+	out      io.Writer
+	feature  string
+	lastID   int64
+	// features maps task ids to feature names, as events only carry the
+	// task's name.
+	features map[string]string
+}
+
+func newTaskWatcher(database *db.DB, out io.Writer, feature string) *taskWatcher {
+	return &taskWatcher{database: database, out: out, feature: feature, features: make(map[string]string)}
+}
+
+// start prints the last n transitions and positions the watcher after the
+// latest event, so poll only reports what happens from now on.
+func (w *taskWatcher) start(ctx context.Context, n int) error {
+	events, err := w.database.ListEvents(ctx, db.EntityTask, "", watchBatch)
+	if err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		w.lastID = events[len(events)-1].ID
+	}
+
+	var lines []string
+	for _, e := range events {
+		if line, ok := w.format(ctx, e); ok {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w.out, line)
+	}
+	return nil
+}
+
+// poll prints the transitions recorded since the last call.
+func (w *taskWatcher) poll(ctx context.Context) error {
+	for {
+		events, err := w.database.ListEventsAfter(ctx, db.EntityTask, w.lastID, watchBatch)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			w.lastID = e.ID
+			if line, ok := w.format(ctx, e); ok {
+				fmt.Fprintln(w.out, line)
+			}
+		}
+		if len(events) < watchBatch {
+			return nil
+		}
+	}
+}
+
+// format renders a task event as a feed line. It reports false for events
+// that do not change a task's status or that fall outside the feature
+// filter.
+func (w *taskWatcher) format(ctx context.Context, e *models.Event) (string, bool) {
+	var change string
+	switch e.Action {
+	case models.EventCreated:
+		change = "created → " + eventStatus(e.After)
+	case models.EventStatusChanged:
+		change = eventStatus(e.Before) + " → " + eventStatus(e.After)
+	case models.EventDeleted:
+		change = "deleted"
+	default:
+		return "", false
+	}
+
+	feature := w.featureOf(ctx, e.EntityID)
+	if w.feature != "" && feature != w.feature {
+		return "", false
+	}
+	name := e.EntityName
+	if feature != "" {
+		name = feature + "/" + name
+	}
+	return fmt.Sprintf("%s  %-32s %-28s %s", e.CreatedAt.Local().Format("2006-01-02 15:04:05"), name, change, e.Actor), true
+}
+
+// featureOf returns the feature name of a task, or "" once the task is gone.
+func (w *taskWatcher) featureOf(ctx context.Context, taskID string) string {
+	if name, ok := w.features[taskID]; ok {
+		return name
+	}
+	task, err := w.database.GetTask(ctx, taskID)
+	if err != nil || task == nil {
+		return ""
+	}
+	w.features[taskID] = task.FeatureName
+	return task.FeatureName
+}
+
+// eventStatus pulls the status out of an event snapshot.
+func eventStatus(snippet json.RawMessage) string {
+	var s struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(snippet, &s); err != nil || s.Status == "" {
+		return "?"
+	}
+	return s.Status
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskWatcher(t *testing.T) {
+	tmpDir, _ := setupTestDB(t)
+	defer os.RemoveAll(tmpDir)
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	var out bytes.Buffer
+	w := newTaskWatcher(database, &out, "")
+	if err := w.start(ctx, 10); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "feature1/task1") || !strings.Contains(out.String(), "created → pending") {
+		t.Errorf("expected backfill to show task1 being created, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := w.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing new, got %q", out.String())
+	}
+
+	task, err := findTaskByName(ctx, database, "", "task1")
+	if err != nil {
+		t.Fatalf("failed to find task: %v", err)
+	}
+	if err := database.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+	task.Description = "edited"
+	if err := database.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("failed to update task: %v", err)
+	}
+	if err := w.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "feature1/task1") || !strings.Contains(lines[0], "pending → in_progress") {
+		t.Errorf("expected a single transition to in_progress, got %q", out.String())
+	}
+
+	out.Reset()
+	filtered := newTaskWatcher(database, &out, "other")
+	if err := filtered.start(ctx, 10); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected feature filter to hide task1, got %q", out.String())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net"
+	"net/url"
+)
+
+// defaultWebHost keeps the web UI, which can change tasks, off the network
+// unless asked otherwise.
+const defaultWebHost = "127.0.0.1"
+
+// webAuthToken is required by the web UI and REST API when set, from
+// config.json or PONDER_WEB_AUTH_TOKEN.
+var webAuthToken string
+
+// webURL is the address to open the web UI at. It carries the auth token so
+// the browser is let in.
+func webURL(host, port string) string {
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, port), Path: "/"}
+	if webAuthToken != "" {
+		u.RawQuery = url.Values{"token": {webAuthToken}}.Encode()
+	}
+	return u.String()
+}
+
+// isLoopback reports whether host only accepts local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
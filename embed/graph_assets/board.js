@@ -0,0 +1,206 @@
+const TASKS_ENDPOINT = '/api/tasks';
+const FEATURES_ENDPOINT = '/api/features';
+
+// Columns in workflow order. Colors match the graph view.
+const COLUMNS = [
+  { status: 'pending', title: 'Pending', color: '#2e3c62' },
+  { status: 'in_progress', title: 'In Progress', color: '#fff000' },
+  { status: 'in_review', title: 'In Review', color: '#fb923c' },
+  { status: 'blocked', title: 'Blocked', color: '#f43f5e' },
+  { status: 'completed', title: 'Completed', color: '#22d3ee' },
+  { status: 'cancelled', title: 'Cancelled', color: '#52525b' },
+];
+
+const board = document.getElementById('board');
+const featureFilter = document.getElementById('feature-filter');
+
+let tasks = [];
+let featureNames = new Map();
+let draggingTaskId = null;
+
+function showError(message) {
+  const errorDiv = document.createElement('div');
+  errorDiv.className = 'error-message';
+  errorDiv.textContent = message;
+  document.body.appendChild(errorDiv);
+  setTimeout(() => errorDiv.remove(), 5000);
+}
+
+function buildColumns() {
+  COLUMNS.forEach(col => {
+    const column = document.createElement('div');
+    column.className = 'column';
+    column.dataset.status = col.status;
+    column.style.setProperty('--column-color', col.color);
+    column.innerHTML =
+      '<div class="column-header">' +
+      '<span class="column-dot"></span>' +
+      `<span>${col.title}</span>` +
+      '<span class="column-count">0</span>' +
+      '</div>' +
+      '<div class="column-cards"></div>';
+
+    column.addEventListener('dragover', event => {
+      event.preventDefault();
+      column.classList.add('drag-over');
+    });
+    column.addEventListener('dragleave', event => {
+      if (!column.contains(event.relatedTarget)) {
+        column.classList.remove('drag-over');
+      }
+    });
+    column.addEventListener('drop', event => {
+      event.preventDefault();
+      column.classList.remove('drag-over');
+      const taskId = event.dataTransfer.getData('text/plain');
+      // drop fires before dragend; clear the flag so the move renders.
+      draggingTaskId = null;
+      moveTask(taskId, col.status);
+    });
+
+    board.appendChild(column);
+  });
+}
+
+function createCard(task) {
+  const card = document.createElement('div');
+  card.className = 'card';
+  card.draggable = true;
+  card.dataset.taskId = task.id;
+
+  const name = document.createElement('a');
+  name.className = 'card-name';
+  name.href = `/tasks/${encodeURIComponent(task.id)}`;
+  name.draggable = false;
+  name.textContent = task.name;
+
+  const meta = document.createElement('div');
+  meta.className = 'card-meta';
+  const feature = document.createElement('span');
+  feature.textContent = featureNames.get(task.feature_id) || '';
+  const priority = document.createElement('span');
+  priority.className = 'card-priority';
+  priority.textContent = `P${task.priority}`;
+  meta.append(feature, priority);
+
+  card.append(name, meta);
+  if (task.status === 'blocked' && task.blocked_reason) {
+    card.title = task.blocked_reason;
+  } else if (task.completion_summary) {
+    card.title = task.completion_summary;
+  }
+
+  card.addEventListener('dragstart', event => {
+    draggingTaskId = task.id;
+    event.dataTransfer.setData('text/plain', task.id);
+    event.dataTransfer.effectAllowed = 'move';
+    card.classList.add('dragging');
+  });
+  card.addEventListener('dragend', () => {
+    draggingTaskId = null;
+    card.classList.remove('dragging');
+  });
+
+  return card;
+}
+
+function render() {
+  // Rebuilding mid-drag would drop the element being dragged.
+  if (draggingTaskId) {
+    return;
+  }
+
+  COLUMNS.forEach(col => {
+    const column = board.querySelector(`.column[data-status="${col.status}"]`);
+    const cards = column.querySelector('.column-cards');
+    const columnTasks = tasks.filter(t => t.status === col.status);
+
+    cards.replaceChildren(...columnTasks.map(createCard));
+    column.querySelector('.column-count').textContent = columnTasks.length;
+  });
+}
+
+function renderFeatureFilter(features) {
+  // Leave the dropdown alone unless the features changed, so a refresh
+  // doesn't close it while open.
+  const key = features.map(f => `${f.id}:${f.name}`).join('|');
+  if (key === featureFilter.dataset.key) {
+    return;
+  }
+  featureFilter.dataset.key = key;
+
+  const selected = featureFilter.value;
+  const options = [new Option('All features', '')];
+  features.forEach(f => options.push(new Option(f.name, f.id)));
+  featureFilter.replaceChildren(...options);
+  featureFilter.value = featureNames.has(selected) ? selected : '';
+}
+
+// tasksURL asks the server for the selected feature's tasks only, so large
+// projects don't send every task on each refresh.
+function tasksURL() {
+  const feature = featureNames.get(featureFilter.value);
+  return feature ? `${TASKS_ENDPOINT}?feature=${encodeURIComponent(feature)}` : TASKS_ENDPOINT;
+}
+
+async function fetchBoard() {
+  try {
+    const [tasksResponse, featuresResponse] = await Promise.all([
+      fetch(tasksURL()),
+      fetch(FEATURES_ENDPOINT)
+    ]);
+
+    if (!tasksResponse.ok) {
+      throw new Error(`Tasks HTTP ${tasksResponse.status}: ${tasksResponse.statusText}`);
+    }
+    if (!featuresResponse.ok) {
+      throw new Error(`Features HTTP ${featuresResponse.status}: ${featuresResponse.statusText}`);
+    }
+
+    tasks = (await tasksResponse.json()).items || [];
+    const features = (await featuresResponse.json()).items || [];
+    featureNames = new Map(features.map(f => [f.id, f.name]));
+
+    renderFeatureFilter(features);
+    render();
+  } catch (error) {
+    console.error('Error fetching board:', error);
+    showError(`Failed to fetch board: ${error.message}`);
+  }
+}
+
+async function moveTask(taskId, status) {
+  const task = tasks.find(t => t.id === taskId);
+  if (!task || task.status === status) {
+    return;
+  }
+
+  // Move the card straight away and let the next fetch reconcile.
+  const previous = task.status;
+  task.status = status;
+  render();
+
+  try {
+    const response = await fetch(`${TASKS_ENDPOINT}/${encodeURIComponent(taskId)}`, {
+      method: 'PATCH',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ status }),
+    });
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || `HTTP ${response.status}`);
+    }
+  } catch (error) {
+    task.status = previous;
+    render();
+    showError(`Cannot move ${task.name}: ${error.message}`);
+  }
+  fetchBoard();
+}
+
+featureFilter.addEventListener('change', fetchBoard);
+
+buildColumns();
+fetchBoard();
+
+// Auto-refresh every 3 seconds
+setInterval(fetchBoard, 3000);
@@ -0,0 +1,170 @@
+const BURNDOWN_ENDPOINT = '/api/reports/burndown';
+const FEATURES_ENDPOINT = '/api/features';
+const SVG_NS = 'http://www.w3.org/2000/svg';
+
+// Colors match the swatches in burndown.html.
+const ESTIMATE_COLOR = '#2e3c62';
+const ACTUAL_COLOR = '#22d3ee';
+const REMAINING_COLOR = '#fff000';
+
+const featureFilter = document.getElementById('feature-filter');
+const daysFilter = document.getElementById('days-filter');
+const summary = document.getElementById('summary');
+const remainingChart = document.getElementById('remaining-chart');
+const featureChart = document.getElementById('feature-chart');
+
+function showError(message) {
+  const errorDiv = document.createElement('div');
+  errorDiv.className = 'error-message';
+  errorDiv.textContent = message;
+  document.body.appendChild(errorDiv);
+  setTimeout(() => errorDiv.remove(), 5000);
+}
+
+function svg(tag, attrs, text) {
+  const el = document.createElementNS(SVG_NS, tag);
+  Object.entries(attrs).forEach(([k, v]) => el.setAttribute(k, v));
+  if (text !== undefined) {
+    el.textContent = text;
+  }
+  return el;
+}
+
+function formatMinutes(minutes) {
+  const m = Math.round(minutes);
+  if (m < 60) {
+    return `${m}m`;
+  }
+  const h = Math.floor(m / 60);
+  return m % 60 ? `${h}h${m % 60}m` : `${h}h`;
+}
+
+function renderSummary(total) {
+  const items = [
+    ['Tasks', `${total.completed}/${total.tasks}`],
+    ['Estimated', formatMinutes(total.estimate_minutes)],
+    ['Remaining', formatMinutes(total.remaining_minutes)],
+    ['Unestimated', total.unestimated],
+    ['Actual / estimate', total.ratio === null ? '–' : `${total.ratio.toFixed(2)}×`],
+  ];
+  summary.replaceChildren(...items.map(([label, value]) => {
+    const div = document.createElement('div');
+    const strong = document.createElement('strong');
+    strong.textContent = value;
+    div.append(strong, label);
+    return div;
+  }));
+}
+
+// renderRemaining draws the estimated work left at the end of each day.
+function renderRemaining(days) {
+  const width = remainingChart.clientWidth || 800;
+  const height = 220;
+  const pad = { top: 12, right: 16, bottom: 24, left: 56 };
+  const max = Math.max(1, ...days.map(d => d.remaining_minutes));
+  const x = i => pad.left + (days.length > 1 ? i * (width - pad.left - pad.right) / (days.length - 1) : 0);
+  const y = v => pad.top + (1 - v / max) * (height - pad.top - pad.bottom);
+
+  const children = [];
+  [0, 0.5, 1].forEach(f => {
+    children.push(svg('line', { x1: pad.left, x2: width - pad.right, y1: y(max * f), y2: y(max * f), stroke: '#27272a' }));
+    children.push(svg('text', { x: pad.left - 8, y: y(max * f) + 3, 'text-anchor': 'end' }, formatMinutes(max * f)));
+  });
+  const step = Math.ceil(days.length / 8);
+  days.forEach((d, i) => {
+    if (i % step === 0 || i === days.length - 1) {
+      children.push(svg('text', { x: x(i), y: height - 6, 'text-anchor': 'middle' }, d.date.slice(5)));
+    }
+  });
+  const points = days.map((d, i) => `${x(i)},${y(d.remaining_minutes)}`).join(' ');
+  children.push(svg('polyline', { points, fill: 'none', stroke: REMAINING_COLOR, 'stroke-width': 2 }));
+  days.forEach((d, i) => {
+    const dot = svg('circle', { cx: x(i), cy: y(d.remaining_minutes), r: 3, fill: REMAINING_COLOR });
+    dot.appendChild(svg('title', {}, `${d.date}: ${formatMinutes(d.remaining_minutes)} left`));
+    children.push(dot);
+  });
+  remainingChart.replaceChildren(...children);
+}
+
+// renderFeatures draws, per feature, the estimate and actual time of its
+// completed tasks next to the estimated work it has left.
+function renderFeatures(features) {
+  const width = featureChart.clientWidth || 800;
+  const rowHeight = 44;
+  const labelWidth = 180;
+  const height = Math.max(rowHeight, features.length * rowHeight);
+  featureChart.setAttribute('height', height);
+
+  const max = Math.max(1, ...features.flatMap(f => [f.completed_estimate_minutes, f.actual_minutes, f.remaining_minutes]));
+  const w = v => v / max * (width - labelWidth - 80);
+
+  const children = [];
+  features.forEach((f, i) => {
+    const top = i * rowHeight;
+    children.push(svg('text', { x: 0, y: top + 22 }, f.feature_name));
+    [
+      [f.completed_estimate_minutes, ESTIMATE_COLOR, 'estimated'],
+      [f.actual_minutes, ACTUAL_COLOR, 'actual'],
+      [f.remaining_minutes, REMAINING_COLOR, 'remaining'],
+    ].forEach(([value, color, label], j) => {
+      const bar = svg('rect', { x: labelWidth, y: top + 4 + j * 11, width: w(value), height: 9, fill: color, rx: 2 });
+      bar.appendChild(svg('title', {}, `${f.feature_name}: ${formatMinutes(value)} ${label}`));
+      children.push(bar);
+      children.push(svg('text', { x: labelWidth + w(value) + 6, y: top + 12 + j * 11 }, formatMinutes(value)));
+    });
+  });
+  featureChart.replaceChildren(...children);
+}
+
+function renderFeatureFilter(features) {
+  // Leave the dropdown alone unless the features changed, so a refresh
+  // doesn't close it while open.
+  const key = features.map(f => f.name).join('|');
+  if (key === featureFilter.dataset.key) {
+    return;
+  }
+  featureFilter.dataset.key = key;
+
+  const selected = featureFilter.value;
+  const options = [new Option('All features', '')];
+  features.forEach(f => options.push(new Option(f.name, f.name)));
+  featureFilter.replaceChildren(...options);
+  featureFilter.value = features.some(f => f.name === selected) ? selected : '';
+}
+
+async function fetchBurndown() {
+  const params = new URLSearchParams({ days: daysFilter.value });
+  if (featureFilter.value) {
+    params.set('feature', featureFilter.value);
+  }
+  try {
+    const [reportResponse, featuresResponse] = await Promise.all([
+      fetch(`${BURNDOWN_ENDPOINT}?${params}`),
+      fetch(FEATURES_ENDPOINT)
+    ]);
+    if (!reportResponse.ok) {
+      throw new Error(`Burndown HTTP ${reportResponse.status}: ${reportResponse.statusText}`);
+    }
+    if (!featuresResponse.ok) {
+      throw new Error(`Features HTTP ${featuresResponse.status}: ${featuresResponse.statusText}`);
+    }
+
+    const report = await reportResponse.json();
+    renderFeatureFilter((await featuresResponse.json()).items || []);
+    renderSummary(report.total);
+    renderRemaining(report.remaining);
+    renderFeatures(report.features);
+  } catch (error) {
+    console.error('Error fetching burndown:', error);
+    showError(`Failed to fetch burndown: ${error.message}`);
+  }
+}
+
+featureFilter.addEventListener('change', fetchBurndown);
+daysFilter.addEventListener('change', fetchBurndown);
+window.addEventListener('resize', fetchBurndown);
+
+fetchBurndown();
+
+// Refresh every 30 seconds; estimates change far less often than statuses.
+setInterval(fetchBurndown, 30000);
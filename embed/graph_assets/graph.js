@@ -137,6 +137,11 @@ function handleNodeClick(event, d) {
 }
 
 // Helper to format duration in seconds as mm:ss or hh:mm:ss
+// formatTimestamp shows an RFC 3339 timestamp from the API in local time.
+function formatTimestamp(value) {
+  return value ? new Date(value).toLocaleString() : value;
+}
+
 function formatDuration(seconds) {
   if (seconds === null || seconds === undefined) return '';
   const h = Math.floor(seconds / 3600);
@@ -196,8 +201,10 @@ function applySidebarWidth(width, restartSimulation = true) {
 const STATUS_COLORS = {
   pending: '#2e3c62',
   in_progress: '#fff000',
+  in_review: '#fb923c',
   completed: '#22d3ee',
   blocked: '#f43f5e',
+  cancelled: '#52525b',
 };
 
 // Create SVG
@@ -450,16 +457,51 @@ function showError(message) {
   setTimeout(() => errorDiv.remove(), 5000);
 }
 
+// The graph as last fetched. Each fetch asks only for what changed since the
+// previous one, so a large graph isn't downloaded and laid out again every time.
+const graphState = {
+  nodes: new Map(),
+  edges: [],
+  asOf: '1970-01-01T00:00:00Z',
+  drawn: false,
+};
+
+// applyGraphDelta merges a delta from /api/graph?since=... into graphState
+// and reports whether anything changed.
+function applyGraphDelta(delta) {
+  graphState.asOf = delta.as_of;
+  if (delta.full) {
+    graphState.nodes = new Map(delta.nodes.map(n => [n.id, n]));
+    graphState.edges = delta.edges;
+    return true;
+  }
+  if (delta.nodes.length === 0 && delta.removed.length === 0) {
+    return false;
+  }
+
+  delta.removed.forEach(id => graphState.nodes.delete(id));
+  const changed = new Set(delta.nodes.map(n => n.id));
+  delta.nodes.forEach(n => graphState.nodes.set(n.id, n));
+  // The delta carries every dependency of the changed tasks.
+  graphState.edges = graphState.edges
+    .filter(e => !changed.has(e.from) && graphState.nodes.has(e.from) && graphState.nodes.has(e.to))
+    .concat(delta.edges);
+  return true;
+}
+
 async function fetchGraph() {
   try {
-    const response = await fetch(API_ENDPOINT);
+    const response = await fetch(`${API_ENDPOINT}?since=${encodeURIComponent(graphState.asOf)}`);
 
     if (!response.ok) {
       throw new Error(`HTTP ${response.status}: ${response.statusText}`);
     }
 
-    const data = await response.json();
-    updateGraph(data);
+    const delta = await response.json();
+    if (applyGraphDelta(delta) || !graphState.drawn) {
+      updateGraph({ nodes: [...graphState.nodes.values()], edges: graphState.edges });
+      graphState.drawn = true;
+    }
   } catch (error) {
     console.error('Error fetching graph:', error);
     showError(`Failed to fetch graph: ${error.message}`);
@@ -480,8 +522,8 @@ async function fetchTasks() {
       throw new Error(`Features HTTP ${featuresResponse.status}: ${featuresResponse.statusText}`);
     }
 
-    const tasks = await tasksResponse.json();
-    const features = await featuresResponse.json();
+    const { items: tasks } = await tasksResponse.json();
+    const { items: features } = await featuresResponse.json();
     updateTaskList(tasks, features);
   } catch (error) {
     console.error('Error fetching tasks/features:', error);
@@ -512,8 +554,10 @@ function updateTaskList(tasks, features) {
   const statusColors = {
     pending: '#2e3c62',
     in_progress: '#fff000',
+    in_review: '#fb923c',
     completed: '#22d3ee',
     blocked: '#f43f5e',
+    cancelled: '#52525b',
   };
 
   // Group tasks by feature
@@ -623,7 +667,11 @@ function updateTaskList(tasks, features) {
         '<span class="feature-meta-label">Description</span>' +
         `<div class="feature-meta-value">${featureData.description ? marked.parse(featureData.description) : 'No description'}</div>` +
         '<span class="feature-meta-label">Specification</span>' +
-        `<div class="feature-meta-value">${featureData.specification ? marked.parse(featureData.specification) : 'No specification'}</div>`;
+        `<div class="feature-meta-value">${featureData.specification ? marked.parse(featureData.specification) : 'No specification'}</div>` +
+        (featureData.depends_on && featureData.depends_on.length > 0
+          ? '<span class="feature-meta-label">Waits For</span>' +
+            `<div class="feature-meta-value">${featureData.depends_on.join(', ')}</div>`
+          : '');
     } else if (featureMeta) {
       featureMeta.remove();
     }
@@ -737,19 +785,24 @@ function updateTaskList(tasks, features) {
           `<div class="task-details-value">${marked.parse(task.specification)}</div></div>`;
       }
 
-      if (task.status === 'completed' && task.completion_summary) {
+      if (['completed', 'in_review', 'cancelled'].includes(task.status) && task.completion_summary) {
         detailsHtml += `<div class="task-details-row"><span class="task-details-label">Summary:</span>` +
           `<div class="task-details-value task-completion-summary">${marked.parse(task.completion_summary)}</div></div>`;
       }
 
-      detailsHtml += `<div class="task-details-row"><span class="task-details-label">Created:</span> ${task.created_at || 'None'}</div>`;
+      if (task.status === 'blocked' && task.blocked_reason) {
+        detailsHtml += `<div class="task-details-row"><span class="task-details-label">Blocked:</span>` +
+          `<div class="task-details-value task-completion-summary">${marked.parse(task.blocked_reason)}</div></div>`;
+      }
+
+      detailsHtml += `<div class="task-details-row"><span class="task-details-label">Created:</span> ${formatTimestamp(task.created_at) || 'None'}</div>`;
 
       if (task.started_at) {
-        detailsHtml += `<div class="task-details-row"><span class="task-details-label">Started:</span> ${task.started_at}</div>`;
+        detailsHtml += `<div class="task-details-row"><span class="task-details-label">Started:</span> ${formatTimestamp(task.started_at)}</div>`;
       }
 
       if (task.completed_at) {
-        detailsHtml += `<div class="task-details-row"><span class="task-details-label">Completed:</span> ${task.completed_at}</div>`;
+        detailsHtml += `<div class="task-details-row"><span class="task-details-label">Completed:</span> ${formatTimestamp(task.completed_at)}</div>`;
         if (task.started_at) {
           const start = new Date(task.started_at);
           const end = new Date(task.completed_at);
@@ -758,6 +811,8 @@ function updateTaskList(tasks, features) {
         }
       }
 
+      detailsHtml += `<div class="task-details-row"><a class="task-edit-link" href="/tasks/${encodeURIComponent(task.id)}">Edit task</a></div>`;
+
       detailsInner.innerHTML = detailsHtml;
     });
   });
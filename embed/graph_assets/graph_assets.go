@@ -2,5 +2,5 @@ package graph_assets
 
 import "embed"
 
-//go:embed index.html graph.js favicon.svg
+//go:embed index.html graph.js board.html board.js burndown.html burndown.js task.html task.js favicon.svg
 var Assets embed.FS
@@ -0,0 +1,156 @@
+const TASKS_ENDPOINT = '/api/tasks';
+
+// The task to edit is the last part of the page's path, /tasks/{id}.
+const taskId = decodeURIComponent(location.pathname.split('/').pop());
+const taskURL = `${TASKS_ENDPOINT}/${encodeURIComponent(taskId)}`;
+
+const editor = document.getElementById('editor');
+const fields = {
+  name: document.getElementById('name'),
+  priority: document.getElementById('priority'),
+  labels: document.getElementById('labels'),
+  description: document.getElementById('description'),
+  specification: document.getElementById('specification'),
+};
+const preview = document.getElementById('spec-preview');
+const saveButton = document.getElementById('save');
+const saveState = document.getElementById('save-state');
+
+// task is the task as last loaded or saved; the form is compared with it to
+// send only what changed, along with its version.
+let task = null;
+
+function showError(message) {
+  const errorDiv = document.createElement('div');
+  errorDiv.className = 'error-message';
+  errorDiv.textContent = message;
+  document.body.appendChild(errorDiv);
+  setTimeout(() => errorDiv.remove(), 5000);
+}
+
+function parseLabels(text) {
+  return text.split(',').map(l => l.trim()).filter(l => l !== '');
+}
+
+function fill(loaded) {
+  task = loaded;
+  fields.name.value = task.name;
+  fields.priority.value = task.priority;
+  fields.labels.value = task.labels.join(', ');
+  fields.description.value = task.description;
+  fields.specification.value = task.specification;
+
+  document.title = `${task.name} - Ponder`;
+  document.getElementById('task-feature').textContent = task.feature_name || task.feature_id;
+  document.getElementById('task-status').textContent = task.status;
+  document.getElementById('task-version').textContent = `v${task.version}`;
+  if (!preview.hidden) {
+    renderPreview();
+  }
+  updateSaveState();
+}
+
+// changes returns the PATCH body for the fields that differ from task.
+function changes() {
+  const body = {};
+  ['name', 'description', 'specification'].forEach(key => {
+    if (fields[key].value !== task[key]) {
+      body[key] = fields[key].value;
+    }
+  });
+  const priority = Number(fields.priority.value);
+  if (priority !== task.priority) {
+    body.priority = priority;
+  }
+  const labels = parseLabels(fields.labels.value);
+  if (labels.join(',') !== task.labels.join(',')) {
+    body.labels = labels;
+  }
+  return body;
+}
+
+function updateSaveState() {
+  const dirty = task !== null && Object.keys(changes()).length > 0;
+  saveButton.disabled = !dirty;
+  saveState.textContent = dirty ? 'Unsaved changes' : '';
+}
+
+function renderPreview() {
+  preview.innerHTML = marked.parse(fields.specification.value);
+}
+
+function showTab(tab) {
+  document.querySelectorAll('.spec-tab').forEach(b => {
+    b.classList.toggle('active', b.dataset.tab === tab);
+  });
+  fields.specification.hidden = tab !== 'write';
+  preview.hidden = tab !== 'preview';
+  if (tab === 'preview') {
+    renderPreview();
+  }
+}
+
+async function loadTask() {
+  try {
+    const response = await fetch(taskURL);
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || `HTTP ${response.status}`);
+    }
+    fill(await response.json());
+    editor.hidden = false;
+  } catch (error) {
+    console.error('Error fetching task:', error);
+    showError(`Failed to load task: ${error.message}`);
+  }
+}
+
+async function save() {
+  const body = changes();
+  if (Object.keys(body).length === 0 || !editor.reportValidity()) {
+    return;
+  }
+  body.version = task.version;
+
+  saveButton.disabled = true;
+  saveState.textContent = 'Saving…';
+  try {
+    const response = await fetch(taskURL, {
+      method: 'PATCH',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body),
+    });
+    if (response.status === 409) {
+      throw new Error(`${(await response.text()).trim()}. Reload to see the latest version; your edits are kept until you do.`);
+    }
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || `HTTP ${response.status}`);
+    }
+    fill(await response.json());
+    saveState.textContent = 'Saved';
+  } catch (error) {
+    showError(`Cannot save ${task.name}: ${error.message}`);
+    updateSaveState();
+  }
+}
+
+editor.addEventListener('input', updateSaveState);
+editor.addEventListener('submit', event => {
+  event.preventDefault();
+  save();
+});
+document.querySelectorAll('.spec-tab').forEach(b => {
+  b.addEventListener('click', () => showTab(b.dataset.tab));
+});
+document.addEventListener('keydown', event => {
+  if ((event.ctrlKey || event.metaKey) && event.key === 's') {
+    event.preventDefault();
+    save();
+  }
+});
+window.addEventListener('beforeunload', event => {
+  if (task !== null && Object.keys(changes()).length > 0) {
+    event.preventDefault();
+  }
+});
+
+loadTask();
@@ -7,3 +7,6 @@ var Header string
 
 //go:embed footer.md
 var Footer string
+
+//go:embed planning.md
+var Planning string
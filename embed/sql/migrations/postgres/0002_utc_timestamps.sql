@@ -0,0 +1,4 @@
+-- Postgres version of migrations/sqlite/0002_utc_timestamps.sql. Keep the two in step.
+-- TIMESTAMPTZ columns stored imported times correctly, so there is nothing to
+-- rewrite here.
+SELECT 1;
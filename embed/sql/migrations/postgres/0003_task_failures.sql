@@ -0,0 +1,12 @@
+-- Postgres version of migrations/sqlite/0003_task_failures.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_failures (
+  task_id VARCHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  fail_count INTEGER NOT NULL CHECK (fail_count > 0),
+  -- failures since the task last moved along the model fallback chain, to
+  -- fallback_model
+  model_failures INTEGER NOT NULL CHECK (model_failures >= 0),
+  fallback_model TEXT,
+
+  failed_at TIMESTAMPTZ NOT NULL
+);
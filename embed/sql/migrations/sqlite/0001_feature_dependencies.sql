@@ -0,0 +1,11 @@
+-- Feature dependencies shipped just before migrations did, so databases
+-- created earlier may lack the table. Same as
+-- sql/tables/015_feature_dependencies.sql.
+CREATE TABLE IF NOT EXISTS feature_dependencies (
+  feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  depends_on_feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  PRIMARY KEY (feature_id, depends_on_feature_id),
+  CHECK (feature_id != depends_on_feature_id) -- Prevent self-dependencies
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_dependencies_depends_on ON feature_dependencies(depends_on_feature_id);
@@ -0,0 +1,7 @@
+-- Snapshot imports used to store timestamps the way Go prints them
+-- ("2026-01-02 16:04:05 +0100 CET"), which SQLite's date functions can't
+-- read: such tasks were exported with null times and sorted wrongly against
+-- others. They are rewritten in UTC as "YYYY-MM-DD HH:MM:SS", like
+-- CURRENT_TIMESTAMP, by this migration's Go step, rewriteOffsetTimestamps in
+-- internal/db/migration_steps.go, one column at a time.
+SELECT 1;
@@ -0,0 +1,13 @@
+-- Adds the table that keeps the orchestrator's failure counts across restarts.
+-- Same as sql/tables/017_task_failures.sql.
+CREATE TABLE IF NOT EXISTS task_failures (
+  task_id CHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  fail_count INTEGER NOT NULL CHECK (fail_count > 0),
+  -- failures since the task last moved along the model fallback chain, to
+  -- fallback_model
+  model_failures INTEGER NOT NULL CHECK (model_failures >= 0),
+  fallback_model TEXT,
+
+  failed_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,847 @@
+-- Postgres version of sql/tables/001_features.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS features (
+  id VARCHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Added after the first release; upgradeFeatureColumns does this for SQLite.
+ALTER TABLE features ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+  IF NEW.updated_at IS NOT DISTINCT FROM OLD.updated_at THEN
+    NEW.updated_at := CURRENT_TIMESTAMP;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_features_updated_at ON features;
+CREATE TRIGGER set_features_updated_at
+BEFORE UPDATE ON features
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Bump the version of updates that don't set it themselves.
+CREATE OR REPLACE FUNCTION set_version() RETURNS trigger AS $$
+BEGIN
+  IF NEW.version = OLD.version THEN
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_features_version ON features;
+CREATE TRIGGER set_features_version
+BEFORE UPDATE ON features
+FOR EACH ROW EXECUTE FUNCTION set_version();
+
+-- Seed the default feature (required for basic operation)
+INSERT INTO features (id, name, description, specification) VALUES
+(
+  '00000000-0000-0000-0000-000000000000',
+  'misc',
+  'Default feature for uncategorized tasks',
+  'Use this feature in cases where a task is minimal and does not require a feature, such as minor hotfixes, tweaks etc.'
+)
+ON CONFLICT DO NOTHING;
+-- Postgres version of sql/tables/002_tasks.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS tasks (
+  id VARCHAR(36) PRIMARY KEY,
+  feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0 CHECK(priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+  status TEXT DEFAULT 'pending' CHECK(
+    status IN (
+      'pending',
+      'in_progress',
+      'completed',
+      'blocked',
+      'in_review',
+      'cancelled'
+    )
+  ),
+  completion_summary TEXT,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ,
+  -- Scheduling window: the task can't be claimed before not_before, and is
+  -- overdue once due_at passes without it being completed.
+  not_before TIMESTAMPTZ,
+  due_at TIMESTAMPTZ,
+  -- Subtasks point at their parent. subtask_order on the parent decides which
+  -- side waits for the other.
+  parent_task_id VARCHAR(36) REFERENCES tasks(id) ON DELETE SET NULL,
+  subtask_order TEXT NOT NULL DEFAULT 'children_first' CHECK (subtask_order IN ('children_first', 'parent_first')),
+  -- Orders tasks of equal priority, lowest first, as set by reorder_tasks.
+  position INTEGER NOT NULL DEFAULT 0,
+  -- Why the task is blocked, as reported by whoever blocked it. Cleared when
+  -- the task leaves the blocked status.
+  blocked_reason TEXT,
+  -- How many minutes the task is expected to take; NULL when not estimated.
+  estimate_minutes INTEGER CHECK (estimate_minutes IS NULL OR estimate_minutes > 0),
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
+
+  CHECK (status != 'completed' OR completion_summary IS NOT NULL),
+  UNIQUE(name, feature_id)
+);
+
+-- Added after the first release; upgradeTaskColumns does this for SQLite.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS not_before TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_task_id VARCHAR(36) REFERENCES tasks(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS subtask_order TEXT NOT NULL DEFAULT 'children_first'
+  CHECK (subtask_order IN ('children_first', 'parent_first'));
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS blocked_reason TEXT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER
+  CHECK (estimate_minutes IS NULL OR estimate_minutes > 0);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
+
+-- Set started_at and completed_at when the status changes to in_progress or
+-- completed, as the SQLite triggers do.
+CREATE OR REPLACE FUNCTION set_task_status_timestamps() RETURNS trigger AS $$
+BEGIN
+  IF NEW.status = 'in_progress' AND OLD.status != 'in_progress' THEN
+    NEW.started_at := CURRENT_TIMESTAMP;
+  END IF;
+  IF NEW.status = 'completed' AND OLD.status != 'completed' THEN
+    NEW.completed_at := CURRENT_TIMESTAMP;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_task_status_timestamps ON tasks;
+CREATE TRIGGER set_task_status_timestamps
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION set_task_status_timestamps();
+
+DROP TRIGGER IF EXISTS set_tasks_updated_at ON tasks;
+CREATE TRIGGER set_tasks_updated_at
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+DROP TRIGGER IF EXISTS set_tasks_version ON tasks;
+CREATE TRIGGER set_tasks_version
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION set_version();
+-- Postgres version of sql/tables/003_dependencies.sql. Keep the two in step.
+-- Circular dependencies are rejected in Go before inserting, so there is no
+-- equivalent of the SQLite trigger.
+CREATE TABLE IF NOT EXISTS dependencies (
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  depends_on_task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  PRIMARY KEY (task_id, depends_on_task_id),
+  CHECK (task_id != depends_on_task_id) -- Prevent self-dependencies
+);
+-- Postgres version of sql/tables/004_events.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS events (
+  id BIGSERIAL PRIMARY KEY,
+  entity_type TEXT NOT NULL,
+  entity_id TEXT NOT NULL,
+  entity_name TEXT NOT NULL,
+  action TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT 'system',
+  before_json TEXT,
+  after_json TEXT,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_entity ON events(entity_type, entity_id);
+-- Postgres version of sql/tables/005_task_notes.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_notes (
+  id VARCHAR(36) PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  author TEXT NOT NULL,
+  body TEXT NOT NULL CHECK (length(body) > 0),
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  -- Insertion order, standing in for SQLite's rowid
+  seq BIGSERIAL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_notes_task ON task_notes(task_id, created_at);
+-- Postgres version of sql/tables/006_task_links.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_links (
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_links_task ON task_links(task_id);
+-- Postgres version of sql/tables/007_task_usage.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_usage (
+  id BIGSERIAL PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out INTEGER NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  -- true when the cost was computed from configured pricing rather than reported by the agent
+  estimated BOOLEAN NOT NULL DEFAULT FALSE,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_usage_task ON task_usage(task_id);
+-- Postgres version of sql/tables/008_archive.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS archived_features (
+  id VARCHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  created_at TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ,
+  archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS archived_tasks (
+  id VARCHAR(36) PRIMARY KEY,
+  feature_id VARCHAR(36) NOT NULL,
+  -- Kept so the task can be shown after its feature is archived too
+  feature_name TEXT NOT NULL,
+
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0,
+  tests_required INTEGER NOT NULL DEFAULT 1,
+  status TEXT NOT NULL,
+  completion_summary TEXT,
+
+  created_at TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ,
+  started_at TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ,
+  archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_tasks_feature ON archived_tasks(feature_name, name);
+
+CREATE TABLE IF NOT EXISTS archived_dependencies (
+  task_id VARCHAR(36) NOT NULL,
+  depends_on_task_id VARCHAR(36) NOT NULL,
+  PRIMARY KEY (task_id, depends_on_task_id)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_notes (
+  id VARCHAR(36) PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL,
+  author TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_notes_task ON archived_task_notes(task_id, created_at);
+
+CREATE TABLE IF NOT EXISTS archived_task_links (
+  task_id VARCHAR(36) NOT NULL,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_usage (
+  id BIGSERIAL PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL,
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0,
+  tokens_out INTEGER NOT NULL DEFAULT 0,
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+  estimated BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_usage_task ON archived_task_usage(task_id);
+-- Postgres version of sql/tables/009_claims.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS claims (
+  task_id VARCHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  hostname TEXT NOT NULL,
+  pid INTEGER NOT NULL,
+  worker_id INTEGER NOT NULL,
+
+  claimed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  lease_expires_at TIMESTAMPTZ NOT NULL
+);
+-- Postgres version of sql/tables/010_run_environments.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS run_environments (
+  task_id VARCHAR(36) UNIQUE REFERENCES tasks(id) ON DELETE CASCADE,
+  feature_id VARCHAR(36) UNIQUE REFERENCES features(id) ON DELETE CASCADE,
+
+  working_dir TEXT NOT NULL DEFAULT '',
+  env TEXT NOT NULL DEFAULT '{}',
+
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+  CHECK ((task_id IS NULL) <> (feature_id IS NULL))
+);
+-- Postgres version of sql/tables/011_task_templates.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_templates (
+  id VARCHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  task_name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  specification TEXT NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 5 CHECK (priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+
+  recurrence TEXT NOT NULL DEFAULT '',
+  next_run_at TIMESTAMPTZ,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+-- Postgres version of sql/tables/012_runs.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS runs (
+  id BIGSERIAL PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  prompt TEXT NOT NULL DEFAULT '',
+  -- stdout and stderr, interleaved as they were written
+  output TEXT NOT NULL DEFAULT '',
+  -- -1 when the agent did not exit by itself, e.g. it was killed or never started
+  exit_code INTEGER NOT NULL DEFAULT 0,
+  -- why the run failed, including a failed verification; empty on success
+  error TEXT NOT NULL DEFAULT '',
+  -- agent_missing, exit, cancelled, timeout, oom, verification or other;
+  -- empty on success
+  failure_class TEXT NOT NULL DEFAULT '',
+  duration_ms BIGINT NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMPTZ NOT NULL
+);
+
+-- Added after the first release; upgradeRunColumns does this for SQLite.
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS failure_class TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_runs_task ON runs(task_id, started_at);
+-- Postgres version of sql/tables/013_staged_changes.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS staged_changes (
+  session_id TEXT PRIMARY KEY,
+  items TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+-- Postgres version of sql/tables/014_task_labels.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_labels (
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  label VARCHAR(55) NOT NULL,
+
+  PRIMARY KEY (task_id, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label ON task_labels(label);
+-- Postgres version of sql/tables/015_feature_dependencies.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS feature_dependencies (
+  feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  depends_on_feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  PRIMARY KEY (feature_id, depends_on_feature_id),
+  CHECK (feature_id != depends_on_feature_id) -- Prevent self-dependencies
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_dependencies_depends_on ON feature_dependencies(depends_on_feature_id);
+-- Postgres version of sql/tables/016_run_sessions.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS run_sessions (
+  id BIGSERIAL PRIMARY KEY,
+
+  -- JSON object of model names to the number of task runs they did
+  models TEXT NOT NULL DEFAULT '{}',
+  tasks_attempted INTEGER NOT NULL DEFAULT 0 CHECK (tasks_attempted >= 0),
+  tasks_completed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_completed >= 0),
+  tasks_failed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_failed >= 0),
+  tokens_in BIGINT NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out BIGINT NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  duration_ms BIGINT NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMPTZ NOT NULL,
+  ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_sessions_started ON run_sessions(started_at);
+-- Postgres version of sql/tables/017_task_failures.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_failures (
+  task_id VARCHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  fail_count INTEGER NOT NULL CHECK (fail_count > 0),
+  -- failures since the task last moved along the model fallback chain, to
+  -- fallback_model
+  model_failures INTEGER NOT NULL CHECK (model_failures >= 0),
+  fallback_model TEXT,
+
+  failed_at TIMESTAMPTZ NOT NULL
+);
+-- Postgres version of sql/views/001_available_tasks.sql. Keep the two in step.
+-- It applies the strict availability policy; ponder's own queries follow the
+-- configured one (see AvailabilityPolicy in internal/db).
+DROP VIEW IF EXISTS v_available_tasks CASCADE;
+
+CREATE VIEW v_available_tasks AS
+SELECT t.*, f.name AS feature_name
+FROM tasks t
+LEFT JOIN features f ON t.feature_id = f.id
+WHERE t.status = 'pending'
+  AND (t.not_before IS NULL OR t.not_before <= CURRENT_TIMESTAMP)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM dependencies d
+    JOIN tasks dep_task ON d.depends_on_task_id = dep_task.id
+    WHERE d.task_id = t.id
+      AND dep_task.status != 'completed'
+  )
+  AND NOT EXISTS (
+    -- A children_first parent waits for its subtasks to be completed or cancelled
+    SELECT 1
+    FROM tasks sub
+    WHERE sub.parent_task_id = t.id
+      AND t.subtask_order = 'children_first'
+      AND sub.status NOT IN ('completed', 'cancelled')
+  )
+  AND NOT EXISTS (
+    -- A subtask of a parent_first parent waits for the parent to be completed
+    SELECT 1
+    FROM tasks parent
+    WHERE parent.id = t.parent_task_id
+      AND parent.subtask_order = 'parent_first'
+      AND parent.status != 'completed'
+  )
+  AND NOT EXISTS (
+    -- A feature waits for every task of the features it depends on
+    SELECT 1
+    FROM feature_dependencies fd
+    JOIN tasks gate ON gate.feature_id = fd.depends_on_feature_id
+    WHERE fd.feature_id = t.feature_id
+      AND gate.status NOT IN ('completed', 'cancelled')
+  )
+ORDER BY t.priority DESC, t.position ASC, t.created_at ASC;
+-- Postgres version of sql/views/002_dependency_tree.sql. Keep the two in step.
+DROP VIEW IF EXISTS v_dependency_tree CASCADE;
+
+CREATE VIEW v_dependency_tree AS
+WITH RECURSIVE task_tree AS (
+    SELECT
+        t.id,
+        t.name,
+        t.description,
+        t.status,
+        t.priority,
+        t.completed_at,
+        0 AS level,
+        CAST(t.name AS TEXT) AS path,
+        CAST(NULL AS TEXT) AS parent_name
+    FROM tasks t
+    WHERE NOT EXISTS (
+        SELECT 1 FROM dependencies d
+        WHERE d.task_id = t.id
+    )
+
+    UNION ALL
+
+    SELECT
+        t.id,
+        t.name,
+        t.description,
+        t.status,
+        t.priority,
+        t.completed_at,
+        tt.level + 1,
+        tt.path || '->' || t.name,
+        tt.name AS parent_name
+    FROM tasks t
+    JOIN dependencies d ON t.id = d.task_id
+    JOIN task_tree tt ON d.depends_on_task_id = tt.id
+)
+SELECT
+    name,
+    description,
+    status,
+    priority,
+    completed_at,
+    level,
+    path,
+    parent_name
+FROM task_tree
+ORDER BY path;
+-- Postgres version of sql/views/003_graph_json.sql. Keep the two in step.
+-- Timestamps are formatted the way SQLite stores them so both backends serve
+-- the same graph.
+DROP VIEW IF EXISTS v_graph_json CASCADE;
+DROP VIEW IF EXISTS v_graph_nodes CASCADE;
+
+-- One graph node per task, so changed tasks can be sent on their own
+CREATE VIEW v_graph_nodes AS
+SELECT t.id,
+    json_build_object(
+        'id', t.id,
+        'name', t.name,
+        'feature_name', f.name,
+        'description', t.description,
+        'status', t.status,
+        'priority', t.priority,
+        'parent_task_id', t.parent_task_id,
+        'subtask_order', t.subtask_order,
+        'completion_summary', t.completion_summary,
+        'blocked_reason', t.blocked_reason,
+        'estimate_minutes', t.estimate_minutes,
+        'completed_at', to_char(t.completed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'started_at', to_char(t.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'completion_seconds', CASE
+            WHEN t.started_at IS NULL OR t.completed_at IS NULL THEN NULL
+            ELSE CAST(ROUND(EXTRACT(EPOCH FROM (t.completed_at - t.started_at))) AS INTEGER)
+        END,
+        'is_available', CASE WHEN t.id IN (SELECT id FROM v_available_tasks) THEN 1 ELSE 0 END
+    ) AS node_json
+FROM tasks t
+JOIN features f ON t.feature_id = f.id;
+
+CREATE VIEW v_graph_json AS
+SELECT json_build_object(
+    'nodes', COALESCE((
+        SELECT json_agg(n.node_json)
+        FROM v_graph_nodes n
+    ), '[]'::json),
+    'edges', COALESCE((
+        SELECT json_agg(
+            json_build_object(
+                'from', d.task_id,
+                'to', d.depends_on_task_id
+            )
+        )
+        FROM dependencies d
+    ), '[]'::json),
+    'feature_edges', COALESCE((
+        SELECT json_agg(
+            json_build_object(
+                'from', f.name,
+                'to', df.name
+            )
+        )
+        FROM feature_dependencies fd
+        JOIN features f ON fd.feature_id = f.id
+        JOIN features df ON fd.depends_on_feature_id = df.id
+    ), '[]'::json)
+)::text AS graph_json;
+-- Postgres version of sql/views/004_snapshot_jsonl.sql. Keep the two in step.
+-- Sort keys use the "C" collation so lines come out in the same byte order as
+-- with SQLite, and ExportSnapshot compacts the JSON to match SQLite's output.
+DROP VIEW IF EXISTS v_snapshot_jsonl_lines CASCADE;
+
+CREATE VIEW v_snapshot_jsonl_lines AS
+SELECT
+  0 AS record_order,
+  '' COLLATE "C" AS sort_name,
+  '' COLLATE "C" AS sort_secondary,
+  json_build_object(
+    'record_type', 'meta',
+    'schema_version', '2',
+    'generated_at', to_char(CURRENT_TIMESTAMP AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'source', 'postgres'
+  )::text AS json_line
+
+UNION ALL
+
+SELECT
+  1 AS record_order,
+  f.name AS sort_name,
+  '' AS sort_secondary,
+  json_build_object(
+    'record_type', 'feature',
+    'id', f.id,
+    'name', f.name,
+    'description', f.description,
+    'specification', f.specification,
+    'created_at', to_char(f.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(f.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM features f
+
+UNION ALL
+
+SELECT
+  2 AS record_order,
+  t.name AS sort_name,
+  '' AS sort_secondary,
+  json_build_object(
+    'record_type', 'task',
+    'id', t.id,
+    'name', t.name,
+    'description', t.description,
+    'specification', t.specification,
+    'feature_name', f.name,
+    'tests_required', t.tests_required = 1,
+    'priority', t.priority,
+    'position', t.position,
+    'status', t.status,
+    'completion_summary', t.completion_summary,
+    'blocked_reason', t.blocked_reason,
+    'estimate_minutes', t.estimate_minutes,
+    'labels', (SELECT json_agg(l.label ORDER BY l.label) FROM task_labels l WHERE l.task_id = t.id),
+    'created_at', to_char(t.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(t.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'started_at', to_char(t.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'completed_at', to_char(t.completed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'not_before', to_char(t.not_before AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'due_at', to_char(t.due_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'parent_task_id', t.parent_task_id,
+    'parent_task_name', p.name,
+    'parent_task_feature_name', pf.name,
+    'subtask_order', t.subtask_order
+  )::text AS json_line
+FROM tasks t
+LEFT JOIN features f ON t.feature_id = f.id
+LEFT JOIN tasks p ON t.parent_task_id = p.id
+LEFT JOIN features pf ON p.feature_id = pf.id
+
+UNION ALL
+
+SELECT
+  3 AS record_order,
+  t.name AS sort_name,
+  dep.name AS sort_secondary,
+  json_build_object(
+    'record_type', 'dependency',
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'depends_on_task_id', dep.id,
+    'depends_on_task_name', dep.name,
+    'depends_on_task_feature_name', df.name
+  )::text AS json_line
+FROM dependencies d
+JOIN tasks t ON d.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+JOIN tasks dep ON d.depends_on_task_id = dep.id
+JOIN features df ON dep.feature_id = df.id
+
+UNION ALL
+
+SELECT
+  4 AS record_order,
+  tf.name || '/' || t.name AS sort_name,
+  to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') || lpad(n.seq::text, 12, '0') AS sort_secondary,
+  json_build_object(
+    'record_type', 'note',
+    'id', n.id,
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'author', n.author,
+    'body', n.body,
+    'created_at', to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM task_notes n
+JOIN tasks t ON n.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  5 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_build_object(
+    'record_type', 'link',
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', to_char(l.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM task_links l
+JOIN tasks t ON l.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  11 AS record_order,
+  f.name AS sort_name,
+  COALESCE(t.name, '') AS sort_secondary,
+  json_build_object(
+    'record_type', 'environment',
+    'feature_name', f.name,
+    'task_id', e.task_id,
+    'task_name', t.name,
+    'working_dir', e.working_dir,
+    'env', e.env::json
+  )::text AS json_line
+FROM run_environments e
+LEFT JOIN tasks t ON e.task_id = t.id
+JOIN features f ON f.id = COALESCE(e.feature_id, t.feature_id)
+
+UNION ALL
+
+SELECT
+  12 AS record_order,
+  tt.name AS sort_name,
+  '' AS sort_secondary,
+  json_build_object(
+    'record_type', 'template',
+    'id', tt.id,
+    'name', tt.name,
+    'feature_name', f.name,
+    'task_name', tt.task_name,
+    'description', tt.description,
+    'specification', tt.specification,
+    'priority', tt.priority,
+    'tests_required', tt.tests_required = 1,
+    'recurrence', tt.recurrence,
+    'next_run_at', to_char(tt.next_run_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'created_at', to_char(tt.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM task_templates tt
+JOIN features f ON tt.feature_id = f.id
+
+UNION ALL
+
+SELECT
+  13 AS record_order,
+  f.name AS sort_name,
+  df.name AS sort_secondary,
+  json_build_object(
+    'record_type', 'feature_dependency',
+    'feature_id', f.id,
+    'feature_name', f.name,
+    'depends_on_feature_id', df.id,
+    'depends_on_feature_name', df.name
+  )::text AS json_line
+FROM feature_dependencies fd
+JOIN features f ON fd.feature_id = f.id
+JOIN features df ON fd.depends_on_feature_id = df.id;
+-- Postgres version of sql/views/005_snapshot_archived_jsonl.sql. Keep the two
+-- in step.
+DROP VIEW IF EXISTS v_snapshot_archived_jsonl_lines CASCADE;
+
+CREATE VIEW v_snapshot_archived_jsonl_lines AS
+SELECT
+  6 AS record_order,
+  (t.feature_name || '/' || t.name) COLLATE "C" AS sort_name,
+  t.id::text COLLATE "C" AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_task',
+    'id', t.id,
+    'name', t.name,
+    'description', t.description,
+    'specification', t.specification,
+    'feature_id', t.feature_id,
+    'feature_name', t.feature_name,
+    'tests_required', t.tests_required = 1,
+    'priority', t.priority,
+    'status', t.status,
+    'completion_summary', t.completion_summary,
+    'created_at', to_char(t.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(t.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'started_at', to_char(t.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'completed_at', to_char(t.completed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'archived_at', to_char(t.archived_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_tasks t
+
+UNION ALL
+
+SELECT
+  7 AS record_order,
+  d.task_id AS sort_name,
+  d.depends_on_task_id AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_dependency',
+    'task_id', d.task_id,
+    'task_name', COALESCE(lt.name, ta.name),
+    'task_feature_name', COALESCE(lf.name, ta.feature_name),
+    'depends_on_task_id', d.depends_on_task_id,
+    'depends_on_task_name', COALESCE(ldt.name, adt.name),
+    'depends_on_task_feature_name', COALESCE(ldf.name, adt.feature_name)
+  )::text AS json_line
+FROM archived_dependencies d
+LEFT JOIN tasks lt ON d.task_id = lt.id
+LEFT JOIN features lf ON lt.feature_id = lf.id
+LEFT JOIN archived_tasks ta ON d.task_id = ta.id
+LEFT JOIN tasks ldt ON d.depends_on_task_id = ldt.id
+LEFT JOIN features ldf ON ldt.feature_id = ldf.id
+LEFT JOIN archived_tasks adt ON d.depends_on_task_id = adt.id
+
+UNION ALL
+
+SELECT
+  8 AS record_order,
+  n.task_id AS sort_name,
+  to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') || n.id AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_note',
+    'id', n.id,
+    'task_id', n.task_id,
+    'author', n.author,
+    'body', n.body,
+    'created_at', to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_task_notes n
+
+UNION ALL
+
+SELECT
+  9 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_link',
+    'task_id', l.task_id,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', to_char(l.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_task_links l
+
+UNION ALL
+
+SELECT
+  10 AS record_order,
+  f.name AS sort_name,
+  f.id AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_feature',
+    'id', f.id,
+    'name', f.name,
+    'description', f.description,
+    'specification', f.specification,
+    'created_at', to_char(f.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(f.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'archived_at', to_char(f.archived_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_features f;
@@ -4,6 +4,8 @@ CREATE TABLE IF NOT EXISTS features (
   name VARCHAR(55) NOT NULL UNIQUE,
   description TEXT NOT NULL,
   specification TEXT NOT NULL,
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
 
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -18,6 +20,16 @@ BEGIN
   WHERE id = NEW.id;
 END;
 
+-- Bump the version of updates that don't set it themselves.
+CREATE TRIGGER IF NOT EXISTS set_features_version
+AFTER UPDATE OF name, description, specification ON features
+WHEN NEW.version = OLD.version
+BEGIN
+  UPDATE features
+  SET version = OLD.version + 1
+  WHERE id = NEW.id;
+END;
+
 -- Seed the default feature (required for basic operation)
 -- Note: id must be provided by the application (Go will generate UUIDs)
 INSERT OR IGNORE INTO features (id, name, description, specification) VALUES
@@ -43,7 +55,9 @@ CREATE TABLE IF NOT EXISTS tasks (
       'pending',
       'in_progress',
       'completed',
-      'blocked'
+      'blocked',
+      'in_review',
+      'cancelled'
     )
   ),
   completion_summary TEXT,
@@ -52,11 +66,30 @@ CREATE TABLE IF NOT EXISTS tasks (
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   started_at TIMESTAMP,
   completed_at TIMESTAMP,
+  -- Scheduling window: the task can't be claimed before not_before, and is
+  -- overdue once due_at passes without it being completed.
+  not_before TIMESTAMP,
+  due_at TIMESTAMP,
+  -- Subtasks point at their parent. subtask_order on the parent decides which
+  -- side waits for the other.
+  parent_task_id CHAR(36) REFERENCES tasks(id) ON DELETE SET NULL,
+  subtask_order TEXT NOT NULL DEFAULT 'children_first' CHECK (subtask_order IN ('children_first', 'parent_first')),
+  -- Orders tasks of equal priority, lowest first, as set by reorder_tasks.
+  position INTEGER NOT NULL DEFAULT 0,
+  -- Why the task is blocked, as reported by whoever blocked it. Cleared when
+  -- the task leaves the blocked status.
+  blocked_reason TEXT,
+  -- How many minutes the task is expected to take; NULL when not estimated.
+  estimate_minutes INTEGER CHECK (estimate_minutes IS NULL OR estimate_minutes > 0),
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
 
   CHECK (status != 'completed' OR completion_summary IS NOT NULL),
   UNIQUE(name, feature_id)
 );
 
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
+
 -- Triggers to automatically set timestamps based on status changes
 
 -- Trigger to set started_at when status becomes 'in_progress'
@@ -87,6 +120,19 @@ BEGIN
   SET updated_at = CURRENT_TIMESTAMP
   WHERE id = NEW.id;
 END;
+
+-- Bump the version of updates that don't set it themselves. Only changes to
+-- these columns count, so the timestamp triggers above don't bump it again.
+CREATE TRIGGER IF NOT EXISTS set_tasks_version
+AFTER UPDATE OF feature_id, name, description, specification, priority, tests_required, status,
+  completion_summary, not_before, due_at, parent_task_id, subtask_order, position, blocked_reason,
+  estimate_minutes ON tasks
+WHEN NEW.version = OLD.version
+BEGIN
+  UPDATE tasks
+  SET version = OLD.version + 1
+  WHERE id = NEW.id;
+END;
 -- Dependencies are edges in the graph between tasks and other tasks they depend on
 CREATE TABLE IF NOT EXISTS dependencies (
   task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
@@ -122,7 +168,283 @@ BEGIN
       RAISE(ABORT, 'Circular dependencies are not allowed!')
     END;
 END;
+-- Audit log of every mutation. Rows are not tied to entities by foreign key so
+-- that history survives deletions.
+CREATE TABLE IF NOT EXISTS events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  entity_type TEXT NOT NULL,
+  entity_id TEXT NOT NULL,
+  entity_name TEXT NOT NULL,
+  action TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT 'system',
+  before_json TEXT,
+  after_json TEXT,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_entity ON events(entity_type, entity_id);
+-- Free-form notes agents leave on a task for whoever picks it up next
+CREATE TABLE IF NOT EXISTS task_notes (
+  id CHAR(36) PRIMARY KEY,
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  author TEXT NOT NULL,
+  body TEXT NOT NULL CHECK (length(body) > 0),
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_notes_task ON task_notes(task_id, created_at);
+-- Links between tasks and items in external trackers (e.g. GitHub issues)
+CREATE TABLE IF NOT EXISTS task_links (
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_links_task ON task_links(task_id);
+-- Token usage and cost of each agent run, so spend can be traced to tasks and features
+CREATE TABLE IF NOT EXISTS task_usage (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out INTEGER NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd REAL NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  -- 1 when the cost was computed from configured pricing rather than reported by the agent
+  estimated BOOLEAN NOT NULL DEFAULT 0,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_usage_task ON task_usage(task_id);
+-- Archive tables hold completed work moved out of the live tables so that
+-- long-lived projects keep their views fast. They mirror the live tables with
+-- an archived_at column and no foreign keys, since the rows they referred to
+-- may be archived or deleted independently.
+CREATE TABLE IF NOT EXISTS archived_features (
+  id CHAR(36) PRIMARY KEY,
+  name VARCHAR(55) NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS archived_tasks (
+  id CHAR(36) PRIMARY KEY,
+  feature_id CHAR(36) NOT NULL,
+  -- Kept so the task can be shown after its feature is archived too
+  feature_name VARCHAR(55) NOT NULL,
+
+  name VARCHAR(55) NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0,
+  tests_required INTEGER NOT NULL DEFAULT 1,
+  status TEXT NOT NULL,
+  completion_summary TEXT,
+
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  started_at TIMESTAMP,
+  completed_at TIMESTAMP,
+  archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_tasks_feature ON archived_tasks(feature_name, name);
+
+CREATE TABLE IF NOT EXISTS archived_dependencies (
+  task_id CHAR(36) NOT NULL,
+  depends_on_task_id CHAR(36) NOT NULL,
+  PRIMARY KEY (task_id, depends_on_task_id)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_notes (
+  id CHAR(36) PRIMARY KEY,
+  task_id CHAR(36) NOT NULL,
+  author TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_notes_task ON archived_task_notes(task_id, created_at);
+
+CREATE TABLE IF NOT EXISTS archived_task_links (
+  task_id CHAR(36) NOT NULL,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_usage (
+  id INTEGER PRIMARY KEY,
+  task_id CHAR(36) NOT NULL,
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0,
+  tokens_out INTEGER NOT NULL DEFAULT 0,
+  cost_usd REAL NOT NULL DEFAULT 0,
+  estimated BOOLEAN NOT NULL DEFAULT 0,
+  created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_usage_task ON archived_task_usage(task_id);
+-- Which worker of which ponder process holds each in_progress task it
+-- claimed. The worker renews the lease while it runs; once a lease expires
+-- the task is put back to pending for any worker to claim.
+CREATE TABLE IF NOT EXISTS claims (
+  task_id CHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  hostname TEXT NOT NULL,
+  pid INTEGER NOT NULL,
+  worker_id INTEGER NOT NULL,
+
+  claimed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  lease_expires_at TIMESTAMP NOT NULL
+);
+-- Where and with which extra environment variables the agent runs, set on a
+-- task or on a whole feature. Exactly one of task_id and feature_id is set;
+-- a task's settings take precedence over its feature's.
+CREATE TABLE IF NOT EXISTS run_environments (
+  task_id CHAR(36) UNIQUE REFERENCES tasks(id) ON DELETE CASCADE,
+  feature_id CHAR(36) UNIQUE REFERENCES features(id) ON DELETE CASCADE,
+
+  -- Relative to the repository root, or the task's worktree.
+  working_dir TEXT NOT NULL DEFAULT '',
+  -- JSON object of variable names to values.
+  env TEXT NOT NULL DEFAULT '{}',
+
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CHECK ((task_id IS NULL) <> (feature_id IS NULL))
+);
+-- Reusable task definitions. Their task name and specification may contain
+-- {{variable}} placeholders that are filled in when a task is created from
+-- the template. A template with a recurrence also gets a task created for it
+-- whenever next_run_at passes.
+CREATE TABLE IF NOT EXISTS task_templates (
+  id CHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  task_name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  specification TEXT NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 5 CHECK (priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+
+  -- daily, weekly, monthly or a duration such as 36h. Empty for templates
+  -- that are only used by hand.
+  recurrence TEXT NOT NULL DEFAULT '',
+  next_run_at TIMESTAMP,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- Transcript of each agent run on a task: what it was asked, everything it
+-- printed and how it ended, so failures can be analysed without run logs.
+-- Runs are deleted with their task and not archived.
+CREATE TABLE IF NOT EXISTS runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  prompt TEXT NOT NULL DEFAULT '',
+  -- stdout and stderr, interleaved as they were written
+  output TEXT NOT NULL DEFAULT '',
+  -- -1 when the agent did not exit by itself, e.g. it was killed or never started
+  exit_code INTEGER NOT NULL DEFAULT 0,
+  -- why the run failed, including a failed verification; empty on success
+  error TEXT NOT NULL DEFAULT '',
+  -- agent_missing, exit, cancelled, timeout, oom, verification or other;
+  -- empty on success
+  failure_class TEXT NOT NULL DEFAULT '',
+  duration_ms INTEGER NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_runs_task ON runs(task_id, started_at);
+-- Changes staged by MCP sessions but not yet committed, as the JSON of
+-- db.StagedItems, so a restarted MCP server picks up half-built plans.
+-- Sessions untouched for longer than the staging TTL are dropped.
+CREATE TABLE IF NOT EXISTS staged_changes (
+  session_id TEXT PRIMARY KEY,
+  items TEXT NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+-- Free-form labels on tasks, cutting across features, e.g. so that a worker
+-- only claims tasks labelled "frontend"
+CREATE TABLE IF NOT EXISTS task_labels (
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  label VARCHAR(55) NOT NULL,
+
+  PRIMARY KEY (task_id, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label ON task_labels(label);
+-- Feature dependencies order whole features: no task of a feature is
+-- available until every task of the features it depends on is completed or
+-- cancelled. Circular feature dependencies are rejected in Go before
+-- inserting.
+CREATE TABLE IF NOT EXISTS feature_dependencies (
+  feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  depends_on_feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  PRIMARY KEY (feature_id, depends_on_feature_id),
+  CHECK (feature_id != depends_on_feature_id) -- Prevent self-dependencies
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_dependencies_depends_on ON feature_dependencies(depends_on_feature_id);
+-- One row per orchestrator invocation, so what a run got done outlives the
+-- TUI. ended_at stays NULL while the session runs, or if it crashed.
+CREATE TABLE IF NOT EXISTS run_sessions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+  -- JSON object of model names to the number of task runs they did
+  models TEXT NOT NULL DEFAULT '{}',
+  tasks_attempted INTEGER NOT NULL DEFAULT 0 CHECK (tasks_attempted >= 0),
+  tasks_completed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_completed >= 0),
+  tasks_failed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_failed >= 0),
+  tokens_in INTEGER NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out INTEGER NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd REAL NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  duration_ms INTEGER NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMP NOT NULL,
+  ended_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_sessions_started ON run_sessions(started_at);
+-- Failed runs of tasks the orchestrator will retry, so that its retry policy
+-- and backoff outlast a restart. The row goes once the task succeeds or is
+-- blocked, completed, cancelled or deleted.
+CREATE TABLE IF NOT EXISTS task_failures (
+  task_id CHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  fail_count INTEGER NOT NULL CHECK (fail_count > 0),
+  -- failures since the task last moved along the model fallback chain, to
+  -- fallback_model
+  model_failures INTEGER NOT NULL CHECK (model_failures >= 0),
+  fallback_model TEXT,
+
+  failed_at TIMESTAMP NOT NULL
+);
 -- View for tasks whose dependencies are all completed
+-- It applies the strict availability policy; ponder's own queries follow the
+-- configured one (see AvailabilityPolicy in internal/db).
 DROP VIEW IF EXISTS v_available_tasks;
 
 CREATE VIEW v_available_tasks AS
@@ -130,6 +452,7 @@ SELECT t.*, f.name AS feature_name
 FROM tasks t
 LEFT JOIN features f ON t.feature_id = f.id
 WHERE t.status = 'pending'  -- Only tasks that are pending
+  AND (t.not_before IS NULL OR julianday(t.not_before) <= julianday('now'))
   AND NOT EXISTS (
     -- Check for any uncompleted dependencies
     SELECT 1
@@ -138,6 +461,30 @@ WHERE t.status = 'pending'  -- Only tasks that are pending
     WHERE d.task_id = t.id
       AND dep_task.status != 'completed'
   )
+  AND NOT EXISTS (
+    -- A children_first parent waits for its subtasks to be completed or cancelled
+    SELECT 1
+    FROM tasks sub
+    WHERE sub.parent_task_id = t.id
+      AND t.subtask_order = 'children_first'
+      AND sub.status NOT IN ('completed', 'cancelled')
+  )
+  AND NOT EXISTS (
+    -- A subtask of a parent_first parent waits for the parent to be completed
+    SELECT 1
+    FROM tasks parent
+    WHERE parent.id = t.parent_task_id
+      AND parent.subtask_order = 'parent_first'
+      AND parent.status != 'completed'
+  )
+  AND NOT EXISTS (
+    -- A feature waits for every task of the features it depends on
+    SELECT 1
+    FROM feature_dependencies fd
+    JOIN tasks gate ON gate.feature_id = fd.depends_on_feature_id
+    WHERE fd.feature_id = t.feature_id
+      AND gate.status NOT IN ('completed', 'cancelled')
+  )
   AND (
     -- Include tasks with no dependencies
     NOT EXISTS (
@@ -149,7 +496,7 @@ WHERE t.status = 'pending'  -- Only tasks that are pending
       SELECT 1 FROM dependencies d WHERE d.task_id = t.id
     )
   )
-ORDER BY t.priority DESC, t.created_at ASC;
+ORDER BY t.priority DESC, t.position ASC, t.created_at ASC;
 -- Recursive view that computes the dependency tree with levels and paths
 DROP VIEW IF EXISTS v_dependency_tree;
 
@@ -201,33 +548,43 @@ SELECT
 FROM task_tree
 ORDER BY path;
 -- View that outputs the entire task graph as a JSON structure
--- Format: {"nodes": [...], "edges": [...]}
+-- Format: {"nodes": [...], "edges": [...], "feature_edges": [...]}
+-- feature_edges go from a feature to one it depends on, by name
 -- Each node includes an is_available flag indicating if all dependencies are complete
 DROP VIEW IF EXISTS v_graph_json;
+DROP VIEW IF EXISTS v_graph_nodes;
+
+-- One graph node per task, so changed tasks can be sent on their own
+CREATE VIEW v_graph_nodes AS
+SELECT t.id,
+    json_object(
+        'id', t.id,
+        'name', t.name,
+        'feature_name', f.name,
+        'description', t.description,
+        'status', t.status,
+        'priority', t.priority,
+        'parent_task_id', t.parent_task_id,
+        'subtask_order', t.subtask_order,
+        'completion_summary', t.completion_summary,
+        'blocked_reason', t.blocked_reason,
+        'estimate_minutes', t.estimate_minutes,
+        'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at),
+        'started_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.started_at),
+        'completion_seconds', CASE
+            WHEN t.started_at IS NULL OR t.completed_at IS NULL THEN NULL
+            ELSE CAST(ROUND((julianday(t.completed_at) - julianday(t.started_at)) * 24 * 60 * 60) AS INTEGER)
+        END,
+        'is_available', CASE WHEN t.id IN (SELECT id FROM v_available_tasks) THEN 1 ELSE 0 END
+    ) AS node_json
+FROM tasks t
+JOIN features f ON t.feature_id = f.id;
 
 CREATE VIEW v_graph_json AS
 SELECT json_object(
     'nodes', (
-        SELECT json_group_array(
-            json_object(
-                'id', t.id,
-                'name', t.name,
-                'feature_name', f.name,
-                'description', t.description,
-                'status', t.status,
-                'priority', t.priority,
-                'completion_summary', t.completion_summary,
-                'completed_at', t.completed_at,
-                'started_at', t.started_at,
-                'completion_seconds', CASE
-                    WHEN t.started_at IS NULL OR t.completed_at IS NULL THEN NULL
-                    ELSE CAST(ROUND((julianday(t.completed_at) - julianday(t.started_at)) * 24 * 60 * 60) AS INTEGER)
-                END,
-                'is_available', CASE WHEN t.id IN (SELECT id FROM v_available_tasks) THEN 1 ELSE 0 END
-            )
-        )
-        FROM tasks t
-        JOIN features f ON t.feature_id = f.id
+        SELECT json_group_array(json(n.node_json))
+        FROM v_graph_nodes n
     ),
     'edges', (
         SELECT json_group_array(
@@ -237,11 +594,24 @@ SELECT json_object(
             )
         )
         FROM dependencies d
+    ),
+    'feature_edges', (
+        SELECT json_group_array(
+            json_object(
+                'from', f.name,
+                'to', df.name
+            )
+        )
+        FROM feature_dependencies fd
+        JOIN features f ON fd.feature_id = f.id
+        JOIN features df ON fd.depends_on_feature_id = df.id
     )
 ) as graph_json;
 -- View that emits deterministic JSONL snapshot lines using JSON1
 -- Columns:
---   record_order: ordering bucket (meta=0, feature=1, task=2, dependency=3)
+--   record_order: ordering bucket (meta=0, feature=1, task=2, dependency=3, note=4, link=5,
+--                 environment=11, template=12, feature_dependency=13, after
+--                 the archived buckets of v_snapshot_archived_jsonl_lines)
 --   sort_name: primary sort key within bucket
 --   sort_secondary: secondary sort key within bucket
 --   json_line: JSON text for the snapshot line
@@ -249,7 +619,7 @@ DROP VIEW IF EXISTS v_snapshot_jsonl_lines;
 
 CREATE VIEW v_snapshot_jsonl_lines AS
 WITH meta AS (
-  SELECT CURRENT_TIMESTAMP AS generated_at
+  SELECT strftime('%Y-%m-%dT%H:%M:%SZ', 'now') AS generated_at
 )
 SELECT
   0 AS record_order,
@@ -257,7 +627,7 @@ SELECT
   '' AS sort_secondary,
   json_object(
     'record_type', 'meta',
-    'schema_version', '1',
+    'schema_version', '2',
     'generated_at', meta.generated_at,
     'source', 'sqlite'
   ) AS json_line
@@ -295,15 +665,29 @@ SELECT
     'feature_name', f.name,
     'tests_required', json(CASE WHEN t.tests_required THEN 'true' ELSE 'false' END),
     'priority', t.priority,
+    'position', t.position,
     'status', t.status,
     'completion_summary', t.completion_summary,
+    'blocked_reason', t.blocked_reason,
+    'estimate_minutes', t.estimate_minutes,
+    'labels', CASE WHEN EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = t.id) THEN json((
+      SELECT json_group_array(label) FROM (SELECT label FROM task_labels l WHERE l.task_id = t.id ORDER BY label)
+    )) END,
     'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.created_at),
     'updated_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.updated_at),
     'started_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.started_at),
-    'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at)
+    'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at),
+    'not_before', strftime('%Y-%m-%dT%H:%M:%SZ', t.not_before),
+    'due_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.due_at),
+    'parent_task_id', t.parent_task_id,
+    'parent_task_name', p.name,
+    'parent_task_feature_name', pf.name,
+    'subtask_order', t.subtask_order
   ) AS json_line
 FROM tasks t
 LEFT JOIN features f ON t.feature_id = f.id
+LEFT JOIN tasks p ON t.parent_task_id = p.id
+LEFT JOIN features pf ON p.feature_id = pf.id
 
 UNION ALL
 
@@ -324,4 +708,211 @@ FROM dependencies d
 JOIN tasks t ON d.task_id = t.id
 JOIN features tf ON t.feature_id = tf.id
 JOIN tasks dep ON d.depends_on_task_id = dep.id
-JOIN features df ON dep.feature_id = df.id;
+JOIN features df ON dep.feature_id = df.id
+
+UNION ALL
+
+SELECT
+  4 AS record_order,
+  tf.name || '/' || t.name AS sort_name,
+  printf('%s%012d', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at), n.rowid) AS sort_secondary,
+  json_object(
+    'record_type', 'note',
+    'id', n.id,
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'author', n.author,
+    'body', n.body,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at)
+  ) AS json_line
+FROM task_notes n
+JOIN tasks t ON n.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  5 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_object(
+    'record_type', 'link',
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', l.created_at)
+  ) AS json_line
+FROM task_links l
+JOIN tasks t ON l.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  11 AS record_order,
+  f.name AS sort_name,
+  COALESCE(t.name, '') AS sort_secondary,
+  json_object(
+    'record_type', 'environment',
+    'feature_name', f.name,
+    'task_id', e.task_id,
+    'task_name', t.name,
+    'working_dir', e.working_dir,
+    'env', json(e.env)
+  ) AS json_line
+FROM run_environments e
+LEFT JOIN tasks t ON e.task_id = t.id
+JOIN features f ON f.id = COALESCE(e.feature_id, t.feature_id)
+
+UNION ALL
+
+SELECT
+  12 AS record_order,
+  tt.name AS sort_name,
+  '' AS sort_secondary,
+  json_object(
+    'record_type', 'template',
+    'id', tt.id,
+    'name', tt.name,
+    'feature_name', f.name,
+    'task_name', tt.task_name,
+    'description', tt.description,
+    'specification', tt.specification,
+    'priority', tt.priority,
+    'tests_required', json(CASE WHEN tt.tests_required THEN 'true' ELSE 'false' END),
+    'recurrence', tt.recurrence,
+    'next_run_at', strftime('%Y-%m-%dT%H:%M:%SZ', tt.next_run_at),
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', tt.created_at)
+  ) AS json_line
+FROM task_templates tt
+JOIN features f ON tt.feature_id = f.id
+
+UNION ALL
+
+SELECT
+  13 AS record_order,
+  f.name AS sort_name,
+  df.name AS sort_secondary,
+  json_object(
+    'record_type', 'feature_dependency',
+    'feature_id', f.id,
+    'feature_name', f.name,
+    'depends_on_feature_id', df.id,
+    'depends_on_feature_name', df.name
+  ) AS json_line
+FROM feature_dependencies fd
+JOIN features f ON fd.feature_id = f.id
+JOIN features df ON fd.depends_on_feature_id = df.id;
+-- View that emits snapshot lines for archived records, in the same shape as
+-- v_snapshot_jsonl_lines. Only included when a snapshot is exported with
+-- archived records. Archived tasks come before archived features so that an
+-- importer sees a feature's tasks before the feature itself.
+-- Columns:
+--   record_order: ordering bucket (archived_task=6, archived_dependency=7,
+--                 archived_note=8, archived_link=9, archived_feature=10)
+--   sort_name: primary sort key within bucket
+--   sort_secondary: secondary sort key within bucket
+--   json_line: JSON text for the snapshot line
+DROP VIEW IF EXISTS v_snapshot_archived_jsonl_lines;
+
+CREATE VIEW v_snapshot_archived_jsonl_lines AS
+SELECT
+  6 AS record_order,
+  t.feature_name || '/' || t.name AS sort_name,
+  t.id AS sort_secondary,
+  json_object(
+    'record_type', 'archived_task',
+    'id', t.id,
+    'name', t.name,
+    'description', t.description,
+    'specification', t.specification,
+    'feature_id', t.feature_id,
+    'feature_name', t.feature_name,
+    'tests_required', json(CASE WHEN t.tests_required THEN 'true' ELSE 'false' END),
+    'priority', t.priority,
+    'status', t.status,
+    'completion_summary', t.completion_summary,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.created_at),
+    'updated_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.updated_at),
+    'started_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.started_at),
+    'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at),
+    'archived_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.archived_at)
+  ) AS json_line
+FROM archived_tasks t
+
+UNION ALL
+
+SELECT
+  7 AS record_order,
+  d.task_id AS sort_name,
+  d.depends_on_task_id AS sort_secondary,
+  json_object(
+    'record_type', 'archived_dependency',
+    'task_id', d.task_id,
+    'task_name', COALESCE(lt.name, at.name),
+    'task_feature_name', COALESCE(lf.name, at.feature_name),
+    'depends_on_task_id', d.depends_on_task_id,
+    'depends_on_task_name', COALESCE(ldt.name, adt.name),
+    'depends_on_task_feature_name', COALESCE(ldf.name, adt.feature_name)
+  ) AS json_line
+FROM archived_dependencies d
+LEFT JOIN tasks lt ON d.task_id = lt.id
+LEFT JOIN features lf ON lt.feature_id = lf.id
+LEFT JOIN archived_tasks at ON d.task_id = at.id
+LEFT JOIN tasks ldt ON d.depends_on_task_id = ldt.id
+LEFT JOIN features ldf ON ldt.feature_id = ldf.id
+LEFT JOIN archived_tasks adt ON d.depends_on_task_id = adt.id
+
+UNION ALL
+
+SELECT
+  8 AS record_order,
+  n.task_id AS sort_name,
+  printf('%s%s', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at), n.id) AS sort_secondary,
+  json_object(
+    'record_type', 'archived_note',
+    'id', n.id,
+    'task_id', n.task_id,
+    'author', n.author,
+    'body', n.body,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at)
+  ) AS json_line
+FROM archived_task_notes n
+
+UNION ALL
+
+SELECT
+  9 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_object(
+    'record_type', 'archived_link',
+    'task_id', l.task_id,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', l.created_at)
+  ) AS json_line
+FROM archived_task_links l
+
+UNION ALL
+
+SELECT
+  10 AS record_order,
+  f.name AS sort_name,
+  f.id AS sort_secondary,
+  json_object(
+    'record_type', 'archived_feature',
+    'id', f.id,
+    'name', f.name,
+    'description', f.description,
+    'specification', f.specification,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', f.created_at),
+    'updated_at', strftime('%Y-%m-%dT%H:%M:%SZ', f.updated_at),
+    'archived_at', strftime('%Y-%m-%dT%H:%M:%SZ', f.archived_at)
+  ) AS json_line
+FROM archived_features f;
@@ -1,6 +1,19 @@
 package sql
 
-import _ "embed"
+import "embed"
 
 //go:embed schema.sql
 var Schema string
+
+// PostgresSchema is the equivalent of Schema for the Postgres backend.
+//
+//go:embed postgres_schema.sql
+var PostgresSchema string
+
+// Migrations holds the numbered schema changes that bring existing databases
+// up to date, one NNNN_name.sql file per change under migrations/sqlite and
+// migrations/postgres. Schema and PostgresSchema already include them, so
+// fresh databases start at the latest version.
+//
+//go:embed migrations
+var Migrations embed.FS
@@ -0,0 +1,8 @@
+// Package starters holds the templates `ponder init --template` seeds a new
+// project with. Each is a JSONL file of "feature" and "task" records.
+package starters
+
+import "embed"
+
+//go:embed *.jsonl
+var FS embed.FS
@@ -0,0 +1,23 @@
+// Package actor carries the identity of whoever is mutating Ponder data
+// (an MCP session, an orchestrator worker, or the CLI) through a context.
+package actor
+
+import "context"
+
+// System is reported when no actor has been attached to the context.
+const System = "system"
+
+type contextKey struct{}
+
+// With returns a copy of ctx that records name as the acting party.
+func With(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// From returns the actor recorded in ctx, or System if there is none.
+func From(ctx context.Context) string {
+	if name, ok := ctx.Value(contextKey{}).(string); ok && name != "" {
+		return name
+	}
+	return System
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// PriorityAging raises the effective priority of pending tasks the longer
+// they sit unclaimed, so low-priority work still runs while higher-priority
+// tasks keep arriving. Only the claim order changes; the stored priority is
+// left alone.
+type PriorityAging struct {
+	// Interval is how long a task waits for each bump. Zero disables aging.
+	Interval time.Duration
+	// Step is added to the effective priority every Interval.
+	Step int
+	// MaxBoost caps the total bump. Zero means no cap.
+	MaxBoost int
+}
+
+// Enabled reports whether aging changes the claim order at all.
+func (a PriorityAging) Enabled() bool {
+	return a.Interval > 0 && a.Step > 0
+}
+
+// Validate checks that the aging settings are usable.
+func (a PriorityAging) Validate() error {
+	if a.Interval < 0 {
+		return fmt.Errorf("interval must be >= 0")
+	}
+	if a.Interval > 0 && a.Interval < time.Second {
+		return fmt.Errorf("interval must be at least 1s")
+	}
+	if a.Step < 0 {
+		return fmt.Errorf("step must be >= 0")
+	}
+	if a.MaxBoost < 0 {
+		return fmt.Errorf("max_boost must be >= 0")
+	}
+	return nil
+}
+
+// SetPriorityAging configures how ClaimNextTask and GetAvailableTasks age
+// pending tasks. The zero value turns aging off.
+func (db *DB) SetPriorityAging(a PriorityAging) {
+	db.agingMu.Lock()
+	defer db.agingMu.Unlock()
+	db.aging = a
+}
+
+// PriorityAging returns the current aging settings.
+func (db *DB) PriorityAging() PriorityAging {
+	db.agingMu.RLock()
+	defer db.agingMu.RUnlock()
+	return db.aging
+}
+
+// effectivePriority returns an SQL expression for the aged priority of the
+// task table aliased as alias, along with its arguments. Age is measured from
+// the task's creation.
+func (a PriorityAging) effectivePriority(d dialect, alias string) (string, []any) {
+	if !a.Enabled() {
+		return alias + ".priority", nil
+	}
+
+	bump := d.truncate(d.secondsSince(alias+".created_at")+" / ?") + " * ?"
+	args := []any{a.Interval.Seconds(), a.Step}
+	if a.MaxBoost > 0 {
+		bump = d.least(bump, "?")
+		args = append(args, a.MaxBoost)
+	}
+	return fmt.Sprintf("(%s.priority + %s)", alias, bump), args
+}
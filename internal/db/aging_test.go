@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestPriorityAging(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	old := &models.Task{FeatureID: f.ID, Name: "old-low", Description: "d", Specification: "s", Priority: 1, Status: models.TaskStatusPending}
+	fresh := &models.Task{FeatureID: f.ID, Name: "fresh-high", Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{old, fresh} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	// The low-priority task has been waiting for ten hours.
+	if _, err := db.ExecContext(ctx, "UPDATE tasks SET created_at = datetime('now', '-10 hours') WHERE id = ?", old.ID); err != nil {
+		t.Fatalf("Failed to backdate task: %v", err)
+	}
+
+	first := func() string {
+		t.Helper()
+		tasks, err := db.GetAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("GetAvailableTasks failed: %v", err)
+		}
+		if len(tasks) != 2 {
+			t.Fatalf("Expected 2 available tasks, got %d", len(tasks))
+		}
+		return tasks[0].Name
+	}
+
+	if got := first(); got != "fresh-high" {
+		t.Errorf("Without aging expected fresh-high first, got %s", got)
+	}
+
+	// +1 per hour: 1 + 10 beats 5.
+	db.SetPriorityAging(PriorityAging{Interval: time.Hour, Step: 1})
+	if got := first(); got != "old-low" {
+		t.Errorf("With aging expected old-low first, got %s", got)
+	}
+
+	// Capped at +3: 1 + 3 still loses to 5.
+	db.SetPriorityAging(PriorityAging{Interval: time.Hour, Step: 1, MaxBoost: 3})
+	if got := first(); got != "fresh-high" {
+		t.Errorf("With capped aging expected fresh-high first, got %s", got)
+	}
+
+	db.SetPriorityAging(PriorityAging{Interval: time.Hour, Step: 1})
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimNextTask failed: %v", err)
+	}
+	if claimed == nil || claimed.ID != old.ID {
+		t.Errorf("Expected ClaimNextTask to pick the aged task, got %+v", claimed)
+	}
+	if claimed != nil && claimed.Priority != 1 {
+		t.Errorf("Aging must not change the stored priority, got %d", claimed.Priority)
+	}
+}
+
+func TestPriorityAgingValidate(t *testing.T) {
+	if err := (PriorityAging{}).Validate(); err != nil {
+		t.Errorf("Zero value should be valid: %v", err)
+	}
+	for _, a := range []PriorityAging{
+		{Interval: -time.Minute, Step: 1},
+		{Interval: time.Millisecond, Step: 1},
+		{Interval: time.Hour, Step: -1},
+		{Interval: time.Hour, Step: 1, MaxBoost: -2},
+	} {
+		if err := a.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", a)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrEmptyAppend is returned when there is no text to append to a
+// specification.
+var ErrEmptyAppend = errors.New("nothing to append")
+
+// specSection formats text as a section to add to the end of a
+// specification, headed by title, if any, and the time it was added.
+func specSection(spec, title, text string, now time.Time) string {
+	heading := "## Update"
+	if title = strings.TrimSpace(title); title != "" {
+		heading = "## " + title
+	}
+	heading += " (" + now.UTC().Format(time.RFC3339) + ")"
+
+	section := heading + "\n\n" + strings.TrimSpace(text)
+	if strings.TrimSpace(spec) == "" {
+		return section
+	}
+	return "\n\n" + section
+}
+
+// AppendTaskSpecification adds a timestamped section to the end of a task's
+// specification, leaving what is already there untouched. The append happens
+// in the database, so concurrent appends don't overwrite each other.
+func (db *DB) AppendTaskSpecification(ctx context.Context, id, title, text string) (*models.Task, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyAppend
+	}
+
+	var t *models.Task
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := db.getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+
+		after := *before
+		query := `
+			UPDATE tasks SET specification = specification || ?
+			WHERE id = ?
+			RETURNING specification, updated_at
+		`
+		err = tx.QueryRowContext(ctx, query, specSection(before.Specification, title, text, time.Now()), id).
+			Scan(&after.Specification, &after.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to append to task specification: %w", err)
+		}
+		t = &after
+		return recordEvent(ctx, tx, EntityTask, id, t.Name, models.EventUpdated, snippetOfTask(before), snippetOfTask(t))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.triggerChange(ctx)
+	return t, nil
+}
+
+// AppendFeatureSpecification adds a timestamped section to the end of a
+// feature's specification, like AppendTaskSpecification.
+func (db *DB) AppendFeatureSpecification(ctx context.Context, id, title, text string) (*models.Feature, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyAppend
+	}
+
+	var f *models.Feature
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := db.getFeature(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("feature not found: %s", id)
+		}
+
+		after := *before
+		query := `
+			UPDATE features SET specification = specification || ?
+			WHERE id = ?
+			RETURNING specification, updated_at
+		`
+		err = tx.QueryRowContext(ctx, query, specSection(before.Specification, title, text, time.Now()), id).
+			Scan(&after.Specification, &after.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to append to feature specification: %w", err)
+		}
+		f = &after
+		return recordEvent(ctx, tx, EntityFeature, id, f.Name, models.EventUpdated, snippetOfFeature(before), snippetOfFeature(f))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.triggerChange(ctx)
+	return f, nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestAppendTaskSpecification(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "Do the thing.", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	for _, text := range []string{"First finding.", "Second finding."} {
+		if _, err := db.AppendTaskSpecification(ctx, task.ID, "", text); err != nil {
+			t.Fatalf("AppendTaskSpecification failed: %v", err)
+		}
+	}
+	got, _ := db.GetTask(ctx, task.ID)
+	want := "Do the thing.\n\n## Update (X)\n\nFirst finding.\n\n## Update (X)\n\nSecond finding."
+	if stripTimestamps(got.Specification) != want {
+		t.Errorf("expected %q, got %q", want, got.Specification)
+	}
+
+	if _, err := db.AppendTaskSpecification(ctx, task.ID, "", "\n"); !errors.Is(err, ErrEmptyAppend) {
+		t.Errorf("expected ErrEmptyAppend, got %v", err)
+	}
+
+	events, err := db.ListEvents(ctx, EntityTask, task.ID, 10)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	updates := 0
+	for _, e := range events {
+		if e.Action == models.EventUpdated {
+			updates++
+		}
+	}
+	if updates != 2 {
+		t.Errorf("expected both appends in the audit log, got %d updates", updates)
+	}
+}
+
+func TestSpecSection(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := specSection("", "Notes", " text \n", now); got != "## Notes (2026-01-02T03:04:05Z)\n\ntext" {
+		t.Errorf("unexpected section for an empty spec: %q", got)
+	}
+	if got := specSection("spec", "", "text", now); got != "\n\n## Update (2026-01-02T03:04:05Z)\n\ntext" {
+		t.Errorf("unexpected section: %q", got)
+	}
+}
+
+// stripTimestamps replaces the times in section headings with X.
+func stripTimestamps(spec string) string {
+	return regexp.MustCompile(`\(\d{4}-\d\d-\d\dT[\d:]+Z\)`).ReplaceAllString(spec, "(X)")
+}
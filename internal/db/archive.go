@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// defaultFeatureID is the seeded "misc" feature, which is never archived.
+const defaultFeatureID = "00000000-0000-0000-0000-000000000000"
+
+// ArchiveResult reports how many rows an archive run moved.
+type ArchiveResult struct {
+	Tasks    int
+	Features int
+}
+
+// archivableTasks returns a subquery selecting the completed tasks due for
+// archiving; its single argument is the cutoff time.
+func (db *DB) archivableTasks() string {
+	return `
+	SELECT id FROM tasks
+	WHERE status = 'completed' AND completed_at IS NOT NULL
+	  AND ` + db.dialect.atOrBefore("completed_at")
+}
+
+// upsertArchivedTask and upsertArchivedFeature overwrite an archived row that
+// was archived before, e.g. by an earlier snapshot import.
+const (
+	upsertArchivedTask = `
+	ON CONFLICT (id) DO UPDATE SET
+		feature_id = excluded.feature_id, feature_name = excluded.feature_name, name = excluded.name,
+		description = excluded.description, specification = excluded.specification,
+		priority = excluded.priority, tests_required = excluded.tests_required, status = excluded.status,
+		completion_summary = excluded.completion_summary, created_at = excluded.created_at,
+		updated_at = excluded.updated_at, started_at = excluded.started_at,
+		completed_at = excluded.completed_at, archived_at = excluded.archived_at`
+	upsertArchivedFeature = `
+	ON CONFLICT (id) DO UPDATE SET
+		name = excluded.name, description = excluded.description, specification = excluded.specification,
+		created_at = excluded.created_at, updated_at = excluded.updated_at, archived_at = excluded.archived_at`
+)
+
+// ArchiveCompleted moves tasks completed before the cutoff, with their
+// dependencies, notes, links and usage, into the archive tables. Features left
+// without live tasks whose tasks have all been archived are moved as well.
+// Archived rows no longer appear in lists, the graph or default snapshots.
+func (db *DB) ArchiveCompleted(ctx context.Context, before time.Time) (*ArchiveResult, error) {
+	cutoff := before.UTC().Format("2006-01-02 15:04:05")
+	result := &ArchiveResult{}
+
+	if err := db.backupBefore(ctx, "archive"); err != nil {
+		return nil, err
+	}
+
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		archivableTasks := db.archivableTasks()
+		tasks, err := archiveCandidates(ctx, tx, archivableTasks, cutoff)
+		if err != nil {
+			return err
+		}
+		result.Tasks = len(tasks)
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		statements := []string{
+			`INSERT INTO archived_dependencies (task_id, depends_on_task_id)
+			 SELECT task_id, depends_on_task_id FROM dependencies
+			 WHERE task_id IN (` + archivableTasks + `) OR depends_on_task_id IN (` + archivableTasks + `)
+			 ON CONFLICT DO NOTHING`,
+			`INSERT INTO archived_task_notes (id, task_id, author, body, created_at)
+			 SELECT id, task_id, author, body, created_at FROM task_notes
+			 WHERE task_id IN (` + archivableTasks + `)
+			 ON CONFLICT (id) DO UPDATE SET
+				task_id = excluded.task_id, author = excluded.author, body = excluded.body, created_at = excluded.created_at`,
+			`INSERT INTO archived_task_links (task_id, provider, external_ref, url, created_at)
+			 SELECT task_id, provider, external_ref, url, created_at FROM task_links
+			 WHERE task_id IN (` + archivableTasks + `)
+			 ON CONFLICT (provider, external_ref) DO UPDATE SET
+				task_id = excluded.task_id, url = excluded.url, created_at = excluded.created_at`,
+			`INSERT INTO archived_task_usage (task_id, model, tokens_in, tokens_out, cost_usd, estimated, created_at)
+			 SELECT task_id, model, tokens_in, tokens_out, cost_usd, estimated, created_at FROM task_usage
+			 WHERE task_id IN (` + archivableTasks + `)`,
+			`INSERT INTO archived_tasks (
+				id, feature_id, feature_name, name, description, specification, priority, tests_required,
+				status, completion_summary, created_at, updated_at, started_at, completed_at
+			 )
+			 SELECT t.id, t.feature_id, f.name, t.name, t.description, t.specification, t.priority, t.tests_required,
+			        t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at
+			 FROM tasks t
+			 JOIN features f ON t.feature_id = f.id
+			 WHERE t.id IN (` + archivableTasks + `)` + upsertArchivedTask,
+		}
+		for i, query := range statements {
+			args := []any{cutoff}
+			if i == 0 {
+				args = append(args, cutoff)
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("failed to archive tasks: %w", err)
+			}
+		}
+
+		for _, t := range tasks {
+			if err := recordEvent(ctx, tx, EntityTask, t.ID, t.Name, models.EventArchived, snippetOfTask(t), nil); err != nil {
+				return err
+			}
+		}
+
+		// Deleting cascades to the live dependencies, notes, links and usage
+		// copied above.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE id IN (`+archivableTasks+`)`, cutoff); err != nil {
+			return fmt.Errorf("failed to delete archived tasks: %w", err)
+		}
+
+		features, err := archiveFeatures(ctx, tx)
+		if err != nil {
+			return err
+		}
+		result.Features = features
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Tasks > 0 {
+		db.triggerChange(ctx)
+	}
+	return result, nil
+}
+
+func archiveCandidates(ctx context.Context, tx *sql.Tx, archivableTasks, cutoff string) ([]*models.Task, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, name, status, priority FROM tasks WHERE id IN (`+archivableTasks+`)`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks to archive: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		t := &models.Task{}
+		if err := rows.Scan(&t.ID, &t.Name, &t.Status, &t.Priority); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// archiveFeatures moves features that have archived tasks and no live ones.
+func archiveFeatures(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, name, description, specification FROM features f
+		WHERE f.id != ?
+		  AND NOT EXISTS (SELECT 1 FROM tasks t WHERE t.feature_id = f.id)
+		  AND EXISTS (SELECT 1 FROM archived_tasks a WHERE a.feature_id = f.id)`, defaultFeatureID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query features to archive: %w", err)
+	}
+	var features []*models.Feature
+	for rows.Next() {
+		f := &models.Feature{}
+		if err := rows.Scan(&f.ID, &f.Name, &f.Description, &f.Specification); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan feature: %w", err)
+		}
+		features = append(features, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, f := range features {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO archived_features (id, name, description, specification, created_at, updated_at)
+			SELECT id, name, description, specification, created_at, updated_at FROM features WHERE id = ?`+
+			upsertArchivedFeature, f.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to archive feature %s: %w", f.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM features WHERE id = ?`, f.ID); err != nil {
+			return 0, fmt.Errorf("failed to delete archived feature %s: %w", f.Name, err)
+		}
+		if err := recordEvent(ctx, tx, EntityFeature, f.ID, f.Name, models.EventArchived, snippetOfFeature(f), nil); err != nil {
+			return 0, err
+		}
+	}
+	return len(features), nil
+}
+
+// ListArchivedTasks returns archived tasks, most recently completed first,
+// optionally limited to one feature.
+func (db *DB) ListArchivedTasks(ctx context.Context, featureName *string) ([]*models.Task, error) {
+	query := `
+		SELECT id, feature_id, name, description, specification, priority, tests_required,
+		       status, completion_summary, created_at, updated_at, started_at, completed_at,
+		       NULL AS not_before, NULL AS due_at, NULL AS parent_task_id,
+		       'children_first' AS subtask_order, 0 AS position, NULL AS blocked_reason, NULL AS estimate_minutes, 0 AS version, feature_name
+		FROM archived_tasks
+		WHERE 1=1
+	`
+	args := []interface{}{}
+
+	if featureName != nil {
+		query += " AND feature_name = ?"
+		args = append(args, *featureName)
+	}
+
+	query += " ORDER BY completed_at DESC, name ASC"
+
+	return db.queryTasks(ctx, query, args...)
+}
+
+// ListArchivedFeatures returns archived features ordered by name.
+func (db *DB) ListArchivedFeatures(ctx context.Context) ([]*models.Feature, error) {
+	rows, err := db.read().QueryContext(ctx, `
+		SELECT id, name, description, specification, created_at, updated_at
+		FROM archived_features
+		ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived features: %w", err)
+	}
+	defer rows.Close()
+
+	var features []*models.Feature
+	for rows.Next() {
+		f := &models.Feature{}
+		if err := rows.Scan(&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan archived feature: %w", err)
+		}
+		features = append(features, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return features, nil
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestArchiveCompleted(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	newTask := func(feature *models.Feature, name string) *models.Task {
+		task := &models.Task{FeatureID: feature.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		return task
+	}
+	complete := func(task *models.Task) {
+		summary := "done"
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			t.Fatalf("Failed to start task: %v", err)
+		}
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted, &summary); err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+	}
+
+	core := &models.Feature{Name: "core", Description: "d", Specification: "s"}
+	web := &models.Feature{Name: "web", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{core, web} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+
+	schema := newTask(core, "schema")
+	config := newTask(core, "config")
+	page := newTask(web, "page")
+	recent := newTask(web, "recent")
+	for _, task := range []*models.Task{schema, config, recent} {
+		complete(task)
+	}
+	if err := db.CreateDependency(ctx, page.ID, schema.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+	if err := db.AddTaskNote(ctx, &models.TaskNote{TaskID: schema.ID, Author: "agent", Body: "used sqlite"}); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if err := db.RecordTaskUsage(ctx, &models.TaskUsage{TaskID: schema.ID, TokensIn: 100, CostUSD: 0.5}); err != nil {
+		t.Fatalf("Failed to record usage: %v", err)
+	}
+
+	// Age the core tasks so only they fall before the cutoff.
+	if _, err := db.ExecContext(ctx, "UPDATE tasks SET completed_at = datetime('now', '-40 days') WHERE feature_id = ?", core.ID); err != nil {
+		t.Fatalf("Failed to age tasks: %v", err)
+	}
+
+	result, err := db.ArchiveCompleted(ctx, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("ArchiveCompleted failed: %v", err)
+	}
+	if result.Tasks != 2 || result.Features != 1 {
+		t.Errorf("Expected 2 tasks and 1 feature archived, got %+v", result)
+	}
+
+	tasks, err := db.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected 2 live tasks, got %d", len(tasks))
+	}
+	if f, _ := db.GetFeatureByName(ctx, "core"); f != nil {
+		t.Error("Expected core feature to be archived")
+	}
+	if f, _ := db.GetFeatureByName(ctx, "web"); f == nil {
+		t.Error("Expected web feature to stay live")
+	}
+
+	available, err := db.GetAvailableTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAvailableTasks failed: %v", err)
+	}
+	if len(available) != 1 || available[0].ID != page.ID {
+		t.Errorf("Expected page to stay available, got %+v", available)
+	}
+
+	archived, err := db.ListArchivedTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListArchivedTasks failed: %v", err)
+	}
+	if len(archived) != 2 || archived[0].FeatureName != "core" {
+		t.Errorf("Expected 2 archived core tasks, got %+v", archived)
+	}
+	features, err := db.ListArchivedFeatures(ctx)
+	if err != nil {
+		t.Fatalf("ListArchivedFeatures failed: %v", err)
+	}
+	if len(features) != 1 || features[0].Name != "core" {
+		t.Errorf("Expected archived core feature, got %+v", features)
+	}
+
+	totals, err := db.GetUsageTotals(ctx)
+	if err != nil {
+		t.Fatalf("GetUsageTotals failed: %v", err)
+	}
+	if totals.Runs != 1 || totals.CostUSD != 0.5 {
+		t.Errorf("Expected archived usage in totals, got %+v", totals)
+	}
+
+	events, err := db.ListEvents(ctx, EntityTask, schema.ID, 1)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != models.EventArchived {
+		t.Errorf("Expected archived event, got %+v", events)
+	}
+
+	// A second run has nothing left to do.
+	result, err = db.ArchiveCompleted(ctx, time.Now().Add(-30*24*time.Hour))
+	if err != nil || result.Tasks != 0 {
+		t.Errorf("Expected nothing to archive, got %+v, %v", result, err)
+	}
+}
+
+func TestSnapshotIncludeArchived(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "core", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	done := &models.Task{FeatureID: f.ID, Name: "done", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	next := &models.Task{FeatureID: f.ID, Name: "next", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{done, next} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if err := db.CreateDependency(ctx, next.ID, done.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+	if err := db.AddTaskNote(ctx, &models.TaskNote{TaskID: done.ID, Author: "agent", Body: "note"}); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	summary := "done"
+	db.UpdateTaskStatus(ctx, done.ID, models.TaskStatusInProgress, nil)
+	if err := db.UpdateTaskStatus(ctx, done.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	if _, err := db.ArchiveCompleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ArchiveCompleted failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	live := filepath.Join(dir, "live.jsonl")
+	full := filepath.Join(dir, "full.jsonl")
+	if err := db.ExportSnapshot(ctx, live); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if err := db.ExportSnapshotWithOptions(ctx, full, SnapshotOptions{IncludeArchived: true}); err != nil {
+		t.Fatalf("ExportSnapshotWithOptions failed: %v", err)
+	}
+
+	liveData, _ := os.ReadFile(live)
+	if strings.Contains(string(liveData), "archived_") {
+		t.Error("Default snapshot should not contain archived records")
+	}
+	fullData, _ := os.ReadFile(full)
+	for _, typ := range []string{"archived_task", "archived_dependency", "archived_note"} {
+		if !strings.Contains(string(fullData), `"record_type":"`+typ+`"`) {
+			t.Errorf("Expected %s record in full snapshot", typ)
+		}
+	}
+
+	restored, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer restored.Close()
+	if err := restored.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := restored.ImportSnapshot(ctx, full); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+
+	archived, err := restored.ListArchivedTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListArchivedTasks failed: %v", err)
+	}
+	if len(archived) != 1 || archived[0].Name != "done" || archived[0].CompletionSummary == nil {
+		t.Errorf("Expected archived task to be restored, got %+v", archived)
+	}
+	tasks, _ := restored.ListTasks(ctx, nil, nil)
+	if len(tasks) != 1 || tasks[0].Name != "next" {
+		t.Errorf("Expected only the live task to be restored live, got %+v", tasks)
+	}
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Dependency policies of an AvailabilityPolicy.
+const (
+	// DependencyPolicyStrict keeps a task unavailable until every task it
+	// depends on is completed, so a blocked or cancelled dependency holds it
+	// back for good.
+	DependencyPolicyStrict = "strict"
+	// DependencyPolicySoft lets a task whose dependencies are blocked or
+	// cancelled be claimed anyway, at a lower priority. Pending and running
+	// dependencies still hold it back.
+	DependencyPolicySoft = "soft"
+)
+
+// AvailabilityPolicy decides how dependencies that won't complete affect
+// the tasks waiting on them. The zero value is the strict policy.
+type AvailabilityPolicy struct {
+	// Dependencies is DependencyPolicyStrict or DependencyPolicySoft. Empty
+	// means strict.
+	Dependencies string
+	// Penalty is taken off a task's priority for each of its dependencies
+	// that is blocked or cancelled, under the soft policy.
+	Penalty int
+}
+
+// Soft reports whether blocked and cancelled dependencies only lower the
+// priority of the tasks waiting on them.
+func (p AvailabilityPolicy) Soft() bool {
+	return p.Dependencies == DependencyPolicySoft
+}
+
+// Validate checks that the policy settings are usable.
+func (p AvailabilityPolicy) Validate() error {
+	switch p.Dependencies {
+	case "", DependencyPolicyStrict, DependencyPolicySoft:
+	default:
+		return fmt.Errorf("dependencies must be %q or %q", DependencyPolicyStrict, DependencyPolicySoft)
+	}
+	if p.Penalty < 0 {
+		return fmt.Errorf("penalty must be >= 0")
+	}
+	if p.Penalty > 0 && !p.Soft() {
+		return fmt.Errorf("penalty needs the %q dependencies policy", DependencyPolicySoft)
+	}
+	return nil
+}
+
+// unmet returns the condition under which the dependency aliased dep holds
+// back the tasks waiting on it.
+func (p AvailabilityPolicy) unmet(dep string) string {
+	if p.Soft() {
+		return dep + ".status NOT IN ('completed', 'blocked', 'cancelled')"
+	}
+	return dep + ".status != 'completed'"
+}
+
+// penalize returns the priority expression with the soft policy's penalty
+// taken off for the task aliased t, along with the arguments of both.
+func (p AvailabilityPolicy) penalize(priority string, args []any, t string) (string, []any) {
+	if !p.Soft() || p.Penalty == 0 {
+		return priority, args
+	}
+	return `(` + priority + ` - ? * (
+		SELECT COUNT(*)
+		FROM dependencies d
+		JOIN tasks dep_task ON d.depends_on_task_id = dep_task.id
+		WHERE d.task_id = ` + t + `.id
+		  AND dep_task.status IN ('blocked', 'cancelled')
+	))`, append(args, p.Penalty)
+}
+
+// SetAvailabilityPolicy sets how GetAvailableTasks, CountAvailableTasks,
+// ClaimNextTask and PlanClaims treat blocked and cancelled dependencies. The
+// zero value is the strict policy.
+func (db *DB) SetAvailabilityPolicy(p AvailabilityPolicy) {
+	db.agingMu.Lock()
+	defer db.agingMu.Unlock()
+	db.availability = p
+	db.cache.invalidate()
+}
+
+// AvailabilityPolicy returns the current availability policy.
+func (db *DB) AvailabilityPolicy() AvailabilityPolicy {
+	db.agingMu.RLock()
+	defer db.agingMu.RUnlock()
+	return db.availability
+}
+
+// availableWhere returns the condition under which the task aliased t can be
+// claimed now: it is pending, its not_before has passed, none of its
+// dependencies hold it back under the availability policy, its subtasks or
+// parent don't have to go first and its feature's prerequisite features are
+// done.
+func (db *DB) availableWhere(t string) string {
+	return t + `.status = 'pending'
+		  AND (` + t + `.not_before IS NULL OR ` + db.dialect.secondsSince(t+".not_before") + ` >= 0)
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM dependencies d
+			JOIN tasks dep_task ON d.depends_on_task_id = dep_task.id
+			WHERE d.task_id = ` + t + `.id
+			  AND ` + db.AvailabilityPolicy().unmet("dep_task") + `
+		)
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM tasks sub
+			WHERE sub.parent_task_id = ` + t + `.id
+			  AND ` + t + `.subtask_order = 'children_first'
+			  AND sub.status NOT IN ('completed', 'cancelled')
+		)
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM tasks parent
+			WHERE parent.id = ` + t + `.parent_task_id
+			  AND parent.subtask_order = 'parent_first'
+			  AND parent.status != 'completed'
+		)
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM feature_dependencies fd
+			JOIN tasks gate ON gate.feature_id = fd.depends_on_feature_id
+			WHERE fd.feature_id = ` + t + `.feature_id
+			  AND gate.status NOT IN ('completed', 'cancelled')
+		)`
+}
+
+// claimPriority returns the expression tasks aliased t are claimed in order
+// of, highest first: the priority after aging and the availability policy's
+// penalty, along with its arguments.
+func (db *DB) claimPriority(t string) (string, []any) {
+	priority, args := db.PriorityAging().effectivePriority(db.dialect, t)
+	return db.AvailabilityPolicy().penalize(priority, args, t)
+}
+
+// markAvailable sets the is_available flag of graph nodes by the
+// availability policy. The graph views only know the strict policy, so under
+// the soft one the flags are worked out again here.
+func (db *DB) markAvailable(ctx context.Context, nodes []json.RawMessage) error {
+	if !db.AvailabilityPolicy().Soft() || len(nodes) == 0 {
+		return nil
+	}
+
+	rows, err := db.read().QueryContext(ctx, "SELECT t.id FROM tasks t WHERE "+db.availableWhere("t"))
+	if err != nil {
+		return fmt.Errorf("failed to get available tasks: %w", err)
+	}
+	defer rows.Close()
+	available := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan available task: %w", err)
+		}
+		available[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get available tasks: %w", err)
+	}
+
+	for i, raw := range nodes {
+		var node map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return fmt.Errorf("failed to parse graph node: %w", err)
+		}
+		var id string
+		if err := json.Unmarshal(node["id"], &id); err != nil {
+			return fmt.Errorf("failed to parse graph node: %w", err)
+		}
+		node["is_available"] = json.RawMessage("0")
+		if available[id] {
+			node["is_available"] = json.RawMessage("1")
+		}
+		if nodes[i], err = json.Marshal(node); err != nil {
+			return fmt.Errorf("failed to encode graph node: %w", err)
+		}
+	}
+	return nil
+}
+
+// markGraphAvailable applies markAvailable to the nodes of a whole graph.
+func (db *DB) markGraphAvailable(ctx context.Context, graph string) (string, error) {
+	var parts map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(graph), &parts); err != nil {
+		return "", fmt.Errorf("failed to parse graph json: %w", err)
+	}
+	var nodes []json.RawMessage
+	if err := json.Unmarshal(parts["nodes"], &nodes); err != nil {
+		return "", fmt.Errorf("failed to parse graph nodes: %w", err)
+	}
+	if err := db.markAvailable(ctx, nodes); err != nil {
+		return "", err
+	}
+	var err error
+	if parts["nodes"], err = json.Marshal(nodes); err != nil {
+		return "", fmt.Errorf("failed to encode graph nodes: %w", err)
+	}
+	out, err := json.Marshal(parts)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode graph json: %w", err)
+	}
+	return string(out), nil
+}
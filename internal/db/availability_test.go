@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestAvailabilityPolicy(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	tasks := map[string]*models.Task{}
+	for _, spec := range []struct {
+		name     string
+		priority int
+	}{
+		{"blocked", 5}, {"cancelled", 5}, {"pending", 1},
+		{"after-blocked", 8}, {"after-both", 8}, {"after-pending", 9}, {"free", 7},
+	} {
+		task := &models.Task{FeatureID: f.ID, Name: spec.name, Description: "d", Specification: "s", Priority: spec.priority, Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		tasks[spec.name] = task
+	}
+	for task, deps := range map[string][]string{
+		"after-blocked": {"blocked"},
+		"after-both":    {"blocked", "cancelled"},
+		"after-pending": {"pending"},
+	} {
+		for _, dep := range deps {
+			if err := db.CreateDependency(ctx, tasks[task].ID, tasks[dep].ID); err != nil {
+				t.Fatalf("Failed to create dependency: %v", err)
+			}
+		}
+	}
+	for name, status := range map[string]string{"blocked": "blocked", "cancelled": "cancelled"} {
+		if _, err := db.ExecContext(ctx, "UPDATE tasks SET status = ? WHERE id = ?", status, tasks[name].ID); err != nil {
+			t.Fatalf("Failed to set status: %v", err)
+		}
+	}
+
+	names := func(tasks []*models.Task) []string {
+		var names []string
+		for _, task := range tasks {
+			names = append(names, task.Name)
+		}
+		return names
+	}
+
+	cases := []struct {
+		policy    AvailabilityPolicy
+		available []string
+		plan      []string
+	}{
+		{
+			policy:    AvailabilityPolicy{},
+			available: []string{"free", "pending"},
+			plan:      []string{"free", "pending", "after-pending"},
+		},
+		{
+			policy:    AvailabilityPolicy{Dependencies: DependencyPolicySoft},
+			available: []string{"after-blocked", "after-both", "free", "pending"},
+			plan:      []string{"after-blocked", "after-both", "free", "pending", "after-pending"},
+		},
+		{
+			// 8 less 2 per blocked or cancelled dependency: 6 and 4.
+			policy:    AvailabilityPolicy{Dependencies: DependencyPolicySoft, Penalty: 2},
+			available: []string{"free", "after-blocked", "after-both", "pending"},
+			plan:      []string{"free", "after-blocked", "after-both", "pending", "after-pending"},
+		},
+	}
+	for _, c := range cases {
+		db.SetAvailabilityPolicy(c.policy)
+
+		available, err := db.GetAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("GetAvailableTasks failed: %v", err)
+		}
+		if got := names(available); !reflect.DeepEqual(got, c.available) {
+			t.Errorf("%+v: expected available %v, got %v", c.policy, c.available, got)
+		}
+		if count, err := db.CountAvailableTasks(ctx); err != nil || count != len(c.available) {
+			t.Errorf("%+v: expected %d available, counted %d (%v)", c.policy, len(c.available), count, err)
+		}
+		plan, err := db.PlanClaims(ctx)
+		if err != nil {
+			t.Fatalf("PlanClaims failed: %v", err)
+		}
+		if got := names(plan); !reflect.DeepEqual(got, c.plan) {
+			t.Errorf("%+v: expected plan %v, got %v", c.policy, c.plan, got)
+		}
+
+		graphJSON, err := db.GetGraphJSON(ctx)
+		if err != nil {
+			t.Fatalf("GetGraphJSON failed: %v", err)
+		}
+		var graph struct {
+			Nodes []struct {
+				Name        string `json:"name"`
+				IsAvailable int    `json:"is_available"`
+			} `json:"nodes"`
+		}
+		if err := json.Unmarshal([]byte(graphJSON), &graph); err != nil {
+			t.Fatalf("Failed to parse graph: %v", err)
+		}
+		var graphAvailable int
+		for _, n := range graph.Nodes {
+			graphAvailable += n.IsAvailable
+		}
+		if graphAvailable != len(c.available) {
+			t.Errorf("%+v: expected %d available graph nodes, got %d", c.policy, len(c.available), graphAvailable)
+		}
+	}
+
+	// Claiming follows the policy too.
+	task, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil || task == nil || task.Name != "free" {
+		t.Fatalf("Expected to claim free first, got %+v (%v)", task, err)
+	}
+	task, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil || task == nil || task.Name != "after-blocked" {
+		t.Errorf("Expected to claim after-blocked under the soft policy, got %+v (%v)", task, err)
+	}
+}
+
+func TestAvailabilityPolicyValidate(t *testing.T) {
+	cases := []struct {
+		policy AvailabilityPolicy
+		ok     bool
+	}{
+		{AvailabilityPolicy{}, true},
+		{AvailabilityPolicy{Dependencies: DependencyPolicyStrict}, true},
+		{AvailabilityPolicy{Dependencies: DependencyPolicySoft, Penalty: 3}, true},
+		{AvailabilityPolicy{Dependencies: "lenient"}, false},
+		{AvailabilityPolicy{Dependencies: DependencyPolicySoft, Penalty: -1}, false},
+		{AvailabilityPolicy{Penalty: 1}, false},
+	}
+	for _, c := range cases {
+		if err := c.policy.Validate(); (err == nil) != c.ok {
+			t.Errorf("%+v: expected ok=%v, got %v", c.policy, c.ok, err)
+		}
+	}
+}
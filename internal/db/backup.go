@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrBackupUnsupported is returned when backing up a database that is not
+// SQLite. Use the database's own tools, such as pg_dump, instead.
+var ErrBackupUnsupported = errors.New("backup is only supported for SQLite databases")
+
+// AutoBackup keeps rolling backups taken before destructive operations such as
+// ImportSnapshot and ArchiveCompleted. The zero value disables it.
+type AutoBackup struct {
+	// Dir is where backups are written.
+	Dir string
+	// Keep is how many backups to retain; older ones are deleted.
+	Keep int
+}
+
+// Enabled reports whether backups are taken at all.
+func (a AutoBackup) Enabled() bool {
+	return a.Dir != "" && a.Keep > 0
+}
+
+// SetAutoBackup configures backups before destructive operations. It has no
+// effect on databases that cannot be backed up.
+func (db *DB) SetAutoBackup(a AutoBackup) {
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+	db.autoBackup = a
+}
+
+// Backup writes a consistent copy of the database to path using SQLite's
+// VACUUM INTO, which is safe while other connections are writing. An existing
+// file at path is replaced.
+func (db *DB) Backup(ctx context.Context, path string) error {
+	if _, ok := db.dialect.(sqliteDialect); !ok {
+		return ErrBackupUnsupported
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// VACUUM INTO refuses to overwrite, so write next to the target and
+	// rename it into place.
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return nil
+}
+
+// backupBefore takes a rolling backup, if configured, before the operation op
+// and prunes backups beyond the configured count.
+func (db *DB) backupBefore(ctx context.Context, op string) error {
+	db.backupMu.RLock()
+	a := db.autoBackup
+	db.backupMu.RUnlock()
+	if !a.Enabled() {
+		return nil
+	}
+	if _, ok := db.dialect.(sqliteDialect); !ok {
+		return nil
+	}
+
+	name := fmt.Sprintf("ponder-%s-%s.db", time.Now().UTC().Format("20060102T150405.000000000"), op)
+	if err := db.Backup(ctx, filepath.Join(a.Dir, name)); err != nil {
+		return fmt.Errorf("failed to back up before %s: %w", op, err)
+	}
+	return pruneBackups(a.Dir, a.Keep)
+}
+
+// pruneBackups deletes all but the newest keep automatic backups in dir.
+// Their names start with a sortable timestamp, so name order is age order.
+func pruneBackups(dir string, keep int) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "ponder-*.db"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+	for len(matches) > keep {
+		if err := os.Remove(matches[0]); err != nil {
+			return fmt.Errorf("failed to prune backup: %w", err)
+		}
+		matches = matches[1:]
+	}
+	return nil
+}
+
+// Restore replaces the SQLite database at dbPath with the backup at
+// backupPath, after checking that the backup is an intact Ponder database.
+// The database must not be open while it is restored.
+func Restore(backupPath, dbPath string) error {
+	if IsPostgresDSN(dbPath) {
+		return ErrBackupUnsupported
+	}
+	if err := checkBackup(backupPath); err != nil {
+		return err
+	}
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), "restore-*.db")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	// A leftover write-ahead log belongs to the old database and would be
+	// replayed onto the restored one.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", dbPath+suffix, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), dbPath); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+	return nil
+}
+
+// checkBackup opens path read-only and verifies it passes SQLite's integrity
+// check and has Ponder's tables.
+func checkBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("backup %s is not a valid database: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup %s failed the integrity check: %s", path, result)
+	}
+
+	var tables int
+	err = conn.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'table' AND name IN ('features', 'tasks', 'dependencies')`).Scan(&tables)
+	if err != nil {
+		return fmt.Errorf("failed to inspect backup: %w", err)
+	}
+	if tables != 3 {
+		return fmt.Errorf("backup %s is not a Ponder database", path)
+	}
+	return nil
+}
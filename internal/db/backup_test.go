@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "ponder.db")
+	backupPath := filepath.Join(tmpDir, "backups", "ponder.db")
+	ctx := context.Background()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	feature := &models.Feature{Name: "kept", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, feature); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	if err := db.Backup(ctx, backupPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	// A second backup to the same path replaces the first.
+	if err := db.Backup(ctx, backupPath); err != nil {
+		t.Fatalf("Second backup failed: %v", err)
+	}
+
+	if err := db.DeleteFeature(ctx, feature.ID); err != nil {
+		t.Fatalf("Failed to delete feature: %v", err)
+	}
+	db.Close()
+
+	if err := Restore(backupPath, dbPath); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	got, err := db.GetFeatureByName(ctx, "kept")
+	if err != nil {
+		t.Fatalf("Failed to get feature: %v", err)
+	}
+	if got == nil {
+		t.Errorf("Expected restored database to contain the deleted feature")
+	}
+}
+
+func TestRestoreRejectsInvalidBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "ponder.db")
+
+	notADB := filepath.Join(tmpDir, "garbage.db")
+	if err := os.WriteFile(notADB, []byte("not a database"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := Restore(notADB, dbPath); err == nil {
+		t.Errorf("Expected error restoring a non-database file")
+	}
+
+	if err := Restore(filepath.Join(tmpDir, "missing.db"), dbPath); err == nil {
+		t.Errorf("Expected error restoring a missing file")
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("Expected failed restores to leave the database untouched")
+	}
+}
+
+func TestAutoBackupBeforeImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	backupDir := filepath.Join(tmpDir, "backups")
+	ctx := context.Background()
+
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	snapshotPath := filepath.Join(tmpDir, "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+
+	if err := db.ImportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to import snapshot: %v", err)
+	}
+	if _, err := os.Stat(backupDir); !os.IsNotExist(err) {
+		t.Errorf("Expected no backups while auto backup is off")
+	}
+
+	db.SetAutoBackup(AutoBackup{Dir: backupDir, Keep: 2})
+	for i := 0; i < 3; i++ {
+		if err := db.ImportSnapshot(ctx, snapshotPath); err != nil {
+			t.Fatalf("Failed to import snapshot: %v", err)
+		}
+	}
+
+	backups, err := filepath.Glob(filepath.Join(backupDir, "ponder-*-import.db"))
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("Expected 2 rolling backups to be kept, got %d: %v", len(backups), backups)
+	}
+}
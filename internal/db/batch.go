@@ -2,31 +2,102 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
+// CommitBatch applies the changes staged for a session in one transaction,
+// which also drops the session's saved copy. If it fails, the staged
+// changes are kept so they can be fixed and committed again.
 func (db *DB) CommitBatch(ctx context.Context, sessionID string) error {
-	items := db.Staging.GetAndClear(sessionID)
-	if items == nil {
-		return nil
+	if err := db.commitItems(ctx, sessionID, db.Staging.Copy(sessionID)); err != nil {
+		return err
 	}
+	db.Staging.forget(sessionID)
+	return nil
+}
 
+// commitItems applies items in one transaction, filling in the IDs they are
+// given. If sessionID isn't empty, the session's saved staged changes are
+// deleted in the same transaction.
+func (db *DB) commitItems(ctx context.Context, sessionID string, items *StagedItems) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	failFast := func(err error) error { return err }
+	if err := db.applyBatch(ctx, tx, items, failFast); err != nil {
+		return err
+	}
+	if sessionID != "" {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM staged_changes WHERE session_id = ?`, sessionID); err != nil {
+			return fmt.Errorf("failed to delete staged changes of session %s: %w", sessionID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	db.triggerChange(ctx)
+	return nil
+}
+
+// ValidateBatch applies the changes staged for a session the way CommitBatch
+// would, inside a transaction that is always rolled back, and returns every
+// problem found rather than only the first. Staging is left untouched. A nil
+// result means committing would succeed.
+func (db *DB) ValidateBatch(ctx context.Context, sessionID string) ([]error, error) {
+	items := db.Staging.Copy(sessionID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var problems []error
+	collect := func(err error) error {
+		problems = append(problems, err)
+		return nil
+	}
+	if err := db.applyBatch(ctx, tx, items, collect); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// applyBatch creates staged features, then tasks, then dependencies in tx.
+// Each problem is passed to fail: a non-nil return stops the batch with that
+// error, while nil skips the offending item and carries on with the rest.
+// Duplicates are checked before inserting, since a failed statement aborts a
+// Postgres transaction and would hide the problems that follow.
+func (db *DB) applyBatch(ctx context.Context, tx *sql.Tx, items *StagedItems, fail func(error) error) error {
 	featureIDs := make(map[string]string)
 	taskIDs := make(map[string]string)
 
 	// 1. Features
 	for _, f := range items.Features {
+		existing, err := db.getFeatureByName(ctx, tx, f.Name)
+		if err != nil {
+			return fmt.Errorf("failed to look up staged feature %s: %w", f.Name, err)
+		}
+		if existing != nil {
+			if err := fail(fmt.Errorf("feature %s already exists", f.Name)); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := db.createFeature(ctx, tx, f); err != nil {
-			return fmt.Errorf("failed to create staged feature %s: %w", f.Name, err)
+			if err := fail(fmt.Errorf("failed to create staged feature %s: %w", f.Name, err)); err != nil {
+				return err
+			}
+			continue
 		}
 		featureIDs[f.Name] = f.ID
 	}
@@ -43,14 +114,49 @@ func (db *DB) CommitBatch(ctx context.Context, sessionID string) error {
 					return fmt.Errorf("failed to resolve feature %s for task %s: %w", t.FeatureName, t.Name, err)
 				}
 				if f == nil {
-					return fmt.Errorf("feature %s not found for task %s", t.FeatureName, t.Name)
+					if err := fail(fmt.Errorf("feature %s not found for task %s", t.FeatureName, t.Name)); err != nil {
+						return err
+					}
+					continue
 				}
 				t.FeatureID = f.ID
 			}
 		}
 
+		// Resolve the parent the same way, within the task's feature
+		if t.ParentTaskID == nil && t.ParentTaskName != "" {
+			id, ok := taskIDs[fmt.Sprintf("%s:%s", t.FeatureName, t.ParentTaskName)]
+			if !ok {
+				parent, err := db.getTaskByName(ctx, tx, t.ParentTaskName, t.FeatureID)
+				if err != nil {
+					return fmt.Errorf("failed to resolve parent %s for task %s: %w", t.ParentTaskName, t.Name, err)
+				}
+				if parent == nil {
+					if err := fail(fmt.Errorf("parent task %s not found for task %s", t.ParentTaskName, t.Name)); err != nil {
+						return err
+					}
+					continue
+				}
+				id = parent.ID
+			}
+			t.ParentTaskID = &id
+		}
+
+		existing, err := db.getTaskByName(ctx, tx, t.Name, t.FeatureID)
+		if err != nil {
+			return fmt.Errorf("failed to look up staged task %s: %w", t.Name, err)
+		}
+		if existing != nil {
+			if err := fail(fmt.Errorf("task %s already exists in feature %s", t.Name, t.FeatureName)); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := db.createTask(ctx, tx, t); err != nil {
-			return fmt.Errorf("failed to create staged task %s: %w", t.Name, err)
+			if err := fail(fmt.Errorf("failed to create staged task %s: %w", t.Name, err)); err != nil {
+				return err
+			}
+			continue
 		}
 		taskIDs[fmt.Sprintf("%s:%s", t.FeatureName, t.Name)] = t.ID
 	}
@@ -65,7 +171,10 @@ func (db *DB) CommitBatch(ctx context.Context, sessionID string) error {
 			} else {
 				id, err := db.resolveTaskIDTx(ctx, tx, d.FeatureName, d.TaskName)
 				if err != nil {
-					return fmt.Errorf("failed to resolve task %s for dependency: %w", key, err)
+					if err := fail(fmt.Errorf("failed to resolve task %s for dependency: %w", key, err)); err != nil {
+						return err
+					}
+					continue
 				}
 				d.TaskID = id
 			}
@@ -78,22 +187,37 @@ func (db *DB) CommitBatch(ctx context.Context, sessionID string) error {
 			} else {
 				id, err := db.resolveTaskIDTx(ctx, tx, d.DependsOnFeatureName, d.DependsOnTaskName)
 				if err != nil {
-					return fmt.Errorf("failed to resolve depends_on task %s for dependency: %w", key, err)
+					if err := fail(fmt.Errorf("failed to resolve depends_on task %s for dependency: %w", key, err)); err != nil {
+						return err
+					}
+					continue
 				}
 				d.DependsOnTaskID = id
 			}
 		}
 
+		var exists int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM dependencies WHERE task_id = ? AND depends_on_task_id = ?`,
+			d.TaskID, d.DependsOnTaskID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to look up staged dependency: %w", err)
+		}
+		if exists > 0 {
+			if err := fail(fmt.Errorf("dependency %s:%s -> %s:%s already exists",
+				d.FeatureName, d.TaskName, d.DependsOnFeatureName, d.DependsOnTaskName)); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := db.createDependency(ctx, tx, d.TaskID, d.DependsOnTaskID); err != nil {
-			return fmt.Errorf("failed to create staged dependency: %w", err)
+			if err := fail(fmt.Errorf("failed to create staged dependency: %w", err)); err != nil {
+				return err
+			}
+			continue
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	db.triggerChange(ctx)
 	return nil
 }
 
@@ -123,13 +247,13 @@ func (db *DB) createFeature(ctx context.Context, exec executor, f *models.Featur
 	query := `
 		INSERT INTO features (id, name, description, specification)
 		VALUES (?, ?, ?, ?)
-		RETURNING created_at, updated_at
+		RETURNING created_at, updated_at, version
 	`
-	err := exec.QueryRowContext(ctx, query, f.ID, f.Name, f.Description, f.Specification).Scan(&f.CreatedAt, &f.UpdatedAt)
+	err := exec.QueryRowContext(ctx, query, f.ID, f.Name, f.Description, f.Specification).Scan(&f.CreatedAt, &f.UpdatedAt, &f.Version)
 	if err != nil {
 		return fmt.Errorf("failed to create feature: %w", err)
 	}
-	return nil
+	return recordEvent(ctx, exec, EntityFeature, f.ID, f.Name, models.EventCreated, nil, snippetOfFeature(f))
 }
 
 func (db *DB) createTask(ctx context.Context, exec executor, t *models.Task) error {
@@ -142,25 +266,63 @@ func (db *DB) createTask(ctx context.Context, exec executor, t *models.Task) err
 		testsRequired = 1
 	}
 
+	order, err := models.ParseSubtaskOrder(string(t.SubtaskOrder))
+	if err != nil {
+		return err
+	}
+	t.SubtaskOrder = order
+	if err := checkEstimate(t.EstimateMinutes); err != nil {
+		return err
+	}
+	if t.ParentTaskID != nil {
+		if err := checkParentTask(ctx, exec, t.ID, *t.ParentTaskID); err != nil {
+			return err
+		}
+	}
+
 	query := `
-		INSERT INTO tasks (id, feature_id, name, description, specification, priority, tests_required, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING created_at, updated_at
+		INSERT INTO tasks (id, feature_id, name, description, specification, priority, tests_required, status,
+		                   not_before, due_at, parent_task_id, subtask_order, estimate_minutes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING created_at, updated_at, version
 	`
-	err := exec.QueryRowContext(ctx, query,
+	err = exec.QueryRowContext(ctx, query,
 		t.ID, t.FeatureID, t.Name, t.Description, t.Specification, t.Priority, testsRequired, t.Status,
-	).Scan(&t.CreatedAt, &t.UpdatedAt)
+		db.timestampArg(t.NotBefore), db.timestampArg(t.DueAt), t.ParentTaskID, t.SubtaskOrder, t.EstimateMinutes,
+	).Scan(&t.CreatedAt, &t.UpdatedAt, &t.Version)
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
-	return nil
+	return recordEvent(ctx, exec, EntityTask, t.ID, t.Name, models.EventCreated, nil, snippetOfTask(t))
 }
 
 func (db *DB) createDependency(ctx context.Context, exec executor, taskID, dependsOnTaskID string) error {
+	if err := db.checkDependencyCycle(ctx, exec, taskID, dependsOnTaskID); err != nil {
+		return err
+	}
+
 	query := `INSERT INTO dependencies (task_id, depends_on_task_id) VALUES (?, ?)`
 	_, err := exec.ExecContext(ctx, query, taskID, dependsOnTaskID)
 	if err != nil {
 		return fmt.Errorf("failed to create dependency: %w", err)
 	}
-	return nil
+	return recordDependencyEvent(ctx, exec, taskID, dependsOnTaskID, models.EventDependencyAdded)
+}
+
+// recordDependencyEvent logs a dependency change against the dependent task.
+func recordDependencyEvent(ctx context.Context, exec executor, taskID, dependsOnTaskID string, action models.EventAction) error {
+	var taskName string
+	if err := exec.QueryRowContext(ctx, `SELECT name FROM tasks WHERE id = ?`, taskID).Scan(&taskName); err != nil {
+		return fmt.Errorf("failed to resolve task %s: %w", taskID, err)
+	}
+	label, err := taskLabel(ctx, exec, dependsOnTaskID)
+	if err != nil {
+		return err
+	}
+
+	snippet := dependencySnippet{DependsOn: label}
+	if action == models.EventDependencyRemoved {
+		return recordEvent(ctx, exec, EntityTask, taskID, taskName, action, snippet, nil)
+	}
+	return recordEvent(ctx, exec, EntityTask, taskID, taskName, action, nil, snippet)
 }
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestValidateBatchReportsAllProblems(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	existing := &models.Feature{Name: "existing", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, existing); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	session := "plan"
+	task := func(feature, name string) *models.Task {
+		return &models.Task{FeatureName: feature, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	}
+	db.Staging.AddFeature(session, &models.Feature{Name: "existing", Description: "d", Specification: "s"})
+	db.Staging.AddFeature(session, &models.Feature{Name: "new", Description: "d", Specification: "s"})
+	db.Staging.AddTask(session, task("new", "x"))
+	db.Staging.AddTask(session, task("new", "x"))
+	db.Staging.AddTask(session, task("nowhere", "y"))
+	db.Staging.AddDependency(session, &models.Dependency{FeatureName: "new", TaskName: "x", DependsOnFeatureName: "new", DependsOnTaskName: "missing"})
+
+	problems, err := db.ValidateBatch(ctx, session)
+	if err != nil {
+		t.Fatalf("ValidateBatch failed: %v", err)
+	}
+	want := []string{
+		"feature existing already exists",
+		"task x already exists in feature new",
+		"feature nowhere not found for task y",
+		"task missing not found in feature new",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %v", len(want), problems)
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i].Error(), w) {
+			t.Errorf("Expected problem %d to mention %q, got %q", i, w, problems[i])
+		}
+	}
+
+	if f, _ := db.GetFeatureByName(ctx, "new"); f != nil {
+		t.Error("Expected validation to roll back")
+	}
+	if items := db.Staging.Peek(session); len(items.Tasks) != 3 || items.Tasks[0].FeatureID != "" {
+		t.Errorf("Expected staged changes to be left untouched, got %+v", items.Tasks)
+	}
+
+	db.Staging.Discard(session)
+	db.Staging.AddTask(session, task("existing", "z"))
+	problems, err = db.ValidateBatch(ctx, session)
+	if err != nil || problems != nil {
+		t.Errorf("Expected a valid batch, got %v, %v", problems, err)
+	}
+}
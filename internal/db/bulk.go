@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrInvalidBulkTasks is returned when a bulk task request is malformed.
+var ErrInvalidBulkTasks = errors.New("invalid bulk tasks")
+
+// TaskRef names a task by feature and name. In JSON it is either an object
+// {"feature_name", "name"} or a bare task name, which refers to a task in the
+// same feature as the task that depends on it.
+type TaskRef struct {
+	FeatureName string `json:"feature_name,omitempty"`
+	Name        string `json:"name"`
+}
+
+func (r *TaskRef) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = TaskRef{Name: name}
+		return nil
+	}
+	type plain TaskRef
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// BulkTask is one entry of a bulk task creation.
+type BulkTask struct {
+	// FeatureName defaults to the feature given for the whole batch.
+	FeatureName   string `json:"feature_name,omitempty"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Specification string `json:"specification"`
+	Priority      int    `json:"priority"`
+	TestsRequired *bool  `json:"tests_required,omitempty"`
+	// EstimateMinutes is how long the task is expected to take.
+	EstimateMinutes *int      `json:"estimate_minutes,omitempty"`
+	DependsOn       []TaskRef `json:"depends_on,omitempty"`
+}
+
+// StageTasks validates a batch of tasks and stages them, with their
+// dependencies, under sessionID. Dependencies may point at tasks in the same
+// batch, already staged, or already in the database; they are resolved when
+// the session is committed. Nothing is staged if any entry is invalid.
+func (db *DB) StageTasks(sessionID, defaultFeature string, tasks []BulkTask) ([]*models.Task, error) {
+	staged, deps, err := prepareBulkTasks(defaultFeature, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range staged {
+		db.Staging.AddTask(sessionID, t)
+	}
+	for _, d := range deps {
+		db.Staging.AddDependency(sessionID, d)
+	}
+	return staged, nil
+}
+
+// CreateTasks creates a batch of tasks and their dependencies in a single
+// transaction. Features and tasks outside the batch must already exist.
+func (db *DB) CreateTasks(ctx context.Context, defaultFeature string, tasks []BulkTask) ([]*models.Task, error) {
+	staged, deps, err := prepareBulkTasks(defaultFeature, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	inBatch := make(map[TaskRef]bool, len(staged))
+	for _, t := range staged {
+		inBatch[TaskRef{FeatureName: t.FeatureName, Name: t.Name}] = true
+	}
+	featureIDs := make(map[string]string)
+	for _, t := range staged {
+		featureID, ok := featureIDs[t.FeatureName]
+		if !ok {
+			f, err := db.GetFeatureByName(ctx, t.FeatureName)
+			if err != nil {
+				return nil, err
+			}
+			if f == nil {
+				return nil, fmt.Errorf("%w: feature %s not found", ErrInvalidBulkTasks, t.FeatureName)
+			}
+			featureID = f.ID
+			featureIDs[t.FeatureName] = featureID
+		}
+		existing, err := db.GetTaskByName(ctx, t.Name, featureID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, fmt.Errorf("%w: task %s/%s already exists", ErrInvalidBulkTasks, t.FeatureName, t.Name)
+		}
+	}
+	for _, d := range deps {
+		ref := TaskRef{FeatureName: d.DependsOnFeatureName, Name: d.DependsOnTaskName}
+		if inBatch[ref] {
+			continue
+		}
+		if _, err := db.resolveTaskIDTx(ctx, db.read(), ref.FeatureName, ref.Name); err != nil {
+			return nil, fmt.Errorf("%w: dependency of %s: %v", ErrInvalidBulkTasks, d.TaskName, err)
+		}
+	}
+
+	items := &StagedItems{Features: []*models.Feature{}, Tasks: staged, Dependencies: deps}
+	if err := db.commitItems(ctx, "", items); err != nil {
+		return nil, err
+	}
+	return staged, nil
+}
+
+// prepareBulkTasks checks a batch and turns it into tasks and dependencies
+// ready for staging.
+func prepareBulkTasks(defaultFeature string, tasks []BulkTask) ([]*models.Task, []*models.Dependency, error) {
+	if len(tasks) == 0 {
+		return nil, nil, fmt.Errorf("%w: no tasks given", ErrInvalidBulkTasks)
+	}
+
+	var staged []*models.Task
+	var deps []*models.Dependency
+	seen := make(map[TaskRef]bool, len(tasks))
+
+	for i, bt := range tasks {
+		featureName := bt.FeatureName
+		if featureName == "" {
+			featureName = defaultFeature
+		}
+		switch {
+		case bt.Name == "":
+			return nil, nil, fmt.Errorf("%w: task %d has no name", ErrInvalidBulkTasks, i+1)
+		case len(bt.Name) > 55:
+			return nil, nil, fmt.Errorf("%w: task name %q is longer than 55 characters", ErrInvalidBulkTasks, bt.Name)
+		case featureName == "":
+			return nil, nil, fmt.Errorf("%w: task %s has no feature", ErrInvalidBulkTasks, bt.Name)
+		case bt.Priority < 0 || bt.Priority > 10:
+			return nil, nil, fmt.Errorf("%w: task %s priority must be between 0 and 10", ErrInvalidBulkTasks, bt.Name)
+		case checkEstimate(bt.EstimateMinutes) != nil:
+			return nil, nil, fmt.Errorf("%w: task %s estimate must be a positive number of minutes", ErrInvalidBulkTasks, bt.Name)
+		}
+
+		self := TaskRef{FeatureName: featureName, Name: bt.Name}
+		if seen[self] {
+			return nil, nil, fmt.Errorf("%w: task %s/%s appears more than once", ErrInvalidBulkTasks, featureName, bt.Name)
+		}
+		seen[self] = true
+
+		testsRequired := true
+		if bt.TestsRequired != nil {
+			testsRequired = *bt.TestsRequired
+		}
+		staged = append(staged, &models.Task{
+			FeatureName:     featureName,
+			Name:            bt.Name,
+			Description:     bt.Description,
+			Specification:   bt.Specification,
+			Priority:        bt.Priority,
+			TestsRequired:   testsRequired,
+			Status:          models.TaskStatusPending,
+			EstimateMinutes: bt.EstimateMinutes,
+		})
+
+		for _, ref := range bt.DependsOn {
+			if ref.FeatureName == "" {
+				ref.FeatureName = featureName
+			}
+			if ref.Name == "" {
+				return nil, nil, fmt.Errorf("%w: task %s has a dependency without a name", ErrInvalidBulkTasks, bt.Name)
+			}
+			if ref == self {
+				return nil, nil, fmt.Errorf("%w: task %s depends on itself", ErrInvalidBulkTasks, bt.Name)
+			}
+			deps = append(deps, &models.Dependency{
+				TaskName:             bt.Name,
+				FeatureName:          featureName,
+				DependsOnTaskName:    ref.Name,
+				DependsOnFeatureName: ref.FeatureName,
+			})
+		}
+	}
+	return staged, deps, nil
+}
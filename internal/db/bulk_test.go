@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestCreateTasks(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	for _, name := range []string{"core", "auth"} {
+		if err := db.CreateFeature(ctx, &models.Feature{Name: name, Description: "d", Specification: "s"}); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	core, _ := db.GetFeatureByName(ctx, "core")
+	schema := &models.Task{FeatureID: core.ID, Name: "schema", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, schema); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	var tasks []BulkTask
+	payload := `[
+		{"name": "login", "description": "d", "specification": "s", "priority": 7,
+		 "depends_on": ["session", {"feature_name": "core", "name": "schema"}]},
+		{"name": "session", "description": "d", "specification": "s", "tests_required": false}
+	]`
+	if err := json.Unmarshal([]byte(payload), &tasks); err != nil {
+		t.Fatalf("Failed to unmarshal tasks: %v", err)
+	}
+
+	created, err := db.CreateTasks(ctx, "auth", tasks)
+	if err != nil {
+		t.Fatalf("CreateTasks failed: %v", err)
+	}
+	if len(created) != 2 || created[0].ID == "" || created[1].TestsRequired {
+		t.Fatalf("Unexpected created tasks: %+v", created)
+	}
+
+	deps, err := db.GetDependencies(ctx, created[0].ID)
+	if err != nil {
+		t.Fatalf("GetDependencies failed: %v", err)
+	}
+	names := map[string]bool{}
+	for _, d := range deps {
+		names[d.FeatureName+"/"+d.Name] = true
+	}
+	if len(deps) != 2 || !names["auth/session"] || !names["core/schema"] {
+		t.Errorf("Expected login to depend on auth/session and core/schema, got %v", names)
+	}
+
+	invalid := []struct {
+		name  string
+		tasks []BulkTask
+	}{
+		{"empty", nil},
+		{"duplicate", []BulkTask{{Name: "a"}, {Name: "a"}}},
+		{"existing", []BulkTask{{Name: "login"}}},
+		{"self", []BulkTask{{Name: "a", DependsOn: []TaskRef{{Name: "a"}}}}},
+		{"priority", []BulkTask{{Name: "a", Priority: 11}}},
+		{"missing dependency", []BulkTask{{Name: "a", DependsOn: []TaskRef{{Name: "nope"}}}}},
+		{"missing feature", []BulkTask{{FeatureName: "nope", Name: "a"}}},
+	}
+	for _, tc := range invalid {
+		if _, err := db.CreateTasks(ctx, "auth", tc.tasks); !errors.Is(err, ErrInvalidBulkTasks) {
+			t.Errorf("%s: expected ErrInvalidBulkTasks, got %v", tc.name, err)
+		}
+	}
+
+	cycle := []BulkTask{
+		{Name: "x", DependsOn: []TaskRef{{Name: "y"}}},
+		{Name: "y", DependsOn: []TaskRef{{Name: "x"}}},
+	}
+	if _, err := db.CreateTasks(ctx, "auth", cycle); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Expected ErrDependencyCycle, got %v", err)
+	}
+	if x, _ := db.GetTaskByName(ctx, "x", created[0].FeatureID); x != nil {
+		t.Error("Expected cyclic batch to be rolled back")
+	}
+}
+
+func TestStageTasks(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	// The feature is staged in the same session, so it need not exist yet.
+	db.Staging.AddFeature("s1", &models.Feature{Name: "new", Description: "d", Specification: "s"})
+	if _, err := db.StageTasks("s1", "new", []BulkTask{{Name: "a"}, {Name: "a"}}); err == nil {
+		t.Fatal("Expected error for duplicate names")
+	}
+	if items := db.Staging.Peek("s1"); len(items.Tasks) != 0 {
+		t.Fatalf("Expected nothing staged after invalid batch, got %d tasks", len(items.Tasks))
+	}
+
+	if _, err := db.StageTasks("s1", "new", []BulkTask{{Name: "a"}, {Name: "b", DependsOn: []TaskRef{{Name: "a"}}}}); err != nil {
+		t.Fatalf("StageTasks failed: %v", err)
+	}
+	items := db.Staging.Peek("s1")
+	if len(items.Tasks) != 2 || len(items.Dependencies) != 1 {
+		t.Fatalf("Expected 2 tasks and 1 dependency staged, got %+v", items)
+	}
+	if err := db.CommitBatch(ctx, "s1"); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	tasks, err := db.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected 2 tasks after commit, got %d", len(tasks))
+	}
+}
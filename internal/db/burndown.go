@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// DefaultBurndownDays is the period GetBurndownReport covers when none is
+// given.
+const DefaultBurndownDays = 14
+
+// burndownTask is what GetBurndownReport needs of a task.
+type burndownTask struct {
+	feature     string
+	status      models.TaskStatus
+	estimate    *int
+	createdAt   time.Time
+	startedAt   *time.Time
+	completedAt *time.Time
+}
+
+// GetBurndownReport compares the estimates of completed tasks with how long
+// they took, per feature and in total, and follows the estimated work left
+// at the end of each of the last days days, today included. Days are UTC
+// calendar days; days <= 0 means DefaultBurndownDays. A non-empty feature
+// limits the report to that feature.
+func (db *DB) GetBurndownReport(ctx context.Context, days int, feature string) (*models.BurndownReport, error) {
+	if days <= 0 {
+		days = DefaultBurndownDays
+	}
+	query := `
+		SELECT f.name, t.status, t.estimate_minutes, t.created_at, t.started_at, t.completed_at
+		FROM tasks t
+		JOIN features f ON f.id = t.feature_id
+		WHERE t.status != 'cancelled'`
+	var args []any
+	if feature != "" {
+		query += " AND f.name = ?"
+		args = append(args, feature)
+	}
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task estimates: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []burndownTask
+	for rows.Next() {
+		var t burndownTask
+		if err := rows.Scan(&t.feature, &t.status, &t.estimate, &t.createdAt, &t.startedAt, &t.completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task estimate: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	report := &models.BurndownReport{Days: days, Total: &models.FeatureBurndown{}, Features: []*models.FeatureBurndown{}}
+	byName := make(map[string]*models.FeatureBurndown)
+	for _, t := range tasks {
+		f := byName[t.feature]
+		if f == nil {
+			f = &models.FeatureBurndown{FeatureName: t.feature}
+			byName[t.feature] = f
+			report.Features = append(report.Features, f)
+		}
+		addToBurndown(f, t)
+		addToBurndown(report.Total, t)
+	}
+	sort.Slice(report.Features, func(i, j int) bool { return report.Features[i].FeatureName < report.Features[j].FeatureName })
+	for _, f := range append(report.Features, report.Total) {
+		if f.CompletedEstimateMinutes > 0 {
+			ratio := f.ActualMinutes / float64(f.CompletedEstimateMinutes)
+			f.Ratio = &ratio
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	report.Remaining = make([]*models.DailyRemaining, 0, days)
+	for d := 0; d < days; d++ {
+		day := since.AddDate(0, 0, d)
+		end := day.AddDate(0, 0, 1)
+		remaining := 0
+		for _, t := range tasks {
+			if t.estimate == nil || !t.createdAt.Before(end) {
+				continue
+			}
+			if t.completedAt != nil && t.completedAt.Before(end) {
+				continue
+			}
+			remaining += *t.estimate
+		}
+		report.Remaining = append(report.Remaining, &models.DailyRemaining{Date: day.Format("2006-01-02"), RemainingMinutes: remaining})
+	}
+	return report, nil
+}
+
+// addToBurndown counts t in f.
+func addToBurndown(f *models.FeatureBurndown, t burndownTask) {
+	f.Tasks++
+	if t.estimate != nil {
+		f.EstimateMinutes += *t.estimate
+	}
+	if t.status != models.TaskStatusCompleted {
+		if t.estimate != nil {
+			f.RemainingMinutes += *t.estimate
+		} else {
+			f.Unestimated++
+		}
+		return
+	}
+	f.Completed++
+	if t.estimate != nil && t.startedAt != nil && t.completedAt != nil {
+		f.CompletedEstimateMinutes += *t.estimate
+		f.ActualMinutes += t.completedAt.Sub(*t.startedAt).Minutes()
+	}
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskEstimate(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	estimate := 45
+	task := &models.Task{FeatureID: f.ID, Name: "login", Description: "d", Specification: "s", Status: models.TaskStatusPending, EstimateMinutes: &estimate}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	got, err := db.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.EstimateMinutes == nil || *got.EstimateMinutes != 45 {
+		t.Errorf("Expected an estimate of 45 minutes, got %v", got.EstimateMinutes)
+	}
+
+	zero := 0
+	got.EstimateMinutes = &zero
+	if err := db.UpdateTask(ctx, got); !errors.Is(err, ErrInvalidEstimate) {
+		t.Errorf("Expected ErrInvalidEstimate, got %v", err)
+	}
+	bad := &models.Task{FeatureID: f.ID, Name: "bad", Description: "d", Specification: "s", Status: models.TaskStatusPending, EstimateMinutes: &zero}
+	if err := db.CreateTask(ctx, bad); !errors.Is(err, ErrInvalidEstimate) {
+		t.Errorf("Expected ErrInvalidEstimate creating a task, got %v", err)
+	}
+
+	got.EstimateMinutes = nil
+	if err := db.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("Failed to clear the estimate: %v", err)
+	}
+	got, _ = db.GetTask(ctx, task.ID)
+	if got.EstimateMinutes != nil {
+		t.Errorf("Expected the estimate to be cleared, got %d", *got.EstimateMinutes)
+	}
+}
+
+func TestGetBurndownReport(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	api := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	ui := &models.Feature{Name: "ui", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{api, ui} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	create := func(f *models.Feature, name string, estimate int) *models.Task {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if estimate > 0 {
+			task.EstimateMinutes = &estimate
+		}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		return task
+	}
+
+	login := create(api, "login", 60)
+	create(api, "logout", 30)
+	create(api, "docs", 0)
+	create(ui, "page", 120)
+
+	summary := "summary"
+	if err := db.UpdateTaskStatus(ctx, login.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("Failed to start login: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, login.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete login: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "UPDATE tasks SET started_at = datetime('now', '-90 minutes') WHERE id = ?", login.ID)
+	if err != nil {
+		t.Fatalf("Failed to backdate login: %v", err)
+	}
+
+	report, err := db.GetBurndownReport(ctx, 0, "")
+	if err != nil {
+		t.Fatalf("GetBurndownReport failed: %v", err)
+	}
+	if report.Days != DefaultBurndownDays || len(report.Remaining) != DefaultBurndownDays {
+		t.Errorf("Expected %d days, got %d days and %d entries", DefaultBurndownDays, report.Days, len(report.Remaining))
+	}
+	if len(report.Features) != 2 {
+		t.Fatalf("Expected 2 features, got %d", len(report.Features))
+	}
+
+	f := report.Features[0]
+	if f.FeatureName != "api" || f.Tasks != 3 || f.Completed != 1 || f.EstimateMinutes != 90 || f.RemainingMinutes != 30 || f.Unestimated != 1 {
+		t.Errorf("Unexpected api burndown: %+v", f)
+	}
+	if f.CompletedEstimateMinutes != 60 || math.Abs(f.ActualMinutes-90) > 0.1 || f.Ratio == nil || math.Abs(*f.Ratio-1.5) > 0.01 {
+		t.Errorf("Expected login to take 1.5x its estimate, got %+v", f)
+	}
+	if report.Total.Tasks != 4 || report.Total.RemainingMinutes != 150 {
+		t.Errorf("Unexpected total: %+v", report.Total)
+	}
+	if today := report.Remaining[len(report.Remaining)-1]; today.RemainingMinutes != 150 {
+		t.Errorf("Expected 150 minutes left today, got %d", today.RemainingMinutes)
+	}
+	if report.Remaining[0].RemainingMinutes != 0 {
+		t.Errorf("Expected nothing left before the tasks were created, got %d", report.Remaining[0].RemainingMinutes)
+	}
+
+	report, err = db.GetBurndownReport(ctx, 7, "ui")
+	if err != nil {
+		t.Fatalf("GetBurndownReport failed: %v", err)
+	}
+	if len(report.Features) != 1 || report.Total.RemainingMinutes != 120 || report.Total.Ratio != nil {
+		t.Errorf("Expected only the unstarted ui task, got %+v", report.Total)
+	}
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// readCacheTTL bounds how long a cached read is served. Tasks become
+// available as their not_before passes, without a write to invalidate the
+// cache, so no result is kept for longer than this.
+const readCacheTTL = 2 * time.Second
+
+// Keys of the cached reads.
+const (
+	cacheFeatures       = "features"
+	cacheAvailableCount = "available_count"
+	cacheGraph          = "graph"
+)
+
+// readCache holds the results of the reads the orchestrator and web UI poll
+// every 100ms to 5s, each of which walks the whole dependency graph. It is
+// emptied by triggerChange after this process writes; writes by other
+// processes, such as an agent's MCP server, are caught by the latest event
+// having moved on since the result was read.
+type readCache struct {
+	mu sync.Mutex
+	// generation goes up with every invalidation, so a read that raced a
+	// write doesn't store what it read.
+	generation uint64
+	entries    map[string]cachedRead
+}
+
+// cachedRead is one cached result.
+type cachedRead struct {
+	value   any
+	eventID int64
+	readAt  time.Time
+}
+
+// invalidate drops every cached result.
+func (c *readCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = nil
+}
+
+// readCached returns the cached result of the read named key if it is still
+// current, and otherwise calls load and caches what it returns.
+func readCached[T any](ctx context.Context, db *DB, key string, load func(context.Context) (T, error)) (T, error) {
+	eventID, err := db.LatestEventID(ctx)
+	if err != nil {
+		return load(ctx)
+	}
+
+	c := &db.cache
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && entry.eventID == eventID && time.Since(entry.readAt) < readCacheTTL {
+		return entry.value.(T), nil
+	}
+
+	readAt := time.Now()
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		if c.entries == nil {
+			c.entries = make(map[string]cachedRead)
+		}
+		c.entries[key] = cachedRead{value: value, eventID: eventID, readAt: readAt}
+	}
+	return value, nil
+}
+
+// copyFeatures copies listed features, so callers can change what they get
+// without changing the cached list.
+func copyFeatures(features []*models.Feature) []*models.Feature {
+	if features == nil {
+		return nil
+	}
+	out := make([]*models.Feature, len(features))
+	for i, f := range features {
+		c := *f
+		if f.Progress != nil {
+			progress := *f.Progress
+			c.Progress = &progress
+		}
+		c.DependsOn = slices.Clone(f.DependsOn)
+		out[i] = &c
+	}
+	return out
+}
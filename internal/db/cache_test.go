@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestReadCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ponder.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	feature := &models.Feature{Name: "auth", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, feature); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	login := &models.Task{FeatureID: feature.ID, Name: "login", Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, login); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	count := func(want int) {
+		t.Helper()
+		got, err := db.CountAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("CountAvailableTasks failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %d available tasks, got %d", want, got)
+		}
+	}
+	count(1)
+
+	// A write that bypasses the DB's methods isn't seen until the cache is
+	// invalidated, which shows the count is served from it.
+	if _, err := db.ExecContext(ctx, "UPDATE tasks SET status = 'blocked' WHERE id = ?", login.ID); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	count(1)
+	if err := db.UpdateTaskStatus(ctx, login.ID, models.TaskStatusPending, nil); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	count(1)
+
+	// Changing what counts as available invalidates the cache.
+	db.SetClaimFilter(ClaimFilter{Features: []string{"billing"}})
+	count(0)
+	db.SetClaimFilter(ClaimFilter{})
+	count(1)
+
+	// Writes by another process are caught by their events.
+	other, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database again: %v", err)
+	}
+	defer other.Close()
+	if err := other.CreateTask(ctx, &models.Task{FeatureID: feature.ID, Name: "logout", Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}); err != nil {
+		t.Fatalf("Failed to create task from the other handle: %v", err)
+	}
+	count(2)
+	graph, err := db.GetGraphJSON(ctx)
+	if err != nil {
+		t.Fatalf("GetGraphJSON failed: %v", err)
+	}
+	if !strings.Contains(graph, "logout") {
+		t.Errorf("expected the graph to include the other process's task, got %s", graph)
+	}
+
+	// Callers get their own copy of the cached features.
+	features, err := db.ListFeatures(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatures failed: %v", err)
+	}
+	features[0].Name = "changed"
+	features[0].Progress.Total = 99
+	features, err = db.ListFeatures(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatures failed: %v", err)
+	}
+	if features[0].Name != "auth" || features[0].Progress.Total != 2 {
+		t.Errorf("expected the cached features to be unchanged, got %+v", features[0])
+	}
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// GetChangelog returns the tasks completed at or after since, archived ones
+// included, grouped by feature with their completion summaries. Features are
+// ordered by name with misc last, and tasks by completion time. A zero since
+// covers all completed work; a non-empty feature limits it to that feature.
+func (db *DB) GetChangelog(ctx context.Context, since time.Time, feature string) (*models.Changelog, error) {
+	var args []any
+	// where selects the completed tasks of a table whose columns are
+	// prefixed with prefix.
+	where := func(prefix, featureName string) string {
+		cond := prefix + "status = 'completed' AND " + prefix + "completed_at IS NOT NULL"
+		if !since.IsZero() {
+			cond += " AND " + db.dialect.atOrAfter(prefix+"completed_at")
+			args = append(args, db.dialect.timestamp(since))
+		}
+		if feature != "" {
+			cond += " AND " + featureName + " = ?"
+			args = append(args, feature)
+		}
+		return cond
+	}
+	query := `
+		SELECT t.id, f.name, t.name, COALESCE(t.completion_summary, ''), t.completed_at
+		FROM tasks t
+		JOIN features f ON f.id = t.feature_id
+		WHERE ` + where("t.", "f.name") + `
+		UNION ALL
+		SELECT id, feature_name, name, COALESCE(completion_summary, ''), completed_at
+		FROM archived_tasks
+		WHERE ` + where("", "feature_name")
+
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list completed tasks: %w", err)
+	}
+	defer rows.Close()
+
+	changelog := &models.Changelog{Since: since, Features: []*models.ChangelogFeature{}}
+	byName := make(map[string]*models.ChangelogFeature)
+	for rows.Next() {
+		e := &models.ChangelogEntry{}
+		var featureName string
+		if err := rows.Scan(&e.TaskID, &featureName, &e.TaskName, &e.Summary, &e.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan completed task: %w", err)
+		}
+		f := byName[featureName]
+		if f == nil {
+			f = &models.ChangelogFeature{FeatureName: featureName}
+			byName[featureName] = f
+			changelog.Features = append(changelog.Features, f)
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	sort.Slice(changelog.Features, func(i, j int) bool {
+		a, b := changelog.Features[i].FeatureName, changelog.Features[j].FeatureName
+		if (a == "misc") != (b == "misc") {
+			return b == "misc"
+		}
+		return a < b
+	})
+	for _, f := range changelog.Features {
+		sort.SliceStable(f.Entries, func(i, j int) bool {
+			if !f.Entries[i].CompletedAt.Equal(f.Entries[j].CompletedAt) {
+				return f.Entries[i].CompletedAt.Before(f.Entries[j].CompletedAt)
+			}
+			return f.Entries[i].TaskName < f.Entries[j].TaskName
+		})
+	}
+	return changelog, nil
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestGetChangelog(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	ui := &models.Feature{Name: "ui", Description: "d", Specification: "s"}
+	api := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{ui, api} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	misc, err := db.GetFeatureByName(ctx, "misc")
+	if err != nil || misc == nil {
+		t.Fatalf("Failed to get misc feature: %v", err)
+	}
+	complete := func(f *models.Feature, name, completed string) {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		if completed == "" {
+			return
+		}
+		summary := "Did " + name
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			t.Fatalf("Failed to start %s: %v", name, err)
+		}
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted, &summary); err != nil {
+			t.Fatalf("Failed to complete %s: %v", name, err)
+		}
+		if _, err := db.ExecContext(ctx, "UPDATE tasks SET completed_at = datetime('now', ?) WHERE id = ?", completed, task.ID); err != nil {
+			t.Fatalf("Failed to backdate %s: %v", name, err)
+		}
+	}
+	complete(misc, "typo", "-1 hours")
+	complete(ui, "page", "-2 hours")
+	complete(ui, "button", "-3 hours")
+	complete(api, "old", "-40 days")
+	complete(api, "login", "-1 hours")
+	complete(api, "todo", "")
+
+	// Archived work still belongs in the changelog.
+	if _, err := db.ArchiveCompleted(ctx, time.Now().Add(-30*24*time.Hour)); err != nil {
+		t.Fatalf("ArchiveCompleted failed: %v", err)
+	}
+
+	names := func(c *models.Changelog) []string {
+		var out []string
+		for _, f := range c.Features {
+			for _, e := range f.Entries {
+				out = append(out, f.FeatureName+"/"+e.TaskName)
+			}
+		}
+		return out
+	}
+
+	all, err := db.GetChangelog(ctx, time.Time{}, "")
+	if err != nil {
+		t.Fatalf("GetChangelog failed: %v", err)
+	}
+	want := []string{"api/old", "api/login", "ui/button", "ui/page", "misc/typo"}
+	if got := names(all); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if e := all.Features[0].Entries[1]; e.Summary != "Did login" || e.CompletedAt.IsZero() {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	recent, err := db.GetChangelog(ctx, time.Now().Add(-24*time.Hour), "api")
+	if err != nil {
+		t.Fatalf("GetChangelog failed: %v", err)
+	}
+	if got := names(recent); !reflect.DeepEqual(got, []string{"api/login"}) {
+		t.Errorf("expected only api/login since yesterday, got %v", got)
+	}
+}
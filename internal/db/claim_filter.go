@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ClaimFilter narrows the tasks ClaimNextTask hands out, so that a worker
+// can specialise, e.g. only taking tasks labelled "frontend". The zero
+// value lets every task through.
+type ClaimFilter struct {
+	// Features, when set, are the only features tasks are claimed from.
+	Features []string
+	// Labels, when set, only let through tasks with at least one of them.
+	Labels []string
+	// MinPriority is the lowest stored priority claimed; aging doesn't count.
+	MinPriority int
+	// Exclude are IDs of tasks not to claim, such as ones backing off after
+	// a failed run. Set it with SetClaimExclusions.
+	Exclude []string
+}
+
+// Enabled reports whether the filter holds any tasks back.
+func (f ClaimFilter) Enabled() bool {
+	return len(f.Features) > 0 || len(f.Labels) > 0 || f.MinPriority > 0 || len(f.Exclude) > 0
+}
+
+// Validate checks that the filter settings are usable.
+func (f ClaimFilter) Validate() error {
+	if f.MinPriority < 0 || f.MinPriority > 10 {
+		return fmt.Errorf("min_priority must be between 0 and 10")
+	}
+	for _, name := range f.Features {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("features must not be empty")
+		}
+	}
+	return ValidateLabels(f.Labels)
+}
+
+// where returns the conditions, each starting with AND, that the task
+// aliased t has to meet, and their arguments.
+func (f ClaimFilter) where(t string) (string, []any) {
+	var where strings.Builder
+	var args []any
+	if len(f.Features) > 0 {
+		where.WriteString(" AND " + t + ".feature_id IN (SELECT id FROM features WHERE name IN (" + placeholders(len(f.Features)) + "))")
+		for _, name := range f.Features {
+			args = append(args, name)
+		}
+	}
+	if len(f.Labels) > 0 {
+		where.WriteString(" AND EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = " + t + ".id AND l.label IN (" + placeholders(len(f.Labels)) + "))")
+		for _, label := range f.Labels {
+			args = append(args, strings.TrimSpace(label))
+		}
+	}
+	if f.MinPriority > 0 {
+		where.WriteString(" AND " + t + ".priority >= ?")
+		args = append(args, f.MinPriority)
+	}
+	if len(f.Exclude) > 0 {
+		where.WriteString(" AND " + t + ".id NOT IN (" + placeholders(len(f.Exclude)) + ")")
+		for _, id := range f.Exclude {
+			args = append(args, id)
+		}
+	}
+	return where.String(), args
+}
+
+// placeholders returns n comma-separated query placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// SetClaimFilter restricts the tasks ClaimNextTask, PlanClaims and
+// CountAvailableTasks consider. The zero value removes the restriction.
+func (db *DB) SetClaimFilter(f ClaimFilter) {
+	db.agingMu.Lock()
+	defer db.agingMu.Unlock()
+	db.claimFilter = f
+	db.cache.invalidate()
+}
+
+// SetClaimExclusions replaces the IDs of the tasks the claim filter holds
+// back, leaving the rest of it as it is.
+func (db *DB) SetClaimExclusions(ids []string) {
+	db.agingMu.Lock()
+	defer db.agingMu.Unlock()
+	if slices.Equal(db.claimFilter.Exclude, ids) {
+		return
+	}
+	db.claimFilter.Exclude = slices.Clone(ids)
+	db.cache.invalidate()
+}
+
+// ClaimFilter returns the current claim filter.
+func (db *DB) ClaimFilter() ClaimFilter {
+	db.agingMu.RLock()
+	defer db.agingMu.RUnlock()
+	return db.claimFilter
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestClaimFilter(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	ui := &models.Feature{Name: "ui", Description: "d", Specification: "s"}
+	api := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{ui, api} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	button := &models.Task{FeatureID: ui.ID, Name: "button", Description: "d", Specification: "s", Priority: 3, Status: models.TaskStatusPending}
+	styles := &models.Task{FeatureID: api.ID, Name: "error-styles", Description: "d", Specification: "s", Priority: 8, Status: models.TaskStatusPending}
+	schema := &models.Task{FeatureID: api.ID, Name: "schema", Description: "d", Specification: "s", Priority: 9, Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{button, styles, schema} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if err := db.AddTaskLabels(ctx, styles.ID, "frontend"); err != nil {
+		t.Fatalf("AddTaskLabels failed: %v", err)
+	}
+
+	plan := func(f ClaimFilter) []string {
+		t.Helper()
+		db.SetClaimFilter(f)
+		tasks, err := db.PlanClaims(ctx)
+		if err != nil {
+			t.Fatalf("PlanClaims failed: %v", err)
+		}
+		count, err := db.CountAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("CountAvailableTasks failed: %v", err)
+		}
+		if count != len(tasks) {
+			t.Errorf("%+v: counted %d available tasks, planned %d", f, count, len(tasks))
+		}
+		var names []string
+		for _, task := range tasks {
+			names = append(names, task.Name)
+		}
+		return names
+	}
+
+	cases := []struct {
+		filter ClaimFilter
+		want   []string
+	}{
+		{ClaimFilter{}, []string{"schema", "error-styles", "button"}},
+		{ClaimFilter{Features: []string{"ui"}}, []string{"button"}},
+		{ClaimFilter{Labels: []string{"frontend", "design"}}, []string{"error-styles"}},
+		{ClaimFilter{MinPriority: 9}, []string{"schema"}},
+		{ClaimFilter{Features: []string{"api"}, MinPriority: 5}, []string{"schema", "error-styles"}},
+		{ClaimFilter{Features: []string{"ui"}, Labels: []string{"frontend"}}, nil},
+		{ClaimFilter{Exclude: []string{schema.ID, button.ID}}, []string{"error-styles"}},
+	}
+	for _, c := range cases {
+		if got := plan(c.filter); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%+v: expected %v, got %v", c.filter, c.want, got)
+		}
+	}
+
+	db.SetClaimFilter(ClaimFilter{Labels: []string{"frontend"}})
+	claimed, err := db.ClaimNextTask(ctx, models.Claimer{}, time.Minute)
+	if err != nil || claimed == nil || claimed.Name != "error-styles" {
+		t.Fatalf("Expected to claim error-styles, got %v, %v", claimed, err)
+	}
+	claimed, err = db.ClaimNextTask(ctx, models.Claimer{}, time.Minute)
+	if err != nil || claimed != nil {
+		t.Errorf("Expected nothing else to claim, got %v, %v", claimed, err)
+	}
+
+	db.SetClaimFilter(ClaimFilter{Features: []string{"api"}})
+	db.SetClaimExclusions([]string{schema.ID})
+	if f := db.ClaimFilter(); len(f.Features) != 1 || len(f.Exclude) != 1 {
+		t.Errorf("Expected the exclusions to be added to the filter, got %+v", f)
+	}
+	if got := plan(db.ClaimFilter()); len(got) != 0 {
+		t.Errorf("Expected the claimed and the excluded task to be held back, got %v", got)
+	}
+
+	if err := (ClaimFilter{MinPriority: 11}).Validate(); err == nil {
+		t.Error("Expected min_priority 11 to be rejected")
+	}
+	if err := (ClaimFilter{Labels: []string{" "}}).Validate(); err == nil {
+		t.Error("Expected a blank label to be rejected")
+	}
+}
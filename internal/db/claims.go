@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// leaseSeconds rounds a lease up to whole seconds, with a minimum of one.
+func leaseSeconds(lease time.Duration) int64 {
+	seconds := int64((lease + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// RenewClaim extends the lease the claimer holds on a task to lease from now.
+// It returns models.ErrClaimLost when the claimer no longer holds the task.
+func (db *DB) RenewClaim(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE claims SET lease_expires_at = `+db.dialect.secondsFromNow()+`
+		WHERE task_id = ? AND hostname = ? AND pid = ? AND worker_id = ?`,
+		leaseSeconds(lease), taskID, claimer.Hostname, claimer.PID, claimer.WorkerID)
+	if err != nil {
+		return fmt.Errorf("failed to renew claim: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to renew claim: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w on task %s", models.ErrClaimLost, taskID)
+	}
+	return nil
+}
+
+// ListClaims returns the current claims, soonest to expire first.
+func (db *DB) ListClaims(ctx context.Context) ([]*models.Claim, error) {
+	rows, err := db.read().QueryContext(ctx, `
+		SELECT c.task_id, c.hostname, c.pid, c.worker_id, c.claimed_at, c.lease_expires_at, t.name, f.name
+		FROM claims c
+		JOIN tasks t ON c.task_id = t.id
+		JOIN features f ON t.feature_id = f.id
+		ORDER BY c.lease_expires_at ASC, t.name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claims: %w", err)
+	}
+	defer rows.Close()
+
+	var claims []*models.Claim
+	for rows.Next() {
+		c := &models.Claim{}
+		if err := rows.Scan(&c.TaskID, &c.Hostname, &c.PID, &c.WorkerID, &c.ClaimedAt, &c.LeaseExpiresAt, &c.TaskName, &c.FeatureName); err != nil {
+			return nil, fmt.Errorf("failed to scan claim: %w", err)
+		}
+		claims = append(claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return claims, nil
+}
+
+// reclaimExpired puts in_progress tasks whose lease has expired back to
+// pending and drops every expired claim.
+func (db *DB) reclaimExpired(ctx context.Context, tx *sql.Tx) error {
+	expired := db.dialect.secondsSince("c.lease_expires_at") + " > 0"
+	rows, err := tx.QueryContext(ctx, `
+		SELECT t.id, t.name
+		FROM claims c
+		JOIN tasks t ON c.task_id = t.id
+		WHERE t.status = 'in_progress' AND `+expired)
+	if err != nil {
+		return fmt.Errorf("failed to find expired claims: %w", err)
+	}
+	var reclaimed [][2]string
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		reclaimed = append(reclaimed, [2]string{id, name})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range reclaimed {
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET status = 'pending' WHERE id = ?", r[0]); err != nil {
+			return fmt.Errorf("failed to reclaim task %s: %w", r[1], err)
+		}
+		err := recordEvent(ctx, tx, EntityTask, r[0], r[1], models.EventStatusChanged,
+			statusSnippet{Status: models.TaskStatusInProgress},
+			statusSnippet{Status: models.TaskStatusPending},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM claims WHERE "+db.dialect.secondsSince("lease_expires_at")+" > 0")
+	if err != nil {
+		return fmt.Errorf("failed to drop expired claims: %w", err)
+	}
+	return nil
+}
+
+// releaseClaim drops the claim on a task that has left in_progress.
+func releaseClaim(ctx context.Context, exec executor, taskID string) error {
+	if _, err := exec.ExecContext(ctx, "DELETE FROM claims WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to release claim: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+var testClaimer = models.Claimer{Hostname: "test-host", PID: 42, WorkerID: 1}
+
+func TestClaims(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	for _, name := range []string{"a", "b"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+	}
+	expire := func(taskID string) {
+		_, err := db.ExecContext(ctx, "UPDATE claims SET lease_expires_at = datetime('now', '-1 minute') WHERE task_id = ?", taskID)
+		if err != nil {
+			t.Fatalf("Failed to expire claim: %v", err)
+		}
+	}
+
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil || claimed == nil {
+		t.Fatalf("Failed to claim task: %v", err)
+	}
+	claims, err := db.ListClaims(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list claims: %v", err)
+	}
+	if len(claims) != 1 || claims[0].TaskID != claimed.ID || claims[0].Claimer != testClaimer {
+		t.Fatalf("Expected one claim on %s by %s, got %+v", claimed.Name, testClaimer, claims)
+	}
+	if claims[0].TaskName != claimed.Name || claims[0].FeatureName != "f" {
+		t.Errorf("Expected claim to name its task, got %+v", claims[0])
+	}
+
+	if err := db.RenewClaim(ctx, claimed.ID, testClaimer, time.Minute); err != nil {
+		t.Errorf("Failed to renew claim: %v", err)
+	}
+	other := models.Claimer{Hostname: "other-host", PID: 7, WorkerID: 1}
+	if err := db.RenewClaim(ctx, claimed.ID, other, time.Minute); !errors.Is(err, models.ErrClaimLost) {
+		t.Errorf("Expected ErrClaimLost renewing someone else's claim, got %v", err)
+	}
+
+	// A live claim survives a restart; an expired one is reset.
+	if err := db.ResetInProgressTasks(ctx); err != nil {
+		t.Fatalf("Failed to reset tasks: %v", err)
+	}
+	if task, _ := db.GetTask(ctx, claimed.ID); task.Status != models.TaskStatusInProgress {
+		t.Errorf("Expected claimed task to stay in_progress, got %s", task.Status)
+	}
+
+	// An expired lease is reclaimed by the next claim.
+	expire(claimed.ID)
+	second, err := db.ClaimNextTask(ctx, other, time.Minute)
+	if err != nil || second == nil {
+		t.Fatalf("Failed to claim task: %v", err)
+	}
+	if task, _ := db.GetTask(ctx, claimed.ID); second.ID != claimed.ID && task.Status != models.TaskStatusPending {
+		t.Errorf("Expected expired task to be pending again, got %s", task.Status)
+	}
+	if err := db.RenewClaim(ctx, claimed.ID, testClaimer, time.Minute); !errors.Is(err, models.ErrClaimLost) {
+		t.Errorf("Expected ErrClaimLost after the lease expired, got %v", err)
+	}
+
+	// Leaving in_progress releases the claim.
+	summary := "done"
+	if err := db.UpdateTaskStatus(ctx, second.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	claims, err = db.ListClaims(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list claims: %v", err)
+	}
+	for _, c := range claims {
+		if c.TaskID == second.ID {
+			t.Errorf("Expected claim on completed task to be released, got %+v", c)
+		}
+	}
+}
+
+func TestClaimTask(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	tasks := map[string]*models.Task{}
+	for i, name := range []string{"urgent", "minor", "waiting"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Priority: 9 - i, Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		tasks[name] = task
+	}
+	if err := db.CreateDependency(ctx, tasks["waiting"].ID, tasks["urgent"].ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+	// The claim filter doesn't apply to tasks picked by ID.
+	db.SetClaimFilter(ClaimFilter{Features: []string{"other"}})
+
+	claimed, err := db.ClaimTask(ctx, tasks["minor"].ID, testClaimer, time.Minute)
+	if err != nil || claimed == nil || claimed.ID != tasks["minor"].ID {
+		t.Fatalf("Expected to claim minor ahead of urgent, got %+v, %v", claimed, err)
+	}
+	if claimed.Status != models.TaskStatusInProgress || claimed.FeatureName != "f" {
+		t.Errorf("Expected claimed task in progress in feature f, got %+v", claimed)
+	}
+	if claims, _ := db.ListClaims(ctx); len(claims) != 1 || claims[0].TaskID != claimed.ID {
+		t.Errorf("Expected a claim on minor, got %+v", claims)
+	}
+
+	for _, name := range []string{"minor", "waiting"} {
+		task, err := db.ClaimTask(ctx, tasks[name].ID, testClaimer, time.Minute)
+		if err != nil || task != nil {
+			t.Errorf("Expected %s not to be claimable, got %+v, %v", name, task, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrDependencyCycle is returned when adding a dependency would create a cycle.
+var ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+// checkDependencyCycle returns an error naming the cycle path if adding the edge
+// taskID -> dependsOnTaskID would make the dependency graph cyclic.
+func (db *DB) checkDependencyCycle(ctx context.Context, exec executor, taskID, dependsOnTaskID string) error {
+	path, err := findDependencyPath(ctx, exec, taskDependencyEdges, dependsOnTaskID, taskID)
+	if err != nil {
+		return err
+	}
+	if path == nil {
+		return nil
+	}
+	return dependencyCycleError(ctx, exec, append([]string{taskID}, path...))
+}
+
+// dependencyCycleError returns ErrDependencyCycle naming the tasks of cycle,
+// a list of task IDs that starts and ends with the same task.
+func dependencyCycleError(ctx context.Context, exec executor, cycle []string) error {
+	labels := make([]string, len(cycle))
+	for i, id := range cycle {
+		label, err := taskLabel(ctx, exec, id)
+		if err != nil {
+			return err
+		}
+		labels[i] = label
+	}
+
+	return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(labels, " -> "))
+}
+
+// The queries selecting the edges findDependencyPath follows, as (from, to)
+// pairs of IDs.
+const (
+	taskDependencyEdges    = `SELECT task_id, depends_on_task_id FROM dependencies`
+	featureDependencyEdges = `SELECT feature_id, depends_on_feature_id FROM feature_dependencies`
+)
+
+// findDependencyPath searches the graph of the edges selected by the edges
+// query breadth-first for a chain of depends_on edges leading from start to
+// target. It returns the IDs on the path, including both ends, or nil if
+// target is unreachable.
+func findDependencyPath(ctx context.Context, exec executor, edges, start, target string) ([]string, error) {
+	if start == target {
+		return []string{start}, nil
+	}
+
+	rows, err := exec.QueryContext(ctx, edges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	next := make(map[string][]string)
+	for rows.Next() {
+		var from, to string
+		if err := rows.Scan(&from, &to); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		next[from] = append(next[from], to)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	parent := map[string]string{start: ""}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, to := range next[current] {
+			if _, seen := parent[to]; seen {
+				continue
+			}
+			parent[to] = current
+			if to == target {
+				var path []string
+				for id := target; id != ""; id = parent[id] {
+					path = append([]string{id}, path...)
+				}
+				return path, nil
+			}
+			queue = append(queue, to)
+		}
+	}
+
+	return nil, nil
+}
+
+// findCycle returns a cycle in the graph of next, as the IDs along it with
+// the first repeated at the end, or nil if the graph is acyclic.
+func findCycle(next map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(next))
+	var path []string
+
+	// visit walks depth-first from id and returns the cycle it runs into, if
+	// any. It recurses once per edge on the path, so at most as deep as the
+	// longest dependency chain.
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = visiting
+		path = append(path, id)
+		for _, to := range next[id] {
+			switch state[to] {
+			case visiting:
+				start := slices.Index(path, to)
+				return append(slices.Clone(path[start:]), to)
+			case unvisited:
+				if cycle := visit(to); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(next)) {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func taskLabel(ctx context.Context, exec executor, taskID string) (string, error) {
+	var featureName, taskName string
+	err := exec.QueryRowContext(ctx, `
+		SELECT f.name, t.name
+		FROM tasks t
+		JOIN features f ON t.feature_id = f.id
+		WHERE t.id = ?`, taskID).Scan(&featureName, &taskName)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve task %s: %w", taskID, err)
+	}
+	return featureName + "/" + taskName, nil
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestCreateDependencyRejectsCycleWithPath(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	ids := make(map[string]string)
+	for _, name := range []string{"A", "B", "C"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		ids[name] = task.ID
+	}
+
+	if err := db.CreateDependency(ctx, ids["B"], ids["A"]); err != nil {
+		t.Fatalf("Failed to create B -> A: %v", err)
+	}
+	if err := db.CreateDependency(ctx, ids["C"], ids["B"]); err != nil {
+		t.Fatalf("Failed to create C -> B: %v", err)
+	}
+
+	err = db.CreateDependency(ctx, ids["A"], ids["C"])
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "f/A -> f/C -> f/B -> f/A") {
+		t.Errorf("expected error to name the cycle path, got %v", err)
+	}
+
+	err = db.CreateDependency(ctx, ids["A"], ids["A"])
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("expected self-dependency to be reported as a cycle, got %v", err)
+	}
+
+	// A diamond is not a cycle.
+	if err := db.CreateDependency(ctx, ids["C"], ids["A"]); err != nil {
+		t.Errorf("expected redundant edge C -> A to be accepted, got %v", err)
+	}
+}
+
+func TestCommitBatchRejectsStagedCycle(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	session := "cycle"
+	db.Staging.AddFeature(session, &models.Feature{Name: "loop", Description: "d", Specification: "s"})
+	db.Staging.AddTask(session, &models.Task{FeatureName: "loop", Name: "x", Description: "d", Specification: "s", Status: models.TaskStatusPending})
+	db.Staging.AddTask(session, &models.Task{FeatureName: "loop", Name: "y", Description: "d", Specification: "s", Status: models.TaskStatusPending})
+	db.Staging.AddDependency(session, &models.Dependency{FeatureName: "loop", TaskName: "x", DependsOnFeatureName: "loop", DependsOnTaskName: "y"})
+	db.Staging.AddDependency(session, &models.Dependency{FeatureName: "loop", TaskName: "y", DependsOnFeatureName: "loop", DependsOnTaskName: "x"})
+
+	err = db.CommitBatch(ctx, session)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "loop/y -> loop/x -> loop/y") {
+		t.Errorf("expected error to name the cycle path, got %v", err)
+	}
+
+	f, err := db.GetFeatureByName(ctx, "loop")
+	if err != nil {
+		t.Fatalf("Failed to look up feature: %v", err)
+	}
+	if f != nil {
+		t.Error("expected the batch to be rolled back")
+	}
+}
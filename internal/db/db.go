@@ -3,21 +3,44 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
+	"sync/atomic"
 
-	embedsql "github.com/nick-dorsch/ponder/embed/sql"
+	"github.com/nick-dorsch/ponder/internal/telemetry"
 	_ "modernc.org/sqlite"
 )
 
+// sqliteBusyTimeout is how long, in milliseconds, a SQLite connection waits
+// for another connection or process to release a lock before failing.
+const sqliteBusyTimeout = 5000
+
 type DB struct {
+	// DB is the pool writes and transactions go through.
 	*sql.DB
-	Staging          *StagingManager
-	onChange         func(ctx context.Context)
-	onChangeMu       sync.RWMutex
-	onChangeDisabled bool
+	// reader is a separate pool for SQLite queries that don't write, so they
+	// never wait behind a writer. Nil when DB serves reads too.
+	reader          *sql.DB
+	Staging         *StagingManager
+	onChange        func(ctx context.Context)
+	onChangeMu      sync.RWMutex
+	subscribers     map[int]func(ctx context.Context) // called after onChange
+	nextSub         int
+	aging           PriorityAging
+	claimFilter     ClaimFilter        // guarded by agingMu too
+	availability    AvailabilityPolicy // guarded by agingMu too
+	agingMu         sync.RWMutex
+	dialect         dialect
+	autoBackup      AutoBackup
+	backupMu        sync.RWMutex
+	snapshots       *snapshotExporter
+	snapshotHistory SnapshotHistory
+	snapshotMu      sync.Mutex
+	cache           readCache
 }
 
 type executor interface {
@@ -26,69 +49,189 @@ type executor interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
+// read returns the pool for queries that don't write, traced when tracing
+// is on.
+func (db *DB) read() executor {
+	pool := db.DB
+	if db.reader != nil {
+		pool = db.reader
+	}
+	if telemetry.Enabled() {
+		return tracedExecutor{db: db, exec: pool}
+	}
+	return pool
+}
+
+// Close writes out a pending snapshot export and closes both pools.
+func (db *DB) Close() error {
+	flushErr := db.FlushSnapshot(context.Background())
+	var readErr error
+	if db.reader != nil {
+		readErr = db.reader.Close()
+	}
+	return errors.Join(flushErr, db.DB.Close(), readErr)
+}
+
 func (db *DB) SetOnChange(fn func(ctx context.Context)) {
 	db.onChangeMu.Lock()
 	defer db.onChangeMu.Unlock()
 	db.onChange = fn
 }
 
-func (db *DB) DisableOnChange() {
+// Subscribe calls fn after every change, as SetOnChange does, without
+// replacing the hook set there. Call unsubscribe to stop.
+func (db *DB) Subscribe(fn func(ctx context.Context)) (unsubscribe func()) {
 	db.onChangeMu.Lock()
 	defer db.onChangeMu.Unlock()
-	db.onChangeDisabled = true
+	if db.subscribers == nil {
+		db.subscribers = make(map[int]func(ctx context.Context))
+	}
+	id := db.nextSub
+	db.nextSub++
+	db.subscribers[id] = fn
+	return func() {
+		db.onChangeMu.Lock()
+		defer db.onChangeMu.Unlock()
+		delete(db.subscribers, id)
+	}
 }
 
-func (db *DB) EnableOnChange() {
-	db.onChangeMu.Lock()
-	defer db.onChangeMu.Unlock()
-	db.onChangeDisabled = false
+// changeBatchKey is the context key of the changeBatch started by
+// WithBatchedChanges.
+type changeBatchKey struct{}
+
+// changeBatch records whether anything changed while a batch was open.
+type changeBatch struct {
+	changed atomic.Bool
+}
+
+// WithBatchedChanges runs fn with a context whose writes don't call the
+// change hook one by one: the hook is called once, after fn returns, if any
+// of them changed something, whether or not fn failed. Batches opened inside
+// fn join the outer one. Writes made with other contexts notify as usual.
+func (db *DB) WithBatchedChanges(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(changeBatchKey{}).(*changeBatch); ok {
+		return fn(ctx)
+	}
+
+	batch := &changeBatch{}
+	err := fn(context.WithValue(ctx, changeBatchKey{}, batch))
+	if batch.changed.Load() {
+		db.triggerChange(ctx)
+	}
+	return err
 }
 
 func (db *DB) triggerChange(ctx context.Context) {
+	// The write is done even if the hooks wait for the batch to end, or
+	// don't run at all.
+	db.cache.invalidate()
+
+	if batch, ok := ctx.Value(changeBatchKey{}).(*changeBatch); ok {
+		batch.changed.Store(true)
+		return
+	}
+
 	db.onChangeMu.RLock()
 	fn := db.onChange
-	disabled := db.onChangeDisabled
+	subscribers := make([]func(ctx context.Context), 0, len(db.subscribers))
+	for _, sub := range db.subscribers {
+		subscribers = append(subscribers, sub)
+	}
 	db.onChangeMu.RUnlock()
 
-	if fn != nil && !disabled {
+	if fn != nil {
 		fn(ctx)
 	}
+	for _, sub := range subscribers {
+		sub(ctx)
+	}
+}
+
+// Open opens the database named by dsn: a Postgres database for
+// postgres:// and postgresql:// URLs, otherwise a SQLite database at that path.
+// A database Init has set up is brought up to date with ApplyMigrations.
+func Open(dsn string) (*DB, error) {
+	return OpenWithOptions(dsn, OpenOptions{})
 }
 
-// Open opens a SQLite database at the given path.
-func Open(path string) (*DB, error) {
-	db, err := sql.Open("sqlite", path)
+// OpenOptions controls what OpenWithOptions does besides opening.
+type OpenOptions struct {
+	// SkipMigrations leaves pending migrations for ApplyMigrations.
+	SkipMigrations bool
+}
+
+// OpenWithOptions opens the database named by dsn like Open.
+func OpenWithOptions(dsn string, opts OpenOptions) (*DB, error) {
+	var db *DB
+	var err error
+	if IsPostgresDSN(dsn) {
+		db, err = openPostgres(dsn)
+	} else {
+		db, err = openSQLite(dsn)
+	}
+	if err != nil || opts.SkipMigrations {
+		return db, err
+	}
+
+	ctx := context.Background()
+	initialized, err := db.hasTable(ctx, "features")
+	if err == nil && initialized {
+		_, err = db.ApplyMigrations(ctx)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		db.Close()
+		return nil, err
 	}
+	return db, nil
+}
 
+// openSQLite opens a SQLite database with one writer connection and, for
+// files, a pool of read-only connections. In WAL mode readers see the last
+// committed state without waiting for the writer, and writers take the lock up
+// front with BEGIN IMMEDIATE, waiting up to sqliteBusyTimeout for other
+// processes rather than failing mid-transaction.
+func openSQLite(path string) (*DB, error) {
 	if path != ":memory:" {
 		dir := filepath.Dir(path)
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			db.Close()
 			return nil, fmt.Errorf("failed to create database directory: %w", err)
 		}
 	}
 
-	// WAL mode for better concurrency
-	if _, err := db.Exec("PRAGMA journal_mode=WAL;"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+	pragmas := fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)", sqliteBusyTimeout)
+	writer, err := sql.Open("sqlite", path+"?"+pragmas+"&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// SQLite allows one writer at a time; more connections would only queue
+	// on the lock.
+	writer.SetMaxOpenConns(1)
+	if err := writer.Ping(); err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Foreign keys support
-	if _, err := db.Exec("PRAGMA foreign_keys=ON;"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	db := &DB{
+		DB:      writer,
+		Staging: NewStagingManager(),
+		dialect: sqliteDialect{},
 	}
 
-	// SQLite works best with a single writer.
-	db.SetMaxOpenConns(1)
+	// Each connection to :memory: is a separate database, so it can't be
+	// shared by two pools.
+	if path == ":memory:" {
+		return db, nil
+	}
 
-	return &DB{
-		DB:      db,
-		Staging: NewStagingManager(),
-	}, nil
+	reader, err := sql.Open("sqlite", path+"?"+pragmas+"&_pragma=query_only(1)")
+	if err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	reader.SetMaxOpenConns(max(4, runtime.NumCPU()))
+	db.reader = reader
+	return db, nil
 }
 
 func (db *DB) Migrate(ctx context.Context, schema string) error {
@@ -99,16 +242,43 @@ func (db *DB) Migrate(ctx context.Context, schema string) error {
 	return nil
 }
 
+// Init sets up the schema. A new database gets the current schema, which
+// includes every migration; an existing one is migrated before the schema
+// fills in what is missing and replaces the views.
 func (db *DB) Init(ctx context.Context) error {
-	return db.Migrate(ctx, embedsql.Schema)
+	initialized, err := db.hasTable(ctx, "features")
+	if err != nil {
+		return err
+	}
+	if initialized {
+		if _, err := db.ApplyMigrations(ctx); err != nil {
+			return err
+		}
+	}
+	if err := db.Migrate(ctx, db.dialect.schema()); err != nil {
+		return err
+	}
+	if !initialized {
+		return db.recordSchemaMigrations(ctx)
+	}
+	return nil
 }
 
+// GetGraphJSON returns the task graph as JSON, from the read cache while it
+// is current.
 func (db *DB) GetGraphJSON(ctx context.Context) (string, error) {
+	return readCached(ctx, db, cacheGraph, db.getGraphJSON)
+}
+
+func (db *DB) getGraphJSON(ctx context.Context) (string, error) {
 	var json string
 	query := `SELECT graph_json FROM v_graph_json`
-	err := db.QueryRowContext(ctx, query).Scan(&json)
+	err := db.read().QueryRowContext(ctx, query).Scan(&json)
 	if err != nil {
 		return "", fmt.Errorf("failed to get graph json: %w", err)
 	}
-	return json, nil
+	if !db.AvailabilityPolicy().Soft() {
+		return json, nil
+	}
+	return db.markGraphAvailable(ctx, json)
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
 func TestOpen(t *testing.T) {
@@ -35,6 +38,66 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+func TestSQLiteReadsDoNotWaitForWriters(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	// Another process holding the write lock blocks neither reads nor, until
+	// the busy timeout, writers of this one.
+	other, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open second handle: %v", err)
+	}
+	defer other.Close()
+	tx, err := other.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO features (id, name, description, specification) VALUES ('f1', 'held', '', '')"); err != nil {
+		t.Fatalf("Failed to write in transaction: %v", err)
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, time.Second)
+	features, err := db.ListFeatures(readCtx)
+	cancel()
+	if err != nil {
+		t.Fatalf("Expected read to proceed during a write, got %v", err)
+	}
+	for _, f := range features {
+		if f.Name == "held" {
+			t.Error("Expected uncommitted feature to be invisible to readers")
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- db.CreateFeature(ctx, &models.Feature{Name: "queued", Description: "d", Specification: "s"})
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected queued write to succeed once the lock was released, got %v", err)
+	}
+
+	if f, _ := db.GetFeatureByName(ctx, "held"); f == nil {
+		t.Error("Expected committed feature to be visible to readers")
+	}
+	if _, err := db.read().ExecContext(ctx, "DELETE FROM features"); err == nil {
+		t.Error("Expected the read pool to reject writes")
+	}
+}
+
 func TestMigrate(t *testing.T) {
 	db, err := Open(":memory:")
 	if err != nil {
@@ -86,3 +149,62 @@ func TestInit(t *testing.T) {
 		t.Fatalf("Features table does not exist or query failed: %v", err)
 	}
 }
+
+func TestWithBatchedChanges(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	changes := 0
+	db.SetOnChange(func(ctx context.Context) { changes++ })
+
+	err = db.WithBatchedChanges(ctx, func(ctx context.Context) error {
+		f := &models.Feature{Name: "batch", Description: "d", Specification: "s"}
+		if err := db.CreateFeature(ctx, f); err != nil {
+			return err
+		}
+		// A nested batch joins the outer one.
+		return db.WithBatchedChanges(ctx, func(ctx context.Context) error {
+			for _, name := range []string{"one", "two", "three"} {
+				task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+				if err := db.CreateTask(ctx, task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithBatchedChanges failed: %v", err)
+	}
+	if changes != 1 {
+		t.Errorf("expected one change notification for the batch, got %d", changes)
+	}
+
+	changes = 0
+	err = db.WithBatchedChanges(ctx, func(ctx context.Context) error { return nil })
+	if err != nil || changes != 0 {
+		t.Errorf("expected a batch without writes not to notify, got %d (err %v)", changes, err)
+	}
+
+	changes = 0
+	err = db.WithBatchedChanges(ctx, func(ctx context.Context) error {
+		if err := db.CreateFeature(ctx, &models.Feature{Name: "partial", Description: "d", Specification: "s"}); err != nil {
+			return err
+		}
+		return db.CreateFeature(ctx, &models.Feature{Name: "partial", Description: "d", Specification: "s"})
+	})
+	if err == nil {
+		t.Fatal("expected the duplicate feature to fail the batch")
+	}
+	if changes != 1 {
+		t.Errorf("expected a failed batch to still notify its earlier writes, got %d", changes)
+	}
+}
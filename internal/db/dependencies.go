@@ -2,13 +2,17 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
 func (db *DB) CreateDependency(ctx context.Context, taskID, dependsOnTaskID string) error {
-	if err := db.createDependency(ctx, db.DB, taskID, dependsOnTaskID); err != nil {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		return db.createDependency(ctx, tx, taskID, dependsOnTaskID)
+	})
+	if err != nil {
 		return err
 	}
 	db.triggerChange(ctx)
@@ -16,19 +20,26 @@ func (db *DB) CreateDependency(ctx context.Context, taskID, dependsOnTaskID stri
 }
 
 func (db *DB) DeleteDependency(ctx context.Context, taskID, dependsOnTaskID string) error {
-	query := `DELETE FROM dependencies WHERE task_id = ? AND depends_on_task_id = ?`
-	res, err := db.ExecContext(ctx, query, taskID, dependsOnTaskID)
-	if err != nil {
-		return fmt.Errorf("failed to delete dependency: %w", err)
-	}
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		query := `DELETE FROM dependencies WHERE task_id = ? AND depends_on_task_id = ?`
+		res, err := tx.ExecContext(ctx, query, taskID, dependsOnTaskID)
+		if err != nil {
+			return fmt.Errorf("failed to delete dependency: %w", err)
+		}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
 
-	if rows == 0 {
-		return fmt.Errorf("dependency not found: %s -> %s", taskID, dependsOnTaskID)
+		if rows == 0 {
+			return fmt.Errorf("dependency not found: %s -> %s", taskID, dependsOnTaskID)
+		}
+
+		return recordDependencyEvent(ctx, tx, taskID, dependsOnTaskID, models.EventDependencyRemoved)
+	})
+	if err != nil {
+		return err
 	}
 
 	db.triggerChange(ctx)
@@ -39,12 +50,12 @@ func (db *DB) GetDependencies(ctx context.Context, taskID string) ([]*models.Tas
 	query := `
 		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required, 
 		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
-		       f.name as feature_name
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
 		FROM tasks t
 		JOIN dependencies d ON t.id = d.depends_on_task_id
 		LEFT JOIN features f ON t.feature_id = f.id
 		WHERE d.task_id = ?
-		ORDER BY t.priority DESC, t.created_at ASC
+		ORDER BY t.priority DESC, t.position ASC, t.created_at ASC
 	`
 	return db.queryTasks(ctx, query, taskID)
 }
@@ -53,12 +64,47 @@ func (db *DB) GetDependents(ctx context.Context, taskID string) ([]*models.Task,
 	query := `
 		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required, 
 		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
-		       f.name as feature_name
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
 		FROM tasks t
 		JOIN dependencies d ON t.id = d.task_id
 		LEFT JOIN features f ON t.feature_id = f.id
 		WHERE d.depends_on_task_id = ?
-		ORDER BY t.priority DESC, t.created_at ASC
+		ORDER BY t.priority DESC, t.position ASC, t.created_at ASC
 	`
 	return db.queryTasks(ctx, query, taskID)
 }
+
+// ListDependencies returns every dependency edge with task and feature names resolved.
+func (db *DB) ListDependencies(ctx context.Context) ([]*models.Dependency, error) {
+	query := `
+		SELECT d.task_id, d.depends_on_task_id, t.name, tf.name, dep.name, df.name
+		FROM dependencies d
+		JOIN tasks t ON d.task_id = t.id
+		JOIN features tf ON t.feature_id = tf.id
+		JOIN tasks dep ON d.depends_on_task_id = dep.id
+		JOIN features df ON dep.feature_id = df.id
+		ORDER BY tf.name, t.name, df.name, dep.name
+	`
+	rows, err := db.read().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	var deps []*models.Dependency
+	for rows.Next() {
+		d := &models.Dependency{}
+		if err := rows.Scan(
+			&d.TaskID, &d.DependsOnTaskID, &d.TaskName, &d.FeatureName, &d.DependsOnTaskName, &d.DependsOnFeatureName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		deps = append(deps, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return deps, nil
+}
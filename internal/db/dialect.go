@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	embedsql "github.com/nick-dorsch/ponder/embed/sql"
+)
+
+// dialect covers the few places where SQLite and Postgres need different SQL.
+// Everything else is written once in the subset both understand, with ?
+// placeholders that the Postgres driver rewrites.
+type dialect interface {
+	// schema returns the DDL applied by Init.
+	schema() string
+	// upgrade brings a database that predates schema_migrations up to the
+	// point where migrations start.
+	upgrade(ctx context.Context, db *DB) error
+	// migrationsDir names the directory of embedsql.Migrations holding the
+	// dialect's migrations.
+	migrationsDir() string
+	// tableCount returns a query counting the tables named by a placeholder
+	// argument.
+	tableCount() string
+	// secondsSince returns an expression for the seconds elapsed since the
+	// timestamp expression expr.
+	secondsSince(expr string) string
+	// truncate returns expr rounded down to an integer.
+	truncate(expr string) string
+	// least returns the smaller of two expressions.
+	least(a, b string) string
+	// atOrBefore returns a condition comparing the timestamp column col with a
+	// "YYYY-MM-DD HH:MM:SS" UTC placeholder argument.
+	atOrBefore(col string) string
+	// atOrAfter is the counterpart of atOrBefore.
+	atOrAfter(col string) string
+	// secondsBetween returns an expression for the seconds from the timestamp
+	// expression from to the timestamp expression to.
+	secondsBetween(from, to string) string
+	// utcDate returns the UTC date of a timestamp expression as YYYY-MM-DD.
+	utcDate(expr string) string
+	// jsonText returns the text of the top-level key of a JSON text column.
+	jsonText(col, key string) string
+	// lockRows is appended to the subquery that picks a task to claim.
+	lockRows() string
+	// lockMigrations is run first in a migration's transaction, so that
+	// processes migrating at once apply each migration once. Empty when
+	// writers already take turns.
+	lockMigrations() string
+	// insertOrder names a column that orders rows of a table by insertion.
+	insertOrder() string
+	// secondsFromNow returns an expression for the time a placeholder number
+	// of whole seconds from now, by the database's clock.
+	secondsFromNow() string
+	// timestamp converts t to an argument stored in a timestamp column in a
+	// form the dialect's date functions understand.
+	timestamp(t time.Time) any
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) schema() string { return embedsql.Schema }
+
+func (sqliteDialect) migrationsDir() string { return "migrations/sqlite" }
+
+func (sqliteDialect) tableCount() string {
+	return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+}
+
+func (sqliteDialect) upgrade(ctx context.Context, db *DB) error {
+	for _, up := range upgrades {
+		if err := up(ctx, db); err != nil {
+			return fmt.Errorf("upgrade failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (sqliteDialect) secondsSince(expr string) string {
+	return fmt.Sprintf("((julianday('now') - julianday(%s)) * 86400)", expr)
+}
+
+func (sqliteDialect) truncate(expr string) string {
+	return fmt.Sprintf("CAST(%s AS INTEGER)", expr)
+}
+
+func (sqliteDialect) least(a, b string) string {
+	return fmt.Sprintf("MIN(%s, %s)", a, b)
+}
+
+func (sqliteDialect) atOrBefore(col string) string {
+	return fmt.Sprintf("julianday(%s) <= julianday(?)", col)
+}
+
+func (sqliteDialect) atOrAfter(col string) string {
+	return fmt.Sprintf("julianday(%s) >= julianday(?)", col)
+}
+
+func (sqliteDialect) secondsBetween(from, to string) string {
+	return fmt.Sprintf("((julianday(%s) - julianday(%s)) * 86400)", to, from)
+}
+
+func (sqliteDialect) utcDate(expr string) string {
+	return fmt.Sprintf("date(%s)", expr)
+}
+
+func (sqliteDialect) jsonText(col, key string) string {
+	return fmt.Sprintf("json_extract(%s, '$.%s')", col, key)
+}
+
+func (sqliteDialect) lockRows() string    { return "" }
+func (sqliteDialect) insertOrder() string { return "rowid" }
+
+// lockMigrations is empty: transactions begin immediately, taking the write
+// lock up front.
+func (sqliteDialect) lockMigrations() string { return "" }
+
+func (sqliteDialect) secondsFromNow() string {
+	return "datetime('now', '+' || CAST(? AS INTEGER) || ' seconds')"
+}
+
+// timestamp writes the UTC format of CURRENT_TIMESTAMP, which julianday and
+// strftime parse; the driver's default for time.Time is Go's String form.
+func (sqliteDialect) timestamp(t time.Time) any {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) schema() string { return embedsql.PostgresSchema }
+
+// upgrade is a no-op: Postgres support started with the current schema.
+func (postgresDialect) upgrade(ctx context.Context, db *DB) error { return nil }
+
+func (postgresDialect) migrationsDir() string { return "migrations/postgres" }
+
+func (postgresDialect) tableCount() string {
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+}
+
+func (postgresDialect) secondsSince(expr string) string {
+	return fmt.Sprintf("EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - %s))", expr)
+}
+
+// truncate floors explicitly, since casting to INTEGER rounds in Postgres.
+func (postgresDialect) truncate(expr string) string {
+	return fmt.Sprintf("CAST(FLOOR(%s) AS INTEGER)", expr)
+}
+
+func (postgresDialect) least(a, b string) string {
+	return fmt.Sprintf("LEAST(%s, %s)", a, b)
+}
+
+func (postgresDialect) atOrBefore(col string) string {
+	return fmt.Sprintf("%s <= CAST(CAST(? AS TEXT) AS TIMESTAMP) AT TIME ZONE 'UTC'", col)
+}
+
+func (postgresDialect) atOrAfter(col string) string {
+	return fmt.Sprintf("%s >= CAST(CAST(? AS TEXT) AS TIMESTAMP) AT TIME ZONE 'UTC'", col)
+}
+
+func (postgresDialect) secondsBetween(from, to string) string {
+	return fmt.Sprintf("EXTRACT(EPOCH FROM (%s - %s))", to, from)
+}
+
+func (postgresDialect) utcDate(expr string) string {
+	return fmt.Sprintf("TO_CHAR(%s AT TIME ZONE 'UTC', 'YYYY-MM-DD')", expr)
+}
+
+func (postgresDialect) jsonText(col, key string) string {
+	return fmt.Sprintf("(CAST(%s AS JSONB) ->> '%s')", col, key)
+}
+
+// lockRows lets several orchestrators claim tasks concurrently without
+// waiting on, or double-claiming, the row another one is taking.
+func (postgresDialect) lockRows() string { return " FOR UPDATE SKIP LOCKED" }
+
+// lockMigrations holds off other migrating processes until this migration
+// commits, without blocking reads of schema_migrations.
+func (postgresDialect) lockMigrations() string {
+	return "LOCK TABLE schema_migrations IN SHARE ROW EXCLUSIVE MODE"
+}
+
+// insertOrder uses the seq column the Postgres schema adds for this purpose,
+// as Postgres has no rowid.
+func (postgresDialect) insertOrder() string { return "seq" }
+
+func (postgresDialect) secondsFromNow() string {
+	return "CURRENT_TIMESTAMP + CAST(? AS INTEGER) * INTERVAL '1 second'"
+}
+
+func (postgresDialect) timestamp(t time.Time) any { return t }
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// DefaultDurationDays is the period GetDurationReport covers when none is
+// given.
+const DefaultDurationDays = 30
+
+// GetDurationReport returns the wall time of the tasks completed over the
+// last days days, today included, per task and per feature, and the daily
+// average duration of the agent runs started in that period. Days are UTC
+// calendar days; days <= 0 means DefaultDurationDays. A non-empty feature
+// limits the report to that feature.
+func (db *DB) GetDurationReport(ctx context.Context, days int, feature string) (*models.DurationReport, error) {
+	if days <= 0 {
+		days = DefaultDurationDays
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	report := &models.DurationReport{Days: days}
+	tasks, err := db.listTaskDurations(ctx, since, feature)
+	if err != nil {
+		return nil, err
+	}
+	report.Tasks = tasks
+	report.Features = featureDurations(tasks)
+	if report.RunTrend, err = db.runTrend(ctx, since, days, feature); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// listTaskDurations returns the tasks completed since since, longest first.
+func (db *DB) listTaskDurations(ctx context.Context, since time.Time, feature string) ([]*models.TaskDuration, error) {
+	query := `
+		SELECT t.id, f.name, t.name, t.priority, t.started_at, t.completed_at,
+		       COUNT(r.id), COALESCE(SUM(r.duration_ms), 0)
+		FROM tasks t
+		JOIN features f ON f.id = t.feature_id
+		LEFT JOIN runs r ON r.task_id = t.id
+		WHERE t.status = 'completed'
+		  AND t.started_at IS NOT NULL
+		  AND t.completed_at IS NOT NULL
+		  AND ` + db.dialect.atOrAfter("t.completed_at")
+	args := []any{db.dialect.timestamp(since)}
+	if feature != "" {
+		query += " AND f.name = ?"
+		args = append(args, feature)
+	}
+	query += `
+		GROUP BY t.id, f.name, t.name, t.priority, t.started_at, t.completed_at`
+
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task durations: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []*models.TaskDuration{}
+	for rows.Next() {
+		d := &models.TaskDuration{}
+		var runMS int64
+		if err := rows.Scan(&d.TaskID, &d.FeatureName, &d.TaskName, &d.Priority, &d.StartedAt, &d.CompletedAt, &d.Runs, &runMS); err != nil {
+			return nil, fmt.Errorf("failed to scan task duration: %w", err)
+		}
+		d.WallSeconds = d.CompletedAt.Sub(d.StartedAt).Seconds()
+		d.RunSeconds = float64(runMS) / 1000
+		tasks = append(tasks, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].WallSeconds != tasks[j].WallSeconds {
+			return tasks[i].WallSeconds > tasks[j].WallSeconds
+		}
+		return tasks[i].FeatureName+"/"+tasks[i].TaskName < tasks[j].FeatureName+"/"+tasks[j].TaskName
+	})
+	return tasks, nil
+}
+
+// featureDurations sums tasks up by feature, ordered by feature name.
+func featureDurations(tasks []*models.TaskDuration) []*models.FeatureDuration {
+	byName := make(map[string]*models.FeatureDuration)
+	first := make(map[string]time.Time)
+	last := make(map[string]time.Time)
+	for _, t := range tasks {
+		f := byName[t.FeatureName]
+		if f == nil {
+			f = &models.FeatureDuration{FeatureName: t.FeatureName}
+			byName[t.FeatureName] = f
+			first[t.FeatureName] = t.StartedAt
+			last[t.FeatureName] = t.CompletedAt
+		}
+		f.Completed++
+		f.WallSeconds += t.WallSeconds
+		f.RunSeconds += t.RunSeconds
+		if t.StartedAt.Before(first[t.FeatureName]) {
+			first[t.FeatureName] = t.StartedAt
+		}
+		if t.CompletedAt.After(last[t.FeatureName]) {
+			last[t.FeatureName] = t.CompletedAt
+		}
+	}
+
+	features := make([]*models.FeatureDuration, 0, len(byName))
+	for name, f := range byName {
+		f.AvgWallSeconds = f.WallSeconds / float64(f.Completed)
+		f.ElapsedSeconds = last[name].Sub(first[name]).Seconds()
+		features = append(features, f)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].FeatureName < features[j].FeatureName })
+	return features
+}
+
+// runTrend averages the duration of the agent runs started on each day since
+// since.
+func (db *DB) runTrend(ctx context.Context, since time.Time, days int, feature string) ([]*models.DailyRunDuration, error) {
+	query := `
+		SELECT ` + db.dialect.utcDate("r.started_at") + `, COUNT(*), SUM(r.duration_ms)
+		FROM runs r
+		JOIN tasks t ON t.id = r.task_id
+		JOIN features f ON f.id = t.feature_id
+		WHERE ` + db.dialect.atOrAfter("r.started_at")
+	args := []any{db.dialect.timestamp(since)}
+	if feature != "" {
+		query += " AND f.name = ?"
+		args = append(args, feature)
+	}
+	query += `
+		GROUP BY ` + db.dialect.utcDate("r.started_at")
+
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run durations: %w", err)
+	}
+	defer rows.Close()
+
+	type daily struct {
+		runs int
+		ms   int64
+	}
+	perDay := make(map[string]daily)
+	for rows.Next() {
+		var day string
+		var d daily
+		if err := rows.Scan(&day, &d.runs, &d.ms); err != nil {
+			return nil, fmt.Errorf("failed to scan run durations: %w", err)
+		}
+		perDay[day] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	trend := make([]*models.DailyRunDuration, 0, days)
+	for i := 0; i < days; i++ {
+		day := since.AddDate(0, 0, i).Format("2006-01-02")
+		entry := &models.DailyRunDuration{Date: day}
+		if d, ok := perDay[day]; ok && d.runs > 0 {
+			avg := float64(d.ms) / 1000 / float64(d.runs)
+			entry.Runs = d.runs
+			entry.AvgSeconds = &avg
+		}
+		trend = append(trend, entry)
+	}
+	return trend, nil
+}
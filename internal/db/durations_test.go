@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestGetDurationReport(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	api := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	ui := &models.Feature{Name: "ui", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{api, ui} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	create := func(f *models.Feature, name string) *models.Task {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		return task
+	}
+	complete := func(task *models.Task, started, completed string) {
+		summary := "summary"
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			t.Fatalf("Failed to start %s: %v", task.Name, err)
+		}
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted, &summary); err != nil {
+			t.Fatalf("Failed to complete %s: %v", task.Name, err)
+		}
+		_, err := db.ExecContext(ctx, "UPDATE tasks SET started_at = datetime('now', ?), completed_at = datetime('now', ?) WHERE id = ?", started, completed, task.ID)
+		if err != nil {
+			t.Fatalf("Failed to backdate %s: %v", task.Name, err)
+		}
+	}
+
+	login := create(api, "login")
+	logout := create(api, "logout")
+	page := create(ui, "page")
+	create(ui, "todo")
+	complete(login, "-3 hours", "-1 hours")
+	complete(logout, "-2 hours", "-30 minutes")
+	complete(page, "-10 minutes", "-5 minutes")
+
+	for _, run := range []*models.Run{
+		{TaskID: login.ID, DurationMS: 60000, StartedAt: time.Now().Add(-3 * time.Hour)},
+		{TaskID: login.ID, DurationMS: 120000, StartedAt: time.Now().Add(-2 * time.Hour)},
+		{TaskID: page.ID, DurationMS: 30000, StartedAt: time.Now().Add(-10 * time.Minute)},
+	} {
+		if err := db.RecordRun(ctx, run); err != nil {
+			t.Fatalf("Failed to record run: %v", err)
+		}
+	}
+
+	report, err := db.GetDurationReport(ctx, 0, "")
+	if err != nil {
+		t.Fatalf("GetDurationReport failed: %v", err)
+	}
+	if report.Days != DefaultDurationDays || len(report.RunTrend) != DefaultDurationDays {
+		t.Errorf("Expected %d days of trend, got %d days and %d entries", DefaultDurationDays, report.Days, len(report.RunTrend))
+	}
+	if len(report.Tasks) != 3 {
+		t.Fatalf("Expected 3 completed tasks, got %d", len(report.Tasks))
+	}
+	if got := report.Tasks[0]; got.TaskName != "login" || math.Abs(got.WallSeconds-7200) > 1 || got.Runs != 2 || got.RunSeconds != 180 {
+		t.Errorf("Expected login first with 2h wall time and 180s of runs, got %+v", got)
+	}
+	if report.Tasks[1].TaskName != "logout" || report.Tasks[2].TaskName != "page" {
+		t.Errorf("Expected tasks longest first, got %s, %s", report.Tasks[1].TaskName, report.Tasks[2].TaskName)
+	}
+
+	if len(report.Features) != 2 {
+		t.Fatalf("Expected 2 features, got %d", len(report.Features))
+	}
+	f := report.Features[0]
+	if f.FeatureName != "api" || f.Completed != 2 || math.Abs(f.WallSeconds-12600) > 1 || math.Abs(f.AvgWallSeconds-6300) > 1 {
+		t.Errorf("Unexpected api durations: %+v", f)
+	}
+	// From login starting to logout completing.
+	if math.Abs(f.ElapsedSeconds-9000) > 1 {
+		t.Errorf("Expected api to span 2.5h, got %vs", f.ElapsedSeconds)
+	}
+
+	runs, total := trendTotals(report.RunTrend)
+	if runs != 3 || math.Abs(total-210) > 0.001 {
+		t.Errorf("Expected 3 runs taking 210s in the trend, got %d taking %vs", runs, total)
+	}
+
+	report, err = db.GetDurationReport(ctx, 7, "ui")
+	if err != nil {
+		t.Fatalf("GetDurationReport failed: %v", err)
+	}
+	if len(report.Tasks) != 1 || report.Tasks[0].TaskName != "page" || len(report.Features) != 1 {
+		t.Errorf("Expected only the ui task, got %+v", report.Tasks)
+	}
+	if runs, total := trendTotals(report.RunTrend); runs != 1 || math.Abs(total-30) > 0.001 {
+		t.Errorf("Expected one 30s ui run in the trend, got %d taking %vs", runs, total)
+	}
+}
+
+// trendTotals adds up the runs of a trend and the seconds they took.
+func trendTotals(trend []*models.DailyRunDuration) (runs int, seconds float64) {
+	for _, d := range trend {
+		if d.AvgSeconds != nil {
+			runs += d.Runs
+			seconds += *d.AvgSeconds * float64(d.Runs)
+		}
+	}
+	return runs, seconds
+}
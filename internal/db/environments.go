@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrInvalidRunEnvironment is returned for a working directory outside the
+// repository or an invalid environment variable name.
+var ErrInvalidRunEnvironment = errors.New("invalid run environment")
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// normalizeRunEnvironment checks e and cleans its working directory. The
+// directory must stay inside the repository, so that it also works inside a
+// task's worktree.
+func normalizeRunEnvironment(e *models.RunEnvironment) error {
+	if e.WorkingDir != "" {
+		dir := filepath.Clean(filepath.FromSlash(e.WorkingDir))
+		if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%w: working directory %q must be relative to the repository root and stay inside it", ErrInvalidRunEnvironment, e.WorkingDir)
+		}
+		if dir == "." {
+			dir = ""
+		}
+		e.WorkingDir = filepath.ToSlash(dir)
+	}
+	for name := range e.Env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: %q is not a valid environment variable name", ErrInvalidRunEnvironment, name)
+		}
+	}
+	return nil
+}
+
+// SetTaskRunEnvironment replaces the run environment of a task. A zero
+// environment removes it, so the task falls back to its feature's.
+func (db *DB) SetTaskRunEnvironment(ctx context.Context, taskID string, e *models.RunEnvironment) error {
+	return db.setRunEnvironment(ctx, "task_id", taskID, e)
+}
+
+// SetFeatureRunEnvironment replaces the run environment shared by a
+// feature's tasks. A zero environment removes it.
+func (db *DB) SetFeatureRunEnvironment(ctx context.Context, featureID string, e *models.RunEnvironment) error {
+	return db.setRunEnvironment(ctx, "feature_id", featureID, e)
+}
+
+func (db *DB) setRunEnvironment(ctx context.Context, column, id string, e *models.RunEnvironment) error {
+	if e.IsZero() {
+		if _, err := db.ExecContext(ctx, "DELETE FROM run_environments WHERE "+column+" = ?", id); err != nil {
+			return fmt.Errorf("failed to clear run environment: %w", err)
+		}
+		db.triggerChange(ctx)
+		return nil
+	}
+
+	if err := normalizeRunEnvironment(e); err != nil {
+		return err
+	}
+	env, err := json.Marshal(e.Env)
+	if err != nil {
+		return fmt.Errorf("failed to encode environment: %w", err)
+	}
+	if len(e.Env) == 0 {
+		env = []byte("{}")
+	}
+	if err := upsertRunEnvironment(ctx, db, column, id, e.WorkingDir, string(env)); err != nil {
+		return fmt.Errorf("failed to set run environment: %w", err)
+	}
+	db.triggerChange(ctx)
+	return nil
+}
+
+// upsertRunEnvironment stores a run environment for the task or feature id,
+// column naming which.
+func upsertRunEnvironment(ctx context.Context, exec executor, column, id, workingDir, env string) error {
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO run_environments (`+column+`, working_dir, env)
+		VALUES (?, ?, ?)
+		ON CONFLICT (`+column+`) DO UPDATE SET
+			working_dir = excluded.working_dir, env = excluded.env, updated_at = CURRENT_TIMESTAMP`,
+		id, workingDir, env)
+	return err
+}
+
+// GetTaskRunEnvironment returns the run environment set on a task itself, or
+// nil if there is none.
+func (db *DB) GetTaskRunEnvironment(ctx context.Context, taskID string) (*models.RunEnvironment, error) {
+	return db.getRunEnvironment(ctx, "task_id", taskID)
+}
+
+// GetFeatureRunEnvironment returns the run environment set on a feature, or
+// nil if there is none.
+func (db *DB) GetFeatureRunEnvironment(ctx context.Context, featureID string) (*models.RunEnvironment, error) {
+	return db.getRunEnvironment(ctx, "feature_id", featureID)
+}
+
+func (db *DB) getRunEnvironment(ctx context.Context, column, id string) (*models.RunEnvironment, error) {
+	var workingDir, env string
+	err := db.read().QueryRowContext(ctx,
+		"SELECT working_dir, env FROM run_environments WHERE "+column+" = ?", id,
+	).Scan(&workingDir, &env)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run environment: %w", err)
+	}
+
+	e := &models.RunEnvironment{WorkingDir: workingDir}
+	if err := json.Unmarshal([]byte(env), &e.Env); err != nil {
+		return nil, fmt.Errorf("failed to decode environment: %w", err)
+	}
+	if len(e.Env) == 0 {
+		e.Env = nil
+	}
+	return e, nil
+}
+
+// ResolveRunEnvironment returns the environment the agent for task runs in:
+// its feature's, with the task's working directory and variables taking
+// precedence. It is never nil.
+func (db *DB) ResolveRunEnvironment(ctx context.Context, task *models.Task) (*models.RunEnvironment, error) {
+	feature, err := db.GetFeatureRunEnvironment(ctx, task.FeatureID)
+	if err != nil {
+		return nil, err
+	}
+	own, err := db.GetTaskRunEnvironment(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &models.RunEnvironment{}
+	for _, e := range []*models.RunEnvironment{feature, own} {
+		if e == nil {
+			continue
+		}
+		if e.WorkingDir != "" {
+			resolved.WorkingDir = e.WorkingDir
+		}
+		for name, value := range e.Env {
+			if resolved.Env == nil {
+				resolved.Env = make(map[string]string)
+			}
+			resolved.Env[name] = value
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestRunEnvironments(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	resolved, err := db.ResolveRunEnvironment(ctx, task)
+	if err != nil || resolved == nil || !resolved.IsZero() {
+		t.Fatalf("expected an empty environment, got %+v (%v)", resolved, err)
+	}
+
+	for _, bad := range []*models.RunEnvironment{
+		{WorkingDir: "/abs"},
+		{WorkingDir: "../up"},
+		{WorkingDir: "a/../../up"},
+		{Env: map[string]string{"1BAD": "x"}},
+		{Env: map[string]string{"A-B": "x"}},
+	} {
+		if err := db.SetTaskRunEnvironment(ctx, task.ID, bad); !errors.Is(err, ErrInvalidRunEnvironment) {
+			t.Errorf("expected ErrInvalidRunEnvironment for %+v, got %v", bad, err)
+		}
+	}
+
+	if err := db.SetFeatureRunEnvironment(ctx, f.ID, &models.RunEnvironment{
+		WorkingDir: "./services/api/",
+		Env:        map[string]string{"PORT": "8080", "MODE": "test"},
+	}); err != nil {
+		t.Fatalf("SetFeatureRunEnvironment failed: %v", err)
+	}
+	if err := db.SetTaskRunEnvironment(ctx, task.ID, &models.RunEnvironment{
+		Env: map[string]string{"PORT": "9090"},
+	}); err != nil {
+		t.Fatalf("SetTaskRunEnvironment failed: %v", err)
+	}
+
+	got, err := db.GetFeatureRunEnvironment(ctx, f.ID)
+	if err != nil || got == nil || got.WorkingDir != "services/api" {
+		t.Fatalf("expected cleaned working directory, got %+v (%v)", got, err)
+	}
+
+	resolved, err = db.ResolveRunEnvironment(ctx, task)
+	if err != nil {
+		t.Fatalf("ResolveRunEnvironment failed: %v", err)
+	}
+	if resolved.WorkingDir != "services/api" || resolved.Env["PORT"] != "9090" || resolved.Env["MODE"] != "test" {
+		t.Errorf("unexpected resolved environment %+v", resolved)
+	}
+
+	// Run environments survive a snapshot round trip.
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	db2, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db2.Close()
+	if err := db2.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db2.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	imported, err := db2.ResolveRunEnvironment(ctx, task)
+	if err != nil || imported.WorkingDir != "services/api" || imported.Env["PORT"] != "9090" || imported.Env["MODE"] != "test" {
+		t.Errorf("unexpected imported environment %+v (%v)", imported, err)
+	}
+
+	// A zero environment clears the task's own settings.
+	if err := db.SetTaskRunEnvironment(ctx, task.ID, &models.RunEnvironment{}); err != nil {
+		t.Fatalf("SetTaskRunEnvironment failed: %v", err)
+	}
+	own, err := db.GetTaskRunEnvironment(ctx, task.ID)
+	if err != nil || own != nil {
+		t.Errorf("expected task environment to be cleared, got %+v (%v)", own, err)
+	}
+
+	// Deleting the feature removes its environment.
+	if err := db.DeleteFeature(ctx, f.ID); err != nil {
+		t.Fatalf("DeleteFeature failed: %v", err)
+	}
+	got, err = db.GetFeatureRunEnvironment(ctx, f.ID)
+	if err != nil || got != nil {
+		t.Errorf("expected feature environment to be deleted, got %+v (%v)", got, err)
+	}
+}
@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Event entity types.
+const (
+	EntityFeature  = "feature"
+	EntityTask     = "task"
+	EntitySnapshot = "snapshot"
+)
+
+// snippetLimit caps long text fields stored in event snapshots.
+const snippetLimit = 200
+
+type taskSnippet struct {
+	Name          string              `json:"name"`
+	FeatureID     string              `json:"feature_id"`
+	Description   string              `json:"description"`
+	Specification string              `json:"specification"`
+	Priority      int                 `json:"priority"`
+	TestsRequired bool                `json:"tests_required"`
+	Status        models.TaskStatus   `json:"status"`
+	NotBefore     *time.Time          `json:"not_before,omitempty"`
+	DueAt         *time.Time          `json:"due_at,omitempty"`
+	ParentTaskID  *string             `json:"parent_task_id,omitempty"`
+	SubtaskOrder  models.SubtaskOrder `json:"subtask_order,omitempty"`
+	Position      int                 `json:"position,omitempty"`
+	// EstimateMinutes is the task's estimate, when it has one.
+	EstimateMinutes *int `json:"estimate_minutes,omitempty"`
+}
+
+type featureSnippet struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Specification string `json:"specification"`
+}
+
+type statusSnippet struct {
+	Status            models.TaskStatus `json:"status"`
+	CompletionSummary *string           `json:"completion_summary,omitempty"`
+	BlockedReason     *string           `json:"blocked_reason,omitempty"`
+	// Reason is why the status was changed, when one was given.
+	Reason *string `json:"reason,omitempty"`
+}
+
+type labelsSnippet struct {
+	Labels []string `json:"labels"`
+}
+
+type dependencySnippet struct {
+	DependsOn string `json:"depends_on"`
+}
+
+func snippetOfTask(t *models.Task) taskSnippet {
+	return taskSnippet{
+		Name:            t.Name,
+		FeatureID:       t.FeatureID,
+		Description:     truncate(t.Description),
+		Specification:   truncate(t.Specification),
+		Priority:        t.Priority,
+		TestsRequired:   t.TestsRequired,
+		Status:          t.Status,
+		NotBefore:       t.NotBefore,
+		DueAt:           t.DueAt,
+		ParentTaskID:    t.ParentTaskID,
+		SubtaskOrder:    t.SubtaskOrder,
+		Position:        t.Position,
+		EstimateMinutes: t.EstimateMinutes,
+	}
+}
+
+func snippetOfFeature(f *models.Feature) featureSnippet {
+	return featureSnippet{
+		Name:          f.Name,
+		Description:   truncate(f.Description),
+		Specification: truncate(f.Specification),
+	}
+}
+
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= snippetLimit {
+		return s
+	}
+	return string(runes[:snippetLimit]) + "…"
+}
+
+// recordEvent appends an entry to the audit log. The actor is taken from ctx.
+func recordEvent(ctx context.Context, exec executor, entityType, entityID, entityName string, action models.EventAction, before, after any) error {
+	beforeJSON, err := marshalSnippet(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalSnippet(after)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO events (entity_type, entity_id, entity_name, action, actor, before_json, after_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = exec.ExecContext(ctx, query,
+		entityType, entityID, entityName, action, actor.From(ctx), beforeJSON, afterJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+	return nil
+}
+
+func marshalSnippet(v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event snippet: %w", err)
+	}
+	s := string(data)
+	return &s, nil
+}
+
+// ListEvents returns audit log entries in chronological order. Empty
+// entityType or entityID match any value; limit > 0 returns only the most
+// recent entries.
+func (db *DB) ListEvents(ctx context.Context, entityType, entityID string, limit int) ([]*models.Event, error) {
+	query := `
+		SELECT id, entity_type, entity_id, entity_name, action, actor, before_json, after_json, created_at
+		FROM events
+		WHERE 1=1
+	`
+	args := []interface{}{}
+
+	if entityType != "" {
+		query += " AND entity_type = ?"
+		args = append(args, entityType)
+	}
+	if entityID != "" {
+		query += " AND entity_id = ?"
+		args = append(args, entityID)
+	}
+
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+
+	return events, nil
+}
+
+// ListEventsAfter returns audit log entries with an id above afterID, oldest
+// first, so callers can follow the log by passing the last id they saw.
+// Empty entityType matches any value; limit > 0 caps how many are returned.
+func (db *DB) ListEventsAfter(ctx context.Context, entityType string, afterID int64, limit int) ([]*models.Event, error) {
+	query := `
+		SELECT id, entity_type, entity_id, entity_name, action, actor, before_json, after_json, created_at
+		FROM events
+		WHERE id > ?
+	`
+	args := []interface{}{afterID}
+
+	if entityType != "" {
+		query += " AND entity_type = ?"
+		args = append(args, entityType)
+	}
+
+	query += " ORDER BY id"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// LatestEventID returns the id of the newest audit log entry, or 0 when the
+// log is empty. It goes up with every change, whichever process made it.
+func (db *DB) LatestEventID(ctx context.Context) (int64, error) {
+	var id sql.NullInt64
+	if err := db.read().QueryRowContext(ctx, "SELECT MAX(id) FROM events").Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get the latest event: %w", err)
+	}
+	return id.Int64, nil
+}
+
+func scanEvents(rows *sql.Rows) ([]*models.Event, error) {
+	var events []*models.Event
+	for rows.Next() {
+		e := &models.Event{}
+		var before, after sql.NullString
+		if err := rows.Scan(
+			&e.ID, &e.EntityType, &e.EntityID, &e.EntityName, &e.Action, &e.Actor, &before, &after, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return events, nil
+}
+
+// withTx runs fn inside a transaction, committing if it returns nil.
+func (db *DB) withTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	ctx, span := db.startSpan(ctx)
+	defer func() { telemetry.End(span, err) }()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestEventsRecordTaskLifecycle(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	mcpCtx := actor.With(ctx, "mcp:session-1")
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(mcpCtx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	a := &models.Task{FeatureID: f.ID, Name: "a", Description: "d", Specification: strings.Repeat("x", 500), Status: models.TaskStatusPending}
+	b := &models.Task{FeatureID: f.ID, Name: "b", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{a, b} {
+		if err := db.CreateTask(mcpCtx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", task.Name, err)
+		}
+	}
+
+	if err := db.CreateDependency(mcpCtx, b.ID, a.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	claimed, err := db.ClaimNextTask(actor.With(ctx, "orchestrator"), testClaimer, time.Minute)
+	if err != nil || claimed == nil || claimed.ID != a.ID {
+		t.Fatalf("Failed to claim task a: %v", err)
+	}
+
+	a.Name = "a2"
+	if err := db.UpdateTask(mcpCtx, a); err != nil {
+		t.Fatalf("Failed to rename task: %v", err)
+	}
+
+	if err := db.DeleteDependency(mcpCtx, b.ID, a.ID); err != nil {
+		t.Fatalf("Failed to delete dependency: %v", err)
+	}
+	if err := db.DeleteTask(ctx, b.ID); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	events, err := db.ListEvents(ctx, EntityTask, a.ID, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+
+	wantActions := []models.EventAction{models.EventCreated, models.EventStatusChanged, models.EventRenamed}
+	if len(events) != len(wantActions) {
+		t.Fatalf("expected %d events for task a, got %d", len(wantActions), len(events))
+	}
+	for i, want := range wantActions {
+		if events[i].Action != want {
+			t.Errorf("event %d: expected action %s, got %s", i, want, events[i].Action)
+		}
+	}
+	if events[1].Actor != "orchestrator" {
+		t.Errorf("expected claim to be attributed to orchestrator, got %s", events[1].Actor)
+	}
+	if events[0].Actor != "mcp:session-1" {
+		t.Errorf("expected create to be attributed to mcp:session-1, got %s", events[0].Actor)
+	}
+
+	var created taskSnippet
+	if err := json.Unmarshal(events[0].After, &created); err != nil {
+		t.Fatalf("failed to decode snippet: %v", err)
+	}
+	if len([]rune(created.Specification)) > snippetLimit+1 {
+		t.Errorf("expected specification to be truncated, got %d runes", len([]rune(created.Specification)))
+	}
+
+	var renamedBefore, renamedAfter taskSnippet
+	json.Unmarshal(events[2].Before, &renamedBefore)
+	json.Unmarshal(events[2].After, &renamedAfter)
+	if renamedBefore.Name != "a" || renamedAfter.Name != "a2" {
+		t.Errorf("expected rename a -> a2, got %s -> %s", renamedBefore.Name, renamedAfter.Name)
+	}
+
+	bEvents, err := db.ListEvents(ctx, EntityTask, b.ID, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	wantActions = []models.EventAction{models.EventCreated, models.EventDependencyAdded, models.EventDependencyRemoved, models.EventDeleted}
+	if len(bEvents) != len(wantActions) {
+		t.Fatalf("expected %d events for task b, got %d", len(wantActions), len(bEvents))
+	}
+	for i, want := range wantActions {
+		if bEvents[i].Action != want {
+			t.Errorf("event %d: expected action %s, got %s", i, want, bEvents[i].Action)
+		}
+	}
+	if !strings.Contains(string(bEvents[1].After), "f/a") {
+		t.Errorf("expected dependency event to name f/a, got %s", bEvents[1].After)
+	}
+	if bEvents[3].Actor != actor.System {
+		t.Errorf("expected delete without actor to default to %s, got %s", actor.System, bEvents[3].Actor)
+	}
+
+	recent, err := db.ListEvents(ctx, EntityTask, b.ID, 2)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Action != models.EventDependencyRemoved || recent[1].Action != models.EventDeleted {
+		t.Errorf("expected limit to keep the two most recent events in order, got %+v", recent)
+	}
+}
+
+func TestEventsRecordFeatureChanges(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	f.Description = "changed"
+	if err := db.UpdateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to update feature: %v", err)
+	}
+	if err := db.DeleteFeature(ctx, f.ID); err != nil {
+		t.Fatalf("Failed to delete feature: %v", err)
+	}
+
+	events, err := db.ListEvents(ctx, EntityFeature, f.ID, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	wantActions := []models.EventAction{models.EventCreated, models.EventUpdated, models.EventDeleted}
+	if len(events) != len(wantActions) {
+		t.Fatalf("expected %d events, got %d", len(wantActions), len(events))
+	}
+	for i, want := range wantActions {
+		if events[i].Action != want {
+			t.Errorf("event %d: expected action %s, got %s", i, want, events[i].Action)
+		}
+	}
+}
+
+func TestListEventsAfter(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	latest, err := db.ListEvents(ctx, "", "", 1)
+	if err != nil || len(latest) != 1 {
+		t.Fatalf("Failed to get latest event: %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+	}
+
+	events, err := db.ListEventsAfter(ctx, EntityTask, latest[0].ID, 2)
+	if err != nil {
+		t.Fatalf("ListEventsAfter failed: %v", err)
+	}
+	if len(events) != 2 || events[0].EntityName != "a" || events[1].EntityName != "b" {
+		t.Fatalf("expected events for a and b oldest first, got %+v", events)
+	}
+
+	events, err = db.ListEventsAfter(ctx, EntityTask, events[1].ID, 0)
+	if err != nil {
+		t.Fatalf("ListEventsAfter failed: %v", err)
+	}
+	if len(events) != 1 || events[0].EntityName != "c" {
+		t.Errorf("expected only the event for c, got %+v", events)
+	}
+
+	events, err = db.ListEventsAfter(ctx, EntityFeature, latest[0].ID, 0)
+	if err != nil {
+		t.Fatalf("ListEventsAfter failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no feature events after the feature was created, got %d", len(events))
+	}
+}
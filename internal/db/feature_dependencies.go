@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// CreateFeatureDependency makes featureID wait for dependsOnFeatureID: none
+// of its tasks are available until every task of dependsOnFeatureID is
+// completed or cancelled. ErrDependencyCycle is returned if
+// dependsOnFeatureID already waits for featureID.
+func (db *DB) CreateFeatureDependency(ctx context.Context, featureID, dependsOnFeatureID string) error {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := db.checkFeatureDependencyCycle(ctx, tx, featureID, dependsOnFeatureID); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO feature_dependencies (feature_id, depends_on_feature_id) VALUES (?, ?)`
+		if _, err := tx.ExecContext(ctx, query, featureID, dependsOnFeatureID); err != nil {
+			return fmt.Errorf("failed to create feature dependency: %w", err)
+		}
+		return recordFeatureDependencyEvent(ctx, tx, featureID, dependsOnFeatureID, models.EventDependencyAdded)
+	})
+	if err != nil {
+		return err
+	}
+
+	db.triggerChange(ctx)
+	return nil
+}
+
+// DeleteFeatureDependency removes the dependency of featureID on
+// dependsOnFeatureID.
+func (db *DB) DeleteFeatureDependency(ctx context.Context, featureID, dependsOnFeatureID string) error {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		query := `DELETE FROM feature_dependencies WHERE feature_id = ? AND depends_on_feature_id = ?`
+		res, err := tx.ExecContext(ctx, query, featureID, dependsOnFeatureID)
+		if err != nil {
+			return fmt.Errorf("failed to delete feature dependency: %w", err)
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return fmt.Errorf("feature dependency not found: %s -> %s", featureID, dependsOnFeatureID)
+		}
+
+		return recordFeatureDependencyEvent(ctx, tx, featureID, dependsOnFeatureID, models.EventDependencyRemoved)
+	})
+	if err != nil {
+		return err
+	}
+
+	db.triggerChange(ctx)
+	return nil
+}
+
+// ListFeatureDependencies returns every feature dependency with the feature
+// names resolved.
+func (db *DB) ListFeatureDependencies(ctx context.Context) ([]*models.FeatureDependency, error) {
+	query := `
+		SELECT fd.feature_id, fd.depends_on_feature_id, f.name, df.name
+		FROM feature_dependencies fd
+		JOIN features f ON fd.feature_id = f.id
+		JOIN features df ON fd.depends_on_feature_id = df.id
+		ORDER BY f.name, df.name
+	`
+	rows, err := db.read().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	var deps []*models.FeatureDependency
+	for rows.Next() {
+		d := &models.FeatureDependency{}
+		if err := rows.Scan(&d.FeatureID, &d.DependsOnFeatureID, &d.FeatureName, &d.DependsOnFeatureName); err != nil {
+			return nil, fmt.Errorf("failed to scan feature dependency: %w", err)
+		}
+		deps = append(deps, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return deps, nil
+}
+
+// checkFeatureDependencyCycle returns an error naming the cycle path if
+// making featureID depend on dependsOnFeatureID would make the feature
+// dependencies cyclic.
+func (db *DB) checkFeatureDependencyCycle(ctx context.Context, exec executor, featureID, dependsOnFeatureID string) error {
+	path, err := findDependencyPath(ctx, exec, featureDependencyEdges, dependsOnFeatureID, featureID)
+	if err != nil {
+		return err
+	}
+	if path == nil {
+		return nil
+	}
+
+	cycle := append([]string{featureID}, path...)
+	labels := make([]string, len(cycle))
+	for i, id := range cycle {
+		f, err := db.getFeature(ctx, exec, id)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			return fmt.Errorf("feature not found: %s", id)
+		}
+		labels[i] = f.Name
+	}
+
+	return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(labels, " -> "))
+}
+
+// recordFeatureDependencyEvent logs a feature dependency change against the
+// dependent feature. The feature's updated_at is bumped too, since its tasks
+// may have become available or unavailable.
+func recordFeatureDependencyEvent(ctx context.Context, exec executor, featureID, dependsOnFeatureID string, action models.EventAction) error {
+	var featureName, dependsOnName string
+	if err := exec.QueryRowContext(ctx, `SELECT name FROM features WHERE id = ?`, featureID).Scan(&featureName); err != nil {
+		return fmt.Errorf("failed to resolve feature %s: %w", featureID, err)
+	}
+	if err := exec.QueryRowContext(ctx, `SELECT name FROM features WHERE id = ?`, dependsOnFeatureID).Scan(&dependsOnName); err != nil {
+		return fmt.Errorf("failed to resolve feature %s: %w", dependsOnFeatureID, err)
+	}
+	if _, err := exec.ExecContext(ctx, `UPDATE features SET updated_at = updated_at WHERE id = ?`, featureID); err != nil {
+		return fmt.Errorf("failed to touch feature %s: %w", featureName, err)
+	}
+
+	snippet := dependencySnippet{DependsOn: dependsOnName}
+	if action == models.EventDependencyRemoved {
+		return recordEvent(ctx, exec, EntityFeature, featureID, featureName, action, snippet, nil)
+	}
+	return recordEvent(ctx, exec, EntityFeature, featureID, featureName, action, nil, snippet)
+}
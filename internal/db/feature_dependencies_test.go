@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestFeatureDependencies(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	features := make(map[string]*models.Feature)
+	tasks := make(map[string]*models.Task)
+	for _, name := range []string{"auth", "billing", "reports"} {
+		f := &models.Feature{Name: name, Description: "d", Specification: "s"}
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature %s: %v", name, err)
+		}
+		features[name] = f
+		task := &models.Task{FeatureID: f.ID, Name: name + "-task", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task for %s: %v", name, err)
+		}
+		tasks[name] = task
+	}
+
+	// billing waits for auth, reports for billing.
+	if err := db.CreateFeatureDependency(ctx, features["billing"].ID, features["auth"].ID); err != nil {
+		t.Fatalf("Failed to create billing -> auth: %v", err)
+	}
+	if err := db.CreateFeatureDependency(ctx, features["reports"].ID, features["billing"].ID); err != nil {
+		t.Fatalf("Failed to create reports -> billing: %v", err)
+	}
+
+	err = db.CreateFeatureDependency(ctx, features["auth"].ID, features["reports"].ID)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "auth -> reports -> billing -> auth") {
+		t.Errorf("expected error to name the cycle path, got %v", err)
+	}
+
+	available := func() []string {
+		t.Helper()
+		avail, err := db.GetAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("GetAvailableTasks failed: %v", err)
+		}
+		var names []string
+		for _, task := range avail {
+			names = append(names, task.Name)
+		}
+		return names
+	}
+	if got := available(); len(got) != 1 || got[0] != "auth-task" {
+		t.Fatalf("expected only auth-task to be available, got %v", got)
+	}
+	if task, err := db.ClaimTask(ctx, tasks["billing"].ID, models.Claimer{Hostname: "h"}, time.Minute); err != nil || task != nil {
+		t.Fatalf("expected billing-task not to be claimable, got %v, %v", task, err)
+	}
+
+	// Cancelled tasks count as done.
+	if err := db.UpdateTaskStatus(ctx, tasks["auth"].ID, models.TaskStatusCancelled, nil); err != nil {
+		t.Fatalf("Failed to cancel auth-task: %v", err)
+	}
+	if got := available(); len(got) != 1 || got[0] != "billing-task" {
+		t.Fatalf("expected only billing-task to be available, got %v", got)
+	}
+
+	listed, err := db.ListFeatures(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatures failed: %v", err)
+	}
+	for _, f := range listed {
+		want := map[string]string{"billing": "auth", "reports": "billing"}[f.Name]
+		if (want == "" && len(f.DependsOn) != 0) || (want != "" && (len(f.DependsOn) != 1 || f.DependsOn[0] != want)) {
+			t.Errorf("feature %s depends on %v, want %q", f.Name, f.DependsOn, want)
+		}
+	}
+
+	// Feature dependencies survive a snapshot round trip.
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if err := db.DeleteFeatureDependency(ctx, features["reports"].ID, features["billing"].ID); err != nil {
+		t.Fatalf("DeleteFeatureDependency failed: %v", err)
+	}
+	if err := db.DeleteFeatureDependency(ctx, features["reports"].ID, features["billing"].ID); err == nil {
+		t.Error("expected deleting a missing feature dependency to fail")
+	}
+	if got := available(); len(got) != 2 {
+		t.Fatalf("expected billing-task and reports-task to be available, got %v", got)
+	}
+	if err := db.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	deps, err := db.ListFeatureDependencies(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatureDependencies failed: %v", err)
+	}
+	if len(deps) != 2 || deps[1].FeatureName != "reports" || deps[1].DependsOnFeatureName != "billing" {
+		t.Errorf("expected the imported snapshot to restore reports -> billing, got %+v", deps)
+	}
+}
@@ -9,7 +9,10 @@ import (
 )
 
 func (db *DB) CreateFeature(ctx context.Context, f *models.Feature) error {
-	if err := db.createFeature(ctx, db.DB, f); err != nil {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		return db.createFeature(ctx, tx, f)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -18,14 +21,18 @@ func (db *DB) CreateFeature(ctx context.Context, f *models.Feature) error {
 }
 
 func (db *DB) GetFeature(ctx context.Context, id string) (*models.Feature, error) {
+	return db.getFeature(ctx, db.read(), id)
+}
+
+func (db *DB) getFeature(ctx context.Context, exec executor, id string) (*models.Feature, error) {
 	query := `
-		SELECT id, name, description, specification, created_at, updated_at
+		SELECT id, name, description, specification, created_at, updated_at, version
 		FROM features
 		WHERE id = ?
 	`
 	f := &models.Feature{}
-	err := db.QueryRowContext(ctx, query, id).Scan(
-		&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt,
+	err := exec.QueryRowContext(ctx, query, id).Scan(
+		&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt, &f.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -38,18 +45,18 @@ func (db *DB) GetFeature(ctx context.Context, id string) (*models.Feature, error
 }
 
 func (db *DB) GetFeatureByName(ctx context.Context, name string) (*models.Feature, error) {
-	return db.getFeatureByName(ctx, db.DB, name)
+	return db.getFeatureByName(ctx, db.read(), name)
 }
 
 func (db *DB) getFeatureByName(ctx context.Context, exec executor, name string) (*models.Feature, error) {
 	query := `
-		SELECT id, name, description, specification, created_at, updated_at
+		SELECT id, name, description, specification, created_at, updated_at, version
 		FROM features
 		WHERE name = ?
 	`
 	f := &models.Feature{}
 	err := exec.QueryRowContext(ctx, query, name).Scan(
-		&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt,
+		&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt, &f.Version,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -61,13 +68,29 @@ func (db *DB) getFeatureByName(ctx context.Context, exec executor, name string)
 	return f, nil
 }
 
+// ListFeatures returns every feature, newest first, with the progress of its
+// tasks and the features it depends on. The list comes from the read cache
+// while it is current.
 func (db *DB) ListFeatures(ctx context.Context) ([]*models.Feature, error) {
+	features, err := readCached(ctx, db, cacheFeatures, db.listFeatures)
+	if err != nil {
+		return nil, err
+	}
+	return copyFeatures(features), nil
+}
+
+func (db *DB) listFeatures(ctx context.Context) ([]*models.Feature, error) {
 	query := `
-		SELECT id, name, description, specification, created_at, updated_at
-		FROM features
-		ORDER BY created_at DESC
+		SELECT f.id, f.name, f.description, f.specification, f.created_at, f.updated_at, f.version,
+		       COALESCE(SUM(CASE WHEN t.status <> 'cancelled' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN t.status = 'completed' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN t.status = 'blocked' THEN 1 ELSE 0 END), 0)
+		FROM features f
+		LEFT JOIN tasks t ON t.feature_id = f.id
+		GROUP BY f.id, f.name, f.description, f.specification, f.created_at, f.updated_at, f.version
+		ORDER BY f.created_at DESC
 	`
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := db.read().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list features: %w", err)
 	}
@@ -76,12 +99,15 @@ func (db *DB) ListFeatures(ctx context.Context) ([]*models.Feature, error) {
 	var features []*models.Feature
 	for rows.Next() {
 		f := &models.Feature{}
+		var total, completed, blocked int
 		err := rows.Scan(
-			&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt,
+			&f.ID, &f.Name, &f.Description, &f.Specification, &f.CreatedAt, &f.UpdatedAt, &f.Version,
+			&total, &completed, &blocked,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan feature: %w", err)
 		}
+		f.Progress = models.NewFeatureProgress(total, completed, blocked)
 		features = append(features, f)
 	}
 
@@ -89,22 +115,68 @@ func (db *DB) ListFeatures(ctx context.Context) ([]*models.Feature, error) {
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
+	deps, err := db.ListFeatureDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dependsOn := make(map[string][]string)
+	for _, d := range deps {
+		dependsOn[d.FeatureID] = append(dependsOn[d.FeatureID], d.DependsOnFeatureName)
+	}
+	for _, f := range features {
+		f.DependsOn = dependsOn[f.ID]
+	}
+
 	return features, nil
 }
 
+// UpdateFeature saves f's name, description and specification. f.Version is
+// set to the new version.
 func (db *DB) UpdateFeature(ctx context.Context, f *models.Feature) error {
-	query := `
-		UPDATE features
-		SET name = ?, description = ?, specification = ?
-		WHERE id = ?
-		RETURNING updated_at
-	`
-	err := db.QueryRowContext(ctx, query, f.Name, f.Description, f.Specification, f.ID).Scan(&f.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("feature not found: %s", f.ID)
-	}
+	return db.updateFeature(ctx, f, 0)
+}
+
+// UpdateFeatureAtVersion is UpdateFeature for a caller that last saw the
+// feature at version: if it has changed since, ErrVersionConflict is returned
+// and nothing is saved.
+func (db *DB) UpdateFeatureAtVersion(ctx context.Context, f *models.Feature, version int) error {
+	return db.updateFeature(ctx, f, version)
+}
+
+// updateFeature saves f, checking the stored version first unless version is
+// 0.
+func (db *DB) updateFeature(ctx context.Context, f *models.Feature, version int) error {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := db.getFeature(ctx, tx, f.ID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("feature not found: %s", f.ID)
+		}
+
+		query := `
+			UPDATE features
+			SET name = ?, description = ?, specification = ?, version = version + 1
+			WHERE id = ? AND (? = 0 OR version = ?)
+			RETURNING updated_at, version
+		`
+		err = tx.QueryRowContext(ctx, query, f.Name, f.Description, f.Specification, f.ID, version, version).Scan(&f.UpdatedAt, &f.Version)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: feature %s is at version %d, not %d", ErrVersionConflict, before.Name, before.Version, version)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update feature: %w", err)
+		}
+
+		action := models.EventUpdated
+		if before.Name != f.Name {
+			action = models.EventRenamed
+		}
+		return recordEvent(ctx, tx, EntityFeature, f.ID, f.Name, action, snippetOfFeature(before), snippetOfFeature(f))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update feature: %w", err)
+		return err
 	}
 
 	db.triggerChange(ctx)
@@ -112,19 +184,24 @@ func (db *DB) UpdateFeature(ctx context.Context, f *models.Feature) error {
 }
 
 func (db *DB) DeleteFeature(ctx context.Context, id string) error {
-	query := `DELETE FROM features WHERE id = ?`
-	res, err := db.ExecContext(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete feature: %w", err)
-	}
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := db.getFeature(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("feature not found: %s", id)
+		}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+		query := `DELETE FROM features WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete feature: %w", err)
+		}
 
-	if rows == 0 {
-		return fmt.Errorf("feature not found: %s", id)
+		return recordEvent(ctx, tx, EntityFeature, id, before.Name, models.EventDeleted, snippetOfFeature(before), nil)
+	})
+	if err != nil {
+		return err
 	}
 
 	db.triggerChange(ctx)
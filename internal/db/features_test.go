@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -97,3 +98,60 @@ func TestFeatureCRUD(t *testing.T) {
 		t.Errorf("Expected feature to be deleted, but it still exists")
 	}
 }
+
+func TestListFeaturesProgress(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	busy := &models.Feature{Name: "busy", Description: "d", Specification: "s"}
+	empty := &models.Feature{Name: "empty", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{busy, empty} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	statuses := []models.TaskStatus{
+		models.TaskStatusCompleted, models.TaskStatusCompleted, models.TaskStatusBlocked,
+		models.TaskStatusPending, models.TaskStatusPending, models.TaskStatusCompleted,
+		models.TaskStatusCancelled,
+	}
+	for i, status := range statuses {
+		task := &models.Task{FeatureID: busy.ID, Name: fmt.Sprintf("t%d", i), Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		if status == models.TaskStatusPending {
+			continue
+		}
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			t.Fatalf("Failed to start task: %v", err)
+		}
+		summary := "done"
+		if err := db.UpdateTaskStatus(ctx, task.ID, status, &summary); err != nil {
+			t.Fatalf("Failed to set status %s: %v", status, err)
+		}
+	}
+
+	features, err := db.ListFeatures(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatures failed: %v", err)
+	}
+	progress := make(map[string]models.FeatureProgress)
+	for _, f := range features {
+		progress[f.Name] = *f.Progress
+	}
+	if got, want := progress["busy"], (models.FeatureProgress{Total: 6, Completed: 3, Blocked: 1, PercentDone: 50}); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if got := progress["empty"]; got != (models.FeatureProgress{}) {
+		t.Errorf("expected no progress for an empty feature, got %+v", got)
+	}
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// AnalyzeGraph reports the critical path through the open tasks, how much
+// work each open task holds up, and the tasks that can never run or are
+// connected to nothing. Only dependencies count as edges, not subtasks.
+func (db *DB) AnalyzeGraph(ctx context.Context) (*models.GraphAnalysis, error) {
+	tasks, err := db.ListTasks(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := db.ListDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return analyzeGraph(tasks, deps), nil
+}
+
+func isOpen(t *models.Task) bool {
+	return t.Status != models.TaskStatusCompleted && t.Status != models.TaskStatusCancelled
+}
+
+func graphRef(t *models.Task) *models.GraphTaskRef {
+	return &models.GraphTaskRef{FeatureName: t.FeatureName, Name: t.Name, Status: t.Status}
+}
+
+// analyzeGraph does the work of AnalyzeGraph. tasks come highest priority
+// first, which is how ties are broken.
+func analyzeGraph(tasks []*models.Task, deps []*models.Dependency) *models.GraphAnalysis {
+	byID := make(map[string]*models.Task, len(tasks))
+	connected := make(map[string]bool)
+	for _, t := range tasks {
+		byID[t.ID] = t
+		if t.ParentTaskID != nil {
+			connected[t.ID] = true
+			connected[*t.ParentTaskID] = true
+		}
+	}
+
+	// prereqs holds every dependency; openPrereqs and openDependents only
+	// those between open tasks, as finished work no longer holds anything up.
+	prereqs := make(map[string][]string)
+	openPrereqs := make(map[string][]string)
+	openDependents := make(map[string][]string)
+	for _, d := range deps {
+		task, dep := byID[d.TaskID], byID[d.DependsOnTaskID]
+		if task == nil || dep == nil {
+			continue
+		}
+		connected[task.ID] = true
+		connected[dep.ID] = true
+		prereqs[task.ID] = append(prereqs[task.ID], dep.ID)
+		if isOpen(task) && isOpen(dep) {
+			openPrereqs[task.ID] = append(openPrereqs[task.ID], dep.ID)
+			openDependents[dep.ID] = append(openDependents[dep.ID], task.ID)
+		}
+	}
+
+	// depth is the length of the longest open chain ending at a task, and
+	// next the prerequisite that chain continues with.
+	depth := make(map[string]int)
+	next := make(map[string]string)
+	var depthOf func(id string) int
+	depthOf = func(id string) int {
+		if d, ok := depth[id]; ok {
+			return d
+		}
+		// Cycles are rejected on insert; this only stops a corrupt graph
+		// from recursing forever.
+		depth[id] = 1
+		best := 0
+		for _, p := range openPrereqs[id] {
+			if d := depthOf(p); d > best {
+				best = d
+				next[id] = p
+			}
+		}
+		depth[id] = best + 1
+		return depth[id]
+	}
+
+	// dead marks tasks that wait, directly or not, on a cancelled task.
+	dead := make(map[string]bool)
+	var deadOf func(id string) bool
+	deadOf = func(id string) bool {
+		if d, ok := dead[id]; ok {
+			return d
+		}
+		dead[id] = false
+		for _, p := range prereqs[id] {
+			dep := byID[p]
+			if dep.Status == models.TaskStatusCancelled || (isOpen(dep) && deadOf(p)) {
+				dead[id] = true
+				break
+			}
+		}
+		return dead[id]
+	}
+
+	analysis := &models.GraphAnalysis{
+		CriticalPath: []*models.GraphTaskRef{},
+		Tasks:        []*models.TaskAnalysis{},
+		Unreachable:  []*models.GraphTaskRef{},
+		Orphaned:     []*models.GraphTaskRef{},
+	}
+	var deepest string
+	for _, t := range tasks {
+		if !isOpen(t) {
+			continue
+		}
+		analysis.OpenTasks++
+		ta := &models.TaskAnalysis{
+			GraphTaskRef:     *graphRef(t),
+			Depth:            depthOf(t.ID),
+			DirectDependents: len(openDependents[t.ID]),
+			BlockedTasks:     countBlocked(t.ID, openDependents),
+		}
+		analysis.Tasks = append(analysis.Tasks, ta)
+		if ta.Depth > analysis.CriticalPathLength {
+			analysis.CriticalPathLength = ta.Depth
+			deepest = t.ID
+		}
+		if deadOf(t.ID) {
+			analysis.Unreachable = append(analysis.Unreachable, graphRef(t))
+		}
+		if !connected[t.ID] {
+			analysis.Orphaned = append(analysis.Orphaned, graphRef(t))
+		}
+	}
+
+	// Walk the deepest chain back to its first prerequisite, then reverse
+	// it so it reads in the order the work has to happen.
+	for id := deepest; id != ""; id = next[id] {
+		analysis.CriticalPath = append(analysis.CriticalPath, graphRef(byID[id]))
+	}
+	for i, j := 0, len(analysis.CriticalPath)-1; i < j; i, j = i+1, j-1 {
+		analysis.CriticalPath[i], analysis.CriticalPath[j] = analysis.CriticalPath[j], analysis.CriticalPath[i]
+	}
+
+	sort.SliceStable(analysis.Tasks, func(i, j int) bool {
+		a, b := analysis.Tasks[i], analysis.Tasks[j]
+		if a.BlockedTasks != b.BlockedTasks {
+			return a.BlockedTasks > b.BlockedTasks
+		}
+		return a.DirectDependents > b.DirectDependents
+	})
+	return analysis
+}
+
+// countBlocked counts the open tasks reachable from id through dependents.
+func countBlocked(id string, dependents map[string][]string) int {
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[cur] {
+			if !seen[d] {
+				seen[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+	return len(seen) - 1
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// GetGraphDelta returns the part of the task graph that changed at or after
+// since: the tasks that were created, changed or archived, together with
+// the tasks whose availability they may have changed, i.e. their dependents,
+// subtasks and parents and the tasks of the features waiting for theirs. Tasks whose not_before passed in the meantime count
+// as changed too. Deleting a task or feature can free tasks the audit log
+// doesn't name, so after one the whole graph is returned with Full set.
+func (db *DB) GetGraphDelta(ctx context.Context, since time.Time) (*models.GraphDelta, error) {
+	delta := &models.GraphDelta{Since: since, AsOf: time.Now().UTC()}
+
+	var deletions int
+	query := `
+		SELECT COUNT(*) FROM events
+		WHERE entity_type IN (?, ?) AND action = ? AND ` + db.dialect.atOrAfter("created_at")
+	err := db.read().QueryRowContext(ctx, query, EntityTask, EntityFeature, models.EventDeleted, db.dialect.timestamp(since)).Scan(&deletions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for deletions: %w", err)
+	}
+	if deletions > 0 {
+		delta.Full = true
+		delta.Removed = []string{}
+		if delta.Nodes, err = db.graphNodes(ctx, ""); err != nil {
+			return nil, err
+		}
+		if delta.Edges, err = db.graphEdges(ctx, ""); err != nil {
+			return nil, err
+		}
+		return delta, nil
+	}
+
+	ids, err := db.changedGraphTasks(ctx, since, delta.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	delta.Nodes = []json.RawMessage{}
+	delta.Edges = []models.GraphEdge{}
+	delta.Removed = []string{}
+	if len(ids) == 0 {
+		return delta, nil
+	}
+
+	in := " IN (" + placeholders(len(ids)) + ")"
+	if delta.Nodes, err = db.graphNodes(ctx, "id"+in, ids...); err != nil {
+		return nil, err
+	}
+	if delta.Edges, err = db.graphEdges(ctx, "task_id"+in, ids...); err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(delta.Nodes))
+	for _, node := range delta.Nodes {
+		var n struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(node, &n); err != nil {
+			return nil, fmt.Errorf("failed to read graph node: %w", err)
+		}
+		present[n.ID] = true
+	}
+	for _, id := range ids {
+		if !present[id.(string)] {
+			delta.Removed = append(delta.Removed, id.(string))
+		}
+	}
+	return delta, nil
+}
+
+// changedGraphTasks returns the IDs of the tasks GetGraphDelta sends, some
+// of which may no longer exist.
+func (db *DB) changedGraphTasks(ctx context.Context, since, asOf time.Time) ([]any, error) {
+	at := db.dialect.atOrAfter
+	query := `
+		WITH changed(id) AS (
+			SELECT entity_id FROM events WHERE entity_type = ? AND ` + at("created_at") + `
+			UNION SELECT id FROM tasks WHERE ` + at("updated_at") + `
+			UNION SELECT t.id FROM tasks t JOIN features f ON f.id = t.feature_id WHERE ` + at("f.updated_at") + `
+			UNION SELECT id FROM tasks WHERE not_before IS NOT NULL AND ` + at("not_before") + ` AND NOT (` + at("not_before") + `)
+		)
+		SELECT id FROM changed
+		UNION SELECT d.task_id FROM dependencies d JOIN changed c ON d.depends_on_task_id = c.id
+		UNION SELECT t.id FROM tasks t JOIN changed c ON t.parent_task_id = c.id
+		UNION SELECT t.parent_task_id FROM tasks t JOIN changed c ON t.id = c.id WHERE t.parent_task_id IS NOT NULL
+		UNION SELECT t.id FROM tasks t
+			JOIN feature_dependencies fd ON fd.feature_id = t.feature_id
+			JOIN tasks gate ON gate.feature_id = fd.depends_on_feature_id
+			JOIN changed c ON c.id = gate.id`
+	s := db.dialect.timestamp(since)
+	rows, err := db.read().QueryContext(ctx, query, EntityTask, s, s, s, s, db.dialect.timestamp(asOf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find changed tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []any
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan changed task: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// graphNodes returns the graph nodes matching cond, or all of them.
+func (db *DB) graphNodes(ctx context.Context, cond string, args ...any) ([]json.RawMessage, error) {
+	query := `SELECT node_json FROM v_graph_nodes`
+	if cond != "" {
+		query += " WHERE " + cond
+	}
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := []json.RawMessage{}
+	for rows.Next() {
+		var node string
+		if err := rows.Scan(&node); err != nil {
+			return nil, fmt.Errorf("failed to scan graph node: %w", err)
+		}
+		nodes = append(nodes, json.RawMessage(node))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get graph nodes: %w", err)
+	}
+	return nodes, db.markAvailable(ctx, nodes)
+}
+
+// graphEdges returns the dependencies matching cond, or all of them.
+func (db *DB) graphEdges(ctx context.Context, cond string, args ...any) ([]models.GraphEdge, error) {
+	query := `SELECT task_id, depends_on_task_id FROM dependencies`
+	if cond != "" {
+		query += " WHERE " + cond
+	}
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph edges: %w", err)
+	}
+	defer rows.Close()
+
+	edges := []models.GraphEdge{}
+	for rows.Next() {
+		var e models.GraphEdge
+		if err := rows.Scan(&e.From, &e.To); err != nil {
+			return nil, fmt.Errorf("failed to scan graph edge: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
@@ -3,7 +3,10 @@ package db
 import (
 	"context"
 	"encoding/json"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
@@ -74,3 +77,191 @@ func TestGetGraphJSON(t *testing.T) {
 		t.Errorf("Expected feature name %s, got %s", f.Name, node.FeatureName)
 	}
 }
+
+func TestAnalyzeGraph(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, name := range []string{"a", "b", "c", "d", "e", "x", "y", "z", "w"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		ids[name] = task.ID
+	}
+	summary := "done"
+	if err := db.UpdateTaskStatus(ctx, ids["x"], models.TaskStatusCancelled, nil); err != nil {
+		t.Fatalf("Failed to cancel x: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, ids["z"], models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("Failed to start z: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, ids["z"], models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete z: %v", err)
+	}
+	// a <- b <- c, a <- d, x (cancelled) <- y, z (completed) <- w; e stands alone.
+	for _, edge := range [][2]string{{"b", "a"}, {"c", "b"}, {"d", "a"}, {"y", "x"}, {"w", "z"}} {
+		if err := db.CreateDependency(ctx, ids[edge[0]], ids[edge[1]]); err != nil {
+			t.Fatalf("Failed to create dependency %s -> %s: %v", edge[0], edge[1], err)
+		}
+	}
+
+	analysis, err := db.AnalyzeGraph(ctx)
+	if err != nil {
+		t.Fatalf("AnalyzeGraph failed: %v", err)
+	}
+
+	names := func(refs []*models.GraphTaskRef) []string {
+		var out []string
+		for _, r := range refs {
+			out = append(out, r.Name)
+		}
+		return out
+	}
+	if analysis.OpenTasks != 7 {
+		t.Errorf("Expected 7 open tasks, got %d", analysis.OpenTasks)
+	}
+	if got := names(analysis.CriticalPath); analysis.CriticalPathLength != 3 || len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("Expected critical path a, b, c, got %d %v", analysis.CriticalPathLength, got)
+	}
+	if len(analysis.Tasks) != 7 {
+		t.Fatalf("Expected 7 analyzed tasks, got %d", len(analysis.Tasks))
+	}
+	if top := analysis.Tasks[0]; top.Name != "a" || top.BlockedTasks != 3 || top.DirectDependents != 2 || top.Depth != 1 {
+		t.Errorf("Expected a to block the most work, got %+v", top)
+	}
+	if got := names(analysis.Unreachable); len(got) != 1 || got[0] != "y" {
+		t.Errorf("Expected y to be unreachable, got %v", got)
+	}
+	if got := names(analysis.Orphaned); len(got) != 1 || got[0] != "e" {
+		t.Errorf("Expected e to be orphaned, got %v", got)
+	}
+}
+
+func TestGetGraphDelta(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "graph", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	tasks := map[string]*models.Task{}
+	for _, name := range []string{"base", "dependent", "unrelated"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		tasks[name] = task
+	}
+	if err := db.CreateDependency(ctx, tasks["dependent"].ID, tasks["base"].ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	// Push everything so far into the past.
+	for _, stmt := range []string{
+		"UPDATE tasks SET updated_at = datetime('now', '-1 days')",
+		"UPDATE features SET updated_at = datetime('now', '-1 days')",
+		"UPDATE events SET created_at = datetime('now', '-1 days')",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to backdate: %v", err)
+		}
+	}
+	since := time.Now().Add(-time.Hour)
+
+	nodeIDs := func(d *models.GraphDelta) []string {
+		var ids []string
+		for _, node := range d.Nodes {
+			var n struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(node, &n); err != nil {
+				t.Fatalf("Failed to unmarshal node: %v", err)
+			}
+			ids = append(ids, n.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	sorted := func(ids ...string) []string {
+		sort.Strings(ids)
+		return ids
+	}
+
+	delta, err := db.GetGraphDelta(ctx, since)
+	if err != nil {
+		t.Fatalf("GetGraphDelta failed: %v", err)
+	}
+	if delta.Full || len(delta.Nodes) != 0 || len(delta.Edges) != 0 || len(delta.Removed) != 0 {
+		t.Errorf("Expected no changes, got %+v", delta)
+	}
+
+	// Completing base changes dependent's availability too.
+	summary := "done"
+	if err := db.UpdateTaskStatus(ctx, tasks["base"].ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("Failed to start base: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, tasks["base"].ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete base: %v", err)
+	}
+	delta, err = db.GetGraphDelta(ctx, since)
+	if err != nil {
+		t.Fatalf("GetGraphDelta failed: %v", err)
+	}
+	if got, want := nodeIDs(delta), sorted(tasks["base"].ID, tasks["dependent"].ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected base and dependent to change, got %v", got)
+	}
+	wantEdges := []models.GraphEdge{{From: tasks["dependent"].ID, To: tasks["base"].ID}}
+	if !reflect.DeepEqual(delta.Edges, wantEdges) {
+		t.Errorf("Expected edges %v, got %v", wantEdges, delta.Edges)
+	}
+	if delta.AsOf.Before(since) {
+		t.Errorf("Expected as_of after since, got %v", delta.AsOf)
+	}
+
+	// Archived tasks leave the graph.
+	if _, err := db.ArchiveCompleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ArchiveCompleted failed: %v", err)
+	}
+	delta, err = db.GetGraphDelta(ctx, since)
+	if err != nil {
+		t.Fatalf("GetGraphDelta failed: %v", err)
+	}
+	if delta.Full || !reflect.DeepEqual(delta.Removed, []string{tasks["base"].ID}) {
+		t.Errorf("Expected base to be removed, got %+v", delta)
+	}
+
+	// A deletion sends the whole graph.
+	if err := db.DeleteTask(ctx, tasks["unrelated"].ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	delta, err = db.GetGraphDelta(ctx, since)
+	if err != nil {
+		t.Fatalf("GetGraphDelta failed: %v", err)
+	}
+	if !delta.Full || !reflect.DeepEqual(nodeIDs(delta), []string{tasks["dependent"].ID}) {
+		t.Errorf("Expected the full graph of dependent alone, got %+v", delta)
+	}
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrInvalidLabel is returned for a label that is empty or too long.
+var ErrInvalidLabel = errors.New("labels must be 1 to 55 characters")
+
+// maxLabelLength matches the label column.
+const maxLabelLength = 55
+
+// normalizeLabels trims labels and drops duplicates, returning them sorted.
+func normalizeLabels(labels []string) ([]string, error) {
+	seen := make(map[string]bool, len(labels))
+	var out []string
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || len(label) > maxLabelLength {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+		}
+		if !seen[label] {
+			seen[label] = true
+			out = append(out, label)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// ValidateLabels checks labels before they are stored.
+func ValidateLabels(labels []string) error {
+	_, err := normalizeLabels(labels)
+	return err
+}
+
+// ListTaskLabels returns a task's labels in alphabetical order.
+func (db *DB) ListTaskLabels(ctx context.Context, taskID string) ([]string, error) {
+	return taskLabels(ctx, db.read(), taskID)
+}
+
+func taskLabels(ctx context.Context, exec executor, taskID string) ([]string, error) {
+	rows, err := exec.QueryContext(ctx, `SELECT label FROM task_labels WHERE task_id = ? ORDER BY label`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task labels: %w", err)
+	}
+	defer rows.Close()
+
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to scan task label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// AddTaskLabels adds labels to a task, keeping those it already has.
+func (db *DB) AddTaskLabels(ctx context.Context, taskID string, labels ...string) error {
+	labels, err := normalizeLabels(labels)
+	if err != nil {
+		return err
+	}
+	err = db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := editTaskLabels(ctx, tx, taskID, 0, true, func() error {
+			for _, label := range labels {
+				if _, err := tx.ExecContext(ctx, `
+					INSERT INTO task_labels (task_id, label) VALUES (?, ?)
+					ON CONFLICT DO NOTHING`, taskID, label); err != nil {
+					return fmt.Errorf("failed to add task label: %w", err)
+				}
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	db.triggerChange(ctx)
+	return nil
+}
+
+// RemoveTaskLabels removes labels from a task. Labels it doesn't have are
+// ignored.
+func (db *DB) RemoveTaskLabels(ctx context.Context, taskID string, labels ...string) error {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := editTaskLabels(ctx, tx, taskID, 0, true, func() error {
+			for _, label := range labels {
+				if _, err := tx.ExecContext(ctx, `DELETE FROM task_labels WHERE task_id = ? AND label = ?`,
+					taskID, strings.TrimSpace(label)); err != nil {
+					return fmt.Errorf("failed to remove task label: %w", err)
+				}
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	db.triggerChange(ctx)
+	return nil
+}
+
+// SetTaskLabels replaces a task's labels. An empty list removes them all.
+func (db *DB) SetTaskLabels(ctx context.Context, taskID string, labels []string) error {
+	_, err := db.SetTaskLabelsAtVersion(ctx, taskID, 0, labels)
+	return err
+}
+
+// SetTaskLabelsAtVersion is SetTaskLabels for a caller that last saw the
+// task at version: if it has changed since, ErrVersionConflict is returned
+// and nothing is saved. It returns the version the task is at afterwards.
+func (db *DB) SetTaskLabelsAtVersion(ctx context.Context, taskID string, version int, labels []string) (int, error) {
+	var current int
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		current, err = editTaskLabels(ctx, tx, taskID, version, true, func() error {
+			return setTaskLabels(ctx, tx, taskID, labels)
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	db.triggerChange(ctx)
+	return current, nil
+}
+
+// editTaskLabels runs edit, which changes the task's labels within tx,
+// checking the stored version first unless version is 0. If the labels
+// changed, the change is recorded in the audit log and, with bump, the task
+// moves to a new version. Callers that already moved the task to a new
+// version in tx pass false. The version the task is at afterwards is
+// returned.
+func editTaskLabels(ctx context.Context, tx *sql.Tx, taskID string, version int, bump bool, edit func() error) (int, error) {
+	var name string
+	var current int
+	err := tx.QueryRowContext(ctx, `SELECT name, version FROM tasks WHERE id = ?`, taskID).Scan(&name, &current)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get task: %w", err)
+	}
+	if version != 0 && current != version {
+		return 0, fmt.Errorf("%w: task %s is at version %d, not %d", ErrVersionConflict, name, current, version)
+	}
+	before, err := taskLabels(ctx, tx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if err := edit(); err != nil {
+		return 0, err
+	}
+	after, err := taskLabels(ctx, tx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if slices.Equal(before, after) {
+		return current, nil
+	}
+
+	if bump {
+		err = tx.QueryRowContext(ctx, `
+			UPDATE tasks SET version = version + 1
+			WHERE id = ? AND version = ?
+			RETURNING version`, taskID, current).Scan(&current)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("%w: task %s changed while its labels were being set", ErrVersionConflict, name)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update task version: %w", err)
+		}
+	}
+	// Record no labels as an empty list rather than null.
+	before, after = append([]string{}, before...), append([]string{}, after...)
+	if err := recordEvent(ctx, tx, EntityTask, taskID, name, models.EventUpdated, labelsSnippet{Labels: before}, labelsSnippet{Labels: after}); err != nil {
+		return 0, err
+	}
+	return current, nil
+}
+
+func setTaskLabels(ctx context.Context, tx *sql.Tx, taskID string, labels []string) error {
+	labels, err := normalizeLabels(labels)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM task_labels WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("failed to clear task labels: %w", err)
+	}
+	for _, label := range labels {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO task_labels (task_id, label) VALUES (?, ?)`, taskID, label); err != nil {
+			return fmt.Errorf("failed to add task label: %w", err)
+		}
+	}
+	return nil
+}
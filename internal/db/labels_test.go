@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskLabels(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	labels := func() []string {
+		t.Helper()
+		got, err := db.ListTaskLabels(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("ListTaskLabels failed: %v", err)
+		}
+		return got
+	}
+
+	if err := db.AddTaskLabels(ctx, task.ID, "ui", " backend ", "ui"); err != nil {
+		t.Fatalf("AddTaskLabels failed: %v", err)
+	}
+	if got := labels(); !reflect.DeepEqual(got, []string{"backend", "ui"}) {
+		t.Errorf("Expected [backend ui], got %v", got)
+	}
+	if err := db.RemoveTaskLabels(ctx, task.ID, "backend", "missing"); err != nil {
+		t.Fatalf("RemoveTaskLabels failed: %v", err)
+	}
+	if err := db.AddTaskLabels(ctx, task.ID, ""); err == nil {
+		t.Error("Expected an empty label to be rejected")
+	}
+	if got := labels(); !reflect.DeepEqual(got, []string{"ui"}) {
+		t.Errorf("Expected [ui], got %v", got)
+	}
+
+	// Labels go through snapshots.
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.SetTaskLabels(ctx, task.ID, []string{"b", "a"}); err != nil {
+		t.Fatalf("SetTaskLabels failed: %v", err)
+	}
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if err := db.SetTaskLabels(ctx, task.ID, nil); err != nil {
+		t.Fatalf("SetTaskLabels failed: %v", err)
+	}
+	if got := labels(); len(got) != 0 {
+		t.Errorf("Expected no labels, got %v", got)
+	}
+	if err := db.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	if got := labels(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expected [a b] after import, got %v", got)
+	}
+}
+
+func TestTaskLabelChangesAreVersionedAndLogged(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	version := func() int {
+		t.Helper()
+		got, err := db.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		return got.Version
+	}
+	events := func() []*models.Event {
+		t.Helper()
+		got, err := db.ListEvents(ctx, EntityTask, task.ID, 0)
+		if err != nil {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		return got
+	}
+
+	if err := db.AddTaskLabels(ctx, task.ID, "ui"); err != nil {
+		t.Fatalf("AddTaskLabels failed: %v", err)
+	}
+	if got := version(); got != task.Version+1 {
+		t.Errorf("expected labelling to move the task to version %d, got %d", task.Version+1, got)
+	}
+	logged := events()
+	last := logged[len(logged)-1]
+	if last.Action != models.EventUpdated || string(last.Before) != `{"labels":[]}` || string(last.After) != `{"labels":["ui"]}` {
+		t.Errorf("expected the label change to be logged, got %+v", last)
+	}
+
+	// Changes that leave the labels as they were aren't.
+	if err := db.AddTaskLabels(ctx, task.ID, "ui"); err != nil {
+		t.Fatalf("AddTaskLabels failed: %v", err)
+	}
+	if err := db.RemoveTaskLabels(ctx, task.ID, "backend"); err != nil {
+		t.Fatalf("RemoveTaskLabels failed: %v", err)
+	}
+	if got := len(events()); got != len(logged) {
+		t.Errorf("expected no events for unchanged labels, got %d more", got-len(logged))
+	}
+
+	// Fields and labels are saved together, at one new version, or not at all.
+	current, _ := db.GetTask(ctx, task.ID)
+	current.Priority = 9
+	if err := db.UpdateTaskWithLabels(ctx, current, current.Version, []string{""}); err == nil {
+		t.Fatal("expected an empty label to be rejected")
+	}
+	current, _ = db.GetTask(ctx, task.ID)
+	if current.Priority == 9 {
+		t.Error("expected the fields not to be saved when the labels are rejected")
+	}
+	before := current.Version
+	if err := db.UpdateTaskWithLabels(ctx, current, before, []string{"backend"}); err != nil {
+		t.Fatalf("UpdateTaskWithLabels failed: %v", err)
+	}
+	if current.Version != before+1 || version() != before+1 {
+		t.Errorf("expected one new version %d, got %d (stored %d)", before+1, current.Version, version())
+	}
+	if labels, _ := db.ListTaskLabels(ctx, task.ID); !reflect.DeepEqual(labels, []string{"backend"}) {
+		t.Errorf("expected labels [backend], got %v", labels)
+	}
+}
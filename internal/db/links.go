@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// LinkTask records that a task tracks an external item. Re-linking the same
+// external item points it at the new task.
+func (db *DB) LinkTask(ctx context.Context, l *models.TaskLink) error {
+	query := `
+		INSERT INTO task_links (task_id, provider, external_ref, url)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (provider, external_ref) DO UPDATE SET task_id = excluded.task_id, url = excluded.url
+		RETURNING created_at
+	`
+	err := db.QueryRowContext(ctx, query, l.TaskID, l.Provider, l.ExternalRef, l.URL).Scan(&l.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to link task: %w", err)
+	}
+	return nil
+}
+
+// GetTaskLink returns the link for an external item, or nil if there is none.
+func (db *DB) GetTaskLink(ctx context.Context, provider, externalRef string) (*models.TaskLink, error) {
+	query := `
+		SELECT task_id, provider, external_ref, url, created_at
+		FROM task_links
+		WHERE provider = ? AND external_ref = ?
+	`
+	l := &models.TaskLink{}
+	err := db.read().QueryRowContext(ctx, query, provider, externalRef).Scan(
+		&l.TaskID, &l.Provider, &l.ExternalRef, &l.URL, &l.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task link: %w", err)
+	}
+	return l, nil
+}
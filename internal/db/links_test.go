@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskLinks(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	missing, err := db.GetTaskLink(ctx, "github", "o/r#1")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no link, got %+v (%v)", missing, err)
+	}
+
+	link := &models.TaskLink{TaskID: task.ID, Provider: "github", ExternalRef: "o/r#1", URL: "https://github.com/o/r/issues/1"}
+	if err := db.LinkTask(ctx, link); err != nil {
+		t.Fatalf("LinkTask failed: %v", err)
+	}
+	// Linking again is an upsert, not a conflict.
+	if err := db.LinkTask(ctx, link); err != nil {
+		t.Fatalf("second LinkTask failed: %v", err)
+	}
+
+	got, err := db.GetTaskLink(ctx, "github", "o/r#1")
+	if err != nil || got == nil || got.TaskID != task.ID || got.URL != link.URL {
+		t.Fatalf("unexpected link: %+v (%v)", got, err)
+	}
+
+	// Links survive a snapshot round trip.
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	db2, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db2.Close()
+	if err := db2.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db2.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	imported, err := db2.GetTaskLink(ctx, "github", "o/r#1")
+	if err != nil || imported == nil || imported.TaskID != task.ID {
+		t.Errorf("unexpected imported link: %+v (%v)", imported, err)
+	}
+
+	// Deleting the task removes its links.
+	if err := db.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	got, err = db.GetTaskLink(ctx, "github", "o/r#1")
+	if err != nil || got != nil {
+		t.Errorf("expected link to be deleted with the task, got %+v (%v)", got, err)
+	}
+}
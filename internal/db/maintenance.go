@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrMaintenanceUnsupported is returned by the maintenance operations on
+// databases that are not SQLite. Postgres has its own, such as VACUUM and
+// amcheck, run by its operators.
+var ErrMaintenanceUnsupported = errors.New("maintenance commands are only supported for SQLite databases")
+
+// CheckpointModes are the SQLite WAL checkpoint modes Checkpoint accepts,
+// from least to most thorough.
+var CheckpointModes = []string{"passive", "full", "restart", "truncate"}
+
+// VacuumResult reports the size of the database file around a vacuum.
+type VacuumResult struct {
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// CheckpointResult reports what a WAL checkpoint did.
+type CheckpointResult struct {
+	// Busy is set when another connection kept the checkpoint from finishing.
+	Busy bool
+	// LogFrames is how many frames the WAL held, and Checkpointed how many of
+	// them were written back to the database. Both are -1 when the database
+	// is not in WAL mode.
+	LogFrames    int
+	Checkpointed int
+}
+
+// Vacuum rebuilds the database file, reclaiming the space left by deleted
+// rows. It needs as much free disk space as the database takes and blocks
+// writers while it runs.
+func (db *DB) Vacuum(ctx context.Context) (VacuumResult, error) {
+	if _, ok := db.dialect.(sqliteDialect); !ok {
+		return VacuumResult{}, ErrMaintenanceUnsupported
+	}
+	var result VacuumResult
+	var err error
+	if result.SizeBefore, err = db.fileSize(ctx); err != nil {
+		return result, err
+	}
+	if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+		return result, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if result.SizeAfter, err = db.fileSize(ctx); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// fileSize returns the size of the database in bytes, from its page count.
+func (db *DB) fileSize(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pages * pageSize, nil
+}
+
+// IntegrityCheck runs SQLite's integrity check and foreign key check and
+// returns the problems they find, or none for a healthy database.
+func (db *DB) IntegrityCheck(ctx context.Context) ([]string, error) {
+	if _, ok := db.dialect.(sqliteDialect); !ok {
+		return nil, ErrMaintenanceUnsupported
+	}
+
+	var problems []string
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	rows.Close()
+
+	rows, err = db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		// Tables without a rowid report NULL for it.
+		var table, parent string
+		var rowid *int64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key check: %w", err)
+		}
+		row := "a row"
+		if rowid != nil {
+			row = fmt.Sprintf("row %d", *rowid)
+		}
+		problems = append(problems, fmt.Sprintf("%s %s references a missing %s row", table, row, parent))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+	return problems, nil
+}
+
+// Checkpoint copies the write-ahead log back into the database file. The
+// mode is one of CheckpointModes; "truncate" also shrinks the WAL file,
+// which otherwise keeps the largest size it has grown to.
+func (db *DB) Checkpoint(ctx context.Context, mode string) (CheckpointResult, error) {
+	if _, ok := db.dialect.(sqliteDialect); !ok {
+		return CheckpointResult{}, ErrMaintenanceUnsupported
+	}
+	mode = strings.ToLower(mode)
+	if !slices.Contains(CheckpointModes, mode) {
+		return CheckpointResult{}, fmt.Errorf("invalid checkpoint mode %q: expected one of %s", mode, strings.Join(CheckpointModes, ", "))
+	}
+
+	var result CheckpointResult
+	var busy int
+	// The mode can't be a placeholder argument; it was checked above.
+	err := db.QueryRowContext(ctx, "PRAGMA wal_checkpoint("+strings.ToUpper(mode)+")").Scan(&busy, &result.LogFrames, &result.Checkpointed)
+	if err != nil {
+		return result, fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	result.Busy = busy != 0
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestMaintenance(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "ponder.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	for i := range 50 {
+		task := &models.Task{FeatureID: f.ID, Name: fmt.Sprintf("t%d", i), Description: "d", Specification: strings.Repeat("s", 4096), Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM tasks"); err != nil {
+		t.Fatalf("Failed to delete tasks: %v", err)
+	}
+
+	checkpoint, err := db.Checkpoint(ctx, "TRUNCATE")
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if checkpoint.Busy || checkpoint.LogFrames != checkpoint.Checkpointed {
+		t.Errorf("Expected the whole WAL to be checkpointed, got %+v", checkpoint)
+	}
+	if _, err := db.Checkpoint(ctx, "eventually"); err == nil {
+		t.Error("Expected an invalid checkpoint mode to fail")
+	}
+
+	vacuum, err := db.Vacuum(ctx)
+	if err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+	if vacuum.SizeAfter >= vacuum.SizeBefore {
+		t.Errorf("Expected vacuum to reclaim the deleted tasks' space, got %+v", vacuum)
+	}
+
+	problems, err := db.IntegrityCheck(ctx)
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("Expected a healthy database, got %v (%v)", problems, err)
+	}
+
+	// Write a dependency on tasks that don't exist behind the foreign keys'
+	// back.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	for _, stmt := range []string{
+		"PRAGMA foreign_keys = OFF",
+		"INSERT INTO dependencies (task_id, depends_on_task_id) VALUES ('missing-a', 'missing-b')",
+		"PRAGMA foreign_keys = ON",
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+	// Writes share one connection, which the check needs back.
+	conn.Close()
+	problems, err = db.IntegrityCheck(ctx)
+	if err != nil {
+		t.Fatalf("IntegrityCheck failed: %v", err)
+	}
+	if len(problems) != 2 || !strings.Contains(problems[0], "dependencies row 1 references a missing tasks row") {
+		t.Errorf("Expected the dangling dependency to be reported, got %q", problems)
+	}
+}
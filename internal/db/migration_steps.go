@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// migrationStep is the part of a migration written in Go, for changes that
+// are awkward to spell out in SQL. It runs after the migration's SQL, in the
+// same transaction.
+type migrationStep func(ctx context.Context, tx *sql.Tx) error
+
+// migrationSteps are the Go steps of migrations, by migrations directory and
+// version.
+var migrationSteps = map[string]map[int]migrationStep{
+	"migrations/sqlite": {2: rewriteOffsetTimestamps},
+}
+
+// offsetTimestampColumns are the SQLite columns snapshot imports could have
+// stored Go-formatted times in.
+var offsetTimestampColumns = []struct{ table, column string }{
+	{"features", "created_at"},
+	{"features", "updated_at"},
+	{"tasks", "created_at"},
+	{"tasks", "updated_at"},
+	{"tasks", "started_at"},
+	{"tasks", "completed_at"},
+	{"task_notes", "created_at"},
+	{"task_links", "created_at"},
+	{"archived_features", "created_at"},
+	{"archived_features", "updated_at"},
+	{"archived_features", "archived_at"},
+	{"archived_tasks", "created_at"},
+	{"archived_tasks", "updated_at"},
+	{"archived_tasks", "started_at"},
+	{"archived_tasks", "completed_at"},
+	{"archived_tasks", "archived_at"},
+	{"archived_task_notes", "created_at"},
+	{"archived_task_links", "created_at"},
+}
+
+// offsetTimestamp converts the column named by {c}, holding a time the way
+// Go prints it ("2026-01-02 16:04:05 +0100 CET"), to UTC in the form of
+// CURRENT_TIMESTAMP: the offset's sign is flipped and applied as minutes.
+const offsetTimestamp = `datetime(substr({c}, 1, 19),
+	CASE substr({c}, instr({c}, ' +') + instr({c}, ' -') + 1, 1) WHEN '+' THEN '-' ELSE '+' END ||
+	(CAST(substr({c}, instr({c}, ' +') + instr({c}, ' -') + 2, 2) AS INTEGER) * 60 +
+	 CAST(substr({c}, instr({c}, ' +') + instr({c}, ' -') + 4, 2) AS INTEGER)) || ' minutes')`
+
+// rewriteOffsetTimestamps is migration 0002_utc_timestamps on SQLite: it
+// rewrites the times older snapshot imports stored with a UTC offset, which
+// SQLite's date functions can't read, in UTC. The updated_at triggers would
+// stamp every fixed row as changed just now, so they are dropped for the
+// rewrite and recreated from their stored definitions.
+func rewriteOffsetTimestamps(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT name, sql FROM sqlite_master
+		WHERE type = 'trigger' AND name IN ('set_features_updated_at', 'set_tasks_updated_at')`)
+	if err != nil {
+		return fmt.Errorf("failed to list triggers: %w", err)
+	}
+	var triggers [][2]string
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan trigger: %w", err)
+		}
+		triggers = append(triggers, [2]string{name, def})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list triggers: %w", err)
+	}
+	for _, t := range triggers {
+		if _, err := tx.ExecContext(ctx, "DROP TRIGGER "+t[0]); err != nil {
+			return fmt.Errorf("failed to drop trigger %s: %w", t[0], err)
+		}
+	}
+
+	for _, c := range offsetTimestampColumns {
+		query := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s GLOB '* [+-][0-9][0-9][0-9][0-9]*'",
+			c.table, c.column, strings.ReplaceAll(offsetTimestamp, "{c}", c.column), c.column)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to rewrite %s.%s: %w", c.table, c.column, err)
+		}
+	}
+
+	for _, t := range triggers {
+		if _, err := tx.ExecContext(ctx, t[1]); err != nil {
+			return fmt.Errorf("failed to recreate trigger %s: %w", t[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+
+	embedsql "github.com/nick-dorsch/ponder/embed/sql"
+)
+
+// createSchemaMigrations creates the table recording which migrations a
+// database has had. It isn't part of the schema, since migrations run before
+// the schema on existing databases.
+const createSchemaMigrations = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+
+// Migration is a numbered schema change from embed/sql/migrations.
+type Migration struct {
+	Version int
+	Name    string
+	// AppliedAt is when the migration ran on this database, or was recorded
+	// as included in the schema it was created with; nil if it is pending.
+	AppliedAt *time.Time
+	sql       string
+	// step is the migration's Go step, if it has one.
+	step migrationStep
+}
+
+// migrationFile matches migration file names such as 0001_feature_dependencies.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// loadMigrations reads the migrations in dir of fsys, which must be numbered
+// from 1 without gaps.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		m := migrationFile.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		if version != len(migrations)+1 {
+			return nil, fmt.Errorf("migration %s is out of sequence: expected version %d", entry.Name(), len(migrations)+1)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: m[2], sql: string(data)})
+	}
+	return migrations, nil
+}
+
+// migrations returns the migrations known for the database's dialect.
+func (db *DB) migrations() ([]Migration, error) {
+	dir := db.dialect.migrationsDir()
+	migrations, err := loadMigrations(embedsql.Migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations {
+		migrations[i].step = migrationSteps[dir][migrations[i].Version]
+	}
+	return migrations, nil
+}
+
+// LatestSchemaVersion returns the version of the newest migration this
+// build knows.
+func (db *DB) LatestSchemaVersion() (int, error) {
+	migrations, err := db.migrations()
+	if err != nil {
+		return 0, err
+	}
+	return len(migrations), nil
+}
+
+// SchemaVersion returns the version of the newest migration applied to the
+// database, 0 if none has been.
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	exists, err := db.hasTable(ctx, "schema_migrations")
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// ListMigrations returns the migrations this build knows, with AppliedAt set
+// on those the database has had.
+func (db *DB) ListMigrations(ctx context.Context) ([]Migration, error) {
+	migrations, err := db.migrations()
+	if err != nil {
+		return nil, err
+	}
+	exists, err := db.hasTable(ctx, "schema_migrations")
+	if err != nil || !exists {
+		return migrations, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		if version >= 1 && version <= len(migrations) {
+			migrations[version-1].AppliedAt = &appliedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return migrations, nil
+}
+
+// ApplyMigrations runs the migrations the database hasn't had, in order and
+// each in its own transaction, and returns them. Databases that predate
+// schema_migrations get the old upgrades and any missing tables first. The
+// database must have been set up by Init.
+func (db *DB) ApplyMigrations(ctx context.Context) ([]Migration, error) {
+	initialized, err := db.hasTable(ctx, "features")
+	if err != nil {
+		return nil, err
+	}
+	if !initialized {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	tracked, err := db.hasTable(ctx, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		if err := db.dialect.upgrade(ctx, db); err != nil {
+			return nil, err
+		}
+		// Migrations may touch any table of the schema they started from,
+		// which databases this old can lack.
+		if err := db.Migrate(ctx, db.dialect.schema()); err != nil {
+			return nil, err
+		}
+		if _, err := db.ExecContext(ctx, createSchemaMigrations); err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	}
+
+	migrations, err := db.ListMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			continue
+		}
+		var skipped bool
+		err := db.withTx(ctx, func(tx *sql.Tx) error {
+			if lock := db.dialect.lockMigrations(); lock != "" {
+				if _, err := tx.ExecContext(ctx, lock); err != nil {
+					return err
+				}
+			}
+			// Another process opening the database may have applied it
+			// while this one waited for the transaction.
+			var n int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, m.Version).Scan(&n); err != nil {
+				return err
+			}
+			if n > 0 {
+				skipped = true
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+				return err
+			}
+			if m.step != nil {
+				if err := m.step(ctx, tx); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		if !skipped {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// recordSchemaMigrations marks every known migration as applied, for a
+// database the current schema has just created.
+func (db *DB) recordSchemaMigrations(ctx context.Context) error {
+	migrations, err := db.migrations()
+	if err != nil {
+		return err
+	}
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, createSchemaMigrations); err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+		for _, m := range migrations {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+				return fmt.Errorf("failed to record migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// hasTable reports whether the database has a table called name.
+func (db *DB) hasTable(ctx context.Context, name string) (bool, error) {
+	var n int
+	if err := db.QueryRowContext(ctx, db.dialect.tableCount(), name).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to check for table %s: %w", name, err)
+	}
+	return n > 0, nil
+}
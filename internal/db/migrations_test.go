@@ -0,0 +1,310 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestInitRecordsMigrations(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	latest, err := db.LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("LatestSchemaVersion failed: %v", err)
+	}
+	if latest < 1 {
+		t.Fatalf("expected at least one migration, got %d", latest)
+	}
+	if version, err := db.SchemaVersion(ctx); err != nil || version != latest {
+		t.Fatalf("expected a new database at schema version %d, got %d, %v", latest, version, err)
+	}
+
+	// Init again is a no-op.
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database again: %v", err)
+	}
+	applied, err := db.ApplyMigrations(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("expected nothing to apply, got %v, %v", applied, err)
+	}
+}
+
+func TestOpenAppliesMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ponder.db")
+	ctx := context.Background()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	// Make it look like a database from before migrations and feature
+	// dependencies.
+	for _, table := range []string{"schema_migrations", "feature_dependencies"} {
+		if _, err := db.ExecContext(ctx, "DROP TABLE "+table); err != nil {
+			t.Fatalf("Failed to drop %s: %v", table, err)
+		}
+	}
+	db.Close()
+
+	db, err = OpenWithOptions(path, OpenOptions{SkipMigrations: true})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	migrations, err := db.ListMigrations(ctx)
+	if err != nil {
+		t.Fatalf("ListMigrations failed: %v", err)
+	}
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			t.Errorf("expected migration %d to be pending", m.Version)
+		}
+	}
+	db.Close()
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	latest, err := db.LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("LatestSchemaVersion failed: %v", err)
+	}
+	if version, err := db.SchemaVersion(ctx); err != nil || version != latest {
+		t.Fatalf("expected Open to migrate to schema version %d, got %d, %v", latest, version, err)
+	}
+	if _, err := db.ListFeatureDependencies(ctx); err != nil {
+		t.Errorf("expected the migration to restore feature_dependencies: %v", err)
+	}
+}
+
+func TestApplyMigrationsSkipsOnesAppliedMeanwhile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ponder.db")
+	ctx := context.Background()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	latest, err := db.LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("LatestSchemaVersion failed: %v", err)
+	}
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM schema_migrations WHERE version = ?", latest).Scan(&name); err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", latest); err != nil {
+		t.Fatalf("Failed to unrecord migration: %v", err)
+	}
+
+	other, err := OpenWithOptions(path, OpenOptions{SkipMigrations: true})
+	if err != nil {
+		t.Fatalf("Failed to open database again: %v", err)
+	}
+	defer other.Close()
+
+	// db holds the write lock, as a process in the middle of the migration
+	// would, while the other one finds it pending and waits its turn.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	done := make(chan error, 1)
+	var applied []Migration
+	go func() {
+		var err error
+		applied, err = other.ApplyMigrations(ctx)
+		done <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", latest, name); err != nil {
+		t.Fatalf("Failed to record migration: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected the migration applied meanwhile to be skipped, got %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected nothing to be applied twice, got %v", applied)
+	}
+}
+
+func TestApplyMigrationsRequiresInit(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ApplyMigrations(context.Background()); err == nil {
+		t.Error("expected migrating an uninitialized database to fail")
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0001_first.sql":  {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"m/0002_second.sql": {Data: []byte("CREATE TABLE b (id INTEGER);")},
+	}
+	migrations, err := loadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+	if len(migrations) != 2 || migrations[1].Version != 2 || migrations[1].Name != "second" {
+		t.Errorf("unexpected migrations: %+v", migrations)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"gap", "m/0004_fourth.sql", "out of sequence"},
+		{"bad name", "m/third.sql", "invalid migration file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bad := fstest.MapFS{tt.file: {Data: []byte("SELECT 1;")}}
+			for name, file := range fsys {
+				bad[name] = file
+			}
+			if _, err := loadMigrations(bad, "m"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMigrateUTCTimestamps(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	// Older imports stored times in the driver's default String form.
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO features (id, name, description, specification, created_at, updated_at)
+		VALUES ('f1', 'east', 'd', 's', '2026-01-02 16:04:05 +0100 +0100', '2026-01-02 16:04:05 +0100 +0100'),
+		       ('f2', 'west', 'd', 's', '2026-01-02 10:04:05.123 -0530 -0530', '2026-01-02 15:04:05')`); err != nil {
+		t.Fatalf("Failed to insert features: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = 2"); err != nil {
+		t.Fatalf("Failed to forget migration 2: %v", err)
+	}
+	if _, err := db.ApplyMigrations(ctx); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name, created_at || '', updated_at || '' FROM features WHERE id IN ('f1', 'f2') ORDER BY name")
+	if err != nil {
+		t.Fatalf("Failed to query features: %v", err)
+	}
+	defer rows.Close()
+	got := map[string][2]string{}
+	for rows.Next() {
+		var name, created, updated string
+		if err := rows.Scan(&name, &created, &updated); err != nil {
+			t.Fatalf("Failed to scan feature: %v", err)
+		}
+		got[name] = [2]string{created, updated}
+	}
+	want := map[string][2]string{
+		"east": {"2026-01-02 15:04:05", "2026-01-02 15:04:05"},
+		"west": {"2026-01-02 15:34:05", "2026-01-02 15:04:05"},
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("Expected %s timestamps %v, got %v", name, w, got[name])
+		}
+	}
+}
+
+func TestRewriteOffsetTimestampsCoversEveryColumn(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	// A misspelled column would only surface when the migration runs on a
+	// database that still has offset timestamps.
+	for _, c := range offsetTimestampColumns {
+		if _, err := db.ExecContext(ctx, "SELECT "+c.column+" FROM "+c.table+" LIMIT 0"); err != nil {
+			t.Errorf("%s.%s is not in the schema: %v", c.table, c.column, err)
+		}
+	}
+
+	err = db.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO features (id, name, description, specification) VALUES ('f1', 'f', 'd', 's')`); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO tasks (id, feature_id, name, description, specification, created_at, updated_at, started_at, completed_at)
+			VALUES ('t1', 'f1', 't', 'd', 's', '2026-01-02 16:04:05 +0100 CET', '2026-01-02 16:04:05 +0100 CET',
+			        '2026-01-02 10:04:05 -0500 EST', '2026-01-02 15:04:05 +0000 UTC')`)
+		if err != nil {
+			return err
+		}
+		return rewriteOffsetTimestamps(ctx, tx)
+	})
+	if err != nil {
+		t.Fatalf("rewriteOffsetTimestamps failed: %v", err)
+	}
+
+	var created, updated, started, completed string
+	err = db.QueryRowContext(ctx, "SELECT created_at || '', updated_at || '', started_at || '', completed_at || '' FROM tasks WHERE id = 't1'").
+		Scan(&created, &updated, &started, &completed)
+	if err != nil {
+		t.Fatalf("Failed to query task: %v", err)
+	}
+	for name, got := range map[string]string{"created_at": created, "updated_at": updated, "started_at": started, "completed_at": completed} {
+		if got != "2026-01-02 15:04:05" {
+			t.Errorf("Expected %s to be rewritten in UTC, got %q", name, got)
+		}
+	}
+
+	var triggers int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('set_features_updated_at', 'set_tasks_updated_at')").Scan(&triggers); err != nil {
+		t.Fatalf("Failed to count triggers: %v", err)
+	}
+	if triggers != 2 {
+		t.Errorf("Expected the updated_at triggers to be recreated, found %d", triggers)
+	}
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// AddTaskNote attaches a note to a task. If n.Author is empty the actor from
+// ctx is used.
+func (db *DB) AddTaskNote(ctx context.Context, n *models.TaskNote) error {
+	if strings.TrimSpace(n.Body) == "" {
+		return fmt.Errorf("note body cannot be empty")
+	}
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	if n.Author == "" {
+		n.Author = actor.From(ctx)
+	}
+
+	query := `
+		INSERT INTO task_notes (id, task_id, author, body)
+		VALUES (?, ?, ?, ?)
+		RETURNING created_at
+	`
+	err := db.QueryRowContext(ctx, query, n.ID, n.TaskID, n.Author, n.Body).Scan(&n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add task note: %w", err)
+	}
+
+	db.triggerChange(ctx)
+	return nil
+}
+
+// ListTaskNotes returns the notes on a task, oldest first.
+func (db *DB) ListTaskNotes(ctx context.Context, taskID string) ([]*models.TaskNote, error) {
+	query := `
+		SELECT id, task_id, author, body, created_at
+		FROM task_notes
+		WHERE task_id = ?
+		ORDER BY created_at, ` + db.dialect.insertOrder()
+
+	rows, err := db.read().QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*models.TaskNote{}
+	for rows.Next() {
+		n := &models.TaskNote{}
+		if err := rows.Scan(&n.ID, &n.TaskID, &n.Author, &n.Body, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task note: %w", err)
+		}
+		notes = append(notes, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskNotes(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	if err := db.AddTaskNote(ctx, &models.TaskNote{TaskID: task.ID, Body: ""}); err == nil {
+		t.Error("expected error for empty note body")
+	}
+
+	first := &models.TaskNote{TaskID: task.ID, Author: "worker-1", Body: "Tried approach A, **did not work**."}
+	if err := db.AddTaskNote(ctx, first); err != nil {
+		t.Fatalf("AddTaskNote failed: %v", err)
+	}
+	second := &models.TaskNote{TaskID: task.ID, Body: "Use approach B."}
+	if err := db.AddTaskNote(actor.With(ctx, "mcp:abc"), second); err != nil {
+		t.Fatalf("AddTaskNote failed: %v", err)
+	}
+	if second.Author != "mcp:abc" {
+		t.Errorf("expected author to default to actor, got %s", second.Author)
+	}
+
+	notes, err := db.ListTaskNotes(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskNotes failed: %v", err)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(notes))
+	}
+	if notes[0].ID != first.ID || notes[1].ID != second.ID {
+		t.Errorf("expected notes oldest first")
+	}
+
+	// Notes survive a snapshot round trip.
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+
+	db2, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db2.Close()
+	if err := db2.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db2.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	// Importing twice must not duplicate notes.
+	if err := db2.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("second ImportSnapshot failed: %v", err)
+	}
+
+	imported, err := db2.ListTaskNotes(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskNotes failed: %v", err)
+	}
+	if len(imported) != 2 || imported[0].Body != first.Body || imported[0].Author != "worker-1" {
+		t.Errorf("unexpected imported notes: %+v", imported)
+	}
+
+	// Deleting the task removes its notes.
+	if err := db.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	notes, err = db.ListTaskNotes(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskNotes failed: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("expected notes to be deleted with the task, got %d", len(notes))
+	}
+}
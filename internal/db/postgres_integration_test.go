@@ -0,0 +1,190 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// openTestPostgres connects to the database named by PONDER_TEST_POSTGRES_DSN
+// and empties it. The DSN must point at a throwaway database.
+func openTestPostgres(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv("PONDER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PONDER_TEST_POSTGRES_DSN not set")
+	}
+
+	db, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "DROP SCHEMA public CASCADE; CREATE SCHEMA public"); err != nil {
+		t.Fatalf("Failed to reset database: %v", err)
+	}
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	// Init must be safe to run again, e.g. from a second machine.
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to re-init database: %v", err)
+	}
+	return db
+}
+
+func TestPostgresTaskLifecycle(t *testing.T) {
+	db := openTestPostgres(t)
+	ctx := context.Background()
+
+	feature := &models.Feature{Name: "pg", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, feature); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	first := &models.Task{FeatureID: feature.ID, Name: "first", Description: "d", Specification: "s", Priority: 5, TestsRequired: true, Status: models.TaskStatusPending}
+	second := &models.Task{FeatureID: feature.ID, Name: "second", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{first, second} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if err := db.CreateDependency(ctx, second.ID, first.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+	if err := db.CreateDependency(ctx, first.ID, second.ID); err == nil {
+		t.Errorf("Expected cycle to be rejected")
+	}
+
+	db.SetPriorityAging(PriorityAging{Interval: time.Minute, Step: 1, MaxBoost: 3})
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim task: %v", err)
+	}
+	if claimed == nil || claimed.ID != first.ID {
+		t.Fatalf("Expected to claim %q, got %+v", first.Name, claimed)
+	}
+	if !claimed.TestsRequired {
+		t.Errorf("Expected tests_required to round-trip")
+	}
+
+	summary := "done"
+	if err := db.UpdateTaskStatus(ctx, first.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	got, err := db.GetTask(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if got.StartedAt == nil || got.CompletedAt == nil {
+		t.Errorf("Expected started_at and completed_at to be set, got %v and %v", got.StartedAt, got.CompletedAt)
+	}
+
+	if err := db.AddTaskNote(ctx, &models.TaskNote{TaskID: second.ID, Author: "a", Body: "one"}); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if err := db.RecordTaskUsage(ctx, &models.TaskUsage{TaskID: first.ID, Model: "m", TokensIn: 10, TokensOut: 5, CostUSD: 0.5}); err != nil {
+		t.Fatalf("Failed to record usage: %v", err)
+	}
+	if _, err := db.ListTaskUsage(ctx, ""); err != nil {
+		t.Errorf("Failed to list task usage: %v", err)
+	}
+	if _, err := db.GetGraphJSON(ctx); err != nil {
+		t.Errorf("Failed to get graph: %v", err)
+	}
+
+	result, err := db.ArchiveCompleted(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+	if result.Tasks != 1 {
+		t.Errorf("Expected 1 archived task, got %d", result.Tasks)
+	}
+	totals, err := db.GetUsageTotals(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get usage totals: %v", err)
+	}
+	if totals.Runs != 1 {
+		t.Errorf("Expected archived usage to be counted, got %d runs", totals.Runs)
+	}
+	stats, err := db.GetProjectStats(ctx, 7)
+	if err != nil {
+		t.Fatalf("Failed to get project stats: %v", err)
+	}
+	if stats.Completed != 1 || stats.FinishedRuns != 1 || stats.AvgDurationSeconds == nil {
+		t.Errorf("Expected the archived completion to be counted, got %+v", stats)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshotWithOptions(ctx, path, SnapshotOptions{IncludeArchived: true}); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	for _, want := range []string{`"record_type":"task","id":"` + second.ID, `"record_type":"archived_task"`, `"record_type":"note"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected snapshot to contain %s, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestPostgresConcurrentClaims(t *testing.T) {
+	db := openTestPostgres(t)
+	ctx := context.Background()
+
+	const n = 10
+	misc, err := db.GetFeatureByName(ctx, "misc")
+	if err != nil || misc == nil {
+		t.Fatalf("Failed to get misc feature: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		task := &models.Task{FeatureID: misc.ID, Name: "task-" + string(rune('a'+i)), Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	var mu sync.Mutex
+	claimed := map[string]int{}
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				task, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+				if err != nil {
+					t.Errorf("Failed to claim task: %v", err)
+					return
+				}
+				if task == nil {
+					return
+				}
+				mu.Lock()
+				claimed[task.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != n {
+		t.Errorf("Expected %d tasks claimed, got %d", n, len(claimed))
+	}
+	for id, count := range claimed {
+		if count != 1 {
+			t.Errorf("Task %s claimed %d times", id, count)
+		}
+	}
+}
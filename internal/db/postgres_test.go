@@ -0,0 +1,39 @@
+package db
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"no placeholders", "SELECT 1", "SELECT 1"},
+		{"numbered in order", "SELECT * FROM tasks WHERE id = ? AND status = ?", "SELECT * FROM tasks WHERE id = $1 AND status = $2"},
+		{"string literal", "SELECT '?' FROM tasks WHERE id = ?", "SELECT '?' FROM tasks WHERE id = $1"},
+		{"escaped quote", "SELECT 'it''s ?' WHERE a = ?", "SELECT 'it''s ?' WHERE a = $1"},
+		{"quoted identifier", `SELECT "a?b" FROM t WHERE x = ?`, `SELECT "a?b" FROM t WHERE x = $1`},
+		{"comment", "SELECT 1 -- why?\nWHERE x = ?", "SELECT 1 -- why?\nWHERE x = $1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rebind(tt.query); got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPostgresDSN(t *testing.T) {
+	tests := map[string]bool{
+		"postgres://user@localhost/ponder":   true,
+		"postgresql://user@localhost/ponder": true,
+		".ponder/ponder.db":                  false,
+		":memory:":                           false,
+	}
+	for dsn, want := range tests {
+		if got := IsPostgresDSN(dsn); got != want {
+			t.Errorf("IsPostgresDSN(%q) = %v, want %v", dsn, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrInvalidReorder is returned when a reorder names no tasks, names a task
+// twice or names a task that doesn't exist.
+var ErrInvalidReorder = errors.New("invalid reorder")
+
+// ReorderTasks puts the named tasks of a feature in the given order, first
+// to be worked on first, in one transaction. The tasks keep the priorities
+// they already have between them, handed out highest first in list order,
+// and their positions are set to their place in the list so that tasks
+// ending up with equal priority keep the order too. Tasks that are not named
+// are left alone. The reordered tasks are returned in list order.
+func (db *DB) ReorderTasks(ctx context.Context, featureID string, names []string) ([]*models.Task, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: no tasks given", ErrInvalidReorder)
+	}
+
+	var tasks []*models.Task
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		seen := make(map[string]bool, len(names))
+		tasks = make([]*models.Task, 0, len(names))
+		for _, name := range names {
+			if seen[name] {
+				return fmt.Errorf("%w: task %s is listed twice", ErrInvalidReorder, name)
+			}
+			seen[name] = true
+			t, err := db.getTaskByName(ctx, tx, name, featureID)
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("%w: task %s not found", ErrInvalidReorder, name)
+			}
+			tasks = append(tasks, t)
+		}
+
+		priorities := make([]int, len(tasks))
+		for i, t := range tasks {
+			priorities[i] = t.Priority
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
+
+		for i, t := range tasks {
+			if t.Priority == priorities[i] && t.Position == i {
+				continue
+			}
+			before := *t
+			t.Priority, t.Position = priorities[i], i
+			err := tx.QueryRowContext(ctx,
+				"UPDATE tasks SET priority = ?, position = ? WHERE id = ? RETURNING updated_at",
+				t.Priority, t.Position, t.ID,
+			).Scan(&t.UpdatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to reorder task %s: %w", t.Name, err)
+			}
+			if err := recordEvent(ctx, tx, EntityTask, t.ID, t.Name, models.EventUpdated, snippetOfTask(&before), snippetOfTask(t)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.triggerChange(ctx)
+	return tasks, nil
+}
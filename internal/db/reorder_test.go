@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestReorderTasks(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	for name, priority := range map[string]int{"a": 9, "b": 5, "c": 5, "d": 1} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Priority: priority, Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+	}
+
+	order := func() string {
+		t.Helper()
+		tasks, err := db.GetAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("GetAvailableTasks failed: %v", err)
+		}
+		var names []string
+		for _, task := range tasks {
+			names = append(names, task.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	tasks, err := db.ReorderTasks(ctx, f.ID, []string{"c", "d", "a"})
+	if err != nil {
+		t.Fatalf("ReorderTasks failed: %v", err)
+	}
+	// c, d and a share out 9, 5 and 1; b keeps its 5 and comes before d,
+	// which now has position 1.
+	if tasks[0].Priority != 9 || tasks[1].Priority != 5 || tasks[2].Priority != 1 {
+		t.Errorf("expected priorities 9, 5, 1, got %d, %d, %d", tasks[0].Priority, tasks[1].Priority, tasks[2].Priority)
+	}
+	if got := order(); got != "c,b,d,a" {
+		t.Errorf("expected order c,b,d,a, got %s", got)
+	}
+
+	// Equal priorities are ordered by position alone.
+	if _, err := db.ReorderTasks(ctx, f.ID, []string{"d", "b"}); err != nil {
+		t.Fatalf("ReorderTasks failed: %v", err)
+	}
+	if got := order(); got != "c,d,b,a" {
+		t.Errorf("expected order c,d,b,a, got %s", got)
+	}
+
+	for name, names := range map[string][]string{
+		"empty":     nil,
+		"duplicate": {"a", "a"},
+		"unknown":   {"a", "zzz"},
+	} {
+		if _, err := db.ReorderTasks(ctx, f.ID, names); !errors.Is(err, ErrInvalidReorder) {
+			t.Errorf("%s: expected ErrInvalidReorder, got %v", name, err)
+		}
+	}
+	if got := order(); got != "c,d,b,a" {
+		t.Errorf("expected a failed reorder to change nothing, got %s", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	db2, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db2.Close()
+	if err := db2.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db2.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	f2, _ := db2.GetFeatureByName(ctx, "f")
+	b, _ := db2.GetTaskByName(ctx, "b", f2.ID)
+	if b == nil || b.Position != 1 {
+		t.Errorf("expected position 1 to survive a snapshot, got %+v", b)
+	}
+}
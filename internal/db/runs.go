@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// RecordRun stores the transcript of one agent run on a task.
+func (db *DB) RecordRun(ctx context.Context, r *models.Run) error {
+	query := `
+		INSERT INTO runs (task_id, model, prompt, output, exit_code, error, failure_class, duration_ms, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+	err := db.QueryRowContext(ctx, query,
+		r.TaskID, r.Model, r.Prompt, r.Output, r.ExitCode, r.Error, r.FailureClass, r.DurationMS, db.dialect.timestamp(r.StartedAt),
+	).Scan(&r.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	return nil
+}
+
+// ListTaskRuns returns the runs of a task, oldest first.
+func (db *DB) ListTaskRuns(ctx context.Context, taskID string) ([]*models.Run, error) {
+	query := `
+		SELECT id, task_id, model, prompt, output, exit_code, error, failure_class, duration_ms, started_at
+		FROM runs
+		WHERE task_id = ?
+		ORDER BY started_at, id
+	`
+	rows, err := db.read().QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*models.Run{}
+	for rows.Next() {
+		r := &models.Run{}
+		if err := rows.Scan(&r.ID, &r.TaskID, &r.Model, &r.Prompt, &r.Output, &r.ExitCode, &r.Error, &r.FailureClass, &r.DurationMS, &r.StartedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
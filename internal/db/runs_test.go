@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskRuns(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	runs, err := db.ListTaskRuns(ctx, task.ID)
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("expected no runs, got %v (%v)", runs, err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	failed := &models.Run{TaskID: task.ID, Model: "m1", Prompt: "do it", Output: "oops\n", ExitCode: 1, Error: "exit status 1", DurationMS: 1500, StartedAt: start}
+	passed := &models.Run{TaskID: task.ID, Model: "m2", Prompt: "do it", Output: "done\n", DurationMS: 90000, StartedAt: start.Add(time.Hour)}
+	for _, r := range []*models.Run{passed, failed} {
+		if err := db.RecordRun(ctx, r); err != nil {
+			t.Fatalf("RecordRun failed: %v", err)
+		}
+		if r.ID == 0 {
+			t.Error("expected run ID to be set")
+		}
+	}
+	if err := db.RecordRun(ctx, &models.Run{TaskID: task.ID, DurationMS: -1, StartedAt: start}); err == nil {
+		t.Error("expected error for negative duration")
+	}
+
+	runs, err = db.ListTaskRuns(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskRuns failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+	got := runs[0]
+	if got.ID != failed.ID || got.Model != "m1" || got.Output != "oops\n" || got.ExitCode != 1 || got.Error != "exit status 1" || got.DurationMS != 1500 {
+		t.Errorf("unexpected first run: %+v", got)
+	}
+	if !got.StartedAt.Equal(start) {
+		t.Errorf("started_at = %v, want %v", got.StartedAt, start)
+	}
+	if runs[1].ID != passed.ID {
+		t.Errorf("expected runs oldest first, got %d then %d", runs[0].ID, runs[1].ID)
+	}
+
+	if err := db.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if runs, _ := db.ListTaskRuns(ctx, task.ID); len(runs) != 0 {
+		t.Errorf("expected runs to be deleted with their task, got %d", len(runs))
+	}
+}
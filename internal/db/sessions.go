@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// StartRunSession records the start of an orchestrator session and sets its
+// ID.
+func (db *DB) StartRunSession(ctx context.Context, s *models.RunSession) error {
+	err := db.QueryRowContext(ctx,
+		"INSERT INTO run_sessions (started_at) VALUES (?) RETURNING id",
+		db.dialect.timestamp(s.StartedAt),
+	).Scan(&s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to start run session: %w", err)
+	}
+	return nil
+}
+
+// FinishRunSession stores the end and the totals of a session started with
+// StartRunSession.
+func (db *DB) FinishRunSession(ctx context.Context, s *models.RunSession) error {
+	if s.EndedAt == nil {
+		return fmt.Errorf("failed to finish run session %d: no end time", s.ID)
+	}
+	return db.UpdateRunSession(ctx, s)
+}
+
+// UpdateRunSession stores the totals so far of a session started with
+// StartRunSession, so a session that never finishes still has them. Its end
+// is stored too if set.
+func (db *DB) UpdateRunSession(ctx context.Context, s *models.RunSession) error {
+	sessionModels, err := json.Marshal(s.Models)
+	if err != nil {
+		return fmt.Errorf("failed to encode session models: %w", err)
+	}
+	if len(s.Models) == 0 {
+		sessionModels = []byte("{}")
+	}
+	var endedAt any
+	if s.EndedAt != nil {
+		endedAt = db.dialect.timestamp(*s.EndedAt)
+	}
+
+	result, err := db.ExecContext(ctx, `
+		UPDATE run_sessions
+		SET models = ?, tasks_attempted = ?, tasks_completed = ?, tasks_failed = ?,
+			tokens_in = ?, tokens_out = ?, cost_usd = ?, duration_ms = ?, ended_at = ?
+		WHERE id = ?`,
+		string(sessionModels), s.Attempted, s.Completed, s.Failed,
+		s.TokensIn, s.TokensOut, s.CostUSD, s.DurationMS, endedAt, s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update run session: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("failed to update run session %d: not found", s.ID)
+	}
+	return nil
+}
+
+// ListRunSessions returns the latest sessions, newest first. A limit of 0
+// returns all of them.
+func (db *DB) ListRunSessions(ctx context.Context, limit int) ([]*models.RunSession, error) {
+	query := `
+		SELECT id, models, tasks_attempted, tasks_completed, tasks_failed,
+			tokens_in, tokens_out, cost_usd, duration_ms, started_at, ended_at
+		FROM run_sessions
+		ORDER BY started_at DESC, id DESC
+	`
+	var args []any
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := db.read().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list run sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.RunSession{}
+	for rows.Next() {
+		s := &models.RunSession{}
+		var sessionModels string
+		if err := rows.Scan(&s.ID, &sessionModels, &s.Attempted, &s.Completed, &s.Failed,
+			&s.TokensIn, &s.TokensOut, &s.CostUSD, &s.DurationMS, &s.StartedAt, &s.EndedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan run session: %w", err)
+		}
+		if err := json.Unmarshal([]byte(sessionModels), &s.Models); err != nil {
+			return nil, fmt.Errorf("failed to decode session models: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
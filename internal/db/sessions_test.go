@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestRunSessions(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	finished := &models.RunSession{StartedAt: start}
+	crashed := &models.RunSession{StartedAt: start.Add(2 * time.Hour)}
+	for _, s := range []*models.RunSession{finished, crashed} {
+		if err := db.StartRunSession(ctx, s); err != nil {
+			t.Fatalf("StartRunSession failed: %v", err)
+		}
+	}
+
+	end := start.Add(90 * time.Minute)
+	finished.EndedAt = &end
+	finished.Models = map[string]int{"m1": 3, "m2": 1}
+	finished.Attempted, finished.Completed, finished.Failed = 4, 3, 1
+	finished.TokensIn, finished.TokensOut, finished.CostUSD = 1200, 300, 0.42
+	finished.DurationMS = end.Sub(start).Milliseconds()
+	if err := db.FinishRunSession(ctx, finished); err != nil {
+		t.Fatalf("FinishRunSession failed: %v", err)
+	}
+	if err := db.FinishRunSession(ctx, &models.RunSession{ID: 99, EndedAt: &end}); err == nil {
+		t.Error("expected finishing an unknown session to fail")
+	}
+	if err := db.FinishRunSession(ctx, &models.RunSession{ID: crashed.ID}); err == nil {
+		t.Error("expected finishing a session without an end time to fail")
+	}
+	crashed.Attempted, crashed.Completed = 2, 1
+	if err := db.UpdateRunSession(ctx, crashed); err != nil {
+		t.Fatalf("UpdateRunSession failed: %v", err)
+	}
+
+	sessions, err := db.ListRunSessions(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListRunSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != crashed.ID || sessions[1].ID != finished.ID {
+		t.Fatalf("expected the sessions newest first, got %+v", sessions)
+	}
+	if s := sessions[0]; s.EndedAt != nil || len(s.Models) != 0 || s.Attempted != 2 || s.Completed != 1 {
+		t.Errorf("expected the crashed session to have no end but its totals so far, got %+v", s)
+	}
+	s := sessions[1]
+	if s.EndedAt == nil || !s.EndedAt.Equal(end) || !s.StartedAt.Equal(start) {
+		t.Errorf("unexpected session times %v - %v", s.StartedAt, s.EndedAt)
+	}
+	if s.Models["m1"] != 3 || s.Models["m2"] != 1 || s.Attempted != 4 || s.Completed != 3 || s.Failed != 1 ||
+		s.TokensIn != 1200 || s.TokensOut != 300 || s.CostUSD != 0.42 || s.DurationMS != 90*60*1000 {
+		t.Errorf("unexpected session totals %+v", s)
+	}
+
+	if sessions, err := db.ListRunSessions(ctx, 1); err != nil || len(sessions) != 1 || sessions[0].ID != crashed.ID {
+		t.Errorf("expected the limit to keep the newest session, got %+v (%v)", sessions, err)
+	}
+}
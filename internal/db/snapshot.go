@@ -2,11 +2,18 @@ package db
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,7 +28,19 @@ func (db *DB) EnableAutoSnapshot(path string) {
 	})
 }
 
+// SnapshotOptions controls what ExportSnapshotWithOptions writes.
+type SnapshotOptions struct {
+	// IncludeArchived adds archived features and tasks, with their
+	// dependencies, notes and links, after the live records.
+	IncludeArchived bool
+}
+
 func (db *DB) ExportSnapshot(ctx context.Context, path string) error {
+	return db.ExportSnapshotWithOptions(ctx, path, SnapshotOptions{})
+}
+
+// ExportSnapshotWithOptions writes a snapshot to path atomically.
+func (db *DB) ExportSnapshotWithOptions(ctx context.Context, path string, opts SnapshotOptions) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create snapshot directory: %w", err)
@@ -38,22 +57,42 @@ func (db *DB) ExportSnapshot(ctx context.Context, path string) error {
 		}
 	}()
 
-	rows, err := db.QueryContext(ctx, `
+	query := `
 		SELECT json_line 
 		FROM v_snapshot_jsonl_lines 
 		ORDER BY record_order, sort_name, sort_secondary
-	`)
+	`
+	if opts.IncludeArchived {
+		query = `
+			SELECT json_line FROM (
+				SELECT * FROM v_snapshot_jsonl_lines
+				UNION ALL
+				SELECT * FROM v_snapshot_archived_jsonl_lines
+			) AS lines
+			ORDER BY record_order, sort_name, sort_secondary
+		`
+	}
+	rows, err := db.read().QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to query snapshot lines: %w", err)
 	}
 	defer rows.Close()
 
+	w := bufio.NewWriter(tempFile)
+	var compact bytes.Buffer
 	for rows.Next() {
 		var line string
 		if err := rows.Scan(&line); err != nil {
 			return fmt.Errorf("failed to scan snapshot line: %w", err)
 		}
-		if _, err := tempFile.WriteString(line + "\n"); err != nil {
+		// Postgres pads its JSON output; compacting keeps snapshots identical
+		// across backends.
+		compact.Reset()
+		if err := json.Compact(&compact, []byte(line)); err != nil {
+			return fmt.Errorf("failed to compact snapshot line: %w", err)
+		}
+		compact.WriteByte('\n')
+		if _, err := w.Write(compact.Bytes()); err != nil {
 			return fmt.Errorf("failed to write snapshot line: %w", err)
 		}
 	}
@@ -62,6 +101,9 @@ func (db *DB) ExportSnapshot(ctx context.Context, path string) error {
 		return fmt.Errorf("rows error: %w", err)
 	}
 
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
 	if err := tempFile.Sync(); err != nil {
 		return fmt.Errorf("failed to sync temp file: %w", err)
 	}
@@ -81,26 +123,267 @@ func (db *DB) ExportSnapshot(ctx context.Context, path string) error {
 	return nil
 }
 
+// ImportSnapshot merges a snapshot into the database: records in it are
+// created or updated, and records missing from it are left alone. Features
+// and tasks are matched by ID, then by name, so renames on either side
+// carry over.
 func (db *DB) ImportSnapshot(ctx context.Context, path string) error {
+	_, err := db.importSnapshot(ctx, path, "import", ImportOptions{}, false)
+	return err
+}
+
+// ImportOptions controls how ImportSnapshotWithOptions merges a snapshot.
+type ImportOptions struct {
+	// Mirror deletes the live features, tasks and dependencies the snapshot
+	// doesn't have, so that they match it. Notes, links, usage, run
+	// environments, templates and the archive are only added to.
+	Mirror bool
+	// DryRun rolls the import back instead of committing it, to see what
+	// Mirror would delete.
+	DryRun bool
+}
+
+// ImportResult lists what an import deleted, or would have with DryRun.
+type ImportResult struct {
+	// DeletedFeatures are feature names.
+	DeletedFeatures []string
+	// DeletedTasks are "feature/task" names.
+	DeletedTasks []string
+	// DeletedDependencies are "feature/task -> feature/task", the task first
+	// and what it depended on second.
+	DeletedDependencies []string
+	// Warnings are what the import skipped, such as records of types the
+	// snapshot's schema version doesn't define.
+	Warnings []string
+}
+
+// ImportSnapshotWithOptions imports a snapshot like ImportSnapshot, deleting
+// what it doesn't have with opts.Mirror.
+func (db *DB) ImportSnapshotWithOptions(ctx context.Context, path string, opts ImportOptions) (*ImportResult, error) {
+	return db.importSnapshot(ctx, path, "import", opts, false)
+}
+
+// RestoreSnapshot makes the live features, tasks, dependencies, run
+// environments and templates match a snapshot, deleting those it doesn't
+// have. Notes,
+// links, usage and the archive are only added to, as with ImportSnapshot.
+func (db *DB) RestoreSnapshot(ctx context.Context, path string) error {
+	_, err := db.importSnapshot(ctx, path, "restore", ImportOptions{Mirror: true}, true)
+	return err
+}
+
+// importSnapshot imports the snapshot at path. With opts.Mirror the live
+// features, tasks and dependencies it doesn't have are deleted, and with
+// restore the run environments and templates it doesn't have are too.
+func (db *DB) importSnapshot(ctx context.Context, path, op string, opts ImportOptions, restore bool) (*ImportResult, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to open snapshot file: %w", err)
+		return nil, fmt.Errorf("failed to open snapshot file: %w", err)
 	}
 	defer file.Close()
 
+	if !opts.DryRun {
+		if err := db.backupBefore(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	result := &ImportResult{}
+	if err := db.applySnapshot(ctx, tx, file, path, opts.Mirror, restore, result); err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	db.triggerChange(ctx)
+	return result, nil
+}
+
+// SnapshotSchemaVersion is the version of the snapshot format ExportSnapshot
+// writes into the meta line. Version 2 added feature_dependency records.
+const SnapshotSchemaVersion = 2
+
+// snapshotParser imports one snapshot record.
+type snapshotParser func(imp *snapshotImport, ctx context.Context, line []byte) error
+
+// snapshotParsers registers, for each snapshot schema version, the record
+// types it added and how to import them. A snapshot is read with the parsers
+// of its version and those before it. Records of other types, such as those
+// a newer ponder added, are skipped with a warning rather than failing the
+// import.
+var snapshotParsers = map[int]map[string]snapshotParser{
+	1: {
+		"feature":             (*snapshotImport).importFeature,
+		"task":                (*snapshotImport).importTask,
+		"dependency":          (*snapshotImport).importDependency,
+		"note":                (*snapshotImport).importNote,
+		"link":                (*snapshotImport).importLink,
+		"environment":         (*snapshotImport).importEnvironment,
+		"template":            (*snapshotImport).importTemplate,
+		"archived_task":       (*snapshotImport).importArchivedTask,
+		"archived_dependency": (*snapshotImport).importArchivedDependency,
+		"archived_note":       (*snapshotImport).importArchivedNote,
+		"archived_link":       (*snapshotImport).importArchivedLink,
+		"archived_feature":    (*snapshotImport).importArchivedFeature,
+	},
+	2: {
+		"feature_dependency": (*snapshotImport).importFeatureDependency,
+	},
+}
+
+// snapshotParsersFor returns the parsers for a snapshot of schema version.
+func snapshotParsersFor(version int) map[string]snapshotParser {
+	parsers := make(map[string]snapshotParser)
+	for v, added := range snapshotParsers {
+		if v <= version {
+			maps.Copy(parsers, added)
+		}
+	}
+	return parsers
+}
+
+// snapshotSchemaVersion reads the schema version from the meta line of a
+// snapshot. Snapshots without one are version 1.
+func snapshotSchemaVersion(lines [][]byte) (int, error) {
+	for _, line := range lines {
+		var meta struct {
+			RecordType    string          `json:"record_type"`
+			SchemaVersion json.RawMessage `json:"schema_version"`
+		}
+		if json.Unmarshal(line, &meta) != nil || meta.RecordType != "meta" {
+			continue
+		}
+		if len(meta.SchemaVersion) == 0 {
+			return 1, nil
+		}
+		// Versions are written as strings, but numbers are accepted too.
+		version, err := strconv.Atoi(strings.Trim(string(meta.SchemaVersion), `"`))
+		if err != nil || version < 1 {
+			return 0, fmt.Errorf("invalid snapshot schema_version %s", meta.SchemaVersion)
+		}
+		return version, nil
+	}
+	return 1, nil
+}
+
+// snapshotImport holds what applySnapshot has learned so far while it
+// imports records one by one.
+type snapshotImport struct {
+	db *DB
+	tx *sql.Tx
+
 	// Maps to translate snapshot IDs to local IDs
-	featureSnapshotIDToLocalID := make(map[string]string)
-	taskSnapshotIDToLocalID := make(map[string]string)
+	featureSnapshotIDToLocalID map[string]string
+	taskSnapshotIDToLocalID    map[string]string
 
-	// Maps to look up existing records by name
-	featureNameMap := make(map[string]string)
-	taskNameMap := make(map[string]string)
+	// Maps to look up records by their name in the snapshot, for the
+	// records that refer to them
+	featureNameMap map[string]string
+	taskNameMap    map[string]string
+
+	// Features and tasks are matched by ID first, so one renamed on either
+	// side keeps its local ID, and with it its history and dependencies.
+	// Only records whose ID is unknown locally fall back to their name, and
+	// never to a local record the snapshot has under its own ID.
+	inSnapshot    map[string]bool
+	localFeatures map[string]string    // id -> name
+	localTasks    map[string]localTask // id -> task
+	localTaskIDs  map[localTask]string // task -> id
+
+	// Parents are linked once every task is imported, since a subtask can
+	// sort before its parent.
+	parentLinks []parentLink
+
+	// The local IDs of the features and tasks in the snapshot, for mirror
+	// to delete the others.
+	keptFeatures map[string]bool
+	keptTasks    map[string]bool
+
+	// Rows of labels, notes and dependencies, inserted in bulk by loadRows
+	// once every task is imported.
+	labels       [][]any
+	notes        [][]any
+	dependencies [][]any
+
+	// Statements prepared by exec, by query.
+	stmts map[string]*sql.Stmt
+}
+
+// parentLink is a subtask to link to its parent, by the parent's snapshot
+// ID or its "feature/task" name.
+type parentLink struct {
+	taskID, parentID, parentName string
+}
+
+// applySnapshot writes the records read from file within tx, adding what
+// mirror deletes and any warnings to result.
+func (db *DB) applySnapshot(ctx context.Context, tx *sql.Tx, file io.Reader, path string, mirror, restore bool, result *ImportResult) error {
+	var err error
+
+	lines, err := readSnapshotLines(file)
+	if err != nil {
+		return err
+	}
+
+	version, err := snapshotSchemaVersion(lines)
+	if err != nil {
+		return err
+	}
+	if version > SnapshotSchemaVersion {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"snapshot schema version %d is newer than this ponder reads (%d); records it doesn't know are skipped",
+			version, SnapshotSchemaVersion))
+	}
+	parsers := snapshotParsersFor(version)
+
+	imp := &snapshotImport{
+		db:                         db,
+		tx:                         tx,
+		featureSnapshotIDToLocalID: make(map[string]string),
+		taskSnapshotIDToLocalID:    make(map[string]string),
+		featureNameMap:             make(map[string]string),
+		taskNameMap:                make(map[string]string),
+		inSnapshot:                 snapshotIDs(lines),
+		localFeatures:              make(map[string]string),
+		localTasks:                 make(map[string]localTask),
+		localTaskIDs:               make(map[localTask]string),
+		keptFeatures:               make(map[string]bool),
+		keptTasks:                  make(map[string]bool),
+		stmts:                      make(map[string]*sql.Stmt),
+	}
+	defer imp.closeStatements()
+
+	// Dependencies hold nothing the snapshot doesn't, so mirror clears them
+	// first instead of deleting those missing from it, as restore does run
+	// environments and templates; that also keeps stale dependencies from
+	// tripping the cycle check.
+	var oldDependencies []string
+	if mirror {
+		oldDependencies, err = listDependencyNames(ctx, tx)
+		if err != nil {
+			return err
+		}
+		tables := []string{"dependencies", "feature_dependencies"}
+		if restore {
+			tables = append(tables, "run_environments", "task_templates")
+		}
+		for _, table := range tables {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+	}
 
 	// Load existing features
 	err = func() error {
@@ -114,7 +397,8 @@ func (db *DB) ImportSnapshot(ctx context.Context, path string) error {
 			if err := rows.Scan(&id, &name); err != nil {
 				return err
 			}
-			featureNameMap[name] = id
+			imp.featureNameMap[name] = id
+			imp.localFeatures[id] = name
 		}
 		return rows.Err()
 	}()
@@ -124,17 +408,20 @@ func (db *DB) ImportSnapshot(ctx context.Context, path string) error {
 
 	// Load existing tasks
 	err = func() error {
-		rows, err := tx.QueryContext(ctx, "SELECT t.id, t.name, f.name FROM tasks t JOIN features f ON t.feature_id = f.id")
+		rows, err := tx.QueryContext(ctx, "SELECT t.id, t.name, f.id, f.name FROM tasks t JOIN features f ON t.feature_id = f.id")
 		if err != nil {
 			return fmt.Errorf("failed to query tasks: %w", err)
 		}
 		defer rows.Close()
 		for rows.Next() {
-			var id, name, featureName string
-			if err := rows.Scan(&id, &name, &featureName); err != nil {
+			var id, name, featureID, featureName string
+			if err := rows.Scan(&id, &name, &featureID, &featureName); err != nil {
 				return err
 			}
-			taskNameMap[featureName+"/"+name] = id
+			imp.taskNameMap[featureName+"/"+name] = id
+			task := localTask{featureID: featureID, name: name}
+			imp.localTasks[id] = task
+			imp.localTaskIDs[task] = id
 		}
 		return rows.Err()
 	}()
@@ -142,162 +429,795 @@ func (db *DB) ImportSnapshot(ctx context.Context, path string) error {
 		return err
 	}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
+	skipped := make(map[string]int)
+	for _, line := range lines {
 		var base struct {
 			RecordType string `json:"record_type"`
 		}
 		if err := json.Unmarshal(line, &base); err != nil {
 			return fmt.Errorf("failed to unmarshal base record: %w", err)
 		}
+		if base.RecordType == "meta" {
+			continue
+		}
 
-		switch base.RecordType {
-		case "meta":
-			// Skip meta
-		case "feature":
-			var f models.Feature
-			if err := json.Unmarshal(line, &f); err != nil {
-				return fmt.Errorf("failed to unmarshal feature: %w", err)
-			}
+		parse, ok := parsers[base.RecordType]
+		if !ok {
+			skipped[base.RecordType]++
+			continue
+		}
+		if err := parse(imp, ctx, line); err != nil {
+			return err
+		}
+	}
+	for _, recordType := range slices.Sorted(maps.Keys(skipped)) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"skipped %d %q records, which schema version %d doesn't define", skipped[recordType], recordType, version))
+	}
 
-			localID, exists := featureNameMap[f.Name]
-			if exists {
-				_, err = tx.ExecContext(ctx, `
-					UPDATE features 
-					SET description = ?, specification = ?, created_at = ?, updated_at = ?
-					WHERE id = ?`,
-					f.Description, f.Specification, f.CreatedAt, f.UpdatedAt, localID)
-			} else {
-				if f.ID == "" {
-					f.ID = uuid.New().String()
-				}
-				localID = f.ID
-				_, err = tx.ExecContext(ctx, `
-					INSERT INTO features (id, name, description, specification, created_at, updated_at)
-					VALUES (?, ?, ?, ?, ?, ?)`,
-					f.ID, f.Name, f.Description, f.Specification, f.CreatedAt, f.UpdatedAt)
-			}
-			if err != nil {
-				return fmt.Errorf("failed to sync feature %s: %w", f.Name, err)
-			}
-			if f.ID != "" {
-				featureSnapshotIDToLocalID[f.ID] = localID
-			}
-			featureNameMap[f.Name] = localID
-
-		case "task":
-			var t struct {
-				ID                string            `json:"id"`
-				Name              string            `json:"name"`
-				Description       string            `json:"description"`
-				Specification     string            `json:"specification"`
-				FeatureName       string            `json:"feature_name"`
-				TestsRequired     bool              `json:"tests_required"`
-				Priority          int               `json:"priority"`
-				Status            models.TaskStatus `json:"status"`
-				CompletionSummary *string           `json:"completion_summary"`
-				CreatedAt         time.Time         `json:"created_at"`
-				UpdatedAt         time.Time         `json:"updated_at"`
-				StartedAt         *time.Time        `json:"started_at"`
-				CompletedAt       *time.Time        `json:"completed_at"`
-			}
-			if err := json.Unmarshal(line, &t); err != nil {
-				return fmt.Errorf("failed to unmarshal task: %w", err)
-			}
+	if err := imp.loadRows(ctx); err != nil {
+		return err
+	}
 
-			featureID, ok := featureNameMap[t.FeatureName]
-			if !ok {
-				return fmt.Errorf("feature not found for task %s: %s", t.Name, t.FeatureName)
-			}
+	for _, link := range imp.parentLinks {
+		parentID, ok := imp.taskSnapshotIDToLocalID[link.parentID]
+		if !ok {
+			parentID, ok = imp.taskNameMap[link.parentName]
+		}
+		if !ok {
+			return fmt.Errorf("parent task not found for task %s: %s", link.taskID, link.parentName)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET parent_task_id = ? WHERE id = ?", parentID, link.taskID); err != nil {
+			return fmt.Errorf("failed to link task %s to its parent: %w", link.taskID, err)
+		}
+	}
 
-			localID, exists := taskNameMap[t.FeatureName+"/"+t.Name]
-			testsRequired := 0
-			if t.TestsRequired {
-				testsRequired = 1
-			}
+	if mirror {
+		if result.DeletedTasks, err = deleteMissing(ctx, tx, EntityTask, "tasks", imp.keptTasks); err != nil {
+			return err
+		}
+		if result.DeletedFeatures, err = deleteMissing(ctx, tx, EntityFeature, "features", imp.keptFeatures); err != nil {
+			return err
+		}
 
-			if exists {
-				_, err = tx.ExecContext(ctx, `
-					UPDATE tasks SET 
-						feature_id = ?, description = ?, specification = ?, priority = ?, 
-						tests_required = ?, status = ?, completion_summary = ?, created_at = ?, 
-						updated_at = ?, started_at = ?, completed_at = ?
-					WHERE id = ?`,
-					featureID, t.Description, t.Specification, t.Priority,
-					testsRequired, t.Status, t.CompletionSummary, t.CreatedAt,
-					t.UpdatedAt, t.StartedAt, t.CompletedAt, localID)
-			} else {
-				if t.ID == "" {
-					t.ID = uuid.New().String()
-				}
-				localID = t.ID
-				_, err = tx.ExecContext(ctx, `
-					INSERT INTO tasks (
-						id, feature_id, name, description, specification, priority, 
-						tests_required, status, completion_summary, created_at, 
-						updated_at, started_at, completed_at
-					) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-					t.ID, featureID, t.Name, t.Description, t.Specification, t.Priority,
-					testsRequired, t.Status, t.CompletionSummary, t.CreatedAt,
-					t.UpdatedAt, t.StartedAt, t.CompletedAt)
-			}
-			if err != nil {
-				return fmt.Errorf("failed to sync task %s: %w", t.Name, err)
-			}
-			if t.ID != "" {
-				taskSnapshotIDToLocalID[t.ID] = localID
-			}
-			taskNameMap[t.FeatureName+"/"+t.Name] = localID
-
-		case "dependency":
-			var d struct {
-				TaskID                   string `json:"task_id"`
-				TaskName                 string `json:"task_name"`
-				TaskFeatureName          string `json:"task_feature_name"`
-				DependsOnTaskID          string `json:"depends_on_task_id"`
-				DependsOnTaskName        string `json:"depends_on_task_name"`
-				DependsOnTaskFeatureName string `json:"depends_on_task_feature_name"`
+		// Dependencies were cleared and re-added from the snapshot, so those
+		// that are gone now are the ones it doesn't have.
+		newDependencies, err := listDependencyNames(ctx, tx)
+		if err != nil {
+			return err
+		}
+		kept := make(map[string]bool, len(newDependencies))
+		for _, d := range newDependencies {
+			kept[d] = true
+		}
+		for _, d := range oldDependencies {
+			if !kept[d] {
+				result.DeletedDependencies = append(result.DeletedDependencies, d)
 			}
-			if err := json.Unmarshal(line, &d); err != nil {
-				return fmt.Errorf("failed to unmarshal dependency: %w", err)
+		}
+	}
+
+	return recordEvent(ctx, tx, EntitySnapshot, path, filepath.Base(path), models.EventImported, nil, nil)
+}
+
+// importFeature imports a feature record.
+func (imp *snapshotImport) importFeature(ctx context.Context, line []byte) error {
+	var err error
+	var f models.Feature
+	if err := json.Unmarshal(line, &f); err != nil {
+		return fmt.Errorf("failed to unmarshal feature: %w", err)
+	}
+
+	localID, exists := f.ID, false
+	if _, ok := imp.localFeatures[f.ID]; ok && f.ID != "" {
+		exists = true
+	} else if id, ok := imp.featureNameMap[f.Name]; ok && !imp.inSnapshot[id] {
+		localID, exists = id, true
+	}
+
+	// A renamed record may take the name of another one, which is
+	// either renamed later on or not in the snapshot at all.
+	if holder, ok := imp.featureNameMap[f.Name]; ok && holder != localID {
+		if _, ok := imp.localFeatures[holder]; ok {
+			displaced := displacedName(f.Name, holder)
+			if _, err := imp.exec(ctx, "UPDATE features SET name = ? WHERE id = ?", displaced, holder); err != nil {
+				return fmt.Errorf("failed to rename feature %s: %w", f.Name, err)
 			}
+			imp.localFeatures[holder] = displaced
+			imp.featureNameMap[displaced] = holder
+		}
+	}
 
-			localTaskID, ok := taskSnapshotIDToLocalID[d.TaskID]
-			if !ok {
-				localTaskID, ok = taskNameMap[d.TaskFeatureName+"/"+d.TaskName]
+	if exists {
+		if oldName := imp.localFeatures[localID]; oldName != f.Name {
+			if err := recordEvent(ctx, imp.tx, EntityFeature, localID, f.Name, models.EventRenamed,
+				map[string]string{"name": oldName}, map[string]string{"name": f.Name}); err != nil {
+				return err
 			}
-			if !ok {
-				return fmt.Errorf("task not found for dependency: %s/%s", d.TaskFeatureName, d.TaskName)
+			if imp.featureNameMap[oldName] == localID {
+				delete(imp.featureNameMap, oldName)
 			}
+			imp.localFeatures[localID] = f.Name
+		}
+		_, err = imp.exec(ctx, `
+			UPDATE features 
+			SET name = ?, description = ?, specification = ?, created_at = ?, updated_at = ?
+			WHERE id = ?`,
+			f.Name, f.Description, f.Specification, imp.db.dialect.timestamp(f.CreatedAt), imp.db.dialect.timestamp(f.UpdatedAt), localID)
+	} else {
+		if f.ID == "" {
+			f.ID = uuid.New().String()
+		}
+		localID = f.ID
+		_, err = imp.exec(ctx, `
+			INSERT INTO features (id, name, description, specification, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			f.ID, f.Name, f.Description, f.Specification, imp.db.dialect.timestamp(f.CreatedAt), imp.db.dialect.timestamp(f.UpdatedAt))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync feature %s: %w", f.Name, err)
+	}
+	if f.ID != "" {
+		imp.featureSnapshotIDToLocalID[f.ID] = localID
+	}
+	imp.featureNameMap[f.Name] = localID
+	imp.keptFeatures[localID] = true
+	return nil
+}
+
+// importTask imports a task record.
+func (imp *snapshotImport) importTask(ctx context.Context, line []byte) error {
+	var err error
+	var t struct {
+		ID                string            `json:"id"`
+		Name              string            `json:"name"`
+		Description       string            `json:"description"`
+		Specification     string            `json:"specification"`
+		FeatureName       string            `json:"feature_name"`
+		TestsRequired     bool              `json:"tests_required"`
+		Priority          int               `json:"priority"`
+		Status            models.TaskStatus `json:"status"`
+		CompletionSummary *string           `json:"completion_summary"`
+		BlockedReason     *string           `json:"blocked_reason"`
+		EstimateMinutes   *int              `json:"estimate_minutes"`
+		Labels            []string          `json:"labels"`
+		CreatedAt         time.Time         `json:"created_at"`
+		UpdatedAt         time.Time         `json:"updated_at"`
+		StartedAt         *time.Time        `json:"started_at"`
+		CompletedAt       *time.Time        `json:"completed_at"`
+		NotBefore         *time.Time        `json:"not_before"`
+		DueAt             *time.Time        `json:"due_at"`
+		ParentTaskID      *string           `json:"parent_task_id"`
+		ParentName        *string           `json:"parent_task_name"`
+		ParentFeatureName *string           `json:"parent_task_feature_name"`
+		SubtaskOrder      string            `json:"subtask_order"`
+		Position          int               `json:"position"`
+	}
+	if err := json.Unmarshal(line, &t); err != nil {
+		return fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	featureID, ok := imp.featureNameMap[t.FeatureName]
+	if !ok {
+		return fmt.Errorf("feature not found for task %s: %s", t.Name, t.FeatureName)
+	}
 
-			localDependsOnID, ok := taskSnapshotIDToLocalID[d.DependsOnTaskID]
-			if !ok {
-				localDependsOnID, ok = taskNameMap[d.DependsOnTaskFeatureName+"/"+d.DependsOnTaskName]
+	localID, exists := t.ID, false
+	key := localTask{featureID: featureID, name: t.Name}
+	if _, ok := imp.localTasks[t.ID]; ok && t.ID != "" {
+		exists = true
+	} else if id, ok := imp.localTaskIDs[key]; ok && !imp.inSnapshot[id] {
+		localID, exists = id, true
+	}
+
+	if holder, ok := imp.localTaskIDs[key]; ok && holder != localID {
+		displaced := localTask{featureID: featureID, name: displacedName(t.Name, holder)}
+		if _, err := imp.exec(ctx, "UPDATE tasks SET name = ? WHERE id = ?", displaced.name, holder); err != nil {
+			return fmt.Errorf("failed to rename task %s: %w", t.Name, err)
+		}
+		imp.localTasks[holder] = displaced
+		imp.localTaskIDs[displaced] = holder
+		delete(imp.localTaskIDs, key)
+	}
+	if exists {
+		if old := imp.localTasks[localID]; old != key {
+			if old.name != t.Name {
+				if err := recordEvent(ctx, imp.tx, EntityTask, localID, t.Name, models.EventRenamed,
+					map[string]string{"name": old.name}, map[string]string{"name": t.Name}); err != nil {
+					return err
+				}
 			}
-			if !ok {
-				return fmt.Errorf("dependent task not found for dependency: %s/%s", d.DependsOnTaskFeatureName, d.DependsOnTaskName)
+			if imp.localTaskIDs[old] == localID {
+				delete(imp.localTaskIDs, old)
 			}
+			imp.localTasks[localID] = key
+			imp.localTaskIDs[key] = localID
+		}
+	}
 
-			_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO dependencies (task_id, depends_on_task_id) VALUES (?, ?)", localTaskID, localDependsOnID)
-			if err != nil {
-				return fmt.Errorf("failed to insert dependency %s -> %s: %w", d.TaskName, d.DependsOnTaskName, err)
-			}
+	testsRequired := 0
+	if t.TestsRequired {
+		testsRequired = 1
+	}
+	subtaskOrder, err := models.ParseSubtaskOrder(t.SubtaskOrder)
+	if err != nil {
+		return fmt.Errorf("invalid task %s: %w", t.Name, err)
+	}
+
+	if exists {
+		_, err = imp.exec(ctx, `
+			UPDATE tasks SET 
+				feature_id = ?, name = ?, description = ?, specification = ?, priority = ?, 
+				tests_required = ?, status = ?, completion_summary = ?, created_at = ?, 
+				updated_at = ?, started_at = ?, completed_at = ?, not_before = ?, due_at = ?,
+				parent_task_id = NULL, subtask_order = ?, position = ?, blocked_reason = ?, estimate_minutes = ?
+			WHERE id = ?`,
+			featureID, t.Name, t.Description, t.Specification, t.Priority,
+			testsRequired, t.Status, t.CompletionSummary, imp.db.dialect.timestamp(t.CreatedAt),
+			imp.db.dialect.timestamp(t.UpdatedAt), imp.db.timestampArg(t.StartedAt), imp.db.timestampArg(t.CompletedAt),
+			imp.db.timestampArg(t.NotBefore), imp.db.timestampArg(t.DueAt), subtaskOrder, t.Position, t.BlockedReason, t.EstimateMinutes, localID)
+	} else {
+		if t.ID == "" {
+			t.ID = uuid.New().String()
+		}
+		localID = t.ID
+		_, err = imp.exec(ctx, `
+			INSERT INTO tasks (
+				id, feature_id, name, description, specification, priority, 
+				tests_required, status, completion_summary, created_at, 
+				updated_at, started_at, completed_at, not_before, due_at, subtask_order, position, blocked_reason, estimate_minutes
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			t.ID, featureID, t.Name, t.Description, t.Specification, t.Priority,
+			testsRequired, t.Status, t.CompletionSummary, imp.db.dialect.timestamp(t.CreatedAt),
+			imp.db.dialect.timestamp(t.UpdatedAt), imp.db.timestampArg(t.StartedAt), imp.db.timestampArg(t.CompletedAt),
+			imp.db.timestampArg(t.NotBefore), imp.db.timestampArg(t.DueAt), subtaskOrder, t.Position, t.BlockedReason, t.EstimateMinutes)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync task %s: %w", t.Name, err)
+	}
+	labels, err := normalizeLabels(t.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to sync labels of task %s: %w", t.Name, err)
+	}
+	if exists {
+		if _, err := imp.exec(ctx, "DELETE FROM task_labels WHERE task_id = ?", localID); err != nil {
+			return fmt.Errorf("failed to sync labels of task %s: %w", t.Name, err)
+		}
+	}
+	for _, label := range labels {
+		imp.labels = append(imp.labels, []any{localID, label})
+	}
+	if t.ID != "" {
+		imp.taskSnapshotIDToLocalID[t.ID] = localID
+	}
+	imp.taskNameMap[t.FeatureName+"/"+t.Name] = localID
+	imp.keptTasks[localID] = true
+	if t.ParentTaskID != nil || t.ParentName != nil {
+		link := parentLink{taskID: localID}
+		if t.ParentTaskID != nil {
+			link.parentID = *t.ParentTaskID
+		}
+		if t.ParentName != nil && t.ParentFeatureName != nil {
+			link.parentName = *t.ParentFeatureName + "/" + *t.ParentName
 		}
+		imp.parentLinks = append(imp.parentLinks, link)
 	}
+	return nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("scanner error: %w", err)
+// importDependency imports a dependency record.
+func (imp *snapshotImport) importDependency(ctx context.Context, line []byte) error {
+	var d struct {
+		TaskID                   string `json:"task_id"`
+		TaskName                 string `json:"task_name"`
+		TaskFeatureName          string `json:"task_feature_name"`
+		DependsOnTaskID          string `json:"depends_on_task_id"`
+		DependsOnTaskName        string `json:"depends_on_task_name"`
+		DependsOnTaskFeatureName string `json:"depends_on_task_feature_name"`
+	}
+	if err := json.Unmarshal(line, &d); err != nil {
+		return fmt.Errorf("failed to unmarshal dependency: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	localTaskID, ok := imp.taskSnapshotIDToLocalID[d.TaskID]
+	if !ok {
+		localTaskID, ok = imp.taskNameMap[d.TaskFeatureName+"/"+d.TaskName]
+	}
+	if !ok {
+		return fmt.Errorf("task not found for dependency: %s/%s", d.TaskFeatureName, d.TaskName)
+	}
+
+	localDependsOnID, ok := imp.taskSnapshotIDToLocalID[d.DependsOnTaskID]
+	if !ok {
+		localDependsOnID, ok = imp.taskNameMap[d.DependsOnTaskFeatureName+"/"+d.DependsOnTaskName]
+	}
+	if !ok {
+		return fmt.Errorf("dependent task not found for dependency: %s/%s", d.DependsOnTaskFeatureName, d.DependsOnTaskName)
+	}
+
+	imp.dependencies = append(imp.dependencies, []any{localTaskID, localDependsOnID})
+	return nil
+}
+
+// importFeatureDependency imports a feature dependency record.
+func (imp *snapshotImport) importFeatureDependency(ctx context.Context, line []byte) error {
+	var d struct {
+		FeatureID            string `json:"feature_id"`
+		FeatureName          string `json:"feature_name"`
+		DependsOnFeatureID   string `json:"depends_on_feature_id"`
+		DependsOnFeatureName string `json:"depends_on_feature_name"`
+	}
+	if err := json.Unmarshal(line, &d); err != nil {
+		return fmt.Errorf("failed to unmarshal feature dependency: %w", err)
+	}
+
+	localFeatureID, ok := imp.featureSnapshotIDToLocalID[d.FeatureID]
+	if !ok {
+		localFeatureID, ok = imp.featureNameMap[d.FeatureName]
+	}
+	if !ok {
+		return fmt.Errorf("feature not found for feature dependency: %s", d.FeatureName)
+	}
+
+	localDependsOnID, ok := imp.featureSnapshotIDToLocalID[d.DependsOnFeatureID]
+	if !ok {
+		localDependsOnID, ok = imp.featureNameMap[d.DependsOnFeatureName]
+	}
+	if !ok {
+		return fmt.Errorf("dependent feature not found for feature dependency: %s", d.DependsOnFeatureName)
+	}
+
+	if err := imp.db.checkFeatureDependencyCycle(ctx, imp.tx, localFeatureID, localDependsOnID); err != nil {
 		return err
 	}
+	_, err := imp.exec(ctx, "INSERT INTO feature_dependencies (feature_id, depends_on_feature_id) VALUES (?, ?) ON CONFLICT DO NOTHING", localFeatureID, localDependsOnID)
+	if err != nil {
+		return fmt.Errorf("failed to insert feature dependency %s -> %s: %w", d.FeatureName, d.DependsOnFeatureName, err)
+	}
+	return nil
+}
 
-	db.triggerChange(ctx)
+// importNote imports a note record.
+func (imp *snapshotImport) importNote(ctx context.Context, line []byte) error {
+	var n struct {
+		ID              string    `json:"id"`
+		TaskID          string    `json:"task_id"`
+		TaskName        string    `json:"task_name"`
+		TaskFeatureName string    `json:"task_feature_name"`
+		Author          string    `json:"author"`
+		Body            string    `json:"body"`
+		CreatedAt       time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(line, &n); err != nil {
+		return fmt.Errorf("failed to unmarshal note: %w", err)
+	}
+
+	localTaskID, ok := imp.taskSnapshotIDToLocalID[n.TaskID]
+	if !ok {
+		localTaskID, ok = imp.taskNameMap[n.TaskFeatureName+"/"+n.TaskName]
+	}
+	if !ok {
+		return fmt.Errorf("task not found for note: %s/%s", n.TaskFeatureName, n.TaskName)
+	}
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+
+	imp.notes = append(imp.notes, []any{n.ID, localTaskID, n.Author, n.Body, imp.db.dialect.timestamp(n.CreatedAt)})
+	return nil
+}
+
+// importLink imports a link record.
+func (imp *snapshotImport) importLink(ctx context.Context, line []byte) error {
+	var l struct {
+		TaskID          string    `json:"task_id"`
+		TaskName        string    `json:"task_name"`
+		TaskFeatureName string    `json:"task_feature_name"`
+		Provider        string    `json:"provider"`
+		ExternalRef     string    `json:"external_ref"`
+		URL             string    `json:"url"`
+		CreatedAt       time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(line, &l); err != nil {
+		return fmt.Errorf("failed to unmarshal link: %w", err)
+	}
+
+	localTaskID, ok := imp.taskSnapshotIDToLocalID[l.TaskID]
+	if !ok {
+		localTaskID, ok = imp.taskNameMap[l.TaskFeatureName+"/"+l.TaskName]
+	}
+	if !ok {
+		return fmt.Errorf("task not found for link: %s/%s", l.TaskFeatureName, l.TaskName)
+	}
+
+	_, err := imp.exec(ctx, `
+		INSERT INTO task_links (task_id, provider, external_ref, url, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (provider, external_ref) DO UPDATE SET task_id = excluded.task_id, url = excluded.url`,
+		localTaskID, l.Provider, l.ExternalRef, l.URL, imp.db.dialect.timestamp(l.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert link %s %s: %w", l.Provider, l.ExternalRef, err)
+	}
+	return nil
+}
+
+// importEnvironment imports an environment record.
+func (imp *snapshotImport) importEnvironment(ctx context.Context, line []byte) error {
+	var e struct {
+		FeatureName string          `json:"feature_name"`
+		TaskID      *string         `json:"task_id"`
+		TaskName    *string         `json:"task_name"`
+		WorkingDir  string          `json:"working_dir"`
+		Env         json.RawMessage `json:"env"`
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		return fmt.Errorf("failed to unmarshal environment: %w", err)
+	}
+
+	column, id := "feature_id", ""
+	name := e.FeatureName
+	if e.TaskName != nil {
+		column = "task_id"
+		name += "/" + *e.TaskName
+		ok := false
+		if e.TaskID != nil {
+			id, ok = imp.taskSnapshotIDToLocalID[*e.TaskID]
+		}
+		if !ok {
+			id, ok = imp.taskNameMap[name]
+		}
+		if !ok {
+			return fmt.Errorf("task not found for environment: %s", name)
+		}
+	} else {
+		var ok bool
+		if id, ok = imp.featureNameMap[e.FeatureName]; !ok {
+			return fmt.Errorf("feature not found for environment: %s", name)
+		}
+	}
+	env := "{}"
+	if len(e.Env) > 0 && string(e.Env) != "null" {
+		env = string(e.Env)
+	}
+	if err := upsertRunEnvironment(ctx, imp.tx, column, id, e.WorkingDir, env); err != nil {
+		return fmt.Errorf("failed to insert environment of %s: %w", name, err)
+	}
 	return nil
 }
+
+// importTemplate imports a template record.
+func (imp *snapshotImport) importTemplate(ctx context.Context, line []byte) error {
+	var tmpl struct {
+		ID            string     `json:"id"`
+		Name          string     `json:"name"`
+		FeatureName   string     `json:"feature_name"`
+		TaskName      string     `json:"task_name"`
+		Description   string     `json:"description"`
+		Specification string     `json:"specification"`
+		Priority      int        `json:"priority"`
+		TestsRequired bool       `json:"tests_required"`
+		Recurrence    string     `json:"recurrence"`
+		NextRunAt     *time.Time `json:"next_run_at"`
+	}
+	if err := json.Unmarshal(line, &tmpl); err != nil {
+		return fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+	featureID, ok := imp.featureNameMap[tmpl.FeatureName]
+	if !ok {
+		return fmt.Errorf("feature not found for template %s: %s", tmpl.Name, tmpl.FeatureName)
+	}
+	if tmpl.ID == "" {
+		tmpl.ID = uuid.New().String()
+	}
+	testsRequired := 0
+	if tmpl.TestsRequired {
+		testsRequired = 1
+	}
+
+	_, err := imp.exec(ctx, `
+		INSERT INTO task_templates (id, name, feature_id, task_name, description, specification, priority,
+		                            tests_required, recurrence, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			feature_id = excluded.feature_id, task_name = excluded.task_name,
+			description = excluded.description, specification = excluded.specification,
+			priority = excluded.priority, tests_required = excluded.tests_required,
+			recurrence = excluded.recurrence, next_run_at = excluded.next_run_at,
+			updated_at = CURRENT_TIMESTAMP`,
+		tmpl.ID, tmpl.Name, featureID, tmpl.TaskName, tmpl.Description, tmpl.Specification, tmpl.Priority,
+		testsRequired, tmpl.Recurrence, imp.db.timestampArg(tmpl.NextRunAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert template %s: %w", tmpl.Name, err)
+	}
+	return nil
+}
+
+// importArchivedTask imports an archived task record. Archived records go
+// straight into the archive tables. They keep their snapshot IDs unless they
+// refer to a live record, which sorts before them.
+func (imp *snapshotImport) importArchivedTask(ctx context.Context, line []byte) error {
+	var t struct {
+		ID                string            `json:"id"`
+		Name              string            `json:"name"`
+		Description       string            `json:"description"`
+		Specification     string            `json:"specification"`
+		FeatureID         string            `json:"feature_id"`
+		FeatureName       string            `json:"feature_name"`
+		TestsRequired     bool              `json:"tests_required"`
+		Priority          int               `json:"priority"`
+		Status            models.TaskStatus `json:"status"`
+		CompletionSummary *string           `json:"completion_summary"`
+		CreatedAt         time.Time         `json:"created_at"`
+		UpdatedAt         time.Time         `json:"updated_at"`
+		StartedAt         *time.Time        `json:"started_at"`
+		CompletedAt       *time.Time        `json:"completed_at"`
+		ArchivedAt        time.Time         `json:"archived_at"`
+	}
+	if err := json.Unmarshal(line, &t); err != nil {
+		return fmt.Errorf("failed to unmarshal archived task: %w", err)
+	}
+	featureID := t.FeatureID
+	if localID, ok := imp.featureSnapshotIDToLocalID[featureID]; ok {
+		featureID = localID
+	}
+	testsRequired := 0
+	if t.TestsRequired {
+		testsRequired = 1
+	}
+
+	_, err := imp.exec(ctx, `
+		INSERT INTO archived_tasks (
+			id, feature_id, feature_name, name, description, specification, priority,
+			tests_required, status, completion_summary, created_at,
+			updated_at, started_at, completed_at, archived_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`+upsertArchivedTask,
+		t.ID, featureID, t.FeatureName, t.Name, t.Description, t.Specification, t.Priority,
+		testsRequired, t.Status, t.CompletionSummary, imp.db.dialect.timestamp(t.CreatedAt),
+		imp.db.dialect.timestamp(t.UpdatedAt), imp.db.timestampArg(t.StartedAt), imp.db.timestampArg(t.CompletedAt), imp.db.dialect.timestamp(t.ArchivedAt))
+	if err != nil {
+		return fmt.Errorf("failed to sync archived task %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// importArchivedDependency imports an archived dependency record.
+func (imp *snapshotImport) importArchivedDependency(ctx context.Context, line []byte) error {
+	var d struct {
+		TaskID          string `json:"task_id"`
+		DependsOnTaskID string `json:"depends_on_task_id"`
+	}
+	if err := json.Unmarshal(line, &d); err != nil {
+		return fmt.Errorf("failed to unmarshal archived dependency: %w", err)
+	}
+	taskID, dependsOnID := d.TaskID, d.DependsOnTaskID
+	if localID, ok := imp.taskSnapshotIDToLocalID[taskID]; ok {
+		taskID = localID
+	}
+	if localID, ok := imp.taskSnapshotIDToLocalID[dependsOnID]; ok {
+		dependsOnID = localID
+	}
+
+	_, err := imp.exec(ctx, "INSERT INTO archived_dependencies (task_id, depends_on_task_id) VALUES (?, ?) ON CONFLICT DO NOTHING", taskID, dependsOnID)
+	if err != nil {
+		return fmt.Errorf("failed to insert archived dependency: %w", err)
+	}
+	return nil
+}
+
+// importArchivedNote imports an archived note record.
+func (imp *snapshotImport) importArchivedNote(ctx context.Context, line []byte) error {
+	var n struct {
+		ID        string    `json:"id"`
+		TaskID    string    `json:"task_id"`
+		Author    string    `json:"author"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(line, &n); err != nil {
+		return fmt.Errorf("failed to unmarshal archived note: %w", err)
+	}
+
+	_, err := imp.exec(ctx, "INSERT INTO archived_task_notes (id, task_id, author, body, created_at) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING",
+		n.ID, n.TaskID, n.Author, n.Body, imp.db.dialect.timestamp(n.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert archived note: %w", err)
+	}
+	return nil
+}
+
+// importArchivedLink imports an archived link record.
+func (imp *snapshotImport) importArchivedLink(ctx context.Context, line []byte) error {
+	var l struct {
+		TaskID      string    `json:"task_id"`
+		Provider    string    `json:"provider"`
+		ExternalRef string    `json:"external_ref"`
+		URL         string    `json:"url"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(line, &l); err != nil {
+		return fmt.Errorf("failed to unmarshal archived link: %w", err)
+	}
+
+	_, err := imp.exec(ctx, `
+		INSERT INTO archived_task_links (task_id, provider, external_ref, url, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (provider, external_ref) DO UPDATE SET
+			task_id = excluded.task_id, url = excluded.url, created_at = excluded.created_at`,
+		l.TaskID, l.Provider, l.ExternalRef, l.URL, imp.db.dialect.timestamp(l.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert archived link %s %s: %w", l.Provider, l.ExternalRef, err)
+	}
+	return nil
+}
+
+// importArchivedFeature imports an archived feature record.
+func (imp *snapshotImport) importArchivedFeature(ctx context.Context, line []byte) error {
+	var f struct {
+		models.Feature
+		ArchivedAt time.Time `json:"archived_at"`
+	}
+	if err := json.Unmarshal(line, &f); err != nil {
+		return fmt.Errorf("failed to unmarshal archived feature: %w", err)
+	}
+
+	_, err := imp.exec(ctx, `
+		INSERT INTO archived_features (id, name, description, specification, created_at, updated_at, archived_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		`+upsertArchivedFeature,
+		f.ID, f.Name, f.Description, f.Specification, imp.db.dialect.timestamp(f.CreatedAt), imp.db.dialect.timestamp(f.UpdatedAt), imp.db.dialect.timestamp(f.ArchivedAt))
+	if err != nil {
+		return fmt.Errorf("failed to sync archived feature %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// localTask identifies a task by its feature and name, which are unique
+// together.
+type localTask struct {
+	featureID, name string
+}
+
+// displacedName is the name a record not in the snapshot is given when a
+// renamed one takes its name: the name with the start of its ID appended,
+// kept within the 55 characters names may have.
+func displacedName(name, id string) string {
+	suffix := "~" + id
+	if len(suffix) > 9 {
+		suffix = suffix[:9]
+	}
+	if len(name)+len(suffix) > 55 {
+		name = name[:55-len(suffix)]
+	}
+	return name + suffix
+}
+
+// readSnapshotLines reads the non-empty lines of a snapshot.
+func readSnapshotLines(r io.Reader) ([][]byte, error) {
+	var lines [][]byte
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			lines = append(lines, bytes.Clone(line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return lines, nil
+}
+
+// snapshotIDs returns the IDs of the features and tasks in a snapshot.
+func snapshotIDs(lines [][]byte) map[string]bool {
+	ids := make(map[string]bool)
+	for _, line := range lines {
+		var r struct {
+			RecordType string `json:"record_type"`
+			ID         string `json:"id"`
+		}
+		if json.Unmarshal(line, &r) != nil || r.ID == "" {
+			continue
+		}
+		if r.RecordType == "feature" || r.RecordType == "task" {
+			ids[r.ID] = true
+		}
+	}
+	return ids
+}
+
+// listDependencyNames returns the live dependencies as
+// "feature/task -> feature/task", sorted, followed by the feature
+// dependencies as "feature -> feature".
+func listDependencyNames(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT tf.name, t.name, df.name, d.name
+		FROM dependencies dep
+		JOIN tasks t ON t.id = dep.task_id
+		JOIN features tf ON tf.id = t.feature_id
+		JOIN tasks d ON d.id = dep.depends_on_task_id
+		JOIN features df ON df.id = d.feature_id
+		ORDER BY tf.name, t.name, df.name, d.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var taskFeature, task, depFeature, dep string
+		if err := rows.Scan(&taskFeature, &task, &depFeature, &dep); err != nil {
+			return nil, err
+		}
+		names = append(names, taskFeature+"/"+task+" -> "+depFeature+"/"+dep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	featureRows, err := tx.QueryContext(ctx, `
+		SELECT f.name, df.name
+		FROM feature_dependencies fd
+		JOIN features f ON f.id = fd.feature_id
+		JOIN features df ON df.id = fd.depends_on_feature_id
+		ORDER BY f.name, df.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feature dependencies: %w", err)
+	}
+	defer featureRows.Close()
+
+	for featureRows.Next() {
+		var feature, dep string
+		if err := featureRows.Scan(&feature, &dep); err != nil {
+			return nil, err
+		}
+		names = append(names, feature+" -> "+dep)
+	}
+	return names, featureRows.Err()
+}
+
+// deleteMissing deletes the live tasks and features whose local IDs are not
+// kept, as mirroring imports do for records missing from the snapshot, and
+// returns their names, "feature/task" for tasks.
+func deleteMissing(ctx context.Context, tx *sql.Tx, entityType, table string, kept map[string]bool) ([]string, error) {
+	query := "SELECT id, name, '' FROM features ORDER BY name"
+	if table == "tasks" {
+		query = "SELECT t.id, t.name, f.name FROM tasks t JOIN features f ON f.id = t.feature_id ORDER BY f.name, t.name"
+	}
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	type record struct{ id, name, feature string }
+	var stale []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.name, &r.feature); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !kept[r.id] {
+			stale = append(stale, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, r := range stale {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", r.id); err != nil {
+			return nil, fmt.Errorf("failed to delete %s %s: %w", entityType, r.name, err)
+		}
+		if err := recordEvent(ctx, tx, entityType, r.id, r.name, models.EventDeleted, nil, nil); err != nil {
+			return nil, err
+		}
+		if r.feature != "" {
+			deleted = append(deleted, r.feature+"/"+r.name)
+		} else {
+			deleted = append(deleted, r.name)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultSnapshotDebounce is how long writes are coalesced before the
+// snapshot is exported.
+const DefaultSnapshotDebounce = 200 * time.Millisecond
+
+// snapshotExporter exports the snapshot in the background once writes have
+// been coalesced for a while, so a burst of writes such as a bulk import
+// rewrites the file once rather than once per write.
+type snapshotExporter struct {
+	db      *DB
+	path    string
+	delay   time.Duration
+	onError func(error)
+
+	mu    sync.Mutex
+	dirty bool
+	timer *time.Timer
+
+	// exportMu keeps two exports from running at once.
+	exportMu sync.Mutex
+}
+
+// trigger marks the snapshot stale and schedules an export delay from now,
+// unless one is already scheduled.
+func (e *snapshotExporter) trigger() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dirty = true
+	if e.timer == nil {
+		e.timer = time.AfterFunc(e.delay, e.fire)
+	}
+}
+
+func (e *snapshotExporter) fire() {
+	e.mu.Lock()
+	e.timer = nil
+	e.mu.Unlock()
+
+	if err := e.export(context.Background()); err != nil && e.onError != nil {
+		e.onError(err)
+	}
+}
+
+// flush exports now if anything changed since the last export, cancelling
+// the scheduled one.
+func (e *snapshotExporter) flush(ctx context.Context) error {
+	e.mu.Lock()
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	e.mu.Unlock()
+	return e.export(ctx)
+}
+
+func (e *snapshotExporter) export(ctx context.Context) error {
+	e.exportMu.Lock()
+	defer e.exportMu.Unlock()
+
+	e.mu.Lock()
+	dirty := e.dirty
+	e.dirty = false
+	e.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	if err := e.db.ExportSnapshot(ctx, e.path); err != nil {
+		return err
+	}
+	return e.db.keepSnapshotVersion(e.path)
+}
+
+// EnableDebouncedSnapshot exports a snapshot to path after writes, off the
+// write path: writes within delay of the first are coalesced into a single
+// export. Errors of background exports are passed to onError. Close and
+// FlushSnapshot write out any pending export.
+func (db *DB) EnableDebouncedSnapshot(path string, delay time.Duration, onError func(error)) {
+	e := &snapshotExporter{db: db, path: path, delay: delay, onError: onError}
+	db.snapshotMu.Lock()
+	db.snapshots = e
+	db.snapshotMu.Unlock()
+	db.SetOnChange(func(ctx context.Context) { e.trigger() })
+}
+
+// FlushSnapshot writes out a pending debounced export right away.
+func (db *DB) FlushSnapshot(ctx context.Context) error {
+	db.snapshotMu.Lock()
+	e := db.snapshots
+	db.snapshotMu.Unlock()
+	if e == nil {
+		return nil
+	}
+	return e.flush(ctx)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestDebouncedSnapshot(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	db.EnableDebouncedSnapshot(path, time.Hour, nil)
+
+	// A burst of writes is not exported until the window closes.
+	for i := 0; i < 20; i++ {
+		f := &models.Feature{Name: fmt.Sprintf("feature-%02d", i), Description: "d", Specification: "s"}
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no export before the debounce window closed, got %v", err)
+	}
+
+	if err := db.FlushSnapshot(ctx); err != nil {
+		t.Fatalf("FlushSnapshot failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected flush to export the snapshot: %v", err)
+	}
+	if !strings.Contains(string(data), `"name":"feature-19"`) {
+		t.Errorf("Expected snapshot to contain the last write, got:\n%s", data)
+	}
+
+	// With a short window the export happens in the background.
+	db.EnableDebouncedSnapshot(path, 10*time.Millisecond, func(err error) { t.Errorf("export failed: %v", err) })
+	if err := db.CreateFeature(ctx, &models.Feature{Name: "late", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), `"name":"late"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the snapshot to be exported in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseFlushesDebouncedSnapshot(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	db.EnableDebouncedSnapshot(path, time.Hour, nil)
+	if err := db.CreateFeature(ctx, &models.Feature{Name: "pending", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"name":"pending"`) {
+		t.Errorf("Expected Close to export the pending snapshot, got %v:\n%s", err, data)
+	}
+}
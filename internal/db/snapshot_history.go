@@ -0,0 +1,141 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSnapshotVersionNotFound is returned when no kept snapshot matches a
+// timestamp.
+var ErrSnapshotVersionNotFound = errors.New("snapshot version not found")
+
+// snapshotVersionFormat names kept snapshots. It sorts in time order and has
+// no characters that need quoting in a shell.
+const snapshotVersionFormat = "20060102T150405.000Z"
+
+// SnapshotHistory keeps copies of the snapshots exported after writes, so a
+// bad bulk edit can be rolled back with RestoreSnapshot. The zero value
+// disables it.
+type SnapshotHistory struct {
+	// Dir is where the copies are written.
+	Dir string
+	// Keep is how many copies to retain; older ones are deleted.
+	Keep int
+}
+
+// Enabled reports whether copies are kept at all.
+func (h SnapshotHistory) Enabled() bool {
+	return h.Dir != "" && h.Keep > 0
+}
+
+// SetSnapshotHistory configures the copies kept of exports made by
+// EnableDebouncedSnapshot.
+func (db *DB) SetSnapshotHistory(h SnapshotHistory) {
+	db.snapshotMu.Lock()
+	defer db.snapshotMu.Unlock()
+	db.snapshotHistory = h
+}
+
+// SnapshotVersion is a kept copy of a snapshot.
+type SnapshotVersion struct {
+	// Timestamp is when the snapshot was exported, as used in its file name.
+	Timestamp string
+	Time      time.Time
+	Path      string
+}
+
+// keepSnapshotVersion copies the snapshot at path into the history, unless
+// it is the same as the newest copy, and prunes copies beyond the count.
+func (db *DB) keepSnapshotVersion(path string) error {
+	db.snapshotMu.Lock()
+	h := db.snapshotHistory
+	db.snapshotMu.Unlock()
+	if !h.Enabled() {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	versions, err := ListSnapshotVersions(h.Dir)
+	if err != nil {
+		return err
+	}
+	if len(versions) > 0 {
+		if newest, err := os.ReadFile(versions[0].Path); err == nil && bytes.Equal(newest, data) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot history directory: %w", err)
+	}
+	name := "snapshot-" + time.Now().UTC().Format(snapshotVersionFormat) + ".jsonl"
+	if err := os.WriteFile(filepath.Join(h.Dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to keep snapshot version: %w", err)
+	}
+
+	versions, err = ListSnapshotVersions(h.Dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions[min(h.Keep, len(versions)):] {
+		if err := os.Remove(v.Path); err != nil {
+			return fmt.Errorf("failed to prune snapshot version: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListSnapshotVersions returns the snapshots kept in dir, newest first. A
+// missing directory has none.
+func ListSnapshotVersions(dir string) ([]SnapshotVersion, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "snapshot-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var versions []SnapshotVersion
+	for _, path := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "snapshot-"), ".jsonl")
+		t, err := time.Parse(snapshotVersionFormat, stamp)
+		if err != nil {
+			// Not one of ours.
+			continue
+		}
+		versions = append(versions, SnapshotVersion{Timestamp: stamp, Time: t, Path: path})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Timestamp > versions[j].Timestamp })
+	return versions, nil
+}
+
+// FindSnapshotVersion returns the snapshot kept in dir whose timestamp is or
+// starts with timestamp, which must match exactly one.
+func FindSnapshotVersion(dir, timestamp string) (*SnapshotVersion, error) {
+	versions, err := ListSnapshotVersions(dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []SnapshotVersion
+	for _, v := range versions {
+		if v.Timestamp == timestamp {
+			return &v, nil
+		}
+		if timestamp != "" && strings.HasPrefix(v.Timestamp, timestamp) {
+			found = append(found, v)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotVersionNotFound, timestamp)
+	case 1:
+		return &found[0], nil
+	}
+	return nil, fmt.Errorf("timestamp %s matches %d snapshot versions; give more of it", timestamp, len(found))
+}
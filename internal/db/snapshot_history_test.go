@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestSnapshotHistory(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, "snapshots")
+	db.SetSnapshotHistory(SnapshotHistory{Dir: historyDir, Keep: 2})
+	db.EnableDebouncedSnapshot(filepath.Join(dir, "snapshot.jsonl"), time.Hour, nil)
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	var tasks []*models.Task
+	for _, name := range []string{"a", "b", "c"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		tasks = append(tasks, task)
+		if err := db.FlushSnapshot(ctx); err != nil {
+			t.Fatalf("FlushSnapshot failed: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// An export that changes nothing keeps no new copy.
+	db.triggerChange(ctx)
+	if err := db.FlushSnapshot(ctx); err != nil {
+		t.Fatalf("FlushSnapshot failed: %v", err)
+	}
+
+	versions, err := ListSnapshotVersions(historyDir)
+	if err != nil {
+		t.Fatalf("ListSnapshotVersions failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected the newest 2 versions, got %d", len(versions))
+	}
+	if !versions[0].Time.After(versions[1].Time) {
+		t.Errorf("expected newest first, got %v", versions)
+	}
+
+	if v, err := FindSnapshotVersion(historyDir, versions[1].Timestamp); err != nil || v.Path != versions[1].Path {
+		t.Errorf("expected to find version by timestamp, got %+v (%v)", v, err)
+	}
+	if _, err := FindSnapshotVersion(historyDir, "1999"); !errors.Is(err, ErrSnapshotVersionNotFound) {
+		t.Errorf("expected ErrSnapshotVersionNotFound, got %v", err)
+	}
+
+	// Restoring the version with a and b drops c and a dependency added since.
+	if err := db.CreateDependency(ctx, tasks[0].ID, tasks[1].ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+	if err := db.RestoreSnapshot(ctx, versions[1].Path); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	if task, _ := db.GetTask(ctx, tasks[2].ID); task != nil {
+		t.Error("expected c to be deleted")
+	}
+	if task, _ := db.GetTask(ctx, tasks[1].ID); task == nil {
+		t.Error("expected b to be kept")
+	}
+	if deps, _ := db.GetDependencies(ctx, tasks[0].ID); len(deps) != 0 {
+		t.Errorf("expected the dependency to be removed, got %d", len(deps))
+	}
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// maxInsertArgs caps the placeholders of one multi-row insert, below the
+// smallest limit SQLite has been built with.
+const maxInsertArgs = 999
+
+// exec runs a statement of the import, preparing each distinct query once:
+// a snapshot runs the same few thousands of times, and parsing them again
+// each time dominated imports.
+func (imp *snapshotImport) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, ok := imp.stmts[query]
+	if !ok {
+		var err error
+		if stmt, err = imp.tx.PrepareContext(ctx, query); err != nil {
+			return nil, err
+		}
+		imp.stmts[query] = stmt
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// closeStatements releases the statements prepared by exec.
+func (imp *snapshotImport) closeStatements() {
+	for _, stmt := range imp.stmts {
+		stmt.Close()
+	}
+}
+
+// insertRows inserts rows into the columns of table, as many to a statement
+// as the placeholder limit allows. The suffix follows the VALUES, e.g. an
+// ON CONFLICT clause.
+func (imp *snapshotImport) insertRows(ctx context.Context, table string, columns []string, suffix string, rows [][]any) error {
+	perStatement := maxInsertArgs / len(columns)
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	for len(rows) > 0 {
+		batch := rows[:min(perStatement, len(rows))]
+		rows = rows[len(batch):]
+
+		values := make([]string, len(batch))
+		args := make([]any, 0, len(batch)*len(columns))
+		for i, row := range batch {
+			values[i] = placeholders
+			args = append(args, row...)
+		}
+		query := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + strings.Join(values, ", ") + " " + suffix
+		if _, err := imp.exec(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadRows writes the labels, notes and dependencies collected while the
+// records were read, once every task they refer to exists.
+func (imp *snapshotImport) loadRows(ctx context.Context) error {
+	restore, err := imp.deferSchemaObjects(ctx, "dependencies", "task_labels", "task_notes")
+	if err != nil {
+		return err
+	}
+
+	if err := imp.insertRows(ctx, "task_labels", []string{"task_id", "label"}, "", imp.labels); err != nil {
+		return fmt.Errorf("failed to insert task labels: %w", err)
+	}
+	if err := imp.insertRows(ctx, "task_notes", []string{"id", "task_id", "author", "body", "created_at"}, "ON CONFLICT DO NOTHING", imp.notes); err != nil {
+		return fmt.Errorf("failed to insert notes: %w", err)
+	}
+	if err := imp.checkDependencies(ctx); err != nil {
+		return err
+	}
+	if err := imp.insertRows(ctx, "dependencies", []string{"task_id", "depends_on_task_id"}, "ON CONFLICT DO NOTHING", imp.dependencies); err != nil {
+		return fmt.Errorf("failed to insert dependencies: %w", err)
+	}
+
+	return restore(ctx)
+}
+
+// checkDependencies rejects the import if the dependencies it adds, with
+// those already there, would form a cycle. Checking the whole graph once
+// replaces checking each dependency as it is inserted, which walks the chain
+// above it every time.
+func (imp *snapshotImport) checkDependencies(ctx context.Context) error {
+	if len(imp.dependencies) == 0 {
+		return nil
+	}
+	rows, err := imp.tx.QueryContext(ctx, taskDependencyEdges)
+	if err != nil {
+		return fmt.Errorf("failed to load dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	next := make(map[string][]string)
+	for rows.Next() {
+		var from, to string
+		if err := rows.Scan(&from, &to); err != nil {
+			return fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		next[from] = append(next[from], to)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load dependencies: %w", err)
+	}
+	rows.Close()
+
+	for _, d := range imp.dependencies {
+		from, to := d[0].(string), d[1].(string)
+		next[from] = append(next[from], to)
+	}
+	if cycle := findCycle(next); cycle != nil {
+		return dependencyCycleError(ctx, imp.tx, cycle)
+	}
+	return nil
+}
+
+// deferSchemaObjects drops the secondary indexes and triggers of tables on
+// SQLite, so that rows are loaded without updating indexes row by row or
+// running the dependency cycle trigger for each. The returned function
+// recreates them, from the definitions SQLite kept, within the same
+// transaction: the objects are never missing for anyone else. Other
+// dialects keep theirs.
+func (imp *snapshotImport) deferSchemaObjects(ctx context.Context, tables ...string) (restore func(context.Context) error, err error) {
+	restore = func(context.Context) error { return nil }
+	if _, ok := imp.db.dialect.(sqliteDialect); !ok {
+		return restore, nil
+	}
+
+	query := `SELECT type, name, sql FROM sqlite_master
+		WHERE type IN ('index', 'trigger') AND sql IS NOT NULL
+		  AND tbl_name IN (?` + strings.Repeat(", ?", len(tables)-1) + `)
+		ORDER BY type, name`
+	args := make([]any, len(tables))
+	for i, t := range tables {
+		args[i] = t
+	}
+	rows, err := imp.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes and triggers: %w", err)
+	}
+	type object struct{ kind, name, sql string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.kind, &o.name, &o.sql); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan schema object: %w", err)
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list indexes and triggers: %w", err)
+	}
+
+	for _, o := range objects {
+		if _, err := imp.tx.ExecContext(ctx, "DROP "+strings.ToUpper(o.kind)+" "+o.name); err != nil {
+			return nil, fmt.Errorf("failed to drop %s %s: %w", o.kind, o.name, err)
+		}
+	}
+	return func(ctx context.Context) error {
+		for _, o := range objects {
+			if _, err := imp.tx.ExecContext(ctx, o.sql); err != nil {
+				return fmt.Errorf("failed to recreate %s %s: %w", o.kind, o.name, err)
+			}
+		}
+		return nil
+	}, nil
+}
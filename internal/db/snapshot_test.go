@@ -4,11 +4,15 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
@@ -90,6 +94,9 @@ func TestExportSnapshot(t *testing.T) {
 	if meta["record_type"] != "meta" {
 		t.Errorf("Expected first line to be meta, got %v", meta["record_type"])
 	}
+	if meta["schema_version"] != strconv.Itoa(SnapshotSchemaVersion) {
+		t.Errorf("Expected schema_version %d, got %v", SnapshotSchemaVersion, meta["schema_version"])
+	}
 
 	// Verify feature line
 	foundFeature := false
@@ -407,3 +414,436 @@ func TestImportSnapshotBrokenDependency(t *testing.T) {
 		t.Errorf("Expected error message to contain 'dependent task not found', got: %v", err)
 	}
 }
+
+func TestImportSnapshotSchemaVersions(t *testing.T) {
+	ctx := context.Background()
+
+	records := []string{
+		`{"record_type": "feature", "id": "00000000-0000-0000-0000-00000000000a", "name": "A", "description": "D", "specification": "S"}`,
+		`{"record_type": "feature", "id": "00000000-0000-0000-0000-00000000000b", "name": "B", "description": "D", "specification": "S"}`,
+		`{"record_type": "feature_dependency", "feature_id": "00000000-0000-0000-0000-00000000000b", "feature_name": "B", "depends_on_feature_id": "00000000-0000-0000-0000-00000000000a", "depends_on_feature_name": "A"}`,
+		`{"record_type": "hologram", "id": "x"}`,
+		`{"record_type": "hologram", "id": "y"}`,
+	}
+
+	tests := []struct {
+		name     string
+		meta     string
+		deps     int
+		warnings []string
+	}{
+		{
+			name: "version 1 predates feature dependencies",
+			meta: `{"record_type": "meta", "schema_version": "1"}`,
+			deps: 0,
+			warnings: []string{
+				`skipped 1 "feature_dependency" records, which schema version 1 doesn't define`,
+				`skipped 2 "hologram" records, which schema version 1 doesn't define`,
+			},
+		},
+		{
+			name: "current version",
+			meta: `{"record_type": "meta", "schema_version": 2}`,
+			deps: 1,
+			warnings: []string{
+				`skipped 2 "hologram" records, which schema version 2 doesn't define`,
+			},
+		},
+		{
+			name: "newer version",
+			meta: `{"record_type": "meta", "schema_version": "99"}`,
+			deps: 1,
+			warnings: []string{
+				"snapshot schema version 99 is newer than this ponder reads (2); records it doesn't know are skipped",
+				`skipped 2 "hologram" records, which schema version 99 doesn't define`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotPath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+			lines := append([]string{tt.meta}, records...)
+			if err := os.WriteFile(snapshotPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+				t.Fatalf("Failed to write manual snapshot: %v", err)
+			}
+
+			db, err := Open(":memory:")
+			if err != nil {
+				t.Fatalf("Failed to open database: %v", err)
+			}
+			defer db.Close()
+			if err := db.Init(ctx); err != nil {
+				t.Fatalf("Failed to init database: %v", err)
+			}
+
+			result, err := db.ImportSnapshotWithOptions(ctx, snapshotPath, ImportOptions{})
+			if err != nil {
+				t.Fatalf("ImportSnapshotWithOptions failed: %v", err)
+			}
+			if !reflect.DeepEqual(result.Warnings, tt.warnings) {
+				t.Errorf("Expected warnings %q, got %q", tt.warnings, result.Warnings)
+			}
+
+			deps, err := db.ListFeatureDependencies(ctx)
+			if err != nil {
+				t.Fatalf("ListFeatureDependencies failed: %v", err)
+			}
+			if len(deps) != tt.deps {
+				t.Errorf("Expected %d feature dependencies, got %+v", tt.deps, deps)
+			}
+		})
+	}
+
+	t.Run("invalid version", func(t *testing.T) {
+		snapshotPath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+		if err := os.WriteFile(snapshotPath, []byte(`{"record_type": "meta", "schema_version": "two"}`+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write manual snapshot: %v", err)
+		}
+		db, err := Open(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer db.Close()
+		if err := db.Init(ctx); err != nil {
+			t.Fatalf("Failed to init database: %v", err)
+		}
+		if err := db.ImportSnapshot(ctx, snapshotPath); err == nil || !strings.Contains(err.Error(), "schema_version") {
+			t.Errorf("Expected an invalid schema_version error, got %v", err)
+		}
+	})
+}
+
+func TestImportSnapshotMirror(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	newTask := func(featureID, name string) *models.Task {
+		t.Helper()
+		task := &models.Task{FeatureID: featureID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		return task
+	}
+
+	kept := &models.Feature{Name: "kept", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, kept); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	first, second := newTask(kept.ID, "first"), newTask(kept.ID, "second")
+	if err := db.CreateDependency(ctx, second.ID, first.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+
+	// Local changes the snapshot doesn't have.
+	gone := &models.Feature{Name: "gone", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, gone); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	newTask(gone.ID, "orphan")
+	third := newTask(kept.ID, "third")
+	if err := db.CreateDependency(ctx, first.ID, third.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	want := ImportResult{
+		DeletedFeatures:     []string{"gone"},
+		DeletedTasks:        []string{"gone/orphan", "kept/third"},
+		DeletedDependencies: []string{"kept/first -> kept/third"},
+	}
+
+	result, err := db.ImportSnapshotWithOptions(ctx, snapshotPath, ImportOptions{Mirror: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("Dry run: expected %+v, got %+v", want, *result)
+	}
+	if f, _ := db.GetFeatureByName(ctx, "gone"); f == nil {
+		t.Error("Dry run deleted a feature")
+	}
+
+	// A plain import leaves local records alone.
+	result, err = db.ImportSnapshotWithOptions(ctx, snapshotPath, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(result.DeletedFeatures)+len(result.DeletedTasks)+len(result.DeletedDependencies) != 0 {
+		t.Errorf("Expected nothing deleted without mirror, got %+v", *result)
+	}
+
+	result, err = db.ImportSnapshotWithOptions(ctx, snapshotPath, ImportOptions{Mirror: true})
+	if err != nil {
+		t.Fatalf("Mirror import failed: %v", err)
+	}
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("Expected %+v, got %+v", want, *result)
+	}
+	if f, _ := db.GetFeatureByName(ctx, "gone"); f != nil {
+		t.Error("Expected the feature missing from the snapshot to be deleted")
+	}
+	if task, _ := db.GetTaskByName(ctx, "third", kept.ID); task != nil {
+		t.Error("Expected the task missing from the snapshot to be deleted")
+	}
+	deps, err := db.GetDependencies(ctx, second.ID)
+	if err != nil || len(deps) != 1 {
+		t.Errorf("Expected the snapshot's dependency to be kept, got %d (%v)", len(deps), err)
+	}
+}
+
+func TestImportSnapshotRenames(t *testing.T) {
+	ctx := context.Background()
+
+	open := func() *DB {
+		t.Helper()
+		db, err := Open(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := db.Init(ctx); err != nil {
+			t.Fatalf("Failed to init database: %v", err)
+		}
+		return db
+	}
+	theirs, ours := open(), open()
+
+	feature := &models.Feature{Name: "old-feature", Description: "d", Specification: "s"}
+	if err := theirs.CreateFeature(ctx, feature); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	tasks := make(map[string]*models.Task)
+	for _, name := range []string{"a", "b", "c"} {
+		task := &models.Task{FeatureID: feature.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := theirs.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		tasks[name] = task
+	}
+	if err := theirs.CreateDependency(ctx, tasks["c"].ID, tasks["a"].ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := theirs.ExportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+	if err := ours.ImportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to import snapshot: %v", err)
+	}
+
+	// Rename the feature, rename c and swap the names of a and b.
+	feature.Name = "new-feature"
+	if err := theirs.UpdateFeature(ctx, feature); err != nil {
+		t.Fatalf("Failed to rename feature: %v", err)
+	}
+	for _, rename := range [][2]string{{"c", "renamed"}, {"a", "tmp"}, {"b", "a"}, {"a", "b"}} {
+		task := tasks[rename[0]]
+		task.Name = rename[1]
+		if err := theirs.UpdateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to rename task: %v", err)
+		}
+	}
+	if err := theirs.ExportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+
+	// A local task that isn't in the snapshot holds a name being taken.
+	local := &models.Task{FeatureID: feature.ID, Name: "renamed", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := ours.CreateTask(ctx, local); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	if err := ours.ImportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to import renamed snapshot: %v", err)
+	}
+
+	f, err := ours.GetFeature(ctx, feature.ID)
+	if err != nil || f == nil || f.Name != "new-feature" {
+		t.Fatalf("Expected the feature to be renamed in place, got %+v (%v)", f, err)
+	}
+	if old, _ := ours.GetFeatureByName(ctx, "old-feature"); old != nil {
+		t.Error("Expected no feature left under the old name")
+	}
+	for _, want := range tasks {
+		got, err := ours.GetTask(ctx, want.ID)
+		if err != nil || got == nil || got.Name != want.Name {
+			t.Errorf("Expected task %s to be named %q, got %+v (%v)", want.ID, want.Name, got, err)
+		}
+	}
+	deps, err := ours.GetDependencies(ctx, tasks["c"].ID)
+	if err != nil || len(deps) != 1 || deps[0].ID != tasks["a"].ID {
+		t.Errorf("Expected the dependency to follow the renamed tasks, got %v (%v)", deps, err)
+	}
+	if got, _ := ours.GetTask(ctx, local.ID); got == nil || got.Name == "renamed" {
+		t.Errorf("Expected the local task to make way for the renamed one, got %+v", got)
+	}
+
+	events, err := ours.ListEvents(ctx, EntityTask, tasks["c"].ID, 0)
+	if err != nil || len(events) == 0 || events[0].Action != models.EventRenamed {
+		t.Errorf("Expected a rename in the task's history, got %v (%v)", events, err)
+	}
+}
+
+func TestImportSnapshotTimestampsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	// Timestamps written on a machine east of UTC.
+	records := []string{
+		`{"record_type": "meta", "schema_version": 2}`,
+		`{"record_type": "feature", "id": "00000000-0000-0000-0000-00000000000a", "name": "A", "description": "D", "specification": "S", "created_at": "2026-01-02T16:04:05+01:00", "updated_at": "2026-01-02T16:04:05+01:00"}`,
+		`{"record_type": "task", "id": "00000000-0000-0000-0000-0000000000a1", "feature_name": "A", "name": "t", "description": "D", "specification": "S", "priority": 1, "status": "completed", "completion_summary": "done", "created_at": "2026-01-02T16:04:05+01:00", "updated_at": "2026-01-02T18:00:00+01:00", "started_at": "2026-01-02T17:00:00+01:00", "completed_at": "2026-01-02T18:00:00+01:00"}`,
+	}
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := os.WriteFile(snapshotPath, []byte(strings.Join(records, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manual snapshot: %v", err)
+	}
+
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db.ImportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Failed to import snapshot: %v", err)
+	}
+
+	var stored string
+	// Concatenating reads the stored text rather than the driver's parsed time.
+	if err := db.QueryRowContext(ctx, "SELECT created_at || '' FROM features WHERE name = 'A'").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read created_at: %v", err)
+	}
+	if stored != "2026-01-02 15:04:05" {
+		t.Errorf("Expected created_at stored in UTC, got %q", stored)
+	}
+
+	task, err := db.GetTask(ctx, "00000000-0000-0000-0000-0000000000a1")
+	if err != nil || task == nil {
+		t.Fatalf("Failed to get task: %+v (%v)", task, err)
+	}
+	if task.StartedAt == nil || task.StartedAt.Location() != time.UTC || task.StartedAt.Format(time.RFC3339) != "2026-01-02T16:00:00Z" {
+		t.Errorf("Expected started_at 2026-01-02T16:00:00Z, got %v", task.StartedAt)
+	}
+
+	exportPath := filepath.Join(t.TempDir(), "export.jsonl")
+	if err := db.ExportSnapshot(ctx, exportPath); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+	data, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	for _, want := range []string{
+		`"created_at":"2026-01-02T15:04:05Z"`,
+		`"started_at":"2026-01-02T16:00:00Z"`,
+		`"completed_at":"2026-01-02T17:00:00Z"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected export to contain %s, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestImportSnapshotInBulk(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "ponder.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	// A chain long enough to take several multi-row statements, which the
+	// per-row cycle trigger made quadratic.
+	const tasks = 1200
+	taskID := func(i int) string { return fmt.Sprintf("00000000-0000-0000-0000-%012d", i+1) }
+	lines := []string{
+		`{"record_type": "meta", "schema_version": "2"}`,
+		`{"record_type": "feature", "id": "00000000-0000-0000-0000-00000000000f", "name": "F1", "description": "D", "specification": "S"}`,
+	}
+	for i := range tasks {
+		lines = append(lines, fmt.Sprintf(`{"record_type": "task", "id": %q, "feature_name": "F1", "name": "T%d", "description": "D", "specification": "S", "status": "pending", "labels": ["b", "a"]}`, taskID(i), i))
+		if i > 0 {
+			lines = append(lines, fmt.Sprintf(`{"record_type": "dependency", "task_id": %q, "task_name": "T%d", "task_feature_name": "F1", "depends_on_task_id": %q, "depends_on_task_name": "T%d", "depends_on_task_feature_name": "F1"}`, taskID(i), i, taskID(i-1), i-1))
+		}
+		if i%2 == 0 {
+			lines = append(lines, fmt.Sprintf(`{"record_type": "note", "id": "note-%d", "task_id": %q, "task_name": "T%d", "task_feature_name": "F1", "author": "a", "body": "b"}`, i, taskID(i), i))
+		}
+	}
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := os.WriteFile(snapshotPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRowContext(ctx, query).Scan(&n); err != nil {
+			t.Fatalf("Failed to run %q: %v", query, err)
+		}
+		return n
+	}
+	checkRows := func() {
+		t.Helper()
+		if n := count("SELECT COUNT(*) FROM dependencies"); n != tasks-1 {
+			t.Errorf("Expected %d dependencies, got %d", tasks-1, n)
+		}
+		if n := count("SELECT COUNT(*) FROM task_labels"); n != 2*tasks {
+			t.Errorf("Expected %d labels, got %d", 2*tasks, n)
+		}
+		if n := count("SELECT COUNT(*) FROM task_notes"); n != tasks/2 {
+			t.Errorf("Expected %d notes, got %d", tasks/2, n)
+		}
+		// The indexes and triggers set aside for the load are back.
+		if n := count(`SELECT COUNT(*) FROM sqlite_master WHERE name IN ('prevent_circular_dependencies', 'idx_task_labels_label', 'idx_task_notes_task')`); n != 3 {
+			t.Errorf("Expected the deferred indexes and trigger to be recreated, found %d of 3", n)
+		}
+	}
+
+	if err := db.ImportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	checkRows()
+	// Importing again updates the same rows.
+	if err := db.ImportSnapshot(ctx, snapshotPath); err != nil {
+		t.Fatalf("Second ImportSnapshot failed: %v", err)
+	}
+	checkRows()
+
+	// Closing the chain into a loop is caught once for the whole import, and
+	// nothing of it is kept.
+	cycle := []string{
+		`{"record_type": "meta", "schema_version": "2"}`,
+		fmt.Sprintf(`{"record_type": "dependency", "task_id": %q, "task_name": "T0", "task_feature_name": "F1", "depends_on_task_id": %q, "depends_on_task_name": "T%d", "depends_on_task_feature_name": "F1"}`, taskID(0), taskID(tasks-1), tasks-1),
+	}
+	if err := os.WriteFile(snapshotPath, []byte(strings.Join(cycle, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	err = db.ImportSnapshot(ctx, snapshotPath)
+	if !errors.Is(err, ErrDependencyCycle) || !strings.Contains(err.Error(), "F1/T0 -> F1/T1199") {
+		t.Fatalf("Expected ErrDependencyCycle naming the loop, got %v", err)
+	}
+	checkRows()
+	if _, err := db.ExecContext(ctx, "INSERT INTO dependencies (task_id, depends_on_task_id) VALUES (?, ?)", taskID(0), taskID(1)); err == nil {
+		t.Error("Expected the cycle trigger to reject a loop after the failed import")
+	}
+}
@@ -1,32 +1,185 @@
 package db
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
+// ErrStagedNameTaken is returned when a staged rename would give a feature or
+// task the name of another one staged in the same session.
+var ErrStagedNameTaken = errors.New("name already staged")
+
 type StagedItems struct {
-	Features     []*models.Feature
-	Tasks        []*models.Task
-	Dependencies []*models.Dependency
+	Features     []*models.Feature    `json:"features"`
+	Tasks        []*models.Task       `json:"tasks"`
+	Dependencies []*models.Dependency `json:"dependencies"`
 }
 
 // StagingManager provides thread-safe in-memory storage for staged changes.
 type StagingManager struct {
 	mu     sync.RWMutex
 	staged map[string]*StagedItems
+	// updated is when each session's staged changes last changed.
+	updated map[string]time.Time
+	// persist, when set, is called with a session's staged changes after
+	// each change, or with nil items once the session is dropped.
+	persist func(sessionID string, items *StagedItems, updated time.Time)
+	// load, when set, returns a session's staged changes as last persisted,
+	// or nil items if none are, so changes made by other processes sharing
+	// the session are picked up.
+	load func(sessionID string) (*StagedItems, time.Time, error)
+	// dirty lists the sessions changed while sm.mu is held, for unlock to
+	// persist; pending counts the changes of each session not yet persisted.
+	dirty   []string
+	pending map[string]int
+	// persistMu keeps persisting in order, so an older copy of a session
+	// never overwrites a newer one.
+	persistMu sync.Mutex
 }
 
 func NewStagingManager() *StagingManager {
 	return &StagingManager{
-		staged: make(map[string]*StagedItems),
+		staged:  make(map[string]*StagedItems),
+		updated: make(map[string]time.Time),
+		pending: make(map[string]int),
 	}
 }
 
-func (sm *StagingManager) AddFeature(sessionID string, feature *models.Feature) {
+// SetPersist sets the functions that load and persist a session's staged
+// changes, so they can outlive the process and be shared between processes.
+// Neither may call back into sm.
+func (sm *StagingManager) SetPersist(load func(sessionID string) (*StagedItems, time.Time, error), persist func(sessionID string, items *StagedItems, updated time.Time)) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.load = load
+	sm.persist = persist
+}
+
+// Restore puts back the staged changes of a session saved earlier, replacing
+// whatever is staged for it, without calling the persist function.
+func (sm *StagingManager) Restore(sessionID string, items *StagedItems, updated time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.staged[sessionID] = items
+	sm.updated[sessionID] = updated
+}
+
+// Expire drops every session whose staged changes haven't changed since
+// before, and returns the dropped session IDs.
+func (sm *StagingManager) Expire(before time.Time) []string {
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	var expired []string
+	for sessionID, updated := range sm.updated {
+		if updated.Before(before) {
+			delete(sm.staged, sessionID)
+			sm.changed(sessionID)
+			expired = append(expired, sessionID)
+		}
+	}
+	return expired
+}
+
+// changed records that a session's staged changes changed, for unlock to
+// persist them. sm.mu must be held.
+func (sm *StagingManager) changed(sessionID string) {
+	if _, ok := sm.staged[sessionID]; ok {
+		sm.updated[sessionID] = time.Now()
+	} else {
+		delete(sm.updated, sessionID)
+	}
+	if sm.persist != nil {
+		sm.dirty = append(sm.dirty, sessionID)
+		sm.pending[sessionID]++
+	}
+}
+
+// unlock releases sm.mu, then persists the sessions changed while it was
+// held, so other callers aren't kept waiting on the database.
+func (sm *StagingManager) unlock() {
+	dirty, persist := sm.dirty, sm.persist
+	sm.dirty = nil
+	sm.mu.Unlock()
+	if len(dirty) == 0 {
+		return
+	}
+
+	sm.persistMu.Lock()
+	defer sm.persistMu.Unlock()
+	for _, sessionID := range dirty {
+		sm.mu.RLock()
+		items, ok := sm.staged[sessionID]
+		updated := sm.updated[sessionID]
+		if ok {
+			items = copyItems(items)
+		} else {
+			items = nil
+		}
+		sm.mu.RUnlock()
+		persist(sessionID, items, updated)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, sessionID := range dirty {
+		if sm.pending[sessionID]--; sm.pending[sessionID] <= 0 {
+			delete(sm.pending, sessionID)
+		}
+	}
+}
+
+// refresh replaces a session's staged changes with the persisted ones, so
+// changes made or dropped by another process are picked up. Once this
+// process's own changes are persisted the two only differ because of
+// another process. sm.mu must not be held.
+func (sm *StagingManager) refresh(sessionID string) {
+	sm.mu.RLock()
+	load := sm.load
+	sm.mu.RUnlock()
+	if load == nil {
+		return
+	}
+
+	sm.persistMu.Lock()
+	defer sm.persistMu.Unlock()
+	items, updated, err := load(sessionID)
+	if err != nil {
+		return
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.pending[sessionID] > 0 {
+		// Our own change hasn't been persisted yet, so it's the newest.
+		return
+	}
+	if items == nil {
+		delete(sm.staged, sessionID)
+		delete(sm.updated, sessionID)
+		return
+	}
+	sm.staged[sessionID] = items
+	sm.updated[sessionID] = updated
+}
+
+// forget drops a session's staged changes without persisting that, for
+// when the persisted copy was already removed.
+func (sm *StagingManager) forget(sessionID string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	delete(sm.staged, sessionID)
+	delete(sm.updated, sessionID)
+}
+
+func (sm *StagingManager) AddFeature(sessionID string, feature *models.Feature) {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
 
 	if sm.staged[sessionID] == nil {
 		sm.staged[sessionID] = &StagedItems{
@@ -36,11 +189,13 @@ func (sm *StagingManager) AddFeature(sessionID string, feature *models.Feature)
 		}
 	}
 	sm.staged[sessionID].Features = append(sm.staged[sessionID].Features, feature)
+	sm.changed(sessionID)
 }
 
 func (sm *StagingManager) AddTask(sessionID string, task *models.Task) {
+	sm.refresh(sessionID)
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	defer sm.unlock()
 
 	if sm.staged[sessionID] == nil {
 		sm.staged[sessionID] = &StagedItems{
@@ -50,11 +205,13 @@ func (sm *StagingManager) AddTask(sessionID string, task *models.Task) {
 		}
 	}
 	sm.staged[sessionID].Tasks = append(sm.staged[sessionID].Tasks, task)
+	sm.changed(sessionID)
 }
 
 func (sm *StagingManager) AddDependency(sessionID string, dep *models.Dependency) {
+	sm.refresh(sessionID)
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	defer sm.unlock()
 
 	if sm.staged[sessionID] == nil {
 		sm.staged[sessionID] = &StagedItems{
@@ -64,11 +221,13 @@ func (sm *StagingManager) AddDependency(sessionID string, dep *models.Dependency
 		}
 	}
 	sm.staged[sessionID].Dependencies = append(sm.staged[sessionID].Dependencies, dep)
+	sm.changed(sessionID)
 }
 
 func (sm *StagingManager) GetAndClear(sessionID string) *StagedItems {
+	sm.refresh(sessionID)
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	defer sm.unlock()
 
 	items, ok := sm.staged[sessionID]
 	if !ok {
@@ -80,10 +239,12 @@ func (sm *StagingManager) GetAndClear(sessionID string) *StagedItems {
 	}
 
 	delete(sm.staged, sessionID)
+	sm.changed(sessionID)
 	return items
 }
 
 func (sm *StagingManager) Peek(sessionID string) *StagedItems {
+	sm.refresh(sessionID)
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
@@ -98,3 +259,287 @@ func (sm *StagingManager) Peek(sessionID string) *StagedItems {
 
 	return items
 }
+
+// UpdateFeature applies update to the staged feature with the given name. If the
+// feature is renamed, staged tasks and dependencies referencing it are updated too.
+// Returns false if no such feature is staged, and ErrStagedNameTaken, leaving the
+// feature unchanged, if the new name clashes with another staged feature or would
+// give two staged tasks the same name.
+func (sm *StagingManager) UpdateFeature(sessionID, name string, update func(f *models.Feature)) (bool, error) {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return false, nil
+	}
+
+	for _, f := range items.Features {
+		if f.Name != name {
+			continue
+		}
+
+		updated := *f
+		update(&updated)
+		if updated.Name != name {
+			if err := checkFeatureRename(items, name, updated.Name); err != nil {
+				return true, err
+			}
+		}
+		*f = updated
+		if f.Name != name {
+			for _, t := range items.Tasks {
+				if t.FeatureName == name {
+					t.FeatureName = f.Name
+				}
+			}
+			for _, d := range items.Dependencies {
+				if d.FeatureName == name {
+					d.FeatureName = f.Name
+				}
+				if d.DependsOnFeatureName == name {
+					d.DependsOnFeatureName = f.Name
+				}
+			}
+		}
+		sm.changed(sessionID)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// checkFeatureRename returns ErrStagedNameTaken if renaming the staged feature
+// from to to clashes with another staged feature or staged task.
+func checkFeatureRename(items *StagedItems, from, to string) error {
+	for _, f := range items.Features {
+		if f.Name == to {
+			return fmt.Errorf("%w: feature %s is already staged", ErrStagedNameTaken, to)
+		}
+	}
+	moved := make(map[string]bool)
+	for _, t := range items.Tasks {
+		if t.FeatureName == from {
+			moved[t.Name] = true
+		}
+	}
+	for _, t := range items.Tasks {
+		if t.FeatureName == to && moved[t.Name] {
+			return fmt.Errorf("%w: task %s/%s is already staged", ErrStagedNameTaken, to, t.Name)
+		}
+	}
+	return nil
+}
+
+// UpdateTask applies update to the staged task identified by feature and task name.
+// If the task is renamed or moved, staged dependencies referencing it are updated too.
+// Returns false if no such task is staged, and ErrStagedNameTaken, leaving the task
+// unchanged, if another task with the new feature and name is staged.
+func (sm *StagingManager) UpdateTask(sessionID, featureName, name string, update func(t *models.Task)) (bool, error) {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return false, nil
+	}
+
+	for _, t := range items.Tasks {
+		if t.FeatureName != featureName || t.Name != name {
+			continue
+		}
+
+		updated := *t
+		update(&updated)
+		if updated.FeatureName != featureName || updated.Name != name {
+			for _, other := range items.Tasks {
+				if other != t && other.FeatureName == updated.FeatureName && other.Name == updated.Name {
+					return true, fmt.Errorf("%w: task %s/%s is already staged", ErrStagedNameTaken, updated.FeatureName, updated.Name)
+				}
+			}
+		}
+		*t = updated
+		for _, d := range items.Dependencies {
+			if d.FeatureName == featureName && d.TaskName == name {
+				d.FeatureName, d.TaskName = t.FeatureName, t.Name
+			}
+			if d.DependsOnFeatureName == featureName && d.DependsOnTaskName == name {
+				d.DependsOnFeatureName, d.DependsOnTaskName = t.FeatureName, t.Name
+			}
+		}
+		sm.changed(sessionID)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// RemoveFeature removes a staged feature along with any staged tasks and
+// dependencies that belong to it. Returns false if no such feature is staged.
+func (sm *StagingManager) RemoveFeature(sessionID, name string) bool {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return false
+	}
+
+	found := false
+	features := items.Features[:0]
+	for _, f := range items.Features {
+		if f.Name == name {
+			found = true
+			continue
+		}
+		features = append(features, f)
+	}
+	if !found {
+		return false
+	}
+	items.Features = features
+
+	tasks := items.Tasks[:0]
+	for _, t := range items.Tasks {
+		if t.FeatureName != name {
+			tasks = append(tasks, t)
+		}
+	}
+	items.Tasks = tasks
+
+	deps := items.Dependencies[:0]
+	for _, d := range items.Dependencies {
+		if d.FeatureName != name && d.DependsOnFeatureName != name {
+			deps = append(deps, d)
+		}
+	}
+	items.Dependencies = deps
+
+	sm.changed(sessionID)
+	return true
+}
+
+// RemoveTask removes a staged task along with any staged dependencies that
+// reference it. Returns false if no such task is staged.
+func (sm *StagingManager) RemoveTask(sessionID, featureName, name string) bool {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return false
+	}
+
+	found := false
+	tasks := items.Tasks[:0]
+	for _, t := range items.Tasks {
+		if t.FeatureName == featureName && t.Name == name {
+			found = true
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	if !found {
+		return false
+	}
+	items.Tasks = tasks
+
+	deps := items.Dependencies[:0]
+	for _, d := range items.Dependencies {
+		if d.FeatureName == featureName && d.TaskName == name {
+			continue
+		}
+		if d.DependsOnFeatureName == featureName && d.DependsOnTaskName == name {
+			continue
+		}
+		deps = append(deps, d)
+	}
+	items.Dependencies = deps
+
+	sm.changed(sessionID)
+	return true
+}
+
+// RemoveDependency removes a staged dependency matching the given task and
+// prerequisite names. Returns false if no such dependency is staged.
+func (sm *StagingManager) RemoveDependency(sessionID string, dep *models.Dependency) bool {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return false
+	}
+
+	for i, d := range items.Dependencies {
+		if d.FeatureName == dep.FeatureName && d.TaskName == dep.TaskName &&
+			d.DependsOnFeatureName == dep.DependsOnFeatureName && d.DependsOnTaskName == dep.DependsOnTaskName {
+			items.Dependencies = append(items.Dependencies[:i], items.Dependencies[i+1:]...)
+			sm.changed(sessionID)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Discard drops all staged changes for a session and returns how many items were removed.
+func (sm *StagingManager) Discard(sessionID string) int {
+	sm.refresh(sessionID)
+	sm.mu.Lock()
+	defer sm.unlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return 0
+	}
+
+	delete(sm.staged, sessionID)
+	sm.changed(sessionID)
+	return len(items.Features) + len(items.Tasks) + len(items.Dependencies)
+}
+
+// Copy returns a deep copy of the staged changes for a session, which the
+// caller may modify without affecting what is staged.
+func (sm *StagingManager) Copy(sessionID string) *StagedItems {
+	sm.refresh(sessionID)
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	items, ok := sm.staged[sessionID]
+	if !ok {
+		return &StagedItems{
+			Features:     []*models.Feature{},
+			Tasks:        []*models.Task{},
+			Dependencies: []*models.Dependency{},
+		}
+	}
+	return copyItems(items)
+}
+
+// copyItems returns a deep copy of items.
+func copyItems(items *StagedItems) *StagedItems {
+	out := &StagedItems{
+		Features:     []*models.Feature{},
+		Tasks:        []*models.Task{},
+		Dependencies: []*models.Dependency{},
+	}
+	for _, f := range items.Features {
+		c := *f
+		out.Features = append(out.Features, &c)
+	}
+	for _, t := range items.Tasks {
+		c := *t
+		out.Tasks = append(out.Tasks, &c)
+	}
+	for _, d := range items.Dependencies {
+		c := *d
+		out.Dependencies = append(out.Dependencies, &c)
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultStagingTTL is how long staged changes are kept after their last
+// change before RunStagingCleanup drops them.
+const DefaultStagingTTL = 7 * 24 * time.Hour
+
+// PersistStaging loads the changes staged by earlier processes from the
+// staged_changes table into db.Staging, then keeps the table up to date with
+// every staging change. Each session is reloaded from the table before it's
+// used, so processes sharing a session see each other's changes. Errors
+// loading or saving a change are passed to onError, since staging itself
+// can't fail.
+func (db *DB) PersistStaging(ctx context.Context, onError func(error)) error {
+	rows, err := db.read().QueryContext(ctx, `SELECT session_id, items, updated_at FROM staged_changes`)
+	if err != nil {
+		return fmt.Errorf("failed to load staged changes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionID, data string
+		var updated time.Time
+		if err := rows.Scan(&sessionID, &data, &updated); err != nil {
+			return fmt.Errorf("failed to scan staged changes: %w", err)
+		}
+		var items StagedItems
+		if err := json.Unmarshal([]byte(data), &items); err != nil {
+			return fmt.Errorf("failed to decode staged changes of session %s: %w", sessionID, err)
+		}
+		db.Staging.Restore(sessionID, &items, updated)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	load := func(sessionID string) (*StagedItems, time.Time, error) {
+		items, updated, err := db.loadStagedChanges(context.Background(), sessionID)
+		if err != nil && onError != nil {
+			onError(err)
+		}
+		return items, updated, err
+	}
+	db.Staging.SetPersist(load, func(sessionID string, items *StagedItems, updated time.Time) {
+		if err := db.saveStagedChanges(context.Background(), sessionID, items, updated); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+	return nil
+}
+
+// loadStagedChanges reads the saved staged changes of a session, returning
+// nil items if none are saved.
+func (db *DB) loadStagedChanges(ctx context.Context, sessionID string) (*StagedItems, time.Time, error) {
+	var data string
+	var updated time.Time
+	err := db.read().QueryRowContext(ctx, `SELECT items, updated_at FROM staged_changes WHERE session_id = ?`, sessionID).Scan(&data, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load staged changes of session %s: %w", sessionID, err)
+	}
+	var items StagedItems
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode staged changes of session %s: %w", sessionID, err)
+	}
+	return &items, updated, nil
+}
+
+// saveStagedChanges writes the staged changes of a session, or deletes them
+// when items is nil.
+func (db *DB) saveStagedChanges(ctx context.Context, sessionID string, items *StagedItems, updated time.Time) error {
+	if items == nil {
+		if _, err := db.ExecContext(ctx, `DELETE FROM staged_changes WHERE session_id = ?`, sessionID); err != nil {
+			return fmt.Errorf("failed to delete staged changes of session %s: %w", sessionID, err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to encode staged changes of session %s: %w", sessionID, err)
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO staged_changes (session_id, items, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (session_id) DO UPDATE SET items = excluded.items, updated_at = excluded.updated_at`,
+		sessionID, string(data), db.dialect.timestamp(updated))
+	if err != nil {
+		return fmt.Errorf("failed to save staged changes of session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// RunStagingCleanup drops the staged changes of sessions left untouched for
+// longer than ttl, straight away and then periodically until ctx is done.
+// A ttl <= 0 keeps staged changes forever.
+func (db *DB) RunStagingCleanup(ctx context.Context, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(ttl, time.Hour))
+	defer ticker.Stop()
+	for {
+		db.Staging.Expire(time.Now().Add(-ttl))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
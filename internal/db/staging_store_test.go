@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestPersistStaging(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ponder.db")
+	ctx := context.Background()
+
+	open := func() *DB {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		if err := db.Init(ctx); err != nil {
+			t.Fatalf("Failed to init database: %v", err)
+		}
+		if err := db.PersistStaging(ctx, func(err error) { t.Errorf("Failed to save staged changes: %v", err) }); err != nil {
+			t.Fatalf("PersistStaging failed: %v", err)
+		}
+		return db
+	}
+
+	db := open()
+	db.Staging.AddFeature("plan", &models.Feature{Name: "api", Description: "d", Specification: "s"})
+	db.Staging.AddTask("plan", &models.Task{FeatureName: "api", Name: "login", Description: "d", Specification: "s", Status: models.TaskStatusPending, Priority: 7})
+	db.Staging.AddTask("plan", &models.Task{FeatureName: "api", Name: "logout", Description: "d", Specification: "s", Status: models.TaskStatusPending})
+	db.Staging.AddDependency("plan", &models.Dependency{FeatureName: "api", TaskName: "logout", DependsOnFeatureName: "api", DependsOnTaskName: "login"})
+	db.Staging.AddTask("scratch", &models.Task{FeatureName: "api", Name: "idea"})
+	db.Staging.Discard("scratch")
+	db.Close()
+
+	// A new process picks up where the old one stopped.
+	db = open()
+	items := db.Staging.Peek("plan")
+	if len(items.Features) != 1 || len(items.Tasks) != 2 || len(items.Dependencies) != 1 {
+		t.Fatalf("Expected the staged plan to be restored, got %+v", items)
+	}
+	if items.Tasks[0].Priority != 7 || items.Dependencies[0].DependsOnTaskName != "login" {
+		t.Errorf("Expected staged details to survive, got %+v and %+v", items.Tasks[0], items.Dependencies[0])
+	}
+	if got := db.Staging.Peek("scratch"); len(got.Tasks) != 0 {
+		t.Errorf("Expected the discarded session to stay discarded, got %+v", got)
+	}
+
+	if err := db.CommitBatch(ctx, "plan"); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	var left int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM staged_changes`).Scan(&left); err != nil {
+		t.Fatalf("Failed to count staged changes: %v", err)
+	}
+	if left != 0 {
+		t.Errorf("Expected committing to clear the saved session, got %d rows", left)
+	}
+	db.Close()
+}
+
+func TestRunStagingCleanup(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db.PersistStaging(ctx, nil); err != nil {
+		t.Fatalf("PersistStaging failed: %v", err)
+	}
+
+	old := &StagedItems{Tasks: []*models.Task{{FeatureName: "api", Name: "stale"}}}
+	db.Staging.Restore("old", old, time.Now().Add(-48*time.Hour))
+	db.Staging.AddTask("fresh", &models.Task{FeatureName: "api", Name: "new"})
+
+	cleanupCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	db.RunStagingCleanup(cleanupCtx, 24*time.Hour)
+
+	if got := db.Staging.Peek("old"); len(got.Tasks) != 0 {
+		t.Errorf("Expected the stale session to be dropped, got %+v", got)
+	}
+	if got := db.Staging.Peek("fresh"); len(got.Tasks) != 1 {
+		t.Errorf("Expected the fresh session to stay, got %+v", got)
+	}
+	var sessions int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM staged_changes`).Scan(&sessions); err != nil {
+		t.Fatalf("Failed to count staged changes: %v", err)
+	}
+	if sessions != 1 {
+		t.Errorf("Expected only the fresh session saved, got %d", sessions)
+	}
+}
+
+func TestPersistStagingSharedSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ponder.db")
+	ctx := context.Background()
+
+	open := func() *DB {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		if err := db.Init(ctx); err != nil {
+			t.Fatalf("Failed to init database: %v", err)
+		}
+		if err := db.PersistStaging(ctx, func(err error) { t.Errorf("Failed to save staged changes: %v", err) }); err != nil {
+			t.Fatalf("PersistStaging failed: %v", err)
+		}
+		return db
+	}
+
+	// Two processes working on the same session.
+	a, b := open(), open()
+	defer a.Close()
+	defer b.Close()
+
+	a.Staging.AddFeature("plan", &models.Feature{Name: "api", Description: "d", Specification: "s"})
+	time.Sleep(10 * time.Millisecond)
+	b.Staging.AddTask("plan", &models.Task{FeatureName: "api", Name: "login", Description: "d", Specification: "s", Status: models.TaskStatusPending})
+	time.Sleep(10 * time.Millisecond)
+	a.Staging.AddTask("plan", &models.Task{FeatureName: "api", Name: "logout", Description: "d", Specification: "s", Status: models.TaskStatusPending})
+
+	items := b.Staging.Copy("plan")
+	if len(items.Features) != 1 || len(items.Tasks) != 2 {
+		t.Fatalf("Expected both processes' changes staged, got %+v", items)
+	}
+
+	// A failed commit keeps the staged changes and their saved copy.
+	b.Staging.AddDependency("plan", &models.Dependency{FeatureName: "api", TaskName: "login", DependsOnFeatureName: "api", DependsOnTaskName: "missing"})
+	if err := b.CommitBatch(ctx, "plan"); err == nil {
+		t.Fatal("Expected committing a dependency on a missing task to fail")
+	}
+	if items := a.Staging.Copy("plan"); len(items.Dependencies) != 1 {
+		t.Fatalf("Expected the staged changes to survive the failed commit, got %+v", items)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	a.Staging.RemoveDependency("plan", &models.Dependency{FeatureName: "api", TaskName: "login", DependsOnFeatureName: "api", DependsOnTaskName: "missing"})
+	if err := b.CommitBatch(ctx, "plan"); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	if items := a.Staging.Peek("plan"); len(items.Tasks) != 0 {
+		t.Errorf("Expected the committed session to be dropped everywhere, got %+v", items)
+	}
+	tasks, err := a.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected 2 committed tasks, got %d", len(tasks))
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
@@ -72,3 +73,142 @@ func TestStagingManagerEmptySession(t *testing.T) {
 		t.Errorf("expected empty staged items, got %v", staged)
 	}
 }
+
+func TestStagingManagerUpdatePropagatesRenames(t *testing.T) {
+	sm := NewStagingManager()
+	s := "session"
+
+	sm.AddFeature(s, &models.Feature{Name: "old-feature"})
+	sm.AddTask(s, &models.Task{FeatureName: "old-feature", Name: "a"})
+	sm.AddTask(s, &models.Task{FeatureName: "old-feature", Name: "b"})
+	sm.AddDependency(s, &models.Dependency{
+		FeatureName: "old-feature", TaskName: "b",
+		DependsOnFeatureName: "old-feature", DependsOnTaskName: "a",
+	})
+
+	if found, err := sm.UpdateFeature(s, "old-feature", func(f *models.Feature) { f.Name = "new-feature" }); !found || err != nil {
+		t.Fatalf("expected staged feature to be renamed, got %v, %v", found, err)
+	}
+	if found, err := sm.UpdateTask(s, "new-feature", "a", func(t *models.Task) { t.Name = "a2" }); !found || err != nil {
+		t.Fatalf("expected staged task to be found under renamed feature, got %v, %v", found, err)
+	}
+	if found, _ := sm.UpdateTask(s, "old-feature", "b", func(t *models.Task) {}); found {
+		t.Error("expected lookup by old feature name to fail")
+	}
+
+	items := sm.Peek(s)
+	for _, task := range items.Tasks {
+		if task.FeatureName != "new-feature" {
+			t.Errorf("expected task %s to follow feature rename, got %s", task.Name, task.FeatureName)
+		}
+	}
+	dep := items.Dependencies[0]
+	if dep.FeatureName != "new-feature" || dep.DependsOnFeatureName != "new-feature" || dep.DependsOnTaskName != "a2" {
+		t.Errorf("expected dependency to follow renames, got %+v", dep)
+	}
+}
+
+func TestStagingManagerRejectsRenameCollisions(t *testing.T) {
+	sm := NewStagingManager()
+	s := "session"
+
+	sm.AddFeature(s, &models.Feature{Name: "api"})
+	sm.AddFeature(s, &models.Feature{Name: "web"})
+	sm.AddTask(s, &models.Task{FeatureName: "api", Name: "login"})
+	sm.AddTask(s, &models.Task{FeatureName: "api", Name: "logout"})
+	sm.AddTask(s, &models.Task{FeatureName: "web", Name: "login"})
+	sm.AddTask(s, &models.Task{FeatureName: "ui", Name: "login"})
+
+	tests := []struct {
+		name   string
+		update func() (bool, error)
+	}{
+		{"feature onto staged feature", func() (bool, error) {
+			return sm.UpdateFeature(s, "web", func(f *models.Feature) { f.Name = "api" })
+		}},
+		{"feature onto tasks of the same name", func() (bool, error) {
+			return sm.UpdateFeature(s, "web", func(f *models.Feature) { f.Name = "ui"; f.Description = "changed" })
+		}},
+		{"task rename", func() (bool, error) {
+			return sm.UpdateTask(s, "api", "logout", func(t *models.Task) { t.Name = "login" })
+		}},
+		{"task move", func() (bool, error) {
+			return sm.UpdateTask(s, "web", "login", func(t *models.Task) { t.FeatureName = "api"; t.Priority = 3 })
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := tt.update()
+			if !found || !errors.Is(err, ErrStagedNameTaken) {
+				t.Errorf("expected ErrStagedNameTaken, got %v, %v", found, err)
+			}
+		})
+	}
+
+	items := sm.Peek(s)
+	if items.Features[1].Name != "web" || items.Features[1].Description != "" {
+		t.Errorf("expected the rejected feature update to change nothing, got %+v", items.Features[1])
+	}
+	for _, task := range items.Tasks {
+		if task.Priority != 0 {
+			t.Errorf("expected the rejected task update to change nothing, got %+v", task)
+		}
+	}
+	if items.Tasks[1].Name != "logout" || items.Tasks[2].FeatureName != "web" {
+		t.Errorf("expected no task renamed, got %+v and %+v", items.Tasks[1], items.Tasks[2])
+	}
+}
+
+func TestStagingManagerRemoveCascades(t *testing.T) {
+	sm := NewStagingManager()
+	s := "session"
+
+	sm.AddFeature(s, &models.Feature{Name: "f1"})
+	sm.AddFeature(s, &models.Feature{Name: "f2"})
+	sm.AddTask(s, &models.Task{FeatureName: "f1", Name: "a"})
+	sm.AddTask(s, &models.Task{FeatureName: "f2", Name: "b"})
+	sm.AddTask(s, &models.Task{FeatureName: "f2", Name: "c"})
+	sm.AddDependency(s, &models.Dependency{FeatureName: "f2", TaskName: "b", DependsOnFeatureName: "f1", DependsOnTaskName: "a"})
+	sm.AddDependency(s, &models.Dependency{FeatureName: "f2", TaskName: "c", DependsOnFeatureName: "f2", DependsOnTaskName: "b"})
+
+	if !sm.RemoveTask(s, "f2", "b") {
+		t.Fatal("expected staged task to be removed")
+	}
+	items := sm.Peek(s)
+	if len(items.Tasks) != 2 || len(items.Dependencies) != 0 {
+		t.Errorf("expected 2 tasks and 0 dependencies after task removal, got %d and %d", len(items.Tasks), len(items.Dependencies))
+	}
+
+	if !sm.RemoveFeature(s, "f1") {
+		t.Fatal("expected staged feature to be removed")
+	}
+	items = sm.Peek(s)
+	if len(items.Features) != 1 || len(items.Tasks) != 1 || items.Tasks[0].Name != "c" {
+		t.Errorf("expected only f2/c to remain, got %d features and tasks %v", len(items.Features), items.Tasks)
+	}
+
+	if sm.RemoveFeature(s, "f1") {
+		t.Error("expected removing an unstaged feature to report not found")
+	}
+
+	if got := sm.Discard(s); got != 2 {
+		t.Errorf("expected 2 discarded items, got %d", got)
+	}
+	if got := sm.Discard(s); got != 0 {
+		t.Errorf("expected 0 discarded items for empty session, got %d", got)
+	}
+}
+
+func TestStagingManagerRemoveDependency(t *testing.T) {
+	sm := NewStagingManager()
+	s := "session"
+	dep := &models.Dependency{FeatureName: "f", TaskName: "b", DependsOnFeatureName: "f", DependsOnTaskName: "a"}
+	sm.AddDependency(s, dep)
+
+	if !sm.RemoveDependency(s, &models.Dependency{FeatureName: "f", TaskName: "b", DependsOnFeatureName: "f", DependsOnTaskName: "a"}) {
+		t.Fatal("expected staged dependency to be removed")
+	}
+	if len(sm.Peek(s).Dependencies) != 0 {
+		t.Error("expected no staged dependencies after removal")
+	}
+}
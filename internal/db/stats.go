@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// DefaultStatsDays is the period GetProjectStats covers when none is given.
+const DefaultStatsDays = 7
+
+// taskStatuses lists every status so stats report zero counts too.
+var taskStatuses = []models.TaskStatus{
+	models.TaskStatusPending,
+	models.TaskStatusInProgress,
+	models.TaskStatusInReview,
+	models.TaskStatusCompleted,
+	models.TaskStatusBlocked,
+	models.TaskStatusCancelled,
+}
+
+func emptyStatusCounts() map[models.TaskStatus]int {
+	counts := make(map[models.TaskStatus]int, len(taskStatuses))
+	for _, s := range taskStatuses {
+		counts[s] = 0
+	}
+	return counts
+}
+
+// GetProjectStats returns task counts by status and feature, and throughput,
+// average task duration and failure rate over the last days days, today
+// included. Days are UTC calendar days; days <= 0 means DefaultStatsDays.
+func (db *DB) GetProjectStats(ctx context.Context, days int) (*models.ProjectStats, error) {
+	if days <= 0 {
+		days = DefaultStatsDays
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	stats := &models.ProjectStats{Days: days, ByStatus: emptyStatusCounts(), Features: []*models.FeatureStats{}}
+	if err := db.countTasksByFeature(ctx, stats); err != nil {
+		return nil, err
+	}
+	if err := db.completionStats(ctx, stats, since); err != nil {
+		return nil, err
+	}
+	if err := db.failureStats(ctx, stats, since); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// countTasksByFeature fills in the live task counts, overall and per feature.
+func (db *DB) countTasksByFeature(ctx context.Context, stats *models.ProjectStats) error {
+	rows, err := db.read().QueryContext(ctx, `
+		SELECT f.name, t.status, COUNT(t.id)
+		FROM features f
+		LEFT JOIN tasks t ON t.feature_id = f.id
+		GROUP BY f.name, t.status
+		ORDER BY f.name`)
+	if err != nil {
+		return fmt.Errorf("failed to count tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var current *models.FeatureStats
+	for rows.Next() {
+		var name string
+		var status *models.TaskStatus
+		var n int
+		if err := rows.Scan(&name, &status, &n); err != nil {
+			return fmt.Errorf("failed to scan task count: %w", err)
+		}
+		if current == nil || current.FeatureName != name {
+			current = &models.FeatureStats{FeatureName: name, ByStatus: emptyStatusCounts()}
+			stats.Features = append(stats.Features, current)
+		}
+		if status == nil {
+			continue
+		}
+		current.ByStatus[*status] += n
+		current.TotalTasks += n
+		stats.ByStatus[*status] += n
+		stats.TotalTasks += n
+	}
+	return rows.Err()
+}
+
+// completionStats fills in the daily throughput and average duration of
+// tasks completed since since, counting archived tasks as well.
+func (db *DB) completionStats(ctx context.Context, stats *models.ProjectStats, since time.Time) error {
+	query := `
+		SELECT ` + db.dialect.utcDate("completed_at") + `, COUNT(*), SUM(` + db.dialect.secondsBetween("started_at", "completed_at") + `), COUNT(started_at)
+		FROM (
+			SELECT started_at, completed_at FROM tasks WHERE status = 'completed'
+			UNION ALL
+			SELECT started_at, completed_at FROM archived_tasks WHERE status = 'completed'
+		) AS done
+		WHERE completed_at IS NOT NULL AND ` + db.dialect.atOrAfter("completed_at") + `
+		GROUP BY ` + db.dialect.utcDate("completed_at")
+	rows, err := db.read().QueryContext(ctx, query, db.dialect.timestamp(since))
+	if err != nil {
+		return fmt.Errorf("failed to get throughput: %w", err)
+	}
+	defer rows.Close()
+
+	perDay := make(map[string]int)
+	var totalSeconds float64
+	var timed int
+	for rows.Next() {
+		var day string
+		var n, withStart int
+		var seconds *float64
+		if err := rows.Scan(&day, &n, &seconds, &withStart); err != nil {
+			return fmt.Errorf("failed to scan throughput: %w", err)
+		}
+		perDay[day] = n
+		stats.Completed += n
+		if seconds != nil {
+			totalSeconds += *seconds
+			timed += withStart
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	stats.Throughput = make([]*models.DailyThroughput, 0, stats.Days)
+	for d := 0; d < stats.Days; d++ {
+		day := since.AddDate(0, 0, d).Format("2006-01-02")
+		stats.Throughput = append(stats.Throughput, &models.DailyThroughput{Date: day, Completed: perDay[day]})
+	}
+	if timed > 0 {
+		avg := totalSeconds / float64(timed)
+		stats.AvgDurationSeconds = &avg
+	}
+	return nil
+}
+
+// failureStats counts, from the audit log, the tasks that left in_progress
+// since since and how many of them went back to pending or to blocked, as a
+// failed run does.
+func (db *DB) failureStats(ctx context.Context, stats *models.ProjectStats, since time.Time) error {
+	after := db.dialect.jsonText("after_json", "status")
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN ` + after + ` IN ('pending', 'blocked') THEN 1 ELSE 0 END), 0)
+		FROM events
+		WHERE entity_type = ? AND action = ?
+		  AND ` + db.dialect.jsonText("before_json", "status") + ` = 'in_progress'
+		  AND ` + db.dialect.atOrAfter("created_at")
+	err := db.read().QueryRowContext(ctx, query, EntityTask, models.EventStatusChanged, db.dialect.timestamp(since)).
+		Scan(&stats.FinishedRuns, &stats.FailedRuns)
+	if err != nil {
+		return fmt.Errorf("failed to get failure rate: %w", err)
+	}
+	if stats.FinishedRuns > 0 {
+		rate := float64(stats.FailedRuns) / float64(stats.FinishedRuns)
+		stats.FailureRate = &rate
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestGetProjectStats(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	api := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	empty := &models.Feature{Name: "empty", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{api, empty} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	tasks := make(map[string]*models.Task)
+	for _, name := range []string{"done", "flaky", "todo"} {
+		task := &models.Task{FeatureID: api.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		tasks[name] = task
+	}
+	setStatus := func(name string, status models.TaskStatus) {
+		summary := "summary"
+		if err := db.UpdateTaskStatus(ctx, tasks[name].ID, status, &summary); err != nil {
+			t.Fatalf("Failed to set %s to %s: %v", name, status, err)
+		}
+	}
+
+	// One run completes; the other fails once and is then blocked.
+	setStatus("done", models.TaskStatusInProgress)
+	_, err = db.ExecContext(ctx, "UPDATE tasks SET started_at = datetime('now', '-1 hour') WHERE id = ?", tasks["done"].ID)
+	if err != nil {
+		t.Fatalf("Failed to backdate start: %v", err)
+	}
+	setStatus("done", models.TaskStatusCompleted)
+	setStatus("flaky", models.TaskStatusInProgress)
+	setStatus("flaky", models.TaskStatusPending)
+	setStatus("flaky", models.TaskStatusInProgress)
+	setStatus("flaky", models.TaskStatusBlocked)
+
+	stats, err := db.GetProjectStats(ctx, 3)
+	if err != nil {
+		t.Fatalf("GetProjectStats failed: %v", err)
+	}
+
+	if stats.Days != 3 || stats.TotalTasks != 3 {
+		t.Errorf("Expected 3 days and 3 tasks, got %d and %d", stats.Days, stats.TotalTasks)
+	}
+	want := map[models.TaskStatus]int{models.TaskStatusCompleted: 1, models.TaskStatusBlocked: 1, models.TaskStatusPending: 1, models.TaskStatusCancelled: 0}
+	for status, n := range want {
+		if got, ok := stats.ByStatus[status]; !ok || got != n {
+			t.Errorf("Expected %d %s tasks, got %d", n, status, got)
+		}
+	}
+	byFeature := make(map[string]*models.FeatureStats)
+	for _, f := range stats.Features {
+		byFeature[f.FeatureName] = f
+	}
+	if f := byFeature["api"]; f == nil || f.TotalTasks != 3 || f.ByStatus[models.TaskStatusBlocked] != 1 {
+		t.Errorf("Unexpected stats for api: %+v", f)
+	}
+	if f := byFeature["empty"]; f == nil || f.TotalTasks != 0 {
+		t.Errorf("Expected empty feature with no tasks, got %+v", f)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	if len(stats.Throughput) != 3 || stats.Throughput[2].Date != today || stats.Throughput[2].Completed != 1 || stats.Throughput[0].Completed != 0 {
+		t.Errorf("Unexpected throughput: %+v", stats.Throughput)
+	}
+	if stats.Completed != 1 || stats.AvgDurationSeconds == nil || *stats.AvgDurationSeconds < 3590 || *stats.AvgDurationSeconds > 3610 {
+		t.Errorf("Expected one completion taking an hour, got %d and %v", stats.Completed, stats.AvgDurationSeconds)
+	}
+
+	if stats.FinishedRuns != 3 || stats.FailedRuns != 2 || stats.FailureRate == nil {
+		t.Fatalf("Expected 2 of 3 runs to fail, got %d of %d", stats.FailedRuns, stats.FinishedRuns)
+	}
+	if rate := *stats.FailureRate; rate < 0.66 || rate > 0.67 {
+		t.Errorf("Expected a failure rate of 2/3, got %f", rate)
+	}
+
+	stats, err = db.GetProjectStats(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetProjectStats failed: %v", err)
+	}
+	if stats.Days != DefaultStatsDays || len(stats.Throughput) != DefaultStatsDays {
+		t.Errorf("Expected the default period, got %d days", stats.Days)
+	}
+}
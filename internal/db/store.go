@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Store is the storage API Ponder is built on. *DB implements it over SQLite
+// or Postgres, depending on the DSN passed to Open; code that only reads and
+// writes features and tasks should depend on Store rather than *DB.
+type Store interface {
+	Init(ctx context.Context) error
+	Close() error
+
+	CreateFeature(ctx context.Context, f *models.Feature) error
+	GetFeature(ctx context.Context, id string) (*models.Feature, error)
+	GetFeatureByName(ctx context.Context, name string) (*models.Feature, error)
+	ListFeatures(ctx context.Context) ([]*models.Feature, error)
+	UpdateFeature(ctx context.Context, f *models.Feature) error
+	AppendFeatureSpecification(ctx context.Context, id, title, text string) (*models.Feature, error)
+	DeleteFeature(ctx context.Context, id string) error
+
+	CreateTask(ctx context.Context, t *models.Task) error
+	CreateTasks(ctx context.Context, defaultFeature string, tasks []BulkTask) ([]*models.Task, error)
+	GetTask(ctx context.Context, id string) (*models.Task, error)
+	GetTaskByName(ctx context.Context, name string, featureID string) (*models.Task, error)
+	ListTasks(ctx context.Context, status *models.TaskStatus, featureName *string) ([]*models.Task, error)
+	ListTasksFiltered(ctx context.Context, f TaskFilter) ([]*models.Task, int, error)
+	UpdateTask(ctx context.Context, t *models.Task) error
+	UpdateTaskAtVersion(ctx context.Context, t *models.Task, version int) error
+	UpdateTaskWithLabels(ctx context.Context, t *models.Task, version int, labels []string) error
+	UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, summary *string) error
+	UpdateTaskStatusAtVersion(ctx context.Context, id string, version int, status models.TaskStatus, summary *string) error
+	BlockTask(ctx context.Context, id string, reason string) error
+	BlockTaskAtVersion(ctx context.Context, id string, version int, reason string) error
+	UnblockTask(ctx context.Context, id string) error
+	ReopenTask(ctx context.Context, id string, reason string) error
+	AppendTaskSpecification(ctx context.Context, id, title, text string) (*models.Task, error)
+	DeleteTask(ctx context.Context, id string) error
+	GetAvailableTasks(ctx context.Context) ([]*models.Task, error)
+	CountAvailableTasks(ctx context.Context) (int, error)
+	ClaimNextTask(ctx context.Context, claimer models.Claimer, lease time.Duration) (*models.Task, error)
+	ClaimTask(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) (*models.Task, error)
+	RenewClaim(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) error
+	ListClaims(ctx context.Context) ([]*models.Claim, error)
+	ResetInProgressTasks(ctx context.Context) error
+
+	CreateDependency(ctx context.Context, taskID, dependsOnTaskID string) error
+	DeleteDependency(ctx context.Context, taskID, dependsOnTaskID string) error
+	GetDependencies(ctx context.Context, taskID string) ([]*models.Task, error)
+	GetDependents(ctx context.Context, taskID string) ([]*models.Task, error)
+	ListDependencies(ctx context.Context) ([]*models.Dependency, error)
+	CreateFeatureDependency(ctx context.Context, featureID, dependsOnFeatureID string) error
+	DeleteFeatureDependency(ctx context.Context, featureID, dependsOnFeatureID string) error
+	ListFeatureDependencies(ctx context.Context) ([]*models.FeatureDependency, error)
+
+	ListTaskLabels(ctx context.Context, taskID string) ([]string, error)
+	SetTaskLabels(ctx context.Context, taskID string, labels []string) error
+	SetTaskLabelsAtVersion(ctx context.Context, taskID string, version int, labels []string) (int, error)
+
+	AddTaskNote(ctx context.Context, n *models.TaskNote) error
+	ListTaskNotes(ctx context.Context, taskID string) ([]*models.TaskNote, error)
+	LinkTask(ctx context.Context, l *models.TaskLink) error
+	GetTaskLink(ctx context.Context, provider, externalRef string) (*models.TaskLink, error)
+
+	SetTaskRunEnvironment(ctx context.Context, taskID string, e *models.RunEnvironment) error
+	SetFeatureRunEnvironment(ctx context.Context, featureID string, e *models.RunEnvironment) error
+	GetTaskRunEnvironment(ctx context.Context, taskID string) (*models.RunEnvironment, error)
+	GetFeatureRunEnvironment(ctx context.Context, featureID string) (*models.RunEnvironment, error)
+	ResolveRunEnvironment(ctx context.Context, task *models.Task) (*models.RunEnvironment, error)
+
+	CreateTemplate(ctx context.Context, tmpl *models.TaskTemplate) error
+	GetTemplateByName(ctx context.Context, name string) (*models.TaskTemplate, error)
+	ListTemplates(ctx context.Context) ([]*models.TaskTemplate, error)
+	DeleteTemplate(ctx context.Context, id string) error
+	CreateTaskFromTemplate(ctx context.Context, tmpl *models.TaskTemplate, vars map[string]string) (*models.Task, error)
+	MaterializeDueTemplates(ctx context.Context, now time.Time) ([]*models.Task, error)
+
+	RecordTaskUsage(ctx context.Context, u *models.TaskUsage) error
+	RecordModelFallback(ctx context.Context, task *models.Task, from, to string, failures int) error
+	GetUsageTotals(ctx context.Context) (*models.UsageTotals, error)
+	ListFeatureUsage(ctx context.Context) ([]*models.FeatureUsage, error)
+	ListTaskUsage(ctx context.Context, featureName string) ([]*models.TaskUsageTotals, error)
+	RecordRun(ctx context.Context, r *models.Run) error
+	ListTaskRuns(ctx context.Context, taskID string) ([]*models.Run, error)
+
+	ListEvents(ctx context.Context, entityType, entityID string, limit int) ([]*models.Event, error)
+	ListEventsAfter(ctx context.Context, entityType string, afterID int64, limit int) ([]*models.Event, error)
+	GetProjectStats(ctx context.Context, days int) (*models.ProjectStats, error)
+	GetDurationReport(ctx context.Context, days int, feature string) (*models.DurationReport, error)
+	GetBurndownReport(ctx context.Context, days int, feature string) (*models.BurndownReport, error)
+	GetGraphJSON(ctx context.Context) (string, error)
+	GetGraphDelta(ctx context.Context, since time.Time) (*models.GraphDelta, error)
+	AnalyzeGraph(ctx context.Context) (*models.GraphAnalysis, error)
+
+	ArchiveCompleted(ctx context.Context, before time.Time) (*ArchiveResult, error)
+	ListArchivedTasks(ctx context.Context, featureName *string) ([]*models.Task, error)
+	ListArchivedFeatures(ctx context.Context) ([]*models.Feature, error)
+
+	ExportSnapshot(ctx context.Context, path string) error
+	ExportSnapshotWithOptions(ctx context.Context, path string, opts SnapshotOptions) error
+	ImportSnapshot(ctx context.Context, path string) error
+
+	Backup(ctx context.Context, path string) error
+	SetAutoBackup(a AutoBackup)
+
+	SetPriorityAging(a PriorityAging)
+	SetOnChange(fn func(ctx context.Context))
+	WithBatchedChanges(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var _ Store = (*DB)(nil)
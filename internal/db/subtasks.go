@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// checkParentTask verifies that the task with ID taskID can become a subtask
+// of parentID: the parent must exist and must not be the task itself or one
+// of its subtasks, directly or further down.
+func checkParentTask(ctx context.Context, exec executor, taskID, parentID string) error {
+	for id := parentID; ; {
+		if id == taskID {
+			return fmt.Errorf("task %s can't be a subtask of itself or of its own subtasks", taskID)
+		}
+		var next sql.NullString
+		err := exec.QueryRowContext(ctx, "SELECT parent_task_id FROM tasks WHERE id = ?", id).Scan(&next)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("parent task not found: %s", id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check parent task: %w", err)
+		}
+		if !next.Valid {
+			return nil
+		}
+		id = next.String
+	}
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestSubtasks(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "epic", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	newTask := func(name string, parent *models.Task, order models.SubtaskOrder) *models.Task {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending, SubtaskOrder: order}
+		if parent != nil {
+			task.ParentTaskID = &parent.ID
+		}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", name, err)
+		}
+		return task
+	}
+	available := func() map[string]bool {
+		tasks, err := db.GetAvailableTasks(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get available tasks: %v", err)
+		}
+		names := make(map[string]bool)
+		for _, task := range tasks {
+			names[task.Name] = true
+		}
+		return names
+	}
+	finish := func(task *models.Task, status models.TaskStatus) {
+		summary := "done"
+		if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			t.Fatalf("Failed to start %s: %v", task.Name, err)
+		}
+		if err := db.UpdateTaskStatus(ctx, task.ID, status, &summary); err != nil {
+			t.Fatalf("Failed to finish %s: %v", task.Name, err)
+		}
+	}
+
+	rollup := newTask("rollup", nil, "")
+	if rollup.SubtaskOrder != models.SubtaskOrderChildrenFirst {
+		t.Errorf("Expected default subtask order children_first, got %q", rollup.SubtaskOrder)
+	}
+	partA := newTask("part-a", rollup, "")
+	partB := newTask("part-b", rollup, "")
+	design := newTask("design", nil, models.SubtaskOrderParentFirst)
+	build := newTask("build", design, "")
+
+	got := available()
+	if got["rollup"] || got["build"] || !got["part-a"] || !got["part-b"] || !got["design"] {
+		t.Errorf("Expected parts and design to be available, got %v", got)
+	}
+
+	// A cancelled subtask no longer holds the parent back.
+	finish(partA, models.TaskStatusCompleted)
+	finish(partB, models.TaskStatusCancelled)
+	finish(design, models.TaskStatusCompleted)
+	got = available()
+	if !got["rollup"] || !got["build"] {
+		t.Errorf("Expected rollup and build to be available, got %v", got)
+	}
+
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil || claimed == nil {
+		t.Fatalf("Failed to claim next task: %v", err)
+	}
+	if claimed.ID != rollup.ID && claimed.ID != build.ID {
+		t.Errorf("Expected to claim rollup or build, got %s", claimed.Name)
+	}
+	if claimed.ID == build.ID && (claimed.ParentTaskID == nil || *claimed.ParentTaskID != design.ID) {
+		t.Errorf("Expected claimed task to keep its parent, got %v", claimed.ParentTaskID)
+	}
+
+	// A task can't become a subtask of itself or of its own subtasks.
+	rollup.ParentTaskID = &rollup.ID
+	if err := db.UpdateTask(ctx, rollup); err == nil {
+		t.Error("Expected error making a task its own parent")
+	}
+	rollup.ParentTaskID = &partA.ID
+	if err := db.UpdateTask(ctx, rollup); err == nil {
+		t.Error("Expected error making a task a subtask of its subtask")
+	}
+
+	// Deleting a parent turns its subtasks into top-level tasks.
+	if err := db.DeleteTask(ctx, design.ID); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+	orphan, err := db.GetTask(ctx, build.ID)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if orphan.ParentTaskID != nil {
+		t.Errorf("Expected parent to be cleared, got %v", *orphan.ParentTaskID)
+	}
+}
+
+func TestSubtasksSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer src.Close()
+	if err := src.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "epic", Description: "d", Specification: "s"}
+	if err := src.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	// The subtask sorts before its parent in the snapshot.
+	parent := &models.Task{FeatureID: f.ID, Name: "z-parent", Description: "d", Specification: "s", Status: models.TaskStatusPending, SubtaskOrder: models.SubtaskOrderParentFirst}
+	if err := src.CreateTask(ctx, parent); err != nil {
+		t.Fatalf("Failed to create parent: %v", err)
+	}
+	child := &models.Task{FeatureID: f.ID, Name: "a-child", Description: "d", Specification: "s", Status: models.TaskStatusPending, ParentTaskID: &parent.ID}
+	if err := src.CreateTask(ctx, child); err != nil {
+		t.Fatalf("Failed to create child: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := src.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+
+	dst, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := dst.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("Failed to import snapshot: %v", err)
+	}
+
+	gotParent, _ := dst.GetTask(ctx, parent.ID)
+	gotChild, _ := dst.GetTask(ctx, child.ID)
+	if gotParent == nil || gotParent.SubtaskOrder != models.SubtaskOrderParentFirst {
+		t.Errorf("Expected parent with parent_first order, got %+v", gotParent)
+	}
+	if gotChild == nil || gotChild.ParentTaskID == nil || *gotChild.ParentTaskID != parent.ID {
+		t.Errorf("Expected child to keep its parent, got %+v", gotChild)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// SaveTaskFailure stores the failures of a task, replacing those stored
+// before.
+func (db *DB) SaveTaskFailure(ctx context.Context, f *models.TaskFailure) error {
+	var fallback sql.NullString
+	if f.FallbackModel != "" {
+		fallback = sql.NullString{String: f.FallbackModel, Valid: true}
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO task_failures (task_id, fail_count, model_failures, fallback_model, failed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (task_id) DO UPDATE SET
+			fail_count = excluded.fail_count, model_failures = excluded.model_failures,
+			fallback_model = excluded.fallback_model, failed_at = excluded.failed_at`,
+		f.TaskID, f.FailCount, f.ModelFailures, fallback, db.dialect.timestamp(f.FailedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save task failures: %w", err)
+	}
+	return nil
+}
+
+// ClearTaskFailure forgets the failures of a task.
+func (db *DB) ClearTaskFailure(ctx context.Context, taskID string) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM task_failures WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("failed to clear task failures: %w", err)
+	}
+	return nil
+}
+
+// ListTaskFailures returns the stored failures of every task.
+func (db *DB) ListTaskFailures(ctx context.Context) ([]*models.TaskFailure, error) {
+	rows, err := db.read().QueryContext(ctx, `
+		SELECT task_id, fail_count, model_failures, fallback_model, failed_at
+		FROM task_failures
+		ORDER BY task_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []*models.TaskFailure
+	for rows.Next() {
+		f := &models.TaskFailure{}
+		var fallback sql.NullString
+		if err := rows.Scan(&f.TaskID, &f.FailCount, &f.ModelFailures, &fallback, &f.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task failure: %w", err)
+		}
+		f.FallbackModel = fallback.String
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
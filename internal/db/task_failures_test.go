@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskFailures(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	feature := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, feature); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	flaky := &models.Task{FeatureID: feature.ID, Name: "flaky", Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}
+	gone := &models.Task{FeatureID: feature.ID, Name: "gone", Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{flaky, gone} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	failedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, f := range []*models.TaskFailure{
+		{TaskID: flaky.ID, FailCount: 1, ModelFailures: 1, FailedAt: failedAt},
+		{TaskID: flaky.ID, FailCount: 2, ModelFailures: 0, FallbackModel: "m2", FailedAt: failedAt.Add(time.Minute)},
+		{TaskID: gone.ID, FailCount: 1, ModelFailures: 1, FailedAt: failedAt},
+	} {
+		if err := db.SaveTaskFailure(ctx, f); err != nil {
+			t.Fatalf("SaveTaskFailure failed: %v", err)
+		}
+	}
+	if err := db.DeleteTask(ctx, gone.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+
+	failures, err := db.ListTaskFailures(ctx)
+	if err != nil {
+		t.Fatalf("ListTaskFailures failed: %v", err)
+	}
+	if len(failures) != 1 {
+		t.Fatalf("expected the deleted task's failures to go with it, got %d", len(failures))
+	}
+	f := failures[0]
+	if f.TaskID != flaky.ID || f.FailCount != 2 || f.ModelFailures != 0 || f.FallbackModel != "m2" || !f.FailedAt.Equal(failedAt.Add(time.Minute)) {
+		t.Errorf("expected the latest failures to replace the first, got %+v", f)
+	}
+
+	if err := db.ClearTaskFailure(ctx, flaky.ID); err != nil {
+		t.Fatalf("ClearTaskFailure failed: %v", err)
+	}
+	if failures, err := db.ListTaskFailures(ctx); err != nil || len(failures) != 0 {
+		t.Errorf("expected no failures once cleared, got %v, %v", failures, err)
+	}
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrInvalidTaskFilter is returned when a TaskFilter names an unknown sort
+// field or a negative limit or offset.
+var ErrInvalidTaskFilter = errors.New("invalid task filter")
+
+// TaskFilter selects, orders and pages the tasks returned by
+// ListTasksFiltered. The zero value returns every task in claim order.
+type TaskFilter struct {
+	Status  *models.TaskStatus
+	Feature *string
+	// Label matches tasks that carry it.
+	Label string
+	// Search matches tasks whose name, description or specification contains
+	// it, ignoring case.
+	Search string
+	// Sort is one of the keys of taskSortColumns, optionally prefixed with "-"
+	// to reverse it. Empty means priority order.
+	Sort string
+	// Limit caps the number of tasks returned; 0 means no limit.
+	Limit  int
+	Offset int
+}
+
+// taskSortColumns maps sort names to their ascending ORDER BY clause. Ties
+// are broken by creation time and then ID so that pages are stable.
+var taskSortColumns = map[string]string{
+	"priority": "t.priority DESC, t.position ASC",
+	"name":     "t.name ASC",
+	"feature":  "f.name ASC, t.name ASC",
+	"status":   "t.status ASC",
+	"created":  "t.created_at ASC",
+	"updated":  "t.updated_at ASC",
+}
+
+// TaskSortFields lists the sort names ListTasksFiltered accepts.
+func TaskSortFields() []string {
+	return []string{"priority", "name", "feature", "status", "created", "updated"}
+}
+
+// ListTasksFiltered returns one page of the tasks matching f, together with
+// the total number of matching tasks.
+func (db *DB) ListTasksFiltered(ctx context.Context, f TaskFilter) ([]*models.Task, int, error) {
+	if f.Limit < 0 || f.Offset < 0 {
+		return nil, 0, fmt.Errorf("%w: limit and offset must not be negative", ErrInvalidTaskFilter)
+	}
+	orderBy, err := taskOrderBy(f.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	from := `
+		FROM tasks t
+		LEFT JOIN features f ON t.feature_id = f.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+
+	if f.Status != nil {
+		from += " AND t.status = ?"
+		args = append(args, *f.Status)
+	}
+
+	if f.Feature != nil {
+		from += " AND f.name = ?"
+		args = append(args, *f.Feature)
+	}
+
+	if f.Label != "" {
+		from += " AND EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = t.id AND l.label = ?)"
+		args = append(args, f.Label)
+	}
+
+	if f.Search != "" {
+		from += ` AND (LOWER(t.name) LIKE ? ESCAPE '\' OR LOWER(t.description) LIKE ? ESCAPE '\'
+			OR LOWER(t.specification) LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(strings.ToLower(f.Search)) + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	var total int
+	if err := db.read().QueryRowContext(ctx, "SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	query := `
+		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required,
+		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
+	` + from + " ORDER BY " + orderBy
+
+	if f.Limit > 0 || f.Offset > 0 {
+		// SQLite only accepts OFFSET after a LIMIT.
+		limit := int64(math.MaxInt64)
+		if f.Limit > 0 {
+			limit = int64(f.Limit)
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, f.Offset)
+	}
+
+	tasks, err := db.queryTasks(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	return tasks, total, nil
+}
+
+// taskOrderBy turns a TaskFilter sort name into an ORDER BY clause.
+func taskOrderBy(sort string) (string, error) {
+	if sort == "" {
+		sort = "priority"
+	}
+	desc := strings.HasPrefix(sort, "-")
+	column, ok := taskSortColumns[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown sort %q (expected one of %s)",
+			ErrInvalidTaskFilter, sort, strings.Join(TaskSortFields(), ", "))
+	}
+	if desc {
+		column = strings.NewReplacer(" ASC", " DESC", " DESC", " ASC").Replace(column)
+	}
+	return column + ", t.created_at ASC, t.id ASC", nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestListTasksFiltered(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	auth := &models.Feature{Name: "auth", Description: "d", Specification: "s"}
+	ui := &models.Feature{Name: "ui", Description: "d", Specification: "s"}
+	for _, f := range []*models.Feature{auth, ui} {
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+	}
+	newTask := func(feature *models.Feature, name, description string, priority int) *models.Task {
+		task := &models.Task{FeatureID: feature.ID, Name: name, Description: description, Specification: "s", Priority: priority, Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		return task
+	}
+	newTask(auth, "login", "Login form", 5)
+	newTask(auth, "logout", "Clear the 100% session", 3)
+	newTask(ui, "theme", "Dark mode", 8)
+	started := newTask(ui, "layout", "Grid layout", 1)
+	if err := db.UpdateTaskStatus(ctx, started.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+
+	names := func(tasks []*models.Task) []string {
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.Name)
+		}
+		return out
+	}
+	inProgress := models.TaskStatusInProgress
+	feature := "auth"
+
+	tests := []struct {
+		name  string
+		f     TaskFilter
+		want  []string
+		total int
+	}{
+		{"default priority order", TaskFilter{}, []string{"theme", "login", "logout", "layout"}, 4},
+		{"status", TaskFilter{Status: &inProgress}, []string{"layout"}, 1},
+		{"feature", TaskFilter{Feature: &feature}, []string{"login", "logout"}, 2},
+		{"search ignores case", TaskFilter{Search: "LOG"}, []string{"login", "logout"}, 2},
+		{"search matches description", TaskFilter{Search: "dark"}, []string{"theme"}, 1},
+		{"search escapes wildcards", TaskFilter{Search: "100%"}, []string{"logout"}, 1},
+		{"sort by name", TaskFilter{Sort: "name"}, []string{"layout", "login", "logout", "theme"}, 4},
+		{"reverse sort", TaskFilter{Sort: "-name"}, []string{"theme", "logout", "login", "layout"}, 4},
+		{"limit", TaskFilter{Sort: "name", Limit: 2}, []string{"layout", "login"}, 4},
+		{"offset", TaskFilter{Sort: "name", Limit: 2, Offset: 2}, []string{"logout", "theme"}, 4},
+		{"offset without limit", TaskFilter{Sort: "name", Offset: 3}, []string{"theme"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, total, err := db.ListTasksFiltered(ctx, tt.f)
+			if err != nil {
+				t.Fatalf("ListTasksFiltered failed: %v", err)
+			}
+			got := names(tasks)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+			if total != tt.total {
+				t.Errorf("Expected total %d, got %d", tt.total, total)
+			}
+		})
+	}
+
+	for _, f := range []TaskFilter{{Sort: "bogus"}, {Limit: -1}, {Offset: -1}} {
+		if _, _, err := db.ListTasksFiltered(ctx, f); !errors.Is(err, ErrInvalidTaskFilter) {
+			t.Errorf("Expected ErrInvalidTaskFilter for %+v, got %v", f, err)
+		}
+	}
+}
@@ -3,13 +3,38 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
+// ErrInvalidTransition is returned when a task cannot move to the requested status.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrEmptyBlockedReason is returned when a task is blocked without saying why.
+var ErrEmptyBlockedReason = errors.New("a reason is required to block a task")
+
+// ErrInvalidEstimate is returned when a task's estimate isn't a positive
+// number of minutes.
+var ErrInvalidEstimate = errors.New("estimate must be a positive number of minutes")
+
+// ErrVersionConflict is returned when a task or feature is updated with a
+// version that is no longer the stored one, because someone else changed it
+// in the meantime.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrEmptyReopenReason is returned when a completed task is reopened without
+// saying why.
+var ErrEmptyReopenReason = errors.New("a reason is required to reopen a task")
+
 func (db *DB) CreateTask(ctx context.Context, t *models.Task) error {
-	if err := db.createTask(ctx, db.DB, t); err != nil {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		return db.createTask(ctx, tx, t)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -18,20 +43,24 @@ func (db *DB) CreateTask(ctx context.Context, t *models.Task) error {
 }
 
 func (db *DB) GetTask(ctx context.Context, id string) (*models.Task, error) {
+	return db.getTask(ctx, db.read(), id)
+}
+
+func (db *DB) getTask(ctx context.Context, exec executor, id string) (*models.Task, error) {
 	query := `
 		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required, 
 		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
-		       f.name as feature_name
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
 		FROM tasks t
 		LEFT JOIN features f ON t.feature_id = f.id
 		WHERE t.id = ?
 	`
 	t := &models.Task{}
 	var testsRequired int
-	err := db.QueryRowContext(ctx, query, id).Scan(
+	err := exec.QueryRowContext(ctx, query, id).Scan(
 		&t.ID, &t.FeatureID, &t.Name, &t.Description, &t.Specification, &t.Priority, &testsRequired,
 		&t.Status, &t.CompletionSummary, &t.CreatedAt, &t.UpdatedAt, &t.StartedAt, &t.CompletedAt,
-		&t.FeatureName,
+		&t.NotBefore, &t.DueAt, &t.ParentTaskID, &t.SubtaskOrder, &t.Position, &t.BlockedReason, &t.EstimateMinutes, &t.Version, &t.FeatureName,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -45,14 +74,14 @@ func (db *DB) GetTask(ctx context.Context, id string) (*models.Task, error) {
 }
 
 func (db *DB) GetTaskByName(ctx context.Context, name string, featureID string) (*models.Task, error) {
-	return db.getTaskByName(ctx, db.DB, name, featureID)
+	return db.getTaskByName(ctx, db.read(), name, featureID)
 }
 
 func (db *DB) getTaskByName(ctx context.Context, exec executor, name string, featureID string) (*models.Task, error) {
 	query := `
 		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required, 
 		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
-		       f.name as feature_name
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
 		FROM tasks t
 		LEFT JOIN features f ON t.feature_id = f.id
 		WHERE t.name = ? AND t.feature_id = ?
@@ -62,7 +91,7 @@ func (db *DB) getTaskByName(ctx context.Context, exec executor, name string, fea
 	err := exec.QueryRowContext(ctx, query, name, featureID).Scan(
 		&t.ID, &t.FeatureID, &t.Name, &t.Description, &t.Specification, &t.Priority, &testsRequired,
 		&t.Status, &t.CompletionSummary, &t.CreatedAt, &t.UpdatedAt, &t.StartedAt, &t.CompletedAt,
-		&t.FeatureName,
+		&t.NotBefore, &t.DueAt, &t.ParentTaskID, &t.SubtaskOrder, &t.Position, &t.BlockedReason, &t.EstimateMinutes, &t.Version, &t.FeatureName,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -79,7 +108,7 @@ func (db *DB) ListTasks(ctx context.Context, status *models.TaskStatus, featureN
 	query := `
 		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required, 
 		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
-		       f.name as feature_name
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
 		FROM tasks t
 		LEFT JOIN features f ON t.feature_id = f.id
 		WHERE 1=1
@@ -96,14 +125,14 @@ func (db *DB) ListTasks(ctx context.Context, status *models.TaskStatus, featureN
 		args = append(args, *featureName)
 	}
 
-	query += " ORDER BY t.priority DESC, t.created_at ASC"
+	query += " ORDER BY t.priority DESC, t.position ASC, t.created_at ASC"
 
 	return db.queryTasks(ctx, query, args...)
 }
 
 // queryTasks is a helper to execute a query that returns a list of tasks.
 func (db *DB) queryTasks(ctx context.Context, query string, args ...interface{}) ([]*models.Task, error) {
-	rows, err := db.QueryContext(ctx, query, args...)
+	rows, err := db.read().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +145,7 @@ func (db *DB) queryTasks(ctx context.Context, query string, args ...interface{})
 		err := rows.Scan(
 			&t.ID, &t.FeatureID, &t.Name, &t.Description, &t.Specification, &t.Priority, &testsRequired,
 			&t.Status, &t.CompletionSummary, &t.CreatedAt, &t.UpdatedAt, &t.StartedAt, &t.CompletedAt,
-			&t.FeatureName,
+			&t.NotBefore, &t.DueAt, &t.ParentTaskID, &t.SubtaskOrder, &t.Position, &t.BlockedReason, &t.EstimateMinutes, &t.Version, &t.FeatureName,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
@@ -132,56 +161,247 @@ func (db *DB) queryTasks(ctx context.Context, query string, args ...interface{})
 	return tasks, nil
 }
 
+// UpdateTask saves t's editable fields; its status is left alone. t.Version
+// is set to the new version.
 func (db *DB) UpdateTask(ctx context.Context, t *models.Task) error {
+	return db.updateTask(ctx, t, 0, nil)
+}
+
+// UpdateTaskAtVersion is UpdateTask for a caller that last saw the task at
+// version: if it has changed since, ErrVersionConflict is returned and
+// nothing is saved.
+func (db *DB) UpdateTaskAtVersion(ctx context.Context, t *models.Task, version int) error {
+	return db.updateTask(ctx, t, version, nil)
+}
+
+// UpdateTaskWithLabels is UpdateTaskAtVersion that also replaces the task's
+// labels, in the same transaction: either both are saved or neither is.
+func (db *DB) UpdateTaskWithLabels(ctx context.Context, t *models.Task, version int, labels []string) error {
+	return db.updateTask(ctx, t, version, &labels)
+}
+
+// updateTask saves t, checking the stored version first unless version is 0,
+// and replaces its labels unless labels is nil.
+func (db *DB) updateTask(ctx context.Context, t *models.Task, version int, labels *[]string) error {
 	testsRequired := 0
 	if t.TestsRequired {
 		testsRequired = 1
 	}
 
-	query := `
-		UPDATE tasks
-		SET name = ?, description = ?, specification = ?, priority = ?, tests_required = ?, feature_id = ?
-		WHERE id = ?
-		RETURNING updated_at
-	`
-	err := db.QueryRowContext(ctx, query,
-		t.Name, t.Description, t.Specification, t.Priority, testsRequired, t.FeatureID, t.ID,
-	).Scan(&t.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("task not found: %s", t.ID)
-	}
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := db.getTask(ctx, tx, t.ID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("task not found: %s", t.ID)
+		}
+
+		order, err := models.ParseSubtaskOrder(string(t.SubtaskOrder))
+		if err != nil {
+			return err
+		}
+		t.SubtaskOrder = order
+		if err := checkEstimate(t.EstimateMinutes); err != nil {
+			return err
+		}
+		if t.ParentTaskID != nil {
+			if err := checkParentTask(ctx, tx, t.ID, *t.ParentTaskID); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			UPDATE tasks
+			SET name = ?, description = ?, specification = ?, priority = ?, tests_required = ?, feature_id = ?,
+			    not_before = ?, due_at = ?, parent_task_id = ?, subtask_order = ?, estimate_minutes = ?,
+			    version = version + 1
+			WHERE id = ? AND (? = 0 OR version = ?)
+			RETURNING updated_at, version
+		`
+		err = tx.QueryRowContext(ctx, query,
+			t.Name, t.Description, t.Specification, t.Priority, testsRequired, t.FeatureID,
+			db.timestampArg(t.NotBefore), db.timestampArg(t.DueAt), t.ParentTaskID, t.SubtaskOrder, t.EstimateMinutes,
+			t.ID, version, version,
+		).Scan(&t.UpdatedAt, &t.Version)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: task %s is at version %d, not %d", ErrVersionConflict, before.Name, before.Version, version)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+
+		action := models.EventUpdated
+		if before.Name != t.Name {
+			action = models.EventRenamed
+		}
+		after := *t
+		after.Status = before.Status
+		if err := recordEvent(ctx, tx, EntityTask, t.ID, t.Name, action, snippetOfTask(before), snippetOfTask(&after)); err != nil {
+			return err
+		}
+
+		if labels == nil {
+			return nil
+		}
+		// The version the fields were just saved at covers the labels too.
+		_, err = editTaskLabels(ctx, tx, t.ID, 0, false, func() error {
+			return setTaskLabels(ctx, tx, t.ID, *labels)
+		})
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+		return err
 	}
 
 	db.triggerChange(ctx)
 	return nil
 }
 
+// checkEstimate rejects an estimate that isn't a positive number of minutes.
+// A nil estimate means the task isn't estimated.
+func checkEstimate(minutes *int) error {
+	if minutes != nil && *minutes <= 0 {
+		return ErrInvalidEstimate
+	}
+	return nil
+}
+
 func (db *DB) UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, summary *string) error {
-	// Validate status transition
-	current, err := db.GetTask(ctx, id)
-	if err != nil {
-		return err
+	return db.setTaskStatus(ctx, id, status, summary, nil, false, 0)
+}
+
+// UpdateTaskStatusAtVersion is UpdateTaskStatus for a caller that last saw
+// the task at version: if it has changed since, ErrVersionConflict is
+// returned and nothing is saved.
+func (db *DB) UpdateTaskStatusAtVersion(ctx context.Context, id string, version int, status models.TaskStatus, summary *string) error {
+	return db.setTaskStatus(ctx, id, status, summary, nil, false, version)
+}
+
+// BlockTask marks a task blocked and records why. Blocking a task that is
+// already blocked replaces its reason.
+func (db *DB) BlockTask(ctx context.Context, id string, reason string) error {
+	return db.BlockTaskAtVersion(ctx, id, 0, reason)
+}
+
+// BlockTaskAtVersion is BlockTask for a caller that last saw the task at
+// version; 0 skips the check.
+func (db *DB) BlockTaskAtVersion(ctx context.Context, id string, version int, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrEmptyBlockedReason
 	}
-	if current == nil {
-		return fmt.Errorf("task not found: %s", id)
+	return db.setTaskStatus(ctx, id, models.TaskStatusBlocked, nil, &reason, true, version)
+}
+
+// UnblockTask moves a blocked task back to pending and clears its blocked
+// reason.
+func (db *DB) UnblockTask(ctx context.Context, id string) error {
+	return db.returnToPending(ctx, id, models.TaskStatusBlocked, nil)
+}
+
+// ReopenTask moves a completed task back to pending, clearing its completion
+// summary. The reason is recorded in the task's history.
+func (db *DB) ReopenTask(ctx context.Context, id string, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrEmptyReopenReason
 	}
+	return db.returnToPending(ctx, id, models.TaskStatusCompleted, &reason)
+}
+
+// returnToPending moves a task that has status from back to pending, so it
+// is picked up again like a new task. Completion and blocking details are
+// cleared; reason, if any, is kept with the status change event.
+func (db *DB) returnToPending(ctx context.Context, id string, from models.TaskStatus, reason *string) error {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := db.getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+		if current.Status != from {
+			return fmt.Errorf("%w from %s to %s: task is not %s", ErrInvalidTransition, current.Status, models.TaskStatusPending, from)
+		}
+
+		query := `
+			UPDATE tasks
+			SET status = ?, completion_summary = NULL, blocked_reason = NULL, completed_at = NULL
+			WHERE id = ?
+		`
+		if _, err := tx.ExecContext(ctx, query, models.TaskStatusPending, id); err != nil {
+			return fmt.Errorf("failed to update task status: %w", err)
+		}
+		if err := releaseClaim(ctx, tx, id); err != nil {
+			return err
+		}
 
-	if err := validateStatusTransition(current.Status, status); err != nil {
+		return recordEvent(ctx, tx, EntityTask, id, current.Name, models.EventStatusChanged,
+			statusSnippet{Status: current.Status, CompletionSummary: current.CompletionSummary, BlockedReason: current.BlockedReason},
+			statusSnippet{Status: models.TaskStatusPending, Reason: reason},
+		)
+	})
+	if err != nil {
 		return err
 	}
 
-	query := `
-		UPDATE tasks
-		SET status = ?, completion_summary = ?
-		WHERE id = ?
-		RETURNING updated_at, started_at, completed_at
-	`
-	var t models.Task
-	err = db.QueryRowContext(ctx, query, status, summary, id).Scan(&t.UpdatedAt, &t.StartedAt, &t.CompletedAt)
+	db.triggerChange(ctx)
+	return nil
+}
+
+// setTaskStatus moves a task to status. The blocked reason is set to reason
+// when setReason is true; otherwise a task staying blocked keeps its reason
+// and any other status clears it. A non-zero version must be the task's
+// current version.
+func (db *DB) setTaskStatus(ctx context.Context, id string, status models.TaskStatus, summary, reason *string, setReason bool, version int) error {
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := db.getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
+
+		if version != 0 && current.Version != version {
+			return fmt.Errorf("%w: task %s is at version %d, not %d", ErrVersionConflict, current.Name, current.Version, version)
+		}
+
+		// Validate status transition
+		if err := validateStatusTransition(current.Status, status); err != nil {
+			return err
+		}
+		if !setReason && status == models.TaskStatusBlocked {
+			reason = current.BlockedReason
+		}
+
+		query := `
+			UPDATE tasks
+			SET status = ?, completion_summary = ?, blocked_reason = ?
+			WHERE id = ? AND (? = 0 OR version = ?)
+			RETURNING updated_at, started_at, completed_at
+		`
+		var t models.Task
+		err = tx.QueryRowContext(ctx, query, status, summary, reason, id, version, version).Scan(&t.UpdatedAt, &t.StartedAt, &t.CompletedAt)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: task %s changed while its status was being set", ErrVersionConflict, current.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update task status: %w", err)
+		}
+		if status != models.TaskStatusInProgress {
+			if err := releaseClaim(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		return recordEvent(ctx, tx, EntityTask, id, current.Name, models.EventStatusChanged,
+			statusSnippet{Status: current.Status, CompletionSummary: current.CompletionSummary, BlockedReason: current.BlockedReason},
+			statusSnippet{Status: status, CompletionSummary: summary, BlockedReason: reason},
+		)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
+		return err
 	}
 
 	db.triggerChange(ctx)
@@ -189,44 +409,71 @@ func (db *DB) UpdateTaskStatus(ctx context.Context, id string, status models.Tas
 }
 
 func (db *DB) DeleteTask(ctx context.Context, id string) error {
-	query := `DELETE FROM tasks WHERE id = ?`
-	res, err := db.ExecContext(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
-	}
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := db.getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("task not found: %s", id)
+		}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+		query := `DELETE FROM tasks WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
 
-	if rows == 0 {
-		return fmt.Errorf("task not found: %s", id)
+		return recordEvent(ctx, tx, EntityTask, id, before.Name, models.EventDeleted, snippetOfTask(before), nil)
+	})
+	if err != nil {
+		return err
 	}
 
 	db.triggerChange(ctx)
 	return nil
 }
 
+// timestampArg converts an optional time to a query argument, leaving nil as
+// NULL.
+func (db *DB) timestampArg(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return db.dialect.timestamp(*t)
+}
+
+// GetAvailableTasks returns the tasks ready to be claimed, in the order
+// ClaimNextTask would pick them.
 func (db *DB) GetAvailableTasks(ctx context.Context) ([]*models.Task, error) {
+	priority, args := db.claimPriority("t")
 	query := `
-		SELECT id, feature_id, name, description, specification, priority, tests_required,
-		       status, completion_summary, created_at, updated_at, started_at, completed_at,
-		       feature_name
-		FROM v_available_tasks
-		ORDER BY priority DESC, created_at ASC
+		SELECT t.id, t.feature_id, t.name, t.description, t.specification, t.priority, t.tests_required,
+		       t.status, t.completion_summary, t.created_at, t.updated_at, t.started_at, t.completed_at,
+		       t.not_before, t.due_at, t.parent_task_id, t.subtask_order, t.position, t.blocked_reason, t.estimate_minutes, t.version, f.name as feature_name
+		FROM tasks t
+		LEFT JOIN features f ON t.feature_id = f.id
+		WHERE ` + db.availableWhere("t") + `
+		ORDER BY ` + priority + ` DESC, t.position ASC, t.created_at ASC
 	`
-	return db.queryTasks(ctx, query)
+	return db.queryTasks(ctx, query, args...)
 }
 
+// CountAvailableTasks counts the tasks ready to be claimed that the claim
+// filter lets through. The count comes from the read cache while it is
+// current.
 func (db *DB) CountAvailableTasks(ctx context.Context) (int, error) {
+	return readCached(ctx, db, cacheAvailableCount, db.countAvailableTasks)
+}
+
+func (db *DB) countAvailableTasks(ctx context.Context) (int, error) {
+	filter, args := db.ClaimFilter().where("t")
 	query := `
 		SELECT COUNT(*)
-		FROM v_available_tasks
-	`
+		FROM tasks t
+		WHERE ` + db.availableWhere("t") + filter
 
 	var count int
-	err := db.QueryRowContext(ctx, query).Scan(&count)
+	err := db.read().QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count available tasks: %w", err)
 	}
@@ -234,42 +481,101 @@ func (db *DB) CountAvailableTasks(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// nextTaskQuery selects the ID of the task ClaimNextTask hands out next: the
+// available task of highest claim priority that the claim filter lets
+// through. Given a taskID, it selects that task instead if it is available,
+// whether or not the claim filter lets it through.
+func (db *DB) nextTaskQuery(taskID string) (string, []any) {
+	filter, args := db.ClaimFilter().where("t")
+	if taskID != "" {
+		filter, args = " AND t.id = ?", []any{taskID}
+	}
+	priority, priorityArgs := db.claimPriority("t")
+	args = append(args, priorityArgs...)
+	return `
+			SELECT t.id
+			FROM tasks t
+			WHERE ` + db.availableWhere("t") + filter + `
+			ORDER BY ` + priority + ` DESC, t.position ASC, t.created_at ASC
+			LIMIT 1`, args
+}
+
 // ClaimNextTask atomically claims the next available task by marking it as 'in_progress'.
 // It uses an UPDATE ... RETURNING query to prevent race conditions where multiple
 // workers might claim the same task. Returns nil if no tasks are available.
-func (db *DB) ClaimNextTask(ctx context.Context) (*models.Task, error) {
+// Tasks are ordered by their aged priority when PriorityAging is configured,
+// less the AvailabilityPolicy's penalty for blocked and cancelled dependencies.
+//
+// The claim is recorded for claimer with a lease expiring after lease, which
+// the claimer keeps alive with RenewClaim. Tasks whose lease has expired are
+// put back to pending first, so they can be claimed again.
+func (db *DB) ClaimNextTask(ctx context.Context, claimer models.Claimer, lease time.Duration) (*models.Task, error) {
+	return db.claimTask(ctx, "", claimer, lease)
+}
+
+// ClaimTask claims the task with the given ID the way ClaimNextTask claims
+// the next one, passing over the tasks ahead of it and the claim filter. It
+// returns nil if the task isn't available, e.g. because it was claimed
+// first or its dependencies aren't completed.
+func (db *DB) ClaimTask(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) (*models.Task, error) {
+	if taskID == "" {
+		return nil, nil
+	}
+	return db.claimTask(ctx, taskID, claimer, lease)
+}
+
+// claimTask claims the task nextTaskQuery(taskID) selects.
+func (db *DB) claimTask(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) (*models.Task, error) {
+	next, args := db.nextTaskQuery(taskID)
 	query := `
 		UPDATE tasks
-		SET status = 'in_progress'
-		WHERE id IN (
-			SELECT t.id
-			FROM tasks t
-			WHERE t.status = 'pending'
-			  AND NOT EXISTS (
-				SELECT 1
-				FROM dependencies d
-				JOIN tasks dep_task ON d.depends_on_task_id = dep_task.id
-				WHERE d.task_id = t.id
-				  AND dep_task.status != 'completed'
-			)
-			ORDER BY t.priority DESC, t.created_at ASC
-			LIMIT 1
+		SET status = 'in_progress', version = version + 1
+		WHERE id IN (` + next + db.dialect.lockRows() + `
 		)
 		RETURNING id, feature_id, name, description, specification, priority, tests_required,
-		          status, completion_summary, created_at, updated_at, started_at, completed_at
+		          status, completion_summary, created_at, updated_at, started_at, completed_at,
+		          not_before, due_at, parent_task_id, subtask_order, position, blocked_reason, estimate_minutes, version
 	`
 
 	t := &models.Task{}
 	var testsRequired int
-	err := db.QueryRowContext(ctx, query).Scan(
-		&t.ID, &t.FeatureID, &t.Name, &t.Description, &t.Specification, &t.Priority, &testsRequired,
-		&t.Status, &t.CompletionSummary, &t.CreatedAt, &t.UpdatedAt, &t.StartedAt, &t.CompletedAt,
-	)
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := db.reclaimExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, query, args...).Scan(
+			&t.ID, &t.FeatureID, &t.Name, &t.Description, &t.Specification, &t.Priority, &testsRequired,
+			&t.Status, &t.CompletionSummary, &t.CreatedAt, &t.UpdatedAt, &t.StartedAt, &t.CompletedAt,
+			&t.NotBefore, &t.DueAt, &t.ParentTaskID, &t.SubtaskOrder, &t.Position, &t.BlockedReason, &t.EstimateMinutes, &t.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx, "SELECT name FROM features WHERE id = ?", t.FeatureID).Scan(&t.FeatureName); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO claims (task_id, hostname, pid, worker_id, lease_expires_at)
+			VALUES (?, ?, ?, ?, `+db.dialect.secondsFromNow()+`)
+			ON CONFLICT (task_id) DO UPDATE SET
+				hostname = excluded.hostname, pid = excluded.pid, worker_id = excluded.worker_id,
+				claimed_at = CURRENT_TIMESTAMP, lease_expires_at = excluded.lease_expires_at`,
+			t.ID, claimer.Hostname, claimer.PID, claimer.WorkerID, leaseSeconds(lease))
+		if err != nil {
+			return fmt.Errorf("failed to record claim: %w", err)
+		}
+
+		return recordEvent(ctx, tx, EntityTask, t.ID, t.Name, models.EventStatusChanged,
+			statusSnippet{Status: models.TaskStatusPending},
+			statusSnippet{Status: models.TaskStatusInProgress},
+		)
+	})
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to claim next task: %w", err)
+		return nil, fmt.Errorf("failed to claim task: %w", err)
 	}
 
 	t.TestsRequired = testsRequired == 1
@@ -277,9 +583,92 @@ func (db *DB) ClaimNextTask(ctx context.Context) (*models.Task, error) {
 	return t, nil
 }
 
+// PlanClaims returns the tasks ClaimNextTask would hand out, in order, if
+// each one completed before the next was claimed, without claiming or
+// changing anything. Tasks held back by not_before are left out, as are
+// those that only become available once a running task finishes.
+func (db *DB) PlanClaims(ctx context.Context) ([]*models.Task, error) {
+	next, args := db.nextTaskQuery("")
+
+	// The plan is worked out by completing tasks in a transaction that is
+	// rolled back, so the claim query itself decides the order.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.reclaimExpired(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	var plan []*models.Task
+	for {
+		var id string
+		err := tx.QueryRowContext(ctx, next, args...).Scan(&id)
+		if err == sql.ErrNoRows {
+			return plan, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan claims: %w", err)
+		}
+		t, err := db.getTask(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, t)
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET status = 'completed', completion_summary = '' WHERE id = ?", id); err != nil {
+			return nil, fmt.Errorf("failed to plan claims: %w", err)
+		}
+	}
+}
+
+// ResetInProgressTasks puts in_progress tasks back to pending, except those
+// claimed under a lease that hasn't expired yet: another ponder process may
+// still be working on them.
 func (db *DB) ResetInProgressTasks(ctx context.Context) error {
-	query := `UPDATE tasks SET status = 'pending' WHERE status = 'in_progress'`
-	_, err := db.ExecContext(ctx, query)
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE tasks SET status = 'pending'
+			WHERE status = 'in_progress'
+			  AND NOT EXISTS (
+				SELECT 1 FROM claims c
+				WHERE c.task_id = tasks.id AND ` + db.dialect.secondsSince("c.lease_expires_at") + ` <= 0
+			)
+			RETURNING id, name`
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		var reset [][2]string
+		for rows.Next() {
+			var id, name string
+			if err := rows.Scan(&id, &name); err != nil {
+				rows.Close()
+				return err
+			}
+			reset = append(reset, [2]string{id, name})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range reset {
+			if err := releaseClaim(ctx, tx, r[0]); err != nil {
+				return err
+			}
+			err := recordEvent(ctx, tx, EntityTask, r[0], r[1], models.EventStatusChanged,
+				statusSnippet{Status: models.TaskStatusInProgress},
+				statusSnippet{Status: models.TaskStatusPending},
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to reset in_progress tasks: %w", err)
 	}
@@ -295,22 +684,30 @@ func validateStatusTransition(from, to models.TaskStatus) error {
 
 	switch from {
 	case models.TaskStatusPending:
-		if to != models.TaskStatusInProgress && to != models.TaskStatusBlocked {
-			return fmt.Errorf("invalid transition from %s to %s", from, to)
+		if to != models.TaskStatusInProgress && to != models.TaskStatusBlocked && to != models.TaskStatusCancelled {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
 		}
 	case models.TaskStatusInProgress:
-		if to != models.TaskStatusCompleted && to != models.TaskStatusBlocked && to != models.TaskStatusPending {
-			return fmt.Errorf("invalid transition from %s to %s", from, to)
+		if to != models.TaskStatusCompleted && to != models.TaskStatusBlocked && to != models.TaskStatusPending &&
+			to != models.TaskStatusInReview && to != models.TaskStatusCancelled {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
+		}
+	case models.TaskStatusInReview:
+		// Reviewers approve (completed), send back (in_progress), or cancel.
+		if to != models.TaskStatusCompleted && to != models.TaskStatusInProgress && to != models.TaskStatusCancelled {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
 		}
 	case models.TaskStatusCompleted:
 		// Completed tasks can be moved back to in_progress if needed.
 		if to != models.TaskStatusInProgress {
-			return fmt.Errorf("invalid transition from %s to %s", from, to)
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
 		}
 	case models.TaskStatusBlocked:
-		if to != models.TaskStatusPending && to != models.TaskStatusInProgress {
-			return fmt.Errorf("invalid transition from %s to %s", from, to)
+		if to != models.TaskStatusPending && to != models.TaskStatusInProgress && to != models.TaskStatusCancelled {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
 		}
+	case models.TaskStatusCancelled:
+		return fmt.Errorf("%w from %s to %s: cancelled tasks are final", ErrInvalidTransition, from, to)
 	}
 
 	return nil
@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
@@ -186,7 +189,7 @@ func TestClaimNextTask(t *testing.T) {
 	}
 
 	// 2. Test claiming when no tasks available
-	claimed, err := db.ClaimNextTask(ctx)
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -225,7 +228,7 @@ func TestClaimNextTask(t *testing.T) {
 	}
 
 	// 4. Claim should return highest priority task (task2)
-	claimed, err = db.ClaimNextTask(ctx)
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -238,9 +241,12 @@ func TestClaimNextTask(t *testing.T) {
 	if claimed.Status != models.TaskStatusInProgress {
 		t.Errorf("Expected status in_progress, got %s", claimed.Status)
 	}
+	if claimed.FeatureName != f.Name {
+		t.Errorf("Expected feature name %s, got %q", f.Name, claimed.FeatureName)
+	}
 
 	// 5. Claim should now return next highest priority (task3)
-	claimed, err = db.ClaimNextTask(ctx)
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -252,7 +258,7 @@ func TestClaimNextTask(t *testing.T) {
 	}
 
 	// 6. Claim should now return task1
-	claimed, err = db.ClaimNextTask(ctx)
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -264,7 +270,7 @@ func TestClaimNextTask(t *testing.T) {
 	}
 
 	// 7. No more tasks available
-	claimed, err = db.ClaimNextTask(ctx)
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -323,7 +329,7 @@ func TestClaimNextTaskWithDependencies(t *testing.T) {
 	}
 
 	// 3. Claim should return Task A (Task B is blocked by dependency)
-	claimed, err := db.ClaimNextTask(ctx)
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -341,7 +347,7 @@ func TestClaimNextTaskWithDependencies(t *testing.T) {
 	}
 
 	// 5. Now claim should return Task B (dependency is completed)
-	claimed, err = db.ClaimNextTask(ctx)
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -353,7 +359,7 @@ func TestClaimNextTaskWithDependencies(t *testing.T) {
 	}
 
 	// 6. No more tasks available
-	claimed, err = db.ClaimNextTask(ctx)
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to claim next task: %v", err)
 	}
@@ -442,3 +448,372 @@ func TestResetInProgressTasks(t *testing.T) {
 		t.Errorf("Expected task3 status completed, got %s", t3.Status)
 	}
 }
+
+func TestValidateStatusTransition(t *testing.T) {
+	tests := []struct {
+		from, to models.TaskStatus
+		ok       bool
+	}{
+		{models.TaskStatusInProgress, models.TaskStatusInReview, true},
+		{models.TaskStatusInReview, models.TaskStatusCompleted, true},
+		{models.TaskStatusInReview, models.TaskStatusInProgress, true},
+		{models.TaskStatusInReview, models.TaskStatusPending, false},
+		{models.TaskStatusPending, models.TaskStatusInReview, false},
+		{models.TaskStatusPending, models.TaskStatusCancelled, true},
+		{models.TaskStatusBlocked, models.TaskStatusCancelled, true},
+		{models.TaskStatusInReview, models.TaskStatusCancelled, true},
+		{models.TaskStatusCompleted, models.TaskStatusCancelled, false},
+		{models.TaskStatusCancelled, models.TaskStatusPending, false},
+		{models.TaskStatusCancelled, models.TaskStatusInProgress, false},
+	}
+
+	for _, tt := range tests {
+		err := validateStatusTransition(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s -> %s: expected ok=%v, got %v", tt.from, tt.to, tt.ok, err)
+		}
+	}
+}
+
+func TestReviewAndCancelledDependencies(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	newTask := func(name string) *models.Task {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		return task
+	}
+
+	reviewed := newTask("reviewed")
+	cancelled := newTask("cancelled")
+	afterReview := newTask("after-review")
+	afterCancel := newTask("after-cancel")
+	if err := db.CreateDependency(ctx, afterReview.ID, reviewed.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+	if err := db.CreateDependency(ctx, afterCancel.ID, cancelled.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	summary := "ready for review"
+	if err := db.UpdateTaskStatus(ctx, reviewed.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, reviewed.ID, models.TaskStatusInReview, &summary); err != nil {
+		t.Fatalf("Failed to move task to review: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, cancelled.ID, models.TaskStatusCancelled, nil); err != nil {
+		t.Fatalf("Failed to cancel task: %v", err)
+	}
+
+	available, err := db.GetAvailableTasks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get available tasks: %v", err)
+	}
+	for _, task := range available {
+		if task.ID == afterReview.ID || task.ID == afterCancel.ID {
+			t.Errorf("expected %s to wait on its dependency", task.Name)
+		}
+	}
+
+	if err := db.UpdateTaskStatus(ctx, reviewed.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to approve task: %v", err)
+	}
+	available, err = db.GetAvailableTasks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get available tasks: %v", err)
+	}
+	found := false
+	for _, task := range available {
+		found = found || task.ID == afterReview.ID
+	}
+	if !found {
+		t.Error("expected approved dependency to unblock its dependent")
+	}
+}
+
+func TestPlanClaims(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "plan", Description: "Description", Specification: "Specification"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	taskA := &models.Task{FeatureID: f.ID, Name: "a", Status: models.TaskStatusPending, Priority: 5}
+	taskB := &models.Task{FeatureID: f.ID, Name: "b", Status: models.TaskStatusPending, Priority: 10}
+	taskC := &models.Task{FeatureID: f.ID, Name: "c", Status: models.TaskStatusPending, Priority: 1}
+	for _, task := range []*models.Task{taskA, taskB, taskC} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task %s: %v", task.Name, err)
+		}
+	}
+	// b outranks a but has to wait for it; once a is done it goes before c.
+	if err := db.CreateDependency(ctx, taskB.ID, taskA.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	plan, err := db.PlanClaims(ctx)
+	if err != nil {
+		t.Fatalf("PlanClaims failed: %v", err)
+	}
+	var names []string
+	for _, task := range plan {
+		names = append(names, task.Name)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("expected plan a,b,c, got %v", names)
+	}
+
+	for _, task := range []*models.Task{taskA, taskB, taskC} {
+		got, err := db.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if got.Status != models.TaskStatusPending {
+			t.Errorf("expected %s to stay pending, got %s", task.Name, got.Status)
+		}
+	}
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim next task: %v", err)
+	}
+	if claimed == nil || claimed.ID != taskA.ID {
+		t.Errorf("expected the first claim to match the plan, got %v", claimed)
+	}
+}
+
+func TestClaimNextTaskNotBefore(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "schedule", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	earlier := time.Now().Add(-time.Hour)
+	scheduled := &models.Task{FeatureID: f.ID, Name: "scheduled", Status: models.TaskStatusPending, Priority: 10, NotBefore: &later, DueAt: &later}
+	ready := &models.Task{FeatureID: f.ID, Name: "ready", Status: models.TaskStatusPending, Priority: 1, NotBefore: &earlier}
+	for _, task := range []*models.Task{scheduled, ready} {
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	got, err := db.GetTask(ctx, scheduled.ID)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if got.NotBefore == nil || !got.NotBefore.Equal(later.Truncate(time.Second)) {
+		t.Errorf("Expected not_before %v, got %v", later, got.NotBefore)
+	}
+
+	available, err := db.GetAvailableTasks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get available tasks: %v", err)
+	}
+	if len(available) != 1 || available[0].ID != ready.ID {
+		t.Errorf("Expected only the ready task to be available, got %d tasks", len(available))
+	}
+
+	claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim next task: %v", err)
+	}
+	if claimed == nil || claimed.ID != ready.ID {
+		t.Fatalf("Expected to claim the ready task, got %v", claimed)
+	}
+	if claimed, err := db.ClaimNextTask(ctx, testClaimer, time.Minute); err != nil || claimed != nil {
+		t.Errorf("Expected nothing to claim before not_before, got %v (%v)", claimed, err)
+	}
+
+	// Moving not_before into the past makes the task claimable.
+	scheduled.NotBefore = &earlier
+	if err := db.UpdateTask(ctx, scheduled); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	claimed, err = db.ClaimNextTask(ctx, testClaimer, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim next task: %v", err)
+	}
+	if claimed == nil || claimed.ID != scheduled.ID || claimed.DueAt == nil {
+		t.Errorf("Expected to claim the scheduled task with its due time, got %v", claimed)
+	}
+}
+
+func TestBlockTask(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	reason := func() *string {
+		t.Helper()
+		got, err := db.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		return got.BlockedReason
+	}
+
+	if err := db.BlockTask(ctx, task.ID, "  "); !errors.Is(err, ErrEmptyBlockedReason) {
+		t.Errorf("expected ErrEmptyBlockedReason, got %v", err)
+	}
+	if err := db.BlockTask(ctx, task.ID, "waiting on keys"); err != nil {
+		t.Fatalf("BlockTask failed: %v", err)
+	}
+	if r := reason(); r == nil || *r != "waiting on keys" {
+		t.Errorf("expected the reason to be set, got %v", r)
+	}
+	if err := db.BlockTask(ctx, task.ID, "waiting on ops"); err != nil {
+		t.Fatalf("BlockTask on a blocked task failed: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusBlocked, nil); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	if r := reason(); r == nil || *r != "waiting on ops" {
+		t.Errorf("expected the new reason to be kept, got %v", r)
+	}
+
+	events, err := db.ListEvents(ctx, EntityTask, task.ID, 1)
+	if err != nil || len(events) != 1 || !strings.Contains(string(events[0].After), `"blocked_reason":"waiting on ops"`) {
+		t.Errorf("expected the reason in the status event, got %+v (%v)", events, err)
+	}
+
+	// Snapshots carry the reason.
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := db.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusPending, nil); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	if r := reason(); r != nil {
+		t.Errorf("expected unblocking to clear the reason, got %q", *r)
+	}
+	if err := db.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	if r := reason(); r == nil || *r != "waiting on ops" {
+		t.Errorf("expected the reason to be imported, got %v", r)
+	}
+}
+
+func TestOptimisticVersions(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "api", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "login", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if task.Version != 1 || f.Version != 1 {
+		t.Fatalf("Expected new records at version 1, got task %d and feature %d", task.Version, f.Version)
+	}
+
+	// Every change counts once, including those made by other writers.
+	if err := db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	got, _ := db.GetTask(ctx, task.ID)
+	if got.Version != 2 {
+		t.Fatalf("Expected version 2 after a status change, got %d", got.Version)
+	}
+
+	task.Specification = "stale edit"
+	if err := db.UpdateTaskAtVersion(ctx, task, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, got %v", err)
+	}
+	if got, _ := db.GetTask(ctx, task.ID); got.Specification != "s" {
+		t.Errorf("Expected the stale edit to be refused, got %q", got.Specification)
+	}
+	got.Specification = "fresh edit"
+	if err := db.UpdateTaskAtVersion(ctx, got, 2); err != nil {
+		t.Fatalf("UpdateTaskAtVersion failed: %v", err)
+	}
+	if got.Version != 3 {
+		t.Errorf("Expected the task to be at version 3, got %d", got.Version)
+	}
+	if stored, _ := db.GetTask(ctx, task.ID); stored.Version != 3 || stored.Specification != "fresh edit" {
+		t.Errorf("Expected the fresh edit at version 3, got %q at %d", stored.Specification, stored.Version)
+	}
+
+	if err := db.BlockTaskAtVersion(ctx, task.ID, 2, "waiting"); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict blocking at a stale version, got %v", err)
+	}
+	summary := "done"
+	if err := db.UpdateTaskStatusAtVersion(ctx, task.ID, 3, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("UpdateTaskStatusAtVersion failed: %v", err)
+	}
+
+	f.Description = "changed"
+	if err := db.UpdateFeature(ctx, f); err != nil {
+		t.Fatalf("UpdateFeature failed: %v", err)
+	}
+	if f.Version != 2 {
+		t.Errorf("Expected the feature at version 2, got %d", f.Version)
+	}
+	f.Specification = "stale"
+	if err := db.UpdateFeatureAtVersion(ctx, f, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict for the feature, got %v", err)
+	}
+}
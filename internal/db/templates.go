@@ -0,0 +1,348 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ErrInvalidTemplate is returned for a template that can't be saved or
+// expanded, such as one with an unknown recurrence or a missing variable.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// builtinTemplateVars are the variables every expansion has, at time now.
+// Recurring templates can only use these.
+func builtinTemplateVars(now time.Time) map[string]string {
+	return map[string]string{
+		"date": now.UTC().Format("2006-01-02"),
+	}
+}
+
+// expandTemplateText replaces the {{variable}} placeholders in s with their
+// values, failing on the first variable that has none.
+func expandTemplateText(s string, vars map[string]string) (string, error) {
+	var missing string
+	out := templateVarPattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := templateVarPattern.FindStringSubmatch(m)[1]
+		value, ok := vars[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("%w: no value for variable %q", ErrInvalidTemplate, missing)
+	}
+	return out, nil
+}
+
+// TemplateVariables returns the names of the variables a template's task
+// name and specification use, in order of first use, without the built-in
+// ones.
+func TemplateVariables(tmpl *models.TaskTemplate) []string {
+	builtin := builtinTemplateVars(time.Time{})
+	seen := make(map[string]bool)
+	var names []string
+	for _, s := range []string{tmpl.TaskName, tmpl.Specification} {
+		for _, m := range templateVarPattern.FindAllStringSubmatch(s, -1) {
+			if _, ok := builtin[m[1]]; ok || seen[m[1]] {
+				continue
+			}
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// nextTemplateRun returns when a template with the given recurrence is next
+// due after from. Recurrence is daily, weekly, monthly or a positive
+// duration such as 36h.
+func nextTemplateRun(recurrence string, from time.Time) (time.Time, error) {
+	switch recurrence {
+	case "daily":
+		return from.AddDate(0, 0, 1), nil
+	case "weekly":
+		return from.AddDate(0, 0, 7), nil
+	case "monthly":
+		return from.AddDate(0, 1, 0), nil
+	}
+	d, err := time.ParseDuration(recurrence)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("%w: recurrence must be daily, weekly, monthly or a positive duration, got %q", ErrInvalidTemplate, recurrence)
+	}
+	return from.Add(d), nil
+}
+
+// ExpandTemplate returns the task a template produces with vars, without
+// saving it. The task is pending and belongs to the template's feature.
+func ExpandTemplate(tmpl *models.TaskTemplate, vars map[string]string, now time.Time) (*models.Task, error) {
+	all := builtinTemplateVars(now)
+	for name, value := range vars {
+		all[name] = value
+	}
+	name, err := expandTemplateText(tmpl.TaskName, all)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := expandTemplateText(tmpl.Specification, all)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Task{
+		FeatureID:     tmpl.FeatureID,
+		FeatureName:   tmpl.FeatureName,
+		Name:          strings.TrimSpace(name),
+		Description:   tmpl.Description,
+		Specification: spec,
+		Priority:      tmpl.Priority,
+		TestsRequired: tmpl.TestsRequired,
+		Status:        models.TaskStatusPending,
+	}, nil
+}
+
+// CreateTemplate saves a new template. A recurring template that has no
+// NextRunAt is first due straight away.
+func (db *DB) CreateTemplate(ctx context.Context, tmpl *models.TaskTemplate) error {
+	if strings.TrimSpace(tmpl.Name) == "" || strings.TrimSpace(tmpl.TaskName) == "" {
+		return fmt.Errorf("%w: name and task name are required", ErrInvalidTemplate)
+	}
+	if tmpl.Priority < 0 || tmpl.Priority > 10 {
+		return fmt.Errorf("%w: priority must be between 0 and 10, got %d", ErrInvalidTemplate, tmpl.Priority)
+	}
+	if tmpl.Recurrence != "" {
+		if _, err := nextTemplateRun(tmpl.Recurrence, time.Now()); err != nil {
+			return err
+		}
+		// Nobody is around to fill in variables when the orchestrator
+		// creates the task.
+		if vars := TemplateVariables(tmpl); len(vars) > 0 {
+			return fmt.Errorf("%w: recurring templates can only use built-in variables, found %q", ErrInvalidTemplate, vars[0])
+		}
+		if tmpl.NextRunAt == nil {
+			now := time.Now().UTC().Truncate(time.Second)
+			tmpl.NextRunAt = &now
+		}
+	}
+	if tmpl.ID == "" {
+		tmpl.ID = uuid.New().String()
+	}
+
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		f, err := db.getFeatureByName(ctx, tx, tmpl.FeatureName)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			return fmt.Errorf("feature not found: %s", tmpl.FeatureName)
+		}
+		tmpl.FeatureID = f.ID
+
+		if err := db.insertTemplate(ctx, tx, tmpl); err != nil {
+			return fmt.Errorf("failed to create template: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	db.triggerChange(ctx)
+	return nil
+}
+
+func (db *DB) insertTemplate(ctx context.Context, exec executor, tmpl *models.TaskTemplate) error {
+	testsRequired := 0
+	if tmpl.TestsRequired {
+		testsRequired = 1
+	}
+	query := `
+		INSERT INTO task_templates (id, name, feature_id, task_name, description, specification, priority,
+		                            tests_required, recurrence, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING created_at, updated_at
+	`
+	return exec.QueryRowContext(ctx, query,
+		tmpl.ID, tmpl.Name, tmpl.FeatureID, tmpl.TaskName, tmpl.Description, tmpl.Specification, tmpl.Priority,
+		testsRequired, tmpl.Recurrence, db.timestampArg(tmpl.NextRunAt),
+	).Scan(&tmpl.CreatedAt, &tmpl.UpdatedAt)
+}
+
+const templateColumns = `
+	tt.id, tt.name, tt.feature_id, f.name, tt.task_name, tt.description, tt.specification, tt.priority,
+	tt.tests_required, tt.recurrence, tt.next_run_at, tt.created_at, tt.updated_at
+`
+
+func scanTemplate(row interface{ Scan(...any) error }) (*models.TaskTemplate, error) {
+	tmpl := &models.TaskTemplate{}
+	var testsRequired int
+	err := row.Scan(
+		&tmpl.ID, &tmpl.Name, &tmpl.FeatureID, &tmpl.FeatureName, &tmpl.TaskName, &tmpl.Description,
+		&tmpl.Specification, &tmpl.Priority, &testsRequired, &tmpl.Recurrence, &tmpl.NextRunAt,
+		&tmpl.CreatedAt, &tmpl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.TestsRequired = testsRequired == 1
+	return tmpl, nil
+}
+
+// GetTemplateByName returns the template called name, or nil if there is
+// none.
+func (db *DB) GetTemplateByName(ctx context.Context, name string) (*models.TaskTemplate, error) {
+	query := `SELECT ` + templateColumns + `
+		FROM task_templates tt
+		JOIN features f ON tt.feature_id = f.id
+		WHERE tt.name = ?
+	`
+	tmpl, err := scanTemplate(db.read().QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ListTemplates returns every template, by name.
+func (db *DB) ListTemplates(ctx context.Context) ([]*models.TaskTemplate, error) {
+	query := `SELECT ` + templateColumns + `
+		FROM task_templates tt
+		JOIN features f ON tt.feature_id = f.id
+		ORDER BY tt.name
+	`
+	rows, err := db.read().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*models.TaskTemplate
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, rows.Err()
+}
+
+// DeleteTemplate removes a template. Tasks created from it are kept.
+func (db *DB) DeleteTemplate(ctx context.Context, id string) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM task_templates WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("template not found: %s", id)
+	}
+
+	db.triggerChange(ctx)
+	return nil
+}
+
+// CreateTaskFromTemplate creates the task a template produces with vars.
+func (db *DB) CreateTaskFromTemplate(ctx context.Context, tmpl *models.TaskTemplate, vars map[string]string) (*models.Task, error) {
+	task, err := ExpandTemplate(tmpl, vars, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.CreateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// MaterializeDueTemplates creates a task for every recurring template whose
+// next run has passed, and moves the template on to its next run after now.
+// Runs missed while nothing was polling are skipped rather than caught up
+// on. A task is not created when its feature already has one by that name,
+// so an unfinished task isn't duplicated. It returns the tasks created.
+func (db *DB) MaterializeDueTemplates(ctx context.Context, now time.Time) ([]*models.Task, error) {
+	query := `SELECT ` + templateColumns + `
+		FROM task_templates tt
+		JOIN features f ON tt.feature_id = f.id
+		WHERE tt.recurrence <> '' AND tt.next_run_at IS NOT NULL AND ` + db.dialect.atOrBefore("tt.next_run_at") + `
+		ORDER BY tt.name
+	`
+	cutoff := now.UTC().Format("2006-01-02 15:04:05")
+	rows, err := db.read().QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due templates: %w", err)
+	}
+	var due []*models.TaskTemplate
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		due = append(due, tmpl)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list due templates: %w", err)
+	}
+
+	var created []*models.Task
+	for _, tmpl := range due {
+		next := *tmpl.NextRunAt
+		for !next.After(now) {
+			if next, err = nextTemplateRun(tmpl.Recurrence, next); err != nil {
+				return created, fmt.Errorf("template %s: %w", tmpl.Name, err)
+			}
+		}
+		task, err := ExpandTemplate(tmpl, nil, now)
+		if err != nil {
+			return created, fmt.Errorf("template %s: %w", tmpl.Name, err)
+		}
+
+		made := false
+		err = db.withTx(ctx, func(tx *sql.Tx) error {
+			// Another orchestrator may have got there first.
+			res, err := tx.ExecContext(ctx, `
+				UPDATE task_templates SET next_run_at = ?, updated_at = CURRENT_TIMESTAMP
+				WHERE id = ? AND `+db.dialect.atOrBefore("next_run_at"),
+				db.timestampArg(&next), tmpl.ID, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to schedule template %s: %w", tmpl.Name, err)
+			}
+			if n, err := res.RowsAffected(); err != nil || n == 0 {
+				return err
+			}
+
+			existing, err := db.getTaskByName(ctx, tx, task.Name, task.FeatureID)
+			if err != nil || existing != nil {
+				return err
+			}
+			if err := db.createTask(ctx, tx, task); err != nil {
+				return err
+			}
+			made = true
+			return nil
+		})
+		if err != nil {
+			return created, err
+		}
+		if made {
+			created = append(created, task)
+		}
+	}
+
+	if len(created) > 0 {
+		db.triggerChange(ctx)
+	}
+	return created, nil
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTemplates(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	f := &models.Feature{Name: "chores", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	tmpl := &models.TaskTemplate{
+		Name: "bugfix", FeatureName: "chores", TaskName: "fix-{{ issue }}",
+		Specification: "Fix {{issue}}, reported {{date}}", Priority: 7, TestsRequired: true,
+	}
+	if err := db.CreateTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+	if vars := TemplateVariables(tmpl); len(vars) != 1 || vars[0] != "issue" {
+		t.Errorf("expected variables [issue], got %v", vars)
+	}
+
+	got, err := db.GetTemplateByName(ctx, "bugfix")
+	if err != nil || got == nil || got.FeatureID != f.ID || got.Priority != 7 || !got.TestsRequired || got.NextRunAt != nil {
+		t.Fatalf("unexpected template %+v (%v)", got, err)
+	}
+
+	if _, err := db.CreateTaskFromTemplate(ctx, got, nil); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("expected ErrInvalidTemplate for a missing variable, got %v", err)
+	}
+	task, err := db.CreateTaskFromTemplate(ctx, got, map[string]string{"issue": "42"})
+	if err != nil {
+		t.Fatalf("CreateTaskFromTemplate failed: %v", err)
+	}
+	wantSpec := "Fix 42, reported " + time.Now().UTC().Format("2006-01-02")
+	if task.Name != "fix-42" || task.Specification != wantSpec || task.Priority != 7 || task.Status != models.TaskStatusPending {
+		t.Errorf("unexpected task %+v", task)
+	}
+
+	for _, bad := range []*models.TaskTemplate{
+		{Name: "r1", FeatureName: "chores", TaskName: "t", Recurrence: "fortnightly"},
+		{Name: "r2", FeatureName: "chores", TaskName: "t-{{who}}", Recurrence: "daily"},
+		{Name: "r3", FeatureName: "chores", TaskName: "t", Priority: 11},
+	} {
+		if err := db.CreateTemplate(ctx, bad); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("expected ErrInvalidTemplate for %s, got %v", bad.Name, err)
+		}
+	}
+	if err := db.CreateTemplate(ctx, &models.TaskTemplate{Name: "x", FeatureName: "nope", TaskName: "t"}); err == nil {
+		t.Error("expected error for an unknown feature")
+	}
+
+	if err := db.DeleteTemplate(ctx, got.ID); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if templates, _ := db.ListTemplates(ctx); len(templates) != 0 {
+		t.Errorf("expected no templates, got %d", len(templates))
+	}
+	if err := db.DeleteTemplate(ctx, got.ID); err == nil {
+		t.Error("expected error deleting a missing template")
+	}
+}
+
+func TestMaterializeDueTemplates(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db.CreateFeature(ctx, &models.Feature{Name: "chores", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	weekly := &models.TaskTemplate{
+		Name: "update-deps", FeatureName: "chores", TaskName: "update-deps-{{date}}",
+		Recurrence: "weekly", NextRunAt: &start,
+	}
+	if err := db.CreateTemplate(ctx, weekly); err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+
+	if tasks, err := db.MaterializeDueTemplates(ctx, start.Add(-time.Minute)); err != nil || len(tasks) != 0 {
+		t.Fatalf("expected nothing due yet, got %d (%v)", len(tasks), err)
+	}
+
+	// Two missed weeks create a single task and move on past now.
+	now := start.Add(15 * 24 * time.Hour)
+	tasks, err := db.MaterializeDueTemplates(ctx, now)
+	if err != nil {
+		t.Fatalf("MaterializeDueTemplates failed: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "update-deps-2026-10-16" || tasks[0].FeatureName != "chores" {
+		t.Fatalf("expected update-deps-2026-10-16, got %+v", tasks)
+	}
+	got, _ := db.GetTemplateByName(ctx, "update-deps")
+	if want := start.AddDate(0, 0, 21); got.NextRunAt == nil || !got.NextRunAt.Equal(want) {
+		t.Errorf("expected next run %v, got %v", want, got.NextRunAt)
+	}
+
+	if tasks, err := db.MaterializeDueTemplates(ctx, now); err != nil || len(tasks) != 0 {
+		t.Errorf("expected nothing due after materializing, got %d (%v)", len(tasks), err)
+	}
+
+	// A task of the same name that is still around isn't duplicated.
+	hourly := &models.TaskTemplate{Name: "hourly", FeatureName: "chores", TaskName: "check-{{date}}", Recurrence: "1h", NextRunAt: &start}
+	if err := db.CreateTemplate(ctx, hourly); err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+	if tasks, _ := db.MaterializeDueTemplates(ctx, start.Add(time.Minute)); len(tasks) != 1 {
+		t.Fatalf("expected one hourly task, got %d", len(tasks))
+	}
+	if tasks, _ := db.MaterializeDueTemplates(ctx, start.Add(61*time.Minute)); len(tasks) != 0 {
+		t.Errorf("expected the existing task not to be duplicated, got %+v", tasks)
+	}
+}
+
+func TestTemplateSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer src.Close()
+	if err := src.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := src.CreateFeature(ctx, &models.Feature{Name: "chores", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	next := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	tmpl := &models.TaskTemplate{
+		Name: "update-deps", FeatureName: "chores", TaskName: "update-deps-{{date}}",
+		Specification: "Run the updater", Priority: 3, Recurrence: "weekly", NextRunAt: &next,
+	}
+	if err := src.CreateTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := src.ExportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+
+	dst, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := dst.ImportSnapshot(ctx, path); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+
+	got, err := dst.GetTemplateByName(ctx, "update-deps")
+	if err != nil || got == nil {
+		t.Fatalf("expected template to be imported, got %v", err)
+	}
+	if got.ID != tmpl.ID || got.Specification != "Run the updater" || got.Priority != 3 || got.TestsRequired ||
+		got.Recurrence != "weekly" || got.NextRunAt == nil || !got.NextRunAt.Equal(next) {
+		t.Errorf("unexpected imported template %+v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a span for database work named after the exported DB
+// method that asked for it, e.g. "db.ClaimNextTask".
+func (db *DB) startSpan(ctx context.Context, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !telemetry.Enabled() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	system := "sqlite"
+	if _, ok := db.dialect.(postgresDialect); ok {
+		system = "postgresql"
+	}
+	attrs = append(attrs, attribute.String("db.system.name", system))
+	return telemetry.Start(ctx, "db."+callerMethod(), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// callerMethod returns the name of the innermost exported DB method on the
+// stack, skipping the unexported helpers it calls.
+func callerMethod() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		const prefix = "/internal/db.(*DB)."
+		if i := strings.Index(frame.Function, prefix); i >= 0 {
+			name := frame.Function[i+len(prefix):]
+			// Closures are named Method.func1.
+			name, _, _ = strings.Cut(name, ".")
+			if name != "" && name[0] >= 'A' && name[0] <= 'Z' {
+				return name
+			}
+		}
+		if !more {
+			return "query"
+		}
+	}
+}
+
+// tracedExecutor spans every query it runs.
+type tracedExecutor struct {
+	db   *DB
+	exec executor
+}
+
+func (t tracedExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := t.db.startSpan(ctx, attribute.String("db.query.text", query))
+	res, err := t.exec.ExecContext(ctx, query, args...)
+	telemetry.End(span, err)
+	return res, err
+}
+
+// QueryContext spans running the query, not reading its rows.
+func (t tracedExecutor) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := t.db.startSpan(ctx, attribute.String("db.query.text", query))
+	rows, err := t.exec.QueryContext(ctx, query, args...)
+	telemetry.End(span, err)
+	return rows, err
+}
+
+func (t tracedExecutor) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := t.db.startSpan(ctx, attribute.String("db.query.text", query))
+	row := t.exec.QueryRowContext(ctx, query, args...)
+	telemetry.End(span, row.Err())
+	return row
+}
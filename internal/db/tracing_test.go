@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"github.com/nick-dorsch/ponder/pkg/models"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpansAreNamedAfterDBMethods(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	telemetry.Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := db.CreateFeature(ctx, &models.Feature{Name: "f", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	if _, err := db.ListFeatures(ctx); err != nil {
+		t.Fatalf("Failed to list features: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = true
+	}
+	for _, want := range []string{"db.CreateFeature", "db.ListFeatures"} {
+		if !names[want] {
+			t.Errorf("no %s span among %v", want, names)
+		}
+	}
+}
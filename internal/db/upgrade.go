@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// upgrades bring databases created before schema_migrations existed up to
+// the point where the migrations in embed/sql/migrations start. New schema
+// changes go there instead. Each upgrade must be a no-op on already upgraded
+// databases. They only apply to SQLite.
+var upgrades = []func(ctx context.Context, db *DB) error{
+	upgradeTaskStatuses,
+	upgradeTaskColumns,
+	upgradeFeatureColumns,
+	upgradeRunColumns,
+}
+
+// upgradeTaskStatuses widens the tasks.status CHECK constraint to allow the
+// in_review and cancelled statuses. Loosening a CHECK constraint does not
+// change the on-disk format, so SQLite allows editing the stored table
+// definition in place instead of rebuilding the table.
+func upgradeTaskStatuses(ctx context.Context, db *DB) error {
+	var tableSQL string
+	err := db.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'tasks'").Scan(&tableSQL)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.Contains(tableSQL, "'in_review'") || !strings.Contains(tableSQL, "'blocked'") {
+		return nil
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		var version int
+		if err := tx.QueryRowContext(ctx, "PRAGMA schema_version").Scan(&version); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "PRAGMA writable_schema = ON"); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE sqlite_master
+			SET sql = replace(sql, '''blocked''', '''blocked'', ''in_review'', ''cancelled''')
+			WHERE type = 'table' AND name = 'tasks'`)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA schema_version = %d", version+1)); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "PRAGMA writable_schema = OFF")
+		return err
+	})
+}
+
+// addedTaskColumns are the columns added to tasks after the first release, in
+// the order they were added.
+var addedTaskColumns = []struct{ name, definition string }{
+	{"not_before", "TIMESTAMP"},
+	{"due_at", "TIMESTAMP"},
+	{"parent_task_id", "CHAR(36) REFERENCES tasks(id) ON DELETE SET NULL"},
+	{"subtask_order", "TEXT NOT NULL DEFAULT 'children_first' CHECK (subtask_order IN ('children_first', 'parent_first'))"},
+	{"position", "INTEGER NOT NULL DEFAULT 0"},
+	{"blocked_reason", "TEXT"},
+	{"estimate_minutes", "INTEGER CHECK (estimate_minutes IS NULL OR estimate_minutes > 0)"},
+	{"version", "INTEGER NOT NULL DEFAULT 1"},
+}
+
+// addedFeatureColumns are the columns added to features after the first
+// release, in the order they were added.
+var addedFeatureColumns = []struct{ name, definition string }{
+	{"version", "INTEGER NOT NULL DEFAULT 1"},
+}
+
+// addedRunColumns are the columns added to runs after the first release, in
+// the order they were added.
+var addedRunColumns = []struct{ name, definition string }{
+	{"failure_class", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// upgradeTaskColumns adds the columns in addedTaskColumns that tasks lacks.
+func upgradeTaskColumns(ctx context.Context, db *DB) error {
+	return addColumns(ctx, db, "tasks", addedTaskColumns)
+}
+
+// upgradeFeatureColumns adds the columns in addedFeatureColumns that
+// features lacks.
+func upgradeFeatureColumns(ctx context.Context, db *DB) error {
+	return addColumns(ctx, db, "features", addedFeatureColumns)
+}
+
+// upgradeRunColumns adds the columns in addedRunColumns that runs lacks.
+func upgradeRunColumns(ctx context.Context, db *DB) error {
+	return addColumns(ctx, db, "runs", addedRunColumns)
+}
+
+// addColumns adds the columns that table lacks.
+func addColumns(ctx context.Context, db *DB, table string, added []struct{ name, definition string }) error {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info('"+table+"')")
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// No table yet: the schema creates it with the columns.
+	if len(columns) == 0 {
+		return nil
+	}
+
+	for _, column := range added {
+		if columns[column.name] {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column.name+" "+column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// legacyTasksSchema is the tasks table as created before the in_review and
+// cancelled statuses existed.
+const legacyTasksSchema = `
+CREATE TABLE features (
+  id CHAR(36) PRIMARY KEY,
+  name VARCHAR(55) NOT NULL UNIQUE,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE tasks (
+  id CHAR(36) PRIMARY KEY,
+  feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  name VARCHAR(55) NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0 CHECK(priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+  status TEXT DEFAULT 'pending' CHECK(
+    status IN (
+      'pending',
+      'in_progress',
+      'completed',
+      'blocked'
+    )
+  ),
+  completion_summary TEXT,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP,
+  completed_at TIMESTAMP,
+
+  CHECK (status != 'completed' OR completion_summary IS NOT NULL),
+  UNIQUE(name, feature_id)
+);
+
+INSERT INTO features (id, name, description, specification) VALUES ('f1', 'legacy', 'd', 's');
+INSERT INTO tasks (id, feature_id, name, description, specification, status) VALUES ('t1', 'f1', 'old', 'd', 's', 'pending');
+`
+
+func TestUpgradeTaskStatuses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	ctx := context.Background()
+
+	legacy, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := legacy.ExecContext(ctx, legacyTasksSchema); err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+	if _, err := legacy.ExecContext(ctx, "UPDATE tasks SET status = 'cancelled' WHERE id = 't1'"); err == nil {
+		t.Fatal("expected legacy schema to reject the cancelled status")
+	}
+	legacy.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	// Init twice to make sure the upgrade is idempotent.
+	for i := 0; i < 2; i++ {
+		if err := db.Init(ctx); err != nil {
+			t.Fatalf("Init %d failed: %v", i+1, err)
+		}
+	}
+
+	if err := db.UpdateTaskStatus(ctx, "t1", models.TaskStatusCancelled, nil); err != nil {
+		t.Fatalf("expected cancelled to be allowed after upgrade: %v", err)
+	}
+	task, err := db.GetTask(ctx, "t1")
+	if err != nil || task == nil || task.Status != models.TaskStatusCancelled || task.Name != "old" {
+		t.Errorf("unexpected task after upgrade: %+v (%v)", task, err)
+	}
+
+	var integrity string
+	if err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&integrity); err != nil || integrity != "ok" {
+		t.Errorf("integrity check failed: %s (%v)", integrity, err)
+	}
+}
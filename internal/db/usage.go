@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// RecordTaskUsage stores the usage of one agent run on a task.
+func (db *DB) RecordTaskUsage(ctx context.Context, u *models.TaskUsage) error {
+	query := `
+		INSERT INTO task_usage (task_id, model, tokens_in, tokens_out, cost_usd, estimated)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at
+	`
+	err := db.QueryRowContext(ctx, query,
+		u.TaskID, u.Model, u.TokensIn, u.TokensOut, u.CostUSD, u.Estimated,
+	).Scan(&u.ID, &u.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record task usage: %w", err)
+	}
+	return nil
+}
+
+// modelSnippet is the audit log view of the model a task runs with.
+type modelSnippet struct {
+	Model    string `json:"model"`
+	Failures int    `json:"failures,omitempty"`
+}
+
+// RecordModelFallback notes in the audit log that task failed failures times
+// with model from and will be retried with model to.
+func (db *DB) RecordModelFallback(ctx context.Context, task *models.Task, from, to string, failures int) error {
+	return recordEvent(ctx, db, EntityTask, task.ID, task.Name, models.EventModelFallback,
+		modelSnippet{Model: from, Failures: failures}, modelSnippet{Model: to})
+}
+
+// GetUsageTotals returns the usage of every recorded run, including runs of
+// archived tasks.
+func (db *DB) GetUsageTotals(ctx context.Context) (*models.UsageTotals, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(tokens_in), 0), COALESCE(SUM(tokens_out), 0), COALESCE(SUM(cost_usd), 0)
+		FROM (
+			SELECT tokens_in, tokens_out, cost_usd FROM task_usage
+			UNION ALL
+			SELECT tokens_in, tokens_out, cost_usd FROM archived_task_usage
+		) AS runs
+	`
+	t := &models.UsageTotals{}
+	if err := db.read().QueryRowContext(ctx, query).Scan(&t.Runs, &t.TokensIn, &t.TokensOut, &t.CostUSD); err != nil {
+		return nil, fmt.Errorf("failed to get usage totals: %w", err)
+	}
+	return t, nil
+}
+
+// ListFeatureUsage returns usage per feature, most expensive first. Features
+// without recorded runs are omitted.
+func (db *DB) ListFeatureUsage(ctx context.Context) ([]*models.FeatureUsage, error) {
+	query := `
+		SELECT f.name, COUNT(*), SUM(u.tokens_in), SUM(u.tokens_out), SUM(u.cost_usd)
+		FROM task_usage u
+		JOIN tasks t ON u.task_id = t.id
+		JOIN features f ON t.feature_id = f.id
+		GROUP BY f.id
+		ORDER BY SUM(u.cost_usd) DESC, SUM(u.tokens_in) + SUM(u.tokens_out) DESC, f.name
+	`
+	rows, err := db.read().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []*models.FeatureUsage{}
+	for rows.Next() {
+		u := &models.FeatureUsage{}
+		if err := rows.Scan(&u.FeatureName, &u.Runs, &u.TokensIn, &u.TokensOut, &u.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan feature usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// ListTaskUsage returns usage per task, most expensive first. If featureName
+// is non-empty only that feature's tasks are included.
+func (db *DB) ListTaskUsage(ctx context.Context, featureName string) ([]*models.TaskUsageTotals, error) {
+	query := `
+		SELECT t.id, t.name, f.name, COUNT(*), SUM(u.tokens_in), SUM(u.tokens_out), SUM(u.cost_usd)
+		FROM task_usage u
+		JOIN tasks t ON u.task_id = t.id
+		JOIN features f ON t.feature_id = f.id
+		WHERE ? = '' OR f.name = ?
+		GROUP BY t.id, f.id
+		ORDER BY SUM(u.cost_usd) DESC, SUM(u.tokens_in) + SUM(u.tokens_out) DESC, f.name, t.name
+	`
+	rows, err := db.read().QueryContext(ctx, query, featureName, featureName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []*models.TaskUsageTotals{}
+	for rows.Next() {
+		u := &models.TaskUsageTotals{}
+		if err := rows.Scan(&u.TaskID, &u.TaskName, &u.FeatureName, &u.Runs, &u.TokensIn, &u.TokensOut, &u.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan task usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
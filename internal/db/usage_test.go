@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskUsage(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	totals, err := db.GetUsageTotals(ctx)
+	if err != nil || totals.Runs != 0 || totals.CostUSD != 0 {
+		t.Fatalf("expected empty totals, got %+v (%v)", totals, err)
+	}
+
+	var tasks []*models.Task
+	for _, name := range []string{"cheap", "pricey"} {
+		f := &models.Feature{Name: name, Description: "d", Specification: "s"}
+		if err := db.CreateFeature(ctx, f); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+		task := &models.Task{FeatureID: f.ID, Name: name + "-task", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := db.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	runs := []*models.TaskUsage{
+		{TaskID: tasks[0].ID, Model: "m", TokensIn: 100, TokensOut: 10, CostUSD: 0.01},
+		{TaskID: tasks[1].ID, Model: "m", TokensIn: 1000, TokensOut: 200, CostUSD: 0.5},
+		{TaskID: tasks[1].ID, Model: "m", TokensIn: 500, TokensOut: 100, CostUSD: 0.25, Estimated: true},
+	}
+	for _, u := range runs {
+		if err := db.RecordTaskUsage(ctx, u); err != nil {
+			t.Fatalf("RecordTaskUsage failed: %v", err)
+		}
+		if u.ID == 0 {
+			t.Error("expected usage ID to be set")
+		}
+	}
+	if err := db.RecordTaskUsage(ctx, &models.TaskUsage{TaskID: tasks[0].ID, TokensIn: -1}); err == nil {
+		t.Error("expected error for negative token count")
+	}
+
+	totals, err = db.GetUsageTotals(ctx)
+	if err != nil {
+		t.Fatalf("GetUsageTotals failed: %v", err)
+	}
+	if totals.Runs != 3 || totals.TokensIn != 1600 || totals.TokensOut != 310 || totals.CostUSD < 0.759 || totals.CostUSD > 0.761 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+
+	features, err := db.ListFeatureUsage(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatureUsage failed: %v", err)
+	}
+	if len(features) != 2 || features[0].FeatureName != "pricey" || features[0].Runs != 2 {
+		t.Errorf("expected pricey feature first with 2 runs, got %+v", features)
+	}
+
+	perTask, err := db.ListTaskUsage(ctx, "cheap")
+	if err != nil {
+		t.Fatalf("ListTaskUsage failed: %v", err)
+	}
+	if len(perTask) != 1 || perTask[0].TaskName != "cheap-task" || perTask[0].TokensIn != 100 {
+		t.Errorf("unexpected task usage: %+v", perTask)
+	}
+}
+
+func TestRecordModelFallback(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := db.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := db.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	if err := db.RecordModelFallback(ctx, task, "model-a", "model-b", 2); err != nil {
+		t.Fatalf("RecordModelFallback failed: %v", err)
+	}
+	events, err := db.ListEvents(ctx, EntityTask, task.ID, 1)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one event, got %v (%v)", events, err)
+	}
+	e := events[0]
+	if e.Action != models.EventModelFallback {
+		t.Errorf("expected %s, got %s", models.EventModelFallback, e.Action)
+	}
+	if string(e.Before) != `{"model":"model-a","failures":2}` || string(e.After) != `{"model":"model-b"}` {
+		t.Errorf("unexpected event payload: %s -> %s", e.Before, e.After)
+	}
+}
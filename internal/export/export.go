@@ -0,0 +1,219 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Store is the subset of the database needed to build an export.
+type Store interface {
+	ListFeatures(ctx context.Context) ([]*models.Feature, error)
+	ListTasks(ctx context.Context, status *models.TaskStatus, featureName *string) ([]*models.Task, error)
+	ListDependencies(ctx context.Context) ([]*models.Dependency, error)
+}
+
+// Format is an output format supported by Write.
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+)
+
+// ParseFormat validates a user-supplied format name.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case FormatMarkdown, "markdown":
+		return FormatMarkdown, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s (expected md, csv, or json)", s)
+	}
+}
+
+// Plan is the feature/task hierarchy rendered by an export.
+type Plan struct {
+	Features []*FeaturePlan `json:"features"`
+}
+
+// FeaturePlan is a feature together with its tasks.
+type FeaturePlan struct {
+	*models.Feature
+	Tasks []*TaskPlan `json:"tasks"`
+}
+
+// TaskPlan is a task together with the "feature/task" names it depends on.
+type TaskPlan struct {
+	*models.Task
+	DependsOn []string `json:"depends_on"`
+}
+
+// Build loads the plan from the store. If featureName is non-empty only that
+// feature is included.
+func Build(ctx context.Context, store Store, featureName string) (*Plan, error) {
+	features, err := store.ListFeatures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter *string
+	if featureName != "" {
+		filter = &featureName
+	}
+	tasks, err := store.ListTasks(ctx, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	deps, err := store.ListDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, d := range deps {
+		dependsOn[d.TaskID] = append(dependsOn[d.TaskID], d.DependsOnFeatureName+"/"+d.DependsOnTaskName)
+	}
+
+	plan := &Plan{Features: []*FeaturePlan{}}
+	byID := make(map[string]*FeaturePlan)
+	for _, f := range features {
+		if featureName != "" && f.Name != featureName {
+			continue
+		}
+		fp := &FeaturePlan{Feature: f, Tasks: []*TaskPlan{}}
+		byID[f.ID] = fp
+		plan.Features = append(plan.Features, fp)
+	}
+
+	if featureName != "" && len(plan.Features) == 0 {
+		return nil, fmt.Errorf("feature not found: %s", featureName)
+	}
+
+	sort.Slice(plan.Features, func(i, j int) bool {
+		return plan.Features[i].Name < plan.Features[j].Name
+	})
+
+	for _, t := range tasks {
+		fp, ok := byID[t.FeatureID]
+		if !ok {
+			continue
+		}
+		deps := dependsOn[t.ID]
+		if deps == nil {
+			deps = []string{}
+		}
+		fp.Tasks = append(fp.Tasks, &TaskPlan{Task: t, DependsOn: deps})
+	}
+
+	return plan, nil
+}
+
+// Write renders the plan to w in the given format.
+func Write(w io.Writer, format Format, plan *Plan) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, plan)
+	case FormatCSV:
+		return writeCSV(w, plan)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func writeMarkdown(w io.Writer, plan *Plan) error {
+	var sb strings.Builder
+	sb.WriteString("# Ponder Plan\n")
+
+	for _, f := range plan.Features {
+		fmt.Fprintf(&sb, "\n## %s\n\n", f.Name)
+		if f.Description != "" {
+			fmt.Fprintf(&sb, "%s\n\n", f.Description)
+		}
+
+		if len(f.Tasks) == 0 {
+			sb.WriteString("_No tasks._\n")
+			continue
+		}
+
+		for _, t := range f.Tasks {
+			check := " "
+			if t.Status == models.TaskStatusCompleted {
+				check = "x"
+			}
+			fmt.Fprintf(&sb, "- [%s] **%s** (%s, priority %d)\n", check, t.Name, t.Status, t.Priority)
+			if t.Description != "" {
+				fmt.Fprintf(&sb, "  - %s\n", t.Description)
+			}
+			if len(t.DependsOn) > 0 {
+				fmt.Fprintf(&sb, "  - Depends on: %s\n", strings.Join(t.DependsOn, ", "))
+			}
+			if t.CompletionSummary != nil && *t.CompletionSummary != "" {
+				fmt.Fprintf(&sb, "  - Summary: %s\n", *t.CompletionSummary)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeCSV(w io.Writer, plan *Plan) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"feature", "task", "status", "priority", "tests_required", "depends_on",
+		"description", "completion_summary", "created_at", "completed_at",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, f := range plan.Features {
+		for _, t := range f.Tasks {
+			summary := ""
+			if t.CompletionSummary != nil {
+				summary = *t.CompletionSummary
+			}
+			completedAt := ""
+			if t.CompletedAt != nil {
+				completedAt = t.CompletedAt.UTC().Format(time.RFC3339)
+			}
+
+			record := []string{
+				f.Name,
+				t.Name,
+				string(t.Status),
+				strconv.Itoa(t.Priority),
+				strconv.FormatBool(t.TestsRequired),
+				strings.Join(t.DependsOn, "; "),
+				t.Description,
+				summary,
+				t.CreatedAt.UTC().Format(time.RFC3339),
+				completedAt,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
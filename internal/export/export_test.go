@@ -0,0 +1,164 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func setupPlanDB(t *testing.T) *db.DB {
+	t.Helper()
+
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "auth", Description: "User authentication", Specification: "spec"}
+	if err := database.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	hashing := &models.Task{FeatureID: f.ID, Name: "hashing", Description: "Hash passwords", Specification: "s", Priority: 9, Status: models.TaskStatusPending}
+	login := &models.Task{FeatureID: f.ID, Name: "login", Description: "Login endpoint", Specification: "s", Priority: 8, Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{hashing, login} {
+		if err := database.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if err := database.CreateDependency(ctx, login.ID, hashing.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	if err := database.UpdateTaskStatus(ctx, hashing.ID, models.TaskStatusInProgress, nil); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	summary := "Used bcrypt"
+	if err := database.UpdateTaskStatus(ctx, hashing.ID, models.TaskStatusCompleted, &summary); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	return database
+}
+
+func TestBuildFiltersByFeature(t *testing.T) {
+	database := setupPlanDB(t)
+	ctx := context.Background()
+
+	plan, err := Build(ctx, database, "auth")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(plan.Features) != 1 || plan.Features[0].Name != "auth" {
+		t.Fatalf("expected only the auth feature, got %d features", len(plan.Features))
+	}
+	if len(plan.Features[0].Tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(plan.Features[0].Tasks))
+	}
+
+	login := plan.Features[0].Tasks[1]
+	if login.Name != "login" || len(login.DependsOn) != 1 || login.DependsOn[0] != "auth/hashing" {
+		t.Errorf("expected login to depend on auth/hashing, got %v", login.DependsOn)
+	}
+
+	if _, err := Build(ctx, database, "missing"); err == nil {
+		t.Error("expected error for unknown feature")
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	database := setupPlanDB(t)
+	plan, err := Build(context.Background(), database, "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, FormatMarkdown, plan); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{
+			"## auth",
+			"- [x] **hashing** (completed, priority 9)",
+			"- [ ] **login** (pending, priority 8)",
+			"Depends on: auth/hashing",
+			"Summary: Used bcrypt",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("markdown output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, FormatCSV, plan); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to parse csv: %v", err)
+		}
+		// header + misc has no tasks + 2 auth tasks
+		if len(records) != 3 {
+			t.Fatalf("expected 3 csv records, got %d", len(records))
+		}
+		if records[2][1] != "login" || records[2][5] != "auth/hashing" {
+			t.Errorf("unexpected login record: %v", records[2])
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, FormatJSON, plan); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		var decoded struct {
+			Features []struct {
+				Name  string `json:"name"`
+				Tasks []struct {
+					Name              string   `json:"name"`
+					Status            string   `json:"status"`
+					DependsOn         []string `json:"depends_on"`
+					CompletionSummary *string  `json:"completion_summary"`
+				} `json:"tasks"`
+			} `json:"features"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("failed to decode json: %v", err)
+		}
+		if len(decoded.Features) != 2 {
+			t.Fatalf("expected 2 features, got %d", len(decoded.Features))
+		}
+		auth := decoded.Features[0]
+		if auth.Name != "auth" || auth.Tasks[0].CompletionSummary == nil {
+			t.Errorf("expected auth feature with completion summary, got %+v", auth)
+		}
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"md": FormatMarkdown, "markdown": FormatMarkdown, "CSV": FormatCSV, "json": FormatJSON} {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
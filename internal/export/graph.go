@@ -0,0 +1,205 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// GraphFormat is a dependency diagram format supported by WriteGraph.
+type GraphFormat string
+
+const (
+	GraphMermaid GraphFormat = "mermaid"
+	GraphDOT     GraphFormat = "dot"
+)
+
+// ParseGraphFormat validates a user-supplied graph format name.
+func ParseGraphFormat(s string) (GraphFormat, error) {
+	switch GraphFormat(strings.ToLower(s)) {
+	case GraphMermaid:
+		return GraphMermaid, nil
+	case GraphDOT, "graphviz":
+		return GraphDOT, nil
+	default:
+		return "", fmt.Errorf("unsupported graph format: %s (expected mermaid or dot)", s)
+	}
+}
+
+// statusColors are the fill colors used for each task status.
+var statusColors = map[models.TaskStatus]string{
+	models.TaskStatusPending:    "#e5e7eb",
+	models.TaskStatusInProgress: "#fde68a",
+	models.TaskStatusCompleted:  "#bbf7d0",
+	models.TaskStatusBlocked:    "#fecaca",
+	models.TaskStatusInReview:   "#ddd6fe",
+	models.TaskStatusCancelled:  "#f3f4f6",
+}
+
+// graphNode is a task in the diagram. External nodes are prerequisites that
+// live outside the exported features.
+type graphNode struct {
+	id       string
+	label    string
+	status   models.TaskStatus
+	external bool
+}
+
+type graphEdge struct {
+	from, to string
+}
+
+// graphLayout assigns stable node IDs and resolves dependency labels to them.
+// featureEdges run between indexes into plan.Features.
+type graphLayout struct {
+	features     [][]*graphNode
+	external     []*graphNode
+	edges        []graphEdge
+	featureEdges [][2]int
+}
+
+func layoutGraph(plan *Plan) *graphLayout {
+	l := &graphLayout{}
+	byLabel := make(map[string]*graphNode)
+
+	n := 0
+	for _, f := range plan.Features {
+		nodes := make([]*graphNode, 0, len(f.Tasks))
+		for _, t := range f.Tasks {
+			node := &graphNode{id: fmt.Sprintf("t%d", n), label: t.Name, status: t.Status}
+			n++
+			byLabel[f.Name+"/"+t.Name] = node
+			nodes = append(nodes, node)
+		}
+		l.features = append(l.features, nodes)
+	}
+
+	for _, f := range plan.Features {
+		for _, t := range f.Tasks {
+			to := byLabel[f.Name+"/"+t.Name]
+			for _, dep := range t.DependsOn {
+				from, ok := byLabel[dep]
+				if !ok {
+					from = &graphNode{id: fmt.Sprintf("t%d", n), label: dep, external: true}
+					n++
+					byLabel[dep] = from
+					l.external = append(l.external, from)
+				}
+				l.edges = append(l.edges, graphEdge{from: from.id, to: to.id})
+			}
+		}
+	}
+
+	// Feature dependencies on features outside the plan are left out.
+	featureIndex := make(map[string]int, len(plan.Features))
+	for i, f := range plan.Features {
+		featureIndex[f.Name] = i
+	}
+	for i, f := range plan.Features {
+		for _, dep := range f.DependsOn {
+			if j, ok := featureIndex[dep]; ok {
+				l.featureEdges = append(l.featureEdges, [2]int{j, i})
+			}
+		}
+	}
+
+	return l
+}
+
+// WriteGraph renders the plan's dependency graph. Edges point from a
+// prerequisite to the task that depends on it; dashed edges between features
+// do the same for feature dependencies.
+func WriteGraph(w io.Writer, format GraphFormat, plan *Plan) error {
+	layout := layoutGraph(plan)
+	switch format {
+	case GraphMermaid:
+		return writeMermaid(w, plan, layout)
+	case GraphDOT:
+		return writeDOT(w, plan, layout)
+	default:
+		return fmt.Errorf("unsupported graph format: %s", format)
+	}
+}
+
+func writeMermaid(w io.Writer, plan *Plan, l *graphLayout) error {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	for i, f := range plan.Features {
+		fmt.Fprintf(&sb, "  subgraph f%d[\"%s\"]\n", i, mermaidEscape(f.Name))
+		for _, node := range l.features[i] {
+			fmt.Fprintf(&sb, "    %s[\"%s\"]:::%s\n", node.id, mermaidEscape(node.label), node.status)
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, node := range l.external {
+		fmt.Fprintf(&sb, "  %s([\"%s\"]):::external\n", node.id, mermaidEscape(node.label))
+	}
+	for _, e := range l.edges {
+		fmt.Fprintf(&sb, "  %s --> %s\n", e.from, e.to)
+	}
+	for _, e := range l.featureEdges {
+		fmt.Fprintf(&sb, "  f%d -.-> f%d\n", e[0], e[1])
+	}
+
+	for _, status := range []models.TaskStatus{
+		models.TaskStatusPending, models.TaskStatusInProgress, models.TaskStatusInReview,
+		models.TaskStatusCompleted, models.TaskStatusBlocked, models.TaskStatusCancelled,
+	} {
+		fmt.Fprintf(&sb, "  classDef %s fill:%s,stroke:#374151\n", status, statusColors[status])
+	}
+	sb.WriteString("  classDef external fill:#ffffff,stroke:#9ca3af,stroke-dasharray:4 2\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeDOT(w io.Writer, plan *Plan, l *graphLayout) error {
+	var sb strings.Builder
+	sb.WriteString("digraph ponder {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	if len(l.featureEdges) > 0 {
+		sb.WriteString("  compound=true;\n")
+	}
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+
+	for i, f := range plan.Features {
+		fmt.Fprintf(&sb, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&sb, "    label=%s;\n", dotQuote(f.Name))
+		for _, node := range l.features[i] {
+			fmt.Fprintf(&sb, "    %s [label=%s, fillcolor=%s];\n", node.id, dotQuote(node.label), dotQuote(statusColors[node.status]))
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, node := range l.external {
+		fmt.Fprintf(&sb, "  %s [label=%s, style=\"rounded,dashed\"];\n", node.id, dotQuote(node.label))
+	}
+	for _, e := range l.edges {
+		fmt.Fprintf(&sb, "  %s -> %s;\n", e.from, e.to)
+	}
+	// Graphviz only draws edges between nodes, so a feature dependency runs
+	// between the first tasks of the two clusters, clipped to their borders.
+	// Features without tasks have no node to attach it to.
+	for _, e := range l.featureEdges {
+		from, to := l.features[e[0]], l.features[e[1]]
+		if len(from) == 0 || len(to) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [ltail=cluster_%d, lhead=cluster_%d, style=dashed];\n", from[0].id, to[0].id, e[0], e[1])
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestWriteGraphMermaid(t *testing.T) {
+	database := setupPlanDB(t)
+	plan, err := Build(context.Background(), database, "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGraph(&buf, GraphMermaid, plan); err != nil {
+		t.Fatalf("WriteGraph failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"flowchart LR",
+		`subgraph f0["auth"]`,
+		`t0["hashing"]:::completed`,
+		`t1["login"]:::pending`,
+		"t0 --> t1",
+		"classDef blocked",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("mermaid output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteGraphDOT(t *testing.T) {
+	database := setupPlanDB(t)
+	plan, err := Build(context.Background(), database, "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGraph(&buf, GraphDOT, plan); err != nil {
+		t.Fatalf("WriteGraph failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"digraph ponder {",
+		"subgraph cluster_0 {",
+		`label="auth";`,
+		`t0 [label="hashing"`,
+		"t0 -> t1;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dot output missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "}\n") {
+		t.Errorf("expected dot output to close the graph:\n%s", out)
+	}
+}
+
+func TestWriteGraphExternalDependencies(t *testing.T) {
+	plan := &Plan{Features: []*FeaturePlan{{
+		Feature: &models.Feature{Name: `ui "v2"`},
+		Tasks: []*TaskPlan{{
+			Task:      &models.Task{Name: "page", Status: models.TaskStatusBlocked},
+			DependsOn: []string{"api/endpoint"},
+		}},
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteGraph(&buf, GraphMermaid, plan); err != nil {
+		t.Fatalf("WriteGraph failed: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `t1(["api/endpoint"]):::external`) || !strings.Contains(out, "t1 --> t0") {
+		t.Errorf("expected external prerequisite node and edge:\n%s", out)
+	}
+	if !strings.Contains(out, `ui #quot;v2#quot;`) {
+		t.Errorf("expected quotes to be escaped:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := WriteGraph(&buf, GraphDOT, plan); err != nil {
+		t.Fatalf("WriteGraph failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `label="ui \"v2\"";`) {
+		t.Errorf("expected dot label to be escaped:\n%s", buf.String())
+	}
+}
+
+func TestParseGraphFormat(t *testing.T) {
+	if f, err := ParseGraphFormat("Graphviz"); err != nil || f != GraphDOT {
+		t.Errorf("expected graphviz to map to dot, got %v %v", f, err)
+	}
+	if _, err := ParseGraphFormat("png"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestWriteGraphFeatureDependencies(t *testing.T) {
+	plan := &Plan{Features: []*FeaturePlan{
+		{
+			Feature: &models.Feature{Name: "auth"},
+			Tasks:   []*TaskPlan{{Task: &models.Task{Name: "login", Status: models.TaskStatusPending}}},
+		},
+		{
+			Feature: &models.Feature{Name: "billing", DependsOn: []string{"auth", "elsewhere"}},
+			Tasks:   []*TaskPlan{{Task: &models.Task{Name: "invoices", Status: models.TaskStatusPending}}},
+		},
+	}}
+
+	var mermaid, dot bytes.Buffer
+	if err := WriteGraph(&mermaid, GraphMermaid, plan); err != nil {
+		t.Fatalf("WriteGraph mermaid failed: %v", err)
+	}
+	if err := WriteGraph(&dot, GraphDOT, plan); err != nil {
+		t.Fatalf("WriteGraph dot failed: %v", err)
+	}
+
+	if !strings.Contains(mermaid.String(), "f0 -.-> f1") {
+		t.Errorf("mermaid output missing the feature edge:\n%s", mermaid.String())
+	}
+	if strings.Count(mermaid.String(), "-.->") != 1 {
+		t.Errorf("expected features outside the plan to be left out:\n%s", mermaid.String())
+	}
+	for _, want := range []string{"compound=true;", "t0 -> t1 [ltail=cluster_0, lhead=cluster_1, style=dashed];"} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("dot output missing %q:\n%s", want, dot.String())
+		}
+	}
+}
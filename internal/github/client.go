@@ -0,0 +1,155 @@
+// Package github imports GitHub issues as Ponder tasks and keeps the two in
+// sync.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the GitHub REST API endpoint.
+const DefaultBaseURL = "https://api.github.com"
+
+// Client is a minimal GitHub REST API client.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for api.github.com. token may be empty for
+// public repositories, but closing issues requires one.
+func NewClient(token string) *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Issue is the subset of a GitHub issue used by the importer.
+type Issue struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"`
+	HTMLURL     string     `json:"html_url"`
+	Labels      []Label    `json:"labels"`
+	Milestone   *Milestone `json:"milestone"`
+	PullRequest *struct{}  `json:"pull_request,omitempty"`
+}
+
+// Label is a GitHub issue label.
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Milestone is a GitHub milestone.
+type Milestone struct {
+	Title string `json:"title"`
+}
+
+// Closed reports whether the issue is closed.
+func (i *Issue) Closed() bool {
+	return i.State == "closed"
+}
+
+// ListIssues returns all issues in repo ("owner/name") with the given state
+// (open, closed, or all). Pull requests are skipped.
+func (c *Client) ListIssues(ctx context.Context, repo, state string) ([]Issue, error) {
+	const perPage = 100
+
+	var issues []Issue
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("state", state)
+		q.Set("per_page", fmt.Sprint(perPage))
+		q.Set("page", fmt.Sprint(page))
+
+		var batch []Issue
+		if err := c.do(ctx, http.MethodGet, "/repos/"+repo+"/issues?"+q.Encode(), nil, &batch); err != nil {
+			return nil, fmt.Errorf("failed to list issues for %s: %w", repo, err)
+		}
+
+		for _, issue := range batch {
+			if issue.PullRequest == nil {
+				issues = append(issues, issue)
+			}
+		}
+		if len(batch) < perPage {
+			return issues, nil
+		}
+	}
+}
+
+// CloseIssue closes an issue, first posting comment if it is non-empty.
+func (c *Client) CloseIssue(ctx context.Context, repo string, number int, comment string) error {
+	path := fmt.Sprintf("/repos/%s/issues/%d", repo, number)
+
+	if comment != "" {
+		body := map[string]string{"body": comment}
+		if err := c.do(ctx, http.MethodPost, path+"/comments", body, nil); err != nil {
+			return fmt.Errorf("failed to comment on %s#%d: %w", repo, number, err)
+		}
+	}
+
+	body := map[string]string{"state": "closed", "state_reason": "completed"}
+	if err := c.do(ctx, http.MethodPatch, path, body, nil); err != nil {
+		return fmt.Errorf("failed to close %s#%d: %w", repo, number, err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("github returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode github response: %w", err)
+	}
+	return nil
+}
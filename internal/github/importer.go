@@ -0,0 +1,279 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Provider is the task link provider name for GitHub issues.
+const Provider = "github"
+
+// maxNameLen matches the VARCHAR(55) limit on feature and task names.
+const maxNameLen = 55
+
+// GroupBy selects how issues are grouped into features.
+type GroupBy string
+
+const (
+	GroupByMilestone GroupBy = "milestone"
+	GroupByLabel     GroupBy = "label"
+)
+
+// ParseGroupBy validates a user-supplied grouping name.
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch GroupBy(strings.ToLower(s)) {
+	case GroupByMilestone:
+		return GroupByMilestone, nil
+	case GroupByLabel:
+		return GroupByLabel, nil
+	default:
+		return "", fmt.Errorf("unsupported grouping: %s (expected milestone or label)", s)
+	}
+}
+
+// ImportOptions configures an import run.
+type ImportOptions struct {
+	// Repo is the repository in owner/name form.
+	Repo string
+	// GroupBy picks the feature for each issue.
+	GroupBy GroupBy
+	// DefaultFeature receives issues without a milestone or label.
+	DefaultFeature string
+	// IncludeClosed also imports closed issues, as completed tasks.
+	IncludeClosed bool
+	// Sync closes open issues whose tasks have been completed in Ponder.
+	Sync bool
+}
+
+// ImportResult summarizes an import run.
+type ImportResult struct {
+	Created   int
+	Updated   int
+	Completed int
+	Closed    int
+}
+
+// Importer maps GitHub issues onto Ponder tasks.
+type Importer struct {
+	Client *Client
+	DB     *db.DB
+}
+
+// Import creates or updates one task per issue. Tasks are matched to issues
+// through task links, so renaming a task in Ponder does not create a
+// duplicate on the next run. Issues closed on GitHub complete their tasks;
+// with Sync set, tasks completed in Ponder close their issues.
+func (im *Importer) Import(ctx context.Context, opts ImportOptions) (*ImportResult, error) {
+	if opts.Repo == "" || !strings.Contains(opts.Repo, "/") {
+		return nil, fmt.Errorf("repository must be in owner/name form, got %q", opts.Repo)
+	}
+	if opts.GroupBy == "" {
+		opts.GroupBy = GroupByMilestone
+	}
+	if opts.DefaultFeature == "" {
+		opts.DefaultFeature = "github"
+	}
+
+	ctx = actor.With(ctx, "github:"+opts.Repo)
+
+	// Closed issues are needed both to import them and to notice that a
+	// tracked issue was closed on GitHub.
+	state := "open"
+	if opts.IncludeClosed || opts.Sync {
+		state = "all"
+	}
+	issues, err := im.Client.ListIssues(ctx, opts.Repo, state)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	for i := range issues {
+		if err := im.importIssue(ctx, opts, &issues[i], result); err != nil {
+			return result, fmt.Errorf("failed to import issue #%d: %w", issues[i].Number, err)
+		}
+	}
+	return result, nil
+}
+
+func (im *Importer) importIssue(ctx context.Context, opts ImportOptions, issue *Issue, result *ImportResult) error {
+	ref := fmt.Sprintf("%s#%d", opts.Repo, issue.Number)
+
+	task, err := im.findTask(ctx, opts, issue, ref)
+	if err != nil {
+		return err
+	}
+
+	if task == nil {
+		if issue.Closed() && !opts.IncludeClosed {
+			return nil
+		}
+		if task, err = im.createTask(ctx, opts, issue); err != nil {
+			return err
+		}
+		result.Created++
+	} else if changed, err := im.updateTask(ctx, task, issue); err != nil {
+		return err
+	} else if changed {
+		result.Updated++
+	}
+
+	if err := im.DB.LinkTask(ctx, &models.TaskLink{
+		TaskID:      task.ID,
+		Provider:    Provider,
+		ExternalRef: ref,
+		URL:         issue.HTMLURL,
+	}); err != nil {
+		return err
+	}
+
+	switch {
+	case issue.Closed() && task.Status != models.TaskStatusCompleted && task.Status != models.TaskStatusCancelled:
+		if err := completeTask(ctx, im.DB, task, "Issue closed on GitHub"); err != nil {
+			return err
+		}
+		result.Completed++
+	case !issue.Closed() && task.Status == models.TaskStatusCompleted && opts.Sync:
+		comment := fmt.Sprintf("Completed in Ponder (%s/%s).", task.FeatureName, task.Name)
+		if task.CompletionSummary != nil && *task.CompletionSummary != "" {
+			comment += "\n\n" + *task.CompletionSummary
+		}
+		if err := im.Client.CloseIssue(ctx, opts.Repo, issue.Number, comment); err != nil {
+			return err
+		}
+		result.Closed++
+	}
+	return nil
+}
+
+// findTask returns the task tracking an issue, falling back to a task with the
+// issue's generated name so that re-imports adopt tasks created before links
+// existed.
+func (im *Importer) findTask(ctx context.Context, opts ImportOptions, issue *Issue, ref string) (*models.Task, error) {
+	link, err := im.DB.GetTaskLink(ctx, Provider, ref)
+	if err != nil {
+		return nil, err
+	}
+	if link != nil {
+		return im.DB.GetTask(ctx, link.TaskID)
+	}
+
+	f, err := im.DB.GetFeatureByName(ctx, featureName(opts, issue))
+	if err != nil || f == nil {
+		return nil, err
+	}
+	return im.DB.GetTaskByName(ctx, taskName(issue), f.ID)
+}
+
+func (im *Importer) createTask(ctx context.Context, opts ImportOptions, issue *Issue) (*models.Task, error) {
+	name := featureName(opts, issue)
+	f, err := im.DB.GetFeatureByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		f = &models.Feature{
+			Name:          name,
+			Description:   fmt.Sprintf("Issues imported from %s", opts.Repo),
+			Specification: fmt.Sprintf("Imported from GitHub %s of %s.", opts.GroupBy, opts.Repo),
+		}
+		if err := im.DB.CreateFeature(ctx, f); err != nil {
+			return nil, err
+		}
+	}
+
+	task := &models.Task{
+		FeatureID:     f.ID,
+		FeatureName:   f.Name,
+		Name:          taskName(issue),
+		Description:   issue.Title,
+		Specification: taskSpec(issue),
+		Priority:      5,
+		TestsRequired: true,
+		Status:        models.TaskStatusPending,
+	}
+	if err := im.DB.CreateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// updateTask refreshes the description and specification from the issue.
+// The name and feature are left alone so that edits made in Ponder stick.
+func (im *Importer) updateTask(ctx context.Context, task *models.Task, issue *Issue) (bool, error) {
+	spec := taskSpec(issue)
+	if task.Description == issue.Title && task.Specification == spec {
+		return false, nil
+	}
+	task.Description = issue.Title
+	task.Specification = spec
+	if err := im.DB.UpdateTask(ctx, task); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// completeTask walks a task through in_progress, the only status it can be
+// completed from.
+func completeTask(ctx context.Context, database *db.DB, task *models.Task, summary string) error {
+	if task.Status != models.TaskStatusInProgress {
+		if err := database.UpdateTaskStatus(ctx, task.ID, models.TaskStatusInProgress, nil); err != nil {
+			return err
+		}
+	}
+	if err := database.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted, &summary); err != nil {
+		return err
+	}
+	task.Status = models.TaskStatusCompleted
+	task.CompletionSummary = &summary
+	return nil
+}
+
+func featureName(opts ImportOptions, issue *Issue) string {
+	switch opts.GroupBy {
+	case GroupByLabel:
+		if len(issue.Labels) > 0 {
+			return truncate(issue.Labels[0].Name)
+		}
+	case GroupByMilestone:
+		if issue.Milestone != nil && issue.Milestone.Title != "" {
+			return truncate(issue.Milestone.Title)
+		}
+	}
+	return truncate(opts.DefaultFeature)
+}
+
+func taskName(issue *Issue) string {
+	return truncate(fmt.Sprintf("#%d %s", issue.Number, issue.Title))
+}
+
+func taskSpec(issue *Issue) string {
+	var sb strings.Builder
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		sb.WriteString(body)
+		sb.WriteString("\n\n")
+	}
+	if len(issue.Labels) > 0 {
+		names := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			names[i] = l.Name
+		}
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&sb, "Source: %s", issue.HTMLURL)
+	return sb.String()
+}
+
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	r := []rune(s)
+	if len(r) <= maxNameLen {
+		return s
+	}
+	return strings.TrimSpace(string(r[:maxNameLen-1])) + "…"
+}
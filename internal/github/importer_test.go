@@ -0,0 +1,269 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// fakeGitHub serves the issues endpoints used by the importer.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	issues   []Issue
+	comments map[int][]string
+	pages    int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const prefix = "/repos/o/r/issues"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	switch {
+	case r.Method == http.MethodGet && rest == "":
+		f.pages++
+		state := r.URL.Query().Get("state")
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		var matching []Issue
+		for _, issue := range f.issues {
+			if state == "all" || issue.State == state {
+				matching = append(matching, issue)
+			}
+		}
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(matching) {
+			start = len(matching)
+		}
+		if end > len(matching) {
+			end = len(matching)
+		}
+		json.NewEncoder(w).Encode(matching[start:end])
+
+	case r.Method == http.MethodPost && strings.HasSuffix(rest, "/comments"):
+		n, _ := strconv.Atoi(strings.TrimSuffix(rest, "/comments"))
+		var body struct{ Body string }
+		json.NewDecoder(r.Body).Decode(&body)
+		f.comments[n] = append(f.comments[n], body.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+
+	case r.Method == http.MethodPatch:
+		n, _ := strconv.Atoi(rest)
+		var body struct{ State string }
+		json.NewDecoder(r.Body).Decode(&body)
+		for i := range f.issues {
+			if f.issues[i].Number == n {
+				f.issues[i].State = body.State
+			}
+		}
+		w.Write([]byte("{}"))
+
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeGitHub) state(number int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, issue := range f.issues {
+		if issue.Number == number {
+			return issue.State
+		}
+	}
+	return ""
+}
+
+func newTestImporter(t *testing.T, fake *fakeGitHub) *Importer {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.Init(context.Background()); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+
+	client := NewClient("token")
+	client.BaseURL = server.URL
+	return &Importer{Client: client, DB: database}
+}
+
+func issue(number int, title, state string) Issue {
+	return Issue{
+		Number:  number,
+		Title:   title,
+		Body:    "Body of " + title,
+		State:   state,
+		HTMLURL: fmt.Sprintf("https://github.com/o/r/issues/%d", number),
+	}
+}
+
+func TestImport(t *testing.T) {
+	login := issue(1, "Login page", "open")
+	login.Milestone = &Milestone{Title: "v1"}
+	login.Labels = []Label{{Name: "frontend"}}
+	docs := issue(2, "Write docs", "open")
+	done := issue(3, "Old bug", "closed")
+	pr := issue(4, "A pull request", "open")
+	pr.PullRequest = &struct{}{}
+
+	fake := &fakeGitHub{issues: []Issue{login, docs, done, pr}, comments: map[int][]string{}}
+	im := newTestImporter(t, fake)
+	ctx := context.Background()
+
+	result, err := im.Import(ctx, ImportOptions{Repo: "o/r"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Created != 2 {
+		t.Errorf("expected 2 tasks created, got %+v", result)
+	}
+
+	v1, _ := im.DB.GetFeatureByName(ctx, "v1")
+	fallback, _ := im.DB.GetFeatureByName(ctx, "github")
+	if v1 == nil || fallback == nil {
+		t.Fatalf("expected milestone and default features, got %v and %v", v1, fallback)
+	}
+	task, _ := im.DB.GetTaskByName(ctx, "#1 Login page", v1.ID)
+	if task == nil {
+		t.Fatal("expected task for issue #1 in milestone feature")
+	}
+	if !strings.Contains(task.Specification, "Body of Login page") || !strings.Contains(task.Specification, login.HTMLURL) {
+		t.Errorf("unexpected specification: %q", task.Specification)
+	}
+	if pull, _ := im.DB.GetTaskByName(ctx, "#4 A pull request", fallback.ID); pull != nil {
+		t.Error("expected pull requests to be skipped")
+	}
+	if old, _ := im.DB.GetTaskByName(ctx, "#3 Old bug", fallback.ID); old != nil {
+		t.Error("expected closed issues to be skipped by default")
+	}
+
+	// Re-importing updates in place and follows the link even after a rename.
+	task.Name = "login"
+	if err := im.DB.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	fake.issues[0].Body = "New body"
+	result, err = im.Import(ctx, ImportOptions{Repo: "o/r"})
+	if err != nil {
+		t.Fatalf("second Import failed: %v", err)
+	}
+	if result.Created != 0 || result.Updated != 1 {
+		t.Errorf("expected one update and no creates, got %+v", result)
+	}
+	task, _ = im.DB.GetTask(ctx, task.ID)
+	if !strings.Contains(task.Specification, "New body") {
+		t.Errorf("expected specification to be refreshed, got %q", task.Specification)
+	}
+
+	// Completing a task in Ponder closes the issue when syncing.
+	if err := completeTask(ctx, im.DB, task, "shipped"); err != nil {
+		t.Fatalf("completeTask failed: %v", err)
+	}
+	// Closing an issue on GitHub completes its task.
+	fake.issues[1].State = "closed"
+
+	result, err = im.Import(ctx, ImportOptions{Repo: "o/r", Sync: true})
+	if err != nil {
+		t.Fatalf("sync Import failed: %v", err)
+	}
+	if result.Closed != 1 || result.Completed != 1 {
+		t.Errorf("expected one issue closed and one task completed, got %+v", result)
+	}
+	if fake.state(1) != "closed" {
+		t.Error("expected issue #1 to be closed")
+	}
+	if len(fake.comments[1]) != 1 || !strings.Contains(fake.comments[1][0], "shipped") {
+		t.Errorf("expected closing comment with summary, got %v", fake.comments[1])
+	}
+	docsTask, _ := im.DB.GetTaskByName(ctx, "#2 Write docs", fallback.ID)
+	if docsTask == nil || docsTask.Status != models.TaskStatusCompleted {
+		t.Errorf("expected docs task completed, got %+v", docsTask)
+	}
+}
+
+func TestImportGroupByLabelAndClosed(t *testing.T) {
+	bug := issue(1, "Crash", "closed")
+	bug.Labels = []Label{{Name: "bug"}, {Name: "p1"}}
+	plain := issue(2, strings.Repeat("very long title ", 10), "open")
+
+	fake := &fakeGitHub{issues: []Issue{bug, plain}, comments: map[int][]string{}}
+	im := newTestImporter(t, fake)
+	ctx := context.Background()
+
+	_, err := im.Import(ctx, ImportOptions{Repo: "o/r", GroupBy: GroupByLabel, DefaultFeature: "inbox", IncludeClosed: true})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	bugFeature, _ := im.DB.GetFeatureByName(ctx, "bug")
+	if bugFeature == nil {
+		t.Fatal("expected feature from first label")
+	}
+	task, _ := im.DB.GetTaskByName(ctx, "#1 Crash", bugFeature.ID)
+	if task == nil || task.Status != models.TaskStatusCompleted {
+		t.Errorf("expected closed issue imported as completed, got %+v", task)
+	}
+
+	status := models.TaskStatusPending
+	inbox := "inbox"
+	tasks, _ := im.DB.ListTasks(ctx, &status, &inbox)
+	if len(tasks) != 1 || len([]rune(tasks[0].Name)) > maxNameLen {
+		t.Errorf("expected one truncated task in inbox, got %+v", tasks)
+	}
+}
+
+func TestImportPagination(t *testing.T) {
+	var issues []Issue
+	for i := 1; i <= 150; i++ {
+		issues = append(issues, issue(i, fmt.Sprintf("Issue %d", i), "open"))
+	}
+	fake := &fakeGitHub{issues: issues, comments: map[int][]string{}}
+	im := newTestImporter(t, fake)
+
+	got, err := im.Client.ListIssues(context.Background(), "o/r", "open")
+	if err != nil {
+		t.Fatalf("ListIssues failed: %v", err)
+	}
+	if len(got) != 150 || fake.pages != 2 {
+		t.Errorf("expected 150 issues over 2 pages, got %d over %d", len(got), fake.pages)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	im := newTestImporter(t, &fakeGitHub{comments: map[int][]string{}})
+
+	if _, err := im.Import(context.Background(), ImportOptions{Repo: "nope"}); err == nil {
+		t.Error("expected error for repository without owner")
+	}
+	if _, err := im.Import(context.Background(), ImportOptions{Repo: "other/repo"}); err == nil {
+		t.Error("expected error when GitHub returns 404")
+	}
+	if _, err := ParseGroupBy("assignee"); err == nil {
+		t.Error("expected error for unknown grouping")
+	}
+}
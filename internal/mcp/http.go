@@ -0,0 +1,21 @@
+package mcp
+
+import (
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// NewHTTPHandler exposes s over streamable HTTP at /mcp and over the legacy
+// SSE transport at /sse and /message. Every connection gets its own MCP
+// session, so multiple agents can share one database.
+func NewHTTPHandler(s *server.MCPServer) http.Handler {
+	streamable := server.NewStreamableHTTPServer(s)
+	sse := server.NewSSEServer(s, server.WithUseFullURLForMessageEndpoint(false))
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", streamable)
+	mux.Handle("/sse", sse)
+	mux.Handle("/message", sse)
+	return mux
+}
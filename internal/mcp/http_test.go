@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func TestHTTPHandlerServesMultipleClients(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.Init(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	ts := httptest.NewServer(NewHTTPHandler(NewServer(database)))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	streamable, err := client.NewStreamableHttpClient(ts.URL + "/mcp")
+	if err != nil {
+		t.Fatalf("Failed to create streamable HTTP client: %v", err)
+	}
+	defer streamable.Close()
+
+	sse, err := client.NewSSEMCPClient(ts.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to create SSE client: %v", err)
+	}
+	defer sse.Close()
+	if err := sse.Start(ctx); err != nil {
+		t.Fatalf("Failed to start SSE client: %v", err)
+	}
+
+	for name, c := range map[string]*client.Client{"http-feature": streamable, "sse-feature": sse} {
+		initReq := mcp.InitializeRequest{}
+		initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+		initReq.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0"}
+		if _, err := c.Initialize(ctx, initReq); err != nil {
+			t.Fatalf("Initialize failed for %s: %v", name, err)
+		}
+
+		callReq := mcp.CallToolRequest{}
+		callReq.Params.Name = "create_feature"
+		callReq.Params.Arguments = map[string]any{
+			"name":          name,
+			"description":   "d",
+			"specification": "s",
+			"session_id":    name,
+		}
+		res, err := c.CallTool(ctx, callReq)
+		if err != nil {
+			t.Fatalf("CallTool failed for %s: %v", name, err)
+		}
+		if res.IsError {
+			t.Fatalf("create_feature returned error for %s: %+v", name, res.Content)
+		}
+
+		callReq = mcp.CallToolRequest{}
+		callReq.Params.Name = "commit_staged_changes"
+		callReq.Params.Arguments = map[string]any{"session_id": name}
+		res, err = c.CallTool(ctx, callReq)
+		if err != nil || res.IsError {
+			t.Fatalf("commit_staged_changes failed for %s: %v %+v", name, err, res)
+		}
+	}
+
+	for _, name := range []string{"http-feature", "sse-feature"} {
+		f, err := database.GetFeatureByName(ctx, name)
+		if err != nil || f == nil {
+			t.Fatalf("expected feature %s in shared database: %v", name, err)
+		}
+
+		events, err := database.ListEvents(ctx, db.EntityFeature, f.ID, 0)
+		if err != nil || len(events) == 0 {
+			t.Fatalf("expected events for %s: %v", name, err)
+		}
+		if !strings.HasPrefix(events[0].Actor, "mcp:") {
+			t.Errorf("expected %s to be attributed to an MCP session, got %s", name, events[0].Actor)
+		}
+	}
+}
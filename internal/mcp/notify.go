@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+// DefaultNotifyInterval is how often NotifyChanges looks for changes made by
+// other processes sharing the database.
+const DefaultNotifyInterval = 2 * time.Second
+
+// NotifyChanges sends notifications/resources/list_changed to every connected
+// client whenever the backlog changes, until ctx is done, so clients can
+// refresh without polling list_tasks. Writes made through database are
+// noticed straight away; those of other processes, such as the orchestrator
+// or the CLI, when the audit log is checked every interval. An interval of 0
+// or less only notifies of this process's writes.
+func NotifyChanges(ctx context.Context, s *server.MCPServer, database *db.DB, interval time.Duration) {
+	changed := make(chan struct{}, 1)
+	unsubscribe := database.Subscribe(func(context.Context) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	var poll <-chan time.Time
+	var last int64
+	if interval > 0 {
+		last, _ = database.LatestEventID(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			// Our own writes are in the log too; don't report them twice.
+			if interval > 0 {
+				if id, err := database.LatestEventID(ctx); err == nil {
+					last = id
+				}
+			}
+		case <-poll:
+			id, err := database.LatestEventID(ctx)
+			if err != nil || id == last {
+				continue
+			}
+			last = id
+		}
+		s.SendNotificationToAllClients(mcp.MethodNotificationResourcesListChanged, nil)
+	}
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// testSession is a connected client that collects its notifications.
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return "test" }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestNotifyChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ponder.db")
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	s := NewServer(database)
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.RegisterSession(ctx, session); err != nil {
+		t.Fatalf("Failed to register session: %v", err)
+	}
+	go NotifyChanges(ctx, s, database, 50*time.Millisecond)
+
+	expectNotification := func(what string) {
+		t.Helper()
+		select {
+		case n := <-session.notifications:
+			if n.Method != mcp.MethodNotificationResourcesListChanged {
+				t.Errorf("%s: expected %s, got %s", what, mcp.MethodNotificationResourcesListChanged, n.Method)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: expected a notification", what)
+		}
+	}
+
+	// Give NotifyChanges time to subscribe.
+	time.Sleep(20 * time.Millisecond)
+	if err := database.CreateFeature(ctx, &models.Feature{Name: "local", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	expectNotification("own write")
+
+	other, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open second connection: %v", err)
+	}
+	defer other.Close()
+	if err := other.CreateFeature(ctx, &models.Feature{Name: "remote", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	expectNotification("other process's write")
+
+	select {
+	case n := <-session.notifications:
+		t.Errorf("expected one notification per change, got another %s", n.Method)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nick-dorsch/ponder/embed/prompts"
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// registerPrompts exposes planning workflows as MCP prompts that bundle the
+// current backlog, the task graph and the planning conventions, so that
+// planning sessions start from the same context.
+func registerPrompts(s *server.MCPServer, database *db.DB) {
+	s.AddPrompt(mcp.NewPrompt("plan-feature",
+		mcp.WithPromptDescription("Break a new or existing feature down into tasks, given the current backlog."),
+		mcp.WithArgument("feature", mcp.ArgumentDescription("Feature name"), mcp.RequiredArgument()),
+		mcp.WithArgument("goal", mcp.ArgumentDescription("What the feature should achieve")),
+	), planFeaturePromptHandler(database))
+
+	s.AddPrompt(mcp.NewPrompt("triage-blocked-tasks",
+		mcp.WithPromptDescription("Review blocked tasks and propose how to unblock each one."),
+		mcp.WithArgument("feature", mcp.ArgumentDescription("Only triage tasks of this feature")),
+	), triageBlockedPromptHandler(database))
+}
+
+func planFeaturePromptHandler(database *db.DB) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		featureName := request.Params.Arguments["feature"]
+		if featureName == "" {
+			return nil, fmt.Errorf("feature is required")
+		}
+		goal := request.Params.Arguments["goal"]
+
+		f, err := database.GetFeatureByName(ctx, featureName)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		if f != nil {
+			fmt.Fprintf(&b, "Plan the remaining work for the existing feature %q.\n\n", featureName)
+		} else {
+			fmt.Fprintf(&b, "Plan the new feature %q.\n\n", featureName)
+		}
+		if goal != "" {
+			fmt.Fprintf(&b, "## Goal\n\n%s\n\n", goal)
+		}
+		if f != nil && f.Specification != "" {
+			fmt.Fprintf(&b, "## Current Specification\n\n%s\n\n", f.Specification)
+		}
+		b.WriteString("Ask about anything the goal leaves open, then stage the feature and its tasks with their dependencies. Reuse or depend on existing tasks rather than duplicating them, and summarize the plan for approval before committing it.\n\n")
+		b.WriteString(prompts.Planning)
+		b.WriteString("\n")
+		if err := writeBacklog(ctx, &b, database); err != nil {
+			return nil, err
+		}
+
+		messages, err := withGraph(ctx, database, b.String())
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewGetPromptResult("Plan feature "+featureName, messages), nil
+	}
+}
+
+func triageBlockedPromptHandler(database *db.DB) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		var featureName *string
+		if name := request.Params.Arguments["feature"]; name != "" {
+			featureName = &name
+		}
+		status := models.TaskStatusBlocked
+		tasks, err := database.ListTasks(ctx, &status, featureName)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		b.WriteString("Triage the blocked tasks below. For each one, work out why it is stuck from its blocked reason, specification, dependencies and notes, and propose one action: clarify the specification, add or remove a dependency, split it into smaller tasks, or cancel it. Once the user agrees, apply the change and set the task back to pending with `update_task_status`.\n\n")
+		b.WriteString(prompts.Planning)
+		b.WriteString("\n## Blocked Tasks\n\n")
+		if len(tasks) == 0 {
+			b.WriteString("No tasks are blocked.\n\n")
+		}
+		for _, t := range tasks {
+			if err := writeBlockedTask(ctx, &b, database, t); err != nil {
+				return nil, err
+			}
+		}
+		if err := writeBacklog(ctx, &b, database); err != nil {
+			return nil, err
+		}
+
+		messages, err := withGraph(ctx, database, b.String())
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewGetPromptResult(fmt.Sprintf("Triage %d blocked tasks", len(tasks)), messages), nil
+	}
+}
+
+// writeBlockedTask describes a blocked task with what it waits on and what
+// waits on it.
+func writeBlockedTask(ctx context.Context, b *strings.Builder, database *db.DB, t *models.Task) error {
+	deps, err := database.GetDependencies(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	dependents, err := database.GetDependents(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	notes, err := database.ListTaskNotes(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b, "### %s/%s\n\n", t.FeatureName, t.Name)
+	fmt.Fprintf(b, "- Priority: %d\n", t.Priority)
+	fmt.Fprintf(b, "- Blocks: %d tasks\n", len(dependents))
+	if t.BlockedReason != nil {
+		fmt.Fprintf(b, "- Blocked because: %s\n", *t.BlockedReason)
+	}
+	b.WriteString("\n")
+	if t.Specification != "" {
+		fmt.Fprintf(b, "%s\n\n", t.Specification)
+	}
+	if len(deps) > 0 {
+		b.WriteString("Depends on:\n")
+		for _, d := range deps {
+			depFeature := d.FeatureName
+			if depFeature == "" {
+				depFeature = t.FeatureName
+			}
+			fmt.Fprintf(b, "- %s/%s - %s\n", depFeature, d.Name, d.Status)
+		}
+		b.WriteString("\n")
+	}
+	if len(notes) > 0 {
+		b.WriteString("Notes:\n")
+		for _, n := range notes {
+			fmt.Fprintf(b, "- %s: %s\n", n.Author, n.Body)
+		}
+		b.WriteString("\n")
+	}
+	return nil
+}
+
+// writeBacklog lists every feature with its open tasks. Finished tasks are
+// only counted to keep the prompt short.
+func writeBacklog(ctx context.Context, b *strings.Builder, database *db.DB) error {
+	features, err := database.ListFeatures(ctx)
+	if err != nil {
+		return err
+	}
+	tasks, err := database.ListTasks(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+	byFeature := make(map[string][]*models.Task)
+	for _, t := range tasks {
+		byFeature[t.FeatureName] = append(byFeature[t.FeatureName], t)
+	}
+
+	b.WriteString("## Current Backlog\n\n")
+	if len(features) == 0 {
+		b.WriteString("No features yet.\n")
+	}
+	for _, f := range features {
+		fmt.Fprintf(b, "### %s\n\n", f.Name)
+		if f.Description != "" {
+			fmt.Fprintf(b, "%s\n\n", f.Description)
+		}
+		finished := 0
+		for _, t := range byFeature[f.Name] {
+			if t.Status == models.TaskStatusCompleted || t.Status == models.TaskStatusCancelled {
+				finished++
+				continue
+			}
+			fmt.Fprintf(b, "- %s - %s, priority %d\n", t.Name, t.Status, t.Priority)
+		}
+		if finished > 0 {
+			fmt.Fprintf(b, "- (%d completed or cancelled)\n", finished)
+		}
+		b.WriteString("\n")
+	}
+	return nil
+}
+
+// withGraph returns text as a user message followed by the task graph as an
+// embedded resource.
+func withGraph(ctx context.Context, database *db.DB, text string) ([]mcp.PromptMessage, error) {
+	graph, err := database.GetGraphJSON(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(strings.TrimRight(text, "\n"))),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+			URI:      graphResourceURI,
+			MIMEType: "application/json",
+			Text:     graph,
+		})),
+	}, nil
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestPrompts(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	f := &models.Feature{Name: "auth", Description: "Login", Specification: "OAuth only"}
+	if err := database.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	schema := &models.Task{FeatureID: f.ID, Name: "schema", Description: "d", Specification: "users table", Priority: 5, Status: models.TaskStatusPending}
+	form := &models.Task{FeatureID: f.ID, Name: "form", Description: "d", Specification: "Email field", Priority: 3, Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{schema, form} {
+		if err := database.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if err := database.BlockTask(ctx, form.ID, "No design yet"); err != nil {
+		t.Fatalf("Failed to block task: %v", err)
+	}
+	if err := database.CreateDependency(ctx, form.ID, schema.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	s := NewServer(database)
+
+	type message struct {
+		Role    string `json:"role"`
+		Content struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			Resource struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"resource"`
+		} `json:"content"`
+	}
+	call := func(method string, params any) (json.RawMessage, string) {
+		t.Helper()
+		raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+		out, err := json.Marshal(s.HandleMessage(ctx, raw))
+		if err != nil {
+			t.Fatalf("Failed to marshal response: %v", err)
+		}
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(out, &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.Error != nil {
+			return nil, resp.Error.Message
+		}
+		return resp.Result, ""
+	}
+	get := func(name string, args map[string]string) []message {
+		t.Helper()
+		raw, errMsg := call("prompts/get", map[string]any{"name": name, "arguments": args})
+		if errMsg != "" {
+			t.Fatalf("prompts/get %s failed: %s", name, errMsg)
+		}
+		var result struct {
+			Messages []message `json:"messages"`
+		}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			t.Fatalf("Failed to unmarshal prompt: %v", err)
+		}
+		if len(result.Messages) != 2 {
+			t.Fatalf("Expected text and graph messages, got %+v", result.Messages)
+		}
+		if result.Messages[1].Content.Type != "resource" || result.Messages[1].Content.Resource.URI != graphResourceURI {
+			t.Errorf("Expected the graph as an embedded resource, got %+v", result.Messages[1])
+		}
+		return result.Messages
+	}
+
+	t.Run("list", func(t *testing.T) {
+		raw, errMsg := call("prompts/list", map[string]any{})
+		if errMsg != "" {
+			t.Fatalf("prompts/list failed: %s", errMsg)
+		}
+		if !strings.Contains(string(raw), "plan-feature") || !strings.Contains(string(raw), "triage-blocked-tasks") {
+			t.Errorf("Expected both prompts, got %s", raw)
+		}
+	})
+
+	t.Run("plan-feature", func(t *testing.T) {
+		text := get("plan-feature", map[string]string{"feature": "billing", "goal": "Charge monthly"})[0].Content.Text
+		for _, want := range []string{`new feature "billing"`, "Charge monthly", "## Planning Conventions", "### auth", "- form - blocked, priority 3"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Expected %q in prompt:\n%s", want, text)
+			}
+		}
+
+		text = get("plan-feature", map[string]string{"feature": "auth"})[0].Content.Text
+		if !strings.Contains(text, `existing feature "auth"`) || !strings.Contains(text, "OAuth only") {
+			t.Errorf("Expected the existing feature's specification:\n%s", text)
+		}
+
+		if _, errMsg := call("prompts/get", map[string]any{"name": "plan-feature"}); errMsg == "" {
+			t.Error("Expected error without a feature")
+		}
+	})
+
+	t.Run("triage-blocked-tasks", func(t *testing.T) {
+		text := get("triage-blocked-tasks", nil)[0].Content.Text
+		for _, want := range []string{"### auth/form", "- Blocked because: No design yet", "- auth/schema - pending"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Expected %q in prompt:\n%s", want, text)
+			}
+		}
+
+		text = get("triage-blocked-tasks", map[string]string{"feature": "misc"})[0].Content.Text
+		if !strings.Contains(text, "No tasks are blocked.") {
+			t.Errorf("Expected no blocked tasks in misc:\n%s", text)
+		}
+	})
+}
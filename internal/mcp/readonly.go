@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// readOnlyTools are the tools that change nothing, not even staged changes.
+// Tools missing from here are refused by a read-only server, so new tools
+// are safe until they are added.
+var readOnlyTools = map[string]bool{
+	"list_features":             true,
+	"get_feature":               true,
+	"list_tasks":                true,
+	"get_task":                  true,
+	"get_available_tasks":       true,
+	"list_blocked_tasks":        true,
+	"list_task_notes":           true,
+	"get_task_runs":             true,
+	"get_run_environment":       true,
+	"list_templates":            true,
+	"get_task_dependencies":     true,
+	"list_feature_dependencies": true,
+	"get_graph_json":            true,
+	"get_graph_mermaid":         true,
+	"analyze_graph":             true,
+	"get_project_stats":         true,
+	"validate_staged_changes":   true,
+	"list_staged_changes":       true,
+}
+
+func readOnlyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !readOnlyTools[request.Params.Name] {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is not allowed: this ponder server is read-only", request.Params.Name)), nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestReadOnlyServer(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := database.CreateFeature(ctx, &models.Feature{Name: "auth", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	s := NewReadOnlyServer(database)
+	for name := range readOnlyTools {
+		if s.GetTool(name) == nil {
+			t.Errorf("read-only tool %s is not registered", name)
+		}
+	}
+
+	callTool := func(name string, args map[string]any) (text string, isError bool) {
+		t.Helper()
+		raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call",
+			"params": map[string]any{"name": name, "arguments": args}})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+		out, err := json.Marshal(s.HandleMessage(ctx, raw))
+		if err != nil {
+			t.Fatalf("Failed to marshal response: %v", err)
+		}
+		var resp struct {
+			Result struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+				IsError bool `json:"isError"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(out, &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(resp.Result.Content) == 0 {
+			t.Fatalf("%s returned no content: %s", name, out)
+		}
+		return resp.Result.Content[0].Text, resp.Result.IsError
+	}
+
+	if text, isError := callTool("list_features", nil); isError || !strings.Contains(text, "auth") {
+		t.Errorf("expected list_features to work, got %q", text)
+	}
+
+	text, isError := callTool("delete_feature", map[string]any{"name": "auth"})
+	if !isError || !strings.Contains(text, "read-only") {
+		t.Errorf("expected delete_feature to be refused, got %q", text)
+	}
+	if f, _ := database.GetFeatureByName(ctx, "auth"); f == nil {
+		t.Error("expected the feature to survive")
+	}
+
+	if _, isError := callTool("create_task", map[string]any{"feature_name": "auth", "name": "t", "description": "d", "specification": "s"}); !isError {
+		t.Error("expected staging a task to be refused")
+	}
+}
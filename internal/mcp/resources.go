@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+// Resource URIs. Names are path-escaped so that task names with spaces or
+// slashes survive the round trip through the URI template.
+const (
+	graphResourceURI    = "ponder://graph"
+	featuresResourceURI = "ponder://features"
+)
+
+func featureResourceURI(name string) string {
+	return "ponder://feature/" + url.PathEscape(name)
+}
+
+func taskResourceURI(featureName, name string) string {
+	return "ponder://task/" + url.PathEscape(featureName) + "/" + url.PathEscape(name)
+}
+
+// registerResources exposes features, tasks and the graph as read-only MCP
+// resources so that clients can browse specifications without tool calls.
+func registerResources(s *server.MCPServer, database *db.DB) {
+	s.AddResource(mcp.NewResource(graphResourceURI, "Task graph",
+		mcp.WithResourceDescription("The complete task dependency graph as JSON."),
+		mcp.WithMIMEType("application/json"),
+	), graphResourceHandler(database))
+
+	s.AddResource(mcp.NewResource(featuresResourceURI, "Features",
+		mcp.WithResourceDescription("Index of all features with links to their resources."),
+		mcp.WithMIMEType("text/markdown"),
+	), featuresResourceHandler(database))
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate("ponder://feature/{name}", "Feature",
+		mcp.WithTemplateDescription("A feature's description, specification and tasks."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	), featureResourceHandler(database))
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate("ponder://task/{feature}/{name}", "Task",
+		mcp.WithTemplateDescription("A task's specification, status, dependencies and notes."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	), taskResourceHandler(database))
+}
+
+func graphResourceHandler(database *db.DB) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		graph, err := database.GetGraphJSON(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     graph,
+		}}, nil
+	}
+}
+
+func featuresResourceHandler(database *db.DB) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		features, err := database.ListFeatures(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		b.WriteString("# Features\n\n")
+		for _, f := range features {
+			fmt.Fprintf(&b, "- [%s](%s)", f.Name, featureResourceURI(f.Name))
+			if f.Description != "" {
+				fmt.Fprintf(&b, " - %s", f.Description)
+			}
+			b.WriteString("\n")
+		}
+		return markdownContents(request, b.String()), nil
+	}
+}
+
+func featureResourceHandler(database *db.DB) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		name := resourceArgument(request, "name")
+
+		f, err := database.GetFeatureByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			return nil, fmt.Errorf("feature with name '%s' not found", name)
+		}
+
+		tasks, err := database.ListTasks(ctx, nil, &f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "# %s\n\n", f.Name)
+		if f.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", f.Description)
+		}
+		if f.Specification != "" {
+			fmt.Fprintf(&b, "## Specification\n\n%s\n\n", f.Specification)
+		}
+		b.WriteString("## Tasks\n\n")
+		if len(tasks) == 0 {
+			b.WriteString("No tasks.\n")
+		}
+		for _, t := range tasks {
+			fmt.Fprintf(&b, "- [%s](%s) - %s, priority %d\n", t.Name, taskResourceURI(f.Name, t.Name), t.Status, t.Priority)
+		}
+		return markdownContents(request, b.String()), nil
+	}
+}
+
+func taskResourceHandler(database *db.DB) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		featureName := resourceArgument(request, "feature")
+		name := resourceArgument(request, "name")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return nil, err
+		}
+		t, err := database.GetTask(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		deps, err := database.GetDependencies(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		notes, err := database.ListTaskNotes(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "# %s\n\n", t.Name)
+		fmt.Fprintf(&b, "- Feature: [%s](%s)\n", featureName, featureResourceURI(featureName))
+		fmt.Fprintf(&b, "- Status: %s\n", t.Status)
+		fmt.Fprintf(&b, "- Priority: %d\n", t.Priority)
+		fmt.Fprintf(&b, "- Tests required: %t\n\n", t.TestsRequired)
+		if t.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", t.Description)
+		}
+		if t.Specification != "" {
+			fmt.Fprintf(&b, "## Specification\n\n%s\n\n", t.Specification)
+		}
+		if len(deps) > 0 {
+			b.WriteString("## Depends On\n\n")
+			for _, d := range deps {
+				depFeature := d.FeatureName
+				if depFeature == "" {
+					depFeature = featureName
+				}
+				fmt.Fprintf(&b, "- [%s](%s) - %s\n", d.Name, taskResourceURI(depFeature, d.Name), d.Status)
+			}
+			b.WriteString("\n")
+		}
+		if t.CompletionSummary != nil && *t.CompletionSummary != "" {
+			fmt.Fprintf(&b, "## Summary\n\n%s\n\n", *t.CompletionSummary)
+		}
+		if len(notes) > 0 {
+			b.WriteString("## Notes\n\n")
+			for _, n := range notes {
+				fmt.Fprintf(&b, "### %s (%s)\n\n%s\n\n", n.Author, n.CreatedAt.UTC().Format("2006-01-02 15:04"), n.Body)
+			}
+		}
+		return markdownContents(request, strings.TrimRight(b.String(), "\n")+"\n"), nil
+	}
+}
+
+// resourceArgument returns a URI template variable, undoing the escaping
+// applied by featureResourceURI and taskResourceURI.
+func resourceArgument(request mcp.ReadResourceRequest, name string) string {
+	var value string
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		value = v
+	case []string:
+		value = strings.Join(v, "/")
+	}
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+func markdownContents(request mcp.ReadResourceRequest, text string) []mcp.ResourceContents {
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "text/markdown",
+		Text:     text,
+	}}
+}
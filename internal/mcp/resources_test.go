@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestResources(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	f := &models.Feature{Name: "auth", Description: "Login", Specification: "OAuth only"}
+	if err := database.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	schema := &models.Task{FeatureID: f.ID, Name: "schema", Description: "d", Specification: "users table", Priority: 5, Status: models.TaskStatusPending}
+	form := &models.Task{FeatureID: f.ID, Name: "login form/v2", Description: "d", Specification: "Email and password fields", Priority: 3, Status: models.TaskStatusPending}
+	for _, task := range []*models.Task{schema, form} {
+		if err := database.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	if err := database.CreateDependency(ctx, form.ID, schema.ID); err != nil {
+		t.Fatalf("Failed to create dependency: %v", err)
+	}
+
+	s := NewServer(database)
+
+	call := func(method string, params any) json.RawMessage {
+		t.Helper()
+		raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+		out, err := json.Marshal(s.HandleMessage(ctx, raw))
+		if err != nil {
+			t.Fatalf("Failed to marshal response: %v", err)
+		}
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(out, &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.Error != nil {
+			t.Fatalf("%s failed: %s", method, resp.Error.Message)
+		}
+		return resp.Result
+	}
+
+	read := func(uri string) mcp.TextResourceContents {
+		t.Helper()
+		var result struct {
+			Contents []mcp.TextResourceContents `json:"contents"`
+		}
+		if err := json.Unmarshal(call("resources/read", map[string]any{"uri": uri}), &result); err != nil {
+			t.Fatalf("Failed to unmarshal contents: %v", err)
+		}
+		if len(result.Contents) != 1 {
+			t.Fatalf("Expected 1 content item for %s, got %d", uri, len(result.Contents))
+		}
+		return result.Contents[0]
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var resources struct {
+			Resources []mcp.Resource `json:"resources"`
+		}
+		if err := json.Unmarshal(call("resources/list", map[string]any{}), &resources); err != nil {
+			t.Fatalf("Failed to unmarshal resources: %v", err)
+		}
+		uris := make(map[string]bool)
+		for _, r := range resources.Resources {
+			uris[r.URI] = true
+		}
+		if !uris[graphResourceURI] || !uris[featuresResourceURI] {
+			t.Errorf("Expected graph and features resources, got %+v", resources.Resources)
+		}
+
+		var templates struct {
+			ResourceTemplates []json.RawMessage `json:"resourceTemplates"`
+		}
+		if err := json.Unmarshal(call("resources/templates/list", map[string]any{}), &templates); err != nil {
+			t.Fatalf("Failed to unmarshal templates: %v", err)
+		}
+		if len(templates.ResourceTemplates) != 2 {
+			t.Errorf("Expected 2 resource templates, got %d", len(templates.ResourceTemplates))
+		}
+	})
+
+	t.Run("graph", func(t *testing.T) {
+		contents := read(graphResourceURI)
+		if contents.MIMEType != "application/json" || !strings.Contains(contents.Text, "schema") {
+			t.Errorf("Unexpected graph resource: %+v", contents)
+		}
+	})
+
+	t.Run("features", func(t *testing.T) {
+		contents := read(featuresResourceURI)
+		if !strings.Contains(contents.Text, "[auth](ponder://feature/auth) - Login") {
+			t.Errorf("Unexpected features index:\n%s", contents.Text)
+		}
+	})
+
+	t.Run("feature", func(t *testing.T) {
+		contents := read(featureResourceURI("auth"))
+		for _, want := range []string{"# auth", "OAuth only", "[schema](ponder://task/auth/schema) - pending, priority 5", taskResourceURI("auth", "login form/v2")} {
+			if !strings.Contains(contents.Text, want) {
+				t.Errorf("Expected %q in feature resource:\n%s", want, contents.Text)
+			}
+		}
+	})
+
+	t.Run("task", func(t *testing.T) {
+		contents := read(taskResourceURI("auth", "login form/v2"))
+		for _, want := range []string{"# login form/v2", "Email and password fields", "## Depends On", "[schema](ponder://task/auth/schema) - pending"} {
+			if !strings.Contains(contents.Text, want) {
+				t.Errorf("Expected %q in task resource:\n%s", want, contents.Text)
+			}
+		}
+		if contents.MIMEType != "text/markdown" {
+			t.Errorf("Expected text/markdown, got %s", contents.MIMEType)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		raw, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": map[string]any{"uri": taskResourceURI("auth", "nope")}})
+		out, _ := json.Marshal(s.HandleMessage(ctx, raw))
+		if !strings.Contains(string(out), "not found") {
+			t.Errorf("Expected not found error, got %s", out)
+		}
+	})
+}
@@ -4,15 +4,40 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/nick-dorsch/ponder/internal/actor"
 	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/export"
 	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
 func NewServer(database *db.DB) *server.MCPServer {
-	s := server.NewMCPServer("Ponder", "0.1.0")
+	return newServer(database, false)
+}
+
+// NewReadOnlyServer is NewServer for clients that may look but not touch:
+// tools that would change anything answer with an error instead.
+func NewReadOnlyServer(database *db.DB) *server.MCPServer {
+	return newServer(database, true)
+}
+
+func newServer(database *db.DB, readOnly bool) *server.MCPServer {
+	opts := []server.ServerOption{
+		server.WithToolHandlerMiddleware(tracingMiddleware),
+		server.WithToolHandlerMiddleware(actorMiddleware),
+		// NotifyChanges tells clients when the backlog changes.
+		server.WithResourceCapabilities(false, true),
+	}
+	if readOnly {
+		opts = append(opts, server.WithToolHandlerMiddleware(readOnlyMiddleware))
+	}
+	s := server.NewMCPServer("Ponder", "0.1.0", opts...)
 
 	// Feature Management
 	s.AddTool(mcp.NewTool("create_feature",
@@ -29,15 +54,23 @@ func NewServer(database *db.DB) *server.MCPServer {
 		mcp.WithString("new_name", mcp.Description("New name")),
 		mcp.WithString("description", mcp.Description("New description")),
 		mcp.WithString("specification", mcp.Description("New specification")),
+		mcp.WithNumber("version", mcp.Description("Version of the feature you last read; the update is refused if someone changed it since")),
 	), updateFeatureHandler(database))
 
+	s.AddTool(mcp.NewTool("append_feature_specification",
+		mcp.WithDescription("Add a timestamped section to the end of a feature's specification, keeping what is already there. Prefer this to update_feature for adding findings or decisions."),
+		mcp.WithString("name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("text", mcp.Description("Markdown to append"), mcp.Required()),
+		mcp.WithString("title", mcp.Description("Heading of the new section (defaults to 'Update')")),
+	), appendFeatureSpecificationHandler(database))
+
 	s.AddTool(mcp.NewTool("delete_feature",
 		mcp.WithDescription("Delete a feature (cascades to tasks)."),
 		mcp.WithString("name", mcp.Description("Feature name"), mcp.Required()),
 	), deleteFeatureHandler(database))
 
 	s.AddTool(mcp.NewTool("list_features",
-		mcp.WithDescription("List all features."),
+		mcp.WithDescription("List all features with the progress of their tasks: total, completed, blocked and percent done."),
 	), listFeaturesHandler(database))
 
 	s.AddTool(mcp.NewTool("get_feature",
@@ -54,9 +87,44 @@ func NewServer(database *db.DB) *server.MCPServer {
 		mcp.WithString("specification", mcp.Description("Detailed task specification. This should give a software engineer enough information to complete the task effectively."), mcp.Required()),
 		mcp.WithNumber("priority", mcp.Description("Priority (0-10)")),
 		mcp.WithBoolean("tests_required", mcp.Description("Whether tests are required")),
+		mcp.WithString("not_before", mcp.Description("Don't start the task before this time (RFC 3339 or YYYY-MM-DD)")),
+		mcp.WithString("due_at", mcp.Description("When the task is due (RFC 3339 or YYYY-MM-DD)")),
+		mcp.WithNumber("estimate_minutes", mcp.Description("How many minutes the task is expected to take")),
+		mcp.WithString("parent_task_name", mcp.Description("Make this a subtask of another task in the same feature (existing or staged earlier)")),
+		mcp.WithString("subtask_order", mcp.Description("For this task's own subtasks: children_first (default, this task waits for its subtasks) or parent_first (subtasks wait for this task)")),
 		mcp.WithString("session_id", mcp.Description("Session ID for staging changes (defaults to 'default').")),
 	), createTaskHandler(database))
 
+	s.AddTool(mcp.NewTool("create_tasks_bulk",
+		mcp.WithDescription("Propose many tasks at once, with dependencies by name. Changes are staged and must be committed to take effect. Nothing is staged if any task is invalid."),
+		mcp.WithString("feature_name", mcp.Description("Default feature for tasks that do not set feature_name")),
+		mcp.WithArray("tasks", mcp.Description("Tasks to stage"), mcp.Required(), mcp.Items(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"feature_name":     map[string]any{"type": "string", "description": "Feature name (defaults to the top-level feature_name)"},
+				"name":             map[string]any{"type": "string", "description": "Task name (max 55 chars)"},
+				"description":      map[string]any{"type": "string", "description": "Short task description"},
+				"specification":    map[string]any{"type": "string", "description": "Detailed task specification"},
+				"priority":         map[string]any{"type": "number", "description": "Priority (0-10)"},
+				"tests_required":   map[string]any{"type": "boolean", "description": "Whether tests are required"},
+				"estimate_minutes": map[string]any{"type": "number", "description": "How many minutes the task is expected to take"},
+				"depends_on": map[string]any{
+					"type":        "array",
+					"description": "Prerequisites: a task name in the same feature, or {feature_name, name}",
+					"items": map[string]any{"anyOf": []any{
+						map[string]any{"type": "string"},
+						map[string]any{"type": "object", "properties": map[string]any{
+							"feature_name": map[string]any{"type": "string"},
+							"name":         map[string]any{"type": "string"},
+						}, "required": []string{"name"}},
+					}},
+				},
+			},
+			"required": []string{"name", "description", "specification"},
+		})),
+		mcp.WithString("session_id", mcp.Description("Session ID for staging changes (defaults to 'default').")),
+	), createTasksBulkHandler(database))
+
 	s.AddTool(mcp.NewTool("update_task",
 		mcp.WithDescription("Update an existing task."),
 		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
@@ -67,14 +135,29 @@ func NewServer(database *db.DB) *server.MCPServer {
 		mcp.WithString("specification", mcp.Description("New specification")),
 		mcp.WithNumber("priority", mcp.Description("New priority")),
 		mcp.WithBoolean("tests_required", mcp.Description("New tests required status")),
+		mcp.WithString("not_before", mcp.Description("New earliest start time (RFC 3339 or YYYY-MM-DD); empty clears it")),
+		mcp.WithString("due_at", mcp.Description("New due time (RFC 3339 or YYYY-MM-DD); empty clears it")),
+		mcp.WithNumber("estimate_minutes", mcp.Description("New estimate in minutes; 0 clears it")),
+		mcp.WithString("parent_task_name", mcp.Description("New parent task, in the task's feature; empty makes it a top-level task")),
+		mcp.WithString("subtask_order", mcp.Description("New subtask order for this task's subtasks (children_first|parent_first)")),
+		mcp.WithArray("labels", mcp.Description("New labels, replacing the task's current ones; an empty list clears them"), mcp.WithStringItems()),
+		mcp.WithNumber("version", mcp.Description("Version of the task you last read; the update is refused if someone changed it since")),
 	), updateTaskHandler(database))
 
+	s.AddTool(mcp.NewTool("append_task_specification",
+		mcp.WithDescription("Add a timestamped section to the end of a task's specification, keeping what is already there. Prefer this to update_task for adding findings or decisions."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+		mcp.WithString("text", mcp.Description("Markdown to append"), mcp.Required()),
+		mcp.WithString("title", mcp.Description("Heading of the new section (defaults to 'Update')")),
+	), appendTaskSpecificationHandler(database))
+
 	s.AddTool(mcp.NewTool("update_task_status",
 		mcp.WithDescription("Update task status."),
 		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
 		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
-		mcp.WithString("status", mcp.Description("New status (pending|in_progress|completed|blocked)"), mcp.Required()),
-		mcp.WithString("completion_summary", mcp.Description("Summary of work (required if status=completed)")),
+		mcp.WithString("status", mcp.Description("New status (pending|in_progress|in_review|completed|blocked|cancelled)"), mcp.Required()),
+		mcp.WithString("completion_summary", mcp.Description("Summary of work (required if status=completed; use in_review to hand finished work to a human for approval)")),
 	), updateTaskStatusHandler(database))
 
 	s.AddTool(mcp.NewTool("delete_task",
@@ -83,12 +166,25 @@ func NewServer(database *db.DB) *server.MCPServer {
 		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
 	), deleteTaskHandler(database))
 
+	s.AddTool(mcp.NewTool("reorder_tasks",
+		mcp.WithDescription("Put tasks of a feature in the order they should be worked on, first first. The listed tasks share out the priorities they already have, highest first, and keep the listed order among equal priorities. Prefer this over setting priority numbers by hand. Unlisted tasks are not changed."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithArray("task_names", mcp.Description("Task names in the order to work on them"), mcp.Required(), mcp.WithStringItems()),
+	), reorderTasksHandler(database))
+
 	s.AddTool(mcp.NewTool("list_tasks",
 		mcp.WithDescription("List tasks with optional filters."),
 		mcp.WithString("feature_name", mcp.Description("Filter by feature name")),
 		mcp.WithString("status", mcp.Description("Filter by status")),
 	), listTasksHandler(database))
 
+	s.AddTool(mcp.NewTool("get_task",
+		mcp.WithDescription("Get everything about one task in a single call: the task with its labels, its feature, the tasks it depends on and that depend on it with their statuses and completion summaries, its notes, and its most recent runs. Use get_task_runs for the full output of every run."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+		mcp.WithNumber("runs", mcp.Description(fmt.Sprintf("How many of the most recent runs to include (default %d)", defaultTaskDetailRuns))),
+	), getTaskHandler(database))
+
 	s.AddTool(mcp.NewTool("get_available_tasks",
 		mcp.WithDescription("Get tasks that are ready to work on."),
 	), getAvailableTasksHandler(database))
@@ -107,12 +203,92 @@ func NewServer(database *db.DB) *server.MCPServer {
 	), completeTaskHandler(database))
 
 	s.AddTool(mcp.NewTool("report_task_blocked",
-		mcp.WithDescription("Report a task as blocked and provide a reason."),
+		mcp.WithDescription("Report a task as blocked and provide a reason. The reason is kept in the task's blocked_reason until it is unblocked."),
 		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
 		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
 		mcp.WithString("reason", mcp.Description("Reason why the task is blocked"), mcp.Required()),
 	), reportTaskBlockedHandler(database))
 
+	s.AddTool(mcp.NewTool("unblock_task",
+		mcp.WithDescription("Unblock a blocked task, returning it to pending and clearing its blocked_reason so it can be picked up again."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+	), unblockTaskHandler(database))
+
+	s.AddTool(mcp.NewTool("list_blocked_tasks",
+		mcp.WithDescription("List blocked tasks for triage: each with its blocked_reason, the dependencies that are not completed with their statuses (and reasons, if blocked), the tasks waiting on it, and suggestions for unblocking it."),
+		mcp.WithString("feature_name", mcp.Description("Only list blocked tasks of this feature")),
+	), listBlockedTasksHandler(database))
+
+	s.AddTool(mcp.NewTool("reopen_task",
+		mcp.WithDescription("Reopen a completed task that needs more work, returning it to pending and clearing its completion summary. The reason is recorded in the task's history."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+		mcp.WithString("reason", mcp.Description("Why the task is being reopened"), mcp.Required()),
+	), reopenTaskHandler(database))
+
+	s.AddTool(mcp.NewTool("cancel_task",
+		mcp.WithDescription("Cancel a task. Cancelled tasks are final and never satisfy the dependencies of other tasks."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+		mcp.WithString("reason", mcp.Description("Why the task was cancelled")),
+	), cancelTaskHandler(database))
+
+	s.AddTool(mcp.NewTool("approve_task",
+		mcp.WithDescription("Approve a task that is in_review, marking it completed."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+		mcp.WithString("completion_summary", mcp.Description("Final summary (defaults to the summary submitted for review)")),
+	), approveTaskHandler(database))
+
+	// Task Notes
+	s.AddTool(mcp.NewTool("add_task_note",
+		mcp.WithDescription("Leave a markdown note on a task, e.g. context or findings for the next worker. Does not change the task itself."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+		mcp.WithString("body", mcp.Description("Markdown note body"), mcp.Required()),
+		mcp.WithString("author", mcp.Description("Note author (defaults to the MCP session)")),
+	), addTaskNoteHandler(database))
+
+	s.AddTool(mcp.NewTool("list_task_notes",
+		mcp.WithDescription("List the notes left on a task, oldest first."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+	), listTaskNotesHandler(database))
+
+	s.AddTool(mcp.NewTool("get_task_runs",
+		mcp.WithDescription("List the agent runs of a task, oldest first: the prompt, full output, exit code, duration and model of each, and why it failed. Use it to find out why a task keeps failing."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
+	), getTaskRunsHandler(database))
+
+	// Run Environments
+	s.AddTool(mcp.NewTool("set_run_environment",
+		mcp.WithDescription("Set the working directory and environment variables the agent runs with, for a whole feature or, with task_name, a single task. Replaces the previous settings; omit both working_dir and env to clear them. Task settings take precedence over their feature's."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("task_name", mcp.Description("Task name (omit to set the feature's environment)")),
+		mcp.WithString("working_dir", mcp.Description("Directory to run the agent in, relative to the repository root")),
+		mcp.WithObject("env", mcp.Description("Environment variables to set, as name-value pairs")),
+	), setRunEnvironmentHandler(database))
+
+	s.AddTool(mcp.NewTool("get_run_environment",
+		mcp.WithDescription("Get the working directory and environment variables set on a feature or task, and, for a task, those its agent actually runs with."),
+		mcp.WithString("feature_name", mcp.Description("Feature name"), mcp.Required()),
+		mcp.WithString("task_name", mcp.Description("Task name (omit for the feature's environment)")),
+	), getRunEnvironmentHandler(database))
+
+	// Templates
+	s.AddTool(mcp.NewTool("list_templates",
+		mcp.WithDescription("List the task templates, with the variables each one needs and, for recurring templates, when the next task is due."),
+	), listTemplatesHandler(database))
+
+	s.AddTool(mcp.NewTool("create_task_from_template",
+		mcp.WithDescription("Propose a new task from a template, filling in its {{variable}} placeholders. {{date}} is always today's date. Changes are staged and must be committed to take effect."),
+		mcp.WithString("template_name", mcp.Description("Template name"), mcp.Required()),
+		mcp.WithObject("variables", mcp.Description("Values of the template's variables, as name-value pairs")),
+		mcp.WithString("session_id", mcp.Description("Session ID for staging changes (defaults to 'default').")),
+	), createTaskFromTemplateHandler(database))
+
 	// Dependency Management
 	s.AddTool(mcp.NewTool("create_dependency",
 		mcp.WithDescription("Propose a dependency between two tasks. Changes are staged and must be committed to take effect."),
@@ -137,25 +313,107 @@ func NewServer(database *db.DB) *server.MCPServer {
 		mcp.WithString("name", mcp.Description("Task name"), mcp.Required()),
 	), getTaskDependenciesHandler(database))
 
+	s.AddTool(mcp.NewTool("create_feature_dependency",
+		mcp.WithDescription("Make a feature wait for another: none of its tasks become available until every task of the prerequisite feature is completed or cancelled. Both features must exist; the dependency takes effect immediately."),
+		mcp.WithString("feature_name", mcp.Description("Name of the dependent feature"), mcp.Required()),
+		mcp.WithString("depends_on_feature_name", mcp.Description("Name of the prerequisite feature"), mcp.Required()),
+	), createFeatureDependencyHandler(database))
+
+	s.AddTool(mcp.NewTool("delete_feature_dependency",
+		mcp.WithDescription("Remove a feature dependency."),
+		mcp.WithString("feature_name", mcp.Description("Name of the dependent feature"), mcp.Required()),
+		mcp.WithString("depends_on_feature_name", mcp.Description("Name of the prerequisite feature"), mcp.Required()),
+	), deleteFeatureDependencyHandler(database))
+
+	s.AddTool(mcp.NewTool("list_feature_dependencies",
+		mcp.WithDescription("List the feature dependencies: which features wait for which others to be done."),
+		mcp.WithString("feature_name", mcp.Description("Only list the dependencies of this feature")),
+	), listFeatureDependenciesHandler(database))
+
 	// Graph Queries
 	s.AddTool(mcp.NewTool("get_graph_json",
 		mcp.WithDescription("Get the complete task graph as JSON."),
 	), getGraphJSONHandler(database))
 
+	s.AddTool(mcp.NewTool("get_graph_mermaid",
+		mcp.WithDescription("Get the task dependency graph as a Mermaid flowchart, suitable for pasting into Markdown."),
+		mcp.WithString("feature_name", mcp.Description("Only include this feature")),
+	), getGraphMermaidHandler(database))
+
+	s.AddTool(mcp.NewTool("analyze_graph",
+		mcp.WithDescription("Analyze the open tasks' dependency graph: the critical path, how many tasks each one holds up (most blocking first), tasks that wait on cancelled work and tasks connected to nothing. Use it to decide which task to prioritize to unblock the most work."),
+		mcp.WithNumber("limit", mcp.Description("Only return the N most blocking tasks (default all)")),
+	), analyzeGraphHandler(database))
+
+	s.AddTool(mcp.NewTool("get_project_stats",
+		mcp.WithDescription("Get project statistics: task counts by status and by feature, tasks completed per day, average task duration and the failure rate of runs over the last N days."),
+		mcp.WithNumber("days", mcp.Description("Number of days, including today, to compute throughput, duration and failure rate over (default 7)")),
+	), getProjectStatsHandler(database))
+
 	// Staging Management
 	s.AddTool(mcp.NewTool("commit_staged_changes",
-		mcp.WithDescription("Commit all staged changes for a session. This applies all proposed features, tasks, and dependencies at once."),
+		mcp.WithDescription("Commit all staged changes for a session. This applies all proposed features, tasks, and dependencies at once. If the commit fails, nothing is applied and the staged changes are kept."),
 		mcp.WithString("session_id", mcp.Description("Session ID (defaults to 'default').")),
 	), commitStagedChangesHandler(database))
 
+	s.AddTool(mcp.NewTool("validate_staged_changes",
+		mcp.WithDescription("Dry-run commit_staged_changes: apply the staged changes in a transaction that is always rolled back and report every problem (missing features, duplicate names, dependency cycles) at once. Nothing is committed and the staged changes are kept."),
+		mcp.WithString("session_id", mcp.Description("Session ID (defaults to 'default').")),
+	), validateStagedChangesHandler(database))
+
 	s.AddTool(mcp.NewTool("list_staged_changes",
 		mcp.WithDescription("List all staged changes for a session. Use this to review a proposed plan before committing."),
 		mcp.WithString("session_id", mcp.Description("Session ID (defaults to 'default').")),
 	), listStagedChangesHandler(database))
 
+	s.AddTool(mcp.NewTool("update_staged_change",
+		mcp.WithDescription("Edit a staged feature or task before committing. Renames are propagated to staged tasks and dependencies that reference the item."),
+		mcp.WithString("kind", mcp.Description("Kind of staged item (feature|task)"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Name of the staged feature or task"), mcp.Required()),
+		mcp.WithString("feature_name", mcp.Description("Feature name of the staged task (required for kind=task)")),
+		mcp.WithString("new_name", mcp.Description("New name")),
+		mcp.WithString("new_feature_name", mcp.Description("New feature name (tasks only)")),
+		mcp.WithString("description", mcp.Description("New description")),
+		mcp.WithString("specification", mcp.Description("New specification")),
+		mcp.WithNumber("priority", mcp.Description("New priority (tasks only)")),
+		mcp.WithBoolean("tests_required", mcp.Description("New tests required status (tasks only)")),
+		mcp.WithString("session_id", mcp.Description("Session ID (defaults to 'default').")),
+	), updateStagedChangeHandler(database))
+
+	s.AddTool(mcp.NewTool("remove_staged_change",
+		mcp.WithDescription("Remove a staged feature, task, or dependency. Removing a feature also removes its staged tasks; removing a task also removes staged dependencies that reference it."),
+		mcp.WithString("kind", mcp.Description("Kind of staged item (feature|task|dependency)"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Name of the staged feature or task")),
+		mcp.WithString("feature_name", mcp.Description("Feature name of the staged task, or of the dependent task for kind=dependency")),
+		mcp.WithString("task_name", mcp.Description("Task name of the dependent task (kind=dependency)")),
+		mcp.WithString("depends_on_task_name", mcp.Description("Task name of the prerequisite task (kind=dependency)")),
+		mcp.WithString("depends_on_feature_name", mcp.Description("Feature name of the prerequisite task (defaults to feature_name)")),
+		mcp.WithString("session_id", mcp.Description("Session ID (defaults to 'default').")),
+	), removeStagedChangeHandler(database))
+
+	s.AddTool(mcp.NewTool("discard_staged_changes",
+		mcp.WithDescription("Discard all staged changes for a session without committing them."),
+		mcp.WithString("session_id", mcp.Description("Session ID (defaults to 'default').")),
+	), discardStagedChangesHandler(database))
+
+	registerResources(s, database)
+	registerPrompts(s, database)
+
 	return s
 }
 
+// actorMiddleware attributes mutations made by tool calls to the MCP client
+// session so they show up in the audit log.
+func actorMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := "mcp"
+		if session := server.ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+			name = "mcp:" + session.SessionID()
+		}
+		return next(actor.With(ctx, name), request)
+	}
+}
+
 func Serve(s *server.MCPServer) error {
 	return server.ServeStdio(s)
 }
@@ -201,11 +459,37 @@ func updateFeatureHandler(database *db.DB) server.ToolHandlerFunc {
 			f.Specification = specification
 		}
 
-		if err := database.UpdateFeature(ctx, f); err != nil {
+		if err := database.UpdateFeatureAtVersion(ctx, f, mcp.ParseInt(request, "version", 0)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Feature updated successfully (now at version %d)", f.Version)), nil
+	}
+}
+
+func appendFeatureSpecificationHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := mcp.ParseString(request, "name", "")
+
+		f, err := database.GetFeatureByName(ctx, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if f == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Feature with name '%s' not found", name)), nil
+		}
+
+		f, err = database.AppendFeatureSpecification(ctx, f.ID, mcp.ParseString(request, "title", ""), mcp.ParseString(request, "text", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(f)
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		return mcp.NewToolResultText("Feature updated successfully"), nil
+		return mcp.NewToolResultText(string(data)), nil
 	}
 }
 
@@ -285,12 +569,106 @@ func createTaskHandler(database *db.DB) server.ToolHandlerFunc {
 			TestsRequired: testsRequired,
 			Status:        models.TaskStatusPending,
 		}
+		args, _ := request.Params.Arguments.(map[string]any)
+		var err error
+		if t.NotBefore, err = parseTaskTimeArg(args, "not_before"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if t.DueAt, err = parseTaskTimeArg(args, "due_at"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if t.EstimateMinutes, err = parseEstimateArg(args); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		t.ParentTaskName = mcp.ParseString(request, "parent_task_name", "")
+		if t.SubtaskOrder, err = models.ParseSubtaskOrder(mcp.ParseString(request, "subtask_order", "")); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
 		database.Staging.AddTask(sessionID, t)
 		return mcp.NewToolResultText(fmt.Sprintf("Task '%s' staged for session '%s'. Propose another or call 'commit_staged_changes' to apply.", name, sessionID)), nil
 	}
 }
 
+// parseTaskTimeArg parses an optional not_before or due_at argument. A missing
+// or empty value gives nil.
+func parseTaskTimeArg(args map[string]any, key string) (*time.Time, error) {
+	s, _ := args[key].(string)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := models.ParseTaskTime(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return &t, nil
+}
+
+// parseEstimateArg parses an optional estimate_minutes argument. A missing
+// value or 0 gives nil, leaving the task unestimated.
+func parseEstimateArg(args map[string]any) (*int, error) {
+	v, ok := args["estimate_minutes"].(float64)
+	if !ok || v == 0 {
+		return nil, nil
+	}
+	if v < 0 || v != math.Trunc(v) {
+		return nil, fmt.Errorf("estimate_minutes: %w", db.ErrInvalidEstimate)
+	}
+	minutes := int(v)
+	return &minutes, nil
+}
+
+func createTasksBulkHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		sessionID := mcp.ParseString(request, "session_id", "default")
+
+		args, _ := request.Params.Arguments.(map[string]any)
+		raw, err := json.Marshal(args["tasks"])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		var tasks []db.BulkTask
+		if err := json.Unmarshal(raw, &tasks); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid tasks: %v", err)), nil
+		}
+
+		staged, err := database.StageTasks(sessionID, featureName, tasks)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%d tasks staged for session '%s'. Call 'commit_staged_changes' to apply.", len(staged), sessionID)), nil
+	}
+}
+
+func reorderTasksHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		names, err := request.RequireStringSlice("task_names")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		f, err := database.GetFeatureByName(ctx, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if f == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Feature with name '%s' not found", featureName)), nil
+		}
+
+		tasks, err := database.ReorderTasks(ctx, f.ID, names)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		data, err := json.Marshal(map[string]interface{}{"tasks": tasks})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
 func updateTaskHandler(database *db.DB) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		featureName := mcp.ParseString(request, "feature_name", "")
@@ -338,12 +716,92 @@ func updateTaskHandler(database *db.DB) server.ToolHandlerFunc {
 		if testsRequired, ok := args["tests_required"].(bool); ok {
 			t.TestsRequired = testsRequired
 		}
+		if _, ok := args["not_before"]; ok {
+			if t.NotBefore, err = parseTaskTimeArg(args, "not_before"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if _, ok := args["due_at"]; ok {
+			if t.DueAt, err = parseTaskTimeArg(args, "due_at"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if _, ok := args["estimate_minutes"]; ok {
+			if t.EstimateMinutes, err = parseEstimateArg(args); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if parentName, ok := args["parent_task_name"].(string); ok {
+			t.ParentTaskID = nil
+			if parentName != "" {
+				parent, err := database.GetTaskByName(ctx, parentName, t.FeatureID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				if parent == nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Parent task with name '%s' not found in the task's feature", parentName)), nil
+				}
+				t.ParentTaskID = &parent.ID
+			}
+		}
+		if order, ok := args["subtask_order"].(string); ok {
+			if t.SubtaskOrder, err = models.ParseSubtaskOrder(order); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		_, setLabels := args["labels"]
+		labels := request.GetStringSlice("labels", nil)
+		if err := db.ValidateLabels(labels); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		version := mcp.ParseInt(request, "version", 0)
+		if setLabels {
+			err = database.UpdateTaskWithLabels(ctx, t, version, labels)
+		} else {
+			err = database.UpdateTaskAtVersion(ctx, t, version)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Task updated successfully (now at version %d)", t.Version)), nil
+	}
+}
+
+func appendTaskSpecificationHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+
+		f, err := database.GetFeatureByName(ctx, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if f == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Feature with name '%s' not found", featureName)), nil
+		}
+
+		t, err := database.GetTaskByName(ctx, name, f.ID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if t == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Task with name '%s' not found in feature '%s'", name, featureName)), nil
+		}
+
+		t, err = database.AppendTaskSpecification(ctx, t.ID, mcp.ParseString(request, "title", ""), mcp.ParseString(request, "text", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
-		if err := database.UpdateTask(ctx, t); err != nil {
+		data, err := json.Marshal(t)
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		return mcp.NewToolResultText("Task updated successfully"), nil
+		return mcp.NewToolResultText(string(data)), nil
 	}
 }
 
@@ -440,6 +898,135 @@ func listTasksHandler(database *db.DB) server.ToolHandlerFunc {
 	}
 }
 
+// defaultTaskDetailRuns is how many runs get_task includes unless asked for
+// more or fewer.
+const defaultTaskDetailRuns = 3
+
+// runOutputTail is how much of a run's output get_task keeps: the end,
+// where the agent says what went wrong.
+const runOutputTail = 4000
+
+// taskDetail is a task as get_task reports it.
+type taskDetail struct {
+	*models.Task
+	Labels       []string           `json:"labels"`
+	Feature      *models.Feature    `json:"feature"`
+	Dependencies []linkedTask       `json:"dependencies"`
+	Dependents   []linkedTask       `json:"dependents"`
+	Notes        []*models.TaskNote `json:"notes"`
+	Runs         []recentRun        `json:"runs"`
+}
+
+// linkedTask is a dependency or dependent of a task, without its
+// specification.
+type linkedTask struct {
+	ID                string            `json:"id"`
+	FeatureName       string            `json:"feature_name"`
+	Name              string            `json:"name"`
+	Status            models.TaskStatus `json:"status"`
+	CompletionSummary *string           `json:"completion_summary,omitempty"`
+}
+
+// recentRun is a run as get_task reports it: without the prompt, and with
+// only the end of long output.
+type recentRun struct {
+	Model           string              `json:"model"`
+	ExitCode        int                 `json:"exit_code"`
+	Error           string              `json:"error,omitempty"`
+	FailureClass    models.FailureClass `json:"failure_class,omitempty"`
+	DurationMS      int64               `json:"duration_ms"`
+	StartedAt       time.Time           `json:"started_at"`
+	Output          string              `json:"output"`
+	OutputTruncated bool                `json:"output_truncated,omitempty"`
+}
+
+func linkedTasks(tasks []*models.Task) []linkedTask {
+	linked := make([]linkedTask, 0, len(tasks))
+	for _, t := range tasks {
+		linked = append(linked, linkedTask{
+			ID:                t.ID,
+			FeatureName:       t.FeatureName,
+			Name:              t.Name,
+			Status:            t.Status,
+			CompletionSummary: t.CompletionSummary,
+		})
+	}
+	return linked
+}
+
+func getTaskHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+		maxRuns := mcp.ParseInt(request, "runs", defaultTaskDetailRuns)
+		if maxRuns < 0 {
+			return mcp.NewToolResultError("runs must be >= 0"), nil
+		}
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		task, err := database.GetTask(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		detail := taskDetail{Task: task}
+
+		if detail.Labels, err = database.ListTaskLabels(ctx, taskID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if detail.Feature, err = database.GetFeature(ctx, task.FeatureID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		deps, err := database.GetDependencies(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		detail.Dependencies = linkedTasks(deps)
+		dependents, err := database.GetDependents(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		detail.Dependents = linkedTasks(dependents)
+		if detail.Notes, err = database.ListTaskNotes(ctx, taskID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		runs, err := database.ListTaskRuns(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(runs) > maxRuns {
+			runs = runs[len(runs)-maxRuns:]
+		}
+		detail.Runs = make([]recentRun, 0, len(runs))
+		for _, r := range runs {
+			run := recentRun{
+				Model:        r.Model,
+				ExitCode:     r.ExitCode,
+				Error:        r.Error,
+				FailureClass: r.FailureClass,
+				DurationMS:   r.DurationMS,
+				StartedAt:    r.StartedAt,
+				Output:       r.Output,
+			}
+			if len(run.Output) > runOutputTail {
+				run.Output = strings.ToValidUTF8(run.Output[len(run.Output)-runOutputTail:], "")
+				run.OutputTruncated = true
+			}
+			detail.Runs = append(detail.Runs, run)
+		}
+
+		data, err := json.Marshal(detail)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
 func getAvailableTasksHandler(database *db.DB) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		tasks, err := database.GetAvailableTasks(ctx)
@@ -523,7 +1110,78 @@ func getTaskDependenciesHandler(database *db.DB) server.ToolHandlerFunc {
 	}
 }
 
-func getGraphJSONHandler(database *db.DB) server.ToolHandlerFunc {
+func createFeatureDependencyHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		dependsOnFeatureName := mcp.ParseString(request, "depends_on_feature_name", "")
+
+		featureID, err := resolveFeatureID(ctx, database, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		dependsOnFeatureID, err := resolveFeatureID(ctx, database, dependsOnFeatureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := database.CreateFeatureDependency(ctx, featureID, dependsOnFeatureID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Feature '%s' now waits for feature '%s'", featureName, dependsOnFeatureName)), nil
+	}
+}
+
+func deleteFeatureDependencyHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		dependsOnFeatureName := mcp.ParseString(request, "depends_on_feature_name", "")
+
+		featureID, err := resolveFeatureID(ctx, database, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		dependsOnFeatureID, err := resolveFeatureID(ctx, database, dependsOnFeatureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := database.DeleteFeatureDependency(ctx, featureID, dependsOnFeatureID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText("Feature dependency deleted successfully"), nil
+	}
+}
+
+func listFeatureDependenciesHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+
+		deps, err := database.ListFeatureDependencies(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		listed := []*models.FeatureDependency{}
+		for _, d := range deps {
+			if featureName == "" || d.FeatureName == featureName {
+				listed = append(listed, d)
+			}
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"feature_dependencies": listed})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func getGraphJSONHandler(database *db.DB) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		json, err := database.GetGraphJSON(ctx)
 		if err != nil {
@@ -534,6 +1192,67 @@ func getGraphJSONHandler(database *db.DB) server.ToolHandlerFunc {
 	}
 }
 
+func getGraphMermaidHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+
+		plan, err := export.Build(ctx, database, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var sb strings.Builder
+		if err := export.WriteGraph(&sb, export.GraphMermaid, plan); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+}
+
+func analyzeGraphHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limit := mcp.ParseInt(request, "limit", 0)
+		if limit < 0 {
+			return mcp.NewToolResultError("limit must not be negative"), nil
+		}
+
+		analysis, err := database.AnalyzeGraph(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if limit > 0 && len(analysis.Tasks) > limit {
+			analysis.Tasks = analysis.Tasks[:limit]
+		}
+
+		data, err := json.Marshal(analysis)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func getProjectStatsHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		days := mcp.ParseInt(request, "days", db.DefaultStatsDays)
+		if days < 1 {
+			return mcp.NewToolResultError("days must be at least 1"), nil
+		}
+
+		stats, err := database.GetProjectStats(ctx, days)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
 func startTaskHandler(database *db.DB) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		featureName := mcp.ParseString(request, "feature_name", "")
@@ -593,16 +1312,397 @@ func reportTaskBlockedHandler(database *db.DB) server.ToolHandlerFunc {
 			return mcp.NewToolResultError(fmt.Sprintf("task with name '%s' not found in feature '%s'", name, featureName)), nil
 		}
 
-		t.Specification += fmt.Sprintf("\n\n### Blocked Reason\n%s", reason)
-		if err := database.UpdateTask(ctx, t); err != nil {
+		if err := database.BlockTask(ctx, t.ID, reason); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		if err := database.UpdateTaskStatus(ctx, t.ID, models.TaskStatusBlocked, nil); err != nil {
+		return mcp.NewToolResultText("Task reported as blocked successfully"), nil
+	}
+}
+
+func unblockTaskHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		return mcp.NewToolResultText("Task reported as blocked successfully"), nil
+		if err := database.UnblockTask(ctx, taskID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText("Task unblocked successfully"), nil
+	}
+}
+
+// blockedTask is a task as list_blocked_tasks reports it.
+type blockedTask struct {
+	ID            string    `json:"id"`
+	FeatureName   string    `json:"feature_name"`
+	Name          string    `json:"name"`
+	Priority      int       `json:"priority"`
+	BlockedReason string    `json:"blocked_reason"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// BlockingDependencies are the dependencies that are not completed.
+	BlockingDependencies []blockingDependency `json:"blocking_dependencies"`
+	// Waiting are the dependents that are not completed or cancelled, which
+	// unblocking the task may free up.
+	Waiting     []linkedTask `json:"waiting"`
+	Suggestions []string     `json:"suggestions"`
+}
+
+// blockingDependency is a dependency that holds back a blocked task.
+type blockingDependency struct {
+	ID            string            `json:"id"`
+	FeatureName   string            `json:"feature_name"`
+	Name          string            `json:"name"`
+	Status        models.TaskStatus `json:"status"`
+	BlockedReason *string           `json:"blocked_reason,omitempty"`
+}
+
+func listBlockedTasksHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]any)
+		var featureName *string
+		if fn, ok := args["feature_name"].(string); ok && fn != "" {
+			featureName = &fn
+		}
+
+		status := models.TaskStatusBlocked
+		tasks, err := database.ListTasks(ctx, &status, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		blocked := make([]blockedTask, 0, len(tasks))
+		for _, t := range tasks {
+			bt := blockedTask{
+				ID:                   t.ID,
+				FeatureName:          t.FeatureName,
+				Name:                 t.Name,
+				Priority:             t.Priority,
+				UpdatedAt:            t.UpdatedAt,
+				BlockingDependencies: []blockingDependency{},
+			}
+			if t.BlockedReason != nil {
+				bt.BlockedReason = *t.BlockedReason
+			}
+
+			deps, err := database.GetDependencies(ctx, t.ID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			for _, d := range deps {
+				if d.Status == models.TaskStatusCompleted {
+					continue
+				}
+				bt.BlockingDependencies = append(bt.BlockingDependencies, blockingDependency{
+					ID:            d.ID,
+					FeatureName:   d.FeatureName,
+					Name:          d.Name,
+					Status:        d.Status,
+					BlockedReason: d.BlockedReason,
+				})
+			}
+
+			dependents, err := database.GetDependents(ctx, t.ID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			dependents = slices.DeleteFunc(dependents, func(d *models.Task) bool {
+				return d.Status == models.TaskStatusCompleted || d.Status == models.TaskStatusCancelled
+			})
+			bt.Waiting = linkedTasks(dependents)
+
+			bt.Suggestions = unblockSuggestions(bt)
+			blocked = append(blocked, bt)
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"tasks": blocked})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+// unblockSuggestions returns what a triage agent could do about a blocked
+// task, from what holds it back.
+func unblockSuggestions(t blockedTask) []string {
+	var suggestions []string
+	for _, d := range t.BlockingDependencies {
+		name := d.FeatureName + "/" + d.Name
+		switch d.Status {
+		case models.TaskStatusBlocked:
+			s := fmt.Sprintf("Unblock the dependency %s first", name)
+			if d.BlockedReason != nil && *d.BlockedReason != "" {
+				s += "; it is blocked because: " + *d.BlockedReason
+			}
+			suggestions = append(suggestions, s)
+		case models.TaskStatusCancelled:
+			suggestions = append(suggestions, fmt.Sprintf("The dependency %s is cancelled and will never complete: remove it with delete_dependency, or depend on a task that replaces it", name))
+		default:
+			suggestions = append(suggestions, fmt.Sprintf("Wait for the dependency %s (%s) to be completed", name, d.Status))
+		}
+	}
+
+	if len(t.BlockingDependencies) == 0 {
+		suggestions = append(suggestions, "No dependency holds it back: deal with the blocked_reason, then call unblock_task, or cancel_task if the task is no longer needed")
+	} else {
+		suggestions = append(suggestions, "Once its dependencies are completed and the blocked_reason is dealt with, call unblock_task")
+	}
+	switch n := len(t.Waiting); {
+	case n == 1:
+		suggestions = append(suggestions, "Worth doing early: 1 waiting task depends on it")
+	case n > 1:
+		suggestions = append(suggestions, fmt.Sprintf("Worth doing early: %d waiting tasks depend on it", n))
+	}
+	return suggestions
+}
+
+func reopenTaskHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+		reason := mcp.ParseString(request, "reason", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := database.ReopenTask(ctx, taskID, reason); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText("Task reopened successfully"), nil
+	}
+}
+
+func cancelTaskHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+		reason := mcp.ParseString(request, "reason", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var summary *string
+		if reason != "" {
+			summary = &reason
+		}
+		if err := database.UpdateTaskStatus(ctx, taskID, models.TaskStatusCancelled, summary); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText("Task cancelled successfully"), nil
+	}
+}
+
+func approveTaskHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+		summary := mcp.ParseString(request, "completion_summary", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		t, err := database.GetTask(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if t.Status != models.TaskStatusInReview {
+			return mcp.NewToolResultError(fmt.Sprintf("task '%s' is %s, only in_review tasks can be approved", name, t.Status)), nil
+		}
+
+		if summary == "" && t.CompletionSummary != nil {
+			summary = *t.CompletionSummary
+		}
+		if summary == "" {
+			return mcp.NewToolResultError("completion_summary is required because none was submitted for review"), nil
+		}
+
+		if err := database.UpdateTaskStatus(ctx, taskID, models.TaskStatusCompleted, &summary); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText("Task approved successfully"), nil
+	}
+}
+
+func addTaskNoteHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+		body := mcp.ParseString(request, "body", "")
+		author := mcp.ParseString(request, "author", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		note := &models.TaskNote{TaskID: taskID, Author: author, Body: body}
+		if err := database.AddTaskNote(ctx, note); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(note)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func listTaskNotesHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		notes, err := database.ListTaskNotes(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"notes": notes})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func getTaskRunsHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		name := mcp.ParseString(request, "name", "")
+
+		taskID, err := resolveTaskID(ctx, database, featureName, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		runs, err := database.ListTaskRuns(ctx, taskID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"runs": runs})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func setRunEnvironmentHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		taskName := mcp.ParseString(request, "task_name", "")
+
+		env := &models.RunEnvironment{WorkingDir: mcp.ParseString(request, "working_dir", "")}
+		if raw := mcp.ParseStringMap(request, "env", nil); len(raw) > 0 {
+			env.Env = make(map[string]string, len(raw))
+			for name, value := range raw {
+				s, ok := value.(string)
+				if !ok {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid env: value of %s must be a string", name)), nil
+				}
+				env.Env[name] = s
+			}
+		}
+
+		var err error
+		if taskName != "" {
+			var taskID string
+			if taskID, err = resolveTaskID(ctx, database, featureName, taskName); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			err = database.SetTaskRunEnvironment(ctx, taskID, env)
+		} else {
+			f, ferr := database.GetFeatureByName(ctx, featureName)
+			if ferr != nil {
+				return mcp.NewToolResultError(ferr.Error()), nil
+			}
+			if f == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("feature with name '%s' not found", featureName)), nil
+			}
+			err = database.SetFeatureRunEnvironment(ctx, f.ID, env)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(env)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func getRunEnvironmentHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		featureName := mcp.ParseString(request, "feature_name", "")
+		taskName := mcp.ParseString(request, "task_name", "")
+
+		f, err := database.GetFeatureByName(ctx, featureName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if f == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("feature with name '%s' not found", featureName)), nil
+		}
+		featureEnv, err := database.GetFeatureRunEnvironment(ctx, f.ID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result := map[string]interface{}{"feature": featureEnv}
+
+		if taskName != "" {
+			t, err := database.GetTaskByName(ctx, taskName, f.ID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if t == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("task with name '%s' not found in feature '%s'", taskName, featureName)), nil
+			}
+			taskEnv, err := database.GetTaskRunEnvironment(ctx, t.ID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			resolved, err := database.ResolveRunEnvironment(ctx, t)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result["task"] = taskEnv
+			result["resolved"] = resolved
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
 	}
 }
 
@@ -617,6 +1717,28 @@ func commitStagedChangesHandler(database *db.DB) server.ToolHandlerFunc {
 	}
 }
 
+func validateStagedChangesHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID := mcp.ParseString(request, "session_id", "default")
+
+		problems, err := database.ValidateBatch(ctx, sessionID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		errs := make([]string, len(problems))
+		for i, p := range problems {
+			errs[i] = p.Error()
+		}
+		data, err := json.Marshal(map[string]interface{}{"valid": len(errs) == 0, "errors": errs})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
 func listStagedChangesHandler(database *db.DB) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		sessionID := mcp.ParseString(request, "session_id", "default")
@@ -631,6 +1753,124 @@ func listStagedChangesHandler(database *db.DB) server.ToolHandlerFunc {
 	}
 }
 
+func updateStagedChangeHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind := mcp.ParseString(request, "kind", "")
+		name := mcp.ParseString(request, "name", "")
+		featureName := mcp.ParseString(request, "feature_name", "")
+		sessionID := mcp.ParseString(request, "session_id", "default")
+		args, _ := request.Params.Arguments.(map[string]any)
+
+		switch kind {
+		case "feature":
+			found, err := database.Staging.UpdateFeature(sessionID, name, func(f *models.Feature) {
+				if newName, ok := args["new_name"].(string); ok {
+					f.Name = newName
+				}
+				if description, ok := args["description"].(string); ok {
+					f.Description = description
+				}
+				if specification, ok := args["specification"].(string); ok {
+					f.Specification = specification
+				}
+			})
+			if !found {
+				return mcp.NewToolResultError(fmt.Sprintf("Staged feature '%s' not found in session '%s'", name, sessionID)), nil
+			}
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		case "task":
+			found, err := database.Staging.UpdateTask(sessionID, featureName, name, func(t *models.Task) {
+				if newName, ok := args["new_name"].(string); ok {
+					t.Name = newName
+				}
+				if newFeatureName, ok := args["new_feature_name"].(string); ok {
+					t.FeatureName = newFeatureName
+				}
+				if description, ok := args["description"].(string); ok {
+					t.Description = description
+				}
+				if specification, ok := args["specification"].(string); ok {
+					t.Specification = specification
+				}
+				if priority, ok := args["priority"].(float64); ok {
+					t.Priority = int(priority)
+				}
+				if testsRequired, ok := args["tests_required"].(bool); ok {
+					t.TestsRequired = testsRequired
+				}
+			})
+			if !found {
+				return mcp.NewToolResultError(fmt.Sprintf("Staged task '%s' not found in feature '%s' for session '%s'", name, featureName, sessionID)), nil
+			}
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid kind '%s': must be feature or task", kind)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Staged %s '%s' updated for session '%s'", kind, name, sessionID)), nil
+	}
+}
+
+func removeStagedChangeHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind := mcp.ParseString(request, "kind", "")
+		name := mcp.ParseString(request, "name", "")
+		featureName := mcp.ParseString(request, "feature_name", "")
+		sessionID := mcp.ParseString(request, "session_id", "default")
+
+		var found bool
+		var label string
+		switch kind {
+		case "feature":
+			found = database.Staging.RemoveFeature(sessionID, name)
+			label = fmt.Sprintf("feature '%s'", name)
+		case "task":
+			found = database.Staging.RemoveTask(sessionID, featureName, name)
+			label = fmt.Sprintf("task '%s:%s'", featureName, name)
+		case "dependency":
+			dep := &models.Dependency{
+				FeatureName:          featureName,
+				TaskName:             mcp.ParseString(request, "task_name", ""),
+				DependsOnTaskName:    mcp.ParseString(request, "depends_on_task_name", ""),
+				DependsOnFeatureName: mcp.ParseString(request, "depends_on_feature_name", featureName),
+			}
+			found = database.Staging.RemoveDependency(sessionID, dep)
+			label = fmt.Sprintf("dependency %s:%s -> %s:%s", dep.FeatureName, dep.TaskName, dep.DependsOnFeatureName, dep.DependsOnTaskName)
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid kind '%s': must be feature, task, or dependency", kind)), nil
+		}
+
+		if !found {
+			return mcp.NewToolResultError(fmt.Sprintf("Staged %s not found in session '%s'", label, sessionID)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Staged %s removed from session '%s'", label, sessionID)), nil
+	}
+}
+
+func discardStagedChangesHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID := mcp.ParseString(request, "session_id", "default")
+		count := database.Staging.Discard(sessionID)
+		return mcp.NewToolResultText(fmt.Sprintf("Discarded %d staged changes for session '%s'", count, sessionID)), nil
+	}
+}
+
+func resolveFeatureID(ctx context.Context, database *db.DB, featureName string) (string, error) {
+	f, err := database.GetFeatureByName(ctx, featureName)
+	if err != nil {
+		return "", err
+	}
+	if f == nil {
+		return "", fmt.Errorf("feature with name '%s' not found", featureName)
+	}
+	return f.ID, nil
+}
+
 func resolveTaskID(ctx context.Context, database *db.DB, featureName, taskName string) (string, error) {
 	f, err := database.GetFeatureByName(ctx, featureName)
 	if err != nil {
@@ -650,3 +1890,59 @@ func resolveTaskID(ctx context.Context, database *db.DB, featureName, taskName s
 
 	return t.ID, nil
 }
+
+// templateInfo is a template as list_templates reports it.
+type templateInfo struct {
+	*models.TaskTemplate
+	Variables []string `json:"variables,omitempty"`
+}
+
+func listTemplatesHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		templates, err := database.ListTemplates(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		infos := make([]templateInfo, 0, len(templates))
+		for _, tmpl := range templates {
+			infos = append(infos, templateInfo{TaskTemplate: tmpl, Variables: db.TemplateVariables(tmpl)})
+		}
+
+		data, err := json.Marshal(infos)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func createTaskFromTemplateHandler(database *db.DB) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		templateName := mcp.ParseString(request, "template_name", "")
+		sessionID := mcp.ParseString(request, "session_id", "default")
+
+		vars := make(map[string]string)
+		for name, value := range mcp.ParseStringMap(request, "variables", nil) {
+			s, ok := value.(string)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid variables: value of %s must be a string", name)), nil
+			}
+			vars[name] = s
+		}
+
+		tmpl, err := database.GetTemplateByName(ctx, templateName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if tmpl == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("template with name '%s' not found", templateName)), nil
+		}
+		t, err := db.ExpandTemplate(tmpl, vars, time.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		database.Staging.AddTask(sessionID, t)
+		return mcp.NewToolResultText(fmt.Sprintf("Task '%s' staged for session '%s'. Propose another or call 'commit_staged_changes' to apply.", t.Name, sessionID)), nil
+	}
+}
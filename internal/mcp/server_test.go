@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -288,6 +289,176 @@ func TestToolHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("update_task_schedule", func(t *testing.T) {
+		update := func(args map[string]interface{}) *mcp.CallToolResult {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "update_task"
+			req.Params.Arguments = args
+			result, err := s.GetTool("update_task").Handler(ctx, req)
+			if err != nil {
+				t.Fatalf("Handler failed: %v", err)
+			}
+			return result
+		}
+
+		result := update(map[string]interface{}{
+			"feature_name": "test-feature",
+			"name":         "updated-task",
+			"not_before":   "2030-01-02T03:04:05Z",
+			"due_at":       "2030-02-01T00:00:00Z",
+		})
+		if result.IsError {
+			t.Fatalf("Tool returned error: %v", result.Content)
+		}
+		f, _ := database.GetFeatureByName(ctx, "test-feature")
+		task, _ := database.GetTaskByName(ctx, "updated-task", f.ID)
+		if task.NotBefore == nil || !task.NotBefore.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("Expected not_before 2030-01-02T03:04:05Z, got %v", task.NotBefore)
+		}
+		if task.DueAt == nil || !task.DueAt.Equal(time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected due_at 2030-02-01T00:00:00Z, got %v", task.DueAt)
+		}
+
+		result = update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "due_at": "soon"})
+		if !result.IsError {
+			t.Error("Expected error for invalid due_at")
+		}
+
+		result = update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "not_before": ""})
+		if result.IsError {
+			t.Fatalf("Tool returned error: %v", result.Content)
+		}
+		task, _ = database.GetTaskByName(ctx, "updated-task", f.ID)
+		if task.NotBefore != nil || task.DueAt == nil {
+			t.Errorf("Expected only not_before to be cleared, got %v and %v", task.NotBefore, task.DueAt)
+		}
+
+		result = update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "due_at": ""})
+		if result.IsError {
+			t.Fatalf("Tool returned error: %v", result.Content)
+		}
+	})
+
+	t.Run("update_task_estimate", func(t *testing.T) {
+		update := func(args map[string]interface{}) *mcp.CallToolResult {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "update_task"
+			req.Params.Arguments = args
+			result, err := s.GetTool("update_task").Handler(ctx, req)
+			if err != nil {
+				t.Fatalf("Handler failed: %v", err)
+			}
+			return result
+		}
+		f, _ := database.GetFeatureByName(ctx, "test-feature")
+
+		if result := update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "estimate_minutes": float64(40)}); result.IsError {
+			t.Fatalf("Tool returned error: %v", result.Content)
+		}
+		task, _ := database.GetTaskByName(ctx, "updated-task", f.ID)
+		if task.EstimateMinutes == nil || *task.EstimateMinutes != 40 {
+			t.Errorf("Expected an estimate of 40 minutes, got %v", task.EstimateMinutes)
+		}
+
+		for _, bad := range []float64{-1, 2.5} {
+			if result := update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "estimate_minutes": bad}); !result.IsError {
+				t.Errorf("Expected error for estimate %v", bad)
+			}
+		}
+
+		if result := update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "estimate_minutes": float64(0)}); result.IsError {
+			t.Fatalf("Tool returned error: %v", result.Content)
+		}
+		task, _ = database.GetTaskByName(ctx, "updated-task", f.ID)
+		if task.EstimateMinutes != nil {
+			t.Errorf("Expected the estimate to be cleared, got %d", *task.EstimateMinutes)
+		}
+	})
+
+	t.Run("update_task_version", func(t *testing.T) {
+		update := func(args map[string]interface{}) *mcp.CallToolResult {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "update_task"
+			req.Params.Arguments = args
+			result, err := s.GetTool("update_task").Handler(ctx, req)
+			if err != nil {
+				t.Fatalf("Handler failed: %v", err)
+			}
+			return result
+		}
+		f, _ := database.GetFeatureByName(ctx, "test-feature")
+		task, _ := database.GetTaskByName(ctx, "updated-task", f.ID)
+
+		result := update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "specification": "stale", "version": float64(task.Version - 1)})
+		if !result.IsError {
+			t.Error("Expected a stale version to be refused")
+		}
+		result = update(map[string]interface{}{"feature_name": "test-feature", "name": "updated-task", "specification": "fresh", "version": float64(task.Version)})
+		if result.IsError {
+			t.Fatalf("Tool returned error: %v", result.Content)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, fmt.Sprintf("version %d", task.Version+1)) {
+			t.Errorf("Expected the new version in %q", text)
+		}
+	})
+
+	t.Run("subtasks", func(t *testing.T) {
+		call := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = name
+			req.Params.Arguments = args
+			result, err := s.GetTool(name).Handler(ctx, req)
+			if err != nil {
+				t.Fatalf("%s failed: %v", name, err)
+			}
+			return result
+		}
+
+		// The parent is staged in the same session as its subtask.
+		for _, args := range []map[string]interface{}{
+			{"feature_name": "test-feature", "name": "epic", "description": "d", "specification": "s", "subtask_order": "parent_first", "session_id": "subtasks"},
+			{"feature_name": "test-feature", "name": "epic-part", "description": "d", "specification": "s", "parent_task_name": "epic", "session_id": "subtasks"},
+		} {
+			if result := call("create_task", args); result.IsError {
+				t.Fatalf("create_task returned error: %v", result.Content)
+			}
+		}
+		if result := call("create_task", map[string]interface{}{"feature_name": "test-feature", "name": "bad", "description": "d", "specification": "s", "subtask_order": "sideways"}); !result.IsError {
+			t.Error("Expected error for invalid subtask_order")
+		}
+		if result := call("commit_staged_changes", map[string]interface{}{"session_id": "subtasks"}); result.IsError {
+			t.Fatalf("commit_staged_changes returned error: %v", result.Content)
+		}
+
+		f, _ := database.GetFeatureByName(ctx, "test-feature")
+		epic, _ := database.GetTaskByName(ctx, "epic", f.ID)
+		part, _ := database.GetTaskByName(ctx, "epic-part", f.ID)
+		if epic == nil || epic.SubtaskOrder != models.SubtaskOrderParentFirst {
+			t.Fatalf("Expected epic with parent_first order, got %+v", epic)
+		}
+		if part == nil || part.ParentTaskID == nil || *part.ParentTaskID != epic.ID {
+			t.Fatalf("Expected epic-part to be a subtask of epic, got %+v", part)
+		}
+
+		if result := call("update_task", map[string]interface{}{"feature_name": "test-feature", "name": "epic", "parent_task_name": "epic-part"}); !result.IsError {
+			t.Error("Expected error making a task a subtask of its own subtask")
+		}
+		if result := call("update_task", map[string]interface{}{"feature_name": "test-feature", "name": "epic-part", "parent_task_name": ""}); result.IsError {
+			t.Fatalf("update_task returned error: %v", result.Content)
+		}
+		part, _ = database.GetTaskByName(ctx, "epic-part", f.ID)
+		if part.ParentTaskID != nil {
+			t.Errorf("Expected parent to be cleared, got %v", *part.ParentTaskID)
+		}
+
+		for _, name := range []string{"epic-part", "epic"} {
+			task, _ := database.GetTaskByName(ctx, name, f.ID)
+			if err := database.DeleteTask(ctx, task.ID); err != nil {
+				t.Fatalf("Failed to delete %s: %v", name, err)
+			}
+		}
+	})
+
 	t.Run("move_task_between_features", func(t *testing.T) {
 		if err := database.CreateFeature(ctx, &models.Feature{
 			Name:          "other-feature",
@@ -648,6 +819,33 @@ func TestToolHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("get_graph_mermaid", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "get_graph_mermaid"
+		req.Params.Arguments = map[string]interface{}{}
+
+		tool := s.GetTool("get_graph_mermaid")
+		if tool == nil {
+			t.Fatal("Tool get_graph_mermaid not found")
+		}
+
+		result, err := tool.Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Handler failed: %v, %v", err, result.Content)
+		}
+
+		text := result.Content[0].(mcp.TextContent).Text
+		if !strings.HasPrefix(text, "flowchart LR") || !strings.Contains(text, "-->") {
+			t.Errorf("Expected a Mermaid flowchart with edges, got: %s", text)
+		}
+
+		req.Params.Arguments = map[string]interface{}{"feature_name": "does-not-exist"}
+		result, err = tool.Handler(ctx, req)
+		if err != nil || !result.IsError {
+			t.Error("Expected error for unknown feature")
+		}
+	})
+
 	t.Run("lifecycle_tools", func(t *testing.T) {
 		fName := "lifecycle-feat"
 		tName := "lifecycle-task"
@@ -704,8 +902,11 @@ func TestToolHandlers(t *testing.T) {
 			if task.Status != models.TaskStatusBlocked {
 				t.Errorf("Expected status blocked, got %s", task.Status)
 			}
-			if !strings.Contains(task.Specification, "### Blocked Reason") || !strings.Contains(task.Specification, "missing API key") {
-				t.Errorf("Specification not updated correctly: %s", task.Specification)
+			if task.BlockedReason == nil || *task.BlockedReason != "missing API key" {
+				t.Errorf("Expected blocked reason, got %v", task.BlockedReason)
+			}
+			if strings.Contains(task.Specification, "missing API key") {
+				t.Errorf("Expected the reason to stay out of the specification: %s", task.Specification)
 			}
 		})
 
@@ -736,6 +937,424 @@ func TestToolHandlers(t *testing.T) {
 				t.Errorf("Completion summary not saved correctly")
 			}
 		})
+
+		t.Run("review_and_cancel", func(t *testing.T) {
+			callTool := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+				req := mcp.CallToolRequest{}
+				req.Params.Name = name
+				req.Params.Arguments = args
+				result, err := s.GetTool(name).Handler(ctx, req)
+				if err != nil {
+					t.Fatalf("%s returned error: %v", name, err)
+				}
+				return result
+			}
+
+			review := &models.Task{FeatureID: f.ID, Name: "review-task", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+			if err := database.CreateTask(ctx, review); err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+
+			args := map[string]interface{}{"feature_name": fName, "name": review.Name}
+			if result := callTool("approve_task", args); !result.IsError {
+				t.Error("expected approve_task to reject a task that is not in review")
+			}
+
+			database.UpdateTaskStatus(ctx, review.ID, models.TaskStatusInProgress, nil)
+			result := callTool("update_task_status", map[string]interface{}{
+				"feature_name":       fName,
+				"name":               review.Name,
+				"status":             "in_review",
+				"completion_summary": "please check the migration",
+			})
+			if result.IsError {
+				t.Fatalf("update_task_status to in_review failed: %v", result.Content)
+			}
+
+			if result := callTool("approve_task", args); result.IsError {
+				t.Fatalf("approve_task failed: %v", result.Content)
+			}
+			task, _ := database.GetTask(ctx, review.ID)
+			if task.Status != models.TaskStatusCompleted || task.CompletionSummary == nil || *task.CompletionSummary != "please check the migration" {
+				t.Errorf("expected approved task to keep its review summary, got %s %v", task.Status, task.CompletionSummary)
+			}
+
+			doomed := &models.Task{FeatureID: f.ID, Name: "doomed-task", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+			if err := database.CreateTask(ctx, doomed); err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+			cancelArgs := map[string]interface{}{"feature_name": fName, "name": doomed.Name, "reason": "out of scope"}
+			if result := callTool("cancel_task", cancelArgs); result.IsError {
+				t.Fatalf("cancel_task failed: %v", result.Content)
+			}
+			task, _ = database.GetTask(ctx, doomed.ID)
+			if task.Status != models.TaskStatusCancelled || task.CompletionSummary == nil || *task.CompletionSummary != "out of scope" {
+				t.Errorf("expected cancelled task with reason, got %s %v", task.Status, task.CompletionSummary)
+			}
+			if result := callTool("start_task", map[string]interface{}{"feature_name": fName, "name": doomed.Name}); !result.IsError {
+				t.Error("expected cancelled task to be final")
+			}
+
+			if result := callTool("reopen_task", args); !result.IsError {
+				t.Error("expected reopen_task to require a reason")
+			}
+			reopenArgs := map[string]interface{}{"feature_name": fName, "name": review.Name, "reason": "migration fails on Postgres"}
+			if result := callTool("reopen_task", reopenArgs); result.IsError {
+				t.Fatalf("reopen_task failed: %v", result.Content)
+			}
+			task, _ = database.GetTask(ctx, review.ID)
+			if task.Status != models.TaskStatusPending || task.CompletionSummary != nil || task.CompletedAt != nil {
+				t.Errorf("expected reopened task to be pending without completion details, got %s %v %v", task.Status, task.CompletionSummary, task.CompletedAt)
+			}
+			events, err := database.ListEvents(ctx, db.EntityTask, review.ID, 1)
+			if err != nil || len(events) != 1 || events[0].After == nil || !strings.Contains(string(events[0].After), "migration fails on Postgres") {
+				t.Errorf("expected the reopen reason in the task history, got %v (err %v)", events, err)
+			}
+			if result := callTool("reopen_task", reopenArgs); !result.IsError {
+				t.Error("expected reopen_task to reject a task that is not completed")
+			}
+
+			if result := callTool("unblock_task", args); !result.IsError {
+				t.Error("expected unblock_task to reject a task that is not blocked")
+			}
+			if err := database.BlockTask(ctx, review.ID, "waiting on a Postgres test server"); err != nil {
+				t.Fatalf("Failed to block task: %v", err)
+			}
+			if result := callTool("unblock_task", args); result.IsError {
+				t.Fatalf("unblock_task failed: %v", result.Content)
+			}
+			task, _ = database.GetTask(ctx, review.ID)
+			if task.Status != models.TaskStatusPending || task.BlockedReason != nil {
+				t.Errorf("expected unblocked task to be pending without a reason, got %s %v", task.Status, task.BlockedReason)
+			}
+		})
+
+		t.Run("task_notes", func(t *testing.T) {
+			for _, body := range []string{"first note", "second note"} {
+				req := mcp.CallToolRequest{}
+				req.Params.Name = "add_task_note"
+				req.Params.Arguments = map[string]interface{}{
+					"feature_name": fName,
+					"name":         tName,
+					"body":         body,
+					"author":       "worker-1",
+				}
+				result, err := s.GetTool("add_task_note").Handler(ctx, req)
+				if err != nil || result.IsError {
+					t.Fatalf("Handler failed: %v, %v", err, result.Content)
+				}
+			}
+
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "add_task_note"
+			req.Params.Arguments = map[string]interface{}{
+				"feature_name": fName,
+				"name":         tName,
+				"body":         "   ",
+			}
+			result, err := s.GetTool("add_task_note").Handler(ctx, req)
+			if err != nil || !result.IsError {
+				t.Errorf("Expected error for empty note body")
+			}
+
+			req = mcp.CallToolRequest{}
+			req.Params.Name = "list_task_notes"
+			req.Params.Arguments = map[string]interface{}{
+				"feature_name": fName,
+				"name":         tName,
+			}
+			result, err = s.GetTool("list_task_notes").Handler(ctx, req)
+			if err != nil || result.IsError {
+				t.Fatalf("Handler failed: %v, %v", err, result.Content)
+			}
+
+			var resp struct {
+				Notes []*models.TaskNote `json:"notes"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal notes: %v", err)
+			}
+			if len(resp.Notes) != 2 {
+				t.Fatalf("Expected 2 notes, got %d", len(resp.Notes))
+			}
+			if resp.Notes[0].Body != "first note" || resp.Notes[1].Body != "second note" {
+				t.Errorf("Expected notes oldest first, got %q then %q", resp.Notes[0].Body, resp.Notes[1].Body)
+			}
+			if resp.Notes[0].Author != "worker-1" {
+				t.Errorf("Expected author worker-1, got %s", resp.Notes[0].Author)
+			}
+
+			task, _ := database.GetTaskByName(ctx, tName, f.ID)
+			if strings.Contains(task.Specification, "first note") {
+				t.Error("Notes must not modify the task specification")
+			}
+		})
+
+		t.Run("get_task_runs", func(t *testing.T) {
+			task, _ := database.GetTaskByName(ctx, tName, f.ID)
+			if err := database.RecordRun(ctx, &models.Run{TaskID: task.ID, Model: "m", Prompt: "p", Output: "boom", ExitCode: 2, Error: "exit status 2", StartedAt: time.Now()}); err != nil {
+				t.Fatalf("Failed to record run: %v", err)
+			}
+
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "get_task_runs"
+			req.Params.Arguments = map[string]interface{}{
+				"feature_name": fName,
+				"name":         tName,
+			}
+			result, err := s.GetTool("get_task_runs").Handler(ctx, req)
+			if err != nil || result.IsError {
+				t.Fatalf("Handler failed: %v, %v", err, result.Content)
+			}
+
+			var resp struct {
+				Runs []*models.Run `json:"runs"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal runs: %v", err)
+			}
+			if len(resp.Runs) != 1 || resp.Runs[0].Output != "boom" || resp.Runs[0].ExitCode != 2 {
+				t.Errorf("Unexpected runs: %+v", resp.Runs)
+			}
+		})
+
+		t.Run("get_task", func(t *testing.T) {
+			task, _ := database.GetTaskByName(ctx, tName, f.ID)
+			long := strings.Repeat("x", runOutputTail) + "the end"
+			if err := database.RecordRun(ctx, &models.Run{TaskID: task.ID, Model: "m", Prompt: "p", Output: long, ExitCode: 1, StartedAt: time.Now()}); err != nil {
+				t.Fatalf("Failed to record run: %v", err)
+			}
+
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "get_task"
+			req.Params.Arguments = map[string]interface{}{
+				"feature_name": fName,
+				"name":         tName,
+				"runs":         1,
+			}
+			result, err := s.GetTool("get_task").Handler(ctx, req)
+			if err != nil || result.IsError {
+				t.Fatalf("Handler failed: %v, %v", err, result.Content)
+			}
+
+			var resp struct {
+				Name    string `json:"name"`
+				Feature struct {
+					Name string `json:"name"`
+				} `json:"feature"`
+				Dependencies []linkedTask       `json:"dependencies"`
+				Notes        []*models.TaskNote `json:"notes"`
+				Runs         []recentRun        `json:"runs"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal task: %v", err)
+			}
+			if resp.Name != tName || resp.Feature.Name != fName {
+				t.Errorf("Expected %s/%s, got %s/%s", fName, tName, resp.Feature.Name, resp.Name)
+			}
+			if resp.Dependencies == nil || len(resp.Notes) != 2 {
+				t.Errorf("Expected dependencies and both notes, got %+v and %d notes", resp.Dependencies, len(resp.Notes))
+			}
+			if len(resp.Runs) != 1 {
+				t.Fatalf("Expected only the latest run, got %d", len(resp.Runs))
+			}
+			if run := resp.Runs[0]; !run.OutputTruncated || len(run.Output) != runOutputTail || !strings.HasSuffix(run.Output, "the end") {
+				t.Errorf("Expected the end of the output, got %d bytes, truncated=%v", len(run.Output), run.OutputTruncated)
+			}
+
+			req.Params.Arguments = map[string]interface{}{"feature_name": fName, "name": "missing"}
+			if result, err := s.GetTool("get_task").Handler(ctx, req); err != nil || !result.IsError {
+				t.Error("Expected an error for a missing task")
+			}
+		})
+
+		t.Run("run_environment", func(t *testing.T) {
+			callTool := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+				req := mcp.CallToolRequest{}
+				req.Params.Name = name
+				req.Params.Arguments = args
+				result, err := s.GetTool(name).Handler(ctx, req)
+				if err != nil {
+					t.Fatalf("%s returned error: %v", name, err)
+				}
+				return result
+			}
+
+			if result := callTool("set_run_environment", map[string]interface{}{
+				"feature_name": fName,
+				"working_dir":  "services/api",
+				"env":          map[string]interface{}{"GOFLAGS": "-mod=mod", "PORT": "8080"},
+			}); result.IsError {
+				t.Fatalf("set_run_environment failed: %v", result.Content)
+			}
+			if result := callTool("set_run_environment", map[string]interface{}{
+				"feature_name": fName,
+				"task_name":    tName,
+				"env":          map[string]interface{}{"PORT": "9090"},
+			}); result.IsError {
+				t.Fatalf("set_run_environment failed: %v", result.Content)
+			}
+			if result := callTool("set_run_environment", map[string]interface{}{
+				"feature_name": fName,
+				"working_dir":  "../outside",
+			}); !result.IsError {
+				t.Error("expected error for a working directory outside the repository")
+			}
+
+			result := callTool("get_run_environment", map[string]interface{}{"feature_name": fName, "task_name": tName})
+			if result.IsError {
+				t.Fatalf("get_run_environment failed: %v", result.Content)
+			}
+			var resp struct {
+				Feature  *models.RunEnvironment `json:"feature"`
+				Task     *models.RunEnvironment `json:"task"`
+				Resolved *models.RunEnvironment `json:"resolved"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal run environment: %v", err)
+			}
+			if resp.Feature == nil || resp.Feature.WorkingDir != "services/api" {
+				t.Errorf("expected feature working dir services/api, got %+v", resp.Feature)
+			}
+			if resp.Task == nil || resp.Task.WorkingDir != "" || resp.Task.Env["PORT"] != "9090" {
+				t.Errorf("unexpected task environment %+v", resp.Task)
+			}
+			if resp.Resolved == nil || resp.Resolved.WorkingDir != "services/api" ||
+				resp.Resolved.Env["PORT"] != "9090" || resp.Resolved.Env["GOFLAGS"] != "-mod=mod" {
+				t.Errorf("unexpected resolved environment %+v", resp.Resolved)
+			}
+		})
+	})
+
+	t.Run("reorder_tasks", func(t *testing.T) {
+		if err := database.CreateFeature(ctx, &models.Feature{Name: "reorder-feature", Description: "d", Specification: "s"}); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+		f, _ := database.GetFeatureByName(ctx, "reorder-feature")
+		for _, name := range []string{"a", "b", "c"} {
+			if err := database.CreateTask(ctx, &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}); err != nil {
+				t.Fatalf("Failed to create task %s: %v", name, err)
+			}
+		}
+
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "reorder_tasks"
+		req.Params.Arguments = map[string]interface{}{
+			"feature_name": "reorder-feature",
+			"task_names":   []interface{}{"c", "a", "b"},
+		}
+		tool := s.GetTool("reorder_tasks")
+		result, err := tool.Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Handler failed: %v, %v", err, result.Content)
+		}
+
+		feature := "reorder-feature"
+		tasks, err := database.ListTasks(ctx, nil, &feature)
+		if err != nil {
+			t.Fatalf("ListTasks failed: %v", err)
+		}
+		var order []string
+		for _, task := range tasks {
+			order = append(order, task.Name)
+		}
+		if strings.Join(order, ",") != "c,a,b" {
+			t.Errorf("expected order c,a,b, got %v", order)
+		}
+
+		req.Params.Arguments = map[string]interface{}{
+			"feature_name": "reorder-feature",
+			"task_names":   []interface{}{"a", "missing"},
+		}
+		if result, _ := tool.Handler(ctx, req); !result.IsError {
+			t.Error("expected error for an unknown task")
+		}
+	})
+
+	t.Run("append_task_specification", func(t *testing.T) {
+		if err := database.CreateFeature(ctx, &models.Feature{Name: "append-feature", Description: "d", Specification: "Feature spec"}); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+		f, _ := database.GetFeatureByName(ctx, "append-feature")
+		if err := database.CreateTask(ctx, &models.Task{FeatureID: f.ID, Name: "append-task", Description: "d", Specification: "Original spec", Status: models.TaskStatusPending}); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "append_task_specification"
+		req.Params.Arguments = map[string]interface{}{
+			"feature_name": "append-feature",
+			"name":         "append-task",
+			"title":        "Findings",
+			"text":         "The cache is keyed by user.",
+		}
+		result, err := s.GetTool("append_task_specification").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Handler failed: %v, %v", err, result.Content)
+		}
+		task, _ := database.GetTaskByName(ctx, "append-task", f.ID)
+		if !strings.HasPrefix(task.Specification, "Original spec\n\n## Findings (") || !strings.HasSuffix(task.Specification, "The cache is keyed by user.") {
+			t.Errorf("unexpected specification: %q", task.Specification)
+		}
+
+		req.Params.Arguments = map[string]interface{}{"name": "append-feature", "text": "Decided on Redis."}
+		result, err = s.GetTool("append_feature_specification").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Handler failed: %v, %v", err, result.Content)
+		}
+		f, _ = database.GetFeatureByName(ctx, "append-feature")
+		if !strings.HasPrefix(f.Specification, "Feature spec\n\n## Update (") || !strings.HasSuffix(f.Specification, "Decided on Redis.") {
+			t.Errorf("unexpected feature specification: %q", f.Specification)
+		}
+
+		req.Params.Arguments = map[string]interface{}{"name": "append-feature", "text": "  "}
+		if result, _ := s.GetTool("append_feature_specification").Handler(ctx, req); !result.IsError {
+			t.Error("expected error for empty text")
+		}
+	})
+
+	t.Run("create_task_from_template", func(t *testing.T) {
+		if err := database.CreateFeature(ctx, &models.Feature{Name: "template-feature", Description: "d", Specification: "s"}); err != nil {
+			t.Fatalf("Failed to create feature: %v", err)
+		}
+		tmpl := &models.TaskTemplate{Name: "bugfix", FeatureName: "template-feature", TaskName: "fix-{{issue}}", Specification: "Fix {{issue}}"}
+		if err := database.CreateTemplate(ctx, tmpl); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "list_templates"
+		result, err := s.GetTool("list_templates").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Handler failed: %v, %v", err, result.Content)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"variables":["issue"]`) {
+			t.Errorf("expected the template's variables to be listed, got %s", text)
+		}
+
+		req.Params.Name = "create_task_from_template"
+		req.Params.Arguments = map[string]interface{}{"template_name": "bugfix", "session_id": "template-session"}
+		if result, _ := s.GetTool("create_task_from_template").Handler(ctx, req); !result.IsError {
+			t.Error("expected error for a missing variable")
+		}
+
+		req.Params.Arguments = map[string]interface{}{
+			"template_name": "bugfix",
+			"variables":     map[string]interface{}{"issue": "7"},
+			"session_id":    "template-session",
+		}
+		result, err = s.GetTool("create_task_from_template").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Handler failed: %v, %v", err, result.Content)
+		}
+		if err := database.CommitBatch(ctx, "template-session"); err != nil {
+			t.Fatalf("CommitBatch failed: %v", err)
+		}
+		f, _ := database.GetFeatureByName(ctx, "template-feature")
+		task, _ := database.GetTaskByName(ctx, "fix-7", f.ID)
+		if task == nil || task.Specification != "Fix 7" {
+			t.Errorf("expected task fix-7 to be created, got %+v", task)
+		}
 	})
 
 	t.Run("error_handling", func(t *testing.T) {
@@ -800,6 +1419,17 @@ func TestToolHandlers(t *testing.T) {
 			if !result.IsError {
 				t.Error("Expected error during commit for non-existent dependency task, got success")
 			}
+
+			// A failed commit keeps the staged changes so they can be fixed.
+			req.Params.Name = "list_staged_changes"
+			result, _ = s.GetTool("list_staged_changes").Handler(ctx, req)
+			if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "does-not-exist") {
+				t.Errorf("Expected the staged dependency to survive the failed commit, got %s", text)
+			}
+			req.Params.Name = "discard_staged_changes"
+			if result, _ = s.GetTool("discard_staged_changes").Handler(ctx, req); result.IsError {
+				t.Fatalf("discard_staged_changes failed: %v", result.Content)
+			}
 		})
 	})
 
@@ -890,6 +1520,79 @@ func TestToolHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("create_tasks_bulk", func(t *testing.T) {
+		sessionID := "bulk-session"
+
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "create_tasks_bulk"
+		req.Params.Arguments = map[string]interface{}{
+			"feature_name": "staged-feature",
+			"session_id":   sessionID,
+			"tasks": []interface{}{
+				map[string]interface{}{
+					"name":          "bulk-api",
+					"description":   "d",
+					"specification": "s",
+					"depends_on":    []interface{}{"bulk-schema", map[string]interface{}{"feature_name": "staged-feature", "name": "staged-task"}},
+				},
+				map[string]interface{}{
+					"name":          "bulk-schema",
+					"description":   "d",
+					"specification": "s",
+					"priority":      float64(8),
+				},
+			},
+		}
+
+		tool := s.GetTool("create_tasks_bulk")
+		result, err := tool.Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Failed to stage tasks: %v, %v", err, result.Content)
+		}
+
+		req = mcp.CallToolRequest{}
+		req.Params.Name = "commit_staged_changes"
+		req.Params.Arguments = map[string]interface{}{
+			"session_id": sessionID,
+		}
+		result, err = s.GetTool("commit_staged_changes").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("Failed to commit staged changes: %v, %v", err, result.Content)
+		}
+
+		f, _ := database.GetFeatureByName(ctx, "staged-feature")
+		schema, _ := database.GetTaskByName(ctx, "bulk-schema", f.ID)
+		if schema == nil || schema.Priority != 8 {
+			t.Fatalf("Expected bulk-schema with priority 8, got %+v", schema)
+		}
+		api, _ := database.GetTaskByName(ctx, "bulk-api", f.ID)
+		if api == nil {
+			t.Fatal("bulk-api should be in DB now")
+		}
+		deps, err := database.GetDependencies(ctx, api.ID)
+		if err != nil {
+			t.Fatalf("GetDependencies failed: %v", err)
+		}
+		if len(deps) != 2 {
+			t.Errorf("Expected 2 dependencies, got %d", len(deps))
+		}
+
+		req = mcp.CallToolRequest{}
+		req.Params.Name = "create_tasks_bulk"
+		req.Params.Arguments = map[string]interface{}{
+			"feature_name": "staged-feature",
+			"session_id":   sessionID,
+			"tasks": []interface{}{
+				map[string]interface{}{"name": "dup"},
+				map[string]interface{}{"name": "dup"},
+			},
+		}
+		result, err = tool.Handler(ctx, req)
+		if err != nil || !result.IsError {
+			t.Errorf("Expected error for duplicate task names, got %v", result.Content)
+		}
+	})
+
 	t.Run("mandatory_staging_verification", func(t *testing.T) {
 		req := mcp.CallToolRequest{}
 		req.Params.Name = "create_feature"
@@ -931,4 +1634,276 @@ func TestToolHandlers(t *testing.T) {
 			t.Fatal("Feature should be in DB after commit")
 		}
 	})
+
+	t.Run("get_project_stats", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "get_project_stats"
+		req.Params.Arguments = map[string]interface{}{"days": float64(3)}
+		result, err := s.GetTool("get_project_stats").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("get_project_stats failed: %v %v", err, result)
+		}
+		var stats models.ProjectStats
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &stats); err != nil {
+			t.Fatalf("Failed to unmarshal stats: %v", err)
+		}
+		if stats.Days != 3 || len(stats.Throughput) != 3 || stats.TotalTasks == 0 {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+
+		req.Params.Arguments = map[string]interface{}{"days": float64(0)}
+		if result, _ := s.GetTool("get_project_stats").Handler(ctx, req); !result.IsError {
+			t.Error("Expected error for days=0")
+		}
+	})
+
+	t.Run("analyze_graph", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "analyze_graph"
+		req.Params.Arguments = map[string]interface{}{"limit": float64(1)}
+		result, err := s.GetTool("analyze_graph").Handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("analyze_graph failed: %v %v", err, result)
+		}
+		var analysis models.GraphAnalysis
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &analysis); err != nil {
+			t.Fatalf("Failed to unmarshal analysis: %v", err)
+		}
+		if analysis.OpenTasks == 0 || len(analysis.Tasks) != 1 || analysis.CriticalPathLength == 0 {
+			t.Errorf("Unexpected analysis: %+v", analysis)
+		}
+
+		req.Params.Arguments = map[string]interface{}{"limit": float64(-1)}
+		if result, _ := s.GetTool("analyze_graph").Handler(ctx, req); !result.IsError {
+			t.Error("Expected error for a negative limit")
+		}
+	})
+
+	t.Run("validate_staged_changes", func(t *testing.T) {
+		sessionID := "validate-session"
+		call := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+			args["session_id"] = sessionID
+			req := mcp.CallToolRequest{}
+			req.Params.Name = name
+			req.Params.Arguments = args
+			result, err := s.GetTool(name).Handler(ctx, req)
+			if err != nil {
+				t.Fatalf("%s handler failed: %v", name, err)
+			}
+			return result
+		}
+		validate := func() (valid bool, errs []string) {
+			result := call("validate_staged_changes", map[string]interface{}{})
+			if result.IsError {
+				t.Fatalf("validate_staged_changes failed: %v", result.Content)
+			}
+			var out struct {
+				Valid  bool     `json:"valid"`
+				Errors []string `json:"errors"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &out); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			return out.Valid, out.Errors
+		}
+
+		call("create_feature", map[string]interface{}{"name": "plan-feature", "description": "d", "specification": "s"})
+		call("create_task", map[string]interface{}{"feature_name": "plan-feature", "name": "a", "description": "d", "specification": "s"})
+		call("create_task", map[string]interface{}{"feature_name": "plan-feature", "name": "b", "description": "d", "specification": "s"})
+		call("create_dependency", map[string]interface{}{"feature_name": "plan-feature", "task_name": "a", "depends_on_task_name": "b"})
+		if valid, errs := validate(); !valid {
+			t.Fatalf("expected a valid plan, got %v", errs)
+		}
+
+		call("create_task", map[string]interface{}{"feature_name": "missing-feature", "name": "c", "description": "d", "specification": "s"})
+		call("create_task", map[string]interface{}{"feature_name": "plan-feature", "name": "a", "description": "d", "specification": "s"})
+		call("create_dependency", map[string]interface{}{"feature_name": "plan-feature", "task_name": "b", "depends_on_task_name": "a"})
+		valid, errs := validate()
+		if valid || len(errs) != 3 {
+			t.Fatalf("expected 3 problems, got %v", errs)
+		}
+		for i, want := range []string{"feature missing-feature not found", "task a already exists", "would create a cycle"} {
+			if !strings.Contains(errs[i], want) {
+				t.Errorf("expected problem %d to mention %q, got %q", i, want, errs[i])
+			}
+		}
+
+		if f, _ := database.GetFeatureByName(ctx, "plan-feature"); f != nil {
+			t.Error("expected validation not to commit anything")
+		}
+		if items := database.Staging.Peek(sessionID); len(items.Tasks) != 4 || items.Tasks[0].ID != "" {
+			t.Errorf("expected staged changes to be kept unchanged, got %+v", items.Tasks)
+		}
+		call("discard_staged_changes", map[string]interface{}{})
+	})
+
+	t.Run("staged_change_editing", func(t *testing.T) {
+		sessionID := "editing-session"
+		call := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+			args["session_id"] = sessionID
+			req := mcp.CallToolRequest{}
+			req.Params.Name = name
+			req.Params.Arguments = args
+			result, err := s.GetTool(name).Handler(ctx, req)
+			if err != nil {
+				t.Fatalf("%s handler failed: %v", name, err)
+			}
+			return result
+		}
+
+		call("create_feature", map[string]interface{}{"name": "draft-feature", "description": "d", "specification": "s"})
+		call("create_task", map[string]interface{}{"feature_name": "draft-feature", "name": "keep", "description": "d", "specification": "s"})
+		call("create_task", map[string]interface{}{"feature_name": "draft-feature", "name": "garbage", "description": "d", "specification": "s"})
+		call("create_dependency", map[string]interface{}{"feature_name": "draft-feature", "task_name": "keep", "depends_on_task_name": "garbage"})
+
+		result := call("update_staged_change", map[string]interface{}{"kind": "feature", "name": "draft-feature", "new_name": "final-feature"})
+		if result.IsError {
+			t.Fatalf("update_staged_change failed: %v", result.Content)
+		}
+
+		result = call("update_staged_change", map[string]interface{}{"kind": "task", "feature_name": "final-feature", "name": "keep", "priority": float64(7)})
+		if result.IsError {
+			t.Fatalf("update_staged_change for renamed feature's task failed: %v", result.Content)
+		}
+
+		result = call("remove_staged_change", map[string]interface{}{"kind": "task", "feature_name": "final-feature", "name": "garbage"})
+		if result.IsError {
+			t.Fatalf("remove_staged_change failed: %v", result.Content)
+		}
+
+		result = call("remove_staged_change", map[string]interface{}{"kind": "task", "feature_name": "final-feature", "name": "garbage"})
+		if !result.IsError {
+			t.Error("expected error when removing a task that is no longer staged")
+		}
+
+		call("create_task", map[string]interface{}{"feature_name": "final-feature", "name": "other", "description": "d", "specification": "s"})
+		result = call("update_staged_change", map[string]interface{}{"kind": "task", "feature_name": "final-feature", "name": "other", "new_name": "keep"})
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "already staged") {
+			t.Errorf("expected renaming onto a staged task to fail, got %v", result.Content)
+		}
+		call("remove_staged_change", map[string]interface{}{"kind": "task", "feature_name": "final-feature", "name": "other"})
+
+		items := database.Staging.Peek(sessionID)
+		if len(items.Dependencies) != 0 {
+			t.Errorf("expected dependency on removed task to be dropped, got %d", len(items.Dependencies))
+		}
+
+		result = call("commit_staged_changes", map[string]interface{}{})
+		if result.IsError {
+			t.Fatalf("commit failed: %v", result.Content)
+		}
+
+		f, _ := database.GetFeatureByName(ctx, "final-feature")
+		if f == nil {
+			t.Fatal("expected renamed feature to be committed")
+		}
+		task, _ := database.GetTaskByName(ctx, "keep", f.ID)
+		if task == nil || task.Priority != 7 {
+			t.Fatalf("expected updated task to be committed with priority 7, got %+v", task)
+		}
+		if garbage, _ := database.GetTaskByName(ctx, "garbage", f.ID); garbage != nil {
+			t.Error("expected removed task not to be committed")
+		}
+
+		call("create_feature", map[string]interface{}{"name": "discarded-feature", "description": "d", "specification": "s"})
+		result = call("discard_staged_changes", map[string]interface{}{})
+		if result.IsError {
+			t.Fatalf("discard_staged_changes failed: %v", result.Content)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Discarded 1") {
+			t.Errorf("expected discard count in result, got %q", text)
+		}
+		if items := database.Staging.Peek(sessionID); len(items.Features) != 0 {
+			t.Errorf("expected no staged features after discard, got %d", len(items.Features))
+		}
+	})
+}
+
+func TestListBlockedTasks(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	s := NewServer(database)
+
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := database.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	tasks := map[string]*models.Task{}
+	for _, name := range []string{"api", "ui", "docs", "old", "tests"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := database.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		tasks[name] = task
+	}
+	// ui waits on the blocked api and the cancelled old; tests waits on ui.
+	for _, dep := range [][2]string{{"ui", "api"}, {"ui", "old"}, {"tests", "ui"}} {
+		if err := database.CreateDependency(ctx, tasks[dep[0]].ID, tasks[dep[1]].ID); err != nil {
+			t.Fatalf("Failed to create dependency: %v", err)
+		}
+	}
+	if err := database.BlockTask(ctx, tasks["api"].ID, "needs credentials"); err != nil {
+		t.Fatalf("Failed to block task: %v", err)
+	}
+	if err := database.BlockTask(ctx, tasks["ui"].ID, "waiting on api"); err != nil {
+		t.Fatalf("Failed to block task: %v", err)
+	}
+	if err := database.UpdateTaskStatus(ctx, tasks["old"].ID, models.TaskStatusCancelled, nil); err != nil {
+		t.Fatalf("Failed to cancel task: %v", err)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "list_blocked_tasks"
+	req.Params.Arguments = map[string]interface{}{"feature_name": "f"}
+	result, err := s.GetTool("list_blocked_tasks").Handler(ctx, req)
+	if err != nil || result.IsError {
+		t.Fatalf("Handler failed: %v, %v", err, result.Content)
+	}
+
+	var resp struct {
+		Tasks []blockedTask `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal blocked tasks: %v", err)
+	}
+	byName := map[string]blockedTask{}
+	for _, bt := range resp.Tasks {
+		byName[bt.Name] = bt
+	}
+	if len(resp.Tasks) != 2 {
+		t.Fatalf("Expected api and ui to be listed, got %+v", resp.Tasks)
+	}
+
+	api := byName["api"]
+	if api.BlockedReason != "needs credentials" || len(api.BlockingDependencies) != 0 || len(api.Waiting) != 1 || api.Waiting[0].Name != "ui" {
+		t.Errorf("Unexpected api entry: %+v", api)
+	}
+	if !strings.Contains(api.Suggestions[0], "No dependency holds it back") {
+		t.Errorf("Expected api to be suggested for unblocking, got %q", api.Suggestions)
+	}
+
+	ui := byName["ui"]
+	if len(ui.BlockingDependencies) != 2 || len(ui.Waiting) != 1 || ui.Waiting[0].Name != "tests" {
+		t.Fatalf("Unexpected ui entry: %+v", ui)
+	}
+	suggestions := strings.Join(ui.Suggestions, "\n")
+	for _, want := range []string{"Unblock the dependency f/api first; it is blocked because: needs credentials", "f/old is cancelled", "1 waiting task depends on it"} {
+		if !strings.Contains(suggestions, want) {
+			t.Errorf("Expected %q in ui's suggestions:\n%s", want, suggestions)
+		}
+	}
+
+	req.Params.Arguments = map[string]interface{}{"feature_name": "other"}
+	result, err = s.GetTool("list_blocked_tasks").Handler(ctx, req)
+	if err != nil || result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, `"tasks":[]`) {
+		t.Errorf("Expected no blocked tasks in another feature, got %v", result.Content)
+	}
 }
@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingMiddleware spans each tool call. Calls without a span of their own
+// join the trace named by TRACEPARENT, which the orchestrator sets for the
+// agent whose ponder mcp process serves them.
+func tracingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !telemetry.Enabled() {
+			return next(ctx, request)
+		}
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = telemetry.FromEnv(ctx)
+		}
+		ctx, span := telemetry.Start(ctx, "mcp."+request.Params.Name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("mcp.tool.name", request.Params.Name)),
+		)
+		result, err := next(ctx, request)
+		// Tools report most failures in the result rather than as errors.
+		if err == nil && result != nil && result.IsError {
+			span.SetStatus(codes.Error, toolErrorText(result))
+		}
+		telemetry.End(span, err)
+		return result, err
+	}
+}
+
+func toolErrorText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return "tool call failed"
+}
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestToolCallsAreTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	telemetry.Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	s := NewServer(database)
+	raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call",
+		"params": map[string]any{"name": "get_feature", "arguments": map[string]any{"name": "missing"}}})
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	s.HandleMessage(ctx, raw)
+
+	var tool, query sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "mcp.get_feature":
+			tool = span
+		case "db.GetFeatureByName":
+			query = span
+		}
+	}
+	if tool == nil || query == nil {
+		t.Fatalf("want mcp.get_feature and db.GetFeatureByName spans, got tool=%v query=%v", tool != nil, query != nil)
+	}
+	if tool.Status().Code != codes.Error {
+		t.Errorf("tool span status = %+v, want error for a missing feature", tool.Status())
+	}
+	if query.Parent().SpanID() != tool.SpanContext().SpanID() {
+		t.Error("query span is not a child of the tool span")
+	}
+}
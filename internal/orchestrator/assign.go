@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// AssignTask has the next free worker run task rather than the available
+// task of highest priority. Tasks assigned one after another are run in
+// that order. A task that is no longer available when a worker frees up is
+// dropped, and the worker takes the next available task as usual.
+func (o *Orchestrator) AssignTask(task *models.Task) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	for _, t := range o.assigned {
+		if t.ID == task.ID {
+			return
+		}
+	}
+	o.assigned = append(o.assigned, task)
+}
+
+// AssignedTasks returns the tasks waiting for a free worker, in order.
+func (o *Orchestrator) AssignedTasks() []*models.Task {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return append([]*models.Task(nil), o.assigned...)
+}
+
+// nextAssigned removes and returns the first assigned task, or nil.
+func (o *Orchestrator) nextAssigned() *models.Task {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	if len(o.assigned) == 0 {
+		return nil
+	}
+	task := o.assigned[0]
+	o.assigned = o.assigned[1:]
+	return task
+}
+
+// claimTask claims a task for workerID: the first assigned task that is
+// still available, otherwise the next available task. assigned reports
+// which it was.
+func (o *Orchestrator) claimTask(ctx context.Context, workerID int) (task *models.Task, assigned bool, err error) {
+	for next := o.nextAssigned(); next != nil; next = o.nextAssigned() {
+		task, err := o.store.ClaimTask(ctx, next.ID, o.claimer(workerID), o.GetClaimLease())
+		if err != nil {
+			return nil, false, err
+		}
+		if task != nil {
+			return task, true, nil
+		}
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Task %s is no longer available; not running it", next.Name),
+		})
+	}
+	task, err = o.store.ClaimNextTask(ctx, o.claimer(workerID), o.GetClaimLease())
+	return task, false, err
+}
@@ -0,0 +1,129 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestClaimTaskPrefersAssigned(t *testing.T) {
+	store := newMockTaskStore()
+	first := store.addTask("1", "first", 9)
+	second := store.addTask("2", "second", 1)
+	done := store.addTask("3", "done", 1)
+	done.Status = models.TaskStatusCompleted
+
+	o := NewOrchestrator(store, 2, "test-model")
+	o.AssignTask(done)
+	o.AssignTask(second)
+	o.AssignTask(second)
+	if got := o.AssignedTasks(); len(got) != 2 {
+		t.Fatalf("expected a task assigned twice to be queued once, got %d", len(got))
+	}
+
+	ctx := context.Background()
+	task, assigned, err := o.claimTask(ctx, 1)
+	if err != nil || task != second || !assigned {
+		t.Fatalf("expected the assigned second task, got %v (assigned %v, err %v)", task, assigned, err)
+	}
+	if len(o.AssignedTasks()) != 0 {
+		t.Errorf("expected the unavailable assignment to be dropped")
+	}
+
+	task, assigned, err = o.claimTask(ctx, 2)
+	if err != nil || task != first || assigned {
+		t.Fatalf("expected the first task in priority order, got %v (assigned %v, err %v)", task, assigned, err)
+	}
+}
+
+func TestOrchestratorModel_TaskPicker(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "first", 9).FeatureName = "core"
+	store.addTask("2", "second", 1).FeatureName = "core"
+	orch := NewOrchestrator(store, 1, "test-model")
+	m := NewOrchestratorModel(orch)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if !m.showTaskPicker || cmd == nil {
+		t.Fatal("expected t to open the task picker and load the tasks")
+	}
+	m.Update(cmd())
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Run Task Next", "→ first (core, P9)", "second (core, P1)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	// Keys go to the picker while it is open.
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if orch.IsPaused() {
+		t.Error("expected p not to pause while the picker is open")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.showTaskPicker {
+		t.Error("expected enter to close the picker")
+	}
+	if got := orch.AssignedTasks(); len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("expected second to be assigned, got %v", got)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showTaskPicker {
+		t.Error("expected esc to close the picker")
+	}
+}
+
+func TestStopAndRestartWorker(t *testing.T) {
+	for _, restart := range []bool{false, true} {
+		store := newMockTaskStore()
+		task := store.addTask("1", "task1", 1)
+
+		o := NewOrchestrator(store, 1, "test-model")
+		o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "sleep", "10")
+		}
+		if o.StopWorker(1) {
+			t.Fatal("expected no worker to stop before one runs")
+		}
+
+		claimed, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+		o.workersMu.Lock()
+		o.spawnWorkerLocked(context.Background(), claimed, 1)
+		worker := o.workers[1]
+		o.workersMu.Unlock()
+
+		stopped := o.StopWorker(1)
+		if restart {
+			stopped = o.RestartWorker(1)
+		}
+		if !stopped {
+			t.Fatal("expected the running worker to stop")
+		}
+		select {
+		case <-worker.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the worker to stop")
+		}
+
+		if task.Status != models.TaskStatusPending {
+			t.Errorf("expected the task back to pending, got %s", task.Status)
+		}
+		if o.isTaskInBackoff(task.ID) {
+			t.Error("expected a stopped run not to count as a failure")
+		}
+		if got := o.AssignedTasks(); restart != (len(got) == 1) {
+			t.Errorf("expected the task to be assigned again only on restart (restart %v), got %v", restart, got)
+		}
+	}
+}
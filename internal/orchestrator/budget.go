@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"fmt"
+)
+
+// BudgetExceededError is returned by Start when it stopped because the
+// session spent more than its budget. Workers that were running when the
+// budget ran out are left to finish first.
+type BudgetExceededError struct {
+	Budget float64
+	Spent  Usage
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget of $%.2f exceeded: spent %s this session", e.Budget, formatCost(e.Spent))
+}
+
+// GetBudget returns the most the session may spend, in USD, before no new
+// tasks are claimed. Zero means no limit.
+func (o *Orchestrator) GetBudget() float64 {
+	o.usageMu.Lock()
+	defer o.usageMu.Unlock()
+	return o.budget
+}
+
+// SetBudget limits what the session may spend, in USD, on agent runs. Once
+// the reported or estimated cost of its runs exceeds the budget, no new
+// tasks are claimed and Start returns a *BudgetExceededError when the
+// running workers are done. Zero means no limit.
+func (o *Orchestrator) SetBudget(budget float64) {
+	o.usageMu.Lock()
+	defer o.usageMu.Unlock()
+	o.budget = budget
+}
+
+// OverBudget reports whether the session has spent more than its budget.
+func (o *Orchestrator) OverBudget() bool {
+	o.usageMu.Lock()
+	defer o.usageMu.Unlock()
+	return o.budget > 0 && o.usage.CostUSD > o.budget
+}
+
+// checkBudget reports whether the session is over budget, announcing it in
+// the status log the first time.
+func (o *Orchestrator) checkBudget() bool {
+	o.usageMu.Lock()
+	over := o.budget > 0 && o.usage.CostUSD > o.budget
+	announce := over && !o.budgetReported
+	o.budgetReported = o.budgetReported || over
+	budget, spent := o.budget, o.usage
+	o.usageMu.Unlock()
+
+	if announce {
+		o.sendMsg(StatusMsg{Message: fmt.Sprintf(
+			"Budget of $%.2f exceeded (spent %s): no new tasks will be claimed; running workers will finish",
+			budget, formatCost(spent))})
+	}
+	return over
+}
+
+func (o *Orchestrator) budgetError() error {
+	o.usageMu.Lock()
+	defer o.usageMu.Unlock()
+	return &BudgetExceededError{Budget: o.budget, Spent: o.usage}
+}
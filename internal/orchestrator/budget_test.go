@@ -0,0 +1,89 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestBudgetStopsClaimingTasks(t *testing.T) {
+	store, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	f := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := store.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	for _, name := range []string{"first", "second"} {
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := store.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.minSpawnInterval = 0
+	o.SetBudget(1)
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		for _, w := range o.GetActiveWorkers() {
+			summary := "done"
+			_ = store.UpdateTaskStatus(ctx, w.task.ID, models.TaskStatusCompleted, &summary)
+		}
+		return exec.CommandContext(ctx, "echo", "Cost: $1.50")
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err = RunHeadless(runCtx, o, &buf, LogFormatText)
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected a BudgetExceededError, got %v", err)
+	}
+	if budgetErr.Budget != 1 || budgetErr.Spent.CostUSD != 1.5 {
+		t.Errorf("unexpected error: %+v", budgetErr)
+	}
+
+	pending := models.TaskStatusPending
+	tasks, err := store.ListTasks(ctx, &pending, nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Errorf("expected one task left unclaimed, got %d", len(tasks))
+	}
+
+	log := buf.String()
+	for _, want := range []string{"Budget of $1.00 exceeded (spent $1.50)", "stopped: budget of $1.00 exceeded"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log missing %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestNoBudgetMeansNoLimit(t *testing.T) {
+	o := NewOrchestrator(newMockTaskStore(), 1, "test-model")
+	o.usage.add(Usage{CostUSD: 1000})
+	if o.OverBudget() {
+		t.Error("expected no budget to mean no limit")
+	}
+	o.SetBudget(1000)
+	if o.OverBudget() {
+		t.Error("expected spending exactly the budget to be allowed")
+	}
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// DefaultClaimLease is how long a claim stays valid without being renewed.
+// Workers renew their claim three times per lease, so a claim only expires
+// once the process holding it has stopped.
+const DefaultClaimLease = 5 * time.Minute
+
+// claimer identifies a worker of this process to the store.
+func (o *Orchestrator) claimer(workerID int) models.Claimer {
+	return models.Claimer{Hostname: o.hostname, PID: o.pid, WorkerID: workerID}
+}
+
+// processIdentity returns the hostname and pid recorded with claims.
+func processIdentity() (string, int) {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return host, os.Getpid()
+}
+
+// keepClaim renews the worker's claim until ctx is done. Losing the claim is
+// reported but doesn't stop the run: the task will simply be claimed again
+// once it is pending.
+func (o *Orchestrator) keepClaim(ctx context.Context, worker *workerInstance) {
+	lease := o.GetClaimLease()
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	claimer := o.claimer(worker.id)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewCtx, cancel := context.WithTimeout(actor.With(ctx, fmt.Sprintf("orchestrator:worker-%d", worker.id)), 5*time.Second)
+			err := o.store.RenewClaim(renewCtx, worker.task.ID, claimer, lease)
+			cancel()
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+			o.sendMsg(StatusMsg{
+				WorkerID: worker.id,
+				Message:  fmt.Sprintf("Failed to renew claim on %s: %v", worker.task.Name, err),
+			})
+			if errors.Is(err, models.ErrClaimLost) {
+				return
+			}
+		}
+	}
+}
+
+// GetClaimLease returns how long claims last without being renewed.
+func (o *Orchestrator) GetClaimLease() time.Duration {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.claimLease
+}
+
+// SetClaimLease sets how long claims last without being renewed. Values
+// below one second are raised to one second.
+func (o *Orchestrator) SetClaimLease(d time.Duration) {
+	if d < time.Second {
+		d = time.Second
+	}
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.claimLease = d
+}
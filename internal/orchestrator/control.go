@@ -0,0 +1,111 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// GetMaxWorkers returns the most workers the orchestrator can run at once.
+func (o *Orchestrator) GetMaxWorkers() int {
+	o.targetWorkersMu.RLock()
+	defer o.targetWorkersMu.RUnlock()
+	return o.maxWorkers
+}
+
+// SetMaxWorkers changes the most workers the orchestrator can run at once.
+// A target at the old maximum follows it to the new one, and a target above
+// the new maximum is lowered to it; running workers over the limit finish
+// their tasks.
+func (o *Orchestrator) SetMaxWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	o.targetWorkersMu.Lock()
+	defer o.targetWorkersMu.Unlock()
+	if o.targetWorkers == o.maxWorkers || o.targetWorkers > n {
+		o.targetWorkers = n
+	}
+	o.maxWorkers = n
+}
+
+// Workers returns the running workers, ordered by ID.
+func (o *Orchestrator) Workers() []models.ActiveWorker {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+
+	workers := make([]models.ActiveWorker, 0, len(o.workers))
+	for _, w := range o.workers {
+		aw := models.ActiveWorker{ID: w.id, Model: w.model, StartedAt: w.startedAt.UTC(), Stalled: w.stalled}
+		if w.task != nil {
+			aw.TaskID, aw.TaskName, aw.FeatureName = w.task.ID, w.task.Name, w.task.FeatureName
+		}
+		if last := w.lastOutputAt().UTC(); !last.IsZero() {
+			aw.LastOutputAt = &last
+		}
+		workers = append(workers, aw)
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ID < workers[j].ID })
+	return workers
+}
+
+// StopWorker cancels the agent of worker id and puts its task back to
+// pending, without counting it as a failed run. It reports whether the
+// worker was running a task.
+func (o *Orchestrator) StopWorker(id int) bool {
+	return o.stopWorker(id, false)
+}
+
+// RestartWorker cancels the agent of worker id like StopWorker, then has
+// the next free worker run the same task again. It reports whether the
+// worker was running a task.
+func (o *Orchestrator) RestartWorker(id int) bool {
+	return o.stopWorker(id, true)
+}
+
+func (o *Orchestrator) stopWorker(id int, restart bool) bool {
+	o.workersMu.Lock()
+	w, ok := o.workers[id]
+	if ok {
+		w.restart = restart
+	}
+	o.workersMu.Unlock()
+	if !ok || w.task == nil {
+		return false
+	}
+
+	message := fmt.Sprintf("Stopping %s; it goes back to pending", w.task.Name)
+	if restart {
+		message = fmt.Sprintf("Restarting %s", w.task.Name)
+	}
+	o.sendMsg(StatusMsg{WorkerID: id, Message: message})
+	w.cancel()
+	return true
+}
+
+// reportTargetWorkers announces a change of the number of workers to run. It
+// runs on the main loop, like reportPaused.
+func (o *Orchestrator) reportTargetWorkers() {
+	o.targetWorkersMu.Lock()
+	target, changed := o.targetWorkers, o.targetWorkers != o.reportedTarget
+	o.reportedTarget = o.targetWorkers
+	o.targetWorkersMu.Unlock()
+
+	if changed {
+		o.sendMsg(TargetWorkersMsg{Target: target})
+	}
+}
+
+// SubscribeEvents writes the orchestrator's events to w as JSON lines, in the
+// -no-tui log format, until the returned function is called. w must not
+// block; see History.Subscribe.
+func (o *Orchestrator) SubscribeEvents(w io.Writer) (unsubscribe func()) {
+	h := o.History()
+	if h == nil {
+		return func() {}
+	}
+	return h.Subscribe(w)
+}
@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PlannedTask is one claim of a dry run: a task, the model it would be run
+// with and the prompt its agent would be sent.
+type PlannedTask struct {
+	Order    int    `json:"order"`
+	TaskID   string `json:"task_id"`
+	Feature  string `json:"feature"`
+	Task     string `json:"task"`
+	Priority int    `json:"priority"`
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+}
+
+// Plan returns the claims the orchestrator would make, in order, assuming
+// every task completes before the next one is claimed. Nothing is claimed.
+// Prompts are built from the current state of the store, so they only list
+// the dependencies that are already completed, not those planned before them.
+func (o *Orchestrator) Plan(ctx context.Context) ([]PlannedTask, error) {
+	tasks, err := o.store.PlanClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := make([]PlannedTask, 0, len(tasks))
+	for i, task := range tasks {
+		plan = append(plan, PlannedTask{
+			Order:    i + 1,
+			TaskID:   task.ID,
+			Feature:  task.FeatureName,
+			Task:     task.Name,
+			Priority: task.Priority,
+			Model:    o.modelFor(task),
+			Prompt:   o.constructPrompt(ctx, task),
+		})
+	}
+	return plan, nil
+}
+
+// WritePlan writes a dry run plan to w, as text or as one JSON object per
+// planned task.
+func WritePlan(w io.Writer, plan []PlannedTask, format LogFormat) error {
+	if format == LogFormatJSON {
+		enc := json.NewEncoder(w)
+		for _, p := range plan {
+			if err := enc.Encode(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(plan) == 0 {
+		_, err := fmt.Fprintln(w, "Dry run: no tasks available to claim")
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Dry run: %d tasks would be claimed in this order\n", len(plan)); err != nil {
+		return err
+	}
+	for _, p := range plan {
+		_, err := fmt.Fprintf(w, "\n%s\n%d. %s/%s (priority %d) with %s\n%s\n\n%s\n",
+			strings.Repeat("=", 72), p.Order, p.Feature, p.Task, p.Priority, p.Model,
+			strings.Repeat("-", 72), strings.TrimRight(p.Prompt, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestPlan(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "critical", 9)
+	store.addTask("2", "routine", 2)
+
+	o := NewOrchestrator(store, 2, "default-model")
+	routing, err := NewModelRouting([]ModelRoute{{MinPriority: 8, Model: "big-model"}})
+	if err != nil {
+		t.Fatalf("NewModelRouting failed: %v", err)
+	}
+	o.SetModelRouting(routing)
+
+	plan, err := o.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan) != 2 {
+		t.Fatalf("expected 2 planned tasks, got %d", len(plan))
+	}
+	if plan[0].Order != 1 || plan[0].Task != "critical" || plan[0].Model != "big-model" {
+		t.Errorf("unexpected first claim: %+v", plan[0])
+	}
+	if plan[1].Order != 2 || plan[1].Task != "routine" || plan[1].Model != "default-model" {
+		t.Errorf("unexpected second claim: %+v", plan[1])
+	}
+	if !strings.Contains(plan[0].Prompt, "# Task: critical") {
+		t.Errorf("expected the prompt of the task, got %q", plan[0].Prompt)
+	}
+
+	for _, task := range store.tasks {
+		if task.Status != models.TaskStatusPending {
+			t.Errorf("expected %s to stay pending, got %s", task.Name, task.Status)
+		}
+	}
+	if len(store.statusUpdates) != 0 {
+		t.Errorf("expected no status updates, got %v", store.statusUpdates)
+	}
+}
+
+func TestWritePlan(t *testing.T) {
+	plan := []PlannedTask{
+		{Order: 1, TaskID: "1", Feature: "api", Task: "auth", Priority: 9, Model: "big-model", Prompt: "Do auth\n"},
+	}
+
+	var text bytes.Buffer
+	if err := WritePlan(&text, plan, LogFormatText); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+	for _, want := range []string{"1 tasks would be claimed", "1. api/auth (priority 9) with big-model", "Do auth"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("expected %q in text plan:\n%s", want, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := WritePlan(&js, plan, LogFormatJSON); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+	var got PlannedTask
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatalf("expected one JSON object, got %q: %v", js.String(), err)
+	}
+	if got != plan[0] {
+		t.Errorf("expected %+v, got %+v", plan[0], got)
+	}
+
+	var empty bytes.Buffer
+	if err := WritePlan(&empty, nil, LogFormatText); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+	if !strings.Contains(empty.String(), "no tasks available") {
+		t.Errorf("unexpected empty plan: %q", empty.String())
+	}
+}
@@ -0,0 +1,57 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestRunEnvironmentAppliesToAgent(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+	store.addTask("2", "task2", 1)
+
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "services", "api")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("failed to create working directory: %v", err)
+	}
+	out := filepath.Join(dir, "out")
+	store.environments = map[string]*models.RunEnvironment{
+		"1": {WorkingDir: sub, Env: map[string]string{"SERVICE": "api"}},
+		"2": {WorkingDir: filepath.Join(dir, "missing")},
+	}
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", `echo "$(pwd) $SERVICE" > `+out)
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("agent did not run: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(data)), sub+" api"; !strings.HasSuffix(got, want) {
+		t.Errorf("expected agent to run in %s with SERVICE=api, got %q", sub, got)
+	}
+
+	// A missing working directory fails the run rather than running the
+	// agent somewhere else.
+	os.Remove(out)
+	task, _ = store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+	if _, err := os.Stat(out); err == nil {
+		t.Error("expected the agent not to run without its working directory")
+	}
+	if task.Status != models.TaskStatusPending {
+		t.Errorf("expected task2 to be reset to pending, got %s", task.Status)
+	}
+}
@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// AgentUnavailableError is returned by Start when it stopped because the
+// agent command could not be run at all, e.g. opencode is not installed.
+// Retrying each task would only fail them all.
+type AgentUnavailableError struct {
+	Err error
+}
+
+func (e *AgentUnavailableError) Error() string {
+	return fmt.Sprintf("agent could not be started: %v", e.Err)
+}
+
+func (e *AgentUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Exit statuses shells and container runtimes use for a command that was
+// not found, and for one killed by SIGKILL.
+const (
+	exitCommandNotFound = 127
+	exitKilled          = 128 + 9
+)
+
+// classifyFailure says how a run that returned err failed, given the
+// worker's context. It is empty when err is nil.
+func classifyFailure(ctx context.Context, err error) models.FailureClass {
+	if err == nil {
+		return ""
+	}
+	if ctx.Err() != nil {
+		return models.FailureCancelled
+	}
+
+	var terr *TaskTimeoutError
+	if errors.As(err, &terr) {
+		return models.FailureTimeout
+	}
+	var serr *StallError
+	if errors.As(err, &serr) {
+		return models.FailureStalled
+	}
+	var verr *VerificationError
+	if errors.As(err, &verr) {
+		return models.FailureVerification
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return models.FailureAgentMissing
+	}
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return models.FailureAgentMissing
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return models.FailureOther
+	}
+	// Wrappers (nice, systemd-run, docker) report a missing agent with 127.
+	if exitErr.ExitCode() == exitCommandNotFound {
+		return models.FailureAgentMissing
+	}
+	// Nothing of ours killed it, as the context is live: with a memory limit
+	// set, SIGKILL comes from the OOM killer. Containers report it as 137.
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == syscall.SIGKILL {
+		return models.FailureOOM
+	}
+	if exitErr.ExitCode() == exitKilled {
+		return models.FailureOOM
+	}
+	return models.FailureExit
+}
+
+// stopWith makes Start stop all workers and return err, unless it is
+// already stopping for another reason.
+func (o *Orchestrator) stopWith(err error) {
+	o.stopMu.Lock()
+	defer o.stopMu.Unlock()
+	if o.stopErr == nil {
+		o.stopErr = err
+	}
+}
+
+func (o *Orchestrator) stopError() error {
+	o.stopMu.Lock()
+	defer o.stopMu.Unlock()
+	return o.stopErr
+}
+
+// handleClassifiedFailure deals with failures the retry policy doesn't
+// apply to, and reports whether it did. A missing agent stops the
+// orchestrator, a cancelled run goes back to pending without counting as a
+// failure, and a run killed for its memory blocks the task at once.
+func (o *Orchestrator) handleClassifiedFailure(workerID int, task *models.Task, class models.FailureClass, runErr error) bool {
+	switch class {
+	case models.FailureAgentMissing:
+		o.resetTask(workerID, task)
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Agent could not be started (%v); stopping", runErr),
+		})
+		o.stopWith(&AgentUnavailableError{Err: runErr})
+	case models.FailureCancelled:
+		o.resetTask(workerID, task)
+	case models.FailureOOM:
+		o.blockTask(workerID, task,
+			fmt.Sprintf("Blocked by orchestrator: the agent was killed, most likely for running out of memory. Last error: %v", runErr),
+			fmt.Sprintf("Task %s blocked: the agent ran out of memory", task.Name))
+	default:
+		return false
+	}
+	return true
+}
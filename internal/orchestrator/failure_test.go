@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	ctx := context.Background()
+	run := func(name string, args ...string) error {
+		return exec.Command(name, args...).Run()
+	}
+	exitErr := run("sh", "-c", "exit 3")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want models.FailureClass
+	}{
+		{"success", ctx, nil, ""},
+		{"non-zero exit", ctx, exitErr, models.FailureExit},
+		{"binary missing", ctx, run("ponder-no-such-agent"), models.FailureAgentMissing},
+		{"binary missing behind a wrapper", ctx, run("sh", "-c", "exit 127"), models.FailureAgentMissing},
+		{"killed", ctx, run("sh", "-c", "kill -9 $$"), models.FailureOOM},
+		{"killed in a container", ctx, run("sh", "-c", "exit 137"), models.FailureOOM},
+		{"timeout", ctx, &TaskTimeoutError{Limit: time.Minute}, models.FailureTimeout},
+		{"stalled", ctx, &StallError{Window: time.Minute}, models.FailureStalled},
+		{"verification", ctx, &VerificationError{Command: "go test", Err: exitErr}, models.FailureVerification},
+		{"cancelled", cancelled, run("sh", "-c", "kill -9 $$"), models.FailureCancelled},
+		{"other", ctx, errors.New("worktree exists"), models.FailureOther},
+	}
+	for _, c := range cases {
+		if got := classifyFailure(c.ctx, c.err); got != c.want {
+			t.Errorf("%s: expected %q, got %q (%v)", c.name, c.want, got, c.err)
+		}
+	}
+}
+
+func TestOrchestrator_StopsWhenAgentMissing(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "task1", 1)
+	store.addTask("2", "task2", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.minSpawnInterval = 0
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "ponder-no-such-agent")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := o.Start(ctx)
+	var unavailable *AgentUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("expected an AgentUnavailableError, got %v", err)
+	}
+	if task.Status != models.TaskStatusPending {
+		t.Errorf("expected the task to be reset to pending, got %s", task.Status)
+	}
+	if o.isTaskInBackoff(task.ID) {
+		t.Error("expected a missing agent not to count as a task failure")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.runs) != 1 || store.runs[0].FailureClass != models.FailureAgentMissing {
+		t.Errorf("expected one run recorded as agent_missing, got %+v", store.runs)
+	}
+}
+
+func TestOrchestrator_BlocksTaskKilledForMemory(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.minSpawnInterval = 0
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "kill -9 $$")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := o.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if task.Status != models.TaskStatusBlocked {
+		t.Fatalf("expected the task to be blocked on the first OOM, got %s", task.Status)
+	}
+	if task.BlockedReason == nil || !strings.Contains(*task.BlockedReason, "out of memory") {
+		t.Errorf("unexpected blocked reason %v", task.BlockedReason)
+	}
+}
@@ -0,0 +1,93 @@
+package orchestrator
+
+import "fmt"
+
+// DefaultFallbackFailures is how many times a task may fail with one model
+// before the fallback chain moves it on to the next.
+const DefaultFallbackFailures = 2
+
+// ModelFallback treats the available models as a fallback chain: a task that
+// fails AfterFailures times with one model is retried with the next model in
+// the list, and the retry policy only blocks it once the chain runs out.
+type ModelFallback struct {
+	// AfterFailures is the number of failed runs with one model before the
+	// next is tried. Zero disables the chain.
+	AfterFailures int
+}
+
+// Enabled reports whether failed tasks move along the chain.
+func (f ModelFallback) Enabled() bool {
+	return f.AfterFailures > 0
+}
+
+// Validate reports whether the fallback values are usable.
+func (f ModelFallback) Validate() error {
+	if f.AfterFailures < 0 {
+		return fmt.Errorf("after_failures must be >= 0")
+	}
+	return nil
+}
+
+// nextModel returns the model after current in chain, or "" when current is
+// the last one. A model outside the chain, such as one picked by priority
+// routing, falls back to the start of the chain.
+func nextModel(chain []string, current string) string {
+	for i, model := range chain {
+		if model == current {
+			if i+1 < len(chain) {
+				return chain[i+1]
+			}
+			return ""
+		}
+	}
+	if len(chain) > 0 {
+		return chain[0]
+	}
+	return ""
+}
+
+// GetModelFallback returns the configured fallback chain settings.
+func (o *Orchestrator) GetModelFallback() ModelFallback {
+	o.modelMu.RLock()
+	defer o.modelMu.RUnlock()
+	return o.modelFallback
+}
+
+// SetModelFallback sets how failed tasks move along the available models.
+func (o *Orchestrator) SetModelFallback(f ModelFallback) {
+	o.modelMu.Lock()
+	defer o.modelMu.Unlock()
+	o.modelFallback = f
+}
+
+// fallbackFor returns the model a task that failed failures times in a row
+// with model should be retried with, or "" to keep retrying as usual.
+func (o *Orchestrator) fallbackFor(model string, failures int) string {
+	f := o.GetModelFallback()
+	if !f.Enabled() || failures < f.AfterFailures {
+		return ""
+	}
+	return nextModel(o.GetAvailableModels(), model)
+}
+
+// fallbackModel returns the model the fallback chain has moved a task on
+// to, or "" if it hasn't.
+func (o *Orchestrator) fallbackModel(taskID string) string {
+	o.failedTasksMu.RLock()
+	defer o.failedTasksMu.RUnlock()
+	if info, ok := o.failedTasks[taskID]; ok {
+		return info.fallbackModel
+	}
+	return ""
+}
+
+// setFallbackModel moves a failed task on to model and starts counting its
+// failures with that model afresh.
+func (o *Orchestrator) setFallbackModel(taskID, model string) {
+	o.failedTasksMu.Lock()
+	defer o.failedTasksMu.Unlock()
+	if info, ok := o.failedTasks[taskID]; ok {
+		info.fallbackModel = model
+		info.modelFailures = 0
+	}
+}
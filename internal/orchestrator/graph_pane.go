@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+var graphReadyStyle, graphRunningStyle, graphWaitingStyle, graphBlockedStyle lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		graphReadyStyle = lipgloss.NewStyle().Foreground(t.Success)
+		graphRunningStyle = lipgloss.NewStyle().Foreground(t.Running)
+		graphWaitingStyle = lipgloss.NewStyle().Foreground(t.Muted)
+		graphBlockedStyle = lipgloss.NewStyle().Foreground(t.Failure)
+	})
+}
+
+// graphLoadTimeout bounds how long the dependency graph pane waits for the
+// task store.
+const graphLoadTimeout = 5 * time.Second
+
+// graphLoadedMsg carries what the dependency graph pane shows.
+type graphLoadedMsg struct {
+	tasks []*models.Task
+	deps  []*models.Dependency
+	err   error
+}
+
+// loadGraph reads the tasks and dependencies for the dependency graph pane.
+func (m *OrchestratorModel) loadGraph() tea.Cmd {
+	store := m.orchestrator.store
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), graphLoadTimeout)
+		defer cancel()
+		tasks, err := store.ListTasks(ctx, nil, nil)
+		if err != nil {
+			return graphLoadedMsg{err: err}
+		}
+		deps, err := store.ListDependencies(ctx)
+		return graphLoadedMsg{tasks: tasks, deps: deps, err: err}
+	}
+}
+
+// toggleGraph shows or hides the dependency graph pane, loading the graph
+// when it is shown.
+func (m *OrchestratorModel) toggleGraph() tea.Cmd {
+	m.showGraph = !m.showGraph
+	m.graphOffset = 0
+	if m.showGraph {
+		return m.loadGraph()
+	}
+	return nil
+}
+
+// scrollGraph moves the dependency graph pane by delta lines.
+func (m *OrchestratorModel) scrollGraph(delta int) {
+	m.graphOffset += delta
+	if maxOffset := len(m.graphLines) - 1; m.graphOffset > maxOffset {
+		m.graphOffset = maxOffset
+	}
+	if m.graphOffset < 0 {
+		m.graphOffset = 0
+	}
+}
+
+// renderGraph renders the dependency graph pane in a width by height area.
+func (m *OrchestratorModel) renderGraph(width, height int) string {
+	lines := []string{headerTextStyle.Render("Dependency Graph")}
+	switch {
+	case m.graphErr != nil:
+		lines = append(lines, statusFailedStyle.Render(fmt.Sprintf("Failed to load tasks: %v", m.graphErr)))
+	case m.graphLines == nil:
+		lines = append(lines, "Loading...")
+	default:
+		start := min(m.graphOffset, len(m.graphLines))
+		lines = append(lines, m.graphLines[start:]...)
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dependencyTree renders the open tasks as a tree: tasks that wait on
+// nothing open come first, with the tasks that depend on them below. A task
+// that depends on several open tasks is drawn under each of them, but its
+// own dependents only the first time. The first line sums up why pending
+// tasks aren't being worked on.
+func dependencyTree(tasks []*models.Task, deps []*models.Dependency, now time.Time) []string {
+	open := make(map[string]*models.Task)
+	for _, t := range tasks {
+		if t.Status != models.TaskStatusCompleted && t.Status != models.TaskStatusCancelled {
+			open[t.ID] = t
+		}
+	}
+	if len(open) == 0 {
+		return []string{"No open tasks"}
+	}
+
+	waitingOn := make(map[string]int)
+	dependents := make(map[string][]string)
+	for _, d := range deps {
+		if open[d.TaskID] == nil || open[d.DependsOnTaskID] == nil {
+			continue
+		}
+		waitingOn[d.TaskID]++
+		dependents[d.DependsOnTaskID] = append(dependents[d.DependsOnTaskID], d.TaskID)
+	}
+
+	var ready, running, waiting, blocked, scheduled int
+	for _, t := range open {
+		switch {
+		case t.Status == models.TaskStatusInProgress || t.Status == models.TaskStatusInReview:
+			running++
+		case t.Status == models.TaskStatusBlocked:
+			blocked++
+		case waitingOn[t.ID] > 0:
+			waiting++
+		case t.NotBefore != nil && t.NotBefore.After(now):
+			scheduled++
+		default:
+			ready++
+		}
+	}
+	summary := fmt.Sprintf("%d open: %d ready, %d running, %d waiting, %d blocked", len(open), ready, running, waiting, blocked)
+	if scheduled > 0 {
+		summary += fmt.Sprintf(", %d scheduled", scheduled)
+	}
+	lines := []string{summary, ""}
+
+	drawn := make(map[string]bool)
+	var draw func(id, indent, branch string)
+	draw = func(id, indent, branch string) {
+		t := open[id]
+		marker, note, style := graphMarker(t, waitingOn[id], now)
+		line := fmt.Sprintf("%s%s%s %s/%s %s", indent, branch, style.Render(marker), t.FeatureName, t.Name, note)
+		if drawn[id] {
+			lines = append(lines, line+" ↑")
+			return
+		}
+		drawn[id] = true
+		lines = append(lines, line)
+
+		switch branch {
+		case "├─ ":
+			indent += "│  "
+		case "└─ ":
+			indent += "   "
+		}
+		children := dependents[id]
+		for i, child := range children {
+			if i == len(children)-1 {
+				draw(child, indent, "└─ ")
+			} else {
+				draw(child, indent, "├─ ")
+			}
+		}
+	}
+	for _, t := range tasks {
+		if open[t.ID] != nil && waitingOn[t.ID] == 0 {
+			draw(t.ID, "", "")
+		}
+	}
+	// Tasks on a dependency cycle have no root to hang from.
+	for _, t := range tasks {
+		if open[t.ID] != nil && !drawn[t.ID] {
+			draw(t.ID, "", "")
+		}
+	}
+	return lines
+}
+
+// graphMarker returns the marker and note a task is drawn with.
+func graphMarker(t *models.Task, waitingOn int, now time.Time) (marker, note string, style lipgloss.Style) {
+	switch {
+	case t.Status == models.TaskStatusInProgress:
+		return "●", "running", graphRunningStyle
+	case t.Status == models.TaskStatusInReview:
+		return "◐", "in review", graphRunningStyle
+	case t.Status == models.TaskStatusBlocked:
+		note = "blocked"
+		if t.BlockedReason != nil && *t.BlockedReason != "" {
+			note += ": " + *t.BlockedReason
+		}
+		return "✖", note, graphBlockedStyle
+	case waitingOn > 0:
+		return "◌", fmt.Sprintf("waiting on %d", waitingOn), graphWaitingStyle
+	case t.NotBefore != nil && t.NotBefore.After(now):
+		return "◷", "not before " + t.NotBefore.Local().Format("2006-01-02 15:04"), graphWaitingStyle
+	default:
+		return "○", "ready", graphReadyStyle
+	}
+}
@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestDependencyTree(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	reason := "needs credentials"
+	task := func(id string, status models.TaskStatus) *models.Task {
+		return &models.Task{ID: id, FeatureName: "f", Name: id, Status: status}
+	}
+	tasks := []*models.Task{
+		task("setup", models.TaskStatusInProgress),
+		task("api", models.TaskStatusPending),
+		task("ui", models.TaskStatusPending),
+		task("deploy", models.TaskStatusBlocked),
+		task("done", models.TaskStatusCompleted),
+		task("later", models.TaskStatusPending),
+	}
+	tasks[3].BlockedReason = &reason
+	tasks[5].NotBefore = &later
+	deps := []*models.Dependency{
+		{TaskID: "api", DependsOnTaskID: "setup"},
+		{TaskID: "ui", DependsOnTaskID: "setup"},
+		{TaskID: "ui", DependsOnTaskID: "api"},
+		{TaskID: "api", DependsOnTaskID: "done"},
+	}
+
+	got := ansi.Strip(strings.Join(dependencyTree(tasks, deps, now), "\n"))
+	want := strings.Join([]string{
+		"5 open: 0 ready, 1 running, 2 waiting, 1 blocked, 1 scheduled",
+		"",
+		"● f/setup running",
+		"├─ ◌ f/api waiting on 1",
+		"│  └─ ◌ f/ui waiting on 2",
+		"└─ ◌ f/ui waiting on 2 ↑",
+		"✖ f/deploy blocked: needs credentials",
+		"◷ f/later not before " + later.Local().Format("2006-01-02 15:04"),
+	}, "\n")
+	if got != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := dependencyTree([]*models.Task{task("done", models.TaskStatusCompleted)}, nil, now); len(got) != 1 || got[0] != "No open tasks" {
+		t.Errorf("expected no open tasks, got %q", got)
+	}
+}
+
+func TestOrchestratorModel_GraphPane(t *testing.T) {
+	store := newMockTaskStore()
+	store.tasks = []*models.Task{
+		{ID: "a", FeatureName: "core", Name: "first", Status: models.TaskStatusPending},
+		{ID: "b", FeatureName: "core", Name: "second", Status: models.TaskStatusPending},
+	}
+	store.dependencies = map[string][]*models.Task{"b": {store.tasks[0]}}
+	orch := NewOrchestrator(store, 1, "test-model")
+	m := NewOrchestratorModel(orch)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if !m.showGraph || cmd == nil {
+		t.Fatal("expected g to show the graph and load it")
+	}
+	m.Update(m.loadGraph()())
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Dependency Graph", "○ core/first ready", "└─ ◌ core/second waiting on 1", "Close Graph"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Worker 1") {
+		t.Error("expected the graph to replace the workers")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showGraph {
+		t.Error("expected esc to close the graph")
+	}
+}
@@ -0,0 +1,276 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LogFormat selects how RunHeadless writes orchestrator events.
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text"
+	LogFormatJSON LogFormat = "json"
+)
+
+// ParseLogFormat validates a user-supplied log format name.
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch LogFormat(strings.ToLower(s)) {
+	case LogFormatText:
+		return LogFormatText, nil
+	case LogFormatJSON, "ndjson":
+		return LogFormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported log format: %s (expected text or json)", s)
+	}
+}
+
+// Event types written by RunHeadless.
+const (
+	EventWorkerStarted = "worker_started"
+	EventTaskStarted   = "task_started"
+	EventOutputChunk   = "output_chunk"
+	EventTaskCompleted = "task_completed"
+	EventStatus        = "status"
+	EventWorkerStalled = "worker_stalled"
+	EventWorkerResumed = "worker_resumed"
+	EventIdle          = "idle"
+	EventPaused        = "paused"
+	EventResumed       = "resumed"
+	EventRunFinished   = "run_finished"
+)
+
+// LogEvent is one line of the JSON event log.
+type LogEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	WorkerID  int       `json:"worker_id,omitempty"`
+	TaskID    string    `json:"task_id,omitempty"`
+	Task      string    `json:"task,omitempty"`
+	Feature   string    `json:"feature,omitempty"`
+	Output    string    `json:"output,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Success   *bool     `json:"success,omitempty"`
+	Branch    string    `json:"branch,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Fallback  string    `json:"fallback_model,omitempty"`
+	Idle      *bool     `json:"idle,omitempty"`
+	TokensIn  int64     `json:"tokens_in,omitempty"`
+	TokensOut int64     `json:"tokens_out,omitempty"`
+	CostUSD   float64   `json:"cost_usd,omitempty"`
+	Completed *int      `json:"completed,omitempty"`
+	Failed    *int      `json:"failed,omitempty"`
+}
+
+// eventLogger turns orchestrator messages into log lines. It remembers which
+// task each worker is running so that every event carries the task identity.
+type eventLogger struct {
+	w      io.Writer
+	format LogFormat
+	now    func() time.Time
+
+	mu        sync.Mutex
+	running   map[int]LogEvent
+	completed int
+	failed    int
+	usage     Usage
+}
+
+func newEventLogger(w io.Writer, format LogFormat) *eventLogger {
+	return &eventLogger{
+		w:       w,
+		format:  format,
+		now:     time.Now,
+		running: make(map[int]LogEvent),
+	}
+}
+
+func (l *eventLogger) handle(msg tea.Msg) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var ev LogEvent
+	switch msg := msg.(type) {
+	case WorkerStartedMsg:
+		ev = LogEvent{Event: EventWorkerStarted, WorkerID: msg.WorkerID}
+		if msg.Task != nil {
+			ev.TaskID, ev.Task, ev.Feature = msg.Task.ID, msg.Task.Name, msg.Task.FeatureName
+		}
+		l.running[msg.WorkerID] = ev
+	case TaskStartedMsg:
+		ev = l.forWorker(EventTaskStarted, msg.WorkerID)
+		ev.Task = msg.TaskName
+	case OutputMsg:
+		ev = l.forWorker(EventOutputChunk, msg.WorkerID)
+		ev.Output = msg.Output
+	case StatusMsg:
+		ev = l.forWorker(EventStatus, msg.WorkerID)
+		ev.Message = msg.Message
+	case WorkerStalledMsg:
+		silent := msg.Silent.Round(time.Second)
+		ev = l.forWorker(EventWorkerResumed, msg.WorkerID)
+		ev.Message = fmt.Sprintf("output resumed after %s", silent)
+		if msg.Stalled {
+			ev.Event = EventWorkerStalled
+			ev.Message = fmt.Sprintf("no output for %s", silent)
+		}
+	case TaskCompletedMsg:
+		ev = l.forWorker(EventTaskCompleted, msg.WorkerID)
+		ev.Task = msg.TaskName
+		ev.Success = &msg.Success
+		ev.Branch = msg.Branch
+		ev.Model, ev.Fallback = msg.Model, msg.FallbackModel
+		ev.TokensIn, ev.TokensOut, ev.CostUSD = msg.Usage.TokensIn, msg.Usage.TokensOut, msg.Usage.CostUSD
+		l.usage.add(msg.Usage)
+		delete(l.running, msg.WorkerID)
+		if msg.Success {
+			l.completed++
+		} else {
+			l.failed++
+		}
+	case IdleStateMsg:
+		ev = LogEvent{Event: EventIdle, Idle: &msg.Idle}
+	case PauseStateMsg:
+		ev = LogEvent{Event: EventResumed}
+		if msg.Paused {
+			ev.Event = EventPaused
+		}
+	default:
+		return nil
+	}
+	return l.write(ev)
+}
+
+// finish writes the closing summary line, with why the run stopped early if
+// it did.
+func (l *eventLogger) finish(reason string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	completed, failed := l.completed, l.failed
+	return l.write(LogEvent{
+		Event:     EventRunFinished,
+		Message:   reason,
+		Completed: &completed,
+		Failed:    &failed,
+		TokensIn:  l.usage.TokensIn,
+		TokensOut: l.usage.TokensOut,
+		CostUSD:   l.usage.CostUSD,
+	})
+}
+
+func (l *eventLogger) forWorker(event string, workerID int) LogEvent {
+	ev := LogEvent{Event: event, WorkerID: workerID}
+	if started, ok := l.running[workerID]; ok {
+		ev.TaskID, ev.Task, ev.Feature = started.TaskID, started.Task, started.Feature
+	}
+	return ev
+}
+
+func (l *eventLogger) write(ev LogEvent) error {
+	ev.Time = l.now().UTC()
+
+	if l.format == LogFormatJSON {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		_, err = l.w.Write(append(line, '\n'))
+		return err
+	}
+
+	var line string
+	switch ev.Event {
+	case EventWorkerStarted:
+		line = fmt.Sprintf("[worker %d] started %s/%s", ev.WorkerID, ev.Feature, ev.Task)
+	case EventTaskStarted:
+		return nil
+	case EventOutputChunk:
+		_, err := io.WriteString(l.w, ev.Output)
+		return err
+	case EventStatus:
+		line = fmt.Sprintf("[worker %d] %s", ev.WorkerID, ev.Message)
+	case EventWorkerStalled:
+		line = fmt.Sprintf("[worker %d] stalled on %s/%s: %s", ev.WorkerID, ev.Feature, ev.Task, ev.Message)
+	case EventWorkerResumed:
+		line = fmt.Sprintf("[worker %d] %s", ev.WorkerID, ev.Message)
+	case EventTaskCompleted:
+		result := "completed"
+		if !*ev.Success {
+			result = "failed"
+		}
+		line = fmt.Sprintf("[worker %d] %s %s/%s", ev.WorkerID, result, ev.Feature, ev.Task)
+		if ev.Branch != "" {
+			line += " on branch " + ev.Branch
+		}
+		line += usageSuffix(ev)
+	case EventIdle:
+		if !*ev.Idle {
+			return nil
+		}
+		line = "Idle: no tasks available"
+	case EventPaused:
+		line = "Paused: no new tasks will be claimed"
+	case EventResumed:
+		line = "Resumed"
+	case EventRunFinished:
+		line = fmt.Sprintf("Finished: %d completed, %d failed", *ev.Completed, *ev.Failed) + usageSuffix(ev)
+		if ev.Message != "" {
+			line += "; stopped: " + ev.Message
+		}
+	}
+	_, err := fmt.Fprintf(l.w, "%s %s\n", ev.Time.Format(time.RFC3339), line)
+	return err
+}
+
+func usageSuffix(ev LogEvent) string {
+	if ev.TokensIn == 0 && ev.TokensOut == 0 && ev.CostUSD == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s in / %s out tokens, $%.2f)", formatTokens(ev.TokensIn), formatTokens(ev.TokensOut), ev.CostUSD)
+}
+
+// RunHeadless runs the orchestrator without the TUI, writing its events to w
+// until the backlog is drained (or ctx is cancelled when polling). All
+// workers are enabled from the start since nobody is there to scale them up.
+func RunHeadless(ctx context.Context, orchestrator *Orchestrator, w io.Writer, format LogFormat) error {
+	orchestrator.SetTargetWorkers(orchestrator.GetMaxWorkers())
+	logger := newEventLogger(w, format)
+
+	orchDone := make(chan error, 1)
+	go func() {
+		orchDone <- orchestrator.Start(ctx)
+	}()
+
+	var writeErr error
+	for msg := range orchestrator.Messages() {
+		if err := logger.handle(msg); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	orchErr := <-orchDone
+	var reason string
+	var budgetErr *BudgetExceededError
+	if errors.As(orchErr, &budgetErr) {
+		reason = budgetErr.Error()
+	}
+	if err := logger.finish(reason); err != nil && writeErr == nil {
+		writeErr = err
+	}
+
+	if orchErr != nil && orchErr != context.Canceled {
+		return orchErr
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to write event log: %w", writeErr)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func newHeadlessTestOrchestrator(t *testing.T) (*Orchestrator, *models.Task) {
+	t.Helper()
+
+	store, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	f := &models.Feature{Name: "ci", Description: "d", Specification: "s"}
+	if err := store.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	task := &models.Task{FeatureID: f.ID, Name: "build", Description: "d", Specification: "s", Priority: 5, Status: models.TaskStatusPending}
+	if err := store.CreateTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	o := NewOrchestrator(store, 2, "test-model")
+	o.minSpawnInterval = 0
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		summary := "done"
+		_ = store.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted, &summary)
+		return exec.CommandContext(ctx, "echo", "agent output")
+	}
+	return o, task
+}
+
+func TestRunHeadlessJSON(t *testing.T) {
+	o, task := newHeadlessTestOrchestrator(t)
+	// The TUI starts with no workers; headless runs must enable them.
+	o.SetTargetWorkers(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if err := RunHeadless(ctx, o, &buf, LogFormatJSON); err != nil {
+		t.Fatalf("RunHeadless failed: %v", err)
+	}
+
+	var events []LogEvent
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var ev LogEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("line is not JSON: %q (%v)", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+
+	seen := make(map[string]LogEvent)
+	var order []string
+	for _, ev := range events {
+		if _, ok := seen[ev.Event]; !ok {
+			order = append(order, ev.Event)
+		}
+		seen[ev.Event] = ev
+	}
+
+	want := []string{EventWorkerStarted, EventTaskStarted, EventOutputChunk, EventTaskCompleted, EventRunFinished}
+	idx := 0
+	for _, name := range order {
+		if idx < len(want) && name == want[idx] {
+			idx++
+		}
+	}
+	if idx != len(want) {
+		t.Fatalf("expected events in order %v, got %v", want, order)
+	}
+
+	completed := seen[EventTaskCompleted]
+	if completed.TaskID != task.ID || completed.Feature != "ci" || completed.Success == nil || !*completed.Success {
+		t.Errorf("unexpected task_completed event: %+v", completed)
+	}
+	if out := seen[EventOutputChunk]; !strings.Contains(out.Output, "agent output") || out.TaskID != task.ID {
+		t.Errorf("unexpected output_chunk event: %+v", out)
+	}
+	if fin := seen[EventRunFinished]; fin.Completed == nil || *fin.Completed != 1 || *fin.Failed != 0 {
+		t.Errorf("unexpected run_finished event: %+v", fin)
+	}
+}
+
+func TestRunHeadlessText(t *testing.T) {
+	o, _ := newHeadlessTestOrchestrator(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if err := RunHeadless(ctx, o, &buf, LogFormatText); err != nil {
+		t.Fatalf("RunHeadless failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"started ci/build", "agent output", "completed ci/build", "Finished: 1 completed, 0 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in text log, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseLogFormat(t *testing.T) {
+	if f, err := ParseLogFormat("JSON"); err != nil || f != LogFormatJSON {
+		t.Errorf("expected json, got %q (%v)", f, err)
+	}
+	if f, err := ParseLogFormat("text"); err != nil || f != LogFormatText {
+		t.Errorf("expected text, got %q (%v)", f, err)
+	}
+	if _, err := ParseLogFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
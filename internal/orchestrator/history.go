@@ -0,0 +1,217 @@
+package orchestrator
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultHistorySize is how many messages the orchestrator keeps in its
+// history unless configured otherwise.
+const DefaultHistorySize = 5000
+
+// HistoryEvent is a message the orchestrator sent, numbered in the order it
+// was sent.
+type HistoryEvent struct {
+	Seq  uint64
+	Time time.Time
+	Msg  tea.Msg
+}
+
+// History keeps the most recent orchestrator messages in a ring buffer, so a
+// worker view can rebuild output it missed: messages are recorded before
+// they are sent, and the message channel drops what the TUI doesn't read in
+// time. With a writer it also appends every message to it as a JSON event,
+// in the -no-tui log format.
+type History struct {
+	mu     sync.Mutex
+	events []HistoryEvent
+	size   int
+	// next is where the following event goes once the buffer is full.
+	next    int
+	seq     uint64
+	dropped bool
+	log     *eventLogger
+	subs    map[*eventLogger]struct{}
+	now     func() time.Time
+}
+
+// NewHistory keeps the last size messages, writing them all to w as well if
+// w is not nil. A size of 0 keeps nothing in memory.
+func NewHistory(size int, w io.Writer) *History {
+	h := &History{size: size, now: time.Now}
+	if w != nil {
+		h.log = newEventLogger(w, LogFormatJSON)
+	}
+	return h
+}
+
+// Record numbers msg, stores it and returns it with its number set.
+func (h *History) Record(msg tea.Msg) tea.Msg {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	msg = withSeq(msg, h.seq)
+
+	// A history file that can't be written is given up on rather than
+	// holding up the workers.
+	if h.log != nil && h.log.handle(msg) != nil {
+		h.log = nil
+	}
+	for sub := range h.subs {
+		if sub.handle(msg) != nil {
+			delete(h.subs, sub)
+		}
+	}
+
+	if h.size <= 0 {
+		h.dropped = true
+		return msg
+	}
+	ev := HistoryEvent{Seq: h.seq, Time: h.now(), Msg: msg}
+	if len(h.events) < h.size {
+		h.events = append(h.events, ev)
+		return msg
+	}
+	h.events[h.next] = ev
+	h.next = (h.next + 1) % h.size
+	h.dropped = true
+	return msg
+}
+
+// Subscribe writes every message recorded from now on to w as a JSON event,
+// in the -no-tui log format, until the returned function is called. Events
+// of workers that were already running carry their task, as long as the
+// start of the run is still kept. Writes happen while messages are
+// recorded, so w must not block; a subscriber whose write fails is dropped.
+func (h *History) Subscribe(w io.Writer) (unsubscribe func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := newEventLogger(io.Discard, LogFormatJSON)
+	for _, ev := range h.eventsLocked() {
+		sub.handle(ev.Msg)
+	}
+	sub.w = w
+
+	if h.subs == nil {
+		h.subs = make(map[*eventLogger]struct{})
+	}
+	h.subs[sub] = struct{}{}
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs, sub)
+	}
+}
+
+// Events returns the kept messages, oldest first.
+func (h *History) Events() []HistoryEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.eventsLocked()
+}
+
+func (h *History) eventsLocked() []HistoryEvent {
+	out := make([]HistoryEvent, 0, len(h.events))
+	out = append(out, h.events[h.next:]...)
+	return append(out, h.events[:h.next]...)
+}
+
+// WorkerRun returns the messages of the latest run on a worker, from its
+// WorkerStartedMsg on. complete is false when the start of the run has
+// already been dropped from the history, in which case the messages of the
+// worker that are left are returned.
+func (h *History) WorkerRun(workerID int) (events []HistoryEvent, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	all := h.eventsLocked()
+	start, complete := 0, !h.dropped
+	for i := len(all) - 1; i >= 0; i-- {
+		if msg, ok := all[i].Msg.(WorkerStartedMsg); ok && msg.WorkerID == workerID {
+			start, complete = i, true
+			break
+		}
+	}
+	for _, ev := range all[start:] {
+		if id, ok := messageWorker(ev.Msg); ok && id == workerID {
+			events = append(events, ev)
+		}
+	}
+	return events, complete
+}
+
+// withSeq sets the history number of the messages that carry one.
+func withSeq(msg tea.Msg, seq uint64) tea.Msg {
+	switch m := msg.(type) {
+	case WorkerStartedMsg:
+		m.Seq = seq
+		return m
+	case TaskStartedMsg:
+		m.Seq = seq
+		return m
+	case OutputMsg:
+		m.Seq = seq
+		return m
+	case StatusMsg:
+		m.Seq = seq
+		return m
+	case TaskCompletedMsg:
+		m.Seq = seq
+		return m
+	}
+	return msg
+}
+
+// messageWorker returns the worker a message is about, if any.
+func messageWorker(msg tea.Msg) (int, bool) {
+	switch m := msg.(type) {
+	case WorkerStartedMsg:
+		return m.WorkerID, true
+	case TaskStartedMsg:
+		return m.WorkerID, true
+	case OutputMsg:
+		return m.WorkerID, true
+	case StatusMsg:
+		return m.WorkerID, true
+	case TaskCompletedMsg:
+		return m.WorkerID, true
+	}
+	return 0, false
+}
+
+// messageSeq returns the history number of a message, or 0 if it has none.
+func messageSeq(msg tea.Msg) uint64 {
+	switch m := msg.(type) {
+	case WorkerStartedMsg:
+		return m.Seq
+	case TaskStartedMsg:
+		return m.Seq
+	case OutputMsg:
+		return m.Seq
+	case StatusMsg:
+		return m.Seq
+	case TaskCompletedMsg:
+		return m.Seq
+	}
+	return 0
+}
+
+// History returns the orchestrator's message history.
+func (o *Orchestrator) History() *History {
+	o.historyMu.RLock()
+	defer o.historyMu.RUnlock()
+	return o.history
+}
+
+// SetHistory replaces the message history, e.g. to change its size or keep
+// it on disk. Set it before Start.
+func (o *Orchestrator) SetHistory(h *History) {
+	o.historyMu.Lock()
+	defer o.historyMu.Unlock()
+	o.history = h
+}
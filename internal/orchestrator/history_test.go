@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestHistoryRingBuffer(t *testing.T) {
+	h := NewHistory(3, nil)
+	for i := 0; i < 5; i++ {
+		msg := h.Record(OutputMsg{WorkerID: 1, Output: string(rune('a' + i))})
+		if got := msg.(OutputMsg).Seq; got != uint64(i+1) {
+			t.Errorf("expected seq %d, got %d", i+1, got)
+		}
+	}
+
+	events := h.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	var out string
+	for _, ev := range events {
+		out += ev.Msg.(OutputMsg).Output
+	}
+	if out != "cde" {
+		t.Errorf("expected the newest events oldest first, got %q", out)
+	}
+
+	// Messages that aren't about a worker are kept but carry no number.
+	if msg := h.Record(IdleStateMsg{Idle: true}); messageSeq(msg) != 0 {
+		t.Errorf("expected no seq on IdleStateMsg")
+	}
+}
+
+func TestHistoryWorkerRun(t *testing.T) {
+	h := NewHistory(100, nil)
+	for _, msg := range []tea.Msg{
+		WorkerStartedMsg{WorkerID: 1},
+		TaskStartedMsg{WorkerID: 1, TaskName: "first"},
+		OutputMsg{WorkerID: 1, Output: "old"},
+		TaskCompletedMsg{WorkerID: 1, TaskName: "first", Success: true},
+		WorkerStartedMsg{WorkerID: 1},
+		WorkerStartedMsg{WorkerID: 2},
+		TaskStartedMsg{WorkerID: 1, TaskName: "second"},
+		OutputMsg{WorkerID: 2, Output: "other worker"},
+		OutputMsg{WorkerID: 1, Output: "new"},
+	} {
+		h.Record(msg)
+	}
+
+	events, complete := h.WorkerRun(1)
+	if !complete {
+		t.Error("expected the whole run to be in the history")
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events of the latest run, got %d", len(events))
+	}
+	if out := events[2].Msg.(OutputMsg).Output; out != "new" {
+		t.Errorf("expected the latest output, got %q", out)
+	}
+
+	if events, _ := h.WorkerRun(3); len(events) != 0 {
+		t.Errorf("expected nothing for a worker that never ran, got %d events", len(events))
+	}
+
+	// Once the start of the run is dropped, what is left is still returned.
+	small := NewHistory(2, nil)
+	small.Record(WorkerStartedMsg{WorkerID: 1})
+	small.Record(OutputMsg{WorkerID: 1, Output: "a"})
+	small.Record(OutputMsg{WorkerID: 1, Output: "b"})
+	events, complete = small.WorkerRun(1)
+	if complete || len(events) != 2 {
+		t.Errorf("expected 2 events of an incomplete run, got %d (complete %v)", len(events), complete)
+	}
+}
+
+func TestHistoryWritesEvents(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHistory(0, &buf)
+	h.Record(StatusMsg{WorkerID: 1, Message: "hello"})
+
+	if len(h.Events()) != 0 {
+		t.Error("expected a size of 0 to keep nothing in memory")
+	}
+	var ev LogEvent
+	if err := json.Unmarshal(buf.Bytes(), &ev); err != nil {
+		t.Fatalf("expected a JSON event, got %q: %v", buf.String(), err)
+	}
+	if ev.Event != EventStatus || ev.Message != "hello" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestHistorySubscribe(t *testing.T) {
+	h := NewHistory(100, nil)
+	h.Record(WorkerStartedMsg{WorkerID: 1, Task: &models.Task{ID: "t1", Name: "build", FeatureName: "core"}})
+	h.Record(OutputMsg{WorkerID: 1, Output: "before"})
+
+	var buf bytes.Buffer
+	unsubscribe := h.Subscribe(&buf)
+	h.Record(OutputMsg{WorkerID: 1, Output: "after"})
+	unsubscribe()
+	h.Record(OutputMsg{WorkerID: 1, Output: "unsubscribed"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the event recorded while subscribed, got %q", buf.String())
+	}
+	var ev LogEvent
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("expected a JSON event, got %q: %v", lines[0], err)
+	}
+	if ev.Event != EventOutputChunk || ev.Output != "after" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if ev.TaskID != "t1" || ev.Task != "build" || ev.Feature != "core" {
+		t.Errorf("expected the task of the run started before subscribing, got %+v", ev)
+	}
+}
+
+func TestOrchestratorModel_ReplaysMissedOutput(t *testing.T) {
+	orch := NewOrchestrator(newMockTaskStore(), 2, "test-model")
+	orch.SetTargetWorkers(2)
+	m := NewOrchestratorModel(orch)
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	// The worker runs a task while the TUI reads none of its messages.
+	orch.sendMsg(WorkerStartedMsg{WorkerID: 1})
+	orch.sendMsg(TaskStartedMsg{WorkerID: 1, TaskName: "build"})
+	orch.sendMsg(OutputMsg{WorkerID: 1, Output: "compiling widgets\n"})
+	orch.sendMsg(TaskCompletedMsg{WorkerID: 1, TaskName: "build", Success: true})
+
+	m.focusedWorker = 1
+	m.toggleExpanded()
+	view := m.workerViews[1]
+	if view.TaskName != "build" || view.Status != "completed" {
+		t.Errorf("expected the finished run to be shown, got %q [%s]", view.TaskName, view.Status)
+	}
+	if got := strings.Count(view.Output.View(), "compiling widgets"); got != 1 {
+		t.Fatalf("expected replayed output once, found it %d times", got)
+	}
+
+	// The same messages arriving late must not be shown twice.
+	for len(orch.Messages()) > 0 {
+		m.Update(<-orch.Messages())
+	}
+	if got := strings.Count(view.Output.View(), "compiling widgets"); got != 1 {
+		t.Errorf("expected output once after late delivery, found it %d times", got)
+	}
+
+	orch.sendMsg(OutputMsg{WorkerID: 1, Output: "next run\n"})
+	m.Update(<-orch.Messages())
+	if !strings.Contains(view.Output.View(), "next run") {
+		t.Error("expected new output to be shown after a replay")
+	}
+}
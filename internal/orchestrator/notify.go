@@ -0,0 +1,340 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Notifier types.
+const (
+	// NotifierSlack posts to a Slack incoming webhook.
+	NotifierSlack = "slack"
+	// NotifierDiscord posts to a Discord channel webhook.
+	NotifierDiscord = "discord"
+	// NotifierWebhook posts the notification as JSON, for anything else.
+	NotifierWebhook = "webhook"
+)
+
+// Notification kinds, which notifiers can be limited to.
+const (
+	NotifyTaskCompleted = "task_completed"
+	NotifyTaskBlocked   = "task_blocked"
+	NotifyRunFinished   = "run_finished"
+)
+
+// notifyTimeout bounds each delivery, so a slow webhook can't hold up
+// shutdown for long.
+const notifyTimeout = 10 * time.Second
+
+// maxNotifySummary caps how much of a completion summary is sent; chat
+// messages have length limits and a ping only needs the gist.
+const maxNotifySummary = 1500
+
+// Notifier sends notifications about the run to a webhook, e.g. a Slack
+// ping when the overnight run ends.
+type Notifier struct {
+	// Type is NotifierSlack, NotifierDiscord or NotifierWebhook.
+	Type string
+	URL  string
+	// Events are the notification kinds to send. Empty means all of them.
+	Events []string
+}
+
+// Validate checks that the notifier settings are usable.
+func (n Notifier) Validate() error {
+	switch n.Type {
+	case NotifierSlack, NotifierDiscord, NotifierWebhook:
+	default:
+		return fmt.Errorf("type must be %s, %s or %s", NotifierSlack, NotifierDiscord, NotifierWebhook)
+	}
+	if !strings.HasPrefix(n.URL, "https://") && !strings.HasPrefix(n.URL, "http://") {
+		return fmt.Errorf("url must be an http(s) URL")
+	}
+	for _, e := range n.Events {
+		switch e {
+		case NotifyTaskCompleted, NotifyTaskBlocked, NotifyRunFinished:
+		default:
+			return fmt.Errorf("unknown event %q: expected %s, %s or %s", e, NotifyTaskCompleted, NotifyTaskBlocked, NotifyRunFinished)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the notifier sends notifications of kind.
+func (n Notifier) wants(kind string) bool {
+	return len(n.Events) == 0 || slices.Contains(n.Events, kind)
+}
+
+// Notification is one message to the notifiers.
+type Notification struct {
+	Kind    string
+	Feature string
+	Task    string
+	// Summary is the completion summary of a completed task.
+	Summary string
+	// Reason is why a task is blocked, or why a run stopped early.
+	Reason string
+	// Stats sum up a finished run.
+	Stats RunStats
+}
+
+// RunStats sum up a run: the stretch of work from the first task claimed
+// until no tasks are left or the orchestrator stops.
+type RunStats struct {
+	Completed int
+	Failed    int
+	Blocked   int
+	Duration  time.Duration
+	Usage     Usage
+}
+
+// send delivers the notification to the notifier's webhook.
+func (n Notifier) send(ctx context.Context, client *http.Client, note Notification) error {
+	body, err := json.Marshal(n.payload(note))
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// withoutURL drops the URL from err. A Slack or Discord webhook's URL is its
+// secret, which must not end up in the status log.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// payload returns the JSON body for the notifier's type.
+func (n Notifier) payload(note Notification) any {
+	switch n.Type {
+	case NotifierSlack:
+		return map[string]string{"text": note.text(slackMarkup)}
+	case NotifierDiscord:
+		// Discord rejects messages over 2000 characters.
+		return map[string]string{"content": truncate(note.text(discordMarkup), 2000)}
+	}
+	return webhookPayload{
+		Event:     note.Kind,
+		Time:      time.Now().UTC(),
+		Feature:   note.Feature,
+		Task:      note.Task,
+		Summary:   note.Summary,
+		Reason:    note.Reason,
+		Completed: note.Stats.Completed,
+		Failed:    note.Stats.Failed,
+		Blocked:   note.Stats.Blocked,
+		Seconds:   int64(note.Stats.Duration.Seconds()),
+		TokensIn:  note.Stats.Usage.TokensIn,
+		TokensOut: note.Stats.Usage.TokensOut,
+		CostUSD:   note.Stats.Usage.CostUSD,
+	}
+}
+
+// webhookPayload is the body posted by NotifierWebhook.
+type webhookPayload struct {
+	Event     string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Feature   string    `json:"feature,omitempty"`
+	Task      string    `json:"task,omitempty"`
+	Summary   string    `json:"summary,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Completed int       `json:"completed,omitempty"`
+	Failed    int       `json:"failed,omitempty"`
+	Blocked   int       `json:"blocked,omitempty"`
+	Seconds   int64     `json:"duration_seconds,omitempty"`
+	TokensIn  int64     `json:"tokens_in,omitempty"`
+	TokensOut int64     `json:"tokens_out,omitempty"`
+	CostUSD   float64   `json:"cost_usd,omitempty"`
+}
+
+// markup is how a chat service writes bold text and escapes the rest.
+type markup struct {
+	bold   func(string) string
+	escape func(string) string
+}
+
+var slackMarkup = markup{
+	bold:   func(s string) string { return "*" + s + "*" },
+	escape: strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace,
+}
+
+var discordMarkup = markup{
+	bold:   func(s string) string { return "**" + s + "**" },
+	escape: strings.NewReplacer("*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "@", "@\u200b").Replace,
+}
+
+// text renders the notification as a chat message.
+func (note Notification) text(m markup) string {
+	name := m.bold(m.escape(note.Feature + "/" + note.Task))
+	switch note.Kind {
+	case NotifyTaskCompleted:
+		text := "✅ " + name + " completed"
+		if summary := strings.TrimSpace(note.Summary); summary != "" {
+			text += "\n" + quote(m.escape(truncate(summary, maxNotifySummary)))
+		}
+		return text
+	case NotifyTaskBlocked:
+		text := "⛔ " + name + " is blocked"
+		if reason := strings.TrimSpace(note.Reason); reason != "" {
+			text += "\n" + quote(m.escape(reason))
+		}
+		return text
+	}
+
+	s := note.Stats
+	text := fmt.Sprintf("🏁 %s in %s: %d completed, %d failed, %d blocked",
+		m.bold("Run finished"), s.Duration.Round(time.Second), s.Completed, s.Failed, s.Blocked)
+	if !s.Usage.IsZero() {
+		text += fmt.Sprintf(" (%s in / %s out tokens, %s)", formatTokens(s.Usage.TokensIn), formatTokens(s.Usage.TokensOut), formatCost(s.Usage))
+	}
+	if note.Reason != "" {
+		text += "\nStopped: " + m.escape(note.Reason)
+	}
+	return text
+}
+
+// quote prefixes every line of s as a block quote.
+func quote(s string) string {
+	return "> " + strings.ReplaceAll(s, "\n", "\n> ")
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// GetNotifiers returns the configured notifiers.
+func (o *Orchestrator) GetNotifiers() []Notifier {
+	o.notifyMu.Lock()
+	defer o.notifyMu.Unlock()
+	return o.notifiers
+}
+
+// SetNotifiers sets where notifications about tasks and runs are sent. Nil
+// turns notifications off.
+func (o *Orchestrator) SetNotifiers(notifiers []Notifier) {
+	o.notifyMu.Lock()
+	defer o.notifyMu.Unlock()
+	o.notifiers = notifiers
+}
+
+// notify sends the notification to every notifier that wants it, in the
+// background. Failures are reported in the status log.
+func (o *Orchestrator) notify(note Notification) {
+	for _, n := range o.GetNotifiers() {
+		if !n.wants(note.Kind) {
+			continue
+		}
+		o.notifyWG.Add(1)
+		go func() {
+			defer o.notifyWG.Done()
+			// Deliveries outlive the orchestrator's context, so the run's
+			// end is still announced on shutdown.
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := n.send(ctx, o.notifyClient, note); err != nil {
+				o.sendMsg(StatusMsg{Message: fmt.Sprintf("Failed to send %s notification: %v", n.Type, err)})
+			}
+		}()
+	}
+}
+
+// notifyTaskResult adds the usage of the task's run, and whether it left the
+// task blocked, to the run's stats and announces it if the task was
+// completed or blocked.
+func (o *Orchestrator) notifyTaskResult(task *models.Task, success bool, usage Usage) {
+	if len(o.GetNotifiers()) == 0 {
+		return
+	}
+
+	// The agent sets the final status and summary, so read them back.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	current, err := o.store.GetTask(ctx, task.ID)
+	if err != nil || current == nil {
+		current = task
+	}
+
+	note := Notification{Feature: current.FeatureName, Task: current.Name}
+	o.notifyMu.Lock()
+	o.runStats.Usage.add(usage)
+	switch {
+	case current.Status == models.TaskStatusBlocked:
+		o.runStats.Blocked++
+		note.Kind = NotifyTaskBlocked
+		if current.BlockedReason != nil {
+			note.Reason = *current.BlockedReason
+		}
+	case success:
+		note.Kind = NotifyTaskCompleted
+		if current.CompletionSummary != nil {
+			note.Summary = *current.CompletionSummary
+		}
+	}
+	o.notifyMu.Unlock()
+
+	if note.Kind != "" {
+		o.notify(note)
+	}
+}
+
+// noteRunStart marks the start of a run when a task is claimed while none
+// is under way.
+func (o *Orchestrator) noteRunStart() {
+	o.notifyMu.Lock()
+	defer o.notifyMu.Unlock()
+	if o.runStart.IsZero() {
+		o.runStart = time.Now()
+		session := o.GetSessionStats()
+		o.runBase = RunStats{Completed: session.Completed, Failed: session.Failed}
+	}
+}
+
+// notifyRunFinished announces the end of the run under way, if any, with
+// why it stopped early if it did.
+func (o *Orchestrator) notifyRunFinished(reason string) {
+	o.notifyMu.Lock()
+	start, stats, base := o.runStart, o.runStats, o.runBase
+	o.runStart, o.runStats = time.Time{}, RunStats{}
+	o.notifyMu.Unlock()
+	if start.IsZero() {
+		return
+	}
+	session := o.GetSessionStats()
+	stats.Completed = session.Completed - base.Completed
+	stats.Failed = session.Failed - base.Failed
+	stats.Duration = time.Since(start)
+	o.notify(Notification{Kind: NotifyRunFinished, Reason: reason, Stats: stats})
+}
@@ -0,0 +1,146 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestNotifier_Validate(t *testing.T) {
+	if err := (Notifier{Type: NotifierSlack, URL: "https://hooks.slack.com/x", Events: []string{NotifyRunFinished}}).Validate(); err != nil {
+		t.Errorf("expected a slack notifier to be valid, got %v", err)
+	}
+	for _, n := range []Notifier{
+		{Type: "teams", URL: "https://example.com"},
+		{Type: NotifierWebhook, URL: "example.com"},
+		{Type: NotifierDiscord, URL: "https://discord.com/x", Events: []string{"task_started"}},
+	} {
+		if err := n.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", n)
+		}
+	}
+}
+
+func TestNotificationText(t *testing.T) {
+	completed := Notification{Kind: NotifyTaskCompleted, Feature: "auth", Task: "login_<form>", Summary: "Added the form\nand tests"}
+	if got, want := completed.text(slackMarkup), "✅ *auth/login_&lt;form&gt;* completed\n> Added the form\n> and tests"; got != want {
+		t.Errorf("slack text = %q, want %q", got, want)
+	}
+	if got, want := completed.text(discordMarkup), "✅ **auth/login\\_<form>** completed\n> Added the form\n> and tests"; got != want {
+		t.Errorf("discord text = %q, want %q", got, want)
+	}
+
+	long := Notification{Kind: NotifyTaskCompleted, Feature: "f", Task: "t", Summary: strings.Repeat("x", 5000)}
+	if got := long.text(slackMarkup); len([]rune(got)) > maxNotifySummary+20 || !strings.HasSuffix(got, "…") {
+		t.Errorf("expected the summary to be truncated, got %d runes", len([]rune(got)))
+	}
+
+	finished := Notification{Kind: NotifyRunFinished, Reason: "interrupted", Stats: RunStats{
+		Completed: 3, Failed: 1, Blocked: 2, Duration: 90 * time.Minute,
+		Usage: Usage{TokensIn: 1500, TokensOut: 200, CostUSD: 1.25},
+	}}
+	want := "🏁 *Run finished* in 1h30m0s: 3 completed, 1 failed, 2 blocked (1.5k in / 200 out tokens, $1.25)\nStopped: interrupted"
+	if got := finished.text(slackMarkup); got != want {
+		t.Errorf("run finished text = %q, want %q", got, want)
+	}
+}
+
+func TestNotifierSendHidesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	n := Notifier{Type: NotifierSlack, URL: server.URL + "/services/T000/B000/s3cret"}
+	err := n.send(context.Background(), http.DefaultClient, Notification{Kind: NotifyRunFinished})
+	if err == nil {
+		t.Fatal("expected sending to a closed server to fail")
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("expected the webhook URL to be left out of the error, got %q", err)
+	}
+}
+
+func TestNotifyRunPostsToWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = append(bodies[r.URL.Path], string(body))
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			http.Error(w, "no such hook", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store := newMockTaskStore()
+	task := store.addTask("1", "task1", 1)
+	task.FeatureName = "feat"
+	summary := "Done"
+	task.CompletionSummary = &summary
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetNotifiers([]Notifier{
+		{Type: NotifierSlack, URL: server.URL + "/slack", Events: []string{NotifyRunFinished}},
+		{Type: NotifierWebhook, URL: server.URL + "/webhook"},
+		{Type: NotifierWebhook, URL: server.URL + "/broken", Events: []string{NotifyTaskBlocked}},
+	})
+
+	// Without a run under way there is nothing to announce.
+	o.notifyRunFinished("")
+
+	o.noteRunStart()
+	o.notifyTaskResult(task, true, Usage{TokensIn: 10, TokensOut: 5})
+	o.recordSessionRun("test-model", true, false)
+	o.notifyTaskResult(&models.Task{ID: "gone", Name: "other", FeatureName: "feat"}, false, Usage{})
+	o.recordSessionRun("test-model", false, false)
+	o.notifyRunFinished("")
+	o.notifyRunFinished("")
+	o.notify(Notification{Kind: NotifyTaskBlocked, Feature: "feat", Task: "task1"})
+	o.notifyWG.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := bodies["/slack"]; len(got) != 1 || !strings.Contains(got[0], "1 completed, 1 failed, 0 blocked") {
+		t.Errorf("expected one run finished message on slack, got %q", got)
+	}
+	hooks := bodies["/webhook"]
+	if len(hooks) != 3 {
+		t.Fatalf("expected a completion, a run finished and a blocked notification on the webhook, got %q", hooks)
+	}
+	var events []string
+	for _, body := range hooks {
+		var payload webhookPayload
+		if err := json.Unmarshal([]byte(body), &payload); err != nil {
+			t.Fatalf("failed to decode webhook payload %q: %v", body, err)
+		}
+		events = append(events, payload.Event)
+		if payload.Event == NotifyTaskCompleted && (payload.Task != "task1" || payload.Summary != "Done") {
+			t.Errorf("unexpected completion payload %+v", payload)
+		}
+	}
+	// Deliveries run concurrently, so they can arrive in any order.
+	slices.Sort(events)
+	if strings.Join(events, ",") != "run_finished,task_blocked,task_completed" {
+		t.Errorf("unexpected webhook events %v", events)
+	}
+
+	var failure bool
+	for len(o.msgChan) > 0 {
+		if msg, ok := (<-o.msgChan).(StatusMsg); ok && strings.Contains(msg.Message, "no such hook") {
+			failure = true
+		}
+	}
+	if !failure {
+		t.Error("expected the failed delivery to be reported")
+	}
+}
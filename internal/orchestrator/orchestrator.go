@@ -2,24 +2,53 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nick-dorsch/ponder/embed/prompts"
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/internal/telemetry"
 	"github.com/nick-dorsch/ponder/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TaskStore interface {
-	ClaimNextTask(ctx context.Context) (*models.Task, error)
+	ClaimNextTask(ctx context.Context, claimer models.Claimer, lease time.Duration) (*models.Task, error)
+	ClaimTask(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) (*models.Task, error)
+	GetAvailableTasks(ctx context.Context) ([]*models.Task, error)
+	PlanClaims(ctx context.Context) ([]*models.Task, error)
+	RenewClaim(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) error
 	UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, summary *string) error
+	BlockTask(ctx context.Context, id string, reason string) error
+	GetTask(ctx context.Context, id string) (*models.Task, error)
+	UpdateTask(ctx context.Context, t *models.Task) error
 	CountAvailableTasks(ctx context.Context) (int, error)
 	ResetInProgressTasks(ctx context.Context) error
-	DisableOnChange()
-	EnableOnChange()
+	RecordTaskUsage(ctx context.Context, u *models.TaskUsage) error
+	RecordRun(ctx context.Context, r *models.Run) error
+	RecordModelFallback(ctx context.Context, task *models.Task, from, to string, failures int) error
+	GetDependencies(ctx context.Context, taskID string) ([]*models.Task, error)
+	ListTasks(ctx context.Context, status *models.TaskStatus, featureName *string) ([]*models.Task, error)
+	ListDependencies(ctx context.Context) ([]*models.Dependency, error)
+	ResolveRunEnvironment(ctx context.Context, task *models.Task) (*models.RunEnvironment, error)
+	MaterializeDueTemplates(ctx context.Context, now time.Time) ([]*models.Task, error)
+	SetClaimExclusions(ids []string)
+	SaveTaskFailure(ctx context.Context, f *models.TaskFailure) error
+	ClearTaskFailure(ctx context.Context, taskID string) error
+	ListTaskFailures(ctx context.Context) ([]*models.TaskFailure, error)
+	WithBatchedChanges(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type workerInstance struct {
@@ -28,37 +57,106 @@ type workerInstance struct {
 	cancel   context.CancelFunc
 	done     chan struct{}
 	messages chan tea.Msg
+	usage    Usage
+	// model is set once the agent is started, under workersMu.
+	model     string
+	startedAt time.Time
+	// restart, set under workersMu, runs the task again once the worker
+	// has been stopped.
+	restart bool
+	// lastOutput is when the agent last wrote output, in Unix nanoseconds,
+	// or zero before it starts. stalled, set under workersMu, is whether
+	// that was longer ago than the stall window.
+	lastOutput atomic.Int64
+	stalled    bool
 }
 
 type failedTaskInfo struct {
 	taskID    string
 	failedAt  time.Time
 	failCount int
+	backoff   time.Duration
+	// modelFailures counts failures since the task last moved along the
+	// model fallback chain; fallbackModel is the model it moved to.
+	modelFailures int
+	fallbackModel string
 }
 
 // Orchestrator manages concurrent task processing.
 type Orchestrator struct {
-	store           TaskStore
+	store TaskStore
+	// maxWorkers and targetWorkers are guarded by targetWorkersMu.
 	maxWorkers      int
 	targetWorkers   int
 	model           string
 	availableModels []string
+	modelRouting    ModelRouting
+	modelFallback   ModelFallback
+	pricing         map[string]ModelPrice
 	modelMu         sync.RWMutex
 	workers         map[int]*workerInstance
 	workersMu       sync.RWMutex
 	targetWorkersMu sync.RWMutex
+	reportedTarget  int
 	cmdFactory      func(ctx context.Context, name string, arg ...string) *exec.Cmd
 	totalTasks      int
-	completedTasks  int
 	msgChan         chan tea.Msg
 	ctx             context.Context
 	cancel          context.CancelFunc
 	WebURL          string
 
 	// Failed task tracking with backoff
-	failedTasks     map[string]*failedTaskInfo
-	failedTasksMu   sync.RWMutex
-	backoffDuration time.Duration
+	failedTasks   map[string]*failedTaskInfo
+	failedTasksMu sync.RWMutex
+	retryPolicy   RetryPolicy
+
+	// Optional per-task git worktree isolation
+	worktrees *WorktreeManager
+
+	// Optional command that must pass before a task may stay completed
+	verification *Verification
+
+	// Optional limits on how long an agent may run on one task
+	timeouts TaskTimeouts
+	stall    StallDetection
+
+	// Optional niceness, memory and process limits for agent commands
+	limits ResourceLimits
+
+	// Optional container agent commands run in
+	sandbox *Sandbox
+
+	// Optional per-provider and per-model limits on agent launches
+	rateLimiter *rateLimiter
+
+	// Tasks the operator picked for the next free workers, in order
+	assigned []*models.Task
+
+	// Optional per-run files keeping the full agent output
+	runLogs RunLogs
+
+	// Claims are recorded under this process's identity and must be renewed
+	// within claimLease
+	hostname   string
+	pid        int
+	claimLease time.Duration
+
+	// The most recent messages, for views that missed them
+	history   *History
+	historyMu sync.RWMutex
+
+	// Token usage and cost of all runs this session, and the most it may
+	// spend before no new tasks are claimed
+	usage          Usage
+	budget         float64
+	budgetReported bool
+	usageMu        sync.Mutex
+
+	// How the task runs of this session went, since the orchestrator was
+	// created
+	session     SessionStats
+	sessionHook func()
+	sessionMu   sync.Mutex
 
 	// Spawn rate limiting
 	lastSpawnTime    time.Time
@@ -69,6 +167,26 @@ type Orchestrator struct {
 	PollingInterval time.Duration
 	isIdle          bool
 	idleMu          sync.Mutex
+
+	// While paused no new tasks are claimed; running workers finish normally
+	paused         bool
+	reportedPaused bool
+	pausedMu       sync.RWMutex
+
+	// Set when a failure means no task can run, such as a missing agent
+	stopErr error
+	stopMu  sync.Mutex
+
+	// Optional webhooks told about completed and blocked tasks and finished
+	// runs, with the stats of the run under way since runStart. The run's
+	// completed and failed tasks are the session's since runBase.
+	notifiers    []Notifier
+	notifyClient *http.Client
+	notifyWG     sync.WaitGroup
+	runStart     time.Time
+	runStats     RunStats
+	runBase      RunStats
+	notifyMu     sync.Mutex
 }
 
 func NewOrchestrator(store TaskStore, maxWorkers int, model string) *Orchestrator {
@@ -78,6 +196,7 @@ func NewOrchestrator(store TaskStore, maxWorkers int, model string) *Orchestrato
 	if model == "" {
 		model = "opencode/gemini-3-flash"
 	}
+	hostname, pid := processIdentity()
 	return &Orchestrator{
 		store:            store,
 		maxWorkers:       maxWorkers,
@@ -88,21 +207,43 @@ func NewOrchestrator(store TaskStore, maxWorkers int, model string) *Orchestrato
 		cmdFactory:       exec.CommandContext,
 		msgChan:          make(chan tea.Msg, 100),
 		failedTasks:      make(map[string]*failedTaskInfo),
-		backoffDuration:  30 * time.Second,
+		retryPolicy:      DefaultRetryPolicy(),
 		minSpawnInterval: 500 * time.Millisecond,
 		lastSpawnTime:    time.Time{},
 		PollingInterval:  0,
+		hostname:         hostname,
+		pid:              pid,
+		claimLease:       DefaultClaimLease,
+		history:          NewHistory(DefaultHistorySize, nil),
+		notifyClient:     &http.Client{Timeout: notifyTimeout},
+		session:          SessionStats{StartedAt: time.Now(), Models: map[string]int{}},
 	}
 }
 
-func (o *Orchestrator) Start(ctx context.Context) error {
+func (o *Orchestrator) Start(ctx context.Context) (err error) {
+	ctx = actor.With(ctx, "orchestrator")
 	if err := o.store.ResetInProgressTasks(ctx); err != nil {
 		o.sendMsg(StatusMsg{WorkerID: 0, Message: fmt.Sprintf("Error resetting in_progress tasks: %v", err)})
 	}
+	if err := o.loadTaskFailures(ctx); err != nil {
+		o.sendMsg(StatusMsg{WorkerID: 0, Message: fmt.Sprintf("Error loading task failures: %v", err)})
+	}
 
 	o.ctx, o.cancel = context.WithCancel(ctx)
 	defer o.cancel()
 	defer close(o.msgChan)
+	// Notifications report failures as messages, so they must be delivered
+	// before the channel closes.
+	defer o.notifyWG.Wait()
+	defer func() {
+		reason := "interrupted"
+		if err == nil {
+			reason = ""
+		} else if !errors.Is(err, context.Canceled) {
+			reason = err.Error()
+		}
+		o.notifyRunFinished(reason)
+	}()
 
 	spawnTicker := time.NewTicker(100 * time.Millisecond)
 	defer spawnTicker.Stop()
@@ -110,6 +251,10 @@ func (o *Orchestrator) Start(ctx context.Context) error {
 	cleanupTicker := time.NewTicker(30 * time.Second)
 	defer cleanupTicker.Stop()
 
+	templateTicker := time.NewTicker(templateInterval)
+	defer templateTicker.Stop()
+	o.materializeTemplates()
+
 	for {
 		select {
 		case <-o.ctx.Done():
@@ -117,9 +262,21 @@ func (o *Orchestrator) Start(ctx context.Context) error {
 			return o.ctx.Err()
 		case <-cleanupTicker.C:
 			o.cleanupFailedTasks()
+		case <-templateTicker.C:
+			o.materializeTemplates()
 		case <-spawnTicker.C:
+			if err := o.stopError(); err != nil {
+				o.stopAllWorkers()
+				return err
+			}
+			o.reportPaused()
+			o.reportTargetWorkers()
 			o.trySpawnWorkers()
 
+			if o.OverBudget() && o.allWorkersIdle() {
+				return o.budgetError()
+			}
+
 			idle := o.allWorkersIdle() && !o.hasMoreTasks()
 			o.setIdle(idle)
 
@@ -137,6 +294,53 @@ func (o *Orchestrator) setIdle(idle bool) {
 	if o.isIdle != idle {
 		o.isIdle = idle
 		o.sendMsg(IdleStateMsg{Idle: idle})
+		if idle {
+			o.notifyRunFinished("")
+		}
+	}
+}
+
+// Pause stops the orchestrator from claiming new tasks. Workers that are
+// already running are left to finish their tasks.
+func (o *Orchestrator) Pause() {
+	o.setPaused(true)
+}
+
+// Resume lets a paused orchestrator claim tasks again.
+func (o *Orchestrator) Resume() {
+	o.setPaused(false)
+}
+
+// TogglePause pauses a running orchestrator or resumes a paused one, and
+// reports whether it is now paused.
+func (o *Orchestrator) TogglePause() bool {
+	paused := !o.IsPaused()
+	o.setPaused(paused)
+	return paused
+}
+
+func (o *Orchestrator) IsPaused() bool {
+	o.pausedMu.RLock()
+	defer o.pausedMu.RUnlock()
+	return o.paused
+}
+
+func (o *Orchestrator) setPaused(paused bool) {
+	o.pausedMu.Lock()
+	defer o.pausedMu.Unlock()
+	o.paused = paused
+}
+
+// reportPaused announces a change of pause state. It runs on the main loop,
+// so Pause and Resume are safe to call once the message channel is closed.
+func (o *Orchestrator) reportPaused() {
+	o.pausedMu.Lock()
+	paused, changed := o.paused, o.paused != o.reportedPaused
+	o.reportedPaused = o.paused
+	o.pausedMu.Unlock()
+
+	if changed {
+		o.sendMsg(PauseStateMsg{Paused: paused})
 	}
 }
 
@@ -152,7 +356,7 @@ func (o *Orchestrator) Messages() <-chan tea.Msg {
 
 // trySpawnWorkers attempts to spawn new workers up to the concurrency limit.
 func (o *Orchestrator) trySpawnWorkers() {
-	if !o.canSpawn() {
+	if o.IsPaused() || o.checkBudget() || !o.canSpawn() {
 		return
 	}
 
@@ -165,10 +369,14 @@ func (o *Orchestrator) trySpawnWorkers() {
 		return
 	}
 
-	if activeWorkers >= o.maxWorkers {
+	maxWorkers := o.GetMaxWorkers()
+	if activeWorkers >= maxWorkers {
 		return
 	}
 
+	// Tasks whose backoff has run out become claimable again.
+	o.holdBackFailedTasks()
+
 	availableCtx, cancel := context.WithTimeout(o.ctx, 2*time.Second)
 	availableCount, err := o.store.CountAvailableTasks(availableCtx)
 	cancel()
@@ -178,6 +386,8 @@ func (o *Orchestrator) trySpawnWorkers() {
 		return
 	}
 
+	// Assigned tasks may be ones the claim filter passes over.
+	availableCount += len(o.AssignedTasks())
 	if availableCount == 0 {
 		return
 	}
@@ -186,8 +396,8 @@ func (o *Orchestrator) trySpawnWorkers() {
 	if workersToSpawn > targetWorkers-activeWorkers {
 		workersToSpawn = targetWorkers - activeWorkers
 	}
-	if workersToSpawn > o.maxWorkers-activeWorkers {
-		workersToSpawn = o.maxWorkers - activeWorkers
+	if workersToSpawn > maxWorkers-activeWorkers {
+		workersToSpawn = maxWorkers - activeWorkers
 	}
 
 	for i := 0; i < workersToSpawn; i++ {
@@ -195,30 +405,35 @@ func (o *Orchestrator) trySpawnWorkers() {
 			return
 		}
 
-		claimCtx, cancel := context.WithTimeout(o.ctx, 5*time.Second)
-		task, err := o.store.ClaimNextTask(claimCtx)
+		o.workersMu.RLock()
+		workerID := o.freeWorkerIDLocked()
+		o.workersMu.RUnlock()
+		if workerID == -1 {
+			return
+		}
+
+		// The task span runs from the claim until runWorker is done with it.
+		taskCtx, span := telemetry.Start(o.ctx, "orchestrator.task", trace.WithAttributes(attribute.Int("worker.id", workerID)))
+		claimCtx, cancel := context.WithTimeout(taskCtx, 5*time.Second)
+		task, _, err := o.claimTask(claimCtx, workerID)
 		cancel()
 
 		if err != nil {
+			telemetry.End(span, err)
 			o.sendMsg(StatusMsg{WorkerID: 0, Message: fmt.Sprintf("Error claiming task: %v", err)})
 			return
 		}
 
 		if task == nil {
+			span.End()
 			return
 		}
-
-		if o.isTaskInBackoff(task.ID) {
-			resetCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			o.store.UpdateTaskStatus(resetCtx, task.ID, models.TaskStatusPending, nil)
-			cancel()
-			continue
-		}
+		span.SetAttributes(attribute.String("task.id", task.ID), attribute.String("task.name", task.Name))
 
 		o.updateSpawnTime()
 
 		o.workersMu.Lock()
-		o.spawnWorkerLocked(task)
+		o.spawnWorkerLocked(taskCtx, task, workerID)
 		o.workersMu.Unlock()
 	}
 }
@@ -249,58 +464,189 @@ func (o *Orchestrator) isTaskInBackoff(taskID string) bool {
 		return false
 	}
 
-	return time.Since(info.failedAt) < o.backoffDuration
+	return time.Since(info.failedAt) < info.backoff
 }
 
-func (o *Orchestrator) recordTaskFailure(taskID string) {
+// holdBackFailedTasks keeps the tasks backing off after a failure out of
+// ClaimNextTask and the available count, rather than claiming them and
+// putting them back on every tick. Tasks the operator assigns are claimed
+// by ID, so their pick still overrides the backoff.
+func (o *Orchestrator) holdBackFailedTasks() {
 	o.failedTasksMu.Lock()
 	defer o.failedTasksMu.Unlock()
 
-	info, exists := o.failedTasks[taskID]
-	if exists {
-		info.failCount++
-		info.failedAt = time.Now()
-	} else {
-		o.failedTasks[taskID] = &failedTaskInfo{
-			taskID:    taskID,
-			failedAt:  time.Now(),
-			failCount: 1,
+	var ids []string
+	for id, info := range o.failedTasks {
+		if time.Since(info.failedAt) < info.backoff {
+			ids = append(ids, id)
 		}
 	}
+	sort.Strings(ids)
+	o.store.SetClaimExclusions(ids)
 }
 
-func (o *Orchestrator) cleanupFailedTasks() {
+// recordTaskFailure tracks a failed run and returns the total number of
+// failures recorded for the task and the number since it last moved along
+// the model fallback chain.
+func (o *Orchestrator) recordTaskFailure(taskID string) (failCount, modelFailures int) {
 	o.failedTasksMu.Lock()
 	defer o.failedTasksMu.Unlock()
 
-	now := time.Now()
-	for id, info := range o.failedTasks {
-		if now.Sub(info.failedAt) > o.backoffDuration*2 {
-			delete(o.failedTasks, id)
+	info, exists := o.failedTasks[taskID]
+	if !exists {
+		info = &failedTaskInfo{taskID: taskID}
+		o.failedTasks[taskID] = info
+	}
+	info.failCount++
+	info.modelFailures++
+	info.failedAt = time.Now()
+	info.backoff = o.retryPolicy.Backoff(info.failCount)
+
+	return info.failCount, info.modelFailures
+}
+
+func (o *Orchestrator) clearTaskFailures(taskID string) {
+	o.failedTasksMu.Lock()
+	_, failed := o.failedTasks[taskID]
+	delete(o.failedTasks, taskID)
+	o.failedTasksMu.Unlock()
+	if !failed {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.store.ClearTaskFailure(ctx, taskID); err != nil {
+		o.sendMsg(StatusMsg{Message: fmt.Sprintf("Failed to clear the failures of task %s: %v", taskID, err)})
+	}
+}
+
+// saveTaskFailures stores the failures of a task, so that a restart doesn't
+// give it a fresh set of attempts.
+func (o *Orchestrator) saveTaskFailures(ctx context.Context, workerID int, task *models.Task) {
+	o.failedTasksMu.RLock()
+	info, ok := o.failedTasks[task.ID]
+	var f models.TaskFailure
+	if ok {
+		f = models.TaskFailure{
+			TaskID:        task.ID,
+			FailCount:     info.failCount,
+			FailedAt:      info.failedAt,
+			ModelFailures: info.modelFailures,
+			FallbackModel: info.fallbackModel,
 		}
 	}
+	o.failedTasksMu.RUnlock()
+	if !ok {
+		return
+	}
+	if err := o.store.SaveTaskFailure(ctx, &f); err != nil {
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Failed to save the failures of %s: %v", task.Name, err),
+		})
+	}
 }
 
-func (o *Orchestrator) spawnWorkerLocked(task *models.Task) {
-	workerID := -1
-	for i := 1; i <= o.maxWorkers; i++ {
-		if _, busy := o.workers[i]; !busy {
-			workerID = i
-			break
+// loadTaskFailures picks up the failures stored by earlier runs, with the
+// backoff still counting from the last of them.
+func (o *Orchestrator) loadTaskFailures(ctx context.Context) error {
+	failures, err := o.store.ListTaskFailures(ctx)
+	if err != nil {
+		return err
+	}
+	policy := o.GetRetryPolicy()
+
+	o.failedTasksMu.Lock()
+	defer o.failedTasksMu.Unlock()
+	for _, f := range failures {
+		o.failedTasks[f.TaskID] = &failedTaskInfo{
+			taskID:        f.TaskID,
+			failedAt:      f.FailedAt,
+			failCount:     f.FailCount,
+			backoff:       policy.Backoff(f.FailCount),
+			modelFailures: f.ModelFailures,
+			fallbackModel: f.FallbackModel,
 		}
 	}
+	return nil
+}
 
-	if workerID == -1 {
-		return
+// cleanupFailedTasks forgets the failures of tasks that won't be retried:
+// ones that were deleted, completed, cancelled or blocked since. The
+// failures of a task still pending or running are kept however long ago
+// they were, or a task whose runs outlast the backoff would start counting
+// again and never reach the retry policy's limit.
+func (o *Orchestrator) cleanupFailedTasks() {
+	o.failedTasksMu.RLock()
+	ids := make([]string, 0, len(o.failedTasks))
+	for id := range o.failedTasks {
+		ids = append(ids, id)
+	}
+	o.failedTasksMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, id := range ids {
+		task, err := o.store.GetTask(ctx, id)
+		if err != nil {
+			continue
+		}
+		if task == nil || (task.Status != models.TaskStatusPending && task.Status != models.TaskStatusInProgress) {
+			o.clearTaskFailures(id)
+		}
+	}
+}
+
+// GetRetryPolicy returns the policy applied to failed tasks.
+func (o *Orchestrator) GetRetryPolicy() RetryPolicy {
+	o.failedTasksMu.RLock()
+	defer o.failedTasksMu.RUnlock()
+	return o.retryPolicy
+}
+
+// SetRetryPolicy replaces the policy applied to failed tasks.
+func (o *Orchestrator) SetRetryPolicy(policy RetryPolicy) {
+	o.failedTasksMu.Lock()
+	defer o.failedTasksMu.Unlock()
+	o.retryPolicy = policy
+}
+
+// GetWorktreeManager returns the worktree manager, or nil when workers share
+// the current checkout.
+func (o *Orchestrator) GetWorktreeManager() *WorktreeManager {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.worktrees
+}
+
+// SetWorktreeManager enables per-task worktree isolation. Pass nil to disable.
+func (o *Orchestrator) SetWorktreeManager(m *WorktreeManager) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.worktrees = m
+}
+
+// freeWorkerIDLocked returns the lowest worker ID not in use, or -1 when all
+// workers are busy.
+func (o *Orchestrator) freeWorkerIDLocked() int {
+	for i := 1; i <= o.GetMaxWorkers(); i++ {
+		if _, busy := o.workers[i]; !busy {
+			return i
+		}
 	}
+	return -1
+}
 
-	workerCtx, cancel := context.WithCancel(o.ctx)
+func (o *Orchestrator) spawnWorkerLocked(ctx context.Context, task *models.Task, workerID int) {
+	workerCtx, cancel := context.WithCancel(ctx)
 	worker := &workerInstance{
-		id:       workerID,
-		task:     task,
-		cancel:   cancel,
-		done:     make(chan struct{}),
-		messages: make(chan tea.Msg, 50),
+		id:        workerID,
+		task:      task,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		messages:  make(chan tea.Msg, 50),
+		startedAt: time.Now(),
 	}
 
 	o.workers[workerID] = worker
@@ -314,60 +660,359 @@ func (o *Orchestrator) spawnWorkerLocked(task *models.Task) {
 	go o.runWorker(workerCtx, worker)
 }
 
+// runWorker runs the worker's task and ends the task span in ctx.
 func (o *Orchestrator) runWorker(ctx context.Context, worker *workerInstance) {
 	defer close(worker.done)
 
 	task := worker.task
+	span := trace.SpanFromContext(ctx)
+
+	claimCtx, stopClaim := context.WithCancel(ctx)
+	go o.keepClaim(claimCtx, worker)
+	defer stopClaim()
 
 	o.sendMsg(TaskStartedMsg{
 		WorkerID: worker.id,
 		TaskName: task.Name,
 	})
+	o.noteRunStart()
 
-	prompt := o.constructPrompt(task)
-	cmd := o.cmdFactory(ctx, "opencode", "run", "--model", o.GetModel())
-	cmd.Stdin = strings.NewReader(prompt)
-
-	output := &outputCapture{
-		orchestrator: o,
-		workerID:     worker.id,
-	}
-	cmd.Stdout = output
-	cmd.Stderr = output
-
-	err := cmd.Run()
+	branch, err := o.executeTask(ctx, worker)
 	success := err == nil
+	defer telemetry.End(span, err)
+
+	var fallback string
+	var class models.FailureClass
 
 	if err != nil {
 		o.sendMsg(OutputMsg{
 			WorkerID: worker.id,
 			Output:   fmt.Sprintf("\n--- Error: %v ---\n", err),
+			Stderr:   true,
 		})
 
-		o.recordTaskFailure(task.ID)
+		var verr *VerificationError
+		if errors.As(err, &verr) {
+			o.sendMsg(OutputMsg{WorkerID: worker.id, Output: verr.Output, Stderr: true})
 
-		resetCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if resetErr := o.store.UpdateTaskStatus(resetCtx, task.ID, models.TaskStatusPending, nil); resetErr != nil {
+			verifyCtx, cancel := context.WithTimeout(actor.With(context.Background(), fmt.Sprintf("orchestrator:worker-%d", worker.id)), 5*time.Second)
+			if err := o.recordVerificationFailure(verifyCtx, task.ID, verr); err != nil {
+				o.sendMsg(StatusMsg{
+					WorkerID: worker.id,
+					Message:  fmt.Sprintf("Failed to record verification failure for %s: %v", task.Name, err),
+				})
+			}
+			cancel()
+		}
+
+		var terr *TaskTimeoutError
+		if errors.As(err, &terr) {
 			o.sendMsg(StatusMsg{
 				WorkerID: worker.id,
-				Message:  fmt.Sprintf("Failed to reset task %s: %v", task.Name, resetErr),
+				Message:  fmt.Sprintf("Killed agent for %s after %s", task.Name, terr.Limit),
 			})
 		}
-		cancel()
+		var serr *StallError
+		if errors.As(err, &serr) {
+			o.sendMsg(StatusMsg{
+				WorkerID: worker.id,
+				Message:  fmt.Sprintf("Killed agent for %s after %s without output", task.Name, serr.Window),
+			})
+		}
+
+		class = classifyFailure(ctx, err)
+		if !o.handleClassifiedFailure(worker.id, task, class, err) {
+			fallback = o.handleTaskFailure(worker.id, task, worker.model, err)
+		}
+	} else {
+		o.clearTaskFailures(task.ID)
+	}
+
+	o.workersMu.RLock()
+	restart := worker.restart
+	o.workersMu.RUnlock()
+	if restart && !success {
+		// The task is pending again by now, so it can be claimed.
+		o.AssignTask(task)
 	}
 
+	o.notifyTaskResult(task, success, worker.usage)
+	o.recordSessionRun(worker.model, success, class == models.FailureCancelled)
+
 	o.sendMsg(TaskCompletedMsg{
-		WorkerID: worker.id,
-		TaskName: task.Name,
-		Success:  success,
+		WorkerID:      worker.id,
+		TaskName:      task.Name,
+		Success:       success,
+		Branch:        branch,
+		Usage:         worker.usage,
+		Model:         worker.model,
+		FallbackModel: fallback,
 	})
 
 	o.workersMu.Lock()
 	delete(o.workers, worker.id)
-	if success {
-		o.completedTasks++
+	o.workersMu.Unlock()
+}
+
+// executeTask runs the agent for the worker's task, inside a dedicated git
+// worktree when worktree mode is enabled. It returns the task branch, if any.
+func (o *Orchestrator) executeTask(ctx context.Context, worker *workerInstance) (branch string, err error) {
+	task := worker.task
+	worktrees := o.GetWorktreeManager()
+
+	var wt *Worktree
+	if worktrees != nil {
+		wtCtx, span := telemetry.Start(ctx, "orchestrator.worktree")
+		var err error
+		wt, err = worktrees.Create(wtCtx, task)
+		telemetry.End(span, err)
+		if err != nil {
+			return "", err
+		}
+		o.sendMsg(StatusMsg{
+			WorkerID: worker.id,
+			Message:  fmt.Sprintf("Working on branch %s in %s", wt.Branch, wt.Path),
+		})
+
+		defer func() {
+			// Use a fresh context so the worktree is cleaned up after cancellation.
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := worktrees.Remove(cleanupCtx, wt, task); err != nil {
+				o.sendMsg(StatusMsg{
+					WorkerID: worker.id,
+					Message:  fmt.Sprintf("Failed to clean up worktree: %v", err),
+				})
+			}
+		}()
 	}
+
+	prompt := o.constructPrompt(ctx, task)
+	model := o.modelFor(task)
+	if fallback := o.fallbackModel(task.ID); fallback != "" {
+		model = fallback
+		o.sendMsg(StatusMsg{
+			WorkerID: worker.id,
+			Message:  fmt.Sprintf("Retrying %s with fallback model %s", task.Name, model),
+		})
+	} else if model != o.GetModel() {
+		o.sendMsg(StatusMsg{
+			WorkerID: worker.id,
+			Message:  fmt.Sprintf("Routing %s (priority %d) to %s", task.Name, task.Priority, model),
+		})
+	}
+	o.workersMu.Lock()
+	worker.model = model
 	o.workersMu.Unlock()
+	root := ""
+	var env []string
+	if wt != nil {
+		root = wt.Path
+		env = append(env, worktrees.Env...)
+	}
+	runEnv, err := o.store.ResolveRunEnvironment(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	agentDir := root
+	if runEnv.WorkingDir != "" {
+		agentDir = filepath.Join(root, filepath.FromSlash(runEnv.WorkingDir))
+		if info, err := os.Stat(agentDir); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("working directory %s of task %s does not exist", runEnv.WorkingDir, task.Name)
+		}
+		o.sendMsg(StatusMsg{
+			WorkerID: worker.id,
+			Message:  fmt.Sprintf("Running agent in %s", runEnv.WorkingDir),
+		})
+	}
+	env = append(env, environ(runEnv.Env)...)
+
+	// Waiting for the rate limit doesn't count towards the task's time limit.
+	if err := o.waitForRateLimit(ctx, worker.id, model); err != nil {
+		return "", err
+	}
+	runCtx := ctx
+	limit := o.GetTaskTimeouts().For(task)
+	if limit > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+	runCtx, stopRun := context.WithCancelCause(runCtx)
+	defer stopRun(nil)
+
+	runCtx, span := telemetry.Start(runCtx, "orchestrator.agent", trace.WithAttributes(attribute.String("agent.model", model)))
+	// The agent's ponder mcp process joins the trace through TRACEPARENT.
+	env = append(env, telemetry.Env(runCtx)...)
+
+	cmd, err := o.command(runCtx, worker.id, agentDir, env, "opencode", "run", "--model", model)
+	if err != nil {
+		telemetry.End(span, err)
+		return "", err
+	}
+	cmd.Stdin = strings.NewReader(prompt)
+
+	var output io.Writer = &outputCapture{
+		orchestrator: o,
+		worker:       worker,
+	}
+	var errOutput io.Writer = &outputCapture{
+		orchestrator: o,
+		worker:       worker,
+		stderr:       true,
+	}
+	if runLog := o.openRunLog(worker.id, task, model); runLog != nil {
+		defer runLog.Close()
+		output = io.MultiWriter(output, runLog)
+		errOutput = io.MultiWriter(errOutput, runLog)
+		defer func() { writeRunLogFooter(runLog, err) }()
+	}
+	meter := &usageMeter{}
+	transcript := &transcript{}
+	cmd.Stdout = io.MultiWriter(output, meter, transcript)
+	cmd.Stderr = io.MultiWriter(errOutput, transcript)
+	// Don't let a child that inherited the output pipes keep a killed run alive.
+	cmd.WaitDelay = 5 * time.Second
+
+	startedAt := time.Now()
+	var runErr error
+	var duration time.Duration
+	defer func() {
+		run := &models.Run{
+			Model:      model,
+			Prompt:     prompt,
+			Output:     transcript.String(),
+			ExitCode:   exitCode(runErr),
+			DurationMS: duration.Milliseconds(),
+			StartedAt:  startedAt,
+		}
+		if err != nil {
+			run.Error = err.Error()
+			run.FailureClass = classifyFailure(ctx, err)
+		}
+		o.recordRun(worker.id, task, run)
+	}()
+
+	// Starting the agent counts as its first sign of life.
+	worker.heartbeat()
+	if stall := o.GetStallDetection(); stall.Window > 0 {
+		go o.watchStall(runCtx, worker, stall, stopRun)
+	}
+
+	runErr = cmd.Run()
+	stopRun(nil)
+	duration = time.Since(startedAt)
+	// Failed runs still cost money, so usage is recorded either way.
+	usage, costReported := meter.Result()
+	worker.usage = o.recordUsage(worker.id, task, model, usage, costReported)
+	span.SetAttributes(
+		attribute.Int64("agent.tokens_in", usage.TokensIn),
+		attribute.Int64("agent.tokens_out", usage.TokensOut),
+	)
+	telemetry.End(span, runErr)
+	if runErr != nil {
+		if ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return "", &TaskTimeoutError{Limit: limit}
+		}
+		var serr *StallError
+		if ctx.Err() == nil && errors.As(context.Cause(runCtx), &serr) {
+			return "", serr
+		}
+		return "", runErr
+	}
+
+	if err := o.runVerification(ctx, worker.id, root); err != nil {
+		return "", err
+	}
+
+	if wt != nil {
+		return wt.Branch, nil
+	}
+	return "", nil
+}
+
+// environ turns variables into KEY=value entries, sorted so runs are
+// reproducible.
+func environ(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// handleTaskFailure resets a failed task to pending so it can be retried, or
+// blocks it with a failure summary once the retry policy is exhausted. A task
+// that has failed often enough with model moves on to the next model of the
+// fallback chain instead of being blocked; that model is returned.
+func (o *Orchestrator) handleTaskFailure(workerID int, task *models.Task, model string, runErr error) (fallback string) {
+	failCount, modelFailures := o.recordTaskFailure(task.ID)
+	o.holdBackFailedTasks()
+	policy := o.GetRetryPolicy()
+
+	resetCtx, cancel := context.WithTimeout(actor.With(context.Background(), fmt.Sprintf("orchestrator:worker-%d", workerID)), 5*time.Second)
+	defer cancel()
+
+	if model != "" {
+		fallback = o.fallbackFor(model, modelFailures)
+	}
+	if fallback != "" {
+		o.setFallbackModel(task.ID, fallback)
+		if err := o.store.RecordModelFallback(resetCtx, task, model, fallback, modelFailures); err != nil {
+			o.sendMsg(StatusMsg{
+				WorkerID: workerID,
+				Message:  fmt.Sprintf("Failed to record model fallback for %s: %v", task.Name, err),
+			})
+		}
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Task %s failed %d times with %s, falling back to %s", task.Name, modelFailures, model, fallback),
+		})
+	}
+
+	if fallback != "" || !policy.Exhausted(modelFailures) {
+		o.saveTaskFailures(resetCtx, workerID, task)
+		o.resetTask(workerID, task)
+		return
+	}
+
+	o.blockTask(workerID, task,
+		fmt.Sprintf("Blocked by orchestrator after %d failed attempts. Last error: %v", failCount, runErr),
+		fmt.Sprintf("Task %s blocked after %d failed attempts", task.Name, failCount))
+	return ""
+}
+
+// resetTask puts a task whose run failed back to pending.
+func (o *Orchestrator) resetTask(workerID int, task *models.Task) {
+	ctx, cancel := context.WithTimeout(actor.With(context.Background(), fmt.Sprintf("orchestrator:worker-%d", workerID)), 5*time.Second)
+	defer cancel()
+	if err := o.store.UpdateTaskStatus(ctx, task.ID, models.TaskStatusPending, nil); err != nil {
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Failed to reset task %s: %v", task.Name, err),
+		})
+	}
+}
+
+// blockTask blocks a task whose run failed, with reason, forgets its
+// failures and reports message.
+func (o *Orchestrator) blockTask(workerID int, task *models.Task, reason, message string) {
+	ctx, cancel := context.WithTimeout(actor.With(context.Background(), fmt.Sprintf("orchestrator:worker-%d", workerID)), 5*time.Second)
+	defer cancel()
+	if err := o.store.BlockTask(ctx, task.ID, reason); err != nil {
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Failed to block task %s: %v", task.Name, err),
+		})
+		return
+	}
+
+	o.clearTaskFailures(task.ID)
+	o.sendMsg(StatusMsg{
+		WorkerID: workerID,
+		Message:  message,
+	})
 }
 
 func (o *Orchestrator) stopAllWorkers() {
@@ -408,17 +1053,18 @@ func (o *Orchestrator) stopAllWorkers() {
 	case <-timeoutCtx.Done():
 	}
 
-	o.store.DisableOnChange()
-	defer o.store.EnableOnChange()
-
 	o.workersMu.Lock()
 	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cleanupCancel()
 
-	for id, worker := range o.workers {
-		_ = o.store.UpdateTaskStatus(cleanupCtx, worker.task.ID, models.TaskStatusPending, nil)
-		delete(o.workers, id)
-	}
+	// Reset the interrupted tasks with a single change notification.
+	_ = o.store.WithBatchedChanges(cleanupCtx, func(ctx context.Context) error {
+		for id, worker := range o.workers {
+			_ = o.store.UpdateTaskStatus(ctx, worker.task.ID, models.TaskStatusPending, nil)
+			delete(o.workers, id)
+		}
+		return nil
+	})
 	o.workersMu.Unlock()
 }
 
@@ -453,8 +1099,9 @@ func (o *Orchestrator) GetActiveWorkers() map[int]*workerInstance {
 
 func (o *Orchestrator) GetStats() (total, completed int) {
 	o.workersMu.RLock()
-	defer o.workersMu.RUnlock()
-	return o.totalTasks, o.completedTasks
+	total = o.totalTasks
+	o.workersMu.RUnlock()
+	return total, o.GetSessionStats().Completed
 }
 
 func (o *Orchestrator) GetTargetWorkers() int {
@@ -517,12 +1164,9 @@ func (o *Orchestrator) SetTargetWorkers(target int) {
 	if target < 0 {
 		target = 0
 	}
-	if target > o.maxWorkers {
-		target = o.maxWorkers
-	}
 
 	o.targetWorkersMu.Lock()
-	o.targetWorkers = target
+	o.targetWorkers = min(target, o.maxWorkers)
 	o.targetWorkersMu.Unlock()
 }
 
@@ -557,62 +1201,132 @@ func (o *Orchestrator) DecreaseWorkersIfIdle() bool {
 }
 
 func (o *Orchestrator) sendMsg(msg tea.Msg) {
+	if h := o.History(); h != nil {
+		msg = h.Record(msg)
+	}
 	select {
 	case o.msgChan <- msg:
 	case <-time.After(100 * time.Millisecond):
 	}
 }
 
-func (o *Orchestrator) constructPrompt(task *models.Task) string {
+func (o *Orchestrator) constructPrompt(ctx context.Context, task *models.Task) string {
 	var sb strings.Builder
 	sb.WriteString(prompts.Header)
 	sb.WriteString("\n\n")
 	sb.WriteString(fmt.Sprintf("# Feature: %s\n# Task: %s\n\n", task.FeatureName, task.Name))
 	sb.WriteString(fmt.Sprintf("## Description\n%s\n\n", task.Description))
 	sb.WriteString(fmt.Sprintf("## Specification\n%s\n\n", task.Specification))
+	writeCompletedDependencies(&sb, o.completedDependencies(ctx, task))
 	sb.WriteString(prompts.Footer)
 	return sb.String()
 }
 
+// completedDependencies returns the task's completed dependencies. Their
+// summaries only add context, so a failed lookup leaves them out rather than
+// failing the task.
+func (o *Orchestrator) completedDependencies(ctx context.Context, task *models.Task) []*models.Task {
+	if o.store == nil {
+		return nil
+	}
+	deps, err := o.store.GetDependencies(ctx, task.ID)
+	if err != nil {
+		return nil
+	}
+	var completed []*models.Task
+	for _, dep := range deps {
+		if dep.Status == models.TaskStatusCompleted {
+			completed = append(completed, dep)
+		}
+	}
+	return completed
+}
+
+// writeCompletedDependencies tells the agent what the tasks this one depends
+// on already did, so it builds on their work instead of rediscovering it.
+func writeCompletedDependencies(sb *strings.Builder, deps []*models.Task) {
+	if len(deps) == 0 {
+		return
+	}
+	sb.WriteString("## Completed Dependencies\n")
+	sb.WriteString("These tasks were finished before this one. Build on their work rather than redoing it.\n\n")
+	for _, dep := range deps {
+		summary := "(no summary recorded)"
+		if dep.CompletionSummary != nil && *dep.CompletionSummary != "" {
+			summary = *dep.CompletionSummary
+		}
+		sb.WriteString(fmt.Sprintf("### %s/%s\n%s\n\n", dep.FeatureName, dep.Name, summary))
+	}
+}
+
 type outputCapture struct {
 	orchestrator *Orchestrator
-	workerID     int
+	worker       *workerInstance
+	stderr       bool
 }
 
 func (o *outputCapture) Write(p []byte) (n int, err error) {
+	o.worker.heartbeat()
 	o.orchestrator.sendMsg(OutputMsg{
-		WorkerID: o.workerID,
+		WorkerID: o.worker.id,
 		Output:   string(p),
+		Stderr:   o.stderr,
 	})
 	return len(p), nil
 }
 
+// The Seq of worker messages is their number in the orchestrator's History,
+// set when they are sent.
+
 type WorkerStartedMsg struct {
 	WorkerID int
 	Task     *models.Task
+	Seq      uint64
 }
 
 type TaskStartedMsg struct {
 	WorkerID int
 	TaskName string
+	Seq      uint64
 }
 
 type OutputMsg struct {
 	WorkerID int
 	Output   string
+	Stderr   bool // written to stderr, or an error reported by the orchestrator
+	Seq      uint64
 }
 
 type StatusMsg struct {
 	WorkerID int
 	Message  string
+	Seq      uint64
 }
 
 type TaskCompletedMsg struct {
 	WorkerID int
 	TaskName string
 	Success  bool
+	Branch   string // set when the task ran in its own worktree
+	Usage    Usage  // tokens and cost parsed from the agent output
+	Model    string // the model the agent ran with
+	// FallbackModel is set when a failed task will be retried with the next
+	// model of the fallback chain.
+	FallbackModel string
+	Seq           uint64
+}
+
+// TargetWorkersMsg is sent when the number of workers to run changes, so the
+// TUI follows changes made through the web API.
+type TargetWorkersMsg struct {
+	Target int
 }
 
 type IdleStateMsg struct {
 	Idle bool
 }
+
+// PauseStateMsg is sent when the orchestrator is paused or resumed.
+type PauseStateMsg struct {
+	Paused bool
+}
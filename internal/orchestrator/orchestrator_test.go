@@ -2,7 +2,9 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -13,41 +15,66 @@ import (
 )
 
 type mockTaskStore struct {
-	mu            sync.Mutex
-	tasks         []*models.Task
-	claimed       map[string]bool
-	statusUpdates []statusUpdate
-	errors        map[string]error
-	nextTaskIndex int
-
-	onChangeDisabled bool
-	disableCalled    bool
-	enableCalled     bool
+	mu      sync.Mutex
+	tasks   []*models.Task
+	claimed map[string]bool
+	// claimedOutOfTurn are the tasks claimed by ClaimTask.
+	claimedOutOfTurn map[string]bool
+	statusUpdates    []statusUpdate
+	errors           map[string]error
+	nextTaskIndex    int
+	usage            []*models.TaskUsage
+	runs             []*models.Run
+	fallbacks        []string
+	dependencies     map[string][]*models.Task
+	environments     map[string]*models.RunEnvironment
+	// recurring are added to tasks by the next MaterializeDueTemplates.
+	recurring []*models.Task
+	// batches counts the calls to WithBatchedChanges.
+	batches int
+	// excluded are the tasks SetClaimExclusions holds back.
+	excluded []string
+	failures map[string]models.TaskFailure
 }
 
 type statusUpdate struct {
-	id     string
-	status models.TaskStatus
+	id      string
+	status  models.TaskStatus
+	summary *string
 }
 
 func newMockTaskStore() *mockTaskStore {
 	return &mockTaskStore{
-		claimed:       make(map[string]bool),
-		statusUpdates: make([]statusUpdate, 0),
-		errors:        make(map[string]error),
+		claimed:          make(map[string]bool),
+		claimedOutOfTurn: make(map[string]bool),
+		statusUpdates:    make([]statusUpdate, 0),
+		errors:           make(map[string]error),
 	}
 }
 
-func (m *mockTaskStore) ClaimNextTask(ctx context.Context) (*models.Task, error) {
+func (m *mockTaskStore) ClaimNextTask(ctx context.Context, claimer models.Claimer, lease time.Duration) (*models.Task, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if m.nextTaskIndex >= len(m.tasks) {
+	// Skip tasks claimed out of turn by ClaimTask.
+	for m.nextTaskIndex < len(m.tasks) && m.claimedOutOfTurn[m.tasks[m.nextTaskIndex].ID] {
+		m.nextTaskIndex++
+	}
+	// Pass over excluded tasks without losing their turn.
+	next := m.nextTaskIndex
+	for next < len(m.tasks) && (m.claimedOutOfTurn[m.tasks[next].ID] || slices.Contains(m.excluded, m.tasks[next].ID)) {
+		next++
+	}
+	if next >= len(m.tasks) {
 		return nil, nil
 	}
 
-	task := m.tasks[m.nextTaskIndex]
-	m.nextTaskIndex++
+	task := m.tasks[next]
+	if next == m.nextTaskIndex {
+		m.nextTaskIndex++
+	} else {
+		m.claimedOutOfTurn[task.ID] = true
+	}
 	m.claimed[task.ID] = true
 
 	task.Status = models.TaskStatusInProgress
@@ -56,6 +83,53 @@ func (m *mockTaskStore) ClaimNextTask(ctx context.Context) (*models.Task, error)
 	return task, nil
 }
 
+func (m *mockTaskStore) ClaimTask(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) (*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, task := range m.tasks {
+		if task.ID != taskID || task.Status != models.TaskStatusPending || m.claimed[task.ID] {
+			continue
+		}
+		m.claimed[task.ID] = true
+		m.claimedOutOfTurn[task.ID] = true
+		task.Status = models.TaskStatusInProgress
+		m.statusUpdates = append(m.statusUpdates, statusUpdate{id: task.ID, status: models.TaskStatusInProgress})
+		return task, nil
+	}
+	return nil, nil
+}
+
+func (m *mockTaskStore) GetAvailableTasks(ctx context.Context) ([]*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var available []*models.Task
+	for _, task := range m.tasks {
+		if task.Status == models.TaskStatusPending {
+			available = append(available, task)
+		}
+	}
+	return available, nil
+}
+
+func (m *mockTaskStore) PlanClaims(ctx context.Context) ([]*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var plan []*models.Task
+	for _, task := range m.tasks[m.nextTaskIndex:] {
+		if task.Status == models.TaskStatusPending {
+			plan = append(plan, task)
+		}
+	}
+	return plan, nil
+}
+
+func (m *mockTaskStore) RenewClaim(ctx context.Context, taskID string, claimer models.Claimer, lease time.Duration) error {
+	return nil
+}
+
 func (m *mockTaskStore) UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, summary *string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -64,7 +138,7 @@ func (m *mockTaskStore) UpdateTaskStatus(ctx context.Context, id string, status
 		return err
 	}
 
-	m.statusUpdates = append(m.statusUpdates, statusUpdate{id: id, status: status})
+	m.statusUpdates = append(m.statusUpdates, statusUpdate{id: id, status: status, summary: summary})
 
 	for _, task := range m.tasks {
 		if task.ID == id {
@@ -76,19 +150,94 @@ func (m *mockTaskStore) UpdateTaskStatus(ctx context.Context, id string, status
 	return nil
 }
 
+func (m *mockTaskStore) BlockTask(ctx context.Context, id string, reason string) error {
+	if err := m.UpdateTaskStatus(ctx, id, models.TaskStatusBlocked, &reason); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, task := range m.tasks {
+		if task.ID == id {
+			task.BlockedReason = &reason
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockTaskStore) GetTask(ctx context.Context, id string) (*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, task := range m.tasks {
+		if task.ID == id {
+			copied := *task
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockTaskStore) UpdateTask(ctx context.Context, t *models.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, task := range m.tasks {
+		if task.ID == t.ID {
+			copied := *t
+			m.tasks[i] = &copied
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
 func (m *mockTaskStore) CountAvailableTasks(ctx context.Context) (int, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	count := 0
 	for _, task := range m.tasks {
-		if task.Status == models.TaskStatusPending {
+		if task.Status == models.TaskStatusPending && !slices.Contains(m.excluded, task.ID) {
 			count++
 		}
 	}
 	return count, nil
 }
 
+func (m *mockTaskStore) SaveTaskFailure(ctx context.Context, f *models.TaskFailure) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures == nil {
+		m.failures = make(map[string]models.TaskFailure)
+	}
+	m.failures[f.TaskID] = *f
+	return nil
+}
+
+func (m *mockTaskStore) ClearTaskFailure(ctx context.Context, taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.failures, taskID)
+	return nil
+}
+
+func (m *mockTaskStore) ListTaskFailures(ctx context.Context) ([]*models.TaskFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var failures []*models.TaskFailure
+	for _, f := range m.failures {
+		failures = append(failures, &f)
+	}
+	return failures, nil
+}
+
+func (m *mockTaskStore) SetClaimExclusions(ids []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.excluded = ids
+}
+
 func (m *mockTaskStore) ResetInProgressTasks(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -101,18 +250,74 @@ func (m *mockTaskStore) ResetInProgressTasks(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockTaskStore) DisableOnChange() {
+func (m *mockTaskStore) RecordTaskUsage(ctx context.Context, u *models.TaskUsage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage = append(m.usage, u)
+	return nil
+}
+
+func (m *mockTaskStore) RecordRun(ctx context.Context, r *models.Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, r)
+	return nil
+}
+
+func (m *mockTaskStore) RecordModelFallback(ctx context.Context, task *models.Task, from, to string, failures int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallbacks = append(m.fallbacks, from+"->"+to)
+	return nil
+}
+
+func (m *mockTaskStore) GetDependencies(ctx context.Context, taskID string) ([]*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dependencies[taskID], nil
+}
+
+func (m *mockTaskStore) ListTasks(ctx context.Context, status *models.TaskStatus, featureName *string) ([]*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Task(nil), m.tasks...), nil
+}
+
+func (m *mockTaskStore) ListDependencies(ctx context.Context) ([]*models.Dependency, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deps []*models.Dependency
+	for taskID, prereqs := range m.dependencies {
+		for _, p := range prereqs {
+			deps = append(deps, &models.Dependency{TaskID: taskID, DependsOnTaskID: p.ID})
+		}
+	}
+	return deps, nil
+}
+
+func (m *mockTaskStore) ResolveRunEnvironment(ctx context.Context, task *models.Task) (*models.RunEnvironment, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.onChangeDisabled = true
-	m.disableCalled = true
+	if e, ok := m.environments[task.ID]; ok {
+		return e, nil
+	}
+	return &models.RunEnvironment{}, nil
 }
 
-func (m *mockTaskStore) EnableOnChange() {
+func (m *mockTaskStore) MaterializeDueTemplates(ctx context.Context, now time.Time) ([]*models.Task, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.onChangeDisabled = false
-	m.enableCalled = true
+	created := m.recurring
+	m.tasks = append(m.tasks, created...)
+	m.recurring = nil
+	return created, nil
+}
+
+func (m *mockTaskStore) WithBatchedChanges(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.mu.Lock()
+	m.batches++
+	m.mu.Unlock()
+	return fn(ctx)
 }
 
 func (m *mockTaskStore) addTask(id, name string, priority int) *models.Task {
@@ -174,6 +379,44 @@ func TestOrchestrator_SingleWorker(t *testing.T) {
 	}
 }
 
+func TestOrchestrator_RunsRecurringTasks(t *testing.T) {
+	store := newMockTaskStore()
+	store.recurring = []*models.Task{{
+		ID: "1", FeatureName: "chores", Name: "update-deps-2026-10-16",
+		Description: "d", Specification: "s", Status: models.TaskStatusPending,
+	}}
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "true")
+	}
+
+	var created bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range o.Messages() {
+			if s, ok := msg.(StatusMsg); ok && strings.Contains(s.Message, "chores/update-deps-2026-10-16") {
+				created = true
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.Start(ctx); err != nil && err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-done
+
+	if !created {
+		t.Error("expected the recurring task to be reported")
+	}
+	if !store.claimed["1"] {
+		t.Error("expected the recurring task to be run")
+	}
+}
+
 func TestOrchestrator_MultipleWorkers(t *testing.T) {
 	store := newMockTaskStore()
 	store.addTask("1", "task1", 3)
@@ -274,6 +517,239 @@ func TestOrchestrator_TaskFailureReset(t *testing.T) {
 	}
 }
 
+func TestOrchestrator_BlocksTaskAfterMaxAttempts(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "flaky", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+		Multiplier:     1,
+	})
+
+	o.handleTaskFailure(1, task, "test-model", errors.New("exit status 1"))
+	if task.Status != models.TaskStatusPending {
+		t.Fatalf("expected task to be pending after first failure, got %s", task.Status)
+	}
+	if !o.isTaskInBackoff(task.ID) {
+		t.Error("expected task to be in backoff after first failure")
+	}
+
+	o.handleTaskFailure(1, task, "test-model", errors.New("exit status 1"))
+	if task.Status != models.TaskStatusBlocked {
+		t.Fatalf("expected task to be blocked after max attempts, got %s", task.Status)
+	}
+
+	store.mu.Lock()
+	last := store.statusUpdates[len(store.statusUpdates)-1]
+	store.mu.Unlock()
+	if last.summary == nil || !strings.Contains(*last.summary, "2 failed attempts") {
+		t.Errorf("expected failure summary mentioning attempts, got %v", last.summary)
+	}
+	if !strings.Contains(*last.summary, "exit status 1") {
+		t.Errorf("expected failure summary to include last error, got %q", *last.summary)
+	}
+	if task.BlockedReason == nil || *task.BlockedReason != *last.summary {
+		t.Errorf("expected the failure to be the blocked reason, got %v", task.BlockedReason)
+	}
+	if o.isTaskInBackoff(task.ID) {
+		t.Error("expected failure tracking to be cleared once the task is blocked")
+	}
+}
+
+func TestOrchestrator_FailuresOutlastRestart(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "flaky", 1)
+	policy := RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+		Multiplier:     1,
+	}
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetRetryPolicy(policy)
+	o.handleTaskFailure(1, task, "test-model", errors.New("exit status 1"))
+	if task.Status != models.TaskStatusPending {
+		t.Fatalf("expected task to be pending after first failure, got %s", task.Status)
+	}
+
+	// A new process picks up where the last one left off.
+	restarted := NewOrchestrator(store, 1, "test-model")
+	restarted.SetRetryPolicy(policy)
+	if err := restarted.loadTaskFailures(context.Background()); err != nil {
+		t.Fatalf("loadTaskFailures failed: %v", err)
+	}
+	if !restarted.isTaskInBackoff(task.ID) {
+		t.Error("expected the backoff to carry over the restart")
+	}
+	restarted.handleTaskFailure(1, task, "test-model", errors.New("exit status 1"))
+	if task.Status != models.TaskStatusBlocked {
+		t.Fatalf("expected task to be blocked after max attempts across restarts, got %s", task.Status)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.failures) != 0 {
+		t.Errorf("expected the stored failures to be cleared once the task is blocked, got %v", store.failures)
+	}
+}
+
+func TestOrchestrator_LeavesTasksInBackoffUnclaimed(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "flaky", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.ctx = context.Background()
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "true")
+	}
+	o.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+		Multiplier:     1,
+	})
+
+	o.handleTaskFailure(1, task, "test-model", errors.New("exit status 1"))
+	store.mu.Lock()
+	updates := len(store.statusUpdates)
+	store.mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		o.trySpawnWorkers()
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.statusUpdates) != updates {
+		t.Errorf("expected a task in backoff not to be claimed and put back, got updates %+v", store.statusUpdates[updates:])
+	}
+	if len(store.excluded) != 1 || store.excluded[0] != task.ID {
+		t.Errorf("expected the task to be held back from claims, got %v", store.excluded)
+	}
+	if len(o.GetActiveWorkers()) != 0 {
+		t.Error("expected no worker to start on a task in backoff")
+	}
+}
+
+func TestOrchestrator_KeepsFailuresOfLongRunningTasks(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "slow", 1)
+	done := store.addTask("2", "done", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+		Multiplier:     1,
+	})
+
+	o.handleTaskFailure(1, task, "test-model", errors.New("timed out"))
+	o.recordTaskFailure(done.ID)
+	done.Status = models.TaskStatusCompleted
+
+	// The retry runs for far longer than the backoff before failing again.
+	task.Status = models.TaskStatusInProgress
+	o.failedTasksMu.Lock()
+	o.failedTasks[task.ID].failedAt = time.Now().Add(-time.Hour)
+	o.failedTasksMu.Unlock()
+	o.cleanupFailedTasks()
+
+	o.failedTasksMu.RLock()
+	_, kept := o.failedTasks[task.ID]
+	_, stale := o.failedTasks[done.ID]
+	o.failedTasksMu.RUnlock()
+	if !kept || stale {
+		t.Errorf("expected only the completed task's failures to be forgotten (running kept: %v, completed kept: %v)", kept, stale)
+	}
+
+	o.handleTaskFailure(1, task, "test-model", errors.New("timed out"))
+	if task.Status != models.TaskStatusBlocked {
+		t.Fatalf("expected task to be blocked after max attempts, got %s", task.Status)
+	}
+}
+
+func TestOrchestrator_FallsBackToNextModel(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "flaky", 1)
+
+	o := NewOrchestrator(store, 1, "model-a")
+	o.SetAvailableModels([]string{"model-a", "model-b"})
+	o.SetModelFallback(ModelFallback{AfterFailures: 2})
+	o.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+		Multiplier:     1,
+	})
+
+	if fallback := o.handleTaskFailure(1, task, "model-a", errors.New("boom")); fallback != "" {
+		t.Errorf("expected no fallback after one failure, got %s", fallback)
+	}
+	if fallback := o.handleTaskFailure(1, task, "model-a", errors.New("boom")); fallback != "model-b" {
+		t.Fatalf("expected fallback to model-b, got %q", fallback)
+	}
+	if task.Status != models.TaskStatusPending {
+		t.Fatalf("expected task to be retried rather than blocked, got %s", task.Status)
+	}
+	if got := o.fallbackModel(task.ID); got != "model-b" {
+		t.Errorf("expected the next run to use model-b, got %q", got)
+	}
+
+	store.mu.Lock()
+	fallbacks := store.fallbacks
+	store.mu.Unlock()
+	if len(fallbacks) != 1 || fallbacks[0] != "model-a->model-b" {
+		t.Errorf("expected the fallback to be recorded, got %v", fallbacks)
+	}
+
+	// model-b is the end of the chain, so the retry policy applies again.
+	o.handleTaskFailure(1, task, "model-b", errors.New("boom"))
+	if task.Status != models.TaskStatusPending {
+		t.Fatalf("expected task to be pending after its first failure with model-b, got %s", task.Status)
+	}
+	o.handleTaskFailure(1, task, "model-b", errors.New("boom"))
+	if task.Status != models.TaskStatusBlocked {
+		t.Fatalf("expected task to be blocked once the chain is exhausted, got %s", task.Status)
+	}
+	store.mu.Lock()
+	last := store.statusUpdates[len(store.statusUpdates)-1]
+	store.mu.Unlock()
+	if last.summary == nil || !strings.Contains(*last.summary, "4 failed attempts") {
+		t.Errorf("expected summary to count every attempt, got %v", last.summary)
+	}
+}
+
+func TestNextModel(t *testing.T) {
+	chain := []string{"a", "b", "c"}
+	for current, want := range map[string]string{"a": "b", "b": "c", "c": "", "routed": "a"} {
+		if got := nextModel(chain, current); got != want {
+			t.Errorf("nextModel(%q) = %q, want %q", current, got, want)
+		}
+	}
+}
+
+func TestOrchestrator_UnlimitedRetriesNeverBlock(t *testing.T) {
+	store := newMockTaskStore()
+	task := store.addTask("1", "flaky", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 0
+	o.SetRetryPolicy(policy)
+
+	for i := 0; i < 10; i++ {
+		o.handleTaskFailure(1, task, "test-model", errors.New("boom"))
+	}
+	if task.Status != models.TaskStatusPending {
+		t.Errorf("expected task to remain pending, got %s", task.Status)
+	}
+}
+
 func TestOrchestrator_Stop(t *testing.T) {
 	store := newMockTaskStore()
 	store.addTask("1", "task1", 1)
@@ -606,6 +1082,61 @@ func TestOrchestrator_ZeroTargetWorkersDoesNotSpawn(t *testing.T) {
 	}
 }
 
+func TestOrchestrator_PauseStopsClaiming(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "true")
+	}
+	o.Pause()
+	if !o.IsPaused() {
+		t.Fatal("expected orchestrator to be paused")
+	}
+
+	var pauseStates []bool
+	msgsDone := make(chan struct{})
+	go func() {
+		defer close(msgsDone)
+		for msg := range o.Messages() {
+			if m, ok := msg.(PauseStateMsg); ok {
+				pauseStates = append(pauseStates, m.Paused)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- o.Start(ctx)
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	store.mu.Lock()
+	claimed := store.claimed["1"]
+	store.mu.Unlock()
+	if claimed {
+		t.Fatal("expected task not to be claimed while paused")
+	}
+
+	if o.TogglePause() {
+		t.Fatal("expected toggle to resume the orchestrator")
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-msgsDone
+
+	if !store.claimed["1"] {
+		t.Error("expected task to be claimed after resuming")
+	}
+	if len(pauseStates) != 2 || !pauseStates[0] || pauseStates[1] {
+		t.Errorf("expected paused then resumed messages, got %v", pauseStates)
+	}
+}
+
 func TestOrchestrator_DecreaseWorkersIfIdle(t *testing.T) {
 	store := newMockTaskStore()
 	o := NewOrchestrator(store, 3, "test-model")
@@ -664,7 +1195,7 @@ func TestOrchestrator_ShutdownOptimization(t *testing.T) {
 	}
 
 	store.mu.Lock()
-	disabled := store.disableCalled
+	batches := store.batches
 	resetDone := false
 	for _, update := range store.statusUpdates {
 		if update.id == "1" && update.status == models.TaskStatusPending {
@@ -674,8 +1205,8 @@ func TestOrchestrator_ShutdownOptimization(t *testing.T) {
 	}
 	store.mu.Unlock()
 
-	if !disabled {
-		t.Error("expected DisableOnChange to be called during shutdown")
+	if batches == 0 {
+		t.Error("expected the shutdown resets to be batched")
 	}
 	if !resetDone {
 		t.Error("expected task to be reset to pending during shutdown")
@@ -691,7 +1222,7 @@ func TestConstructPrompt(t *testing.T) {
 		Specification: "test-spec",
 	}
 
-	prompt := o.constructPrompt(task)
+	prompt := o.constructPrompt(context.Background(), task)
 
 	if !strings.HasPrefix(prompt, prompts.Header) {
 		t.Error("prompt does not start with Header")
@@ -711,4 +1242,57 @@ func TestConstructPrompt(t *testing.T) {
 	if !strings.Contains(prompt, "## Specification\ntest-spec") {
 		t.Error("prompt missing specification")
 	}
+	if strings.Contains(prompt, "## Completed Dependencies") {
+		t.Error("prompt should not list dependencies for a task without any")
+	}
+}
+
+func TestConstructPromptIncludesCompletedDependencies(t *testing.T) {
+	store := newMockTaskStore()
+	summary := "Added the users table and its migration"
+	store.dependencies = map[string][]*models.Task{
+		"task-1": {
+			{ID: "dep-1", FeatureName: "auth", Name: "schema", Status: models.TaskStatusCompleted, CompletionSummary: &summary},
+			{ID: "dep-2", FeatureName: "auth", Name: "config", Status: models.TaskStatusCompleted},
+			{ID: "dep-3", FeatureName: "auth", Name: "pending-dep", Status: models.TaskStatusPending},
+		},
+	}
+	o := &Orchestrator{store: store}
+	task := &models.Task{ID: "task-1", FeatureName: "auth", Name: "login"}
+
+	prompt := o.constructPrompt(context.Background(), task)
+
+	if !strings.Contains(prompt, "## Completed Dependencies") {
+		t.Fatalf("prompt missing completed dependencies section:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "### auth/schema\n"+summary) {
+		t.Error("prompt missing dependency summary")
+	}
+	if !strings.Contains(prompt, "### auth/config\n(no summary recorded)") {
+		t.Error("prompt missing dependency without summary")
+	}
+	if strings.Contains(prompt, "pending-dep") {
+		t.Error("prompt should not include unfinished dependencies")
+	}
+	if !strings.HasSuffix(prompt, prompts.Footer) {
+		t.Error("prompt does not end with Footer")
+	}
+}
+
+func TestOrchestrator_Workers(t *testing.T) {
+	store := newMockTaskStore()
+	o := NewOrchestrator(store, 3, "test-model")
+
+	o.workersMu.Lock()
+	o.workers[2] = &workerInstance{id: 2, task: &models.Task{ID: "t2", Name: "second", FeatureName: "f"}, model: "model-b"}
+	o.workers[1] = &workerInstance{id: 1, task: &models.Task{ID: "t1", Name: "first"}}
+	o.workersMu.Unlock()
+
+	workers := o.Workers()
+	if len(workers) != 2 || workers[0].ID != 1 || workers[1].ID != 2 {
+		t.Fatalf("expected workers 1 and 2 in order, got %+v", workers)
+	}
+	if w := workers[1]; w.TaskName != "second" || w.FeatureName != "f" || w.Model != "model-b" {
+		t.Errorf("unexpected worker: %+v", w)
+	}
 }
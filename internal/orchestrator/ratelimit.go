@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimits caps how often agents are launched, in runs per minute, keyed
+// by provider ("opencode") or by model ("opencode/gpt-5"). A run counts
+// against both its model and its provider, so a provider limit covers all
+// of its models. Launches are spread evenly rather than let through in
+// bursts.
+type RateLimits map[string]float64
+
+// Validate reports whether every limit is usable.
+func (r RateLimits) Validate() error {
+	for key, perMinute := range r {
+		if key == "" {
+			return fmt.Errorf("rate limit key must not be empty")
+		}
+		if perMinute <= 0 {
+			return fmt.Errorf("rate limit of %s must be > 0", key)
+		}
+	}
+	return nil
+}
+
+// provider is the part of model before the first slash, or all of it.
+func provider(model string) string {
+	p, _, _ := strings.Cut(model, "/")
+	return p
+}
+
+// rateLimiter hands out launch times under RateLimits. Each key has a
+// bucket holding a single token, refilled at its rate; reserving takes the
+// token now or books the next one, so concurrent workers queue in turn.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits RateLimits
+	next   map[string]time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{limits: limits, next: make(map[string]time.Time), now: time.Now}
+}
+
+// reserve books a launch of model and returns how long to wait for it.
+func (l *rateLimiter) reserve(model string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := []string{model}
+	if p := provider(model); p != model {
+		keys = append(keys, p)
+	}
+
+	now := l.now()
+	at := now
+	for _, key := range keys {
+		if _, ok := l.limits[key]; ok && l.next[key].After(at) {
+			at = l.next[key]
+		}
+	}
+	for _, key := range keys {
+		if perMinute, ok := l.limits[key]; ok {
+			l.next[key] = at.Add(time.Duration(float64(time.Minute) / perMinute))
+		}
+	}
+	return at.Sub(now)
+}
+
+// GetRateLimits returns the limits agent launches are held to.
+func (o *Orchestrator) GetRateLimits() RateLimits {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	if o.rateLimiter == nil {
+		return nil
+	}
+	return o.rateLimiter.limits
+}
+
+// SetRateLimits holds agent launches to limits, forgetting past launches.
+// nil removes the limits.
+func (o *Orchestrator) SetRateLimits(limits RateLimits) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.rateLimiter = nil
+	if len(limits) > 0 {
+		o.rateLimiter = newRateLimiter(limits)
+	}
+}
+
+// waitForRateLimit waits until model may be launched under the rate limits,
+// reporting the wait to workerID's log.
+func (o *Orchestrator) waitForRateLimit(ctx context.Context, workerID int, model string) error {
+	o.workersMu.RLock()
+	limiter := o.rateLimiter
+	o.workersMu.RUnlock()
+	if limiter == nil {
+		return nil
+	}
+
+	wait := limiter.reserve(model)
+	if wait <= 0 {
+		return nil
+	}
+	o.sendMsg(StatusMsg{
+		WorkerID: workerID,
+		Message:  fmt.Sprintf("Waiting %s for the rate limit of %s", wait.Round(100*time.Millisecond), model),
+	})
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
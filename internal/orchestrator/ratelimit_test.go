@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(RateLimits{"opencode": 60, "opencode/gpt-5": 30})
+	l.now = func() time.Time { return now }
+
+	steps := []struct {
+		model string
+		want  time.Duration
+	}{
+		{"opencode/gpt-5", 0},
+		// The model allows one every 2s, the provider one every second.
+		{"opencode/gpt-5", 2 * time.Second},
+		// Other models of the provider queue behind both.
+		{"opencode/gemini-3-flash", 3 * time.Second},
+		{"anthropic/claude", 0},
+	}
+	for i, step := range steps {
+		if got := l.reserve(step.model); got != step.want {
+			t.Errorf("step %d (%s): expected to wait %s, got %s", i, step.model, step.want, got)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if got := l.reserve("opencode/gpt-5"); got != 0 {
+		t.Errorf("expected no wait once the buckets refilled, got %s", got)
+	}
+}
+
+func TestRateLimitsValidate(t *testing.T) {
+	if err := (RateLimits{"opencode": 10}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, limits := range []RateLimits{{"opencode": 0}, {"opencode": -1}, {"": 5}} {
+		if err := limits.Validate(); err == nil {
+			t.Errorf("expected %v to be rejected", limits)
+		}
+	}
+}
+
+func TestWaitForRateLimit(t *testing.T) {
+	o := NewOrchestrator(newMockTaskStore(), 1, "test-model")
+	ctx := context.Background()
+	if err := o.waitForRateLimit(ctx, 1, "test-model"); err != nil {
+		t.Fatalf("expected no wait without limits, got %v", err)
+	}
+
+	o.SetRateLimits(RateLimits{"test-model": 1})
+	if err := o.waitForRateLimit(ctx, 1, "test-model"); err != nil {
+		t.Fatalf("expected the first launch to go ahead, got %v", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := o.waitForRateLimit(cancelled, 1, "test-model"); err != context.Canceled {
+		t.Errorf("expected the second launch to wait until cancelled, got %v", err)
+	}
+
+	o.SetRateLimits(nil)
+	if o.GetRateLimits() != nil {
+		t.Errorf("expected no limits, got %v", o.GetRateLimits())
+	}
+}
@@ -0,0 +1,47 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigReload holds the settings a running orchestrator picks up when its
+// configuration changes. Zero fields are left as they are.
+type ConfigReload struct {
+	MaxWorkers      int
+	AvailableModels []string
+	// Ignored lists changed settings that only take effect on restart.
+	Ignored []string
+}
+
+// ReloadConfig applies the changed settings and reports them in the status
+// log.
+func (o *Orchestrator) ReloadConfig(c ConfigReload) {
+	var applied []string
+	if c.MaxWorkers > 0 {
+		o.SetMaxWorkers(c.MaxWorkers)
+		applied = append(applied, fmt.Sprintf("max_concurrency %d", c.MaxWorkers))
+	}
+	if len(c.AvailableModels) > 0 {
+		o.SetAvailableModels(c.AvailableModels)
+		applied = append(applied, "available_models "+strings.Join(o.GetAvailableModels(), ", "))
+	}
+
+	var parts []string
+	if len(applied) > 0 {
+		parts = append(parts, "applied "+strings.Join(applied, "; "))
+	}
+	if len(c.Ignored) > 0 {
+		parts = append(parts, "restart to apply "+strings.Join(c.Ignored, ", "))
+	}
+	if len(parts) == 0 {
+		return
+	}
+	o.sendMsg(StatusMsg{Message: "Config reloaded: " + strings.Join(parts, "; ")})
+}
+
+// ReportConfigError reports a configuration change that could not be
+// loaded. The orchestrator keeps its current settings.
+func (o *Orchestrator) ReportConfigError(err error) {
+	o.sendMsg(StatusMsg{Message: fmt.Sprintf("Config not reloaded: %v", err)})
+}
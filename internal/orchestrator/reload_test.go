@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetMaxWorkers(t *testing.T) {
+	o := NewOrchestrator(newMockTaskStore(), 3, "test-model")
+
+	// A target at the maximum follows it.
+	o.SetMaxWorkers(5)
+	if o.GetMaxWorkers() != 5 || o.GetTargetWorkers() != 5 {
+		t.Errorf("expected max and target 5, got %d and %d", o.GetMaxWorkers(), o.GetTargetWorkers())
+	}
+
+	// A lower target is kept unless it is over the new maximum.
+	o.SetTargetWorkers(2)
+	o.SetMaxWorkers(4)
+	if o.GetTargetWorkers() != 2 {
+		t.Errorf("expected target 2 to be kept, got %d", o.GetTargetWorkers())
+	}
+	o.SetMaxWorkers(1)
+	if o.GetTargetWorkers() != 1 {
+		t.Errorf("expected target lowered to 1, got %d", o.GetTargetWorkers())
+	}
+
+	o.SetMaxWorkers(0)
+	if o.GetMaxWorkers() != 1 {
+		t.Errorf("expected at least one worker, got %d", o.GetMaxWorkers())
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	o := NewOrchestrator(newMockTaskStore(), 2, "test-model")
+	o.SetTargetWorkers(0)
+
+	o.ReloadConfig(ConfigReload{
+		MaxWorkers:      4,
+		AvailableModels: []string{"test-model", "other-model"},
+		Ignored:         []string{"worktrees"},
+	})
+	if o.GetMaxWorkers() != 4 {
+		t.Errorf("expected max workers 4, got %d", o.GetMaxWorkers())
+	}
+	if got := o.GetAvailableModels(); !reflect.DeepEqual(got, []string{"test-model", "other-model"}) {
+		t.Errorf("expected the new models, got %v", got)
+	}
+	msg := (<-o.msgChan).(StatusMsg)
+	if !strings.Contains(msg.Message, "max_concurrency 4") || !strings.Contains(msg.Message, "restart to apply worktrees") {
+		t.Errorf("expected the reload to be reported, got %q", msg.Message)
+	}
+
+	o.ReportConfigError(errors.New("bad json"))
+	msg = (<-o.msgChan).(StatusMsg)
+	if !strings.Contains(msg.Message, "bad json") {
+		t.Errorf("expected the error to be reported, got %q", msg.Message)
+	}
+
+	// The TUI makes views for workers beyond the original maximum.
+	m := NewOrchestratorModel(o)
+	o.SetMaxWorkers(6)
+	m.syncWorkerViews(6)
+	if len(m.workerOrder) != 6 || m.workerViews[6] == nil {
+		t.Errorf("expected 6 worker views, got %d", len(m.workerOrder))
+	}
+}
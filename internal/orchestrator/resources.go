@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// ResourceLimits caps what each agent and verification command may use, so
+// several agents running at once leave the machine usable. The zero value
+// sets no limits.
+type ResourceLimits struct {
+	// Nice is added to the niceness of the commands, from 0 to 19.
+	Nice int
+	// MemoryMax is the most memory, in bytes, a command and its children
+	// may use before the kernel reclaims or kills them. Zero means no limit.
+	MemoryMax int64
+	// MaxProcesses is the most processes and threads a command and its
+	// children may run at once. Zero means no limit.
+	MaxProcesses int
+}
+
+// Validate reports whether the limits are usable.
+func (l ResourceLimits) Validate() error {
+	if l.Nice < 0 || l.Nice > 19 {
+		return fmt.Errorf("nice must be between 0 and 19")
+	}
+	if l.MemoryMax < 0 {
+		return fmt.Errorf("memory_max must be >= 0")
+	}
+	if l.MaxProcesses < 0 {
+		return fmt.Errorf("max_processes must be >= 0")
+	}
+	return nil
+}
+
+// cgroup reports whether the limits need a cgroup.
+func (l ResourceLimits) cgroup() bool {
+	return l.MemoryMax > 0 || l.MaxProcesses > 0
+}
+
+// lookPath finds the wrapper commands; tests replace it.
+var lookPath = exec.LookPath
+
+// wrap returns the command line that runs name with args under the limits.
+// Memory and process limits put the command in a transient systemd scope,
+// which holds its own cgroup. Where that isn't available they are left out,
+// the command runs with only the niceness, and the error says why.
+func (l ResourceLimits) wrap(name string, args []string) (string, []string, error) {
+	argv := append([]string{name}, args...)
+	if !l.cgroup() {
+		argv = l.wrapNice(argv)
+		return argv[0], argv[1:], nil
+	}
+
+	var err error
+	if runtime.GOOS != "linux" {
+		err = fmt.Errorf("memory_max and max_processes need Linux cgroups")
+	} else if _, lookErr := lookPath("systemd-run"); lookErr != nil {
+		err = fmt.Errorf("memory_max and max_processes need systemd-run: %w", lookErr)
+	}
+	if err != nil {
+		argv = l.wrapNice(argv)
+		return argv[0], argv[1:], err
+	}
+
+	wrapped := []string{"--user", "--scope", "--quiet", "--collect"}
+	if l.MemoryMax > 0 {
+		wrapped = append(wrapped, "-p", "MemoryMax="+strconv.FormatInt(l.MemoryMax, 10))
+	}
+	if l.MaxProcesses > 0 {
+		wrapped = append(wrapped, "-p", "TasksMax="+strconv.Itoa(l.MaxProcesses))
+	}
+	if l.Nice > 0 {
+		wrapped = append(wrapped, "--nice="+strconv.Itoa(l.Nice))
+	}
+	return "systemd-run", append(append(wrapped, "--"), argv...), nil
+}
+
+// wrapNice prefixes argv with nice when the limits lower the priority.
+func (l ResourceLimits) wrapNice(argv []string) []string {
+	if l.Nice == 0 {
+		return argv
+	}
+	return append([]string{"nice", "-n", strconv.Itoa(l.Nice)}, argv...)
+}
+
+// GetResourceLimits returns the limits agent commands run under.
+func (o *Orchestrator) GetResourceLimits() ResourceLimits {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.limits
+}
+
+// SetResourceLimits sets the limits agent and verification commands run
+// under. The zero value removes them.
+func (o *Orchestrator) SetResourceLimits(l ResourceLimits) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.limits = l
+}
+
+// command builds a command running name with args in dir with env added to
+// the environment, under the resource limits and in the sandbox if there is
+// one. Limits that can't be applied on this machine are reported to
+// workerID's log and the command runs without them.
+func (o *Orchestrator) command(ctx context.Context, workerID int, dir string, env []string, name string, args ...string) (*exec.Cmd, error) {
+	if s := o.GetSandbox(); s != nil {
+		return o.sandboxCommand(ctx, s, dir, env, name, args)
+	}
+
+	name, args, err := o.GetResourceLimits().wrap(name, args)
+	if err != nil {
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Resource limits not applied: %v", err),
+		})
+	}
+	cmd := o.cmdFactory(ctx, name, args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd, nil
+}
@@ -0,0 +1,72 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestResourceLimitsWrap(t *testing.T) {
+	name, args, err := ResourceLimits{}.wrap("opencode", []string{"run"})
+	if err != nil || name != "opencode" || !reflect.DeepEqual(args, []string{"run"}) {
+		t.Errorf("expected no wrapping without limits, got %s %v, %v", name, args, err)
+	}
+
+	name, args, err = ResourceLimits{Nice: 10}.wrap("opencode", []string{"run"})
+	if err != nil || name != "nice" || !reflect.DeepEqual(args, []string{"-n", "10", "opencode", "run"}) {
+		t.Errorf("expected nice wrapping, got %s %v, %v", name, args, err)
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	defer func(orig func(string) (string, error)) { lookPath = orig }(lookPath)
+
+	lookPath = func(string) (string, error) { return "/usr/bin/systemd-run", nil }
+	limits := ResourceLimits{Nice: 5, MemoryMax: 1 << 30, MaxProcesses: 100}
+	name, args, err = limits.wrap("opencode", []string{"run"})
+	want := []string{"--user", "--scope", "--quiet", "--collect", "-p", "MemoryMax=1073741824", "-p", "TasksMax=100", "--nice=5", "--", "opencode", "run"}
+	if err != nil || name != "systemd-run" || !reflect.DeepEqual(args, want) {
+		t.Errorf("expected systemd-run wrapping, got %s %v, %v", name, args, err)
+	}
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	name, args, err = limits.wrap("opencode", []string{"run"})
+	if err == nil || name != "nice" || !reflect.DeepEqual(args, []string{"-n", "5", "opencode", "run"}) {
+		t.Errorf("expected to fall back to nice with an error, got %s %v, %v", name, args, err)
+	}
+}
+
+func TestResourceLimitsValidate(t *testing.T) {
+	for _, l := range []ResourceLimits{{Nice: -1}, {Nice: 20}, {MemoryMax: -1}, {MaxProcesses: -1}} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", l)
+		}
+	}
+}
+
+func TestAgentRunsUnderResourceLimits(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetResourceLimits(ResourceLimits{Nice: 7})
+	var commands []string
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		commands = append(commands, name+" "+strings.Join(arg, " "))
+		return exec.CommandContext(ctx, "true")
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	if len(commands) == 0 || !strings.HasPrefix(commands[0], "nice -n 7 opencode run") {
+		t.Errorf("expected the agent to run under nice, got %v", commands)
+	}
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how failed tasks are retried before being blocked.
+type RetryPolicy struct {
+	// MaxAttempts is the number of failed runs after which a task is moved to
+	// blocked. Zero means retry forever.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential growth of the delay.
+	MaxBackoff time.Duration
+	// Multiplier is applied to the delay after each failed attempt.
+	Multiplier float64
+	// Jitter is the fraction (0-1) of the delay that is randomized.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Validate reports whether the policy values are usable.
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must be >= 0")
+	}
+	if p.InitialBackoff < 0 {
+		return fmt.Errorf("initial_backoff must be >= 0")
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		return fmt.Errorf("max_backoff must be >= initial_backoff")
+	}
+	if p.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be >= 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Exhausted reports whether a task that has failed failCount times should be blocked.
+func (p RetryPolicy) Exhausted(failCount int) bool {
+	return p.MaxAttempts > 0 && failCount >= p.MaxAttempts
+}
+
+// Backoff returns the delay to wait after the given number of failures.
+func (p RetryPolicy) Backoff(failCount int) time.Duration {
+	if failCount < 1 {
+		return 0
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < failCount && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		spread := delay * p.Jitter
+		delay = delay - spread + rand.Float64()*2*spread
+	}
+
+	return time.Duration(delay)
+}
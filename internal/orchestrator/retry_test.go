@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_BackoffGrowsExponentially(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+
+	expected := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for failCount, want := range expected {
+		if got := p.Backoff(failCount); got != want {
+			t.Errorf("Backoff(%d): expected %v, got %v", failCount, want, got)
+		}
+	}
+}
+
+func TestRetryPolicy_JitterStaysWithinBounds(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		got := p.Backoff(1)
+		if got < 5*time.Second || got > 15*time.Second {
+			t.Fatalf("expected backoff within [5s, 15s], got %v", got)
+		}
+	}
+}
+
+func TestRetryPolicy_Exhausted(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 2}
+	if p.Exhausted(1) {
+		t.Error("expected policy not exhausted after 1 failure")
+	}
+	if !p.Exhausted(2) {
+		t.Error("expected policy exhausted after 2 failures")
+	}
+
+	unlimited := RetryPolicy{MaxAttempts: 0}
+	if unlimited.Exhausted(1000) {
+		t.Error("expected unlimited policy to never be exhausted")
+	}
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	if err := DefaultRetryPolicy().Validate(); err != nil {
+		t.Fatalf("expected default policy to be valid, got %v", err)
+	}
+
+	invalid := []RetryPolicy{
+		{MaxAttempts: -1, Multiplier: 1},
+		{InitialBackoff: time.Minute, MaxBackoff: time.Second, Multiplier: 1},
+		{Multiplier: 0.5},
+		{Multiplier: 1, Jitter: 1.5},
+	}
+	for i, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("case %d: expected validation error for %+v", i, p)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// ModelRoute sends tasks of at least MinPriority to Model.
+type ModelRoute struct {
+	MinPriority int
+	Model       string
+}
+
+// ModelRouting picks the model a task is run with from its priority. Tasks
+// matching no route use the orchestrator's current model.
+type ModelRouting []ModelRoute
+
+// NewModelRouting orders routes so the highest threshold is tried first.
+func NewModelRouting(routes []ModelRoute) (ModelRouting, error) {
+	r := make(ModelRouting, len(routes))
+	copy(r, routes)
+	sort.SliceStable(r, func(i, j int) bool { return r[i].MinPriority > r[j].MinPriority })
+	for i, route := range r {
+		if route.Model == "" {
+			return nil, fmt.Errorf("priority>=%d has no model", route.MinPriority)
+		}
+		if i > 0 && r[i-1].MinPriority == route.MinPriority {
+			return nil, fmt.Errorf("priority>=%d is routed twice", route.MinPriority)
+		}
+	}
+	return r, nil
+}
+
+// For returns the model routed to task, or "" when no route matches.
+func (r ModelRouting) For(task *models.Task) string {
+	for _, route := range r {
+		if task.Priority >= route.MinPriority {
+			return route.Model
+		}
+	}
+	return ""
+}
+
+// GetModelRouting returns the configured priority routes.
+func (o *Orchestrator) GetModelRouting() ModelRouting {
+	o.modelMu.RLock()
+	defer o.modelMu.RUnlock()
+	return o.modelRouting
+}
+
+// SetModelRouting sets the priority routes consulted when spawning workers.
+// Nil runs every task with the current model.
+func (o *Orchestrator) SetModelRouting(r ModelRouting) {
+	o.modelMu.Lock()
+	defer o.modelMu.Unlock()
+	o.modelRouting = r
+}
+
+// modelFor returns the model task is run with.
+func (o *Orchestrator) modelFor(task *models.Task) string {
+	if model := o.GetModelRouting().For(task); model != "" {
+		return model
+	}
+	return o.GetModel()
+}
@@ -0,0 +1,64 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestModelRoutingFor(t *testing.T) {
+	routing, err := NewModelRouting([]ModelRoute{
+		{MinPriority: 5, Model: "mid"},
+		{MinPriority: 8, Model: "big"},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouting failed: %v", err)
+	}
+
+	cases := map[int]string{10: "big", 8: "big", 7: "mid", 5: "mid", 4: ""}
+	for priority, want := range cases {
+		if got := routing.For(&models.Task{Priority: priority}); got != want {
+			t.Errorf("priority %d: expected %q, got %q", priority, want, got)
+		}
+	}
+
+	if _, err := NewModelRouting([]ModelRoute{{MinPriority: 8, Model: "a"}, {MinPriority: 8, Model: "b"}}); err == nil {
+		t.Error("expected error for a threshold routed twice")
+	}
+	if _, err := NewModelRouting([]ModelRoute{{MinPriority: 8}}); err == nil {
+		t.Error("expected error for a route without a model")
+	}
+}
+
+func TestRunWorkerUsesRoutedModel(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "critical", 9)
+	store.addTask("2", "routine", 2)
+
+	o := NewOrchestrator(store, 1, "cheap")
+	routing, _ := NewModelRouting([]ModelRoute{{MinPriority: 8, Model: "expensive"}})
+	o.SetModelRouting(routing)
+
+	var mu sync.Mutex
+	used := make(map[string]string)
+	var current string
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		mu.Lock()
+		used[current] = arg[len(arg)-1]
+		mu.Unlock()
+		return exec.CommandContext(ctx, "true")
+	}
+
+	for i := 0; i < 2; i++ {
+		task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+		current = task.Name
+		o.runWorker(context.Background(), &workerInstance{id: 1, task: task, done: make(chan struct{})})
+	}
+
+	if used["critical"] != "expensive" || used["routine"] != "cheap" {
+		t.Errorf("expected critical on expensive and routine on cheap, got %v", used)
+	}
+}
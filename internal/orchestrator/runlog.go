@@ -0,0 +1,148 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// runLogTimeFormat stamps log file names; it sorts in time order.
+const runLogTimeFormat = "20060102T150405.000000000"
+
+// RunLogs keeps the full agent output of every run in Dir, one file per run
+// named <task-id>-<timestamp>.log, so failed runs can be inspected after the
+// TUI has exited. The zero value disables it.
+type RunLogs struct {
+	Dir string
+	// Keep is how many logs to retain per task; older ones are deleted.
+	Keep int
+	// MaxAge deletes logs older than this, for any task. Zero keeps them
+	// until Keep prunes them.
+	MaxAge time.Duration
+}
+
+// Enabled reports whether run logs are written at all.
+func (l RunLogs) Enabled() bool {
+	return l.Dir != "" && l.Keep > 0
+}
+
+// Create opens a new log for a run of task and prunes old logs.
+func (l RunLogs) Create(task *models.Task, now time.Time) (*os.File, error) {
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := l.prune(task.ID, now); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.log", task.ID, now.UTC().Format(runLogTimeFormat))
+	f, err := os.Create(filepath.Join(l.Dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run log: %w", err)
+	}
+	return f, nil
+}
+
+// prune deletes logs past MaxAge and all but the newest Keep-1 logs of the
+// task, making room for the one about to be written.
+func (l RunLogs) prune(taskID string, now time.Time) error {
+	if l.MaxAge > 0 {
+		all, err := filepath.Glob(filepath.Join(l.Dir, "*.log"))
+		if err != nil {
+			return err
+		}
+		for _, path := range all {
+			info, err := os.Stat(path)
+			if err != nil || now.Sub(info.ModTime()) <= l.MaxAge {
+				continue
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to prune run log: %w", err)
+			}
+		}
+	}
+
+	logs, err := ListRunLogs(l.Dir, taskID)
+	if err != nil {
+		return err
+	}
+	for len(logs) >= l.Keep {
+		if err := os.Remove(logs[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to prune run log: %w", err)
+		}
+		logs = logs[1:]
+	}
+	return nil
+}
+
+// ListRunLogs returns the paths of the logs of a task in dir, oldest first.
+func ListRunLogs(dir, taskID string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, taskID+"-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	// Only keep names whose remainder is a timestamp, so a task whose ID
+	// extends this one doesn't match. The timestamp sorts by age.
+	logs := matches[:0]
+	for _, path := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), taskID+"-"), ".log")
+		if _, err := time.Parse(runLogTimeFormat, stamp); err == nil {
+			logs = append(logs, path)
+		}
+	}
+	sort.Strings(logs)
+	return logs, nil
+}
+
+// openRunLog starts the log of a run, or returns nil when run logs are
+// disabled or the log can't be created; a missing log never fails the task.
+func (o *Orchestrator) openRunLog(workerID int, task *models.Task, model string) *os.File {
+	logs := o.GetRunLogs()
+	if !logs.Enabled() {
+		return nil
+	}
+	now := time.Now()
+	f, err := logs.Create(task, now)
+	if err != nil {
+		o.sendMsg(StatusMsg{WorkerID: workerID, Message: fmt.Sprintf("Failed to open run log: %v", err)})
+		return nil
+	}
+	fmt.Fprintf(f, "--- %s/%s (%s) with %s, started %s ---\n\n",
+		task.FeatureName, task.Name, task.ID, model, now.Format(time.RFC3339))
+	return f
+}
+
+// writeRunLogFooter records how the run ended, including the output of a
+// failed verification, which the agent output doesn't contain.
+func writeRunLogFooter(w io.Writer, err error) {
+	var verr *VerificationError
+	if errors.As(err, &verr) && verr.Output != "" {
+		fmt.Fprintf(w, "\n--- Verification output ---\n%s", verr.Output)
+	}
+	result := "success"
+	if err != nil {
+		result = "error: " + err.Error()
+	}
+	fmt.Fprintf(w, "\n--- Finished %s: %s ---\n", time.Now().Format(time.RFC3339), result)
+}
+
+// GetRunLogs returns where agent output is logged.
+func (o *Orchestrator) GetRunLogs() RunLogs {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.runLogs
+}
+
+// SetRunLogs sets where agent output is logged. The zero value disables it.
+func (o *Orchestrator) SetRunLogs(l RunLogs) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.runLogs = l
+}
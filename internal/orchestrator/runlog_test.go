@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestRunLogsKeepsFullOutput(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	dir := t.TempDir()
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetRunLogs(RunLogs{Dir: dir, Keep: 5})
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "echo agent output; echo agent error >&2; exit 1")
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	logs, err := ListRunLogs(dir, "1")
+	if err != nil {
+		t.Fatalf("ListRunLogs failed: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %v", logs)
+	}
+	data, err := os.ReadFile(logs[0])
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	for _, want := range []string{"task1 (1) with test-model", "agent output", "agent error", "Finished", "error: exit status 1"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestRunLogsPrunesOldLogs(t *testing.T) {
+	dir := t.TempDir()
+	logs := RunLogs{Dir: dir, Keep: 2, MaxAge: time.Hour}
+	task := &models.Task{ID: "abc"}
+	now := time.Now()
+
+	stale := filepath.Join(dir, "other-20000101T000000.000000000.log")
+	if err := os.WriteFile(stale, nil, 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	old := now.Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("failed to age log: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		f, err := logs.Create(task, now.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		f.Close()
+	}
+
+	got, err := ListRunLogs(dir, "abc")
+	if err != nil {
+		t.Fatalf("ListRunLogs failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 logs to be kept, got %v", got)
+	}
+	if !strings.Contains(got[1], now.Add(2*time.Second).UTC().Format(runLogTimeFormat)) {
+		t.Errorf("expected newest log to be kept, got %v", got)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected log past max age to be removed")
+	}
+
+	if (RunLogs{Dir: dir}).Enabled() {
+		t.Error("expected keep 0 to disable run logs")
+	}
+}
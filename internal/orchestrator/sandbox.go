@@ -0,0 +1,172 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+)
+
+// DefaultSandboxRuntime is the container CLI used when none is configured.
+const DefaultSandboxRuntime = "docker"
+
+// Sandbox runs agent and verification commands inside a container, so that
+// what an untrusted agent does stays away from the host. The repository is
+// mounted at the same path as on the host, so worktrees, the database and
+// paths in prompts work unchanged; the image must provide the agent CLI and
+// ponder for its MCP server.
+//
+// The container runs as the host user unless User says otherwise, so what
+// the agent writes stays the host's to merge and clean up. .git/hooks and
+// .git/config are mounted read-only, since the host's git runs what they
+// name when it merges the agent's worktree. The rest of the repository is
+// still shared read-write: the database, the other tasks' worktrees and
+// the rest of .git, along with anything in Binds.
+type Sandbox struct {
+	// Runtime is the container CLI: docker unless set, or a compatible one
+	// such as podman.
+	Runtime string
+	// Image is the container image the commands run in.
+	Image string
+	// User is the "uid:gid" the commands run as, the host user's unless
+	// set, e.g. "0:0" for root.
+	User string
+	// Binds are extra "host:container[:options]" mounts, e.g. the agent's
+	// credentials read-only.
+	Binds []string
+	// Env is set in the container on top of the run's environment. Nothing
+	// else from the host environment is passed in.
+	Env map[string]string
+	// Args are extra options for "run", e.g. "--network=none".
+	Args []string
+}
+
+// Validate reports whether the sandbox can be used.
+func (s *Sandbox) Validate() error {
+	if s.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	return nil
+}
+
+// sandboxRuns numbers the containers of this process, to name them.
+var sandboxRuns atomic.Uint64
+
+// GetSandbox returns the container commands run in, or nil when they run
+// on the host.
+func (o *Orchestrator) GetSandbox() *Sandbox {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.sandbox
+}
+
+// SetSandbox runs agent and verification commands in a container. nil runs
+// them on the host.
+func (o *Orchestrator) SetSandbox(s *Sandbox) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.sandbox = s
+}
+
+// sandboxCommand builds a command running name with args in the sandbox,
+// in dir (the working directory if empty) with env. The memory and process
+// limits are applied to the container; niceness isn't.
+func (o *Orchestrator) sandboxCommand(ctx context.Context, s *Sandbox, dir string, env []string, name string, args []string) (*exec.Cmd, error) {
+	repo, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the directory to mount: %w", err)
+	}
+	if wm := o.GetWorktreeManager(); wm != nil {
+		repo = wm.RepoDir
+	}
+	workdir := repo
+	if dir != "" {
+		if workdir, err = filepath.Abs(dir); err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+		}
+	}
+
+	runtime := s.Runtime
+	if runtime == "" {
+		runtime = DefaultSandboxRuntime
+	}
+	container := fmt.Sprintf("ponder-%d-%d", o.pid, sandboxRuns.Add(1))
+
+	runArgs := []string{"run", "--rm", "-i", "--init", "--name", container,
+		"-v", repo + ":" + repo}
+	gitBinds, err := readOnlyGitBinds(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, bind := range gitBinds {
+		runArgs = append(runArgs, "-v", bind)
+	}
+	runArgs = append(runArgs, "-w", workdir)
+	if user := s.user(); user != "" {
+		runArgs = append(runArgs, "--user", user)
+	}
+	for _, bind := range s.Binds {
+		runArgs = append(runArgs, "-v", bind)
+	}
+	for _, kv := range append(append([]string{}, env...), environ(s.Env)...) {
+		runArgs = append(runArgs, "-e", kv)
+	}
+	limits := o.GetResourceLimits()
+	if limits.MemoryMax > 0 {
+		runArgs = append(runArgs, "--memory="+strconv.FormatInt(limits.MemoryMax, 10))
+	}
+	if limits.MaxProcesses > 0 {
+		runArgs = append(runArgs, "--pids-limit="+strconv.Itoa(limits.MaxProcesses))
+	}
+	runArgs = append(runArgs, s.Args...)
+	runArgs = append(runArgs, s.Image, name)
+	runArgs = append(runArgs, args...)
+
+	cmd := o.cmdFactory(ctx, runtime, runArgs...)
+	// Killing the CLI would leave the container running.
+	kill := cmd.Cancel
+	cmd.Cancel = func() error {
+		_ = exec.Command(runtime, "rm", "-f", container).Run()
+		if kill != nil {
+			return kill()
+		}
+		return cmd.Process.Kill()
+	}
+	return cmd, nil
+}
+
+// user returns the "uid:gid" to run the container as, or "" where the host
+// has no such IDs, leaving the image's user.
+func (s *Sandbox) user() string {
+	if s.User != "" {
+		return s.User
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 || gid < 0 {
+		return ""
+	}
+	return strconv.Itoa(uid) + ":" + strconv.Itoa(gid)
+}
+
+// readOnlyGitBinds returns the mounts that keep the repository's git hooks
+// and config, which the host's git acts on, out of the agent's reach. The
+// hooks directory is created if missing, or the agent could add one.
+func readOnlyGitBinds(repo string) ([]string, error) {
+	gitDir := filepath.Join(repo, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	hooks := filepath.Join(gitDir, "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", hooks, err)
+	}
+	binds := []string{hooks + ":" + hooks + ":ro"}
+	config := filepath.Join(gitDir, "config")
+	if _, err := os.Stat(config); err == nil {
+		binds = append(binds, config+":"+config+":ro")
+	}
+	return binds, nil
+}
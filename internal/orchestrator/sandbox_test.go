@@ -0,0 +1,91 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestAgentRunsInSandbox(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetSandbox(&Sandbox{
+		Image: "agent:latest",
+		Binds: []string{"/creds:/root/.creds:ro"},
+		Env:   map[string]string{"SANDBOXED": "1"},
+		Args:  []string{"--network=none"},
+	})
+	o.SetResourceLimits(ResourceLimits{Nice: 5, MemoryMax: 1 << 30, MaxProcesses: 64})
+	var name string
+	var args []string
+	o.cmdFactory = func(ctx context.Context, n string, arg ...string) *exec.Cmd {
+		name, args = n, arg
+		return exec.CommandContext(ctx, "true")
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	wd, _ := os.Getwd()
+	if name != "docker" {
+		t.Fatalf("expected the agent to run through docker, got %s", name)
+	}
+	line := strings.Join(args, " ")
+	for _, want := range []string{
+		"run --rm -i --init --name ponder-",
+		"-v " + wd + ":" + wd + " -w " + wd,
+		"-v /creds:/root/.creds:ro",
+		"-e SANDBOXED=1",
+		fmt.Sprintf("--user %d:%d", os.Getuid(), os.Getgid()),
+		"--memory=1073741824 --pids-limit=64 --network=none agent:latest opencode run --model test-model",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+	if strings.Contains(line, "nice") {
+		t.Errorf("expected nice not to apply in the sandbox, got %q", line)
+	}
+}
+
+func TestReadOnlyGitBinds(t *testing.T) {
+	repo := t.TempDir()
+	if binds, err := readOnlyGitBinds(repo); err != nil || binds != nil {
+		t.Fatalf("expected no binds outside a git repository, got %v, %v", binds, err)
+	}
+
+	gitDir := filepath.Join(repo, ".git")
+	if err := os.Mkdir(gitDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "config"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	binds, err := readOnlyGitBinds(repo)
+	if err != nil {
+		t.Fatalf("readOnlyGitBinds failed: %v", err)
+	}
+	hooks, config := filepath.Join(gitDir, "hooks"), filepath.Join(gitDir, "config")
+	want := []string{hooks + ":" + hooks + ":ro", config + ":" + config + ":ro"}
+	if !slices.Equal(binds, want) {
+		t.Errorf("expected %v, got %v", want, binds)
+	}
+	if info, err := os.Stat(hooks); err != nil || !info.IsDir() {
+		t.Errorf("expected the hooks directory to be created, got %v", err)
+	}
+}
+
+func TestSandboxValidate(t *testing.T) {
+	if err := (&Sandbox{}).Validate(); err == nil {
+		t.Error("expected a sandbox without an image to be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+package orchestrator
+
+import (
+	"maps"
+	"time"
+)
+
+// SessionStats sum up the task runs of one orchestrator, from its creation:
+// usually one ponder invocation.
+type SessionStats struct {
+	StartedAt time.Time
+	// Models counts the task runs of each model.
+	Models map[string]int
+	// Attempted counts task runs. Runs cancelled on shutdown are neither
+	// completed nor failed.
+	Attempted int
+	Completed int
+	Failed    int
+	Usage     Usage
+}
+
+// GetSessionStats returns the stats of the session so far.
+func (o *Orchestrator) GetSessionStats() SessionStats {
+	o.sessionMu.Lock()
+	stats := o.session
+	stats.Models = maps.Clone(o.session.Models)
+	o.sessionMu.Unlock()
+	stats.Usage = o.GetUsage()
+	return stats
+}
+
+// SetSessionHook sets a function called after each finished task run is
+// counted towards the session's stats, so they can be saved as the session
+// goes. It is called from the worker that ran the task.
+func (o *Orchestrator) SetSessionHook(hook func()) {
+	o.sessionMu.Lock()
+	defer o.sessionMu.Unlock()
+	o.sessionHook = hook
+}
+
+// recordSessionRun counts a finished task run towards the session's stats.
+// These are the only counts of completed and failed tasks; the stats of a
+// run are taken from them.
+func (o *Orchestrator) recordSessionRun(model string, success, cancelled bool) {
+	o.sessionMu.Lock()
+	o.session.Attempted++
+	if model != "" {
+		o.session.Models[model]++
+	}
+	switch {
+	case success:
+		o.session.Completed++
+	case !cancelled:
+		o.session.Failed++
+	}
+	hook := o.sessionHook
+	o.sessionMu.Unlock()
+
+	if hook != nil {
+		hook()
+	}
+}
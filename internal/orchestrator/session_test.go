@@ -0,0 +1,47 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestSessionStatsCountTaskRuns(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 2)
+	store.addTask("2", "task2", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	fail := false
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if fail {
+			return exec.CommandContext(ctx, "false")
+		}
+		return exec.CommandContext(ctx, "true")
+	}
+
+	for _, f := range []bool{false, true} {
+		fail = f
+		task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+		o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+	}
+	o.recordSessionRun("other-model", false, true)
+
+	stats := o.GetSessionStats()
+	if stats.Attempted != 3 || stats.Completed != 1 || stats.Failed != 1 {
+		t.Errorf("expected 3 attempted, 1 completed and 1 failed, got %+v", stats)
+	}
+	if stats.Models["test-model"] != 2 || stats.Models["other-model"] != 1 {
+		t.Errorf("unexpected model mix %v", stats.Models)
+	}
+	if stats.StartedAt.IsZero() {
+		t.Error("expected the session start to be set")
+	}
+
+	stats.Models["test-model"] = 10
+	if o.GetSessionStats().Models["test-model"] != 2 {
+		t.Error("expected GetSessionStats to return a copy of the model mix")
+	}
+}
@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxStallCheckInterval bounds how late a stall is noticed with a long
+// stall window.
+const maxStallCheckInterval = 10 * time.Second
+
+// StallDetection flags workers whose agent has gone quiet, which otherwise
+// looks the same as an agent thinking for a long time.
+type StallDetection struct {
+	// Window is how long an agent may produce no output before its worker
+	// counts as stalled. Zero disables stall detection.
+	Window time.Duration
+	// Kill stops a stalled agent. This counts as a failed run, so the task is
+	// retried under the retry policy.
+	Kill bool
+}
+
+// Validate reports whether the stall settings are usable.
+func (s StallDetection) Validate() error {
+	if s.Window < 0 {
+		return fmt.Errorf("window must be >= 0")
+	}
+	if s.Kill && s.Window == 0 {
+		return fmt.Errorf("kill needs a window")
+	}
+	return nil
+}
+
+// StallError reports an agent run that was killed for producing no output
+// for the stall window.
+type StallError struct {
+	Window time.Duration
+}
+
+func (e *StallError) Error() string {
+	return fmt.Sprintf("agent stalled: no output for %s", e.Window)
+}
+
+// WorkerStalledMsg is sent when a worker's agent has produced no output for
+// the stall window, and again with Stalled false once it writes again.
+type WorkerStalledMsg struct {
+	WorkerID int
+	Stalled  bool
+	// Silent is how long the agent has gone without output when it stalls,
+	// and roughly how long the gap was when it recovers.
+	Silent time.Duration
+}
+
+// GetStallDetection returns the stall detection settings.
+func (o *Orchestrator) GetStallDetection() StallDetection {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.stall
+}
+
+// SetStallDetection sets the stall detection settings for agents started
+// from now on. The zero value disables it.
+func (o *Orchestrator) SetStallDetection(s StallDetection) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.stall = s
+}
+
+// heartbeat records that the worker's agent just produced output.
+func (w *workerInstance) heartbeat() {
+	w.lastOutput.Store(time.Now().UnixNano())
+}
+
+// lastOutputAt returns when the worker's agent last produced output, or the
+// zero time if it hasn't started.
+func (w *workerInstance) lastOutputAt() time.Time {
+	if n := w.lastOutput.Load(); n != 0 {
+		return time.Unix(0, n)
+	}
+	return time.Time{}
+}
+
+// watchStall checks the worker's heartbeat until ctx is done, announcing
+// when the agent stalls and when it recovers. With s.Kill set, a stalled
+// agent is stopped through kill.
+func (o *Orchestrator) watchStall(ctx context.Context, worker *workerInstance, s StallDetection, kill context.CancelCauseFunc) {
+	interval := min(max(s.Window/10, time.Millisecond), maxStallCheckInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// quietSince is the last output before the agent stalled.
+	var quietSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			last := worker.lastOutputAt()
+			stalled := now.Sub(last) >= s.Window
+
+			o.workersMu.Lock()
+			changed := stalled != worker.stalled
+			worker.stalled = stalled
+			o.workersMu.Unlock()
+			if !changed {
+				continue
+			}
+
+			silent := now.Sub(last)
+			if stalled {
+				quietSince = last
+			} else {
+				silent = last.Sub(quietSince)
+			}
+			o.sendMsg(WorkerStalledMsg{WorkerID: worker.id, Stalled: stalled, Silent: silent})
+			if stalled && s.Kill {
+				kill(&StallError{Window: s.Window})
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestStallDetection_Validate(t *testing.T) {
+	if err := (StallDetection{Window: time.Minute, Kill: true}).Validate(); err != nil {
+		t.Errorf("expected a window with kill to be valid, got %v", err)
+	}
+	if err := (StallDetection{Window: -time.Second}).Validate(); err == nil {
+		t.Error("expected a negative window to be rejected")
+	}
+	if err := (StallDetection{Kill: true}).Validate(); err == nil {
+		t.Error("expected kill without a window to be rejected")
+	}
+}
+
+func TestStalledAgentIsKilledAndTaskRetried(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetStallDetection(StallDetection{Window: 200 * time.Millisecond, Kill: true})
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "echo thinking; exec sleep 10")
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	start := time.Now()
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected stalled agent to be killed promptly, took %s", elapsed)
+	}
+
+	got, _ := store.GetTask(context.Background(), "1")
+	if got.Status != models.TaskStatusPending {
+		t.Errorf("expected task to be reset to pending, got %s", got.Status)
+	}
+
+	o.failedTasksMu.RLock()
+	info := o.failedTasks["1"]
+	o.failedTasksMu.RUnlock()
+	if info == nil || info.failCount != 1 {
+		t.Errorf("expected the stall to count as a failure, got %+v", info)
+	}
+
+	var stalled bool
+	for len(o.msgChan) > 0 {
+		if msg, ok := (<-o.msgChan).(WorkerStalledMsg); ok && msg.Stalled {
+			stalled = true
+		}
+	}
+	if !stalled {
+		t.Error("expected a WorkerStalledMsg before the kill")
+	}
+}
+
+func TestWatchStallReportsRecovery(t *testing.T) {
+	o := NewOrchestrator(newMockTaskStore(), 1, "test-model")
+	worker := &workerInstance{id: 3}
+	worker.lastOutput.Store(time.Now().Add(-time.Hour).UnixNano())
+	o.workers[worker.id] = worker
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go o.watchStall(ctx, worker, StallDetection{Window: 50 * time.Millisecond}, func(error) {
+		t.Error("expected a stalled agent not to be killed without kill set")
+	})
+
+	next := func() WorkerStalledMsg {
+		t.Helper()
+		select {
+		case msg := <-o.msgChan:
+			return msg.(WorkerStalledMsg)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a WorkerStalledMsg")
+			return WorkerStalledMsg{}
+		}
+	}
+
+	if msg := next(); !msg.Stalled || msg.WorkerID != 3 || msg.Silent < time.Hour {
+		t.Errorf("expected worker 3 to stall after an hour of silence, got %+v", msg)
+	}
+	if w := o.Workers(); len(w) != 1 || !w[0].Stalled || w[0].LastOutputAt == nil {
+		t.Errorf("expected Workers to report the stall, got %+v", w)
+	}
+
+	worker.heartbeat()
+	if msg := next(); msg.Stalled {
+		t.Errorf("expected worker 3 to recover once it wrote output, got %+v", msg)
+	}
+	if w := o.Workers(); w[0].Stalled {
+		t.Error("expected Workers to report the recovery")
+	}
+}
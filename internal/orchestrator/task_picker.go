@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// availableTasksLoadedMsg carries the tasks the task picker offers.
+type availableTasksLoadedMsg struct {
+	tasks []*models.Task
+	err   error
+}
+
+// loadAvailableTasks reads the available tasks for the task picker.
+func (m *OrchestratorModel) loadAvailableTasks() tea.Cmd {
+	store := m.orchestrator.store
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), graphLoadTimeout)
+		defer cancel()
+		tasks, err := store.GetAvailableTasks(ctx)
+		return availableTasksLoadedMsg{tasks: tasks, err: err}
+	}
+}
+
+// toggleTaskPicker opens or closes the task picker, loading the available
+// tasks when it opens.
+func (m *OrchestratorModel) toggleTaskPicker() tea.Cmd {
+	m.showTaskPicker = !m.showTaskPicker
+	m.pickerTasks, m.pickerErr, m.pickerIndex = nil, nil, 0
+	if m.showTaskPicker {
+		return m.loadAvailableTasks()
+	}
+	return nil
+}
+
+// updateTaskPicker handles a key while the task picker is open.
+func (m *OrchestratorModel) updateTaskPicker(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "t", "T", "esc":
+		return m.toggleTaskPicker()
+	case "up", "k":
+		m.movePickerSelection(-1)
+	case "down", "j":
+		m.movePickerSelection(1)
+	case "enter":
+		if len(m.pickerTasks) == 0 {
+			break
+		}
+		m.orchestrator.AssignTask(m.pickerTasks[m.pickerIndex])
+		return m.toggleTaskPicker()
+	}
+	return nil
+}
+
+func (m *OrchestratorModel) movePickerSelection(direction int) {
+	if len(m.pickerTasks) == 0 {
+		return
+	}
+	m.pickerIndex = (m.pickerIndex + direction + len(m.pickerTasks)) % len(m.pickerTasks)
+}
+
+// renderTaskPicker draws the task picker over background.
+func (m *OrchestratorModel) renderTaskPicker(background string) string {
+	var list strings.Builder
+	switch {
+	case m.pickerErr != nil:
+		list.WriteString(statusFailedStyle.Render(fmt.Sprintf("Failed to load tasks: %v", m.pickerErr)))
+	case m.pickerTasks == nil:
+		list.WriteString("Loading...")
+	case len(m.pickerTasks) == 0:
+		list.WriteString("No tasks are available")
+	default:
+		assigned := make(map[string]bool)
+		for _, t := range m.orchestrator.AssignedTasks() {
+			assigned[t.ID] = true
+		}
+
+		// Show a window of the list around the selection.
+		rows := max(m.height-12, 3)
+		start := max(0, min(m.pickerIndex-rows/2, len(m.pickerTasks)-rows))
+		end := min(start+rows, len(m.pickerTasks))
+		for i := start; i < end; i++ {
+			t := m.pickerTasks[i]
+			line := fmt.Sprintf("%s (%s, P%d)", t.Name, t.FeatureName, t.Priority)
+			if assigned[t.ID] {
+				line += " (assigned)"
+			}
+			if i == m.pickerIndex {
+				list.WriteString(modelModalSelectedStyle.Render("→ "+line) + "\n")
+			} else {
+				list.WriteString("  " + line + "\n")
+			}
+		}
+	}
+
+	content := modelModalTitleStyle.Render("Run Task Next") + "\n\n" +
+		strings.TrimRight(list.String(), "\n") + "\n\n" +
+		modelModalHintStyle.Render("J/K or arrows to navigate, Enter to give it to the next free worker, T/Esc to close")
+	return m.overlayModal(background, content)
+}
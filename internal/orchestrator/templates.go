@@ -0,0 +1,22 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+)
+
+// templateInterval is how often the orchestrator looks for recurring
+// templates that are due.
+const templateInterval = time.Minute
+
+// materializeTemplates creates the tasks of recurring templates whose next
+// run has passed. It runs on the main loop.
+func (o *Orchestrator) materializeTemplates() {
+	tasks, err := o.store.MaterializeDueTemplates(o.ctx, time.Now())
+	for _, task := range tasks {
+		o.sendMsg(StatusMsg{WorkerID: 0, Message: fmt.Sprintf("Created recurring task %s/%s", task.FeatureName, task.Name)})
+	}
+	if err != nil {
+		o.sendMsg(StatusMsg{WorkerID: 0, Message: fmt.Sprintf("Error creating recurring tasks: %v", err)})
+	}
+}
@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// TaskTimeouts limits how long an agent may run on a single task before the
+// orchestrator kills it and hands the task back to the queue.
+type TaskTimeouts struct {
+	// Default applies to every task without an override. Zero means no limit.
+	Default time.Duration
+	// Overrides maps "feature/task" to a task-specific limit. Zero disables
+	// the limit for that task.
+	Overrides map[string]time.Duration
+}
+
+// Validate reports whether the timeout values are usable.
+func (t TaskTimeouts) Validate() error {
+	if t.Default < 0 {
+		return fmt.Errorf("max_task_duration must be >= 0")
+	}
+	for key, d := range t.Overrides {
+		if d < 0 {
+			return fmt.Errorf("max_task_duration for %s must be >= 0", key)
+		}
+	}
+	return nil
+}
+
+// For returns the limit that applies to task, or zero for none.
+func (t TaskTimeouts) For(task *models.Task) time.Duration {
+	if d, ok := t.Overrides[task.FeatureName+"/"+task.Name]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// TaskTimeoutError reports an agent run that was killed for exceeding its
+// task's time limit.
+type TaskTimeoutError struct {
+	Limit time.Duration
+}
+
+func (e *TaskTimeoutError) Error() string {
+	return fmt.Sprintf("agent timed out after %s", e.Limit)
+}
+
+// GetTaskTimeouts returns the configured per-task time limits.
+func (o *Orchestrator) GetTaskTimeouts() TaskTimeouts {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.timeouts
+}
+
+// SetTaskTimeouts sets the per-task time limits. The zero value disables them.
+func (o *Orchestrator) SetTaskTimeouts(t TaskTimeouts) {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.timeouts = t
+}
@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestTaskTimeouts_For(t *testing.T) {
+	timeouts := TaskTimeouts{
+		Default:   time.Minute,
+		Overrides: map[string]time.Duration{"core/slow": time.Hour, "core/unbounded": 0},
+	}
+
+	cases := map[string]time.Duration{
+		"fast":      time.Minute,
+		"slow":      time.Hour,
+		"unbounded": 0,
+	}
+	for name, want := range cases {
+		if got := timeouts.For(&models.Task{FeatureName: "core", Name: name}); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+
+	if err := (TaskTimeouts{Overrides: map[string]time.Duration{"a/b": -time.Second}}).Validate(); err == nil {
+		t.Error("expected negative override to be rejected")
+	}
+}
+
+func TestTaskTimeoutKillsAgentAndResetsTask(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetTaskTimeouts(TaskTimeouts{Default: 200 * time.Millisecond})
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "10")
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	start := time.Now()
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected agent to be killed promptly, took %s", elapsed)
+	}
+
+	got, _ := store.GetTask(context.Background(), "1")
+	if got.Status != models.TaskStatusPending {
+		t.Errorf("expected task to be reset to pending, got %s", got.Status)
+	}
+
+	o.failedTasksMu.RLock()
+	info := o.failedTasks["1"]
+	o.failedTasksMu.RUnlock()
+	if info == nil || info.failCount != 1 {
+		t.Errorf("expected timeout to count as a failure, got %+v", info)
+	}
+}
@@ -0,0 +1,61 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// transcript collects what an agent writes to stdout and stderr, which
+// exec.Cmd copies from separate goroutines, for its run record.
+type transcript struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (t *transcript) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.Write(p)
+}
+
+func (t *transcript) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.String()
+}
+
+// exitCode returns the agent's exit code given the error running it, or -1
+// when it was killed or never started.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// recordRun stores the transcript of a run on the task. Failing to store it
+// is reported but doesn't fail the task.
+func (o *Orchestrator) recordRun(workerID int, task *models.Task, run *models.Run) {
+	ctx, cancel := context.WithTimeout(actor.With(context.Background(), fmt.Sprintf("orchestrator:worker-%d", workerID)), 5*time.Second)
+	defer cancel()
+
+	run.TaskID = task.ID
+	if err := o.store.RecordRun(ctx, run); err != nil {
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Failed to record run of %s: %v", task.Name, err),
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func TestRunWorkerRecordsTranscript(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "cat >/dev/null; echo agent output; echo agent error >&2; exit 3")
+	}
+
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(store.runs))
+	}
+	run := store.runs[0]
+	if run.TaskID != "1" || run.Model != "test-model" {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if !strings.Contains(run.Prompt, "task1") {
+		t.Errorf("prompt does not mention the task:\n%s", run.Prompt)
+	}
+	for _, want := range []string{"agent output", "agent error"} {
+		if !strings.Contains(run.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, run.Output)
+		}
+	}
+	if run.ExitCode != 3 || run.Error != "exit status 3" {
+		t.Errorf("exit code = %d, error = %q; want 3, exit status 3", run.ExitCode, run.Error)
+	}
+	if run.StartedAt.IsZero() {
+		t.Error("expected started_at to be set")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if got := exitCode(nil); got != 0 {
+		t.Errorf("exitCode(nil) = %d, want 0", got)
+	}
+	if got := exitCode(exec.ErrNotFound); got != -1 {
+		t.Errorf("exitCode of a command that never started = %d, want -1", got)
+	}
+}
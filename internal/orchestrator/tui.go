@@ -10,49 +10,72 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/nick-dorsch/ponder/internal/ui/components"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
+	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
 var (
+	orbStyle                lipgloss.Style
+	headerTextStyle         lipgloss.Style
+	completedHeaderStyle    lipgloss.Style
+	statsStyle              lipgloss.Style
+	helpStyle               lipgloss.Style
+	headerStyle             lipgloss.Style
+	modelModalStyle         lipgloss.Style
+	modelModalTitleStyle    lipgloss.Style
+	modelModalSelectedStyle lipgloss.Style
+	modelModalHintStyle     lipgloss.Style
+	sidebarStyle            lipgloss.Style
+)
+
+func init() { theme.OnChange(setTUIStyles) }
+
+// setTUIStyles colors the orchestrator TUI's styles from t.
+func setTUIStyles(t theme.Theme) {
 	orbStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("86")). // Ponder cyan #22d3ee
-			Bold(true)
+		Foreground(t.Logo).
+		Bold(true)
 
 	headerTextStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("39")).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(t.Title).
+		Padding(0, 1)
 
 	completedHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("42")).
-				Padding(0, 1)
+		Bold(true).
+		Foreground(t.Success).
+		Padding(0, 1)
 
 	statsStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Italic(true)
+		Foreground(t.Muted).
+		Italic(true)
 
 	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241"))
+		Foreground(t.Muted)
 
 	headerStyle = lipgloss.NewStyle().
-			Padding(1, 2)
+		Padding(1, 2)
 
 	modelModalStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("45")).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Accent).
+		Padding(1, 2)
 
 	modelModalTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("39"))
+		Bold(true).
+		Foreground(t.Title)
 
 	modelModalSelectedStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("42")).
-				Bold(true)
+		Foreground(t.Success).
+		Bold(true)
 
 	modelModalHintStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241"))
-)
+		Foreground(t.Muted)
+
+	sidebarStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, true, false, false).
+		BorderForeground(t.Subtle)
+}
 
 type OrchestratorModel struct {
 	orchestrator   *Orchestrator
@@ -71,6 +94,16 @@ type OrchestratorModel struct {
 	workersWidth   int
 	showModelMenu  bool
 	modelIndex     int
+	// The dependency graph pane replaces the workers while shown.
+	showGraph   bool
+	graphLines  []string
+	graphErr    error
+	graphOffset int
+	// The task picker lists the available tasks to assign to a worker.
+	showTaskPicker bool
+	pickerTasks    []*models.Task
+	pickerErr      error
+	pickerIndex    int
 }
 
 func NewOrchestratorModel(orch *Orchestrator) *OrchestratorModel {
@@ -80,7 +113,7 @@ func NewOrchestratorModel(orch *Orchestrator) *OrchestratorModel {
 	workerViews := make(map[int]*WorkerView)
 	workerOrder := make([]int, 0)
 
-	for i := 1; i <= orch.maxWorkers; i++ {
+	for i := 1; i <= orch.GetMaxWorkers(); i++ {
 		view := NewWorkerView(i, 80, 6)
 		workerViews[i] = view
 		if i <= orch.GetTargetWorkers() {
@@ -127,6 +160,16 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.MouseMsg:
 	case tea.KeyMsg:
+		// Keys typed into a search prompt are not commands.
+		if msg.String() != "ctrl+c" && m.isAnyWorkerSearching() {
+			break
+		}
+		if msg.String() != "ctrl+c" && m.showTaskPicker {
+			if cmd := m.updateTaskPicker(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			m.quitting = true
@@ -140,12 +183,18 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc":
 			if m.showModelMenu {
 				m.showModelMenu = false
+			} else if m.showGraph {
+				m.toggleGraph()
 			}
 		case "up", "k":
 			if m.showModelMenu {
 				m.moveModelSelection(-1)
 				break
 			}
+			if m.showGraph {
+				m.scrollGraph(-1)
+				break
+			}
 			if !m.isAnyWorkerExpanded() {
 				m.moveFocus(-1)
 			}
@@ -154,6 +203,10 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.moveModelSelection(1)
 				break
 			}
+			if m.showGraph {
+				m.scrollGraph(1)
+				break
+			}
 			if !m.isAnyWorkerExpanded() {
 				m.moveFocus(1)
 			}
@@ -162,6 +215,9 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.selectCurrentModel()
 				break
 			}
+			if m.showGraph {
+				break
+			}
 			m.toggleExpanded()
 		case "a", "A":
 			if m.showModelMenu {
@@ -178,10 +234,40 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.removeIdleWorkerView()
 			}
 		case "e":
-			if m.showModelMenu {
+			if m.showModelMenu || m.showGraph {
 				break
 			}
 			m.toggleExpanded()
+		case "g", "G":
+			if m.showModelMenu || m.isAnyWorkerExpanded() {
+				break
+			}
+			if cmd := m.toggleGraph(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		case "p", "P":
+			if m.showModelMenu {
+				break
+			}
+			m.orchestrator.TogglePause()
+		case "x", "X":
+			// In an expanded worker, x filters its output instead.
+			if m.showModelMenu || m.showGraph || m.isAnyWorkerExpanded() {
+				break
+			}
+			m.orchestrator.StopWorker(m.focusedWorker)
+		case "r", "R":
+			if m.showModelMenu || m.showGraph || m.isAnyWorkerExpanded() {
+				break
+			}
+			m.orchestrator.RestartWorker(m.focusedWorker)
+		case "t", "T":
+			if m.showModelMenu || m.isAnyWorkerExpanded() {
+				break
+			}
+			if cmd := m.toggleTaskPicker(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
 		}
 
 	case tea.WindowSizeMsg:
@@ -195,11 +281,31 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.completedTasks.Add(components.TaskResult{
 			Name:    msg.TaskName,
 			Success: msg.Success,
+			Branch:  msg.Branch,
 		}, 100)
 
 	case IdleStateMsg:
 		m.isIdle = msg.Idle
 
+	case graphLoadedMsg:
+		m.graphErr = msg.err
+		if msg.err == nil {
+			m.graphLines = dependencyTree(msg.tasks, msg.deps, time.Now())
+			m.scrollGraph(0)
+		}
+
+	case availableTasksLoadedMsg:
+		m.pickerErr = msg.err
+		if msg.err == nil {
+			m.pickerTasks = msg.tasks
+			if m.pickerTasks == nil {
+				m.pickerTasks = []*models.Task{}
+			}
+		}
+
+	case TargetWorkersMsg:
+		m.syncWorkerViews(msg.Target)
+
 	case error:
 		m.err = msg
 		return m, tea.Quit
@@ -212,10 +318,18 @@ func (m *OrchestratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg.(type) {
-	case WorkerStartedMsg, TaskStartedMsg, OutputMsg, StatusMsg, TaskCompletedMsg, IdleStateMsg, error:
+	case WorkerStartedMsg, TaskStartedMsg, OutputMsg, StatusMsg, WorkerStalledMsg, TaskCompletedMsg, IdleStateMsg, PauseStateMsg, TargetWorkersMsg, error:
 		cmds = append(cmds, m.pollMessages())
 	}
 
+	// Claims and completions change the graph.
+	switch msg.(type) {
+	case WorkerStartedMsg, TaskCompletedMsg, IdleStateMsg:
+		if m.showGraph {
+			cmds = append(cmds, m.loadGraph())
+		}
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -252,7 +366,7 @@ func (m *OrchestratorModel) moveFocus(direction int) {
 }
 
 func (m *OrchestratorModel) addWorkerView() {
-	for i := 1; i <= m.orchestrator.maxWorkers; i++ {
+	for i := 1; i <= m.orchestrator.GetMaxWorkers(); i++ {
 		exists := false
 		for _, id := range m.workerOrder {
 			if id == i {
@@ -261,6 +375,10 @@ func (m *OrchestratorModel) addWorkerView() {
 			}
 		}
 		if !exists {
+			// The maximum may have grown since the views were made.
+			if _, ok := m.workerViews[i]; !ok {
+				m.workerViews[i] = NewWorkerView(i, 80, 6)
+			}
 			m.workerOrder = append(m.workerOrder, i)
 			if m.focusedWorker == 0 {
 				m.focusedWorker = i
@@ -313,6 +431,21 @@ func (m *OrchestratorModel) removeIdleWorkerView() {
 	m.scrollIntoView()
 }
 
+// syncWorkerViews adds or removes worker views until there is one per
+// target worker. Views of busy workers are kept until they finish.
+func (m *OrchestratorModel) syncWorkerViews(target int) {
+	for len(m.workerOrder) < target && len(m.workerOrder) < m.orchestrator.GetMaxWorkers() {
+		m.addWorkerView()
+	}
+	for len(m.workerOrder) > target {
+		n := len(m.workerOrder)
+		m.removeIdleWorkerView()
+		if len(m.workerOrder) == n {
+			break
+		}
+	}
+}
+
 func (m *OrchestratorModel) scrollIntoView() {
 	if len(m.workerOrder) == 0 {
 		return
@@ -358,6 +491,9 @@ func (m *OrchestratorModel) toggleExpanded() {
 			for _, v := range m.workerViews {
 				v.SetExpanded(false)
 			}
+			if h := m.orchestrator.History(); h != nil {
+				view.Replay(h.WorkerRun(view.WorkerID))
+			}
 		}
 		view.SetExpanded(expanded)
 		m.recalculateLayout()
@@ -374,6 +510,15 @@ func (m *OrchestratorModel) isAnyWorkerExpanded() bool {
 	return false
 }
 
+func (m *OrchestratorModel) isAnyWorkerSearching() bool {
+	for _, v := range m.workerViews {
+		if v.IsSearching() {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *OrchestratorModel) recalculateLayout() {
 	if !m.ready {
 		return
@@ -434,11 +579,9 @@ func (m *OrchestratorModel) View() string {
 	}
 
 	sidebarContent := m.renderCompletedTasks()
-	sidebar := lipgloss.NewStyle().
-		Width(m.sidebarWidth-1).
+	sidebar := sidebarStyle.
+		Width(m.sidebarWidth - 1).
 		Height(availableHeight).
-		Border(lipgloss.NormalBorder(), false, true, false, false).
-		BorderForeground(lipgloss.Color("240")).
 		Render(sidebarContent)
 
 	var workerList strings.Builder
@@ -472,6 +615,10 @@ func (m *OrchestratorModel) View() string {
 		clippedWorkers += strings.Repeat("\n", availableHeight-clippedHeight)
 	}
 
+	if m.showGraph {
+		clippedWorkers = m.renderGraph(m.workersWidth-2, availableHeight)
+	}
+
 	workersArea := lipgloss.NewStyle().
 		Width(m.workersWidth).
 		Height(availableHeight).
@@ -483,6 +630,9 @@ func (m *OrchestratorModel) View() string {
 	if m.showModelMenu {
 		return m.renderModelMenu(fullView)
 	}
+	if m.showTaskPicker {
+		return m.renderTaskPicker(fullView)
+	}
 
 	return fullView
 }
@@ -490,7 +640,11 @@ func (m *OrchestratorModel) View() string {
 func (m *OrchestratorModel) renderHeader() string {
 	total, completed := m.orchestrator.GetStats()
 	status := "Active"
-	if m.isIdle {
+	if m.orchestrator.OverBudget() {
+		status = "Over budget, finishing"
+	} else if m.orchestrator.IsPaused() {
+		status = "Paused"
+	} else if m.isIdle {
 		status = "Waiting for tasks..."
 	}
 
@@ -499,10 +653,14 @@ func (m *OrchestratorModel) renderHeader() string {
 		m.orchestrator.GetModel(),
 		len(m.orchestrator.GetActiveWorkers()),
 		m.orchestrator.GetTargetWorkers(),
-		m.orchestrator.maxWorkers,
+		m.orchestrator.GetMaxWorkers(),
 		completed,
 		total,
 	)
+	if usage := m.orchestrator.GetUsage(); !usage.IsZero() {
+		headerText += fmt.Sprintf(" | Tokens: %s in / %s out | Cost: %s",
+			formatTokens(usage.TokensIn), formatTokens(usage.TokensOut), formatCost(usage))
+	}
 	if m.orchestrator.WebURL != "" {
 		headerText += fmt.Sprintf(" | Web UI: %s", m.orchestrator.WebURL)
 	}
@@ -515,7 +673,16 @@ func (m *OrchestratorModel) renderHeader() string {
 }
 
 func (m *OrchestratorModel) renderHelp() string {
-	help := "[Q]uit • [A]dd Worker • [D]elete Worker • [M]odel • [J]/[K] • [E]/[Enter] Expand"
+	help := "[Q]uit • [P]ause • [A]dd/[D]rop • [M]odel • [J/K] • [E]xpand • [G]raph • [T]ask"
+	// The worker keys are shown when there is room for them.
+	if workerKeys := " • [X] Stop/[R]estart Worker"; lipgloss.Width(help+workerKeys) <= m.width {
+		help += workerKeys
+	}
+	if m.showGraph {
+		help = "[Q]uit • [P]ause • [A]dd/[D]rop Worker • [M]odel • [J]/[K] Scroll • [G] Close Graph"
+	} else if m.isAnyWorkerExpanded() {
+		help = "[Q]uit • [P]ause • [E] Collapse • [/] Search • n/N Next/Prev • [X] Errors only"
+	}
 	return helpStyle.Render(help)
 }
 
@@ -594,6 +761,11 @@ func (m *OrchestratorModel) renderModelMenu(background string) string {
 		strings.TrimRight(list.String(), "\n") + "\n\n" +
 		modelModalHintStyle.Render("J/K or arrows to navigate, Enter to apply, M/Esc to close")
 
+	return m.overlayModal(background, content)
+}
+
+// overlayModal draws content in a bordered box centred over background.
+func (m *OrchestratorModel) overlayModal(background, content string) string {
 	modalWidthTarget := m.width / 2
 	if modalWidthTarget < 32 {
 		modalWidthTarget = 32
@@ -211,6 +211,18 @@ func TestOrchestratorModel_RenderHeader_WithOrb(t *testing.T) {
 	if !strings.Contains(header, "Active") {
 		t.Error("header missing 'Active' status when not idle")
 	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if !orch.IsPaused() {
+		t.Error("expected p to pause the orchestrator")
+	}
+	if header = m.renderHeader(); !strings.Contains(header, "Paused") {
+		t.Error("header missing 'Paused' status when paused")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if orch.IsPaused() {
+		t.Error("expected p to resume the orchestrator")
+	}
 }
 
 func TestOrchestratorModel_GetHeaderHeight(t *testing.T) {
@@ -363,6 +375,24 @@ func TestOrchestratorModel_DeleteWorkerKeybindOnlyIdle(t *testing.T) {
 	}
 }
 
+func TestOrchestratorModel_FollowsTargetWorkers(t *testing.T) {
+	store := newMockTaskStore()
+	orch := NewOrchestrator(store, 3, "test-model")
+	m := NewOrchestratorModel(orch)
+
+	orch.SetTargetWorkers(2)
+	orch.reportTargetWorkers()
+	m.Update(<-orch.Messages())
+	if len(m.workerOrder) != 2 {
+		t.Fatalf("expected 2 worker views, got %d", len(m.workerOrder))
+	}
+
+	m.Update(TargetWorkersMsg{Target: 0})
+	if len(m.workerOrder) != 0 {
+		t.Errorf("expected idle worker views to be removed, got %d", len(m.workerOrder))
+	}
+}
+
 func TestOrchestratorModel_ModelMenuSelection(t *testing.T) {
 	store := newMockTaskStore()
 	orch := NewOrchestrator(store, 3, "model-one")
@@ -403,3 +433,37 @@ func TestOrchestratorModel_ModelMenuShownInView(t *testing.T) {
 		t.Fatalf("expected model list to include configured models")
 	}
 }
+
+func TestOrchestratorModel_SearchCapturesKeys(t *testing.T) {
+	store := newMockTaskStore()
+	orch := NewOrchestrator(store, 3, "test-model")
+	orch.SetTargetWorkers(2)
+	m := NewOrchestratorModel(orch)
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m.Update(OutputMsg{WorkerID: 1, Output: "building\nquality gate passed\n"})
+	m.Update(OutputMsg{WorkerID: 1, Output: "boom\n", Stderr: true})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	for _, r := range "pa" {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if m.quitting || orch.IsPaused() || len(m.workerOrder) != 2 {
+		t.Fatalf("expected keys typed into the search to not run commands")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	view := m.workerViews[1]
+	if !view.IsExpanded() {
+		t.Errorf("expected enter to submit the search, not collapse the worker")
+	}
+	if view.IsSearching() || view.Output.Matches() != 1 {
+		t.Errorf("expected one match for %q, got %d", "pa", view.Output.Matches())
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if !view.Output.ErrorsOnly() {
+		t.Errorf("expected x to toggle errors only")
+	}
+}
@@ -0,0 +1,252 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/actor"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Usage is the token usage and cost of one or more agent runs.
+type Usage struct {
+	TokensIn  int64
+	TokensOut int64
+	CostUSD   float64
+	// Estimated is set when the cost was computed from configured pricing
+	// because the agent did not report one.
+	Estimated bool
+}
+
+// IsZero reports whether no usage was recorded.
+func (u Usage) IsZero() bool {
+	return u.TokensIn == 0 && u.TokensOut == 0 && u.CostUSD == 0
+}
+
+func (u *Usage) add(other Usage) {
+	u.TokensIn += other.TokensIn
+	u.TokensOut += other.TokensOut
+	u.CostUSD += other.CostUSD
+	u.Estimated = u.Estimated || other.Estimated
+}
+
+// ModelPrice is what a model costs in USD per million tokens.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// Cost returns the price of the given token counts.
+func (p ModelPrice) Cost(tokensIn, tokensOut int64) float64 {
+	return (float64(tokensIn)*p.Input + float64(tokensOut)*p.Output) / 1e6
+}
+
+// GetPricing returns a copy of the per-model prices used to estimate costs.
+func (o *Orchestrator) GetPricing() map[string]ModelPrice {
+	o.modelMu.RLock()
+	defer o.modelMu.RUnlock()
+
+	pricing := make(map[string]ModelPrice, len(o.pricing))
+	for model, price := range o.pricing {
+		pricing[model] = price
+	}
+	return pricing
+}
+
+// SetPricing sets the per-model prices used to estimate the cost of runs
+// whose agent output reports tokens but no cost.
+func (o *Orchestrator) SetPricing(pricing map[string]ModelPrice) {
+	o.modelMu.Lock()
+	defer o.modelMu.Unlock()
+	o.pricing = pricing
+}
+
+// GetUsage returns the usage of all runs since the orchestrator started.
+func (o *Orchestrator) GetUsage() Usage {
+	o.usageMu.Lock()
+	defer o.usageMu.Unlock()
+	return o.usage
+}
+
+// recordUsage estimates a missing cost, stores the run's usage on the task,
+// and adds it to the session totals.
+func (o *Orchestrator) recordUsage(workerID int, task *models.Task, model string, u Usage, costReported bool) Usage {
+	if u.IsZero() {
+		return u
+	}
+	if !costReported {
+		if price, ok := o.GetPricing()[model]; ok {
+			u.CostUSD = price.Cost(u.TokensIn, u.TokensOut)
+			u.Estimated = true
+		}
+	}
+
+	o.usageMu.Lock()
+	o.usage.add(u)
+	o.usageMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(actor.With(context.Background(), fmt.Sprintf("orchestrator:worker-%d", workerID)), 5*time.Second)
+	defer cancel()
+
+	err := o.store.RecordTaskUsage(ctx, &models.TaskUsage{
+		TaskID:    task.ID,
+		Model:     model,
+		TokensIn:  u.TokensIn,
+		TokensOut: u.TokensOut,
+		CostUSD:   u.CostUSD,
+		Estimated: u.Estimated,
+	})
+	if err != nil {
+		o.sendMsg(StatusMsg{
+			WorkerID: workerID,
+			Message:  fmt.Sprintf("Failed to record usage for %s: %v", task.Name, err),
+		})
+	}
+	return u
+}
+
+var (
+	tokensInPattern  = regexp.MustCompile(`(?i)(?:\b(?:input|prompt)[ _-]?tokens|\btokens[ _-]?in)\s*[:=]\s*([\d,]+)`)
+	tokensOutPattern = regexp.MustCompile(`(?i)(?:\b(?:output|completion)[ _-]?tokens|\btokens[ _-]?out)\s*[:=]\s*([\d,]+)`)
+	costPattern      = regexp.MustCompile(`(?i)\b(?:total[ _-]?)?cost(?:[ _-]?usd)?\s*[:=]\s*\$?(\d+(?:\.\d+)?)`)
+)
+
+// usageMeter scans agent output for usage reports. It understands JSON event
+// lines (opencode step events, Anthropic and OpenAI usage objects) as well as
+// plain "input tokens: N" / "cost: $X" summaries.
+type usageMeter struct {
+	mu           sync.Mutex
+	partial      []byte
+	usage        Usage
+	costReported bool
+}
+
+func (m *usageMeter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.partial = append(m.partial, p...)
+	for {
+		i := bytes.IndexByte(m.partial, '\n')
+		if i < 0 {
+			break
+		}
+		m.parseLine(string(m.partial[:i]))
+		m.partial = m.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Result flushes any unterminated line and returns the accumulated usage and
+// whether the agent reported a cost.
+func (m *usageMeter) Result() (Usage, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.partial) > 0 {
+		m.parseLine(string(m.partial))
+		m.partial = nil
+	}
+	return m.usage, m.costReported
+}
+
+func (m *usageMeter) parseLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	if strings.HasPrefix(line, "{") {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(line), &v); err == nil {
+			m.walkJSON(v)
+			return
+		}
+	}
+
+	if match := tokensInPattern.FindStringSubmatch(line); match != nil {
+		m.usage.TokensIn += parseCount(match[1])
+	}
+	if match := tokensOutPattern.FindStringSubmatch(line); match != nil {
+		m.usage.TokensOut += parseCount(match[1])
+	}
+	if match := costPattern.FindStringSubmatch(line); match != nil {
+		if cost, err := strconv.ParseFloat(match[1], 64); err == nil {
+			m.usage.CostUSD += cost
+			m.costReported = true
+		}
+	}
+}
+
+func (m *usageMeter) walkJSON(v map[string]any) {
+	for key, value := range v {
+		switch key {
+		case "input_tokens", "prompt_tokens":
+			m.usage.TokensIn += jsonCount(value)
+		case "output_tokens", "completion_tokens":
+			m.usage.TokensOut += jsonCount(value)
+		case "cost", "cost_usd", "total_cost_usd":
+			if cost, ok := value.(float64); ok {
+				m.usage.CostUSD += cost
+				m.costReported = true
+			}
+		case "tokens":
+			// opencode: {"input": n, "output": n, "reasoning": n, "cache": {...}}
+			if tokens, ok := value.(map[string]any); ok {
+				m.usage.TokensIn += jsonCount(tokens["input"])
+				m.usage.TokensOut += jsonCount(tokens["output"]) + jsonCount(tokens["reasoning"])
+			}
+		default:
+			switch value := value.(type) {
+			case map[string]any:
+				m.walkJSON(value)
+			case []any:
+				for _, item := range value {
+					if obj, ok := item.(map[string]any); ok {
+						m.walkJSON(obj)
+					}
+				}
+			}
+		}
+	}
+}
+
+func jsonCount(v any) int64 {
+	if n, ok := v.(float64); ok && n > 0 {
+		return int64(n)
+	}
+	return 0
+}
+
+func parseCount(s string) int64 {
+	n, _ := strconv.ParseInt(strings.ReplaceAll(s, ",", ""), 10, 64)
+	return n
+}
+
+// formatTokens renders a token count compactly, e.g. 950, 12.3k, 1.2M.
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
+
+// formatCost renders a cost in dollars, marking estimates with a tilde.
+func formatCost(u Usage) string {
+	cost := fmt.Sprintf("$%.2f", u.CostUSD)
+	if u.Estimated {
+		return "~" + cost
+	}
+	return cost
+}
@@ -0,0 +1,110 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"math"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestUsageMeter(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		want         Usage
+		costReported bool
+	}{
+		{
+			name: "opencode json steps",
+			output: `{"type":"step_finish","part":{"tokens":{"input":1000,"output":200,"reasoning":50,"cache":{"read":10}},"cost":0.01}}
+{"type":"step_finish","part":{"tokens":{"input":500,"output":100},"cost":0.005}}
+`,
+			want:         Usage{TokensIn: 1500, TokensOut: 350, CostUSD: 0.015},
+			costReported: true,
+		},
+		{
+			name:   "anthropic usage object",
+			output: `{"type":"message","usage":{"input_tokens":42,"output_tokens":7}}` + "\n",
+			want:   Usage{TokensIn: 42, TokensOut: 7},
+		},
+		{
+			name:         "text summary without trailing newline",
+			output:       "working...\nInput tokens: 12,345\nOutput tokens: 678\nTotal cost: $0.42",
+			want:         Usage{TokensIn: 12345, TokensOut: 678, CostUSD: 0.42},
+			costReported: true,
+		},
+		{
+			name:   "no usage",
+			output: "just some agent chatter\n{\"not\": \"usage\"}\n",
+			want:   Usage{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &usageMeter{}
+			// Split writes mid-line to make sure partial lines are buffered.
+			for i := 0; i < len(tt.output); i += 7 {
+				end := i + 7
+				if end > len(tt.output) {
+					end = len(tt.output)
+				}
+				io.WriteString(m, tt.output[i:end])
+			}
+
+			got, costReported := m.Result()
+			if got.TokensIn != tt.want.TokensIn || got.TokensOut != tt.want.TokensOut || math.Abs(got.CostUSD-tt.want.CostUSD) > 1e-9 {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+			if costReported != tt.costReported {
+				t.Errorf("expected costReported=%v, got %v", tt.costReported, costReported)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RecordsUsage(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetPricing(map[string]ModelPrice{"test-model": {Input: 1, Output: 10}})
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "echo", "input tokens: 1000000 output tokens: 100000")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := o.Start(ctx)
+	if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.usage) != 1 {
+		t.Fatalf("expected 1 usage record, got %d", len(store.usage))
+	}
+	u := store.usage[0]
+	if u.TaskID != "1" || u.Model != "test-model" || u.TokensIn != 1000000 || u.TokensOut != 100000 {
+		t.Errorf("unexpected usage record: %+v", u)
+	}
+	// 1M input at $1/M plus 100k output at $10/M.
+	if !u.Estimated || math.Abs(u.CostUSD-2) > 1e-9 {
+		t.Errorf("expected estimated cost of $2, got %+v", u)
+	}
+	if total := o.GetUsage(); total.TokensIn != 1000000 || !total.Estimated {
+		t.Errorf("unexpected session usage: %+v", total)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	for n, want := range map[int64]string{950: "950", 12345: "12.3k", 1_250_000: "1.2M"} {
+		if got := formatTokens(n); got != want {
+			t.Errorf("formatTokens(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
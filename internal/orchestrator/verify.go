@@ -0,0 +1,131 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/telemetry"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// verificationHeader marks the section appended to a task's specification
+// when its verification command fails.
+const verificationHeader = "### Verification Failed"
+
+// maxVerificationOutput caps how much command output is copied into the spec.
+const maxVerificationOutput = 4000
+
+// Verification is a project-level command, such as `go test ./...`, that must
+// pass after an agent exits before its task is allowed to stay completed.
+type Verification struct {
+	Command string
+	Timeout time.Duration
+}
+
+// VerificationError reports a failed verification command and its output.
+type VerificationError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("verification command %q failed: %v", e.Command, e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// GetVerification returns the configured verification, or nil if disabled.
+func (o *Orchestrator) GetVerification() *Verification {
+	o.workersMu.RLock()
+	defer o.workersMu.RUnlock()
+	return o.verification
+}
+
+// SetVerification sets the command run after each agent exits. Pass nil or an
+// empty command to disable verification.
+func (o *Orchestrator) SetVerification(v *Verification) {
+	if v != nil && strings.TrimSpace(v.Command) == "" {
+		v = nil
+	}
+
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	o.verification = v
+}
+
+// runVerification runs the verification command in dir. It returns nil when
+// verification is disabled or passes.
+func (o *Orchestrator) runVerification(ctx context.Context, workerID int, dir string) (err error) {
+	v := o.GetVerification()
+	if v == nil {
+		return nil
+	}
+
+	ctx, span := telemetry.Start(ctx, "orchestrator.verify")
+	defer func() { telemetry.End(span, err) }()
+
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+
+	o.sendMsg(StatusMsg{
+		WorkerID: workerID,
+		Message:  fmt.Sprintf("Verifying with: %s", v.Command),
+	})
+
+	var buf bytes.Buffer
+	cmd, err := o.command(ctx, workerID, dir, nil, "sh", "-c", v.Command)
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	if err := cmd.Run(); err != nil {
+		return &VerificationError{Command: v.Command, Output: buf.String(), Err: err}
+	}
+	return nil
+}
+
+// recordVerificationFailure reopens a task the agent marked completed and
+// replaces any previous verification section of its specification with the
+// latest command output, so the next worker can see what broke.
+func (o *Orchestrator) recordVerificationFailure(ctx context.Context, taskID string, verr *VerificationError) error {
+	current, err := o.store.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	spec := current.Specification
+	if idx := strings.Index(spec, verificationHeader); idx >= 0 {
+		spec = strings.TrimRight(spec[:idx], "\n")
+	}
+
+	output := strings.TrimSpace(verr.Output)
+	if len(output) > maxVerificationOutput {
+		output = "...\n" + output[len(output)-maxVerificationOutput:]
+	}
+
+	reopen := current.Status == models.TaskStatusCompleted || current.Status == models.TaskStatusInReview
+	current.Specification = fmt.Sprintf("%s\n\n%s\n`%s` failed: %v\n\n```\n%s\n```", spec, verificationHeader, verr.Command, verr.Err, output)
+	return o.store.WithBatchedChanges(ctx, func(ctx context.Context) error {
+		if reopen {
+			if err := o.store.UpdateTaskStatus(ctx, taskID, models.TaskStatusInProgress, nil); err != nil {
+				return err
+			}
+			current.Status = models.TaskStatusInProgress
+		}
+		return o.store.UpdateTask(ctx, current)
+	})
+}
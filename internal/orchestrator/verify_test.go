@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// newVerifyingOrchestrator returns an orchestrator whose agent marks the task
+// completed and whose verification command is run by sh.
+func newVerifyingOrchestrator(store *mockTaskStore, verifyCommand string) *Orchestrator {
+	o := NewOrchestrator(store, 1, "test-model")
+	o.SetVerification(&Verification{Command: verifyCommand})
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if name == "sh" {
+			return exec.CommandContext(ctx, name, arg...)
+		}
+		return exec.CommandContext(ctx, "true")
+	}
+	return o
+}
+
+func runTaskOnce(o *Orchestrator, store *mockTaskStore, id string) {
+	task, _ := store.ClaimNextTask(context.Background(), models.Claimer{}, DefaultClaimLease)
+	summary := "done"
+	store.UpdateTaskStatus(context.Background(), id, models.TaskStatusCompleted, &summary)
+
+	o.runWorker(context.Background(), &workerInstance{id: 0, task: task, done: make(chan struct{})})
+}
+
+func TestVerificationFailureReopensTask(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := newVerifyingOrchestrator(store, "echo 'FAIL: TestBroken'; exit 1")
+	runTaskOnce(o, store, "1")
+
+	task, _ := store.GetTask(context.Background(), "1")
+	if task.Status != models.TaskStatusPending {
+		t.Errorf("expected task to be reset to pending, got %s", task.Status)
+	}
+	if !strings.HasPrefix(task.Specification, "Test specification") {
+		t.Errorf("expected original specification to be kept, got %q", task.Specification)
+	}
+	if !strings.Contains(task.Specification, verificationHeader) || !strings.Contains(task.Specification, "FAIL: TestBroken") {
+		t.Errorf("expected verification output in specification, got %q", task.Specification)
+	}
+
+	// A second failure replaces the previous section instead of stacking.
+	// The retry comes once the backoff has run out.
+	store.nextTaskIndex = 0
+	store.SetClaimExclusions(nil)
+	runTaskOnce(o, store, "1")
+
+	task, _ = store.GetTask(context.Background(), "1")
+	if n := strings.Count(task.Specification, verificationHeader); n != 1 {
+		t.Errorf("expected one verification section, got %d", n)
+	}
+}
+
+func TestVerificationFailureCountsTowardRetries(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := newVerifyingOrchestrator(store, "exit 1")
+	o.SetRetryPolicy(RetryPolicy{MaxAttempts: 1, InitialBackoff: 1, MaxBackoff: 1, Multiplier: 1})
+	runTaskOnce(o, store, "1")
+
+	task, _ := store.GetTask(context.Background(), "1")
+	if task.Status != models.TaskStatusBlocked {
+		t.Errorf("expected task to be blocked after exhausting retries, got %s", task.Status)
+	}
+}
+
+func TestVerificationPassKeepsTaskCompleted(t *testing.T) {
+	store := newMockTaskStore()
+	store.addTask("1", "task1", 1)
+
+	o := newVerifyingOrchestrator(store, "exit 0")
+	runTaskOnce(o, store, "1")
+
+	task, _ := store.GetTask(context.Background(), "1")
+	if task.Status != models.TaskStatusCompleted {
+		t.Errorf("expected task to stay completed, got %s", task.Status)
+	}
+	if strings.Contains(task.Specification, verificationHeader) {
+		t.Errorf("expected specification to be untouched, got %q", task.Specification)
+	}
+}
+
+func TestSetVerificationIgnoresEmptyCommand(t *testing.T) {
+	o := NewOrchestrator(newMockTaskStore(), 1, "test-model")
+	o.SetVerification(&Verification{Command: "  "})
+	if o.GetVerification() != nil {
+		t.Error("expected empty verification command to disable verification")
+	}
+}
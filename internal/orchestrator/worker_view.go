@@ -7,51 +7,73 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nick-dorsch/ponder/internal/ui/components"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
 )
 
 var (
+	workerHeaderStyle    lipgloss.Style
+	workerUnderlineStyle lipgloss.Style
+	workerActiveStyle    lipgloss.Style
+	workerFocusedStyle   lipgloss.Style
+	statusRunningStyle   lipgloss.Style
+	statusSuccessStyle   lipgloss.Style
+	statusFailedStyle    lipgloss.Style
+	statusStalledStyle   lipgloss.Style
+)
+
+func init() { theme.OnChange(setWorkerViewStyles) }
+
+// setWorkerViewStyles colors the worker panes' styles from t.
+func setWorkerViewStyles(t theme.Theme) {
 	workerHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("205")).
-				Padding(0, 1)
+		Bold(true).
+		Foreground(t.Highlight).
+		Padding(0, 1)
 
 	workerUnderlineStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("205")).
-				Padding(0, 1)
+		Foreground(t.Highlight).
+		Padding(0, 1)
 
 	workerActiveStyle = lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder()).
-				BorderForeground(lipgloss.Color("63")).
-				Padding(0, 1)
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(t.Border).
+		Padding(0, 1)
 
 	workerFocusedStyle = lipgloss.NewStyle().
-				Border(lipgloss.ThickBorder()).
-				BorderForeground(lipgloss.Color("12")).
-				Padding(0, 1)
+		Border(lipgloss.ThickBorder()).
+		BorderForeground(t.FocusBorder).
+		Padding(0, 1)
 
 	statusRunningStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("33")).
-				Bold(true)
+		Foreground(t.Running).
+		Bold(true)
 
 	statusSuccessStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("42")).
-				Bold(true)
+		Foreground(t.Success).
+		Bold(true)
 
 	statusFailedStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("196")).
-				Bold(true)
-)
+		Foreground(t.Failure).
+		Bold(true)
+
+	statusStalledStyle = lipgloss.NewStyle().
+		Foreground(t.Warning).
+		Bold(true)
+}
 
 type WorkerView struct {
 	WorkerID int
 	TaskName string
-	Status   string // "running", "completed", "failed"
+	Status   string // "running", "stalled", "completed", "failed"
 	Output   *components.WorkerOutput
 	width    int
 	height   int
 	expanded bool
 	focused  bool
 	ready    bool
+	// replayed is the Seq of the last history message shown by Replay;
+	// live messages up to it are already on screen.
+	replayed uint64
 }
 
 func NewWorkerView(workerID int, width int, height int) *WorkerView {
@@ -110,10 +132,72 @@ func (w *WorkerView) CompleteTask(success bool) {
 	}
 }
 
+// SetStalled marks a running task as stalled, or running again once its
+// agent produces output.
+func (w *WorkerView) SetStalled(stalled bool) {
+	if w.Status != "running" && w.Status != "stalled" {
+		return
+	}
+	if stalled {
+		w.Status = "stalled"
+	} else {
+		w.Status = "running"
+	}
+}
+
 func (w *WorkerView) AppendOutput(output string) {
 	w.Output.Append(output)
 }
 
+// Replay rebuilds the view from the messages of the worker's latest run, as
+// kept by the orchestrator's History, so output the view missed or cleared
+// when the task finished is shown again. complete is false when the start of
+// the run is no longer in the history.
+func (w *WorkerView) Replay(events []HistoryEvent, complete bool) {
+	if len(events) == 0 {
+		return
+	}
+	w.Output.Reset()
+	if !complete {
+		w.Output.AppendStatus("Earlier output is no longer in the history")
+	}
+
+	// Consecutive chunks of the same stream are appended together, since
+	// every append re-renders the output.
+	var pending strings.Builder
+	pendingStderr := false
+	flush := func() {
+		if pending.Len() == 0 {
+			return
+		}
+		if pendingStderr {
+			w.Output.AppendStderr(pending.String())
+		} else {
+			w.Output.Append(pending.String())
+		}
+		pending.Reset()
+	}
+
+	for _, ev := range events {
+		switch msg := ev.Msg.(type) {
+		case TaskStartedMsg:
+			flush()
+			w.StartTask(msg.TaskName)
+		case OutputMsg:
+			if msg.Stderr != pendingStderr {
+				flush()
+				pendingStderr = msg.Stderr
+			}
+			pending.WriteString(msg.Output)
+		case TaskCompletedMsg:
+			flush()
+			w.CompleteTask(msg.Success)
+		}
+		w.replayed = ev.Seq
+	}
+	flush()
+}
+
 func (w *WorkerView) GetHeight() int {
 	if w.expanded {
 		return w.height
@@ -180,6 +264,8 @@ func (w *WorkerView) getStatusString() string {
 	switch w.Status {
 	case "running":
 		return statusRunningStyle.Render("RUNNING")
+	case "stalled":
+		return statusStalledStyle.Render("STALLED")
 	case "completed":
 		return statusSuccessStyle.Render("COMPLETED")
 	case "failed":
@@ -190,15 +276,27 @@ func (w *WorkerView) getStatusString() string {
 }
 
 func (w *WorkerView) Update(msg tea.Msg) tea.Cmd {
+	if seq := messageSeq(msg); seq != 0 && seq <= w.replayed {
+		return nil
+	}
+
 	switch msg := msg.(type) {
 	case OutputMsg:
 		if msg.WorkerID == w.WorkerID {
-			w.AppendOutput(msg.Output)
+			if msg.Stderr {
+				w.Output.AppendStderr(msg.Output)
+			} else {
+				w.AppendOutput(msg.Output)
+			}
 		}
 	case TaskStartedMsg:
 		if msg.WorkerID == w.WorkerID {
 			w.StartTask(msg.TaskName)
 		}
+	case WorkerStalledMsg:
+		if msg.WorkerID == w.WorkerID {
+			w.SetStalled(msg.Stalled)
+		}
 	case TaskCompletedMsg:
 		if msg.WorkerID == w.WorkerID {
 			w.CompleteTask(msg.Success)
@@ -222,13 +320,19 @@ func (w *WorkerView) GetTaskName() string {
 }
 
 func (w *WorkerView) IsRunning() bool {
-	return w.Status == "running"
+	return w.Status == "running" || w.Status == "stalled"
 }
 
 func (w *WorkerView) IsExpanded() bool {
 	return w.expanded
 }
 
+// IsSearching reports whether a search query is being typed into the
+// worker's output.
+func (w *WorkerView) IsSearching() bool {
+	return w.expanded && w.Output.IsSearching()
+}
+
 func (w *WorkerView) IsFocused() bool {
 	return w.focused
 }
@@ -51,6 +51,15 @@ func TestWorkerView_IdleState(t *testing.T) {
 		t.Errorf("expected status to be RUNNING, got %s", w.getStatusString())
 	}
 
+	w.Update(WorkerStalledMsg{WorkerID: 1, Stalled: true})
+	if !strings.Contains(w.getStatusString(), "STALLED") || !w.IsRunning() {
+		t.Errorf("expected status to be STALLED, got %s", w.getStatusString())
+	}
+	w.Update(WorkerStalledMsg{WorkerID: 1})
+	if !strings.Contains(w.getStatusString(), "RUNNING") {
+		t.Errorf("expected status to be RUNNING once output resumes, got %s", w.getStatusString())
+	}
+
 	w.Reset()
 	if !strings.Contains(w.getStatusString(), "IDLE") {
 		t.Errorf("expected status to be IDLE after reset, got %s", w.getStatusString())
@@ -0,0 +1,146 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Worktree is an isolated checkout created for a single task.
+type Worktree struct {
+	Path   string
+	Branch string
+}
+
+// WorktreeManager creates one git worktree and branch per claimed task so that
+// concurrent workers never edit the same checkout. Branches are kept after the
+// worktree is removed so the work can be merged later.
+type WorktreeManager struct {
+	RepoDir      string
+	BaseDir      string
+	BranchPrefix string
+
+	// Env is appended to the worker environment, e.g. to point the agent's
+	// MCP server at the main checkout's database.
+	Env []string
+}
+
+// NewWorktreeManager returns a manager for the repository containing dir.
+// Worktrees are created under <repo>/.ponder/worktrees.
+func NewWorktreeManager(ctx context.Context, dir string) (*WorktreeManager, error) {
+	out, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("worktree mode requires a git repository: %w", err)
+	}
+	repoDir := strings.TrimSpace(out)
+
+	return &WorktreeManager{
+		RepoDir:      repoDir,
+		BaseDir:      filepath.Join(repoDir, ".ponder", "worktrees"),
+		BranchPrefix: "ponder/",
+	}, nil
+}
+
+// BranchName returns the branch used for a task. It is stable across retries
+// so a retried task continues from the previous attempt's commits.
+func (m *WorktreeManager) BranchName(task *models.Task) string {
+	return m.BranchPrefix + slugify(task.FeatureName) + "/" + slugify(task.Name) + "-" + shortID(task.ID)
+}
+
+// Create adds a worktree for the task, reusing its branch if one exists.
+func (m *WorktreeManager) Create(ctx context.Context, task *models.Task) (*Worktree, error) {
+	wt := &Worktree{
+		Path:   filepath.Join(m.BaseDir, slugify(task.Name)+"-"+shortID(task.ID)),
+		Branch: m.BranchName(task),
+	}
+
+	if err := os.MkdirAll(m.BaseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	// Clear out a worktree left behind by a crashed run.
+	if _, err := os.Stat(wt.Path); err == nil {
+		_, _ = runGit(ctx, m.RepoDir, "worktree", "remove", "--force", wt.Path)
+		_ = os.RemoveAll(wt.Path)
+	}
+	_, _ = runGit(ctx, m.RepoDir, "worktree", "prune")
+
+	args := []string{"worktree", "add"}
+	if _, err := runGit(ctx, m.RepoDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+wt.Branch); err == nil {
+		args = append(args, wt.Path, wt.Branch)
+	} else {
+		args = append(args, "-b", wt.Branch, wt.Path, "HEAD")
+	}
+
+	if _, err := runGit(ctx, m.RepoDir, args...); err != nil {
+		return nil, fmt.Errorf("failed to create worktree for %s: %w", task.Name, err)
+	}
+	return wt, nil
+}
+
+// Remove commits anything the agent left uncommitted onto the task branch and
+// deletes the worktree directory. The branch itself is kept.
+func (m *WorktreeManager) Remove(ctx context.Context, wt *Worktree, task *models.Task) error {
+	status, err := runGit(ctx, wt.Path, "status", "--porcelain")
+	if err != nil {
+		return fmt.Errorf("failed to inspect worktree %s: %w", wt.Path, err)
+	}
+
+	if strings.TrimSpace(status) != "" {
+		if _, err := runGit(ctx, wt.Path, "add", "-A"); err != nil {
+			return fmt.Errorf("failed to stage leftover changes: %w", err)
+		}
+		msg := fmt.Sprintf("ponder: uncommitted changes from %s/%s", task.FeatureName, task.Name)
+		if _, err := runGit(ctx, wt.Path, "commit", "--no-verify", "-m", msg); err != nil {
+			return fmt.Errorf("failed to commit leftover changes: %w", err)
+		}
+	}
+
+	if _, err := runGit(ctx, m.RepoDir, "worktree", "remove", "--force", wt.Path); err != nil {
+		return fmt.Errorf("failed to remove worktree %s: %w", wt.Path, err)
+	}
+	return nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func slugify(s string) string {
+	var sb strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+			lastDash = false
+		case !lastDash && sb.Len() > 0:
+			sb.WriteByte('-')
+			lastDash = true
+		}
+	}
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if slug == "" {
+		return "task"
+	}
+	return slug
+}
+
+func shortID(id string) string {
+	id = strings.ReplaceAll(id, "-", "")
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,155 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "ponder-test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "ponder-test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "ponder-test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "ponder-test@example.com")
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		if _, err := runGit(context.Background(), dir, args...); err != nil {
+			t.Fatalf("failed to set up repo: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Add Login Page":   "add-login-page",
+		"auth/oauth2 flow": "auth-oauth2-flow",
+		"--weird--":        "weird",
+		"!!!":              "task",
+	}
+	for in, want := range tests {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWorktreeManager_CreateAndRemove(t *testing.T) {
+	repo := initTestRepo(t)
+	ctx := context.Background()
+
+	wm, err := NewWorktreeManager(ctx, repo)
+	if err != nil {
+		t.Fatalf("NewWorktreeManager failed: %v", err)
+	}
+
+	task := &models.Task{ID: "0123456789abcdef", Name: "Add Login", FeatureName: "auth"}
+	wt, err := wm.Create(ctx, task)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if wt.Branch != "ponder/auth/add-login-01234567" {
+		t.Errorf("unexpected branch name %s", wt.Branch)
+	}
+	if _, err := os.Stat(wt.Path); err != nil {
+		t.Fatalf("expected worktree directory to exist: %v", err)
+	}
+
+	// Leave an uncommitted file behind; Remove should commit it to the branch.
+	if err := os.WriteFile(filepath.Join(wt.Path, "login.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := wm.Remove(ctx, wt, task); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	if _, err := os.Stat(wt.Path); !os.IsNotExist(err) {
+		t.Errorf("expected worktree directory to be removed, got %v", err)
+	}
+	out, err := runGit(ctx, repo, "show", wt.Branch+":login.txt")
+	if err != nil {
+		t.Fatalf("expected leftover file on branch: %v", err)
+	}
+	if strings.TrimSpace(out) != "hello" {
+		t.Errorf("unexpected file content %q", out)
+	}
+
+	// A retry reuses the branch and picks up the previous attempt's work.
+	wt2, err := wm.Create(ctx, task)
+	if err != nil {
+		t.Fatalf("second Create failed: %v", err)
+	}
+	if wt2.Branch != wt.Branch {
+		t.Errorf("expected branch %s to be reused, got %s", wt.Branch, wt2.Branch)
+	}
+	if _, err := os.Stat(filepath.Join(wt2.Path, "login.txt")); err != nil {
+		t.Errorf("expected previous work in reused worktree: %v", err)
+	}
+	if err := wm.Remove(ctx, wt2, task); err != nil {
+		t.Fatalf("second Remove failed: %v", err)
+	}
+}
+
+func TestNewWorktreeManager_RequiresRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if _, err := NewWorktreeManager(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected error outside a git repository")
+	}
+}
+
+func TestOrchestrator_RunsTaskInWorktree(t *testing.T) {
+	repo := initTestRepo(t)
+	ctx := context.Background()
+
+	wm, err := NewWorktreeManager(ctx, repo)
+	if err != nil {
+		t.Fatalf("NewWorktreeManager failed: %v", err)
+	}
+	wm.Env = []string{"PONDER_TEST_MARKER=from-orchestrator"}
+
+	o := NewOrchestrator(newMockTaskStore(), 1, "test-model")
+	o.SetWorktreeManager(wm)
+	o.cmdFactory = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", `echo "$PONDER_TEST_MARKER" > marker.txt`)
+	}
+
+	worker := &workerInstance{
+		id:   1,
+		task: &models.Task{ID: "task-1", Name: "marker", FeatureName: "misc"},
+	}
+	branch, err := o.executeTask(ctx, worker)
+	if err != nil {
+		t.Fatalf("executeTask failed: %v", err)
+	}
+	if branch != wm.BranchName(worker.task) {
+		t.Errorf("expected branch %s, got %s", wm.BranchName(worker.task), branch)
+	}
+
+	if _, err := os.Stat(filepath.Join(repo, "marker.txt")); !os.IsNotExist(err) {
+		t.Error("expected agent to run outside the main checkout")
+	}
+	out, err := runGit(ctx, repo, "show", branch+":marker.txt")
+	if err != nil {
+		t.Fatalf("expected agent output on task branch: %v", err)
+	}
+	if strings.TrimSpace(out) != "from-orchestrator" {
+		t.Errorf("expected worker env to be passed through, got %q", out)
+	}
+}
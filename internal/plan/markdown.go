@@ -0,0 +1,249 @@
+// Package plan turns Markdown design documents into features and tasks.
+//
+// A plan file uses headings for structure:
+//
+//	# Title (ignored)
+//
+//	## auth-system
+//	Login and sessions.            <- feature description (first paragraph)
+//
+//	More detail...                 <- feature specification (the rest)
+//
+//	### login-form
+//	Build the login form.          <- task description (first paragraph)
+//	Depends on: schema, core/config
+//	Priority: 8
+//
+//	Acceptance criteria...         <- task specification (the rest)
+//
+// "Depends on", "Priority" and "Tests required" lines in a task are read as
+// settings rather than text, with or without a list marker or bold. A
+// dependency is a task name in the same feature or feature/task. Headings
+// inside fenced code blocks are left alone.
+package plan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// Plan is the features and tasks read from a plan file, in file order.
+type Plan struct {
+	Features []*Feature
+}
+
+// Feature is a "##" section of a plan file.
+type Feature struct {
+	Name          string
+	Description   string
+	Specification string
+	Tasks         []db.BulkTask
+}
+
+// Tasks counts the tasks in all features.
+func (p *Plan) Tasks() int {
+	n := 0
+	for _, f := range p.Features {
+		n += len(f.Tasks)
+	}
+	return n
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,3})\s+(.*?)\s*#*\s*$`)
+	fencePattern   = regexp.MustCompile("^\\s*(```|~~~)")
+	settingPattern = regexp.MustCompile(`(?i)^\s*(?:[-*+]\s+)?[*_]*(depends on|priority|tests required)[*_]*\s*:[*_]*\s*(.*?)\s*$`)
+)
+
+// section collects the text under one heading.
+type section struct {
+	body []string
+	task *db.BulkTask
+}
+
+// Parse reads a plan file. Errors name the line they were found on.
+func Parse(r io.Reader) (*Plan, error) {
+	p := &Plan{}
+	var feature *Feature
+	var cur *section
+
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		description, specification := splitBody(cur.body)
+		if cur.task != nil {
+			cur.task.Description = description
+			cur.task.Specification = specification
+			feature.Tasks = append(feature.Tasks, *cur.task)
+		} else if feature != nil {
+			feature.Description = description
+			feature.Specification = specification
+		}
+		cur = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	inFence := false
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil && !inFence {
+			name := strings.Trim(m[2], "`*_ ")
+			switch len(m[1]) {
+			case 1:
+				flush()
+				continue
+			case 2:
+				flush()
+				if err := checkName(n, "feature", name); err != nil {
+					return nil, err
+				}
+				feature = &Feature{Name: name}
+				p.Features = append(p.Features, feature)
+				cur = &section{}
+				continue
+			case 3:
+				flush()
+				if feature == nil {
+					return nil, fmt.Errorf("line %d: task %q is not under a \"##\" feature heading", n, name)
+				}
+				if err := checkName(n, "task", name); err != nil {
+					return nil, err
+				}
+				cur = &section{task: &db.BulkTask{FeatureName: feature.Name, Name: name}}
+				continue
+			}
+		}
+
+		if cur == nil {
+			// Text before the first feature, such as an introduction.
+			continue
+		}
+		if cur.task != nil && !inFence {
+			if m := settingPattern.FindStringSubmatch(line); m != nil {
+				if err := applySetting(cur.task, strings.ToLower(m[1]), m[2]); err != nil {
+					return nil, fmt.Errorf("line %d: %w", n, err)
+				}
+				continue
+			}
+		}
+		cur.body = append(cur.body, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	flush()
+
+	if p.Tasks() == 0 {
+		return nil, fmt.Errorf("no tasks found: expected \"## feature\" headings with \"### task\" headings under them")
+	}
+	return p, nil
+}
+
+func checkName(line int, kind, name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("line %d: %s heading has no name", line, kind)
+	case len(name) > 55:
+		return fmt.Errorf("line %d: %s name %q is longer than 55 characters", line, kind, name)
+	}
+	return nil
+}
+
+func applySetting(t *db.BulkTask, key, value string) error {
+	switch key {
+	case "depends on":
+		for _, ref := range strings.Split(value, ",") {
+			ref = strings.Trim(strings.TrimSpace(ref), "`*_")
+			if ref == "" {
+				continue
+			}
+			if feature, name, ok := strings.Cut(ref, "/"); ok {
+				t.DependsOn = append(t.DependsOn, db.TaskRef{FeatureName: feature, Name: name})
+			} else {
+				t.DependsOn = append(t.DependsOn, db.TaskRef{Name: ref})
+			}
+		}
+	case "priority":
+		p, err := strconv.Atoi(strings.Trim(value, "`*_"))
+		if err != nil || p < 0 || p > 10 {
+			return fmt.Errorf("priority of task %s must be a number from 0 to 10, got %q", t.Name, value)
+		}
+		t.Priority = p
+	case "tests required":
+		var required bool
+		switch strings.ToLower(strings.Trim(value, "`*_")) {
+		case "yes", "true":
+			required = true
+		case "no", "false":
+		default:
+			return fmt.Errorf("tests required of task %s must be yes or no, got %q", t.Name, value)
+		}
+		t.TestsRequired = &required
+	}
+	return nil
+}
+
+// splitBody takes the first paragraph of a section as its description and
+// the rest as its specification. A section of one paragraph uses it for both,
+// since agents work from the specification.
+func splitBody(lines []string) (description, specification string) {
+	body := strings.TrimSpace(strings.Join(lines, "\n"))
+	if body == "" {
+		return "", ""
+	}
+	first, rest, _ := strings.Cut(body, "\n\n")
+	description = strings.Join(strings.Fields(first), " ")
+	rest = strings.TrimSpace(rest)
+	if rest == "" || strings.HasPrefix(first, "```") || strings.HasPrefix(first, "~~~") {
+		return description, body
+	}
+	return description, rest
+}
+
+// Summary describes what Stage staged.
+type Summary struct {
+	// NewFeatures are the features that will be created; the others exist.
+	NewFeatures map[string]bool
+}
+
+// Stage stages the plan's features and tasks under sessionID, to be checked
+// with ValidateBatch and applied with CommitBatch. Features that already
+// exist are not staged, so their sections add tasks to them.
+func Stage(ctx context.Context, database *db.DB, sessionID string, p *Plan) (*Summary, error) {
+	summary := &Summary{NewFeatures: make(map[string]bool)}
+	var tasks []db.BulkTask
+	for _, f := range p.Features {
+		existing, err := database.GetFeatureByName(ctx, f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil && !summary.NewFeatures[f.Name] {
+			summary.NewFeatures[f.Name] = true
+			database.Staging.AddFeature(sessionID, &models.Feature{
+				Name:          f.Name,
+				Description:   f.Description,
+				Specification: f.Specification,
+			})
+		}
+		tasks = append(tasks, f.Tasks...)
+	}
+	if _, err := database.StageTasks(sessionID, "", tasks); err != nil {
+		database.Staging.Discard(sessionID)
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,135 @@
+package plan
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+const samplePlan = `# Billing redesign
+
+Introductions are ignored.
+
+## billing
+Invoices and
+payments.
+
+Uses Stripe.
+
+### schema
+Add invoice tables.
+Priority: 9
+
+Tables: invoices, invoice_lines.
+
+` + "```sql" + `
+## not a feature
+Priority: not a setting
+` + "```" + `
+
+### ` + "`invoice-export`" + `
+Export invoices as PDF.
+- **Depends on:** schema, core/config
+- Tests required: no
+
+## core
+
+### config
+Central config loader.
+`
+
+func TestParse(t *testing.T) {
+	p, err := Parse(strings.NewReader(samplePlan))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(p.Features) != 2 || p.Tasks() != 3 {
+		t.Fatalf("expected 2 features and 3 tasks, got %d and %d", len(p.Features), p.Tasks())
+	}
+
+	billing := p.Features[0]
+	if billing.Name != "billing" || billing.Description != "Invoices and payments." || billing.Specification != "Uses Stripe." {
+		t.Errorf("unexpected feature %+v", billing)
+	}
+
+	schema := billing.Tasks[0]
+	if schema.Name != "schema" || schema.Priority != 9 || schema.Description != "Add invoice tables." {
+		t.Errorf("unexpected task %+v", schema)
+	}
+	if !strings.Contains(schema.Specification, "## not a feature") || !strings.Contains(schema.Specification, "Priority: not a setting") {
+		t.Errorf("expected the code block to stay in the specification, got %q", schema.Specification)
+	}
+
+	export := billing.Tasks[1]
+	if export.Name != "invoice-export" || export.FeatureName != "billing" {
+		t.Errorf("unexpected task %+v", export)
+	}
+	want := []db.TaskRef{{Name: "schema"}, {FeatureName: "core", Name: "config"}}
+	if len(export.DependsOn) != len(want) || export.DependsOn[0] != want[0] || export.DependsOn[1] != want[1] {
+		t.Errorf("expected dependencies %v, got %v", want, export.DependsOn)
+	}
+	if export.TestsRequired == nil || *export.TestsRequired {
+		t.Errorf("expected tests not required, got %v", export.TestsRequired)
+	}
+	// A single paragraph is both description and specification.
+	if export.Specification != "Export invoices as PDF." {
+		t.Errorf("unexpected specification %q", export.Specification)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"task without feature": "### orphan\ntext\n",
+		"bad priority":         "## f\n### t\nPriority: high\n",
+		"bad tests required":   "## f\n### t\nTests required: maybe\n",
+		"long name":            "## f\n### " + strings.Repeat("x", 56) + "\n",
+		"no tasks":             "## f\nJust a feature.\n",
+	}
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestStage(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+	if err := database.CreateFeature(ctx, &models.Feature{Name: "core", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+
+	p, err := Parse(strings.NewReader(samplePlan))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	summary, err := Stage(ctx, database, "plan", p)
+	if err != nil {
+		t.Fatalf("Stage failed: %v", err)
+	}
+	if !summary.NewFeatures["billing"] || summary.NewFeatures["core"] {
+		t.Errorf("expected only billing to be new, got %v", summary.NewFeatures)
+	}
+
+	if problems, err := database.ValidateBatch(ctx, "plan"); err != nil || len(problems) > 0 {
+		t.Fatalf("expected a valid batch, got %v (%v)", problems, err)
+	}
+	if err := database.CommitBatch(ctx, "plan"); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	core, _ := database.GetFeatureByName(ctx, "core")
+	config, _ := database.GetTaskByName(ctx, "config", core.ID)
+	if config == nil {
+		t.Error("expected config task in the existing core feature")
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authCookie carries the token for browsers, which cannot send an
+// Authorization header when loading a page.
+const authCookie = "ponder_token"
+
+// SetAuthToken requires token on every request, either as a bearer token or
+// in a cookie. Visiting any page with ?token=<token> sets the cookie, so the
+// web UI can be opened from a link. Empty turns authentication off.
+func (s *Server) SetAuthToken(token string) {
+	s.authToken = token
+}
+
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return RequireToken(s.authToken, next)
+}
+
+// RequireToken lets requests through to next only if they carry want, the
+// way SetAuthToken has the web UI check them. MCP over HTTP is guarded by
+// it too.
+func RequireToken(want string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if token := q.Get("token"); token != "" && r.Method == http.MethodGet && validToken(token, want) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     authCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			// Drop the token from the address bar and history.
+			q.Del("token")
+			target := *r.URL
+			target.RawQuery = q.Encode()
+			http.Redirect(w, r, target.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		if !authorized(r, want) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ponder"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authorized(r *http.Request, want string) bool {
+	if h := r.Header.Get("Authorization"); h != "" {
+		token, ok := strings.CutPrefix(h, "Bearer ")
+		return ok && validToken(token, want)
+	}
+	if c, err := r.Cookie(authCookie); err == nil {
+		return validToken(c.Value, want)
+	}
+	return false
+}
+
+func validToken(token, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
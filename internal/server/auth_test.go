@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func TestAuthToken(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+	if err := database.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	srv := NewServer(database)
+	srv.SetAuthToken("s3cret")
+	handler := srv.Handler()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/api/tasks", "/", "/board"} {
+		if w := serve(httptest.NewRequest("GET", path, nil)); w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s without a token: expected 401, got %d", path, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	if w := serve(req); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	if w := serve(req); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the bearer token, got %d", w.Code)
+	}
+
+	// Opening the UI with ?token= sets a cookie and strips the token.
+	w := serve(httptest.NewRequest("GET", "/board?token=s3cret&feature=x", nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/board?feature=x" {
+		t.Fatalf("Expected redirect to /board?feature=x, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != authCookie || !cookies[0].HttpOnly {
+		t.Fatalf("Expected an HttpOnly auth cookie, got %+v", cookies)
+	}
+
+	req = httptest.NewRequest("POST", "/api/orchestrator/pause", nil)
+	req.AddCookie(cookies[0])
+	if w := serve(req); w.Code == http.StatusUnauthorized {
+		t.Errorf("Expected the cookie to authorize API calls")
+	}
+
+	if w := serve(httptest.NewRequest("GET", "/?token=wrong", nil)); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong ?token, got %d", w.Code)
+	}
+}
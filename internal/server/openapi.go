@@ -0,0 +1,516 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+// apiRoute is an API endpoint together with the description it gets in the
+// OpenAPI spec. Handler registers the routes from the same list, so the spec
+// cannot drift from what is served.
+type apiRoute struct {
+	Method  string
+	Path    string
+	Summary string
+	Params  []apiParam
+	// Body and Response are values of the request and response types; their
+	// schemas are derived from the Go types. Response may also be a schema.
+	Body     any
+	Response any
+	// ContentType is the media type of the response; application/json
+	// unless set.
+	ContentType string
+	// Status is the success status; 200 unless set.
+	Status  int
+	Errors  []int
+	handler http.HandlerFunc
+}
+
+// apiParam is a path or query parameter.
+type apiParam struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+}
+
+func queryParam(name, typ, description string) apiParam {
+	return apiParam{Name: name, In: "query", Type: typ, Description: description}
+}
+
+// routes lists the API endpoints.
+func (s *Server) routes() []apiRoute {
+	taskStatuses := enumValues[reflect.TypeFor[models.TaskStatus]()]
+	return []apiRoute{
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/tasks",
+			Summary: "List tasks, one page at a time. The number of matching tasks before paging is sent as total and in X-Total-Count.",
+			Params: []apiParam{
+				{Name: "status", In: "query", Type: "string", Description: "Only tasks with this status: " + strings.Join(taskStatuses, ", ")},
+				queryParam("feature", "string", "Only tasks of this feature"),
+				queryParam("label", "string", "Only tasks with this label"),
+				queryParam("q", "string", "Search in name, description and specification"),
+				queryParam("sort", "string", `priority, name, feature, status, created or updated; prefix "-" to reverse`),
+				queryParam("limit", "integer", "Maximum number of tasks"),
+				queryParam("offset", "integer", "Number of tasks to skip"),
+			},
+			Response: taskList{},
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleTasks,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/tasks/{id}",
+			Summary: "Get a task with its labels.",
+			Params: []apiParam{
+				{Name: "id", In: "path", Type: "string", Description: "Task ID"},
+			},
+			Response: taskDetail{},
+			Errors:   []int{http.StatusNotFound},
+			handler:  s.handleTask,
+		},
+		{
+			Method:  http.MethodPatch,
+			Path:    "/api/tasks/{id}",
+			Summary: "Edit a task: its name, description, specification, priority, estimate, labels and status. Only the fields sent change; labels replaces the task's labels. Tasks moved to completed without a summary keep their review summary, or get a default one; blocked_reason records why a task is blocked, and estimate_minutes sets the estimate (0 clears it). With version, the change is refused with 409 if the task has changed since that version; renaming a task to the name of another in its feature is refused with 409 too.",
+			Params: []apiParam{
+				{Name: "id", In: "path", Type: "string", Description: "Task ID"},
+			},
+			Body:     taskPatchRequest{},
+			Response: taskDetail{},
+			Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			handler:  s.handleTaskPatch,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/tasks/{id}/runs",
+			Summary: "List the agent runs of a task, oldest first, with their prompt, full output, exit code, duration and model.",
+			Params: []apiParam{
+				{Name: "id", In: "path", Type: "string", Description: "Task ID"},
+			},
+			Response: []*models.Run{},
+			Errors:   []int{http.StatusNotFound},
+			handler:  s.handleTaskRuns,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/tasks/bulk",
+			Summary:  "Create tasks and their dependencies in one transaction.",
+			Body:     tasksBulkRequest{},
+			Response: []*models.Task{},
+			Status:   http.StatusCreated,
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleTasksBulk,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/features",
+			Summary: "List features with the progress of their tasks, newest first, one page at a time. The number of features is sent as total and in X-Total-Count.",
+			Params: []apiParam{
+				queryParam("limit", "integer", "Maximum number of features"),
+				queryParam("offset", "integer", "Number of features to skip"),
+			},
+			Response: featureList{},
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleFeatures,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/graph",
+			Summary: "Get the task graph: features, tasks and dependencies, as drawn by the web UI.",
+			Params: []apiParam{
+				queryParam("since", "string", "RFC 3339 time; only send what changed at or after it, as {since, as_of, full, nodes, edges, removed}. edges replace those of the tasks in nodes, removed lists tasks that left the graph, and full means nodes and edges are the whole graph. Pass as_of as since next time."),
+			},
+			Errors: []int{http.StatusBadRequest},
+			Response: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"nodes": map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+					"edges": map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+				},
+			},
+			handler: s.handleGraph,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/events",
+			Summary: "List audit log events, newest first.",
+			Params: []apiParam{
+				queryParam("entity_type", "string", "Only events of this entity type, such as task or feature"),
+				queryParam("entity_id", "string", "Only events of this entity"),
+				queryParam("limit", "integer", "Maximum number of events (default 100)"),
+			},
+			Response: []*models.Event{},
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleEvents,
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/api/events/stream",
+			Summary: "Follow audit log events and, when an orchestrator runs alongside the server, worker events as Server-Sent Events. " +
+				"Audit log entries are sent as audit events carrying their id; worker events are sent under their type, " +
+				"such as task_started or output_chunk, with the -no-tui JSON log line as data.",
+			Params: []apiParam{
+				{Name: "Last-Event-ID", In: "header", Type: "integer", Description: "Resume after this audit event; without it the stream starts at the end of the log"},
+				queryParam("after", "integer", "Resume after this audit event, for clients that can't set Last-Event-ID"),
+			},
+			Response:    map[string]any{"type": "string"},
+			ContentType: "text/event-stream",
+			Errors:      []int{http.StatusBadRequest},
+			handler:     s.handleEventStream,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/usage",
+			Summary: "Get token usage and cost, in total and by feature and task.",
+			Params: []apiParam{
+				queryParam("feature", "string", "Only list the tasks of this feature"),
+			},
+			Response: usageResponse{},
+			handler:  s.handleUsage,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/stats",
+			Summary: "Get task counts, throughput, average task duration and failure rate.",
+			Params: []apiParam{
+				queryParam("days", "integer", "Length of the period in days (default 7)"),
+			},
+			Response: &models.ProjectStats{},
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleStats,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/reports/durations",
+			Summary: "Get the wall time of completed tasks by task and feature, and the daily average agent run duration.",
+			Params: []apiParam{
+				queryParam("days", "integer", "Length of the period in days (default 30)"),
+				queryParam("feature", "string", "Only tasks of this feature"),
+			},
+			Response: &models.DurationReport{},
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleDurationReport,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/reports/burndown",
+			Summary: "Compare estimated with actual task durations and get the estimated work left, by feature and by day.",
+			Params: []apiParam{
+				queryParam("days", "integer", "Length of the period in days (default 14)"),
+				queryParam("feature", "string", "Only tasks of this feature"),
+			},
+			Response: &models.BurndownReport{},
+			Errors:   []int{http.StatusBadRequest},
+			handler:  s.handleBurndownReport,
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/orchestrator",
+			Summary:  "Report whether the orchestrator is paused, its model and its workers.",
+			Response: orchestratorState{},
+			Errors:   []int{http.StatusServiceUnavailable},
+			handler:  s.handleOrchestrator,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/orchestrator/pause",
+			Summary:  "Stop the orchestrator claiming new tasks; running workers finish their current tasks.",
+			Response: orchestratorState{},
+			Errors:   []int{http.StatusServiceUnavailable},
+			handler:  s.handleOrchestratorPause,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/orchestrator/resume",
+			Summary:  "Let the orchestrator claim new tasks again.",
+			Response: orchestratorState{},
+			Errors:   []int{http.StatusServiceUnavailable},
+			handler:  s.handleOrchestratorResume,
+		},
+		{
+			Method:   http.MethodPut,
+			Path:     "/api/orchestrator/workers",
+			Summary:  "Set how many workers run at once; workers above a lowered target finish their current tasks.",
+			Body:     orchestratorWorkersRequest{},
+			Response: orchestratorState{},
+			Errors:   []int{http.StatusBadRequest, http.StatusServiceUnavailable},
+			handler:  s.handleOrchestratorWorkers,
+		},
+		{
+			Method:   http.MethodPut,
+			Path:     "/api/orchestrator/model",
+			Summary:  "Switch the model new tasks are run with to one of the available models.",
+			Body:     orchestratorModelRequest{},
+			Response: orchestratorState{},
+			Errors:   []int{http.StatusBadRequest, http.StatusServiceUnavailable},
+			handler:  s.handleOrchestratorModel,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/orchestrator/stop",
+			Summary:  "Stop the orchestrator, ending the ponder process that serves the API.",
+			Response: orchestratorState{},
+			Errors:   []int{http.StatusServiceUnavailable},
+			handler:  s.handleOrchestratorStop,
+		},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, s.openAPISpec(), nil)
+}
+
+// openAPISpec describes the API as an OpenAPI 3.1 document.
+func (s *Server) openAPISpec() map[string]any {
+	schemas := make(map[string]any)
+	paths := make(map[string]map[string]any)
+	for _, route := range s.routes() {
+		op := map[string]any{
+			"operationId": operationID(route),
+			"summary":     route.Summary,
+		}
+
+		if len(route.Params) > 0 {
+			var params []map[string]any
+			for _, p := range route.Params {
+				params = append(params, map[string]any{
+					"name":        p.Name,
+					"in":          p.In,
+					"required":    p.In == "path",
+					"description": p.Description,
+					"schema":      map[string]any{"type": p.Type},
+				})
+			}
+			op["parameters"] = params
+		}
+
+		if route.Body != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(schemaOf(route.Body, schemas)),
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		responses := map[string]any{
+			strconv.Itoa(status): map[string]any{
+				"description": http.StatusText(status),
+				"content":     responseContent(route, schemaOf(route.Response, schemas)),
+			},
+		}
+		for _, code := range route.Errors {
+			responses[strconv.Itoa(code)] = errorResponse(code)
+		}
+		if s.authToken != "" {
+			responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse(http.StatusUnauthorized)
+		}
+		responses["500"] = errorResponse(http.StatusInternalServerError)
+		op["responses"] = responses
+
+		if paths[route.Path] == nil {
+			paths[route.Path] = make(map[string]any)
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = op
+	}
+
+	components := map[string]any{"schemas": schemas}
+	spec := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "ponder",
+			"description": "REST API of the ponder web server.",
+			"version":     "1.0.0",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+	if s.authToken != "" {
+		components["securitySchemes"] = map[string]any{
+			"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			"cookie": map[string]any{"type": "apiKey", "in": "cookie", "name": authCookie},
+		}
+		spec["security"] = []map[string][]string{{"bearer": {}}, {"cookie": {}}}
+	}
+	return spec
+}
+
+// operationID names an operation after its method and path, such as
+// patchTasksId for PATCH /api/tasks/{id}.
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.Method)
+	for _, part := range strings.Split(strings.TrimPrefix(route.Path, "/api/"), "/") {
+		part = strings.Trim(part, "{}")
+		for _, word := range strings.Split(part, "_") {
+			if word != "" {
+				id += strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+	}
+	return id
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// responseContent describes the body of a successful response to route.
+func responseContent(route apiRoute, schema map[string]any) map[string]any {
+	if route.ContentType == "" {
+		return jsonContent(schema)
+	}
+	return map[string]any{route.ContentType: map[string]any{"schema": schema}}
+}
+
+// errorResponse describes an error, which is sent as plain text.
+func errorResponse(code int) map[string]any {
+	return map[string]any{
+		"description": http.StatusText(code),
+		"content": map[string]any{
+			"text/plain": map[string]any{"schema": map[string]any{"type": "string"}},
+		},
+	}
+}
+
+// enumValues lists the values of the string types that are enumerations.
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeFor[models.TaskStatus](): {
+		string(models.TaskStatusPending),
+		string(models.TaskStatusInProgress),
+		string(models.TaskStatusCompleted),
+		string(models.TaskStatusBlocked),
+		string(models.TaskStatusInReview),
+		string(models.TaskStatusCancelled),
+	},
+	reflect.TypeFor[models.SubtaskOrder](): {
+		string(models.SubtaskOrderChildrenFirst),
+		string(models.SubtaskOrderParentFirst),
+	},
+	reflect.TypeFor[models.EventAction](): {
+		string(models.EventCreated),
+		string(models.EventUpdated),
+		string(models.EventRenamed),
+		string(models.EventStatusChanged),
+		string(models.EventDeleted),
+		string(models.EventDependencyAdded),
+		string(models.EventDependencyRemoved),
+		string(models.EventImported),
+		string(models.EventArchived),
+		string(models.EventModelFallback),
+	},
+}
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	rawType     = reflect.TypeFor[json.RawMessage]()
+	taskRefType = reflect.TypeFor[db.TaskRef]()
+)
+
+// schemaOf returns the JSON schema of v's type, or v itself when it already
+// is a schema. Named structs are added to schemas and referenced.
+func schemaOf(v any, schemas map[string]any) map[string]any {
+	if schema, ok := v.(map[string]any); ok {
+		return schema
+	}
+	return typeSchema(reflect.TypeOf(v), schemas)
+}
+
+func typeSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawType:
+		return map[string]any{}
+	case taskRefType:
+		// TaskRef also accepts a bare task name in the same feature.
+		return map[string]any{"oneOf": []any{
+			map[string]any{"type": "string"},
+			structRef(t, schemas),
+		}}
+	}
+	if values, ok := enumValues[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		// Nil is sent as null; references to structs are left as they are.
+		elem := typeSchema(t.Elem(), schemas)
+		if typ, ok := elem["type"].(string); ok {
+			elem["type"] = []string{typ, "null"}
+		}
+		return elem
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), schemas)}
+	case reflect.Struct:
+		return structRef(t, schemas)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+// structRef adds the schema of struct type t to schemas and refers to it.
+// Unexported request and response types are named after their exported
+// form, so taskPatchRequest becomes TaskPatchRequest.
+func structRef(t reflect.Type, schemas map[string]any) map[string]any {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, ok := schemas[name]; !ok {
+		// Reserve the name first so recursive types terminate.
+		schemas[name] = nil
+		properties := make(map[string]any)
+		required := []string{}
+		addFields(t, properties, &required, schemas)
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		schemas[name] = schema
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// addFields adds the JSON fields of struct type t, including those of
+// embedded structs. Fields that are neither omitempty nor pointers are
+// required, as they are always sent.
+func addFields(t reflect.Type, properties map[string]any, required *[]string, schemas map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addFields(f.Type, properties, required, schemas)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = typeSchema(f.Type, schemas)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
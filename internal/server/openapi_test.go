@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+	if err := database.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	srv := NewServer(database)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+
+	var spec struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct {
+					Enum []string `json:"enum"`
+				} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	body := w.Body.String()
+	if err := json.Unmarshal([]byte(body), &spec); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got %q", spec.OpenAPI)
+	}
+
+	for _, route := range srv.routes() {
+		if _, ok := spec.Paths[route.Path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("Spec is missing %s %s", route.Method, route.Path)
+		}
+	}
+
+	// Every reference must resolve.
+	for _, ref := range strings.Split(body, `"$ref":"`)[1:] {
+		name := strings.TrimPrefix(ref[:strings.Index(ref, `"`)], "#/components/schemas/")
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("Unresolved reference to %q", name)
+		}
+	}
+
+	if got := spec.Components.Schemas["Task"].Properties["status"].Enum; len(got) != 6 {
+		t.Errorf("Expected the six task statuses in the Task schema, got %v", got)
+	}
+	if _, ok := spec.Components.Schemas["TaskPatchRequest"]; !ok {
+		t.Errorf("Expected the PATCH /api/tasks/{id} body in the schemas")
+	}
+}
@@ -3,38 +3,103 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/nick-dorsch/ponder/embed/graph_assets"
+	"github.com/nick-dorsch/ponder/internal/actor"
 	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
 )
 
+// OrchestratorControl is the part of a running orchestrator the API can
+// drive.
+type OrchestratorControl interface {
+	Pause()
+	Resume()
+	IsPaused() bool
+	Stop()
+	GetModel() string
+	SetModel(model string)
+	GetAvailableModels() []string
+	GetTargetWorkers() int
+	SetTargetWorkers(target int)
+	GetMaxWorkers() int
+	Workers() []models.ActiveWorker
+	// SubscribeEvents writes the orchestrator's events to w as JSON lines
+	// until the returned function is called. w must not block.
+	SubscribeEvents(w io.Writer) (unsubscribe func())
+}
+
 type Server struct {
-	db     *db.DB
-	server *http.Server
+	db        db.Store
+	orch      OrchestratorControl
+	authToken string
+	readOnly  bool
+	server    *http.Server
 }
 
-func NewServer(database *db.DB) *Server {
+func NewServer(database db.Store) *Server {
 	return &Server{db: database}
 }
 
+// SetOrchestrator enables the /api/orchestrator endpoints, which control the
+// orchestrator running alongside the server.
+func (s *Server) SetOrchestrator(o OrchestratorControl) {
+	s.orch = o
+}
+
+// SetReadOnly makes every API endpoint that changes something answer 403
+// Forbidden, e.g. for a dashboard shown to people who shouldn't move tasks.
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
 func (s *Server) Start(addr string) error {
+	s.server = &http.Server{
+		Addr:    addr,
+		Handler: s.Handler(),
+	}
+
+	return s.server.ListenAndServe()
+}
+
+// Handler routes the API and the web UI, behind the auth token when one is
+// set.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// API endpoints
-	mux.HandleFunc("/api/tasks", s.handleTasks)
-	mux.HandleFunc("/api/features", s.handleFeatures)
-	mux.HandleFunc("/api/graph", s.handleGraph)
+	for _, route := range s.routes() {
+		handler := route.handler
+		if s.readOnly && route.Method != http.MethodGet {
+			handler = handleReadOnly
+		}
+		mux.HandleFunc(route.Method+" "+route.Path, handler)
+	}
+	mux.HandleFunc("GET /api/openapi.json", s.handleOpenAPI)
 
 	// Static files
+	mux.HandleFunc("GET /board", s.handleBoard)
+	mux.HandleFunc("GET /tasks/{id}", s.handleTaskPage)
+	mux.HandleFunc("GET /burndown", s.handleBurndown)
 	mux.Handle("/", http.FileServer(http.FS(graph_assets.Assets)))
 
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: mux,
+	if s.authToken == "" {
+		return mux
 	}
+	return s.requireAuth(mux)
+}
 
-	return s.server.ListenAndServe()
+func handleReadOnly(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "this ponder server is read-only", http.StatusForbidden)
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
@@ -44,21 +109,582 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
+// taskList is the body of GET /api/tasks: one page of the matching tasks,
+// how many match in all and the paging asked for. A limit of 0 means none.
+type taskList struct {
+	Items  []*models.Task `json:"items"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// featureList is the body of GET /api/features, paged like taskList.
+type featureList struct {
+	Items  []*models.Feature `json:"items"`
+	Total  int               `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// parsePaging reads the limit and offset parameters, answering 400 and
+// returning false when they are invalid.
+func parsePaging(w http.ResponseWriter, q url.Values) (limit, offset int, ok bool) {
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid "+name, http.StatusBadRequest)
+			return 0, 0, false
+		}
+		*dst = n
+	}
+	return limit, offset, true
+}
+
+// handleTasks lists tasks, narrowed by the optional status, feature, label
+// and q (search) parameters, ordered by sort and paged by limit and offset.
+// The number of matching tasks before paging is sent in the body and in
+// X-Total-Count.
 func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := s.db.ListTasks(r.Context(), nil, nil)
-	s.respond(w, tasks, err)
+	q := r.URL.Query()
+	filter := db.TaskFilter{
+		Label:  q.Get("label"),
+		Search: q.Get("q"),
+		Sort:   q.Get("sort"),
+	}
+	if v := q.Get("status"); v != "" {
+		status := models.TaskStatus(v)
+		filter.Status = &status
+	}
+	if v := q.Get("feature"); v != "" {
+		filter.Feature = &v
+	}
+	var ok bool
+	if filter.Limit, filter.Offset, ok = parsePaging(w, q); !ok {
+		return
+	}
+
+	tasks, total, err := s.db.ListTasksFiltered(r.Context(), filter)
+	if errors.Is(err, db.ErrInvalidTaskFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	}
+	s.respond(w, taskList{Items: tasks, Total: total, Limit: filter.Limit, Offset: filter.Offset}, err)
+}
+
+// taskDetail is a task together with its labels, as GET and PATCH
+// /api/tasks/{id} send it.
+type taskDetail struct {
+	models.Task
+	Labels []string `json:"labels"`
+}
+
+// handleTask sends one task with its labels.
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	s.respondTask(w, r.Context(), r.PathValue("id"))
+}
+
+// respondTask sends the task with id and its labels, or 404 if there is no
+// such task.
+func (s *Server) respondTask(w http.ResponseWriter, ctx context.Context, id string) {
+	task, err := s.db.GetTask(ctx, id)
+	if err != nil {
+		s.respond(w, nil, err)
+		return
+	}
+	if task == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+	labels, err := s.db.ListTaskLabels(ctx, id)
+	if labels == nil {
+		labels = []string{}
+	}
+	s.respond(w, taskDetail{Task: *task, Labels: labels}, err)
+}
+
+// taskPatchRequest is the body of PATCH /api/tasks/{id}.
+type taskPatchRequest struct {
+	Status            models.TaskStatus `json:"status"`
+	CompletionSummary *string           `json:"completion_summary"`
+	BlockedReason     *string           `json:"blocked_reason"`
+	// EstimateMinutes sets the task's estimate; 0 clears it.
+	EstimateMinutes *int `json:"estimate_minutes"`
+	// Name, Description, Specification and Priority replace the task's.
+	Name          *string `json:"name"`
+	Description   *string `json:"description"`
+	Specification *string `json:"specification"`
+	Priority      *int    `json:"priority"`
+	// Labels replaces the task's labels; an empty list removes them all.
+	Labels *[]string `json:"labels"`
+	// Version is the task version the client last saw. When given, the patch
+	// fails with 409 Conflict if the task has changed since.
+	Version *int `json:"version,omitempty"`
+}
+
+// edits reports whether the patch changes any of the task's fields, as
+// opposed to its status or labels.
+func (req *taskPatchRequest) edits() bool {
+	return req.EstimateMinutes != nil || req.Name != nil || req.Description != nil ||
+		req.Specification != nil || req.Priority != nil
+}
+
+// handleTaskPatch changes a task's fields, labels and status, in that order.
+// Tasks moved to completed without a summary keep their review summary, or
+// get a default one. A blocked_reason is only taken with the blocked status.
+func (s *Server) handleTaskPatch(w http.ResponseWriter, r *http.Request) {
+	ctx := actor.With(r.Context(), "web")
+	id := r.PathValue("id")
+
+	var req taskPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Status == "" && !req.edits() && req.Labels == nil {
+		http.Error(w, "status, labels or a task field is required", http.StatusBadRequest)
+		return
+	}
+	if req.EstimateMinutes != nil && *req.EstimateMinutes < 0 {
+		http.Error(w, db.ErrInvalidEstimate.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		http.Error(w, "name must not be empty", http.StatusBadRequest)
+		return
+	}
+	if req.Priority != nil && (*req.Priority < 0 || *req.Priority > 10) {
+		http.Error(w, "priority must be between 0 and 10", http.StatusBadRequest)
+		return
+	}
+	if req.Labels != nil {
+		if err := db.ValidateLabels(*req.Labels); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.BlockedReason != nil && req.Status != models.TaskStatusBlocked {
+		http.Error(w, "blocked_reason needs status blocked", http.StatusBadRequest)
+		return
+	}
+	if req.Version != nil && *req.Version < 1 {
+		http.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+	version := 0
+	if req.Version != nil {
+		version = *req.Version
+	}
+
+	task, err := s.db.GetTask(ctx, id)
+	if err != nil {
+		s.respond(w, nil, err)
+		return
+	}
+	if task == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	if req.edits() {
+		if req.Name != nil && *req.Name != task.Name {
+			other, err := s.db.GetTaskByName(ctx, *req.Name, task.FeatureID)
+			if err != nil {
+				s.respond(w, nil, err)
+				return
+			}
+			if other != nil {
+				http.Error(w, fmt.Sprintf("feature %s already has a task named %s", task.FeatureName, *req.Name), http.StatusConflict)
+				return
+			}
+			task.Name = *req.Name
+		}
+		if req.Description != nil {
+			task.Description = *req.Description
+		}
+		if req.Specification != nil {
+			task.Specification = *req.Specification
+		}
+		if req.Priority != nil {
+			task.Priority = *req.Priority
+		}
+		if req.EstimateMinutes != nil {
+			task.EstimateMinutes = req.EstimateMinutes
+			if *req.EstimateMinutes == 0 {
+				task.EstimateMinutes = nil
+			}
+		}
+		if req.Labels != nil {
+			err = s.db.UpdateTaskWithLabels(ctx, task, version, *req.Labels)
+		} else {
+			err = s.db.UpdateTaskAtVersion(ctx, task, version)
+		}
+		if err != nil {
+			if errors.Is(err, db.ErrVersionConflict) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			s.respond(w, nil, err)
+			return
+		}
+		// The status change follows on from the fields just saved.
+		if version != 0 {
+			version = task.Version
+		}
+	}
+
+	if req.Labels != nil && !req.edits() {
+		current, err := s.db.SetTaskLabelsAtVersion(ctx, id, version, *req.Labels)
+		if err != nil {
+			if errors.Is(err, db.ErrVersionConflict) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			s.respond(w, nil, err)
+			return
+		}
+		// Changing the labels moves the task to a new version too.
+		if version != 0 {
+			version = current
+		}
+	}
+
+	if req.Status != "" {
+		summary := req.CompletionSummary
+		if summary == nil && req.Status == models.TaskStatusCompleted {
+			if task.Status == models.TaskStatusInReview && task.CompletionSummary != nil {
+				summary = task.CompletionSummary
+			} else {
+				def := "Completed via web board"
+				summary = &def
+			}
+		}
+
+		if req.BlockedReason != nil {
+			err = s.db.BlockTaskAtVersion(ctx, id, version, *req.BlockedReason)
+		} else {
+			err = s.db.UpdateTaskStatusAtVersion(ctx, id, version, req.Status, summary)
+		}
+		if err != nil {
+			if errors.Is(err, db.ErrEmptyBlockedReason) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, db.ErrInvalidTransition) || errors.Is(err, db.ErrVersionConflict) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			s.respond(w, nil, err)
+			return
+		}
+	}
+
+	s.respondTask(w, ctx, id)
+}
+
+// handleTaskRuns lists the transcripts of a task's agent runs, oldest first.
+func (s *Server) handleTaskRuns(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	task, err := s.db.GetTask(r.Context(), id)
+	if err != nil {
+		s.respond(w, nil, err)
+		return
+	}
+	if task == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	runs, err := s.db.ListTaskRuns(r.Context(), id)
+	s.respond(w, runs, err)
 }
 
+// tasksBulkRequest is the body of POST /api/tasks/bulk.
+type tasksBulkRequest struct {
+	FeatureName string        `json:"feature_name"`
+	Tasks       []db.BulkTask `json:"tasks"`
+}
+
+// handleTasksBulk creates tasks and their dependencies in one transaction.
+func (s *Server) handleTasksBulk(w http.ResponseWriter, r *http.Request) {
+	ctx := actor.With(r.Context(), "web")
+
+	var req tasksBulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := s.db.CreateTasks(ctx, req.FeatureName, req.Tasks)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, db.ErrInvalidBulkTasks) || errors.Is(err, db.ErrDependencyCycle) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tasks)
+}
+
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, graph_assets.Assets, "board.html")
+}
+
+// handleTaskPage serves the task editor, which loads the task named in the
+// path through the API.
+func (s *Server) handleTaskPage(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, graph_assets.Assets, "task.html")
+}
+
+// handleFeatures lists the features newest first, paged by limit and offset,
+// with the total in the body and in X-Total-Count.
 func (s *Server) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parsePaging(w, r.URL.Query())
+	if !ok {
+		return
+	}
 	features, err := s.db.ListFeatures(r.Context())
-	s.respond(w, features, err)
+	if err != nil {
+		s.respond(w, nil, err)
+		return
+	}
+
+	total := len(features)
+	features = features[min(offset, total):]
+	if limit > 0 {
+		features = features[:min(limit, len(features))]
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	s.respond(w, featureList{Items: features, Total: total, Limit: limit, Offset: offset}, nil)
 }
 
+// handleGraph sends the task graph or, given since (RFC 3339), only what
+// changed at or after it, so clients with a large graph can keep it up to
+// date cheaply.
 func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	if v := r.URL.Query().Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		delta, err := s.db.GetGraphDelta(r.Context(), since)
+		s.respond(w, delta, err)
+		return
+	}
 	graphJSON, err := s.db.GetGraphJSON(r.Context())
 	s.respond(w, graphJSON, err)
 }
 
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	events, err := s.db.ListEvents(r.Context(), q.Get("entity_type"), q.Get("entity_id"), limit)
+	if events == nil {
+		events = []*models.Event{}
+	}
+	s.respond(w, events, err)
+}
+
+// usageResponse is the body of /api/usage.
+type usageResponse struct {
+	Total    *models.UsageTotals       `json:"total"`
+	Features []*models.FeatureUsage    `json:"features"`
+	Tasks    []*models.TaskUsageTotals `json:"tasks"`
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	total, err := s.db.GetUsageTotals(ctx)
+	if err != nil {
+		s.respond(w, nil, err)
+		return
+	}
+	features, err := s.db.ListFeatureUsage(ctx)
+	if err != nil {
+		s.respond(w, nil, err)
+		return
+	}
+	tasks, err := s.db.ListTaskUsage(ctx, r.URL.Query().Get("feature"))
+	s.respond(w, usageResponse{Total: total, Features: features, Tasks: tasks}, err)
+}
+
+// handleStats reports project statistics over the last ?days= days
+// (default 7).
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	days := db.DefaultStatsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	stats, err := s.db.GetProjectStats(r.Context(), days)
+	s.respond(w, stats, err)
+}
+
+// handleDurationReport reports how long the tasks completed over the last
+// ?days= days (default 30) took, optionally for one ?feature=.
+func (s *Server) handleDurationReport(w http.ResponseWriter, r *http.Request) {
+	days := db.DefaultDurationDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	report, err := s.db.GetDurationReport(r.Context(), days, r.URL.Query().Get("feature"))
+	s.respond(w, report, err)
+}
+
+// handleBurndownReport compares estimated with actual task durations and
+// follows the estimated work left over the last ?days= days (default 14),
+// optionally for one ?feature=.
+func (s *Server) handleBurndownReport(w http.ResponseWriter, r *http.Request) {
+	days := db.DefaultBurndownDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	report, err := s.db.GetBurndownReport(r.Context(), days, r.URL.Query().Get("feature"))
+	s.respond(w, report, err)
+}
+
+func (s *Server) handleBurndown(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, graph_assets.Assets, "burndown.html")
+}
+
+// orchestratorState is the body returned by the /api/orchestrator endpoints.
+type orchestratorState struct {
+	Paused          bool                  `json:"paused"`
+	Model           string                `json:"model"`
+	AvailableModels []string              `json:"available_models"`
+	TargetWorkers   int                   `json:"target_workers"`
+	MaxWorkers      int                   `json:"max_workers"`
+	Workers         []models.ActiveWorker `json:"workers"`
+}
+
+func (s *Server) handleOrchestrator(w http.ResponseWriter, r *http.Request) {
+	if s.orch == nil {
+		http.Error(w, "no orchestrator is running", http.StatusServiceUnavailable)
+		return
+	}
+	s.respond(w, orchestratorState{
+		Paused:          s.orch.IsPaused(),
+		Model:           s.orch.GetModel(),
+		AvailableModels: s.orch.GetAvailableModels(),
+		TargetWorkers:   s.orch.GetTargetWorkers(),
+		MaxWorkers:      s.orch.GetMaxWorkers(),
+		Workers:         s.orch.Workers(),
+	}, nil)
+}
+
+// handleOrchestratorPause stops the orchestrator claiming new tasks; running
+// workers finish their current tasks.
+func (s *Server) handleOrchestratorPause(w http.ResponseWriter, r *http.Request) {
+	if s.orch != nil {
+		s.orch.Pause()
+	}
+	s.handleOrchestrator(w, r)
+}
+
+func (s *Server) handleOrchestratorResume(w http.ResponseWriter, r *http.Request) {
+	if s.orch != nil {
+		s.orch.Resume()
+	}
+	s.handleOrchestrator(w, r)
+}
+
+// orchestratorWorkersRequest is the body of PUT /api/orchestrator/workers.
+type orchestratorWorkersRequest struct {
+	Target int `json:"target"`
+}
+
+// handleOrchestratorWorkers sets how many workers run at once, like `a` and
+// `d` in the TUI. Workers above a lowered target finish their current tasks.
+func (s *Server) handleOrchestratorWorkers(w http.ResponseWriter, r *http.Request) {
+	if s.orch == nil {
+		http.Error(w, "no orchestrator is running", http.StatusServiceUnavailable)
+		return
+	}
+	var req orchestratorWorkersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Target < 0 || req.Target > s.orch.GetMaxWorkers() {
+		http.Error(w, fmt.Sprintf("target must be between 0 and %d", s.orch.GetMaxWorkers()), http.StatusBadRequest)
+		return
+	}
+	s.orch.SetTargetWorkers(req.Target)
+	s.handleOrchestrator(w, r)
+}
+
+// orchestratorModelRequest is the body of PUT /api/orchestrator/model.
+type orchestratorModelRequest struct {
+	Model string `json:"model"`
+}
+
+// handleOrchestratorModel switches the model new tasks are run with to one
+// of the available models, like the model menu of the TUI.
+func (s *Server) handleOrchestratorModel(w http.ResponseWriter, r *http.Request) {
+	if s.orch == nil {
+		http.Error(w, "no orchestrator is running", http.StatusServiceUnavailable)
+		return
+	}
+	var req orchestratorModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(s.orch.GetAvailableModels(), req.Model) {
+		http.Error(w, fmt.Sprintf("model %q is not one of the available models", req.Model), http.StatusBadRequest)
+		return
+	}
+	s.orch.SetModel(req.Model)
+	s.handleOrchestrator(w, r)
+}
+
+// handleOrchestratorStop stops the orchestrator, like `q` in the TUI, which
+// ends the `ponder` process serving the request.
+func (s *Server) handleOrchestratorStop(w http.ResponseWriter, r *http.Request) {
+	if s.orch != nil {
+		s.orch.Stop()
+	}
+	s.handleOrchestrator(w, r)
+}
+
 func (s *Server) respond(w http.ResponseWriter, data any, err error) {
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
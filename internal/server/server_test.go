@@ -1,11 +1,17 @@
 package server
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nick-dorsch/ponder/embed/graph_assets"
 	"github.com/nick-dorsch/ponder/internal/db"
@@ -56,10 +62,14 @@ func TestServer_API(t *testing.T) {
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status OK, got %v", w.Code)
 		}
-		var tasks []*models.Task
-		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		var list taskList
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
 			t.Fatalf("Failed to unmarshal tasks: %v", err)
 		}
+		tasks := list.Items
+		if list.Total != 1 {
+			t.Errorf("Expected total 1, got %d", list.Total)
+		}
 		if len(tasks) != 1 {
 			t.Errorf("Expected 1 task, got %d", len(tasks))
 		} else if tasks[0].Name != "test-task" {
@@ -67,6 +77,51 @@ func TestServer_API(t *testing.T) {
 		}
 	})
 
+	t.Run("GET /api/tasks with filters", func(t *testing.T) {
+		get := func(query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/tasks?"+query, nil)
+			w := httptest.NewRecorder()
+			srv.handleTasks(w, req)
+			return w
+		}
+
+		w := get("feature=test-feature&status=pending&q=TEST&sort=-name&limit=1&offset=0")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		var list taskList
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("Failed to unmarshal tasks: %v", err)
+		}
+		if len(list.Items) != 1 || list.Items[0].Name != "test-task" {
+			t.Errorf("Expected test-task, got %+v", list.Items)
+		}
+		if list.Total != 1 || list.Limit != 1 || list.Offset != 0 {
+			t.Errorf("Expected total 1, limit 1, offset 0, got %+v", list)
+		}
+		if got := w.Header().Get("X-Total-Count"); got != "1" {
+			t.Errorf("Expected X-Total-Count 1, got %q", got)
+		}
+
+		if w := get("status=completed"); w.Header().Get("X-Total-Count") != "0" {
+			t.Errorf("Expected no completed tasks, got %s", w.Body.String())
+		}
+		if err := database.AddTaskLabels(ctx, task.ID, "frontend"); err != nil {
+			t.Fatalf("AddTaskLabels failed: %v", err)
+		}
+		if w := get("label=frontend"); w.Header().Get("X-Total-Count") != "1" {
+			t.Errorf("Expected the labelled task, got %s", w.Body.String())
+		}
+		if w := get("label=backend"); w.Header().Get("X-Total-Count") != "0" {
+			t.Errorf("Expected no backend tasks, got %s", w.Body.String())
+		}
+		for _, query := range []string{"sort=bogus", "limit=abc", "offset=-1"} {
+			if w := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status BadRequest for %s, got %v", query, w.Code)
+			}
+		}
+	})
+
 	t.Run("GET /api/features", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/features", nil)
 		w := httptest.NewRecorder()
@@ -75,10 +130,14 @@ func TestServer_API(t *testing.T) {
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status OK, got %v", w.Code)
 		}
-		var features []*models.Feature
-		if err := json.Unmarshal(w.Body.Bytes(), &features); err != nil {
+		var list featureList
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
 			t.Fatalf("Failed to unmarshal features: %v", err)
 		}
+		features := list.Items
+		if got := w.Header().Get("X-Total-Count"); got != strconv.Itoa(list.Total) || list.Total != len(features) {
+			t.Errorf("Expected X-Total-Count and total to match the %d features, got %q and %d", len(features), got, list.Total)
+		}
 		if len(features) >= 1 {
 			found := false
 			for _, f := range features {
@@ -95,6 +154,36 @@ func TestServer_API(t *testing.T) {
 		}
 	})
 
+	t.Run("GET /api/features paged", func(t *testing.T) {
+		if err := database.CreateFeature(ctx, &models.Feature{Name: "another-feature", Description: "desc", Specification: "spec"}); err != nil {
+			t.Fatalf("CreateFeature failed: %v", err)
+		}
+		get := func(query string) (*httptest.ResponseRecorder, featureList) {
+			req := httptest.NewRequest("GET", "/api/features?"+query, nil)
+			w := httptest.NewRecorder()
+			srv.handleFeatures(w, req)
+			var list featureList
+			json.Unmarshal(w.Body.Bytes(), &list)
+			return w, list
+		}
+
+		w, list := get("limit=1&offset=1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if list.Total < 2 || len(list.Items) != 1 || list.Limit != 1 || list.Offset != 1 {
+			t.Errorf("Expected the second of at least 2 features, got %+v", list)
+		}
+		if _, list := get("offset=100"); len(list.Items) != 0 || list.Total < 2 {
+			t.Errorf("Expected no features past the end, got %+v", list)
+		}
+		for _, query := range []string{"limit=abc", "offset=-1"} {
+			if w, _ := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status BadRequest for %s, got %v", query, w.Code)
+			}
+		}
+	})
+
 	t.Run("GET /api/graph", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/graph", nil)
 		w := httptest.NewRecorder()
@@ -128,6 +217,567 @@ func TestServer_API(t *testing.T) {
 		}
 	})
 
+	t.Run("GET /api/graph since", func(t *testing.T) {
+		get := func(since string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/graph?since="+since, nil)
+			w := httptest.NewRecorder()
+			srv.handleGraph(w, req)
+			return w
+		}
+
+		w := get("1970-01-01T00:00:00Z")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		var delta models.GraphDelta
+		if err := json.Unmarshal(w.Body.Bytes(), &delta); err != nil {
+			t.Fatalf("Failed to unmarshal delta: %v", err)
+		}
+		if len(delta.Nodes) == 0 || delta.AsOf.IsZero() {
+			t.Errorf("Expected every task since 1970, got %s", w.Body.String())
+		}
+
+		w = get(delta.AsOf.Add(time.Hour).Format(time.RFC3339Nano))
+		if err := json.Unmarshal(w.Body.Bytes(), &delta); err != nil {
+			t.Fatalf("Failed to unmarshal delta: %v", err)
+		}
+		if delta.Full || len(delta.Nodes) != 0 {
+			t.Errorf("Expected no changes in the future, got %s", w.Body.String())
+		}
+
+		if w := get("yesterday"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for a bad since, got %v", w.Code)
+		}
+	})
+
+	t.Run("GET /api/events", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/events?entity_type=task&entity_id="+task.ID, nil)
+		w := httptest.NewRecorder()
+		srv.handleEvents(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status OK, got %v", w.Code)
+		}
+		var events []*models.Event
+		if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+			t.Fatalf("Failed to unmarshal events: %v", err)
+		}
+		// The task was created, then labelled.
+		if len(events) != 2 {
+			t.Fatalf("Expected 2 events, got %d", len(events))
+		}
+		if events[0].Action != models.EventCreated || events[0].EntityName != "test-task" {
+			t.Errorf("Unexpected event: %+v", events[0])
+		}
+		if events[1].Action != models.EventUpdated || string(events[1].After) != `{"labels":["frontend"]}` {
+			t.Errorf("Unexpected event: %+v", events[1])
+		}
+	})
+
+	t.Run("GET /api/events invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/events?limit=abc", nil)
+		w := httptest.NewRecorder()
+		srv.handleEvents(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest, got %v", w.Code)
+		}
+	})
+
+	t.Run("GET /api/usage", func(t *testing.T) {
+		if err := database.RecordTaskUsage(ctx, &models.TaskUsage{TaskID: task.ID, Model: "m", TokensIn: 10, TokensOut: 5, CostUSD: 0.02}); err != nil {
+			t.Fatalf("Failed to record usage: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/api/usage", nil)
+		w := httptest.NewRecorder()
+		srv.handleUsage(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status OK, got %v", w.Code)
+		}
+		var usage usageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+			t.Fatalf("Failed to unmarshal usage: %v", err)
+		}
+		if usage.Total.Runs != 1 || usage.Total.TokensIn != 10 {
+			t.Errorf("Unexpected totals: %+v", usage.Total)
+		}
+		if len(usage.Features) != 1 || usage.Features[0].FeatureName != "test-feature" {
+			t.Errorf("Unexpected feature usage: %+v", usage.Features)
+		}
+		if len(usage.Tasks) != 1 || usage.Tasks[0].TaskID != task.ID {
+			t.Errorf("Unexpected task usage: %+v", usage.Tasks)
+		}
+	})
+
+	t.Run("GET /api/tasks/{id}/runs", func(t *testing.T) {
+		if err := database.RecordRun(ctx, &models.Run{TaskID: task.ID, Model: "m", Prompt: "p", Output: "out", ExitCode: 1, StartedAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to record run: %v", err)
+		}
+		get := func(id string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/tasks/"+id+"/runs", nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			srv.handleTaskRuns(w, req)
+			return w
+		}
+
+		w := get(task.ID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v", w.Code)
+		}
+		var runs []*models.Run
+		if err := json.Unmarshal(w.Body.Bytes(), &runs); err != nil {
+			t.Fatalf("Failed to unmarshal runs: %v", err)
+		}
+		if len(runs) != 1 || runs[0].Output != "out" || runs[0].ExitCode != 1 {
+			t.Errorf("Unexpected runs: %+v", runs)
+		}
+
+		if w := get("missing"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status NotFound, got %v", w.Code)
+		}
+	})
+
+	t.Run("GET /api/stats", func(t *testing.T) {
+		get := func(query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/stats"+query, nil)
+			w := httptest.NewRecorder()
+			srv.handleStats(w, req)
+			return w
+		}
+
+		w := get("?days=14")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v", w.Code)
+		}
+		var stats models.ProjectStats
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Failed to unmarshal stats: %v", err)
+		}
+		if stats.Days != 14 || len(stats.Throughput) != 14 || stats.ByStatus[models.TaskStatusPending] != 1 {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+
+		if w := get("?days=0"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest, got %v", w.Code)
+		}
+	})
+
+	t.Run("GET /api/reports/durations", func(t *testing.T) {
+		get := func(query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/reports/durations"+query, nil)
+			w := httptest.NewRecorder()
+			srv.handleDurationReport(w, req)
+			return w
+		}
+
+		w := get("?days=7&feature=" + feature.Name)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v", w.Code)
+		}
+		var report models.DurationReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to unmarshal report: %v", err)
+		}
+		if report.Days != 7 || len(report.RunTrend) != 7 || len(report.Tasks) != 0 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+
+		if w := get("?days=x"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest, got %v", w.Code)
+		}
+	})
+
+	t.Run("GET /api/reports/burndown", func(t *testing.T) {
+		get := func(query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/reports/burndown"+query, nil)
+			w := httptest.NewRecorder()
+			srv.handleBurndownReport(w, req)
+			return w
+		}
+
+		w := get("?days=5&feature=" + feature.Name)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v", w.Code)
+		}
+		var report models.BurndownReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to unmarshal report: %v", err)
+		}
+		if report.Days != 5 || len(report.Remaining) != 5 || len(report.Features) != 1 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+
+		if w := get("?days=-1"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest, got %v", w.Code)
+		}
+	})
+
+	t.Run("PATCH /api/tasks/{id}", func(t *testing.T) {
+		patch := func(id, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("PATCH", "/api/tasks/"+id, strings.NewReader(body))
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			srv.handleTaskPatch(w, req)
+			return w
+		}
+
+		w := patch(task.ID, `{"status": "in_progress"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		var updated models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+		if updated.Status != models.TaskStatusInProgress {
+			t.Errorf("Expected in_progress, got %s", updated.Status)
+		}
+
+		w = patch(task.ID, `{"status": "completed"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		got, err := database.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		if got.Status != models.TaskStatusCompleted || got.CompletionSummary == nil {
+			t.Errorf("Expected completed task with default summary, got %+v", got)
+		}
+
+		if w := patch(task.ID, `{"status": "pending"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected status Conflict for invalid transition, got %v", w.Code)
+		}
+		if w := patch("missing", `{"status": "pending"}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status NotFound, got %v", w.Code)
+		}
+		if w := patch(task.ID, `{}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for missing status, got %v", w.Code)
+		}
+
+		w = patch(task.ID, `{"estimate_minutes": 25}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+		if updated.EstimateMinutes == nil || *updated.EstimateMinutes != 25 || updated.Status != models.TaskStatusCompleted {
+			t.Errorf("Expected a completed task estimated at 25 minutes, got %+v", updated)
+		}
+		if w := patch(task.ID, `{"estimate_minutes": -5}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for a negative estimate, got %v", w.Code)
+		}
+		if w := patch(task.ID, `{"estimate_minutes": 0}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if got, _ := database.GetTask(ctx, task.ID); got.EstimateMinutes != nil {
+			t.Errorf("Expected the estimate to be cleared, got %d", *got.EstimateMinutes)
+		}
+
+		current, _ := database.GetTask(ctx, task.ID)
+		stale := fmt.Sprintf(`{"estimate_minutes": 10, "version": %d}`, current.Version-1)
+		if w := patch(task.ID, stale); w.Code != http.StatusConflict {
+			t.Errorf("Expected status Conflict for a stale version, got %v", w.Code)
+		}
+		w = patch(task.ID, fmt.Sprintf(`{"estimate_minutes": 10, "version": %d}`, current.Version))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+		if updated.Version != current.Version+1 {
+			t.Errorf("Expected version %d, got %d", current.Version+1, updated.Version)
+		}
+
+		events, err := database.ListEvents(ctx, "task", task.ID, 1)
+		if err != nil {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		if len(events) != 1 || events[0].Actor != "web" {
+			t.Errorf("Expected latest event by web, got %+v", events)
+		}
+
+		stuck := &models.Task{FeatureID: task.FeatureID, Name: "patch-blocked", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := database.CreateTask(ctx, stuck); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		if w := patch(stuck.ID, `{"status": "pending", "blocked_reason": "no keys"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for a reason without blocked, got %v", w.Code)
+		}
+		if w := patch(stuck.ID, `{"status": "blocked", "blocked_reason": " "}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for an empty reason, got %v", w.Code)
+		}
+		w = patch(stuck.ID, `{"status": "blocked", "blocked_reason": "Waiting on API keys"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+		if updated.BlockedReason == nil || *updated.BlockedReason != "Waiting on API keys" {
+			t.Errorf("Expected blocked reason, got %v", updated.BlockedReason)
+		}
+		if w := patch(stuck.ID, `{"status": "pending"}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if got, _ := database.GetTask(ctx, stuck.ID); got.BlockedReason != nil {
+			t.Errorf("Expected the reason to be cleared on unblock, got %q", *got.BlockedReason)
+		}
+	})
+
+	t.Run("GET /api/tasks/{id}", func(t *testing.T) {
+		get := func(id string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/tasks/"+id, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			srv.handleTask(w, req)
+			return w
+		}
+
+		w := get(task.ID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		var got taskDetail
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+		if got.Name != "test-task" || got.FeatureName != "test-feature" || len(got.Labels) != 1 || got.Labels[0] != "frontend" {
+			t.Errorf("Expected test-task labelled frontend, got %+v", got)
+		}
+		if w := get("missing"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status NotFound, got %v", w.Code)
+		}
+	})
+
+	t.Run("PATCH /api/tasks/{id} edits", func(t *testing.T) {
+		patch := func(id, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("PATCH", "/api/tasks/"+id, strings.NewReader(body))
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			srv.handleTaskPatch(w, req)
+			return w
+		}
+
+		edited := &models.Task{FeatureID: task.FeatureID, Name: "patch-edit", Description: "d", Specification: "s", Priority: 3, Status: models.TaskStatusPending}
+		if err := database.CreateTask(ctx, edited); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		body := fmt.Sprintf(`{"name": "patch-edited", "specification": "# Spec\n\n- one", "priority": 7, "labels": ["ui", "docs"], "version": %d}`, edited.Version)
+		w := patch(edited.ID, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		var got taskDetail
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+		if got.Name != "patch-edited" || got.Description != "d" || got.Specification != "# Spec\n\n- one" || got.Priority != 7 {
+			t.Errorf("Expected the edited fields, got %+v", got)
+		}
+		if len(got.Labels) != 2 || got.Labels[0] != "docs" || got.Labels[1] != "ui" {
+			t.Errorf("Expected labels docs and ui, got %v", got.Labels)
+		}
+		if got.Version != edited.Version+1 {
+			t.Errorf("Expected version %d, got %d", edited.Version+1, got.Version)
+		}
+
+		if w := patch(edited.ID, `{"labels": []}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+		}
+		if labels, _ := database.ListTaskLabels(ctx, edited.ID); len(labels) != 0 {
+			t.Errorf("Expected the labels to be cleared, got %v", labels)
+		}
+
+		for _, body := range []string{`{"name": " "}`, `{"priority": 11}`, `{"labels": [""]}`} {
+			if w := patch(edited.ID, body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status BadRequest for %s, got %v", body, w.Code)
+			}
+		}
+		if w := patch(edited.ID, `{"name": "test-task"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected status Conflict for a taken name, got %v", w.Code)
+		}
+		if w := patch(edited.ID, fmt.Sprintf(`{"description": "stale", "version": %d}`, edited.Version)); w.Code != http.StatusConflict {
+			t.Errorf("Expected status Conflict for a stale version, got %v", w.Code)
+		}
+		if w := patch(edited.ID, fmt.Sprintf(`{"labels": ["stale"], "version": %d}`, edited.Version)); w.Code != http.StatusConflict {
+			t.Errorf("Expected status Conflict for a stale version with only labels, got %v", w.Code)
+		}
+		if labels, _ := database.ListTaskLabels(ctx, edited.ID); len(labels) != 0 {
+			t.Errorf("Expected a stale label change not to be saved, got %v", labels)
+		}
+
+		current, err := database.GetTask(ctx, edited.ID)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		w = patch(edited.ID, fmt.Sprintf(`{"labels": ["api"], "status": "in_progress", "version": %d}`, current.Version))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status OK for labels and status at the current version, got %v: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("GET /tasks/{id}", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/tasks/"+task.ID, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status OK, got %v", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `<script src="/task.js"></script>`) {
+			t.Error("task page missing task.js")
+		}
+	})
+
+	t.Run("GET /board", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/board", nil)
+		w := httptest.NewRecorder()
+		srv.handleBoard(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status OK, got %v", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `<script src="board.js"></script>`) {
+			t.Error("board page missing board.js")
+		}
+	})
+
+	t.Run("GET /burndown", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/burndown", nil)
+		w := httptest.NewRecorder()
+		srv.handleBurndown(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status OK, got %v", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `<script src="burndown.js"></script>`) {
+			t.Error("burndown page missing burndown.js")
+		}
+	})
+
+	t.Run("POST /api/tasks/bulk", func(t *testing.T) {
+		post := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/tasks/bulk", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			srv.handleTasksBulk(w, req)
+			return w
+		}
+
+		w := post(`{"feature_name": "test-feature", "tasks": [
+			{"name": "bulk-b", "depends_on": ["bulk-a", "test-task"]},
+			{"name": "bulk-a", "priority": 3}
+		]}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status Created, got %v: %s", w.Code, w.Body.String())
+		}
+		var created []models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+			t.Fatalf("Failed to unmarshal tasks: %v", err)
+		}
+		if len(created) != 2 || created[0].ID == "" {
+			t.Fatalf("Expected 2 created tasks, got %+v", created)
+		}
+		deps, err := database.GetDependencies(ctx, created[0].ID)
+		if err != nil {
+			t.Fatalf("GetDependencies failed: %v", err)
+		}
+		if len(deps) != 2 {
+			t.Errorf("Expected 2 dependencies, got %d", len(deps))
+		}
+
+		if w := post(`{"feature_name": "test-feature", "tasks": [{"name": "bulk-a"}]}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for existing task, got %v", w.Code)
+		}
+		if w := post(`not json`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest for invalid body, got %v", w.Code)
+		}
+	})
+
+	t.Run("POST /api/orchestrator/pause", func(t *testing.T) {
+		call := func(handler http.HandlerFunc, method, path string) orchestratorState {
+			t.Helper()
+			req := httptest.NewRequest(method, path, nil)
+			w := httptest.NewRecorder()
+			handler(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s %s: expected status OK, got %v: %s", method, path, w.Code, w.Body.String())
+			}
+			var state orchestratorState
+			if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+				t.Fatalf("Failed to unmarshal state: %v", err)
+			}
+			return state
+		}
+
+		req := httptest.NewRequest("POST", "/api/orchestrator/pause", nil)
+		w := httptest.NewRecorder()
+		srv.handleOrchestratorPause(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status ServiceUnavailable without an orchestrator, got %v", w.Code)
+		}
+
+		orch := &fakeOrchestrator{}
+		srv.SetOrchestrator(orch)
+		defer srv.SetOrchestrator(nil)
+
+		if state := call(srv.handleOrchestratorPause, "POST", "/api/orchestrator/pause"); !state.Paused || !orch.paused {
+			t.Errorf("Expected orchestrator to be paused, got %+v", state)
+		}
+		if state := call(srv.handleOrchestrator, "GET", "/api/orchestrator"); !state.Paused {
+			t.Errorf("Expected paused state, got %+v", state)
+		}
+		if state := call(srv.handleOrchestratorResume, "POST", "/api/orchestrator/resume"); state.Paused || orch.paused {
+			t.Errorf("Expected orchestrator to be resumed, got %+v", state)
+		}
+	})
+
+	t.Run("PUT /api/orchestrator/workers", func(t *testing.T) {
+		orch := &fakeOrchestrator{model: "model-a"}
+		srv.SetOrchestrator(orch)
+		defer srv.SetOrchestrator(nil)
+
+		call := func(handler http.HandlerFunc, method, path, body string) *httptest.ResponseRecorder {
+			t.Helper()
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+			return w
+		}
+
+		w := call(srv.handleOrchestratorWorkers, "PUT", "/api/orchestrator/workers", `{"target": 3}`)
+		if w.Code != http.StatusOK || orch.target != 3 {
+			t.Fatalf("Expected 3 target workers, got %v (%d): %s", w.Code, orch.target, w.Body.String())
+		}
+		var state orchestratorState
+		if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+			t.Fatalf("Failed to unmarshal state: %v", err)
+		}
+		if state.TargetWorkers != 3 || state.MaxWorkers != 4 || len(state.Workers) != 1 || state.Workers[0].TaskName != "task1" {
+			t.Errorf("Unexpected state: %+v", state)
+		}
+		if w := call(srv.handleOrchestratorWorkers, "PUT", "/api/orchestrator/workers", `{"target": 5}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status BadRequest above max workers, got %v", w.Code)
+		}
+
+		if w := call(srv.handleOrchestratorModel, "PUT", "/api/orchestrator/model", `{"model": "model-b"}`); w.Code != http.StatusOK || orch.model != "model-b" {
+			t.Errorf("Expected model-b, got %v (%s)", w.Code, orch.model)
+		}
+		if w := call(srv.handleOrchestratorModel, "PUT", "/api/orchestrator/model", `{"model": "other"}`); w.Code != http.StatusBadRequest || orch.model != "model-b" {
+			t.Errorf("Expected status BadRequest for an unavailable model, got %v (%s)", w.Code, orch.model)
+		}
+
+		if w := call(srv.handleOrchestratorStop, "POST", "/api/orchestrator/stop", ""); w.Code != http.StatusOK || !orch.stopped {
+			t.Errorf("Expected orchestrator to be stopped, got %v", w.Code)
+		}
+	})
+
 	t.Run("GET /", func(t *testing.T) {
 		mux := testMux()
 		req := httptest.NewRequest("GET", "/", nil)
@@ -151,8 +801,165 @@ func TestServer_API(t *testing.T) {
 	})
 }
 
+type fakeOrchestrator struct {
+	paused  bool
+	stopped bool
+	model   string
+	target  int
+	// events are written to each subscriber as it subscribes.
+	events []string
+}
+
+func (o *fakeOrchestrator) Pause()                       { o.paused = true }
+func (o *fakeOrchestrator) Resume()                      { o.paused = false }
+func (o *fakeOrchestrator) IsPaused() bool               { return o.paused }
+func (o *fakeOrchestrator) Stop()                        { o.stopped = true }
+func (o *fakeOrchestrator) GetModel() string             { return o.model }
+func (o *fakeOrchestrator) SetModel(model string)        { o.model = model }
+func (o *fakeOrchestrator) GetAvailableModels() []string { return []string{"model-a", "model-b"} }
+func (o *fakeOrchestrator) GetTargetWorkers() int        { return o.target }
+func (o *fakeOrchestrator) SetTargetWorkers(target int)  { o.target = target }
+func (o *fakeOrchestrator) GetMaxWorkers() int           { return 4 }
+func (o *fakeOrchestrator) Workers() []models.ActiveWorker {
+	return []models.ActiveWorker{{ID: 1, TaskID: "t1", TaskName: "task1", Model: o.model}}
+}
+
+func (o *fakeOrchestrator) SubscribeEvents(w io.Writer) func() {
+	for _, line := range o.events {
+		io.WriteString(w, line+"\n")
+	}
+	return func() {}
+}
+
+func TestServer_EventStream(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := database.CreateFeature(ctx, &models.Feature{Name: "f", Description: "d", Specification: "s"}); err != nil {
+		t.Fatalf("CreateFeature failed: %v", err)
+	}
+
+	defer func(interval time.Duration) { streamPollInterval = interval }(streamPollInterval)
+	streamPollInterval = 10 * time.Millisecond
+
+	srv := NewServer(database)
+	srv.SetOrchestrator(&fakeOrchestrator{events: []string{`{"time":"2024-01-01T00:00:00Z","event":"status","worker_id":1,"message":"hi"}`}})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events/stream?after=x")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status BadRequest for an invalid id, got %v", resp.StatusCode)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, "GET", ts.URL+"/api/events/stream", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", ct)
+	}
+
+	// Events are separated by blank lines; collect them until both the
+	// worker event and the feature's creation have arrived.
+	seen := make(map[string]string)
+	var event, id string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && (seen["status"] == "" || seen["audit"] == "") {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if event == "audit" && id == "" {
+				t.Error("Expected audit events to carry their id")
+			}
+			seen[event] = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			event, id = "", ""
+		}
+	}
+
+	var status struct{ Message string }
+	if err := json.Unmarshal([]byte(seen["status"]), &status); err != nil || status.Message != "hi" {
+		t.Errorf("Expected the worker event, got %q", seen["status"])
+	}
+	var audit models.Event
+	if err := json.Unmarshal([]byte(seen["audit"]), &audit); err != nil || audit.EntityName != "f" || audit.Action != models.EventCreated {
+		t.Errorf("Expected the feature's creation, got %q", seen["audit"])
+	}
+}
+
 func testMux() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.Handle("/", http.FileServer(http.FS(graph_assets.Assets)))
 	return mux
 }
+
+func TestServer_ReadOnly(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	feature := &models.Feature{Name: "f", Description: "d", Specification: "s"}
+	if err := database.CreateFeature(ctx, feature); err != nil {
+		t.Fatalf("CreateFeature failed: %v", err)
+	}
+	task := &models.Task{FeatureID: feature.ID, Name: "t", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := database.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	srv := NewServer(database)
+	srv.SetReadOnly(true)
+	handler := srv.Handler()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	for _, path := range []string{"/api/tasks", "/api/graph", "/board"} {
+		if w := serve("GET", path, ""); w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, w.Code)
+		}
+	}
+
+	w := serve("PATCH", "/api/tasks/"+task.ID, `{"status": "in_progress"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "read-only") {
+		t.Errorf("expected 403 read-only for PATCH, got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve("POST", "/api/tasks/bulk", `{"feature_name": "f", "tasks": [{"name": "x"}]}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for POST /api/tasks/bulk, got %d", w.Code)
+	}
+
+	got, _ := database.GetTask(ctx, task.ID)
+	if got.Status != models.TaskStatusPending {
+		t.Errorf("expected the task to stay pending, got %s", got.Status)
+	}
+}
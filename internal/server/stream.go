@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamPollInterval is how often the event stream checks the audit log for
+// new entries.
+var streamPollInterval = time.Second
+
+// streamKeepAlive is how long the event stream may stay silent before it
+// sends a comment, so proxies don't close an idle connection.
+const streamKeepAlive = 15 * time.Second
+
+// streamBuffer is how many orchestrator events are held for a client that
+// is slow to read; events beyond that are dropped rather than holding up the
+// workers.
+const streamBuffer = 256
+
+// streamAuditEvent is the SSE event name of audit log entries.
+const streamAuditEvent = "audit"
+
+// handleEventStream follows the audit log and, when an orchestrator is
+// attached, the events of its workers as Server-Sent Events. Audit log
+// entries are sent as "audit" events with their id, so a client that
+// reconnects with Last-Event-ID (or ?after=) picks up where it left off;
+// without one the stream starts at the current end of the log. Worker
+// events are sent under their event type, with the JSON of the -no-tui
+// log as data.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ctx := r.Context()
+
+	after := r.Header.Get("Last-Event-ID")
+	if after == "" {
+		after = r.URL.Query().Get("after")
+	}
+	var lastID int64
+	if after != "" {
+		n, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid event id", http.StatusBadRequest)
+			return
+		}
+		lastID = n
+	} else {
+		latest, err := s.db.ListEvents(ctx, "", "", 1)
+		if err != nil {
+			s.respond(w, nil, err)
+			return
+		}
+		if len(latest) > 0 {
+			lastID = latest[0].ID
+		}
+	}
+
+	var workerEvents <-chan []byte
+	if s.orch != nil {
+		lines := make(chan []byte, streamBuffer)
+		unsubscribe := s.orch.SubscribeEvents(streamWriter(lines))
+		defer unsubscribe()
+		workerEvents = lines
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(streamPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		wrote := false
+		select {
+		case <-ctx.Done():
+			return
+		case line := <-workerEvents:
+			var ev struct {
+				Event string `json:"event"`
+			}
+			if json.Unmarshal(line, &ev) != nil || ev.Event == "" {
+				continue
+			}
+			writeStreamEvent(w, "", ev.Event, bytes.TrimSpace(line))
+			wrote = true
+		case <-poll.C:
+			events, err := s.db.ListEventsAfter(ctx, "", lastID, 100)
+			if err != nil {
+				if ctx.Err() == nil {
+					writeStreamEvent(w, "", "error", streamData(map[string]string{"error": err.Error()}))
+					flusher.Flush()
+				}
+				return
+			}
+			for _, e := range events {
+				lastID = e.ID
+				writeStreamEvent(w, strconv.FormatInt(e.ID, 10), streamAuditEvent, streamData(e))
+				wrote = true
+			}
+			if !wrote && time.Since(lastWrite) >= streamKeepAlive {
+				io.WriteString(w, ": keep-alive\n\n")
+				wrote = true
+			}
+		}
+		if wrote {
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+	}
+}
+
+// writeStreamEvent writes one Server-Sent Event. data must not contain
+// newlines, which JSON encoding guarantees.
+func writeStreamEvent(w io.Writer, id, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// streamData encodes v as the data of an event.
+func streamData(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return []byte("null")
+	}
+	return data
+}
+
+// streamWriter hands each JSON line the orchestrator writes to the stream,
+// dropping it when the client has fallen too far behind.
+type streamWriter chan<- []byte
+
+func (s streamWriter) Write(p []byte) (int, error) {
+	select {
+	case s <- bytes.Clone(p):
+	default:
+	}
+	return len(p), nil
+}
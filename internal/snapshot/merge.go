@@ -0,0 +1,420 @@
+// Package snapshot works with the JSONL snapshot files written by
+// db.ExportSnapshot without going through a database.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Conflict markers wrap records that both sides changed incompatibly. Each
+// side of a conflict is a complete JSON line, so resolving one means keeping
+// the right line and deleting the others.
+const (
+	markerOurs   = "<<<<<<< ours"
+	markerSep    = "======="
+	markerTheirs = ">>>>>>> theirs"
+)
+
+// MergeResult describes the outcome of a three-way merge.
+type MergeResult struct {
+	// Conflicts lists the keys of records written with conflict markers.
+	Conflicts []string
+}
+
+// record is one snapshot line. Field order is kept so merged lines look like
+// the ones the exporter writes.
+type record struct {
+	typ    string
+	keys   []string
+	fields map[string]json.RawMessage
+	raw    string
+}
+
+// recordOrder matches the buckets of v_snapshot_jsonl_lines and
+// v_snapshot_archived_jsonl_lines.
+var recordOrder = map[string]int{
+	"meta":                0,
+	"feature":             1,
+	"task":                2,
+	"dependency":          3,
+	"note":                4,
+	"link":                5,
+	"archived_task":       6,
+	"archived_dependency": 7,
+	"archived_note":       8,
+	"archived_link":       9,
+	"archived_feature":    10,
+	"environment":         11,
+	"template":            12,
+	"feature_dependency":  13,
+}
+
+// Merge performs a three-way merge of snapshot files keyed by name rather
+// than line position: features by name, tasks by feature and name,
+// dependencies by both task names, feature dependencies by both feature
+// names, notes by ID, links by external reference, run environments by
+// their feature and task, and templates by name. Records changed on one side
+// only take that side; records changed on both sides are merged field by
+// field. Fields that both sides set to different values are written as a
+// conflict block and reported in the result.
+func Merge(base, ours, theirs io.Reader, out io.Writer) (*MergeResult, error) {
+	baseRecs, _, err := readRecords(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base snapshot: %w", err)
+	}
+	ourRecs, ourOrder, err := readRecords(ours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read our snapshot: %w", err)
+	}
+	theirRecs, theirOrder, err := readRecords(theirs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read their snapshot: %w", err)
+	}
+
+	// Records deleted on both sides appear in neither list and are dropped.
+	keys := ourOrder
+	for _, key := range theirOrder {
+		if _, ok := ourRecs[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	type merged struct {
+		key      string
+		sortKey  []string
+		line     string
+		conflict [2]*record
+	}
+
+	var result MergeResult
+	var lines []merged
+	for _, key := range keys {
+		b, o, t := baseRecs[key], ourRecs[key], theirRecs[key]
+
+		var rec *record
+		conflict := false
+		switch {
+		case o == nil:
+			// Deleted on our side: drop it unless they changed it.
+			if b != nil && b.raw == t.raw {
+				continue
+			}
+			if b != nil {
+				conflict = true
+			} else {
+				rec = t
+			}
+		case t == nil:
+			if b != nil && b.raw == o.raw {
+				continue
+			}
+			if b != nil {
+				conflict = true
+			} else {
+				rec = o
+			}
+		default:
+			rec, conflict = mergeRecord(b, o, t)
+		}
+
+		m := merged{key: key}
+		if conflict {
+			m.conflict = [2]*record{o, t}
+			if o != nil {
+				m.sortKey = o.sortKey()
+			} else {
+				m.sortKey = t.sortKey()
+			}
+			result.Conflicts = append(result.Conflicts, key)
+		} else {
+			m.line = rec.raw
+			m.sortKey = rec.sortKey()
+		}
+		lines = append(lines, m)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		a, b := lines[i].sortKey, lines[j].sortKey
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	w := bufio.NewWriter(out)
+	for _, m := range lines {
+		if m.line != "" {
+			fmt.Fprintln(w, m.line)
+			continue
+		}
+		fmt.Fprintln(w, markerOurs)
+		if m.conflict[0] != nil {
+			fmt.Fprintln(w, m.conflict[0].raw)
+		}
+		fmt.Fprintln(w, markerSep)
+		if m.conflict[1] != nil {
+			fmt.Fprintln(w, m.conflict[1].raw)
+		}
+		fmt.Fprintln(w, markerTheirs)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write merged snapshot: %w", err)
+	}
+	return &result, nil
+}
+
+// mergeRecord merges a record present on both sides, field by field.
+func mergeRecord(base, ours, theirs *record) (*record, bool) {
+	if ours.raw == theirs.raw {
+		return ours, false
+	}
+	if base != nil && base.raw == ours.raw {
+		return theirs, false
+	}
+	if base != nil && base.raw == theirs.raw {
+		return ours, false
+	}
+
+	out := &record{typ: ours.typ, fields: make(map[string]json.RawMessage)}
+	out.keys = append(out.keys, ours.keys...)
+	for _, key := range theirs.keys {
+		if _, ok := ours.fields[key]; !ok {
+			out.keys = append(out.keys, key)
+		}
+	}
+
+	for _, key := range out.keys {
+		o, inOurs := ours.fields[key]
+		t, inTheirs := theirs.fields[key]
+		var b json.RawMessage
+		inBase := false
+		if base != nil {
+			b, inBase = base.fields[key]
+		}
+
+		switch {
+		case !inTheirs:
+			out.fields[key] = o
+		case !inOurs:
+			out.fields[key] = t
+		case bytes.Equal(o, t):
+			out.fields[key] = o
+		case inBase && bytes.Equal(b, o):
+			out.fields[key] = t
+		case inBase && bytes.Equal(b, t):
+			out.fields[key] = o
+		default:
+			v, ok := resolveField(key, o, t)
+			if !ok {
+				return nil, true
+			}
+			out.fields[key] = v
+		}
+	}
+
+	out.raw = out.encode()
+	return out, false
+}
+
+// resolveField settles fields that both sides changed but that can be merged
+// without a person: bookkeeping timestamps and IDs, which differ whenever
+// both branches touch or independently create the same record.
+func resolveField(key string, ours, theirs json.RawMessage) (json.RawMessage, bool) {
+	switch {
+	case key == "id" || strings.HasSuffix(key, "_id"):
+		return ours, true
+	case key == "created_at":
+		return pickTime(ours, theirs, false), true
+	case key == "updated_at" || key == "generated_at":
+		return pickTime(ours, theirs, true), true
+	}
+	return nil, false
+}
+
+// pickTime returns the later (or earlier) of two RFC 3339 timestamps, which
+// compare correctly as strings. A null loses to any time.
+func pickTime(a, b json.RawMessage, later bool) json.RawMessage {
+	var sa, sb string
+	_ = json.Unmarshal(a, &sa)
+	_ = json.Unmarshal(b, &sb)
+	switch {
+	case sa == "":
+		return b
+	case sb == "":
+		return a
+	case (sb > sa) == later:
+		return b
+	default:
+		return a
+	}
+}
+
+func (r *record) encode() string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(r.fields[key])
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+func (r *record) str(field string) string {
+	var s string
+	_ = json.Unmarshal(r.fields[field], &s)
+	return s
+}
+
+// key identifies the record across both sides of a merge.
+func (r *record) key() string {
+	switch r.typ {
+	case "meta":
+		return "meta"
+	case "feature":
+		return "feature:" + r.str("name")
+	case "task":
+		return "task:" + r.str("feature_name") + "/" + r.str("name")
+	case "dependency":
+		return "dependency:" + r.str("task_feature_name") + "/" + r.str("task_name") +
+			"->" + r.str("depends_on_task_feature_name") + "/" + r.str("depends_on_task_name")
+	case "note":
+		return "note:" + r.str("id")
+	case "link":
+		return "link:" + r.str("provider") + "/" + r.str("external_ref")
+	case "environment":
+		return "environment:" + r.str("feature_name") + "/" + r.str("task_name")
+	case "template":
+		return "template:" + r.str("name")
+	case "feature_dependency":
+		return "feature_dependency:" + r.str("feature_name") + "->" + r.str("depends_on_feature_name")
+	case "archived_task", "archived_note", "archived_feature":
+		// Archived names need not be unique, so these are keyed by ID.
+		return r.typ + ":" + r.str("id")
+	case "archived_dependency":
+		return "archived_dependency:" + r.str("task_id") + "->" + r.str("depends_on_task_id")
+	case "archived_link":
+		return "archived_link:" + r.str("provider") + "/" + r.str("external_ref")
+	}
+	return r.typ + ":" + r.raw
+}
+
+// sortKey orders records the way the exporter does, so a merged file diffs
+// cleanly against the next export.
+func (r *record) sortKey() []string {
+	order, ok := recordOrder[r.typ]
+	if !ok {
+		order = len(recordOrder)
+	}
+	bucket := fmt.Sprintf("%02d", order)
+
+	switch r.typ {
+	case "feature":
+		return []string{bucket, r.str("name")}
+	case "task":
+		return []string{bucket, r.str("name"), r.str("feature_name")}
+	case "dependency":
+		return []string{bucket, r.str("task_name"), r.str("depends_on_task_name"), r.str("task_feature_name"), r.str("depends_on_task_feature_name")}
+	case "note":
+		return []string{bucket, r.str("task_feature_name") + "/" + r.str("task_name"), r.str("created_at"), r.str("id")}
+	case "link", "archived_link":
+		return []string{bucket, r.str("provider"), r.str("external_ref")}
+	case "archived_task":
+		return []string{bucket, r.str("feature_name") + "/" + r.str("name"), r.str("id")}
+	case "archived_dependency":
+		return []string{bucket, r.str("task_id"), r.str("depends_on_task_id")}
+	case "archived_note":
+		return []string{bucket, r.str("task_id"), r.str("created_at") + r.str("id")}
+	case "archived_feature":
+		return []string{bucket, r.str("name"), r.str("id")}
+	case "environment":
+		return []string{bucket, r.str("feature_name"), r.str("task_name")}
+	case "template":
+		return []string{bucket, r.str("name")}
+	case "feature_dependency":
+		return []string{bucket, r.str("feature_name"), r.str("depends_on_feature_name")}
+	}
+	return []string{bucket}
+}
+
+// readRecords parses a snapshot into records keyed by identity, returning the
+// keys in file order.
+func readRecords(r io.Reader) (map[string]*record, []string, error) {
+	recs := make(map[string]*record)
+	var order []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		rec, err := parseRecord(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		key := rec.key()
+		if _, dup := recs[key]; !dup {
+			order = append(order, key)
+		}
+		recs[key] = rec
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return recs, order, nil
+}
+
+func parseRecord(line string) (*record, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	rec := &record{fields: make(map[string]json.RawMessage), raw: line}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an object key")
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, seen := rec.fields[key]; !seen {
+			rec.keys = append(rec.keys, key)
+		}
+		rec.fields[key] = value
+	}
+
+	rec.typ = rec.str("record_type")
+	if rec.typ == "" {
+		return nil, fmt.Errorf("missing record_type")
+	}
+	return rec, nil
+}
@@ -0,0 +1,207 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nick-dorsch/ponder/internal/db"
+	"github.com/nick-dorsch/ponder/pkg/models"
+)
+
+const (
+	metaLine = `{"record_type":"meta","schema_version":"1","generated_at":"2026-01-01 00:00:00","source":"sqlite"}`
+	feature  = `{"record_type":"feature","id":"f1","name":"core","description":"d","specification":"s","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`
+)
+
+func taskLine(id, name, description, status, updatedAt string) string {
+	return `{"record_type":"task","id":"` + id + `","name":"` + name + `","description":"` + description +
+		`","specification":"s","feature_name":"core","tests_required":true,"priority":5,"status":"` + status +
+		`","completion_summary":null,"created_at":"2026-01-01T00:00:00Z","updated_at":"` + updatedAt +
+		`","started_at":null,"completed_at":null}`
+}
+
+func merge(t *testing.T, base, ours, theirs []string) (string, *MergeResult) {
+	t.Helper()
+	var out bytes.Buffer
+	result, err := Merge(
+		strings.NewReader(strings.Join(base, "\n")),
+		strings.NewReader(strings.Join(ours, "\n")),
+		strings.NewReader(strings.Join(theirs, "\n")),
+		&out,
+	)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	return out.String(), result
+}
+
+func TestMergeBothAdded(t *testing.T) {
+	base := []string{metaLine, feature}
+	ours := append(base[:2:2], taskLine("t1", "alpha", "d", "pending", "2026-01-02T00:00:00Z"))
+	theirs := append(base[:2:2], taskLine("t2", "beta", "d", "pending", "2026-01-03T00:00:00Z"))
+
+	out, result := merge(t, base, ours, theirs)
+	if len(result.Conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d:\n%s", len(lines), out)
+	}
+	if lines[0] != metaLine || lines[1] != feature {
+		t.Errorf("Expected meta and feature first, got:\n%s", out)
+	}
+	if !strings.Contains(lines[2], `"name":"alpha"`) || !strings.Contains(lines[3], `"name":"beta"`) {
+		t.Errorf("Expected tasks sorted by name, got:\n%s", out)
+	}
+}
+
+func TestMergeFieldLevel(t *testing.T) {
+	base := []string{metaLine, feature, taskLine("t1", "alpha", "d", "pending", "2026-01-01T00:00:00Z")}
+	ours := []string{metaLine, feature, taskLine("t1", "alpha", "d", "in_progress", "2026-01-02T00:00:00Z")}
+	theirs := []string{metaLine, feature, taskLine("t1", "alpha", "better description", "pending", "2026-01-03T00:00:00Z")}
+
+	out, result := merge(t, base, ours, theirs)
+	if len(result.Conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+	}
+	want := taskLine("t1", "alpha", "better description", "in_progress", "2026-01-03T00:00:00Z")
+	if !strings.Contains(out, want+"\n") {
+		t.Errorf("Expected merged task line %s, got:\n%s", want, out)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	base := []string{metaLine, feature, taskLine("t1", "alpha", "d", "in_progress", "2026-01-01T00:00:00Z")}
+	ours := []string{metaLine, feature, taskLine("t1", "alpha", "d", "completed", "2026-01-02T00:00:00Z")}
+	theirs := []string{metaLine, feature, taskLine("t1", "alpha", "d", "blocked", "2026-01-03T00:00:00Z")}
+
+	out, result := merge(t, base, ours, theirs)
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != "task:core/alpha" {
+		t.Fatalf("Expected one conflict on task:core/alpha, got %v", result.Conflicts)
+	}
+	want := strings.Join([]string{markerOurs, ours[2], markerSep, theirs[2], markerTheirs}, "\n")
+	if !strings.Contains(out, want) {
+		t.Errorf("Expected conflict block, got:\n%s", out)
+	}
+}
+
+func TestMergeDeletion(t *testing.T) {
+	task := taskLine("t1", "alpha", "d", "pending", "2026-01-01T00:00:00Z")
+	base := []string{metaLine, feature, task}
+
+	out, result := merge(t, base, []string{metaLine, feature}, base)
+	if len(result.Conflicts) != 0 || strings.Contains(out, "alpha") {
+		t.Errorf("Expected task deleted cleanly, got %v:\n%s", result.Conflicts, out)
+	}
+
+	changed := taskLine("t1", "alpha", "d", "in_progress", "2026-01-02T00:00:00Z")
+	_, result = merge(t, base, []string{metaLine, feature}, []string{metaLine, feature, changed})
+	if len(result.Conflicts) != 1 {
+		t.Errorf("Expected modify/delete conflict, got %v", result.Conflicts)
+	}
+}
+
+func TestMergeExportedSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.jsonl")
+
+	open := func() *db.DB {
+		t.Helper()
+		database, err := db.Open(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+		if err := database.Init(ctx); err != nil {
+			t.Fatalf("Failed to init database: %v", err)
+		}
+		return database
+	}
+
+	// base: one feature with one task
+	baseDB := open()
+	f := &models.Feature{Name: "core", Description: "d", Specification: "s"}
+	if err := baseDB.CreateFeature(ctx, f); err != nil {
+		t.Fatalf("Failed to create feature: %v", err)
+	}
+	root := &models.Task{FeatureID: f.ID, Name: "root", Description: "d", Specification: "s", Status: models.TaskStatusPending}
+	if err := baseDB.CreateTask(ctx, root); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := baseDB.ExportSnapshot(ctx, basePath); err != nil {
+		t.Fatalf("Failed to export base: %v", err)
+	}
+
+	// each branch adds a task depending on root
+	branch := func(name string) string {
+		t.Helper()
+		branchDB := open()
+		if err := branchDB.ImportSnapshot(ctx, basePath); err != nil {
+			t.Fatalf("Failed to import base: %v", err)
+		}
+		task := &models.Task{FeatureID: f.ID, Name: name, Description: "d", Specification: "s", Status: models.TaskStatusPending}
+		if err := branchDB.CreateTask(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		if err := branchDB.CreateDependency(ctx, task.ID, root.ID); err != nil {
+			t.Fatalf("Failed to add dependency: %v", err)
+		}
+		path := filepath.Join(dir, name+".jsonl")
+		if err := branchDB.ExportSnapshot(ctx, path); err != nil {
+			t.Fatalf("Failed to export branch: %v", err)
+		}
+		return path
+	}
+	oursPath, theirsPath := branch("ours-task"), branch("theirs-task")
+
+	var files [3]*bytes.Reader
+	for i, path := range []string{basePath, oursPath, theirsPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		files[i] = bytes.NewReader(data)
+	}
+	var out bytes.Buffer
+	result, err := Merge(files[0], files[1], files[2], &out)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+	}
+
+	mergedPath := filepath.Join(dir, "merged.jsonl")
+	if err := os.WriteFile(mergedPath, out.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write merged snapshot: %v", err)
+	}
+	mergedDB := open()
+	if err := mergedDB.ImportSnapshot(ctx, mergedPath); err != nil {
+		t.Fatalf("Failed to import merged snapshot: %v\n%s", err, out.String())
+	}
+	tasks, err := mergedDB.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to list tasks: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("Expected 3 tasks after merge, got %d", len(tasks))
+	}
+	for _, task := range tasks {
+		if task.Name == "root" {
+			continue
+		}
+		deps, err := mergedDB.GetDependencies(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("Failed to get dependencies: %v", err)
+		}
+		if len(deps) != 1 || deps[0].Name != "root" {
+			t.Errorf("Expected %s to depend on root, got %+v", task.Name, deps)
+		}
+	}
+}
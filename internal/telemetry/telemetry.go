@@ -0,0 +1,132 @@
+// Package telemetry traces ponder with OpenTelemetry. Tracing is off unless an
+// OTLP endpoint is configured through the standard OTEL_* environment
+// variables, in which case spans are exported over OTLP/HTTP.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/nick-dorsch/ponder"
+
+var enabled atomic.Bool
+
+// Setup installs a tracer provider exporting to the OTLP endpoint named by
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT. Without
+// one, or with OTEL_SDK_DISABLED=true or OTEL_TRACES_EXPORTER=none, tracing
+// stays off. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	if !configured() {
+		return shutdown, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return shutdown, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "ponder")),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return shutdown, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	Install(provider)
+	return provider.Shutdown, nil
+}
+
+// Install makes provider the source of ponder's spans and turns tracing on.
+// Setup calls it; tests use it to record spans in memory.
+func Install(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	enabled.Store(true)
+}
+
+// Enabled reports whether spans are being exported. Callers use it to skip
+// work that only feeds spans.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+func configured() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	if strings.EqualFold(os.Getenv("OTEL_TRACES_EXPORTER"), "none") {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Start starts a span as a child of any span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End ends span, marking it failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Env returns TRACEPARENT and TRACESTATE entries carrying the span in ctx, for
+// the environment of a child process. It is empty when tracing is off.
+func Env(ctx context.Context) []string {
+	if !Enabled() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	var env []string
+	for _, key := range []string{"traceparent", "tracestate"} {
+		if value := carrier.Get(key); value != "" {
+			env = append(env, strings.ToUpper(key)+"="+value)
+		}
+	}
+	return env
+}
+
+// FromEnv returns ctx with the remote span named by the TRACEPARENT and
+// TRACESTATE environment variables as its parent, so a process started by a
+// traced run joins that run's trace.
+func FromEnv(ctx context.Context) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{}
+	for _, key := range []string{"traceparent", "tracestate"} {
+		if value := os.Getenv(strings.ToUpper(key)); value != "" {
+			carrier.Set(key, value)
+		}
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestConfigured(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"no endpoint", nil, false},
+		{"endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, true},
+		{"traces endpoint", map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4318/v1/traces"}, true},
+		{"sdk disabled", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_SDK_DISABLED": "TRUE"}, false},
+		{"exporter none", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_TRACES_EXPORTER": "none"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := configured(); got != tt.want {
+				t.Errorf("configured() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTracePropagatesThroughEnv(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := Start(context.Background(), "parent")
+	env := Env(ctx)
+	if len(env) == 0 || !strings.HasPrefix(env[0], "TRACEPARENT=") {
+		t.Fatalf("Env() = %v, want a TRACEPARENT entry", env)
+	}
+	t.Setenv("TRACEPARENT", strings.TrimPrefix(env[0], "TRACEPARENT="))
+
+	_, child := Start(FromEnv(context.Background()), "child")
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	got, want := spans[0].Parent().SpanID(), trace.SpanContextFromContext(ctx).SpanID()
+	if got != want {
+		t.Errorf("child's parent = %s, want %s", got, want)
+	}
+	if spans[0].Status().Code != codes.Error || spans[0].Status().Description != "boom" {
+		t.Errorf("child status = %+v, want error boom", spans[0].Status())
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Errorf("parent status = %+v, want unset", spans[1].Status())
+	}
+}
@@ -5,39 +5,52 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
 )
 
 var (
+	completedTaskStyle   lipgloss.Style
+	failedTaskStyle      lipgloss.Style
+	completedHeaderStyle lipgloss.Style
+	subTitleStyle        lipgloss.Style
+	placeholderStyle     lipgloss.Style
+)
+
+func init() { theme.OnChange(setCompletedTasksStyles) }
+
+// setCompletedTasksStyles colors the task history's styles from t.
+func setCompletedTasksStyles(t theme.Theme) {
 	completedTaskStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("42")).
-				Border(lipgloss.NormalBorder()).
-				BorderForeground(lipgloss.Color("42")).
-				Padding(0, 1)
+		Foreground(t.Success).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(t.Success).
+		Padding(0, 1)
 
 	failedTaskStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("196")).
-			Padding(0, 1)
+		Foreground(t.Failure).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(t.Failure).
+		Padding(0, 1)
 
 	completedHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("252")).
-				Padding(0, 1)
+		Bold(true).
+		Foreground(t.Text).
+		Padding(0, 1)
 
 	subTitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Padding(0, 1)
+		Bold(true).
+		Padding(0, 1)
 
 	placeholderStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("240")).
-				Italic(true).
-				Padding(0, 1)
-)
+		Foreground(t.Subtle).
+		Italic(true).
+		Padding(0, 1)
+}
 
 type TaskResult struct {
 	Name    string
 	Success bool
+	Branch  string
 }
 
 type CompletedTasks struct {
@@ -119,7 +132,11 @@ func (c *CompletedTasks) renderBox(title string, tasks []TaskResult, style lipgl
 	}
 
 	for _, t := range tasks {
-		wrappedName := lipgloss.NewStyle().Width(nameWidth).Render(t.Name)
+		label := t.Name
+		if t.Branch != "" {
+			label += " → " + t.Branch
+		}
+		wrappedName := lipgloss.NewStyle().Width(nameWidth).Render(label)
 		nameLines := strings.Split(wrappedName, "\n")
 		for i, line := range nameLines {
 			if i == 0 {
@@ -1,9 +1,11 @@
 package components
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -183,3 +185,100 @@ func TestWorkerOutputReWrappingOnResize(t *testing.T) {
 		t.Errorf("expected more lines after shrinking width: %d <= %d", len(lines2), len(lines1))
 	}
 }
+
+func TestWorkerOutputSearch(t *testing.T) {
+	o := NewWorkerOutput(40, 5)
+	o.SetSize(40, 5)
+
+	for i := 0; i < 20; i++ {
+		o.Append(fmt.Sprintf("line %d\n", i))
+		if i%5 == 0 {
+			o.Append("needle here\n")
+		}
+	}
+	o.SetSearch("NEEDLE")
+	if o.Matches() != 4 {
+		t.Fatalf("expected 4 matches, got %d", o.Matches())
+	}
+	if !strings.Contains(o.View(), "/NEEDLE  1/4") {
+		t.Errorf("expected search bar in view:\n%s", o.View())
+	}
+	if !strings.Contains(o.viewport.View(), "needle") {
+		t.Errorf("expected the first match to be scrolled into view:\n%s", o.viewport.View())
+	}
+
+	o.NextMatch()
+	second := o.viewport.YOffset
+	o.PrevMatch()
+	if o.viewport.YOffset >= second {
+		t.Errorf("expected previous match above the next one: %d >= %d", o.viewport.YOffset, second)
+	}
+	o.PrevMatch()
+	if !strings.Contains(o.View(), "4/4") {
+		t.Errorf("expected previous to wrap to the last match:\n%s", o.View())
+	}
+
+	// New output does not yank the view away from the match.
+	offset := o.viewport.YOffset
+	o.Append("more\n")
+	if o.viewport.YOffset != offset {
+		t.Errorf("expected view to stay on the match, moved from %d to %d", offset, o.viewport.YOffset)
+	}
+
+	o.ClearSearch()
+	if o.Matches() != 0 || !o.viewport.AtBottom() {
+		t.Errorf("expected clearing the search to follow the output again")
+	}
+}
+
+func TestWorkerOutputErrorsOnly(t *testing.T) {
+	o := NewWorkerOutput(40, 10)
+	o.SetSize(40, 10)
+
+	o.Append("compiling\n")
+	o.AppendStderr("warning: deprecated\n")
+	o.Append("test failed: TestFoo\n")
+	o.Append("all good\n")
+
+	o.ToggleErrorsOnly()
+	view := o.View()
+	for _, want := range []string{"warning: deprecated", "test failed", "[errors only]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in errors-only view:\n%s", want, view)
+		}
+	}
+	for _, hidden := range []string{"compiling", "all good"} {
+		if strings.Contains(view, hidden) {
+			t.Errorf("expected %q to be filtered out:\n%s", hidden, view)
+		}
+	}
+
+	o.ToggleErrorsOnly()
+	if !strings.Contains(o.View(), "compiling") {
+		t.Errorf("expected all output after toggling back")
+	}
+}
+
+func TestWorkerOutputSearchKeys(t *testing.T) {
+	o := NewWorkerOutput(40, 10)
+	o.SetSize(40, 10)
+	o.Append("alpha\nbeta\nalphabet\n")
+
+	o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	if !o.IsSearching() {
+		t.Fatal("expected / to open the search prompt")
+	}
+	for _, r := range "alphx" {
+		o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	o.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	o.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if o.IsSearching() || o.Matches() != 2 {
+		t.Errorf("expected 2 matches for alph, got %d", o.Matches())
+	}
+
+	o.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if o.Matches() != 0 {
+		t.Errorf("expected esc to clear the search")
+	}
+}
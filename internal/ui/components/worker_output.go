@@ -2,37 +2,91 @@ package components
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
 )
 
 var (
+	statusStyle          lipgloss.Style
+	outputStyle          lipgloss.Style
+	scrollbarTrackStyle  lipgloss.Style
+	scrollbarHandleStyle lipgloss.Style
+	matchStyle           lipgloss.Style
+	currentMatchStyle    lipgloss.Style
+	searchBarStyle       lipgloss.Style
+)
+
+func init() { theme.OnChange(setWorkerOutputStyles) }
+
+// setWorkerOutputStyles colors the worker output's styles from t.
+func setWorkerOutputStyles(t theme.Theme) {
 	statusStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Italic(true)
+		Foreground(t.Muted).
+		Italic(true)
 
 	outputStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252"))
+		Foreground(t.Text)
 
 	scrollbarTrackStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("236"))
+		Foreground(t.ScrollTrack)
 
 	scrollbarHandleStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241"))
-)
+		Foreground(t.Muted)
+
+	matchStyle = lipgloss.NewStyle().
+		Background(t.MatchBackground).
+		Foreground(t.MatchText)
+
+	currentMatchStyle = lipgloss.NewStyle().
+		Background(t.Highlight).
+		Foreground(t.OnHighlight).
+		Bold(true)
+
+	searchBarStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight)
+}
+
+// errorLinePattern picks out stdout lines that still belong in the
+// errors-only view.
+var errorLinePattern = regexp.MustCompile(`(?i)\b(errors?|fail(ed|ure)?|fatal|panic)\b`)
+
+type outputLine struct {
+	text   string
+	stderr bool
+
+	// rendered caches the wrapped line for renderedWidth.
+	rendered      string
+	renderedWidth int
+	height        int
+}
 
 type WorkerOutput struct {
 	viewport viewport.Model
-	output   strings.Builder
-	ready    bool
+	// lines is the output split on newlines; the last one is still open.
+	lines  []outputLine
+	height int
+	ready  bool
+
+	// searching is set while a query is typed into input; query is the
+	// search in effect and matches the viewport offsets of its lines.
+	searching  bool
+	input      string
+	query      string
+	matches    []int
+	match      int
+	errorsOnly bool
 }
 
 func NewWorkerOutput(width, height int) *WorkerOutput {
 	return &WorkerOutput{
 		viewport: viewport.New(width, height),
+		lines:    []outputLine{{}},
+		height:   height,
 	}
 }
 
@@ -41,83 +95,323 @@ func (o *WorkerOutput) SetSize(width, height int) {
 	if width > 0 {
 		vpWidth = width - 1
 	}
+	o.height = height
 	if !o.ready {
-		o.viewport = viewport.New(vpWidth, height)
+		o.viewport = viewport.New(vpWidth, o.viewportHeight())
 		o.viewport.HighPerformanceRendering = false
 		o.ready = true
 	} else {
 		o.viewport.Width = vpWidth
-		o.viewport.Height = height
+		o.viewport.Height = o.viewportHeight()
 	}
 	o.updateContent()
 }
 
 func (o *WorkerOutput) Append(content string) {
-	o.output.WriteString(content)
+	o.write(content, false)
+	o.updateContent()
+}
+
+// AppendStderr appends output the agent wrote to stderr, which the
+// errors-only filter keeps.
+func (o *WorkerOutput) AppendStderr(content string) {
+	o.write(content, true)
 	o.updateContent()
 }
 
 func (o *WorkerOutput) AppendStatus(status string) {
-	o.output.WriteString(statusStyle.Render(fmt.Sprintf("\n--- %s ---\n", status)))
+	o.write(statusStyle.Render(fmt.Sprintf("\n--- %s ---\n", status)), false)
 	o.updateContent()
 }
 
 func (o *WorkerOutput) SetContent(content string) {
-	o.output.Reset()
-	o.output.WriteString(content)
+	o.lines = []outputLine{{}}
+	o.write(content, false)
 	o.updateContent()
 }
 
 func (o *WorkerOutput) Reset() {
-	o.output.Reset()
+	o.lines = []outputLine{{}}
 	o.updateContent()
 }
 
+func (o *WorkerOutput) write(content string, stderr bool) {
+	parts := strings.Split(content, "\n")
+	last := &o.lines[len(o.lines)-1]
+	last.text += parts[0]
+	last.stderr = last.stderr || (stderr && parts[0] != "")
+	last.renderedWidth = -1
+	for _, p := range parts[1:] {
+		o.lines = append(o.lines, outputLine{text: p, stderr: stderr && p != "", renderedWidth: -1})
+	}
+}
+
+func (o *WorkerOutput) visible(l outputLine) bool {
+	return !o.errorsOnly || l.stderr || errorLinePattern.MatchString(l.text)
+}
+
 func (o *WorkerOutput) updateContent() {
 	width := o.viewport.Width
-	content := o.output.String()
+	style := outputStyle
 	if width > 0 {
-		content = outputStyle.Copy().Width(width).Render(content)
-	} else {
-		content = outputStyle.Render(content)
+		style = outputStyle.Copy().Width(width)
 	}
-	o.viewport.SetContent(content)
-	o.viewport.GotoBottom()
+
+	query := strings.ToLower(o.query)
+	o.matches = o.matches[:0]
+	rendered := make([]string, 0, len(o.lines))
+	offset := 0
+	for i := range o.lines {
+		l := &o.lines[i]
+		if !o.visible(*l) {
+			continue
+		}
+		if query != "" && strings.Contains(strings.ToLower(l.text), query) {
+			text := highlight(l.text, query, len(o.matches) == o.match)
+			o.matches = append(o.matches, offset)
+			r := style.Render(text)
+			rendered = append(rendered, r)
+			offset += lipgloss.Height(r)
+			continue
+		}
+		if l.renderedWidth != width {
+			l.rendered = style.Render(l.text)
+			l.renderedWidth = width
+			l.height = lipgloss.Height(l.rendered)
+		}
+		rendered = append(rendered, l.rendered)
+		offset += l.height
+	}
+	o.viewport.SetContent(strings.Join(rendered, "\n"))
+
+	// Follow new output unless the user is looking at search results.
+	if o.query == "" {
+		o.viewport.GotoBottom()
+	}
+}
+
+// highlight marks every occurrence of the lower-cased query in text.
+func highlight(text, query string, current bool) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lower-casing changed byte offsets; match without highlighting.
+		return text
+	}
+	style := matchStyle
+	if current {
+		style = currentMatchStyle
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, query)
+		if i < 0 {
+			break
+		}
+		b.WriteString(text[:i])
+		b.WriteString(style.Render(text[i : i+len(query)]))
+		text, lower = text[i+len(query):], lower[i+len(query):]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+// SetSearch highlights the lines containing query, case-insensitively, and
+// scrolls to the first match at or below the top of the viewport. An empty
+// query clears the search.
+func (o *WorkerOutput) SetSearch(query string) {
+	if query == "" {
+		o.ClearSearch()
+		return
+	}
+	top := o.viewport.YOffset
+	o.query = query
+	o.match = -1
+	o.layout()
+	o.match = 0
+	for i, offset := range o.matches {
+		if offset >= top {
+			o.match = i
+			break
+		}
+	}
+	o.showMatch()
+}
+
+// ClearSearch drops the search and follows the output again.
+func (o *WorkerOutput) ClearSearch() {
+	o.query = ""
+	o.matches = nil
+	o.match = 0
+	o.layout()
+}
+
+// NextMatch scrolls to the next match, wrapping around at the end.
+func (o *WorkerOutput) NextMatch() {
+	o.moveMatch(1)
+}
+
+// PrevMatch scrolls to the previous match, wrapping around at the start.
+func (o *WorkerOutput) PrevMatch() {
+	o.moveMatch(-1)
+}
+
+func (o *WorkerOutput) moveMatch(direction int) {
+	if len(o.matches) == 0 {
+		return
+	}
+	o.match = (o.match + direction + len(o.matches)) % len(o.matches)
+	o.showMatch()
+}
+
+// showMatch renders the current match and scrolls it into view.
+func (o *WorkerOutput) showMatch() {
+	o.updateContent()
+	if o.match < len(o.matches) {
+		o.viewport.SetYOffset(o.matches[o.match])
+	}
+}
+
+// ToggleErrorsOnly switches between all output and only the lines written
+// to stderr or mentioning an error.
+func (o *WorkerOutput) ToggleErrorsOnly() {
+	o.errorsOnly = !o.errorsOnly
+	o.viewport.Height = o.viewportHeight()
+	if o.query == "" {
+		o.updateContent()
+		return
+	}
+	o.match = 0
+	o.showMatch()
+}
+
+// ErrorsOnly reports whether the errors-only filter is on.
+func (o *WorkerOutput) ErrorsOnly() bool {
+	return o.errorsOnly
+}
+
+// IsSearching reports whether a query is being typed, in which case every
+// key belongs to the search prompt.
+func (o *WorkerOutput) IsSearching() bool {
+	return o.searching
+}
+
+// Matches returns how many lines match the search.
+func (o *WorkerOutput) Matches() int {
+	return len(o.matches)
+}
+
+// layout gives the search bar a line when it is shown and re-renders.
+func (o *WorkerOutput) layout() {
+	o.viewport.Height = o.viewportHeight()
+	o.updateContent()
+}
+
+func (o *WorkerOutput) viewportHeight() int {
+	if o.searchBar() == "" || o.height < 2 {
+		return o.height
+	}
+	return o.height - 1
+}
+
+func (o *WorkerOutput) searchBar() string {
+	var parts []string
+	switch {
+	case o.searching:
+		parts = append(parts, "/"+o.input+"█")
+	case o.query != "" && len(o.matches) == 0:
+		parts = append(parts, fmt.Sprintf("/%s  no matches", o.query))
+	case o.query != "":
+		parts = append(parts, fmt.Sprintf("/%s  %d/%d", o.query, o.match+1, len(o.matches)))
+	}
+	if o.errorsOnly {
+		parts = append(parts, "[errors only]")
+	}
+	return strings.Join(parts, "  ")
 }
 
 func (o *WorkerOutput) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok && o.handleKey(key) {
+		return nil
+	}
 	var cmd tea.Cmd
 	o.viewport, cmd = o.viewport.Update(msg)
 	return cmd
 }
 
+// handleKey handles the search and filter keys: / to search, n/N to move
+// between matches, x to toggle errors only and esc to clear the search. It
+// reports whether key was used.
+func (o *WorkerOutput) handleKey(key tea.KeyMsg) bool {
+	if o.searching {
+		switch key.Type {
+		case tea.KeyEnter:
+			o.searching = false
+			o.SetSearch(o.input)
+		case tea.KeyEsc:
+			o.searching = false
+			o.layout()
+		case tea.KeyBackspace:
+			if runes := []rune(o.input); len(runes) > 0 {
+				o.input = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			o.input += string(key.Runes)
+		}
+		return true
+	}
+
+	switch key.String() {
+	case "/":
+		o.searching = true
+		o.input = ""
+		o.layout()
+	case "n":
+		o.NextMatch()
+	case "N":
+		o.PrevMatch()
+	case "x":
+		o.ToggleErrorsOnly()
+	case "esc":
+		if o.query == "" {
+			return false
+		}
+		o.ClearSearch()
+	default:
+		return false
+	}
+	return true
+}
+
 func (o *WorkerOutput) View() string {
 	if !o.ready {
 		return ""
 	}
 
-	if o.viewport.TotalLineCount() <= o.viewport.Height {
-		return o.viewport.View()
-	}
-
-	h := o.viewport.Height
-	percent := o.viewport.ScrollPercent()
+	view := o.viewport.View()
+	if o.viewport.TotalLineCount() > o.viewport.Height {
+		h := o.viewport.Height
+		percent := o.viewport.ScrollPercent()
 
-	handlePos := int(float64(h-1) * percent)
+		handlePos := int(float64(h-1) * percent)
 
-	var sb strings.Builder
-	for i := 0; i < h; i++ {
-		if i == handlePos {
-			sb.WriteString(scrollbarHandleStyle.Render("┃"))
-		} else {
-			sb.WriteString(scrollbarTrackStyle.Render("│"))
-		}
-		if i < h-1 {
-			sb.WriteString("\n")
+		var sb strings.Builder
+		for i := 0; i < h; i++ {
+			if i == handlePos {
+				sb.WriteString(scrollbarHandleStyle.Render("┃"))
+			} else {
+				sb.WriteString(scrollbarTrackStyle.Render("│"))
+			}
+			if i < h-1 {
+				sb.WriteString("\n")
+			}
 		}
+		view = lipgloss.JoinHorizontal(lipgloss.Top, view, sb.String())
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, o.viewport.View(), sb.String())
+	if bar := o.searchBar(); bar != "" && o.viewport.Height < o.height {
+		view += "\n" + searchBarStyle.Render(bar)
+	}
+	return view
 }
 
 func (o *WorkerOutput) GotoBottom() {
@@ -125,9 +419,10 @@ func (o *WorkerOutput) GotoBottom() {
 }
 
 func (o *WorkerOutput) Height() int {
-	return o.viewport.Height
+	return o.height
 }
 
 func (o *WorkerOutput) SetHeight(height int) {
-	o.viewport.Height = height
+	o.height = height
+	o.viewport.Height = o.viewportHeight()
 }
@@ -0,0 +1,229 @@
+// Package theme holds the colors of the terminal UIs, so they can be swapped
+// for ones that read well on the user's terminal.
+package theme
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a color for each role in the terminal UIs. Colors are ANSI color
+// numbers or hex codes; an empty color leaves the terminal's default.
+type Theme struct {
+	// Logo is the Ponder orb in the header.
+	Logo lipgloss.Color
+	// Title is for headers and modal titles.
+	Title lipgloss.Color
+	// Accent is for modal borders.
+	Accent lipgloss.Color
+	// Highlight marks worker headers, the search bar and the current search
+	// match, which is drawn in OnHighlight.
+	Highlight   lipgloss.Color
+	OnHighlight lipgloss.Color
+	// Border surrounds worker panes; FocusBorder the focused one.
+	Border      lipgloss.Color
+	FocusBorder lipgloss.Color
+	Running     lipgloss.Color
+	Success     lipgloss.Color
+	Failure     lipgloss.Color
+	// Warning is for stalled workers.
+	Warning lipgloss.Color
+	// Text is agent output.
+	Text lipgloss.Color
+	// Muted is for stats, help and status lines; Subtle for dividers and
+	// placeholders, which should recede further.
+	Muted  lipgloss.Color
+	Subtle lipgloss.Color
+	// ScrollTrack is the track of scrollbars; the handle is Muted.
+	ScrollTrack lipgloss.Color
+	// MatchBackground and MatchText draw search matches.
+	MatchBackground lipgloss.Color
+	MatchText       lipgloss.Color
+}
+
+// Dark is the default theme, for terminals with a dark background.
+var Dark = Theme{
+	Logo:            "86",
+	Title:           "39",
+	Accent:          "45",
+	Highlight:       "205",
+	OnHighlight:     "0",
+	Border:          "63",
+	FocusBorder:     "12",
+	Running:         "33",
+	Success:         "42",
+	Failure:         "196",
+	Warning:         "214",
+	Text:            "252",
+	Muted:           "241",
+	Subtle:          "240",
+	ScrollTrack:     "236",
+	MatchBackground: "238",
+	MatchText:       "229",
+}
+
+// Light is for terminals with a light background, where the dark theme's
+// cyans and light grays wash out.
+var Light = Theme{
+	Logo:            "30",
+	Title:           "25",
+	Accent:          "31",
+	Highlight:       "162",
+	OnHighlight:     "231",
+	Border:          "61",
+	FocusBorder:     "20",
+	Running:         "26",
+	Success:         "28",
+	Failure:         "160",
+	Warning:         "130",
+	Text:            "235",
+	Muted:           "242",
+	Subtle:          "247",
+	ScrollTrack:     "253",
+	MatchBackground: "229",
+	MatchText:       "0",
+}
+
+// HighContrast sticks to the terminal's own 16 colors and default
+// foreground, which the terminal keeps readable on its background.
+var HighContrast = Theme{
+	Logo:            "6",
+	Title:           "4",
+	Accent:          "6",
+	Highlight:       "5",
+	OnHighlight:     "15",
+	Border:          "",
+	FocusBorder:     "4",
+	Running:         "4",
+	Success:         "2",
+	Failure:         "1",
+	Warning:         "3",
+	Text:            "",
+	Muted:           "",
+	Subtle:          "",
+	ScrollTrack:     "8",
+	MatchBackground: "3",
+	MatchText:       "0",
+}
+
+var builtin = map[string]Theme{
+	"dark":          Dark,
+	"light":         Light,
+	"high-contrast": HighContrast,
+}
+
+// Names returns the names of the built-in themes.
+func Names() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Named returns the built-in theme called name.
+func Named(name string) (Theme, error) {
+	t, ok := builtin[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q: expected %s", name, strings.Join(Names(), ", "))
+	}
+	return t, nil
+}
+
+// roles returns the theme's colors by the names config.json uses for them.
+func (t *Theme) roles() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"logo":             &t.Logo,
+		"title":            &t.Title,
+		"accent":           &t.Accent,
+		"highlight":        &t.Highlight,
+		"on_highlight":     &t.OnHighlight,
+		"border":           &t.Border,
+		"focus_border":     &t.FocusBorder,
+		"running":          &t.Running,
+		"success":          &t.Success,
+		"failure":          &t.Failure,
+		"warning":          &t.Warning,
+		"text":             &t.Text,
+		"muted":            &t.Muted,
+		"subtle":           &t.Subtle,
+		"scroll_track":     &t.ScrollTrack,
+		"match_background": &t.MatchBackground,
+		"match_text":       &t.MatchText,
+	}
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Override returns the theme with the colors of the roles in colors
+// replaced, e.g. {"success": "#15803d"}.
+func (t Theme) Override(colors map[string]string) (Theme, error) {
+	roles := t.roles()
+	for role, color := range colors {
+		c, ok := roles[role]
+		if !ok {
+			known := make([]string, 0, len(roles))
+			for name := range roles {
+				known = append(known, name)
+			}
+			slices.Sort(known)
+			return Theme{}, fmt.Errorf("unknown color %q: expected one of %s", role, strings.Join(known, ", "))
+		}
+		if !validColor(color) {
+			return Theme{}, fmt.Errorf("invalid %s color %q: expected an ANSI color number (0-255) or a hex code like #22d3ee", role, color)
+		}
+		*c = lipgloss.Color(color)
+	}
+	return t, nil
+}
+
+func validColor(color string) bool {
+	if hexColor.MatchString(color) {
+		return true
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
+
+var (
+	mu       sync.Mutex
+	current  = Dark
+	appliers []func(Theme)
+)
+
+// Current returns the theme in use.
+func Current() Theme {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// Use switches to t, restyling everything registered with OnChange. Call it
+// before starting a UI.
+func Use(t Theme) {
+	mu.Lock()
+	current = t
+	apply := slices.Clone(appliers)
+	mu.Unlock()
+	for _, fn := range apply {
+		fn(t)
+	}
+}
+
+// OnChange calls apply with the current theme now and with every theme
+// passed to Use later. Packages register the function that builds their
+// styles from init.
+func OnChange(apply func(Theme)) {
+	mu.Lock()
+	appliers = append(appliers, apply)
+	t := current
+	mu.Unlock()
+	apply(t)
+}
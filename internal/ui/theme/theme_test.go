@@ -0,0 +1,53 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestOverride(t *testing.T) {
+	got, err := Light.Override(map[string]string{"success": "#15803d", "muted": "244"})
+	if err != nil {
+		t.Fatalf("Override failed: %v", err)
+	}
+	if got.Success != "#15803d" || got.Muted != "244" || got.Failure != Light.Failure {
+		t.Errorf("unexpected theme %+v", got)
+	}
+	if Light.Success != "28" {
+		t.Error("expected Override to leave the built-in theme alone")
+	}
+
+	for _, colors := range []map[string]string{
+		{"sucess": "2"},
+		{"success": "green"},
+		{"success": "256"},
+		{"success": "#12345"},
+	} {
+		if _, err := Dark.Override(colors); err == nil {
+			t.Errorf("expected %v to be rejected", colors)
+		}
+	}
+}
+
+func TestUseRestylesRegistered(t *testing.T) {
+	defer Use(Dark)
+
+	var style lipgloss.Style
+	OnChange(func(t Theme) { style = lipgloss.NewStyle().Foreground(t.Title) })
+	if style.GetForeground() != Dark.Title {
+		t.Errorf("expected the current theme to be applied on registering, got %v", style.GetForeground())
+	}
+
+	hc, err := Named("high-contrast")
+	if err != nil {
+		t.Fatalf("Named failed: %v", err)
+	}
+	Use(hc)
+	if style.GetForeground() != HighContrast.Title || Current() != HighContrast {
+		t.Errorf("expected Use to restyle with the new theme, got %v", style.GetForeground())
+	}
+	if _, err := Named("solarized"); err == nil {
+		t.Error("expected an unknown theme to be rejected")
+	}
+}
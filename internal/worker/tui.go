@@ -6,24 +6,34 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nick-dorsch/ponder/internal/ui/components"
+	"github.com/nick-dorsch/ponder/internal/ui/theme"
 )
 
 var (
+	headerStyle lipgloss.Style
+	promptStyle lipgloss.Style
+	statusStyle lipgloss.Style
+)
+
+func init() { theme.OnChange(setTUIStyles) }
+
+// setTUIStyles colors the worker TUI's styles from t.
+func setTUIStyles(t theme.Theme) {
 	headerStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("205")).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(t.Highlight).
+		Bold(true).
+		Padding(0, 1)
 
 	promptStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder(), true, true, true, false).
-			BorderForeground(lipgloss.Color("63")).
-			Padding(0, 1).
-			Margin(1, 0)
+		Border(lipgloss.NormalBorder(), true, true, true, false).
+		BorderForeground(t.Border).
+		Padding(0, 1).
+		Margin(1, 0)
 
 	statusStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Italic(true)
-)
+		Foreground(t.Muted).
+		Italic(true)
+}
 
 type TUIModel struct {
 	ModelName     string
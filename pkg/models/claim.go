@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClaimLost is returned when renewing a claim the worker no longer holds,
+// typically because its lease expired and the task was reclaimed.
+var ErrClaimLost = errors.New("claim lost")
+
+// Claimer identifies a worker of a ponder process, possibly on another host
+// sharing the same database.
+type Claimer struct {
+	Hostname string `json:"hostname"`
+	PID      int    `json:"pid"`
+	WorkerID int    `json:"worker_id"`
+}
+
+func (c Claimer) String() string {
+	return fmt.Sprintf("%s:%d/worker-%d", c.Hostname, c.PID, c.WorkerID)
+}
+
+// Claim records which worker holds an in_progress task and until when.
+type Claim struct {
+	TaskID string `json:"task_id"`
+	Claimer
+	ClaimedAt      time.Time `json:"claimed_at"`
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+
+	// TaskName and FeatureName are helper fields for joined queries
+	TaskName    string `json:"task_name,omitempty"`
+	FeatureName string `json:"feature_name,omitempty"`
+}
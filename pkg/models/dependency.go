@@ -10,3 +10,13 @@ type Dependency struct {
 	DependsOnTaskName    string `json:"depends_on_task_name,omitempty"`
 	DependsOnFeatureName string `json:"depends_on_feature_name,omitempty"`
 }
+
+// FeatureDependency orders two features: no task of FeatureID is available
+// until every task of DependsOnFeatureID is completed or cancelled.
+type FeatureDependency struct {
+	FeatureID          string `json:"feature_id"`
+	DependsOnFeatureID string `json:"depends_on_feature_id"`
+
+	FeatureName          string `json:"feature_name,omitempty"`
+	DependsOnFeatureName string `json:"depends_on_feature_name,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// RunEnvironment is where the agent working on a task is started and the
+// environment variables it gets on top of ponder's own. It can be set on a
+// task or on a feature, for all of the feature's tasks.
+type RunEnvironment struct {
+	// WorkingDir is relative to the repository root, or to the task's
+	// worktree in worktree mode. Empty means the root.
+	WorkingDir string            `json:"working_dir,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+}
+
+// IsZero reports whether e changes nothing about how the agent runs.
+func (e *RunEnvironment) IsZero() bool {
+	return e == nil || (e.WorkingDir == "" && len(e.Env) == 0)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventAction string
+
+const (
+	EventCreated           EventAction = "created"
+	EventUpdated           EventAction = "updated"
+	EventRenamed           EventAction = "renamed"
+	EventStatusChanged     EventAction = "status_changed"
+	EventDeleted           EventAction = "deleted"
+	EventDependencyAdded   EventAction = "dependency_added"
+	EventDependencyRemoved EventAction = "dependency_removed"
+	EventImported          EventAction = "imported"
+	EventArchived          EventAction = "archived"
+	EventModelFallback     EventAction = "model_fallback"
+)
+
+// Event is a single entry in the audit log.
+type Event struct {
+	ID         int64           `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	EntityName string          `json:"entity_name"`
+	Action     EventAction     `json:"action"`
+	Actor      string          `json:"actor"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type Feature struct {
 	ID            string    `json:"id"`
@@ -9,4 +12,30 @@ type Feature struct {
 	Specification string    `json:"specification"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	// Version goes up by one with every change, for optimistic updates.
+	Version int `json:"version"`
+	// Progress is only filled in when features are listed.
+	Progress *FeatureProgress `json:"progress,omitempty"`
+	// DependsOn names the features whose tasks must all be done before any
+	// of this feature's tasks are available. Only filled in when features
+	// are listed.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// FeatureProgress rolls up the status of a feature's tasks. Cancelled tasks
+// are left out, since they will never be done.
+type FeatureProgress struct {
+	Total       int     `json:"total"`
+	Completed   int     `json:"completed"`
+	Blocked     int     `json:"blocked"`
+	PercentDone float64 `json:"percent_done"`
+}
+
+// NewFeatureProgress computes the share of completed tasks, to one decimal.
+func NewFeatureProgress(total, completed, blocked int) *FeatureProgress {
+	p := &FeatureProgress{Total: total, Completed: completed, Blocked: blocked}
+	if total > 0 {
+		p.PercentDone = math.Round(float64(completed)*1000/float64(total)) / 10
+	}
+	return p
 }
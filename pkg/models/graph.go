@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// GraphAnalysis describes the shape of the remaining work: the open tasks
+// (neither completed nor cancelled) and the dependencies between them.
+type GraphAnalysis struct {
+	OpenTasks int `json:"open_tasks"`
+	// CriticalPath is the longest chain of open tasks that must finish one
+	// after another, first prerequisite first.
+	CriticalPathLength int             `json:"critical_path_length"`
+	CriticalPath       []*GraphTaskRef `json:"critical_path"`
+	// Tasks lists every open task, those blocking the most work first.
+	Tasks []*TaskAnalysis `json:"tasks"`
+	// Unreachable tasks wait, directly or not, on a cancelled task and so
+	// can never become available.
+	Unreachable []*GraphTaskRef `json:"unreachable"`
+	// Orphaned tasks are open but connected to no other task: no
+	// dependencies either way, no parent and no subtasks.
+	Orphaned []*GraphTaskRef `json:"orphaned"`
+}
+
+// GraphTaskRef names a task in a graph analysis.
+type GraphTaskRef struct {
+	FeatureName string     `json:"feature_name"`
+	Name        string     `json:"name"`
+	Status      TaskStatus `json:"status"`
+}
+
+// TaskAnalysis is where an open task sits in the graph.
+type TaskAnalysis struct {
+	GraphTaskRef
+	// Depth is the number of open tasks on the longest chain ending with
+	// this one, itself included.
+	Depth int `json:"depth"`
+	// DirectDependents counts the open tasks that depend on this one, and
+	// BlockedTasks those that wait on it directly or not.
+	DirectDependents int `json:"direct_dependents"`
+	BlockedTasks     int `json:"blocked_tasks"`
+}
+
+// GraphDelta is what changed in the task graph since a point in time, for
+// clients keeping a copy of the graph up to date without fetching it all.
+type GraphDelta struct {
+	Since time.Time `json:"since"`
+	// AsOf is when the delta was taken: the since to ask for next time.
+	AsOf time.Time `json:"as_of"`
+	// Full means the changes could not be worked out, as when tasks were
+	// deleted along with their dependents' availability; Nodes and Edges
+	// are then the whole graph and replace the copy.
+	Full bool `json:"full"`
+	// Nodes are the changed tasks, shaped as in the full graph.
+	Nodes []json.RawMessage `json:"nodes"`
+	// Edges are all the dependencies of the tasks in Nodes, replacing the
+	// ones those tasks had.
+	Edges []GraphEdge `json:"edges"`
+	// Removed lists the IDs of tasks that left the graph, e.g. archived.
+	Removed []string `json:"removed"`
+}
+
+// GraphEdge is a dependency in the graph: task From depends on task To.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TaskLink ties a task to an item in an external tracker, such as a GitHub
+// issue referenced as "owner/repo#123".
+type TaskLink struct {
+	TaskID      string    `json:"task_id"`
+	Provider    string    `json:"provider"`
+	ExternalRef string    `json:"external_ref"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TaskNote is a markdown note attached to a task.
+type TaskNote struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Run is the transcript of one agent run on a task.
+type Run struct {
+	ID     int64  `json:"id"`
+	TaskID string `json:"task_id"`
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	// Output is everything the agent wrote to stdout and stderr.
+	Output string `json:"output"`
+	// ExitCode is -1 when the agent was killed or never started.
+	ExitCode int `json:"exit_code"`
+	// Error says why the run failed; empty when it succeeded.
+	Error string `json:"error,omitempty"`
+	// FailureClass is the kind of failure; empty when the run succeeded.
+	FailureClass FailureClass `json:"failure_class,omitempty"`
+	DurationMS   int64        `json:"duration_ms"`
+	StartedAt    time.Time    `json:"started_at"`
+}
+
+// FailureClass says how an agent run failed, which decides whether the task
+// is retried.
+type FailureClass string
+
+const (
+	// FailureAgentMissing means the agent (or sandbox runtime) command could
+	// not be found. Every run would fail the same way, so the orchestrator
+	// stops.
+	FailureAgentMissing FailureClass = "agent_missing"
+	// FailureExit means the agent exited with a non-zero status.
+	FailureExit FailureClass = "exit"
+	// FailureCancelled means the run was stopped, e.g. on shutdown. It
+	// doesn't count against the task.
+	FailureCancelled FailureClass = "cancelled"
+	// FailureTimeout means the agent ran past the task's time limit.
+	FailureTimeout FailureClass = "timeout"
+	// FailureStalled means the agent was killed for producing no output for
+	// the stall window.
+	FailureStalled FailureClass = "stalled"
+	// FailureOOM means the agent was killed for using too much memory.
+	// Retrying under the same limits would fail again, so the task is
+	// blocked.
+	FailureOOM FailureClass = "oom"
+	// FailureVerification means the agent finished but the verification
+	// command failed.
+	FailureVerification FailureClass = "verification"
+	// FailureOther is anything else, such as a worktree that could not be
+	// created.
+	FailureOther FailureClass = "other"
+)
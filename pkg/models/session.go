@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RunSession sums up one orchestrator invocation: when it ran, which models
+// did its task runs and how they went.
+type RunSession struct {
+	ID        int64     `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt is nil while the session runs, or if it crashed.
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+	// Models counts the task runs of each model.
+	Models map[string]int `json:"models"`
+	// Attempted counts task runs. Runs interrupted by the end of the session
+	// are neither completed nor failed.
+	Attempted  int     `json:"tasks_attempted"`
+	Completed  int     `json:"tasks_completed"`
+	Failed     int     `json:"tasks_failed"`
+	TokensIn   int64   `json:"tokens_in"`
+	TokensOut  int64   `json:"tokens_out"`
+	CostUSD    float64 `json:"cost_usd"`
+	DurationMS int64   `json:"duration_ms"`
+}
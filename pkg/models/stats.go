@@ -0,0 +1,151 @@
+package models
+
+import "time"
+
+// ProjectStats summarizes the backlog and how work on it has gone over the
+// last Days days.
+type ProjectStats struct {
+	Days       int                `json:"days"`
+	TotalTasks int                `json:"total_tasks"`
+	ByStatus   map[TaskStatus]int `json:"by_status"`
+	Features   []*FeatureStats    `json:"features"`
+
+	// Throughput has one entry per day of the period, oldest first, counting
+	// tasks completed that day (UTC), including since-archived ones.
+	Throughput []*DailyThroughput `json:"throughput"`
+	Completed  int                `json:"completed"`
+	// AvgDurationSeconds averages started_at to completed_at over the tasks
+	// completed in the period; nil when none were.
+	AvgDurationSeconds *float64 `json:"avg_duration_seconds"`
+
+	// FinishedRuns counts tasks leaving in_progress in the period, and
+	// FailedRuns those that went back to pending or to blocked.
+	FinishedRuns int `json:"finished_runs"`
+	FailedRuns   int `json:"failed_runs"`
+	// FailureRate is FailedRuns / FinishedRuns; nil when nothing finished.
+	FailureRate *float64 `json:"failure_rate"`
+}
+
+// FeatureStats counts a feature's tasks by status.
+type FeatureStats struct {
+	FeatureName string             `json:"feature_name"`
+	TotalTasks  int                `json:"total_tasks"`
+	ByStatus    map[TaskStatus]int `json:"by_status"`
+}
+
+// DailyThroughput is the number of tasks completed on Date (YYYY-MM-DD).
+type DailyThroughput struct {
+	Date      string `json:"date"`
+	Completed int    `json:"completed"`
+}
+
+// DurationReport sums up how long completed tasks took over the last Days
+// days, to calibrate priorities and estimates against.
+type DurationReport struct {
+	Days int `json:"days"`
+	// Tasks lists the tasks completed in the period, longest first.
+	Tasks    []*TaskDuration    `json:"tasks"`
+	Features []*FeatureDuration `json:"features"`
+	// RunTrend has one entry per day of the period, oldest first, averaging
+	// the agent runs started that day (UTC).
+	RunTrend []*DailyRunDuration `json:"run_trend"`
+}
+
+// TaskDuration is the time a completed task took.
+type TaskDuration struct {
+	TaskID      string    `json:"task_id"`
+	FeatureName string    `json:"feature_name"`
+	TaskName    string    `json:"task_name"`
+	Priority    int       `json:"priority"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	// WallSeconds runs from started_at to completed_at, so it includes
+	// retries and time spent in review.
+	WallSeconds float64 `json:"wall_seconds"`
+	// Runs counts the agent runs on the task and RunSeconds adds up how long
+	// they took.
+	Runs       int     `json:"runs"`
+	RunSeconds float64 `json:"run_seconds"`
+}
+
+// FeatureDuration sums up the durations of a feature's tasks completed in
+// the period.
+type FeatureDuration struct {
+	FeatureName    string  `json:"feature_name"`
+	Completed      int     `json:"completed"`
+	WallSeconds    float64 `json:"wall_seconds"`
+	AvgWallSeconds float64 `json:"avg_wall_seconds"`
+	RunSeconds     float64 `json:"run_seconds"`
+	// ElapsedSeconds runs from the first of the tasks starting to the last
+	// completing, so tasks worked on in parallel count once.
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// DailyRunDuration averages the agent runs started on Date (YYYY-MM-DD).
+type DailyRunDuration struct {
+	Date string `json:"date"`
+	Runs int    `json:"runs"`
+	// AvgSeconds is nil when no run started that day.
+	AvgSeconds *float64 `json:"avg_seconds"`
+}
+
+// BurndownReport compares estimated with actual task durations and tracks
+// the estimated work left, per feature and over the last Days days.
+type BurndownReport struct {
+	Days     int                `json:"days"`
+	Total    *FeatureBurndown   `json:"total"`
+	Features []*FeatureBurndown `json:"features"`
+	// Remaining has one entry per day of the period, oldest first.
+	Remaining []*DailyRemaining `json:"remaining"`
+}
+
+// FeatureBurndown sums up the estimates of a feature's tasks, leaving
+// cancelled tasks out.
+type FeatureBurndown struct {
+	FeatureName string `json:"feature_name,omitempty"`
+	Tasks       int    `json:"tasks"`
+	Completed   int    `json:"completed"`
+	// EstimateMinutes adds up the estimates of all the tasks.
+	EstimateMinutes int `json:"estimate_minutes"`
+	// CompletedEstimateMinutes and ActualMinutes compare, over the completed
+	// tasks that have an estimate and a start time, what was estimated with
+	// the wall time from start to completion.
+	CompletedEstimateMinutes int     `json:"completed_estimate_minutes"`
+	ActualMinutes            float64 `json:"actual_minutes"`
+	// Ratio is ActualMinutes / CompletedEstimateMinutes: above 1 the tasks
+	// took longer than estimated. Nil when no task could be compared.
+	Ratio *float64 `json:"ratio"`
+	// RemainingMinutes adds up the estimates of the tasks not completed yet,
+	// and Unestimated counts those of them without an estimate.
+	RemainingMinutes int `json:"remaining_minutes"`
+	Unestimated      int `json:"unestimated"`
+}
+
+// DailyRemaining is the estimated work left at the end of Date (YYYY-MM-DD,
+// UTC): the estimates of the tasks created by then and not yet completed.
+type DailyRemaining struct {
+	Date             string `json:"date"`
+	RemainingMinutes int    `json:"remaining_minutes"`
+}
+
+// Changelog lists the work completed since Since, by feature, for release
+// notes.
+type Changelog struct {
+	// Since is zero when the changelog covers all completed work.
+	Since    time.Time           `json:"since"`
+	Features []*ChangelogFeature `json:"features"`
+}
+
+// ChangelogFeature is the work completed on one feature, oldest first.
+type ChangelogFeature struct {
+	FeatureName string            `json:"feature_name"`
+	Entries     []*ChangelogEntry `json:"entries"`
+}
+
+// ChangelogEntry is one completed task. Archived tasks are included.
+type ChangelogEntry struct {
+	TaskID      string    `json:"task_id"`
+	TaskName    string    `json:"task_name"`
+	Summary     string    `json:"summary"`
+	CompletedAt time.Time `json:"completed_at"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type TaskStatus string
 
@@ -9,8 +12,36 @@ const (
 	TaskStatusInProgress TaskStatus = "in_progress"
 	TaskStatusCompleted  TaskStatus = "completed"
 	TaskStatusBlocked    TaskStatus = "blocked"
+	// TaskStatusInReview marks work an agent has finished that awaits human
+	// approval. It does not satisfy dependencies until approved.
+	TaskStatusInReview TaskStatus = "in_review"
+	// TaskStatusCancelled is terminal and never satisfies dependencies.
+	TaskStatusCancelled TaskStatus = "cancelled"
+)
+
+// SubtaskOrder decides whether a parent task or its subtasks run first.
+type SubtaskOrder string
+
+const (
+	// SubtaskOrderChildrenFirst holds the parent back until every subtask is
+	// completed or cancelled. It is the default.
+	SubtaskOrderChildrenFirst SubtaskOrder = "children_first"
+	// SubtaskOrderParentFirst holds the subtasks back until the parent is
+	// completed.
+	SubtaskOrderParentFirst SubtaskOrder = "parent_first"
 )
 
+// ParseSubtaskOrder checks s names a SubtaskOrder. Empty means the default.
+func ParseSubtaskOrder(s string) (SubtaskOrder, error) {
+	switch o := SubtaskOrder(s); o {
+	case "":
+		return SubtaskOrderChildrenFirst, nil
+	case SubtaskOrderChildrenFirst, SubtaskOrderParentFirst:
+		return o, nil
+	}
+	return "", fmt.Errorf("invalid subtask order %q: expected %s or %s", s, SubtaskOrderChildrenFirst, SubtaskOrderParentFirst)
+}
+
 type Task struct {
 	ID                string     `json:"id"`
 	FeatureID         string     `json:"feature_id"`
@@ -25,7 +56,50 @@ type Task struct {
 	UpdatedAt         time.Time  `json:"updated_at"`
 	StartedAt         *time.Time `json:"started_at"`
 	CompletedAt       *time.Time `json:"completed_at"`
+	// NotBefore keeps the task from being claimed until this time.
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	// DueAt is when the task should be done by.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// ParentTaskID makes the task a subtask of another task.
+	ParentTaskID *string `json:"parent_task_id,omitempty"`
+	// SubtaskOrder applies to the task's own subtasks.
+	SubtaskOrder SubtaskOrder `json:"subtask_order,omitempty"`
+	// Position orders tasks of equal priority, lowest first.
+	Position int `json:"position"`
+	// BlockedReason says why a blocked task is stuck.
+	BlockedReason *string `json:"blocked_reason,omitempty"`
+	// EstimateMinutes is how long the task is expected to take; nil when
+	// nobody estimated it.
+	EstimateMinutes *int `json:"estimate_minutes,omitempty"`
+	// Version goes up by one with every change, for optimistic updates.
+	Version int `json:"version"`
 
 	// FeatureName is a helper field for joined queries
 	FeatureName string `json:"feature_name,omitempty"`
+	// ParentTaskName names the parent of a staged task, in the same feature,
+	// until it is resolved to ParentTaskID on commit.
+	ParentTaskName string `json:"parent_task_name,omitempty"`
+}
+
+// Overdue reports whether the task is past its due time at now without being
+// completed or cancelled.
+func (t *Task) Overdue(now time.Time) bool {
+	if t.DueAt == nil || t.Status == TaskStatusCompleted || t.Status == TaskStatusCancelled {
+		return false
+	}
+	return t.DueAt.Before(now)
+}
+
+// ParseTaskTime parses a not_before or due_at value: an RFC 3339 timestamp,
+// or a YYYY-MM-DD date meaning midnight local time. The result is in UTC,
+// like every time ponder stores.
+func ParseTaskTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC 3339 or YYYY-MM-DD", s)
+	}
+	return t.UTC(), nil
 }
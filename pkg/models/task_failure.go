@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TaskFailure counts the failed runs of a task the orchestrator will retry.
+type TaskFailure struct {
+	TaskID    string    `json:"task_id"`
+	FailCount int       `json:"fail_count"`
+	FailedAt  time.Time `json:"failed_at"`
+	// ModelFailures counts failures since the task last moved along the
+	// model fallback chain; FallbackModel is the model it moved to.
+	ModelFailures int    `json:"model_failures"`
+	FallbackModel string `json:"fallback_model,omitempty"`
+}
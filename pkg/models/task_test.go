@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTaskTime(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want time.Time
+	}{
+		{"utc", "2026-01-02T15:04:05Z", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"offset", "2026-01-02T16:04:05+01:00", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"date", "2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTaskTime(tt.in)
+			if err != nil {
+				t.Fatalf("ParseTaskTime(%q) failed: %v", tt.in, err)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("expected a UTC time, got %v", got.Location())
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := ParseTaskTime("tomorrow"); err == nil {
+		t.Error("expected an invalid time to fail")
+	}
+}
+
+func TestTaskTimestampsJSON(t *testing.T) {
+	due, err := ParseTaskTime("2026-01-02T16:04:05+01:00")
+	if err != nil {
+		t.Fatalf("ParseTaskTime failed: %v", err)
+	}
+	task := Task{
+		ID:        "t1",
+		CreatedAt: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		DueAt:     &due,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{`"created_at":"2026-01-02T15:04:05Z"`, `"due_at":"2026-01-02T15:04:05Z"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in %s", want, data)
+		}
+	}
+
+	var back Task
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !back.CreatedAt.Equal(task.CreatedAt) || back.DueAt == nil || !back.DueAt.Equal(due) {
+		t.Errorf("expected timestamps to round-trip, got %v and %v", back.CreatedAt, back.DueAt)
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TaskTemplate describes a task that can be created again and again. Its
+// TaskName and Specification may contain {{variable}} placeholders that are
+// filled in each time; {{date}} is always available. A template with a
+// Recurrence has a task created from it whenever NextRunAt passes.
+type TaskTemplate struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	FeatureID     string     `json:"feature_id"`
+	FeatureName   string     `json:"feature_name"`
+	TaskName      string     `json:"task_name"`
+	Description   string     `json:"description"`
+	Specification string     `json:"specification"`
+	Priority      int        `json:"priority"`
+	TestsRequired bool       `json:"tests_required"`
+	Recurrence    string     `json:"recurrence,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// TaskUsage is the token usage and cost of a single agent run on a task.
+type TaskUsage struct {
+	ID        int64     `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Model     string    `json:"model"`
+	TokensIn  int64     `json:"tokens_in"`
+	TokensOut int64     `json:"tokens_out"`
+	CostUSD   float64   `json:"cost_usd"`
+	Estimated bool      `json:"estimated"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UsageTotals sums the usage of one or more runs.
+type UsageTotals struct {
+	Runs      int     `json:"runs"`
+	TokensIn  int64   `json:"tokens_in"`
+	TokensOut int64   `json:"tokens_out"`
+	CostUSD   float64 `json:"cost_usd"`
+}
+
+// FeatureUsage is the usage of all runs on a feature's tasks.
+type FeatureUsage struct {
+	FeatureName string `json:"feature_name"`
+	UsageTotals
+}
+
+// TaskUsageTotals is the usage of all runs on a task.
+type TaskUsageTotals struct {
+	TaskID      string `json:"task_id"`
+	TaskName    string `json:"task_name"`
+	FeatureName string `json:"feature_name"`
+	UsageTotals
+}
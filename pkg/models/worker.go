@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ActiveWorker is a worker of a running orchestrator and the task it is
+// working on.
+type ActiveWorker struct {
+	ID          int       `json:"id"`
+	TaskID      string    `json:"task_id"`
+	TaskName    string    `json:"task_name"`
+	FeatureName string    `json:"feature_name,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	// LastOutputAt is when the agent last produced output; nil before it
+	// starts.
+	LastOutputAt *time.Time `json:"last_output_at,omitempty"`
+	// Stalled is set once the agent has gone without output for the stall
+	// window.
+	Stalled bool `json:"stalled"`
+}
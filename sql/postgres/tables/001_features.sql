@@ -0,0 +1,54 @@
+-- Postgres version of sql/tables/001_features.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS features (
+  id VARCHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Added after the first release; upgradeFeatureColumns does this for SQLite.
+ALTER TABLE features ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+  IF NEW.updated_at IS NOT DISTINCT FROM OLD.updated_at THEN
+    NEW.updated_at := CURRENT_TIMESTAMP;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_features_updated_at ON features;
+CREATE TRIGGER set_features_updated_at
+BEFORE UPDATE ON features
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Bump the version of updates that don't set it themselves.
+CREATE OR REPLACE FUNCTION set_version() RETURNS trigger AS $$
+BEGIN
+  IF NEW.version = OLD.version THEN
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_features_version ON features;
+CREATE TRIGGER set_features_version
+BEFORE UPDATE ON features
+FOR EACH ROW EXECUTE FUNCTION set_version();
+
+-- Seed the default feature (required for basic operation)
+INSERT INTO features (id, name, description, specification) VALUES
+(
+  '00000000-0000-0000-0000-000000000000',
+  'misc',
+  'Default feature for uncategorized tasks',
+  'Use this feature in cases where a task is minimal and does not require a feature, such as minor hotfixes, tweaks etc.'
+)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,91 @@
+-- Postgres version of sql/tables/002_tasks.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS tasks (
+  id VARCHAR(36) PRIMARY KEY,
+  feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0 CHECK(priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+  status TEXT DEFAULT 'pending' CHECK(
+    status IN (
+      'pending',
+      'in_progress',
+      'completed',
+      'blocked',
+      'in_review',
+      'cancelled'
+    )
+  ),
+  completion_summary TEXT,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ,
+  -- Scheduling window: the task can't be claimed before not_before, and is
+  -- overdue once due_at passes without it being completed.
+  not_before TIMESTAMPTZ,
+  due_at TIMESTAMPTZ,
+  -- Subtasks point at their parent. subtask_order on the parent decides which
+  -- side waits for the other.
+  parent_task_id VARCHAR(36) REFERENCES tasks(id) ON DELETE SET NULL,
+  subtask_order TEXT NOT NULL DEFAULT 'children_first' CHECK (subtask_order IN ('children_first', 'parent_first')),
+  -- Orders tasks of equal priority, lowest first, as set by reorder_tasks.
+  position INTEGER NOT NULL DEFAULT 0,
+  -- Why the task is blocked, as reported by whoever blocked it. Cleared when
+  -- the task leaves the blocked status.
+  blocked_reason TEXT,
+  -- How many minutes the task is expected to take; NULL when not estimated.
+  estimate_minutes INTEGER CHECK (estimate_minutes IS NULL OR estimate_minutes > 0),
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
+
+  CHECK (status != 'completed' OR completion_summary IS NOT NULL),
+  UNIQUE(name, feature_id)
+);
+
+-- Added after the first release; upgradeTaskColumns does this for SQLite.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS not_before TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_task_id VARCHAR(36) REFERENCES tasks(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS subtask_order TEXT NOT NULL DEFAULT 'children_first'
+  CHECK (subtask_order IN ('children_first', 'parent_first'));
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS blocked_reason TEXT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER
+  CHECK (estimate_minutes IS NULL OR estimate_minutes > 0);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
+
+-- Set started_at and completed_at when the status changes to in_progress or
+-- completed, as the SQLite triggers do.
+CREATE OR REPLACE FUNCTION set_task_status_timestamps() RETURNS trigger AS $$
+BEGIN
+  IF NEW.status = 'in_progress' AND OLD.status != 'in_progress' THEN
+    NEW.started_at := CURRENT_TIMESTAMP;
+  END IF;
+  IF NEW.status = 'completed' AND OLD.status != 'completed' THEN
+    NEW.completed_at := CURRENT_TIMESTAMP;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_task_status_timestamps ON tasks;
+CREATE TRIGGER set_task_status_timestamps
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION set_task_status_timestamps();
+
+DROP TRIGGER IF EXISTS set_tasks_updated_at ON tasks;
+CREATE TRIGGER set_tasks_updated_at
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+DROP TRIGGER IF EXISTS set_tasks_version ON tasks;
+CREATE TRIGGER set_tasks_version
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION set_version();
@@ -0,0 +1,9 @@
+-- Postgres version of sql/tables/003_dependencies.sql. Keep the two in step.
+-- Circular dependencies are rejected in Go before inserting, so there is no
+-- equivalent of the SQLite trigger.
+CREATE TABLE IF NOT EXISTS dependencies (
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  depends_on_task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  PRIMARY KEY (task_id, depends_on_task_id),
+  CHECK (task_id != depends_on_task_id) -- Prevent self-dependencies
+);
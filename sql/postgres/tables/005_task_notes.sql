@@ -0,0 +1,14 @@
+-- Postgres version of sql/tables/005_task_notes.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_notes (
+  id VARCHAR(36) PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  author TEXT NOT NULL,
+  body TEXT NOT NULL CHECK (length(body) > 0),
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  -- Insertion order, standing in for SQLite's rowid
+  seq BIGSERIAL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_notes_task ON task_notes(task_id, created_at);
@@ -0,0 +1,13 @@
+-- Postgres version of sql/tables/006_task_links.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_links (
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_links_task ON task_links(task_id);
@@ -0,0 +1,16 @@
+-- Postgres version of sql/tables/007_task_usage.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_usage (
+  id BIGSERIAL PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out INTEGER NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  -- true when the cost was computed from configured pricing rather than reported by the agent
+  estimated BOOLEAN NOT NULL DEFAULT FALSE,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_usage_task ON task_usage(task_id);
@@ -0,0 +1,74 @@
+-- Postgres version of sql/tables/008_archive.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS archived_features (
+  id VARCHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  created_at TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ,
+  archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS archived_tasks (
+  id VARCHAR(36) PRIMARY KEY,
+  feature_id VARCHAR(36) NOT NULL,
+  -- Kept so the task can be shown after its feature is archived too
+  feature_name TEXT NOT NULL,
+
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0,
+  tests_required INTEGER NOT NULL DEFAULT 1,
+  status TEXT NOT NULL,
+  completion_summary TEXT,
+
+  created_at TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ,
+  started_at TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ,
+  archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_tasks_feature ON archived_tasks(feature_name, name);
+
+CREATE TABLE IF NOT EXISTS archived_dependencies (
+  task_id VARCHAR(36) NOT NULL,
+  depends_on_task_id VARCHAR(36) NOT NULL,
+  PRIMARY KEY (task_id, depends_on_task_id)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_notes (
+  id VARCHAR(36) PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL,
+  author TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_notes_task ON archived_task_notes(task_id, created_at);
+
+CREATE TABLE IF NOT EXISTS archived_task_links (
+  task_id VARCHAR(36) NOT NULL,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_usage (
+  id BIGSERIAL PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL,
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0,
+  tokens_out INTEGER NOT NULL DEFAULT 0,
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+  estimated BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_usage_task ON archived_task_usage(task_id);
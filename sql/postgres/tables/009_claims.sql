@@ -0,0 +1,11 @@
+-- Postgres version of sql/tables/009_claims.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS claims (
+  task_id VARCHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  hostname TEXT NOT NULL,
+  pid INTEGER NOT NULL,
+  worker_id INTEGER NOT NULL,
+
+  claimed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  lease_expires_at TIMESTAMPTZ NOT NULL
+);
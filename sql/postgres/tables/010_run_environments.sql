@@ -0,0 +1,12 @@
+-- Postgres version of sql/tables/010_run_environments.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS run_environments (
+  task_id VARCHAR(36) UNIQUE REFERENCES tasks(id) ON DELETE CASCADE,
+  feature_id VARCHAR(36) UNIQUE REFERENCES features(id) ON DELETE CASCADE,
+
+  working_dir TEXT NOT NULL DEFAULT '',
+  env TEXT NOT NULL DEFAULT '{}',
+
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+  CHECK ((task_id IS NULL) <> (feature_id IS NULL))
+);
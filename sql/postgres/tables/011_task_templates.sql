@@ -0,0 +1,18 @@
+-- Postgres version of sql/tables/011_task_templates.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_templates (
+  id VARCHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  task_name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  specification TEXT NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 5 CHECK (priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+
+  recurrence TEXT NOT NULL DEFAULT '',
+  next_run_at TIMESTAMPTZ,
+
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
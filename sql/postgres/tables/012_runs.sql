@@ -0,0 +1,25 @@
+-- Postgres version of sql/tables/012_runs.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS runs (
+  id BIGSERIAL PRIMARY KEY,
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  prompt TEXT NOT NULL DEFAULT '',
+  -- stdout and stderr, interleaved as they were written
+  output TEXT NOT NULL DEFAULT '',
+  -- -1 when the agent did not exit by itself, e.g. it was killed or never started
+  exit_code INTEGER NOT NULL DEFAULT 0,
+  -- why the run failed, including a failed verification; empty on success
+  error TEXT NOT NULL DEFAULT '',
+  -- agent_missing, exit, cancelled, timeout, oom, verification or other;
+  -- empty on success
+  failure_class TEXT NOT NULL DEFAULT '',
+  duration_ms BIGINT NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMPTZ NOT NULL
+);
+
+-- Added after the first release; upgradeRunColumns does this for SQLite.
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS failure_class TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_runs_task ON runs(task_id, started_at);
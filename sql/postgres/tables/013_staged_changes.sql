@@ -0,0 +1,6 @@
+-- Postgres version of sql/tables/013_staged_changes.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS staged_changes (
+  session_id TEXT PRIMARY KEY,
+  items TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,9 @@
+-- Postgres version of sql/tables/014_task_labels.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS task_labels (
+  task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  label VARCHAR(55) NOT NULL,
+
+  PRIMARY KEY (task_id, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label ON task_labels(label);
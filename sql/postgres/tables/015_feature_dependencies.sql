@@ -0,0 +1,9 @@
+-- Postgres version of sql/tables/015_feature_dependencies.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS feature_dependencies (
+  feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  depends_on_feature_id VARCHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  PRIMARY KEY (feature_id, depends_on_feature_id),
+  CHECK (feature_id != depends_on_feature_id) -- Prevent self-dependencies
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_dependencies_depends_on ON feature_dependencies(depends_on_feature_id);
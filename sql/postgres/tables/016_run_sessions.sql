@@ -0,0 +1,19 @@
+-- Postgres version of sql/tables/016_run_sessions.sql. Keep the two in step.
+CREATE TABLE IF NOT EXISTS run_sessions (
+  id BIGSERIAL PRIMARY KEY,
+
+  -- JSON object of model names to the number of task runs they did
+  models TEXT NOT NULL DEFAULT '{}',
+  tasks_attempted INTEGER NOT NULL DEFAULT 0 CHECK (tasks_attempted >= 0),
+  tasks_completed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_completed >= 0),
+  tasks_failed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_failed >= 0),
+  tokens_in BIGINT NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out BIGINT NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  duration_ms BIGINT NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMPTZ NOT NULL,
+  ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_sessions_started ON run_sessions(started_at);
@@ -0,0 +1,43 @@
+-- Postgres version of sql/views/001_available_tasks.sql. Keep the two in step.
+-- It applies the strict availability policy; ponder's own queries follow the
+-- configured one (see AvailabilityPolicy in internal/db).
+DROP VIEW IF EXISTS v_available_tasks CASCADE;
+
+CREATE VIEW v_available_tasks AS
+SELECT t.*, f.name AS feature_name
+FROM tasks t
+LEFT JOIN features f ON t.feature_id = f.id
+WHERE t.status = 'pending'
+  AND (t.not_before IS NULL OR t.not_before <= CURRENT_TIMESTAMP)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM dependencies d
+    JOIN tasks dep_task ON d.depends_on_task_id = dep_task.id
+    WHERE d.task_id = t.id
+      AND dep_task.status != 'completed'
+  )
+  AND NOT EXISTS (
+    -- A children_first parent waits for its subtasks to be completed or cancelled
+    SELECT 1
+    FROM tasks sub
+    WHERE sub.parent_task_id = t.id
+      AND t.subtask_order = 'children_first'
+      AND sub.status NOT IN ('completed', 'cancelled')
+  )
+  AND NOT EXISTS (
+    -- A subtask of a parent_first parent waits for the parent to be completed
+    SELECT 1
+    FROM tasks parent
+    WHERE parent.id = t.parent_task_id
+      AND parent.subtask_order = 'parent_first'
+      AND parent.status != 'completed'
+  )
+  AND NOT EXISTS (
+    -- A feature waits for every task of the features it depends on
+    SELECT 1
+    FROM feature_dependencies fd
+    JOIN tasks gate ON gate.feature_id = fd.depends_on_feature_id
+    WHERE fd.feature_id = t.feature_id
+      AND gate.status NOT IN ('completed', 'cancelled')
+  )
+ORDER BY t.priority DESC, t.position ASC, t.created_at ASC;
@@ -0,0 +1,48 @@
+-- Postgres version of sql/views/002_dependency_tree.sql. Keep the two in step.
+DROP VIEW IF EXISTS v_dependency_tree CASCADE;
+
+CREATE VIEW v_dependency_tree AS
+WITH RECURSIVE task_tree AS (
+    SELECT
+        t.id,
+        t.name,
+        t.description,
+        t.status,
+        t.priority,
+        t.completed_at,
+        0 AS level,
+        CAST(t.name AS TEXT) AS path,
+        CAST(NULL AS TEXT) AS parent_name
+    FROM tasks t
+    WHERE NOT EXISTS (
+        SELECT 1 FROM dependencies d
+        WHERE d.task_id = t.id
+    )
+
+    UNION ALL
+
+    SELECT
+        t.id,
+        t.name,
+        t.description,
+        t.status,
+        t.priority,
+        t.completed_at,
+        tt.level + 1,
+        tt.path || '->' || t.name,
+        tt.name AS parent_name
+    FROM tasks t
+    JOIN dependencies d ON t.id = d.task_id
+    JOIN task_tree tt ON d.depends_on_task_id = tt.id
+)
+SELECT
+    name,
+    description,
+    status,
+    priority,
+    completed_at,
+    level,
+    path,
+    parent_name
+FROM task_tree
+ORDER BY path;
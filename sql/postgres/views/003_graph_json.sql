@@ -0,0 +1,59 @@
+-- Postgres version of sql/views/003_graph_json.sql. Keep the two in step.
+-- Timestamps are formatted the way SQLite stores them so both backends serve
+-- the same graph.
+DROP VIEW IF EXISTS v_graph_json CASCADE;
+DROP VIEW IF EXISTS v_graph_nodes CASCADE;
+
+-- One graph node per task, so changed tasks can be sent on their own
+CREATE VIEW v_graph_nodes AS
+SELECT t.id,
+    json_build_object(
+        'id', t.id,
+        'name', t.name,
+        'feature_name', f.name,
+        'description', t.description,
+        'status', t.status,
+        'priority', t.priority,
+        'parent_task_id', t.parent_task_id,
+        'subtask_order', t.subtask_order,
+        'completion_summary', t.completion_summary,
+        'blocked_reason', t.blocked_reason,
+        'estimate_minutes', t.estimate_minutes,
+        'completed_at', to_char(t.completed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'started_at', to_char(t.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+        'completion_seconds', CASE
+            WHEN t.started_at IS NULL OR t.completed_at IS NULL THEN NULL
+            ELSE CAST(ROUND(EXTRACT(EPOCH FROM (t.completed_at - t.started_at))) AS INTEGER)
+        END,
+        'is_available', CASE WHEN t.id IN (SELECT id FROM v_available_tasks) THEN 1 ELSE 0 END
+    ) AS node_json
+FROM tasks t
+JOIN features f ON t.feature_id = f.id;
+
+CREATE VIEW v_graph_json AS
+SELECT json_build_object(
+    'nodes', COALESCE((
+        SELECT json_agg(n.node_json)
+        FROM v_graph_nodes n
+    ), '[]'::json),
+    'edges', COALESCE((
+        SELECT json_agg(
+            json_build_object(
+                'from', d.task_id,
+                'to', d.depends_on_task_id
+            )
+        )
+        FROM dependencies d
+    ), '[]'::json),
+    'feature_edges', COALESCE((
+        SELECT json_agg(
+            json_build_object(
+                'from', f.name,
+                'to', df.name
+            )
+        )
+        FROM feature_dependencies fd
+        JOIN features f ON fd.feature_id = f.id
+        JOIN features df ON fd.depends_on_feature_id = df.id
+    ), '[]'::json)
+)::text AS graph_json;
@@ -0,0 +1,189 @@
+-- Postgres version of sql/views/004_snapshot_jsonl.sql. Keep the two in step.
+-- Sort keys use the "C" collation so lines come out in the same byte order as
+-- with SQLite, and ExportSnapshot compacts the JSON to match SQLite's output.
+DROP VIEW IF EXISTS v_snapshot_jsonl_lines CASCADE;
+
+CREATE VIEW v_snapshot_jsonl_lines AS
+SELECT
+  0 AS record_order,
+  '' COLLATE "C" AS sort_name,
+  '' COLLATE "C" AS sort_secondary,
+  json_build_object(
+    'record_type', 'meta',
+    'schema_version', '2',
+    'generated_at', to_char(CURRENT_TIMESTAMP AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'source', 'postgres'
+  )::text AS json_line
+
+UNION ALL
+
+SELECT
+  1 AS record_order,
+  f.name AS sort_name,
+  '' AS sort_secondary,
+  json_build_object(
+    'record_type', 'feature',
+    'id', f.id,
+    'name', f.name,
+    'description', f.description,
+    'specification', f.specification,
+    'created_at', to_char(f.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(f.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM features f
+
+UNION ALL
+
+SELECT
+  2 AS record_order,
+  t.name AS sort_name,
+  '' AS sort_secondary,
+  json_build_object(
+    'record_type', 'task',
+    'id', t.id,
+    'name', t.name,
+    'description', t.description,
+    'specification', t.specification,
+    'feature_name', f.name,
+    'tests_required', t.tests_required = 1,
+    'priority', t.priority,
+    'position', t.position,
+    'status', t.status,
+    'completion_summary', t.completion_summary,
+    'blocked_reason', t.blocked_reason,
+    'estimate_minutes', t.estimate_minutes,
+    'labels', (SELECT json_agg(l.label ORDER BY l.label) FROM task_labels l WHERE l.task_id = t.id),
+    'created_at', to_char(t.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(t.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'started_at', to_char(t.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'completed_at', to_char(t.completed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'not_before', to_char(t.not_before AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'due_at', to_char(t.due_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'parent_task_id', t.parent_task_id,
+    'parent_task_name', p.name,
+    'parent_task_feature_name', pf.name,
+    'subtask_order', t.subtask_order
+  )::text AS json_line
+FROM tasks t
+LEFT JOIN features f ON t.feature_id = f.id
+LEFT JOIN tasks p ON t.parent_task_id = p.id
+LEFT JOIN features pf ON p.feature_id = pf.id
+
+UNION ALL
+
+SELECT
+  3 AS record_order,
+  t.name AS sort_name,
+  dep.name AS sort_secondary,
+  json_build_object(
+    'record_type', 'dependency',
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'depends_on_task_id', dep.id,
+    'depends_on_task_name', dep.name,
+    'depends_on_task_feature_name', df.name
+  )::text AS json_line
+FROM dependencies d
+JOIN tasks t ON d.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+JOIN tasks dep ON d.depends_on_task_id = dep.id
+JOIN features df ON dep.feature_id = df.id
+
+UNION ALL
+
+SELECT
+  4 AS record_order,
+  tf.name || '/' || t.name AS sort_name,
+  to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') || lpad(n.seq::text, 12, '0') AS sort_secondary,
+  json_build_object(
+    'record_type', 'note',
+    'id', n.id,
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'author', n.author,
+    'body', n.body,
+    'created_at', to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM task_notes n
+JOIN tasks t ON n.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  5 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_build_object(
+    'record_type', 'link',
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', to_char(l.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM task_links l
+JOIN tasks t ON l.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  11 AS record_order,
+  f.name AS sort_name,
+  COALESCE(t.name, '') AS sort_secondary,
+  json_build_object(
+    'record_type', 'environment',
+    'feature_name', f.name,
+    'task_id', e.task_id,
+    'task_name', t.name,
+    'working_dir', e.working_dir,
+    'env', e.env::json
+  )::text AS json_line
+FROM run_environments e
+LEFT JOIN tasks t ON e.task_id = t.id
+JOIN features f ON f.id = COALESCE(e.feature_id, t.feature_id)
+
+UNION ALL
+
+SELECT
+  12 AS record_order,
+  tt.name AS sort_name,
+  '' AS sort_secondary,
+  json_build_object(
+    'record_type', 'template',
+    'id', tt.id,
+    'name', tt.name,
+    'feature_name', f.name,
+    'task_name', tt.task_name,
+    'description', tt.description,
+    'specification', tt.specification,
+    'priority', tt.priority,
+    'tests_required', tt.tests_required = 1,
+    'recurrence', tt.recurrence,
+    'next_run_at', to_char(tt.next_run_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'created_at', to_char(tt.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM task_templates tt
+JOIN features f ON tt.feature_id = f.id
+
+UNION ALL
+
+SELECT
+  13 AS record_order,
+  f.name AS sort_name,
+  df.name AS sort_secondary,
+  json_build_object(
+    'record_type', 'feature_dependency',
+    'feature_id', f.id,
+    'feature_name', f.name,
+    'depends_on_feature_id', df.id,
+    'depends_on_feature_name', df.name
+  )::text AS json_line
+FROM feature_dependencies fd
+JOIN features f ON fd.feature_id = f.id
+JOIN features df ON fd.depends_on_feature_id = df.id;
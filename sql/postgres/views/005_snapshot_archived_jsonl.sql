@@ -0,0 +1,101 @@
+-- Postgres version of sql/views/005_snapshot_archived_jsonl.sql. Keep the two
+-- in step.
+DROP VIEW IF EXISTS v_snapshot_archived_jsonl_lines CASCADE;
+
+CREATE VIEW v_snapshot_archived_jsonl_lines AS
+SELECT
+  6 AS record_order,
+  (t.feature_name || '/' || t.name) COLLATE "C" AS sort_name,
+  t.id::text COLLATE "C" AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_task',
+    'id', t.id,
+    'name', t.name,
+    'description', t.description,
+    'specification', t.specification,
+    'feature_id', t.feature_id,
+    'feature_name', t.feature_name,
+    'tests_required', t.tests_required = 1,
+    'priority', t.priority,
+    'status', t.status,
+    'completion_summary', t.completion_summary,
+    'created_at', to_char(t.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(t.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'started_at', to_char(t.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'completed_at', to_char(t.completed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'archived_at', to_char(t.archived_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_tasks t
+
+UNION ALL
+
+SELECT
+  7 AS record_order,
+  d.task_id AS sort_name,
+  d.depends_on_task_id AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_dependency',
+    'task_id', d.task_id,
+    'task_name', COALESCE(lt.name, ta.name),
+    'task_feature_name', COALESCE(lf.name, ta.feature_name),
+    'depends_on_task_id', d.depends_on_task_id,
+    'depends_on_task_name', COALESCE(ldt.name, adt.name),
+    'depends_on_task_feature_name', COALESCE(ldf.name, adt.feature_name)
+  )::text AS json_line
+FROM archived_dependencies d
+LEFT JOIN tasks lt ON d.task_id = lt.id
+LEFT JOIN features lf ON lt.feature_id = lf.id
+LEFT JOIN archived_tasks ta ON d.task_id = ta.id
+LEFT JOIN tasks ldt ON d.depends_on_task_id = ldt.id
+LEFT JOIN features ldf ON ldt.feature_id = ldf.id
+LEFT JOIN archived_tasks adt ON d.depends_on_task_id = adt.id
+
+UNION ALL
+
+SELECT
+  8 AS record_order,
+  n.task_id AS sort_name,
+  to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') || n.id AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_note',
+    'id', n.id,
+    'task_id', n.task_id,
+    'author', n.author,
+    'body', n.body,
+    'created_at', to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_task_notes n
+
+UNION ALL
+
+SELECT
+  9 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_link',
+    'task_id', l.task_id,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', to_char(l.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_task_links l
+
+UNION ALL
+
+SELECT
+  10 AS record_order,
+  f.name AS sort_name,
+  f.id AS sort_secondary,
+  json_build_object(
+    'record_type', 'archived_feature',
+    'id', f.id,
+    'name', f.name,
+    'description', f.description,
+    'specification', f.specification,
+    'created_at', to_char(f.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'updated_at', to_char(f.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    'archived_at', to_char(f.archived_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  )::text AS json_line
+FROM archived_features f;
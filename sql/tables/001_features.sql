@@ -4,6 +4,8 @@ CREATE TABLE IF NOT EXISTS features (
   name VARCHAR(55) NOT NULL UNIQUE,
   description TEXT NOT NULL,
   specification TEXT NOT NULL,
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
 
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -18,6 +20,16 @@ BEGIN
   WHERE id = NEW.id;
 END;
 
+-- Bump the version of updates that don't set it themselves.
+CREATE TRIGGER IF NOT EXISTS set_features_version
+AFTER UPDATE OF name, description, specification ON features
+WHEN NEW.version = OLD.version
+BEGIN
+  UPDATE features
+  SET version = OLD.version + 1
+  WHERE id = NEW.id;
+END;
+
 -- Seed the default feature (required for basic operation)
 -- Note: id must be provided by the application (Go will generate UUIDs)
 INSERT OR IGNORE INTO features (id, name, description, specification) VALUES
@@ -14,7 +14,9 @@ CREATE TABLE IF NOT EXISTS tasks (
       'pending',
       'in_progress',
       'completed',
-      'blocked'
+      'blocked',
+      'in_review',
+      'cancelled'
     )
   ),
   completion_summary TEXT,
@@ -23,11 +25,30 @@ CREATE TABLE IF NOT EXISTS tasks (
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   started_at TIMESTAMP,
   completed_at TIMESTAMP,
+  -- Scheduling window: the task can't be claimed before not_before, and is
+  -- overdue once due_at passes without it being completed.
+  not_before TIMESTAMP,
+  due_at TIMESTAMP,
+  -- Subtasks point at their parent. subtask_order on the parent decides which
+  -- side waits for the other.
+  parent_task_id CHAR(36) REFERENCES tasks(id) ON DELETE SET NULL,
+  subtask_order TEXT NOT NULL DEFAULT 'children_first' CHECK (subtask_order IN ('children_first', 'parent_first')),
+  -- Orders tasks of equal priority, lowest first, as set by reorder_tasks.
+  position INTEGER NOT NULL DEFAULT 0,
+  -- Why the task is blocked, as reported by whoever blocked it. Cleared when
+  -- the task leaves the blocked status.
+  blocked_reason TEXT,
+  -- How many minutes the task is expected to take; NULL when not estimated.
+  estimate_minutes INTEGER CHECK (estimate_minutes IS NULL OR estimate_minutes > 0),
+  -- Goes up with every change, so writers can detect concurrent edits.
+  version INTEGER NOT NULL DEFAULT 1,
 
   CHECK (status != 'completed' OR completion_summary IS NOT NULL),
   UNIQUE(name, feature_id)
 );
 
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
+
 -- Triggers to automatically set timestamps based on status changes
 
 -- Trigger to set started_at when status becomes 'in_progress'
@@ -58,3 +79,16 @@ BEGIN
   SET updated_at = CURRENT_TIMESTAMP
   WHERE id = NEW.id;
 END;
+
+-- Bump the version of updates that don't set it themselves. Only changes to
+-- these columns count, so the timestamp triggers above don't bump it again.
+CREATE TRIGGER IF NOT EXISTS set_tasks_version
+AFTER UPDATE OF feature_id, name, description, specification, priority, tests_required, status,
+  completion_summary, not_before, due_at, parent_task_id, subtask_order, position, blocked_reason,
+  estimate_minutes ON tasks
+WHEN NEW.version = OLD.version
+BEGIN
+  UPDATE tasks
+  SET version = OLD.version + 1
+  WHERE id = NEW.id;
+END;
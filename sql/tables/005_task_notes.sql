@@ -0,0 +1,12 @@
+-- Free-form notes agents leave on a task for whoever picks it up next
+CREATE TABLE IF NOT EXISTS task_notes (
+  id CHAR(36) PRIMARY KEY,
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  author TEXT NOT NULL,
+  body TEXT NOT NULL CHECK (length(body) > 0),
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_notes_task ON task_notes(task_id, created_at);
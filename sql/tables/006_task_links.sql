@@ -0,0 +1,13 @@
+-- Links between tasks and items in external trackers (e.g. GitHub issues)
+CREATE TABLE IF NOT EXISTS task_links (
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_links_task ON task_links(task_id);
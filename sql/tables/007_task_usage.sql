@@ -0,0 +1,16 @@
+-- Token usage and cost of each agent run, so spend can be traced to tasks and features
+CREATE TABLE IF NOT EXISTS task_usage (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out INTEGER NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd REAL NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  -- 1 when the cost was computed from configured pricing rather than reported by the agent
+  estimated BOOLEAN NOT NULL DEFAULT 0,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_usage_task ON task_usage(task_id);
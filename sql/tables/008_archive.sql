@@ -0,0 +1,77 @@
+-- Archive tables hold completed work moved out of the live tables so that
+-- long-lived projects keep their views fast. They mirror the live tables with
+-- an archived_at column and no foreign keys, since the rows they referred to
+-- may be archived or deleted independently.
+CREATE TABLE IF NOT EXISTS archived_features (
+  id CHAR(36) PRIMARY KEY,
+  name VARCHAR(55) NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS archived_tasks (
+  id CHAR(36) PRIMARY KEY,
+  feature_id CHAR(36) NOT NULL,
+  -- Kept so the task can be shown after its feature is archived too
+  feature_name VARCHAR(55) NOT NULL,
+
+  name VARCHAR(55) NOT NULL,
+  description TEXT NOT NULL,
+  specification TEXT NOT NULL,
+
+  priority INTEGER DEFAULT 0,
+  tests_required INTEGER NOT NULL DEFAULT 1,
+  status TEXT NOT NULL,
+  completion_summary TEXT,
+
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  started_at TIMESTAMP,
+  completed_at TIMESTAMP,
+  archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_tasks_feature ON archived_tasks(feature_name, name);
+
+CREATE TABLE IF NOT EXISTS archived_dependencies (
+  task_id CHAR(36) NOT NULL,
+  depends_on_task_id CHAR(36) NOT NULL,
+  PRIMARY KEY (task_id, depends_on_task_id)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_notes (
+  id CHAR(36) PRIMARY KEY,
+  task_id CHAR(36) NOT NULL,
+  author TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_notes_task ON archived_task_notes(task_id, created_at);
+
+CREATE TABLE IF NOT EXISTS archived_task_links (
+  task_id CHAR(36) NOT NULL,
+  provider TEXT NOT NULL,
+  external_ref TEXT NOT NULL,
+  url TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP,
+
+  PRIMARY KEY (provider, external_ref)
+);
+
+CREATE TABLE IF NOT EXISTS archived_task_usage (
+  id INTEGER PRIMARY KEY,
+  task_id CHAR(36) NOT NULL,
+  model TEXT NOT NULL DEFAULT '',
+  tokens_in INTEGER NOT NULL DEFAULT 0,
+  tokens_out INTEGER NOT NULL DEFAULT 0,
+  cost_usd REAL NOT NULL DEFAULT 0,
+  estimated BOOLEAN NOT NULL DEFAULT 0,
+  created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_task_usage_task ON archived_task_usage(task_id);
@@ -0,0 +1,13 @@
+-- Which worker of which ponder process holds each in_progress task it
+-- claimed. The worker renews the lease while it runs; once a lease expires
+-- the task is put back to pending for any worker to claim.
+CREATE TABLE IF NOT EXISTS claims (
+  task_id CHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  hostname TEXT NOT NULL,
+  pid INTEGER NOT NULL,
+  worker_id INTEGER NOT NULL,
+
+  claimed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  lease_expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,16 @@
+-- Where and with which extra environment variables the agent runs, set on a
+-- task or on a whole feature. Exactly one of task_id and feature_id is set;
+-- a task's settings take precedence over its feature's.
+CREATE TABLE IF NOT EXISTS run_environments (
+  task_id CHAR(36) UNIQUE REFERENCES tasks(id) ON DELETE CASCADE,
+  feature_id CHAR(36) UNIQUE REFERENCES features(id) ON DELETE CASCADE,
+
+  -- Relative to the repository root, or the task's worktree.
+  working_dir TEXT NOT NULL DEFAULT '',
+  -- JSON object of variable names to values.
+  env TEXT NOT NULL DEFAULT '{}',
+
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CHECK ((task_id IS NULL) <> (feature_id IS NULL))
+);
@@ -0,0 +1,23 @@
+-- Reusable task definitions. Their task name and specification may contain
+-- {{variable}} placeholders that are filled in when a task is created from
+-- the template. A template with a recurrence also gets a task created for it
+-- whenever next_run_at passes.
+CREATE TABLE IF NOT EXISTS task_templates (
+  id CHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+
+  task_name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  specification TEXT NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 5 CHECK (priority >= 0 AND priority <= 10),
+  tests_required INTEGER NOT NULL DEFAULT 1 CHECK (tests_required IN (0, 1)),
+
+  -- daily, weekly, monthly or a duration such as 36h. Empty for templates
+  -- that are only used by hand.
+  recurrence TEXT NOT NULL DEFAULT '',
+  next_run_at TIMESTAMP,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,24 @@
+-- Transcript of each agent run on a task: what it was asked, everything it
+-- printed and how it ended, so failures can be analysed without run logs.
+-- Runs are deleted with their task and not archived.
+CREATE TABLE IF NOT EXISTS runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+  model TEXT NOT NULL DEFAULT '',
+  prompt TEXT NOT NULL DEFAULT '',
+  -- stdout and stderr, interleaved as they were written
+  output TEXT NOT NULL DEFAULT '',
+  -- -1 when the agent did not exit by itself, e.g. it was killed or never started
+  exit_code INTEGER NOT NULL DEFAULT 0,
+  -- why the run failed, including a failed verification; empty on success
+  error TEXT NOT NULL DEFAULT '',
+  -- agent_missing, exit, cancelled, timeout, oom, verification or other;
+  -- empty on success
+  failure_class TEXT NOT NULL DEFAULT '',
+  duration_ms INTEGER NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_runs_task ON runs(task_id, started_at);
@@ -0,0 +1,8 @@
+-- Changes staged by MCP sessions but not yet committed, as the JSON of
+-- db.StagedItems, so a restarted MCP server picks up half-built plans.
+-- Sessions untouched for longer than the staging TTL are dropped.
+CREATE TABLE IF NOT EXISTS staged_changes (
+  session_id TEXT PRIMARY KEY,
+  items TEXT NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,10 @@
+-- Free-form labels on tasks, cutting across features, e.g. so that a worker
+-- only claims tasks labelled "frontend"
+CREATE TABLE IF NOT EXISTS task_labels (
+  task_id CHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  label VARCHAR(55) NOT NULL,
+
+  PRIMARY KEY (task_id, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label ON task_labels(label);
@@ -0,0 +1,12 @@
+-- Feature dependencies order whole features: no task of a feature is
+-- available until every task of the features it depends on is completed or
+-- cancelled. Circular feature dependencies are rejected in Go before
+-- inserting.
+CREATE TABLE IF NOT EXISTS feature_dependencies (
+  feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  depends_on_feature_id CHAR(36) NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  PRIMARY KEY (feature_id, depends_on_feature_id),
+  CHECK (feature_id != depends_on_feature_id) -- Prevent self-dependencies
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_dependencies_depends_on ON feature_dependencies(depends_on_feature_id);
@@ -0,0 +1,20 @@
+-- One row per orchestrator invocation, so what a run got done outlives the
+-- TUI. ended_at stays NULL while the session runs, or if it crashed.
+CREATE TABLE IF NOT EXISTS run_sessions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+  -- JSON object of model names to the number of task runs they did
+  models TEXT NOT NULL DEFAULT '{}',
+  tasks_attempted INTEGER NOT NULL DEFAULT 0 CHECK (tasks_attempted >= 0),
+  tasks_completed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_completed >= 0),
+  tasks_failed INTEGER NOT NULL DEFAULT 0 CHECK (tasks_failed >= 0),
+  tokens_in INTEGER NOT NULL DEFAULT 0 CHECK (tokens_in >= 0),
+  tokens_out INTEGER NOT NULL DEFAULT 0 CHECK (tokens_out >= 0),
+  cost_usd REAL NOT NULL DEFAULT 0 CHECK (cost_usd >= 0),
+  duration_ms INTEGER NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+
+  started_at TIMESTAMP NOT NULL,
+  ended_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_sessions_started ON run_sessions(started_at);
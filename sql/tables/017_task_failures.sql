@@ -0,0 +1,14 @@
+-- Failed runs of tasks the orchestrator will retry, so that its retry policy
+-- and backoff outlast a restart. The row goes once the task succeeds or is
+-- blocked, completed, cancelled or deleted.
+CREATE TABLE IF NOT EXISTS task_failures (
+  task_id CHAR(36) PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+
+  fail_count INTEGER NOT NULL CHECK (fail_count > 0),
+  -- failures since the task last moved along the model fallback chain, to
+  -- fallback_model
+  model_failures INTEGER NOT NULL CHECK (model_failures >= 0),
+  fallback_model TEXT,
+
+  failed_at TIMESTAMP NOT NULL
+);
@@ -1,31 +1,41 @@
 -- View that outputs the entire task graph as a JSON structure
--- Format: {"nodes": [...], "edges": [...]}
+-- Format: {"nodes": [...], "edges": [...], "feature_edges": [...]}
+-- feature_edges go from a feature to one it depends on, by name
 -- Each node includes an is_available flag indicating if all dependencies are complete
 DROP VIEW IF EXISTS v_graph_json;
+DROP VIEW IF EXISTS v_graph_nodes;
+
+-- One graph node per task, so changed tasks can be sent on their own
+CREATE VIEW v_graph_nodes AS
+SELECT t.id,
+    json_object(
+        'id', t.id,
+        'name', t.name,
+        'feature_name', f.name,
+        'description', t.description,
+        'status', t.status,
+        'priority', t.priority,
+        'parent_task_id', t.parent_task_id,
+        'subtask_order', t.subtask_order,
+        'completion_summary', t.completion_summary,
+        'blocked_reason', t.blocked_reason,
+        'estimate_minutes', t.estimate_minutes,
+        'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at),
+        'started_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.started_at),
+        'completion_seconds', CASE
+            WHEN t.started_at IS NULL OR t.completed_at IS NULL THEN NULL
+            ELSE CAST(ROUND((julianday(t.completed_at) - julianday(t.started_at)) * 24 * 60 * 60) AS INTEGER)
+        END,
+        'is_available', CASE WHEN t.id IN (SELECT id FROM v_available_tasks) THEN 1 ELSE 0 END
+    ) AS node_json
+FROM tasks t
+JOIN features f ON t.feature_id = f.id;
 
 CREATE VIEW v_graph_json AS
 SELECT json_object(
     'nodes', (
-        SELECT json_group_array(
-            json_object(
-                'id', t.id,
-                'name', t.name,
-                'feature_name', f.name,
-                'description', t.description,
-                'status', t.status,
-                'priority', t.priority,
-                'completion_summary', t.completion_summary,
-                'completed_at', t.completed_at,
-                'started_at', t.started_at,
-                'completion_seconds', CASE
-                    WHEN t.started_at IS NULL OR t.completed_at IS NULL THEN NULL
-                    ELSE CAST(ROUND((julianday(t.completed_at) - julianday(t.started_at)) * 24 * 60 * 60) AS INTEGER)
-                END,
-                'is_available', CASE WHEN t.id IN (SELECT id FROM v_available_tasks) THEN 1 ELSE 0 END
-            )
-        )
-        FROM tasks t
-        JOIN features f ON t.feature_id = f.id
+        SELECT json_group_array(json(n.node_json))
+        FROM v_graph_nodes n
     ),
     'edges', (
         SELECT json_group_array(
@@ -35,5 +45,16 @@ SELECT json_object(
             )
         )
         FROM dependencies d
+    ),
+    'feature_edges', (
+        SELECT json_group_array(
+            json_object(
+                'from', f.name,
+                'to', df.name
+            )
+        )
+        FROM feature_dependencies fd
+        JOIN features f ON fd.feature_id = f.id
+        JOIN features df ON fd.depends_on_feature_id = df.id
     )
 ) as graph_json;
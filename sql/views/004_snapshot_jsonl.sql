@@ -1,6 +1,8 @@
 -- View that emits deterministic JSONL snapshot lines using JSON1
 -- Columns:
---   record_order: ordering bucket (meta=0, feature=1, task=2, dependency=3)
+--   record_order: ordering bucket (meta=0, feature=1, task=2, dependency=3, note=4, link=5,
+--                 environment=11, template=12, feature_dependency=13, after
+--                 the archived buckets of v_snapshot_archived_jsonl_lines)
 --   sort_name: primary sort key within bucket
 --   sort_secondary: secondary sort key within bucket
 --   json_line: JSON text for the snapshot line
@@ -8,7 +10,7 @@ DROP VIEW IF EXISTS v_snapshot_jsonl_lines;
 
 CREATE VIEW v_snapshot_jsonl_lines AS
 WITH meta AS (
-  SELECT CURRENT_TIMESTAMP AS generated_at
+  SELECT strftime('%Y-%m-%dT%H:%M:%SZ', 'now') AS generated_at
 )
 SELECT
   0 AS record_order,
@@ -16,7 +18,7 @@ SELECT
   '' AS sort_secondary,
   json_object(
     'record_type', 'meta',
-    'schema_version', '1',
+    'schema_version', '2',
     'generated_at', meta.generated_at,
     'source', 'sqlite'
   ) AS json_line
@@ -54,15 +56,29 @@ SELECT
     'feature_name', f.name,
     'tests_required', json(CASE WHEN t.tests_required THEN 'true' ELSE 'false' END),
     'priority', t.priority,
+    'position', t.position,
     'status', t.status,
     'completion_summary', t.completion_summary,
+    'blocked_reason', t.blocked_reason,
+    'estimate_minutes', t.estimate_minutes,
+    'labels', CASE WHEN EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = t.id) THEN json((
+      SELECT json_group_array(label) FROM (SELECT label FROM task_labels l WHERE l.task_id = t.id ORDER BY label)
+    )) END,
     'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.created_at),
     'updated_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.updated_at),
     'started_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.started_at),
-    'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at)
+    'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at),
+    'not_before', strftime('%Y-%m-%dT%H:%M:%SZ', t.not_before),
+    'due_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.due_at),
+    'parent_task_id', t.parent_task_id,
+    'parent_task_name', p.name,
+    'parent_task_feature_name', pf.name,
+    'subtask_order', t.subtask_order
   ) AS json_line
 FROM tasks t
 LEFT JOIN features f ON t.feature_id = f.id
+LEFT JOIN tasks p ON t.parent_task_id = p.id
+LEFT JOIN features pf ON p.feature_id = pf.id
 
 UNION ALL
 
@@ -83,4 +99,102 @@ FROM dependencies d
 JOIN tasks t ON d.task_id = t.id
 JOIN features tf ON t.feature_id = tf.id
 JOIN tasks dep ON d.depends_on_task_id = dep.id
-JOIN features df ON dep.feature_id = df.id;
+JOIN features df ON dep.feature_id = df.id
+
+UNION ALL
+
+SELECT
+  4 AS record_order,
+  tf.name || '/' || t.name AS sort_name,
+  printf('%s%012d', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at), n.rowid) AS sort_secondary,
+  json_object(
+    'record_type', 'note',
+    'id', n.id,
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'author', n.author,
+    'body', n.body,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at)
+  ) AS json_line
+FROM task_notes n
+JOIN tasks t ON n.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  5 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_object(
+    'record_type', 'link',
+    'task_id', t.id,
+    'task_name', t.name,
+    'task_feature_name', tf.name,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', l.created_at)
+  ) AS json_line
+FROM task_links l
+JOIN tasks t ON l.task_id = t.id
+JOIN features tf ON t.feature_id = tf.id
+
+UNION ALL
+
+SELECT
+  11 AS record_order,
+  f.name AS sort_name,
+  COALESCE(t.name, '') AS sort_secondary,
+  json_object(
+    'record_type', 'environment',
+    'feature_name', f.name,
+    'task_id', e.task_id,
+    'task_name', t.name,
+    'working_dir', e.working_dir,
+    'env', json(e.env)
+  ) AS json_line
+FROM run_environments e
+LEFT JOIN tasks t ON e.task_id = t.id
+JOIN features f ON f.id = COALESCE(e.feature_id, t.feature_id)
+
+UNION ALL
+
+SELECT
+  12 AS record_order,
+  tt.name AS sort_name,
+  '' AS sort_secondary,
+  json_object(
+    'record_type', 'template',
+    'id', tt.id,
+    'name', tt.name,
+    'feature_name', f.name,
+    'task_name', tt.task_name,
+    'description', tt.description,
+    'specification', tt.specification,
+    'priority', tt.priority,
+    'tests_required', json(CASE WHEN tt.tests_required THEN 'true' ELSE 'false' END),
+    'recurrence', tt.recurrence,
+    'next_run_at', strftime('%Y-%m-%dT%H:%M:%SZ', tt.next_run_at),
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', tt.created_at)
+  ) AS json_line
+FROM task_templates tt
+JOIN features f ON tt.feature_id = f.id
+
+UNION ALL
+
+SELECT
+  13 AS record_order,
+  f.name AS sort_name,
+  df.name AS sort_secondary,
+  json_object(
+    'record_type', 'feature_dependency',
+    'feature_id', f.id,
+    'feature_name', f.name,
+    'depends_on_feature_id', df.id,
+    'depends_on_feature_name', df.name
+  ) AS json_line
+FROM feature_dependencies fd
+JOIN features f ON fd.feature_id = f.id
+JOIN features df ON fd.depends_on_feature_id = df.id;
@@ -0,0 +1,109 @@
+-- View that emits snapshot lines for archived records, in the same shape as
+-- v_snapshot_jsonl_lines. Only included when a snapshot is exported with
+-- archived records. Archived tasks come before archived features so that an
+-- importer sees a feature's tasks before the feature itself.
+-- Columns:
+--   record_order: ordering bucket (archived_task=6, archived_dependency=7,
+--                 archived_note=8, archived_link=9, archived_feature=10)
+--   sort_name: primary sort key within bucket
+--   sort_secondary: secondary sort key within bucket
+--   json_line: JSON text for the snapshot line
+DROP VIEW IF EXISTS v_snapshot_archived_jsonl_lines;
+
+CREATE VIEW v_snapshot_archived_jsonl_lines AS
+SELECT
+  6 AS record_order,
+  t.feature_name || '/' || t.name AS sort_name,
+  t.id AS sort_secondary,
+  json_object(
+    'record_type', 'archived_task',
+    'id', t.id,
+    'name', t.name,
+    'description', t.description,
+    'specification', t.specification,
+    'feature_id', t.feature_id,
+    'feature_name', t.feature_name,
+    'tests_required', json(CASE WHEN t.tests_required THEN 'true' ELSE 'false' END),
+    'priority', t.priority,
+    'status', t.status,
+    'completion_summary', t.completion_summary,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.created_at),
+    'updated_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.updated_at),
+    'started_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.started_at),
+    'completed_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.completed_at),
+    'archived_at', strftime('%Y-%m-%dT%H:%M:%SZ', t.archived_at)
+  ) AS json_line
+FROM archived_tasks t
+
+UNION ALL
+
+SELECT
+  7 AS record_order,
+  d.task_id AS sort_name,
+  d.depends_on_task_id AS sort_secondary,
+  json_object(
+    'record_type', 'archived_dependency',
+    'task_id', d.task_id,
+    'task_name', COALESCE(lt.name, at.name),
+    'task_feature_name', COALESCE(lf.name, at.feature_name),
+    'depends_on_task_id', d.depends_on_task_id,
+    'depends_on_task_name', COALESCE(ldt.name, adt.name),
+    'depends_on_task_feature_name', COALESCE(ldf.name, adt.feature_name)
+  ) AS json_line
+FROM archived_dependencies d
+LEFT JOIN tasks lt ON d.task_id = lt.id
+LEFT JOIN features lf ON lt.feature_id = lf.id
+LEFT JOIN archived_tasks at ON d.task_id = at.id
+LEFT JOIN tasks ldt ON d.depends_on_task_id = ldt.id
+LEFT JOIN features ldf ON ldt.feature_id = ldf.id
+LEFT JOIN archived_tasks adt ON d.depends_on_task_id = adt.id
+
+UNION ALL
+
+SELECT
+  8 AS record_order,
+  n.task_id AS sort_name,
+  printf('%s%s', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at), n.id) AS sort_secondary,
+  json_object(
+    'record_type', 'archived_note',
+    'id', n.id,
+    'task_id', n.task_id,
+    'author', n.author,
+    'body', n.body,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', n.created_at)
+  ) AS json_line
+FROM archived_task_notes n
+
+UNION ALL
+
+SELECT
+  9 AS record_order,
+  l.provider AS sort_name,
+  l.external_ref AS sort_secondary,
+  json_object(
+    'record_type', 'archived_link',
+    'task_id', l.task_id,
+    'provider', l.provider,
+    'external_ref', l.external_ref,
+    'url', l.url,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', l.created_at)
+  ) AS json_line
+FROM archived_task_links l
+
+UNION ALL
+
+SELECT
+  10 AS record_order,
+  f.name AS sort_name,
+  f.id AS sort_secondary,
+  json_object(
+    'record_type', 'archived_feature',
+    'id', f.id,
+    'name', f.name,
+    'description', f.description,
+    'specification', f.specification,
+    'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', f.created_at),
+    'updated_at', strftime('%Y-%m-%dT%H:%M:%SZ', f.updated_at),
+    'archived_at', strftime('%Y-%m-%dT%H:%M:%SZ', f.archived_at)
+  ) AS json_line
+FROM archived_features f;